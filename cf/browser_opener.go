@@ -1,14 +1,68 @@
 package cf
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strings"
 )
 
-// OpenInBrowser opens the given URL in the user's default browser
+// NoBrowserEnv disables OpenInBrowser when set to a true value (1, true, yes), and
+// forces it on when set to a false value (0, false, no) even on a headless host
+const NoBrowserEnv = "KANSHO_NO_BROWSER"
+
+// noBrowserFile is the kill-switch file under ~/.config/kansho, its presence
+// disables OpenInBrowser like KANSHO_NO_BROWSER=1
+const noBrowserFile = "no-browser"
+
+// ErrBrowserDisabled is returned by OpenInBrowser when opening a browser is
+// disabled. Callers still return a CfChallengeError so the URL is reported.
+var ErrBrowserDisabled = errors.New("opening a browser is disabled")
+
+// BrowserOpeningDisabled reports whether OpenInBrowser should stay a no-op: the
+// KANSHO_NO_BROWSER env var decides when set, otherwise the kill-switch file, and
+// headless hosts (no display on Linux/BSD) default to disabled
+func BrowserOpeningDisabled() bool {
+	if value, ok := os.LookupEnv(NoBrowserEnv); ok && strings.TrimSpace(value) != "" {
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "0", "false", "no", "off":
+			return false
+		default:
+			return true
+		}
+	}
+
+	if configDir, err := os.UserConfigDir(); err == nil {
+		if _, err := os.Stat(filepath.Join(configDir, "kansho", noBrowserFile)); err == nil {
+			return true
+		}
+	}
+
+	return isHeadless()
+}
+
+// isHeadless reports whether there is no display to open a browser on. Windows and
+// macOS always have one for a logged in user.
+func isHeadless() bool {
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		return false
+	}
+	return os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == ""
+}
+
+// OpenInBrowser opens the given URL in the user's default browser. Returns
+// ErrBrowserDisabled without launching anything when BrowserOpeningDisabled.
 func OpenInBrowser(url string) error {
+	if BrowserOpeningDisabled() {
+		log.Printf("Not opening browser (disabled via %s, kill-switch file or headless host): %s", NoBrowserEnv, url)
+		return ErrBrowserDisabled
+	}
+
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
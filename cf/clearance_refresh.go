@@ -0,0 +1,110 @@
+package cf
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+type clearanceRefreshKey struct{}
+
+// WithClearanceRefresh returns a context whose challenges are left to the caller to
+// solve in an embedded browser, OpenChallenge then does not open the default
+// browser. The download queue sets it on its tasks unless external_cf_browser is on.
+func WithClearanceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, clearanceRefreshKey{}, true)
+}
+
+// ClearanceRefreshEnabled reports whether challenges met by the download in ctx are
+// solved in an embedded browser, see WithClearanceRefresh
+func ClearanceRefreshEnabled(ctx context.Context) bool {
+	refresh, _ := ctx.Value(clearanceRefreshKey{}).(bool)
+	return refresh
+}
+
+// OpenChallenge opens a detected challenge for the user to solve: in the default
+// browser with OpenInBrowser, or not at all when ctx is WithClearanceRefresh, the
+// download queue then solves it in an embedded browser.
+func OpenChallenge(ctx context.Context, challengeURL string) error {
+	if ClearanceRefreshEnabled(ctx) {
+		log.Printf("Leaving CF challenge to the embedded browser: %s", challengeURL)
+		return nil
+	}
+	return OpenInBrowser(challengeURL)
+}
+
+// ClearanceFromCookies builds the bypass data of a solved challenge at pageURL from
+// the browser's cookies and user agent. Errors when there is no cf_clearance among
+// the cookies, the challenge has not passed yet.
+func ClearanceFromCookies(pageURL, userAgent string, cookies []Cookie) (*BypassData, error) {
+	parsed, err := url.Parse(pageURL)
+	if err != nil || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid page URL %q", pageURL)
+	}
+
+	var clearance *CfClearanceCookie
+	for _, ck := range cookies {
+		if ck.Name != "cf_clearance" || ck.Value == "" {
+			continue
+		}
+		clearance = &CfClearanceCookie{
+			Name:     ck.Name,
+			Value:    ck.Value,
+			Domain:   ck.Domain,
+			Path:     ck.Path,
+			HttpOnly: ck.HTTPOnly,
+			Secure:   ck.Secure,
+			SameSite: ck.SameSite,
+		}
+		if ck.ExpirationDate > 0 {
+			expires := time.Unix(int64(ck.ExpirationDate), 0)
+			clearance.Expires = &expires
+		}
+		break
+	}
+	if clearance == nil {
+		return nil, fmt.Errorf("no cf_clearance cookie for %s", parsed.Hostname())
+	}
+
+	now := time.Now()
+	return &BypassData{
+		Type:                  ProtectionCookie,
+		CapturedAt:            now.Format(time.RFC3339),
+		URL:                   pageURL,
+		Domain:                parsed.Hostname(),
+		Cookies:               cookies,
+		AllCookies:            cookies,
+		Entropy:               Entropy{UserAgent: userAgent},
+		Headers:               map[string]string{},
+		CfClearance:           clearance.Value,
+		CfClearanceUrl:        pageURL,
+		CfClearanceCapturedAt: now,
+		CfClearanceStruct:     clearance,
+	}, nil
+}
+
+// BrowserCookies converts a chromedp browser's cookies into the stored form,
+// session cookies (no expiry) keep an ExpirationDate of 0
+func BrowserCookies(cookies []*network.Cookie) []Cookie {
+	stored := make([]Cookie, 0, len(cookies))
+	for _, ck := range cookies {
+		cookie := Cookie{
+			Name:     ck.Name,
+			Value:    ck.Value,
+			Domain:   ck.Domain,
+			Path:     ck.Path,
+			Secure:   ck.Secure,
+			HTTPOnly: ck.HTTPOnly,
+			SameSite: ck.SameSite.String(),
+		}
+		if !ck.Session && ck.Expires > 0 {
+			cookie.ExpirationDate = ck.Expires
+		}
+		stored = append(stored, cookie)
+	}
+	return stored
+}
@@ -27,6 +27,30 @@ type CfInfo struct {
 	IsBIC        bool // Browser Integrity Check
 }
 
+// challengeTitleRe matches the <title> of Cloudflare interstitial pages. Titles are
+// checked rather than the whole body because phrases like "just a moment" turn up
+// in user comments on normal chapter pages.
+var challengeTitleRe = regexp.MustCompile(`(?i)<title[^>]*>[^<]*(just a moment|attention required|please wait\.*\s*\|\s*cloudflare|one more step|checking your browser)[^<]*</title>`)
+
+// challengeTitle returns the challenge phrase found in the page <title>, or ""
+func challengeTitle(body string) string {
+	if m := challengeTitleRe.FindStringSubmatch(body); len(m) > 1 {
+		return strings.ToLower(m[1])
+	}
+	return ""
+}
+
+// DetectHTML runs Detectcf against HTML that was already fetched, eg: the rendered
+// DOM from a browser session where no HTTP response is available. The page is
+// treated as a 200 response so only the content based indicators can match.
+func DetectHTML(html string) (bool, *CfInfo, error) {
+	return Detectcf(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(html)),
+		Header:     make(http.Header),
+	})
+}
+
 // Detectcf inspects the HTTP response and determines
 // whether CF is blocking or challenging the request.
 func Detectcf(resp *http.Response) (bool, *CfInfo, error) {
@@ -125,6 +149,12 @@ func Detectcf(resp *http.Response) (bool, *CfInfo, error) {
 		"attention required":              "Cloudflare BIC",
 		"checking your browser":           "Cloudflare browser check",
 		"verify you are human":            "Cloudflare human verification",
+		// Challenge bootstrap config and orchestrator scripts, served with a 200 on
+		// managed challenges and never present on a normal page
+		"_cf_chl_opt":          "Cloudflare challenge options script",
+		"orchestrate/chl_page": "Cloudflare challenge orchestrator",
+		"orchestrate/managed":  "Cloudflare managed challenge orchestrator",
+		"orchestrate/jsch":     "Cloudflare JS challenge orchestrator",
 	}
 	weakChecks := map[string]string{
 		// Present on normal Asura pages — only a challenge when combined with something strong
@@ -149,13 +179,13 @@ func Detectcf(resp *http.Response) (bool, *CfInfo, error) {
 	// "just a moment" must ONLY match inside <title> — Asura chapter pages contain
 	// user comments with this phrase (e.g. "i was at 19 just a moment ago") which
 	// caused false positives when matching anywhere in the body.
-	// Real CF challenge pages always have: <title>Just a moment...</title>
-	justAMomentRe := regexp.MustCompile(`(?i)<title[^>]*>[^<]*just a moment[^<]*</title>`)
-	if justAMomentRe.MatchString(body) {
+	// Real CF challenge pages always have: <title>Just a moment...</title>, other
+	// interstitials use "Attention Required! | Cloudflare" or "Please Wait... | Cloudflare"
+	if phrase := challengeTitle(body); phrase != "" {
 		info.Indicators = append(info.Indicators, "Cloudflare challenge page")
 		match = true
 		strongMatch = true
-		logCF("  Indicator (strong): Found 'just a moment' in <title> (Cloudflare challenge page)")
+		logCF("  Indicator (strong): Found '%s' in <title> (Cloudflare challenge page)", phrase)
 	} else if strings.Contains(body, "just a moment") {
 		logCF("  Skipping 'just a moment' — present in body but NOT in <title> (user comment, not a CF challenge)")
 	}
@@ -244,11 +274,16 @@ func DetectFromColly(r *colly.Response) (bool, *CfInfo, error) {
 		return false, nil, nil
 	}
 
-	// Convert *colly.Response → *http.Response
+	// Convert *colly.Response → *http.Response, keeping the headers so the
+	// Server and Set-Cookie checks see them
+	header := make(http.Header)
+	if r.Headers != nil {
+		header = r.Headers.Clone()
+	}
 	httpResp := &http.Response{
 		StatusCode: r.StatusCode,
 		Body:       io.NopCloser(bytes.NewReader(r.Body)),
-		Header:     make(http.Header),
+		Header:     header,
 	}
 
 	return Detectcf(httpResp)
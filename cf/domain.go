@@ -0,0 +1,61 @@
+package cf
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// NormalizeDomain reduces a host or URL to the key bypass data is stored under:
+// lowercased, without scheme, port, path or trailing dot, and without a leading
+// "www." so a site reached on both www and the apex (or redirecting between them,
+// or from http to https) shares one stored cookie.
+func NormalizeDomain(hostOrURL string) string {
+	host := bareHost(hostOrURL)
+	if rest, ok := strings.CutPrefix(host, "www."); ok && strings.Contains(rest, ".") && net.ParseIP(host) == nil {
+		return rest
+	}
+	return host
+}
+
+// bareHost is hostOrURL lowercased and reduced to the host name
+func bareHost(hostOrURL string) string {
+	host := strings.ToLower(strings.TrimSpace(hostOrURL))
+	if strings.Contains(host, "://") {
+		if u, err := url.Parse(host); err == nil {
+			host = u.Hostname()
+		}
+	}
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(host, "."), ".")
+}
+
+// storageKeys lists the file names bypass data for domain may be stored under,
+// the normalized key first. The others are where files saved before keys were
+// normalized ended up: the host as given, and the www variant of the apex.
+func storageKeys(domain string) []string {
+	key := NormalizeDomain(domain)
+	keys := []string{key}
+	for _, legacy := range []string{bareHost(domain), "www." + key} {
+		if key != "" && !slices.Contains(keys, legacy) {
+			keys = append(keys, legacy)
+		}
+	}
+	return keys
+}
+
+// cookieScope widens a www cookie domain to the apex, dot prefixed, so the jar
+// sends cf_clearance to both hosts. Any other domain is returned unchanged.
+func cookieScope(domain string) string {
+	host := strings.TrimPrefix(strings.ToLower(domain), ".")
+	if rest, ok := strings.CutPrefix(host, "www."); ok && strings.Contains(rest, ".") {
+		return "." + rest
+	}
+	return domain
+}
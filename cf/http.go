@@ -21,6 +21,11 @@ func ApplyToCollector(c *colly.Collector, targetURL string) error {
 
 	domain := parsedURL.Hostname()
 
+	// Site login cookies go first, the cf bypass below keeps its own User-Agent
+	if ApplyLoginSession(c, targetURL) {
+		log.Printf("✓ Applied login session for %s", domain)
+	}
+
 	data, err := LoadFromFile(domain)
 	if err != nil {
 		log.Printf("No bypass data found for domain: %s", domain)
@@ -82,7 +87,7 @@ func ApplyCookieBypass(c *colly.Collector, data *BypassData, targetURL string) e
 			Name:     data.CfClearanceStruct.Name,
 			Value:    data.CfClearanceStruct.Value,
 			Path:     data.CfClearanceStruct.Path,
-			Domain:   data.CfClearanceStruct.Domain,
+			Domain:   cookieScope(data.CfClearanceStruct.Domain),
 			Secure:   data.CfClearanceStruct.Secure,
 			HttpOnly: data.CfClearanceStruct.HttpOnly,
 		}
@@ -108,7 +113,7 @@ func ApplyCookieBypass(c *colly.Collector, data *BypassData, targetURL string) e
 			Name:   cookie.Name,
 			Value:  cookie.Value,
 			Path:   cookie.Path,
-			Domain: cookie.Domain,
+			Domain: cookieScope(cookie.Domain),
 			Secure: cookie.Secure,
 		}
 
@@ -0,0 +1,192 @@
+package cf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+// Login sessions are the cookies a site sets once the user has logged in, captured
+// from a browser like the cf bypass data. They are stored apart from it, in
+// kansho/cf/login/<domain>.json, so deleting a stale cf_clearance does not also log
+// the user out.
+
+// loginSessionFile returns the file holding the login session for domain
+func loginSessionFile(domain string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "kansho", "cf", "login", fmt.Sprintf("%s.json", domain)), nil
+}
+
+// SaveLoginSession stores the session cookies captured for domain, replacing any
+// earlier session. Type is set to ProtectionLogin and CapturedAt to now when empty.
+func SaveLoginSession(data *BypassData, domain string) error {
+	logCF("SaveLoginSession: Saving login session for domain=%s (%d cookies)", domain, len(data.AllCookies))
+
+	data.Type = ProtectionLogin
+	if data.CapturedAt == "" {
+		data.CapturedAt = time.Now().Format(time.RFC3339)
+	}
+
+	filename, err := loginSessionFile(domain)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+	// Session cookies are as good as the password, keep them private
+	if err := os.WriteFile(filename, jsonData, 0600); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// LoadLoginSession loads the login session for domain. A session saved for a parent
+// domain also covers its subdomains, the one for example.com is used for
+// www.example.com when that has none of its own.
+func LoadLoginSession(domain string) (*BypassData, error) {
+	for _, candidate := range loginSessionDomains(domain) {
+		filename, err := loginSessionFile(candidate)
+		if err != nil {
+			return nil, err
+		}
+		jsonData, err := os.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		var data BypassData
+		if err := json.Unmarshal(jsonData, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		logCF("LoadLoginSession: Loaded login session for domain=%s from %s", domain, candidate)
+		return &data, nil
+	}
+	return nil, fmt.Errorf("no login session found for domain: %s", domain)
+}
+
+// DeleteLoginSession removes the stored login session for domain
+func DeleteLoginSession(domain string) error {
+	filename, err := loginSessionFile(domain)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filename); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no login session found for domain: %s", domain)
+		}
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	logCF("DeleteLoginSession: Deleted login session for domain=%s", domain)
+	return nil
+}
+
+// loginSessionDomains is domain followed by its parent domains, down to the
+// registrable two labels. IP addresses have no parents.
+func loginSessionDomains(domain string) []string {
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+	if net.ParseIP(domain) != nil {
+		return []string{domain}
+	}
+	domains := []string{domain}
+	for {
+		dot := strings.Index(domain, ".")
+		if dot < 0 || strings.Count(domain, ".") < 2 {
+			return domains
+		}
+		domain = domain[dot+1:]
+		domains = append(domains, domain)
+	}
+}
+
+// LoginCookies returns the stored login session cookies for targetURL's host, nil
+// when there is no session. Cookies past their expiry are left out.
+func LoginCookies(targetURL string) []*http.Cookie {
+	data := loginSessionFor(targetURL)
+	if data == nil {
+		return nil
+	}
+	return data.SessionCookies()
+}
+
+// loginSessionFor loads the login session for targetURL's host, nil when it has none
+func loginSessionFor(targetURL string) *BypassData {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return nil
+	}
+	data, err := LoadLoginSession(parsedURL.Hostname())
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// SessionCookies returns the unexpired cookies of a login session as net/http
+// cookies
+func (b *BypassData) SessionCookies() []*http.Cookie {
+	var cookies []*http.Cookie
+	for _, ck := range b.AllCookies {
+		if ck.Name == "" {
+			continue
+		}
+		cookie := &http.Cookie{
+			Name:     ck.Name,
+			Value:    ck.Value,
+			Path:     ck.Path,
+			Domain:   ck.Domain,
+			Secure:   ck.Secure,
+			HttpOnly: ck.HTTPOnly,
+		}
+		if ck.ExpirationDate > 0 {
+			cookie.Expires = time.Unix(int64(ck.ExpirationDate), 0)
+			if cookie.Expires.Before(time.Now()) {
+				continue
+			}
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
+
+// ApplyLoginSession adds the stored login session cookies for targetURL to the
+// collector's cookie jar and reports whether there were any. The User-Agent of the
+// login browser is used unless cf bypass data applied later sets its own.
+func ApplyLoginSession(c *colly.Collector, targetURL string) bool {
+	data := loginSessionFor(targetURL)
+	if data == nil {
+		return false
+	}
+	cookies := data.SessionCookies()
+	if len(cookies) == 0 {
+		return false
+	}
+	if err := c.SetCookies(targetURL, cookies); err != nil {
+		logCF("ApplyLoginSession: Failed to set cookies for %s: %v", targetURL, err)
+		return false
+	}
+	if data.Entropy.UserAgent != "" {
+		c.UserAgent = data.Entropy.UserAgent
+	}
+	logCF("ApplyLoginSession: Applied %d login cookies for %s", len(cookies), data.Domain)
+	return true
+}
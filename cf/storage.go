@@ -180,8 +180,11 @@ func ParseCapturedData(jsonData string) (*BypassData, error) {
 	return &data, nil
 }
 
-// SaveToFile saves the captured data to a JSON file
+// SaveToFile saves the captured data to a JSON file, named by the normalized
+// domain (see NormalizeDomain). A file left under an older www key is removed.
 func SaveToFile(data *BypassData, domain string) error {
+	keys := storageKeys(domain)
+	domain = keys[0]
 	logCF("SaveToFile: Saving bypass data for domain=%s", domain)
 
 	configDir, err := os.UserConfigDir()
@@ -210,12 +213,20 @@ func SaveToFile(data *BypassData, domain string) error {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
+	for _, legacy := range keys[1:] {
+		if err := os.Remove(filepath.Join(cfDir, legacy+".json")); err == nil {
+			logCF("SaveToFile: Removed legacy file for %s", legacy)
+		}
+	}
+
 	logCF("SaveToFile: Successfully saved (%d bytes)", len(jsonData))
 	LogCFCookieData(domain, data)
 	return nil
 }
 
-// LoadFromFile loads captured data for a specific domain
+// LoadFromFile loads captured data for a specific domain. The www and apex
+// hosts of a site share their data, files saved before keys were normalized are
+// still found under the host they were saved for.
 func LoadFromFile(domain string) (*BypassData, error) {
 	logCF("LoadFromFile: Loading bypass data for domain=%s", domain)
 
@@ -225,9 +236,15 @@ func LoadFromFile(domain string) (*BypassData, error) {
 		return nil, fmt.Errorf("failed to get config directory: %w", err)
 	}
 
-	filename := filepath.Join(configDir, "kansho", "cf", fmt.Sprintf("%s.json", domain))
-
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
+	filename := ""
+	for _, key := range storageKeys(domain) {
+		candidate := filepath.Join(configDir, "kansho", "cf", fmt.Sprintf("%s.json", key))
+		if _, err := os.Stat(candidate); err == nil {
+			filename = candidate
+			break
+		}
+	}
+	if filename == "" {
 		logCF("LoadFromFile: No data file found for domain=%s", domain)
 		return nil, fmt.Errorf("no cf data found for domain: %s", domain)
 	}
@@ -319,14 +336,18 @@ func ValidateCookieData(data *BypassData, targetDomain ...string) error {
 		//   2. Parent domain: cookie domain "example.com"  for target "www.example.com"
 		//      (standard browser cookie scoping — a cookie issued for the apex domain
 		//       is sent by the browser for all subdomains including www)
+		//   3. www and apex:  cookie domain "www.example.com" for target "example.com"
+		//      (both compare by their NormalizeDomain key)
 		//
 		// We reject:
 		//   - cookie domain "other.com" for target "example.com"  (completely different site)
 		//   - cookie domain "sub.example.com" for target "example.com" (subdomain can't cover apex)
 		if len(targetDomain) > 0 && targetDomain[0] != "" {
 			target := targetDomain[0]
-			cookieDomain := strings.TrimPrefix(data.CfClearanceStruct.Domain, ".")
-			targetClean := strings.TrimPrefix(target, ".")
+			// www and the apex are one site, a redirect between them must not
+			// turn a valid token into a mismatch
+			cookieDomain := NormalizeDomain(data.CfClearanceStruct.Domain)
+			targetClean := NormalizeDomain(target)
 
 			exactMatch := cookieDomain == targetClean
 			// Parent domain match: cookie is for "example.com", target is "sub.example.com"
@@ -373,6 +394,24 @@ func ValidateCookieData(data *BypassData, targetDomain ...string) error {
 	return nil
 }
 
+// CheckClearance reports why the stored bypass data for domain cannot be used as it
+// is, nil when it can: there is none, it fails ValidateCookieData, or its
+// cf_clearance is past its expiry. Unlike ValidateCookieData the expiry counts,
+// this is for callers that must not find out by triggering a challenge.
+func CheckClearance(domain string) error {
+	data, err := LoadFromFile(domain)
+	if err != nil {
+		return fmt.Errorf("no bypass data for %s: %w", domain, err)
+	}
+	if err := ValidateCookieData(data, domain); err != nil {
+		return err
+	}
+	if expires := data.CfClearanceStruct.Expires; expires != nil && time.Now().After(*expires) {
+		return fmt.Errorf("cf_clearance for %s expired %s ago", domain, time.Since(*expires).Round(time.Minute))
+	}
+	return nil
+}
+
 // MarkCookieAsFailed marks a cookie as having failed
 func MarkCookieAsFailed(domain string) error {
 	logCF("MarkCookieAsFailed: Marking cookie as failed for domain=%s", domain)
@@ -422,7 +461,8 @@ func ListStoredDomains() ([]string, error) {
 	return domains, nil
 }
 
-// DeleteDomain removes stored CF data for a specific domain
+// DeleteDomain removes stored CF data for a specific domain, under its
+// normalized key and any legacy www key
 func DeleteDomain(domain string) error {
 	logCF("DeleteDomain: Deleting data for domain=%s", domain)
 
@@ -432,15 +472,21 @@ func DeleteDomain(domain string) error {
 		return fmt.Errorf("failed to get config directory: %w", err)
 	}
 
-	filename := filepath.Join(configDir, "kansho", "cf", fmt.Sprintf("%s.json", domain))
-
-	if err := os.Remove(filename); err != nil {
-		if os.IsNotExist(err) {
-			logCF("DeleteDomain: No data found for domain=%s", domain)
-			return fmt.Errorf("no data found for domain: %s", domain)
+	deleted := false
+	for _, key := range storageKeys(domain) {
+		filename := filepath.Join(configDir, "kansho", "cf", fmt.Sprintf("%s.json", key))
+		if err := os.Remove(filename); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			logCF("DeleteDomain: Failed to delete file: %v", err)
+			return fmt.Errorf("failed to delete file: %w", err)
 		}
-		logCF("DeleteDomain: Failed to delete file: %v", err)
-		return fmt.Errorf("failed to delete file: %w", err)
+		deleted = true
+	}
+	if !deleted {
+		logCF("DeleteDomain: No data found for domain=%s", domain)
+		return fmt.Errorf("no data found for domain: %s", domain)
 	}
 
 	logCF("DeleteDomain: Successfully deleted data for domain=%s", domain)
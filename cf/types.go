@@ -9,6 +9,7 @@ const (
 	ProtectionNone      ProtectionType = "none"
 	ProtectionCookie    ProtectionType = "cookie"    // cf_clearance based
 	ProtectionTurnstile ProtectionType = "turnstile" // Turnstile token based
+	ProtectionLogin     ProtectionType = "login"     // site session cookies, see SaveLoginSession
 	ProtectionUnknown   ProtectionType = "unknown"
 )
 
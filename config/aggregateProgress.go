@@ -0,0 +1,115 @@
+package config
+
+import (
+	"context"
+	"fmt"
+)
+
+// chapterReporter receives chapter counts from a running download, see
+// ReportChaptersPlanned and ReportChapterDone
+type chapterReporter struct {
+	planned func(int)
+	done    func()
+}
+
+type chapterReporterKey struct{}
+
+// withChapterReporter returns a context the download reports its chapter counts through
+func withChapterReporter(ctx context.Context, planned func(int), done func()) context.Context {
+	return context.WithValue(ctx, chapterReporterKey{}, &chapterReporter{planned: planned, done: done})
+}
+
+// ReportChaptersPlanned tells the queue how many chapters the download in ctx is
+// about to fetch, once the chapter list has been compared with the library.
+// Does nothing for downloads that are not run by the queue.
+func ReportChaptersPlanned(ctx context.Context, chapters int) {
+	if r, ok := ctx.Value(chapterReporterKey{}).(*chapterReporter); ok {
+		r.planned(chapters)
+	}
+}
+
+// ReportChapterDone tells the queue one planned chapter has finished, whether it was
+// downloaded, failed or skipped
+func ReportChapterDone(ctx context.Context) {
+	if r, ok := ctx.Value(chapterReporterKey{}).(*chapterReporter); ok {
+		r.done()
+	}
+}
+
+// AggregateProgress is the combined progress of every series in a queue batch, eg:
+// all the series queued by "Recheck All"
+type AggregateProgress struct {
+	Series     int // series in the batch
+	SeriesDone int // series that finished, whatever the outcome
+	Listing    int // running or queued series whose chapter count is not known yet
+
+	ChaptersPlanned int
+	ChaptersDone    int
+}
+
+// AggregateTasks sums the chapter counts of tasks. Series that finished count as
+// fully done even if they failed or were cancelled part way, so the total reaches
+// 100% once every series is done. Series that have not reported how many chapters
+// they will fetch only count towards Series and Listing until they do.
+func AggregateTasks(tasks []DownloadTask) AggregateProgress {
+	var agg AggregateProgress
+	for _, task := range tasks {
+		agg.Series++
+
+		finished := task.Status != "queued" && task.Status != "downloading"
+		switch {
+		case finished:
+			agg.SeriesDone++
+			if task.PlanKnown {
+				done := min(task.ChaptersDone, task.ChaptersPlanned)
+				agg.ChaptersPlanned += done
+				agg.ChaptersDone += done
+			}
+		case task.PlanKnown:
+			agg.ChaptersPlanned += task.ChaptersPlanned
+			agg.ChaptersDone += min(task.ChaptersDone, task.ChaptersPlanned)
+		default:
+			agg.Listing++
+		}
+	}
+	return agg
+}
+
+// Fraction returns the batch progress from 0 to 1. Chapters drive it once any are
+// planned, before that the share of finished series is used.
+func (a AggregateProgress) Fraction() float64 {
+	if a.Series == 0 {
+		return 0
+	}
+	if a.SeriesDone == a.Series {
+		return 1
+	}
+	if a.ChaptersPlanned == 0 {
+		return float64(a.SeriesDone) / float64(a.Series)
+	}
+	return float64(a.ChaptersDone) / float64(a.ChaptersPlanned)
+}
+
+// String returns the summary shown above the queue, eg: "37/200 chapters across 12 series"
+func (a AggregateProgress) String() string {
+	msg := fmt.Sprintf("%d/%d chapters across %d series", a.ChaptersDone, a.ChaptersPlanned, a.Series)
+	if a.Listing > 0 {
+		msg += fmt.Sprintf(" (%d still checking for new chapters)", a.Listing)
+	}
+	return msg
+}
+
+// AggregateProgress returns the combined progress of the current batch: the tasks
+// queued since the queue was last idle
+func (q *DownloadQueue) AggregateProgress() AggregateProgress {
+	q.mu.RLock()
+	var batch []DownloadTask
+	for _, task := range q.tasks {
+		if task.batch == q.batch {
+			batch = append(batch, *task)
+		}
+	}
+	q.mu.RUnlock()
+
+	return AggregateTasks(batch)
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"sort"
+	"sync"
+)
+
+// bookmarksFileMu serializes reads and writes of bookmarks.json, so a save from the
+// UI never interleaves with another save or a load for a bulk update
+var bookmarksFileMu sync.Mutex
+
+// BookmarkStore guards the in-memory bookmarks shared by the UI and downloads. UI
+// callbacks edit bookmarks while queued downloads and bulk updates read them from
+// other goroutines, so every access goes through the store's lock.
+//
+// Get and All return copies; a caller holding one never sees, or races with, later
+// edits. Changes are made with Update, Add and Delete and written with Save.
+type BookmarkStore struct {
+	mu   sync.RWMutex
+	data Manga
+}
+
+// NewBookmarkStore returns a store holding data
+func NewBookmarkStore(data Manga) *BookmarkStore {
+	return &BookmarkStore{data: data}
+}
+
+// Len returns the number of bookmarks
+func (s *BookmarkStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.data.Manga)
+}
+
+// Get returns a copy of the bookmark at index i, false when i is out of range
+func (s *BookmarkStore) Get(i int) (Bookmarks, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i < 0 || i >= len(s.data.Manga) {
+		return Bookmarks{}, false
+	}
+	return s.data.Manga[i], true
+}
+
+// All returns a copy of every bookmark
+func (s *BookmarkStore) All() []Bookmarks {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Bookmarks(nil), s.data.Manga...)
+}
+
+// Snapshot returns a copy of the bookmarks in the form SaveBookmarks takes
+func (s *BookmarkStore) Snapshot() Manga {
+	return Manga{Manga: s.All()}
+}
+
+// Replace swaps every bookmark for data, eg: after an import rewrote the file
+func (s *BookmarkStore) Replace(data Manga) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = Manga{Manga: append([]Bookmarks(nil), data.Manga...)}
+}
+
+// Add appends a bookmark
+func (s *BookmarkStore) Add(manga Bookmarks) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Manga = append(s.data.Manga, manga)
+}
+
+// Delete removes the bookmark at index i, false when i is out of range
+func (s *BookmarkStore) Delete(i int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i < 0 || i >= len(s.data.Manga) {
+		return false
+	}
+	s.data.Manga = append(s.data.Manga[:i], s.data.Manga[i+1:]...)
+	return true
+}
+
+// Update calls fn with the bookmark at index i while holding the lock, false when i
+// is out of range. fn must not call back into the store.
+func (s *BookmarkStore) Update(i int, fn func(*Bookmarks)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i < 0 || i >= len(s.data.Manga) {
+		return false
+	}
+	fn(&s.data.Manga[i])
+	return true
+}
+
+// SortByTitle orders the bookmarks by title, as the manga list shows them
+func (s *BookmarkStore) SortByTitle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sort.SliceStable(s.data.Manga, func(i, j int) bool {
+		return s.data.Manga[i].Title < s.data.Manga[j].Title
+	})
+}
+
+// Save writes a snapshot of the bookmarks to disk with SaveBookmarks
+func (s *BookmarkStore) Save() error {
+	return SaveBookmarks(s.Snapshot())
+}
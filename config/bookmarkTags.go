@@ -0,0 +1,86 @@
+package config
+
+import (
+	"log"
+	"slices"
+	"strings"
+)
+
+// NormalizeTags trims and lowercases tags, dropping empty and repeated ones, so
+// "Isekai" and " isekai" are the same tag. The order of first use is kept.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// ParseTags reads the comma separated tags of the edit form ("ongoing, isekai")
+func ParseTags(text string) []string {
+	return NormalizeTags(strings.Split(text, ","))
+}
+
+// HasTag reports whether the series carries tag, compared as NormalizeTags does
+func (b *Bookmarks) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range b.Tags {
+		if strings.ToLower(strings.TrimSpace(t)) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AllTags returns every tag used by mangas, sorted
+func AllTags(mangas []Bookmarks) []string {
+	var tags []string
+	for _, manga := range mangas {
+		for _, tag := range NormalizeTags(manga.Tags) {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+// TaggedCandidates returns the bookmarks tagged tag that a bulk download of the tag
+// queues. Completed series are left out, as they are from Recheck All.
+func TaggedCandidates(mangas []Bookmarks, tag string) []*Bookmarks {
+	var candidates []*Bookmarks
+	for i := range mangas {
+		manga := &mangas[i]
+		if !manga.HasTag(tag) {
+			continue
+		}
+		if manga.Completed {
+			log.Printf("[Tags] %s: completed, not downloading", manga.Title)
+			continue
+		}
+		candidates = append(candidates, manga)
+	}
+	return candidates
+}
+
+// QueueTagged adds every bookmark TaggedCandidates returns to the download queue
+// the way QueueLikelyUpdates does. Returns the number of tagged series queued and
+// the number skipped (completed or already queued).
+func (q *DownloadQueue) QueueTagged(mangas []Bookmarks, tag string) (queued, skipped int) {
+	tagged := 0
+	for i := range mangas {
+		if mangas[i].HasTag(tag) {
+			tagged++
+		}
+	}
+	candidates := TaggedCandidates(mangas, tag)
+	queued, failed := q.queueBulk(candidates, "[Tags]")
+	skipped = tagged - len(candidates) + failed
+
+	log.Printf("[Tags] Queued %d series tagged %q, skipped %d", queued, tag, skipped)
+	return queued, skipped
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+
+	"kansho/cf"
+)
+
+// cfProtectedSites are the registered sites whose plugin NeedsCFBypass
+var cfProtectedSites = make(map[string]bool)
+
+// RegisterCFProtected marks siteName as needing CF bypass data, alongside its
+// RegisterSite call. Unattended downloads of its series are checked with CFPreflight.
+func RegisterCFProtected(siteName string) {
+	cfProtectedSites[siteName] = true
+}
+
+// CFSkippedError holds back an unattended download of a CF protected series that
+// has no usable cf_clearance, see CFPreflight. The queue leaves these tasks as
+// "waiting_cf" for the user to retry once the cookie is imported.
+type CFSkippedError struct {
+	Domain string
+	Err    error
+}
+
+func (e *CFSkippedError) Error() string {
+	return fmt.Sprintf("skipped, no usable Cloudflare cookie for %s: %v", e.Domain, e.Err)
+}
+
+func (e *CFSkippedError) Unwrap() error { return e.Err }
+
+// CFPreflight returns a *CFSkippedError when manga is on a CF protected site and
+// cf.CheckClearance finds no usable bypass data for its host, so a download would
+// stop at a challenge and open a browser. nil for every other series.
+func CFPreflight(manga *Bookmarks) error {
+	if !cfProtectedSites[manga.Site] {
+		return nil
+	}
+	parsed, err := url.Parse(manga.Url)
+	if err != nil || parsed.Hostname() == "" {
+		// The download reports the bad URL itself
+		return nil
+	}
+	domain := parsed.Hostname()
+	if err := cf.CheckClearance(domain); err != nil {
+		log.Printf("[Queue] %s: %v", manga.Title, err)
+		return &CFSkippedError{Domain: domain, Err: err}
+	}
+	return nil
+}
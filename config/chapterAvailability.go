@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"kansho/parser"
+)
+
+// Chapter availability states, see ClassifyAvailability
+const (
+	AvailabilityUnknown  = "unknown"
+	AvailabilityBehind   = "behind"
+	AvailabilityUpToDate = "up to date"
+)
+
+// ChapterAvailability is how a series' local chapters compare with the site's
+type ChapterAvailability struct {
+	State string
+
+	// Behind is how many more chapters the site has, set for AvailabilityBehind
+	Behind int
+}
+
+// Label is the availability as shown in the bookmarks window ("behind 3")
+func (a ChapterAvailability) Label() string {
+	if a.State == AvailabilityBehind {
+		return fmt.Sprintf("behind %d", a.Behind)
+	}
+	return a.State
+}
+
+// ClassifyAvailability compares the local chapter count with the site's. A remote
+// count of 0 or less means the site has not been checked.
+func ClassifyAvailability(local, remote int) ChapterAvailability {
+	switch {
+	case remote <= 0:
+		return ChapterAvailability{State: AvailabilityUnknown}
+	case remote > local:
+		return ChapterAvailability{State: AvailabilityBehind, Behind: remote - local}
+	default:
+		return ChapterAvailability{State: AvailabilityUpToDate}
+	}
+}
+
+// remoteChapterCounts keeps the chapter count each series' site listed the last time
+// it was downloaded or checked, by series URL. It only lives as long as the app.
+var remoteChapterCounts = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: make(map[string]int)}
+
+// RecordRemoteChapters remembers how many chapters the series at url lists
+func RecordRemoteChapters(url string, count int) {
+	if count <= 0 {
+		return
+	}
+	remoteChapterCounts.Lock()
+	remoteChapterCounts.counts[url] = count
+	remoteChapterCounts.Unlock()
+}
+
+// RemoteChapters returns the last recorded chapter count of the series at url
+func RemoteChapters(url string) (int, bool) {
+	remoteChapterCounts.Lock()
+	defer remoteChapterCounts.Unlock()
+	count, ok := remoteChapterCounts.counts[url]
+	return count, ok
+}
+
+// SeriesAvailability classifies manga from its local chapters and the last recorded
+// remote count, without going to the site. A series keeping only its latest N
+// chapters is up to date with N of them.
+func SeriesAvailability(manga Bookmarks) ChapterAvailability {
+	remote, ok := RemoteChapters(manga.Url)
+	if !ok {
+		return ChapterAvailability{State: AvailabilityUnknown}
+	}
+	local, err := parser.LocalChapterList(manga.Location)
+	if err != nil {
+		return ChapterAvailability{State: AvailabilityUnknown}
+	}
+	if manga.KeepLatest > 0 && remote > manga.KeepLatest {
+		remote = manga.KeepLatest
+	}
+	return ClassifyAvailability(len(local), remote)
+}
+
+type checkOnlyKey struct{}
+
+// WithCheckOnly returns a context whose site download stops after comparing the
+// site's chapter list with the library, nothing is downloaded
+func WithCheckOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, checkOnlyKey{}, true)
+}
+
+// CheckOnly reports whether the download in ctx only checks for new chapters
+func CheckOnly(ctx context.Context) bool {
+	checkOnly, _ := ctx.Value(checkOnlyKey{}).(bool)
+	return checkOnly
+}
+
+// CheckRemoteChapters fetches the series' chapter list (a recently cached one will
+// do) to record its remote count, and returns the series' availability
+func CheckRemoteChapters(ctx context.Context, manga Bookmarks) (ChapterAvailability, error) {
+	result, err := RunSiteDownload(WithCheckOnly(ctx), &manga, nil)
+	if err != nil {
+		return ChapterAvailability{State: AvailabilityUnknown}, err
+	}
+	RecordRemoteChapters(manga.Url, result.RemoteChapters)
+	return SeriesAvailability(manga), nil
+}
@@ -0,0 +1,18 @@
+package config
+
+import "context"
+
+type chapterListRefreshKey struct{}
+
+// WithChapterListRefresh returns a context whose download scrapes the site's chapter
+// list again even when a recently cached one could be reused
+func WithChapterListRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, chapterListRefreshKey{}, true)
+}
+
+// ChapterListRefresh reports whether the download in ctx bypasses the chapter list
+// cache
+func ChapterListRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(chapterListRefreshKey{}).(bool)
+	return refresh
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"context"
+	"sync"
+)
+
+// ChapterSkipper lets the user abandon the chapters currently being downloaded while
+// the rest of the series carries on. The downloader runs each chapter in a context
+// from ChapterContext, Skip cancels only those contexts.
+type ChapterSkipper struct {
+	mu      sync.Mutex
+	running map[*skippableChapter]struct{} // chapters in progress, several when chapters download in parallel
+}
+
+// skippableChapter is one chapter running under a ChapterSkipper
+type skippableChapter struct {
+	cancel  context.CancelFunc
+	skipped bool
+}
+
+type chapterSkipperKey struct{}
+
+// WithChapterSkipper returns a context carrying skipper, downloads run with it pick
+// it up through ChapterContext
+func WithChapterSkipper(ctx context.Context, skipper *ChapterSkipper) context.Context {
+	return context.WithValue(ctx, chapterSkipperKey{}, skipper)
+}
+
+// ChapterContext derives the context one chapter is downloaded with. The returned
+// end func must be called once the chapter is done, it reports whether the chapter
+// was skipped. Without a skipper in ctx the chapter simply uses ctx.
+func ChapterContext(ctx context.Context) (context.Context, func() (skipped bool)) {
+	skipper, _ := ctx.Value(chapterSkipperKey{}).(*ChapterSkipper)
+	if skipper == nil {
+		return ctx, func() bool { return false }
+	}
+
+	chapterCtx, cancel := context.WithCancel(ctx)
+	chapter := &skippableChapter{cancel: cancel}
+	skipper.mu.Lock()
+	if skipper.running == nil {
+		skipper.running = make(map[*skippableChapter]struct{})
+	}
+	skipper.running[chapter] = struct{}{}
+	skipper.mu.Unlock()
+
+	return chapterCtx, func() bool {
+		skipper.mu.Lock()
+		defer skipper.mu.Unlock()
+		delete(skipper.running, chapter)
+		cancel()
+		// A series cancel also ends the chapter, that is not a skip
+		return chapter.skipped && ctx.Err() == nil
+	}
+}
+
+// Skip abandons the chapters in progress. Returns false when no chapter is running.
+func (s *ChapterSkipper) Skip() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for chapter := range s.running {
+		chapter.skipped = true
+		chapter.cancel()
+	}
+	return len(s.running) > 0
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"context"
+
+	"kansho/cf"
+)
+
+// ClearanceRefreshFunc solves the Cloudflare challenge at target, a challenge URL or
+// a bare domain, in a browser window and returns the bypass data it saved
+type ClearanceRefreshFunc func(ctx context.Context, target string) (*cf.BypassData, error)
+
+// clearanceRefresher is the registered ClearanceRefreshFunc, nil leaves challenges
+// to the default browser
+var clearanceRefresher ClearanceRefreshFunc
+
+// RegisterClearanceRefresher sets how the queue solves the challenges its downloads
+// meet (downloader.RefreshClearance), alongside the RegisterSite calls. Until one is
+// registered, or with the external_cf_browser setting on, challenges are opened in
+// the default browser for the cookie to be imported by hand.
+func RegisterClearanceRefresher(refresh ClearanceRefreshFunc) {
+	clearanceRefresher = refresh
+}
+
+// withClearanceRefresh marks the queue download in ctx as solving its challenges
+// with the clearance refresher, see cf.WithClearanceRefresh
+func withClearanceRefresh(ctx context.Context, settings Settings) context.Context {
+	if clearanceRefresher == nil || settings.ExternalCFBrowser {
+		return ctx
+	}
+	return cf.WithClearanceRefresh(ctx)
+}
@@ -1,12 +1,15 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"gopkg.in/lumberjack.v3"
 
@@ -30,54 +33,261 @@ type Bookmarks struct {
 	Location  string `json:"location"`
 	Site      string `json:"site"`
 	Shortname string `json:"shortname"`
+
+	// KeepLatest limits the series to the latest N chapters on disk, older
+	// chapters are pruned after each download run. 0 keeps everything.
+	KeepLatest int `json:"keep_latest,omitempty"`
+
+	// SyncMode is SyncModeAppend (default, only missing chapters are fetched) or
+	// SyncModeFull, which also re-downloads local chapters whose page count no
+	// longer matches the source
+	SyncMode string `json:"sync_mode,omitempty"`
+
+	// Completed marks a finished series, bulk updates ("Recheck All") leave it out
+	// so it is not scraped for chapters that will never come. Downloading it on its
+	// own still works.
+	Completed bool `json:"completed,omitempty"`
+
+	// MirrorLocations are extra folders every new chapter is copied to after it is
+	// written to Location, eg: a NAS share. Only Location decides which chapters
+	// are already downloaded.
+	MirrorLocations []string `json:"mirror_locations,omitempty"`
+
+	// DelayNewChapterHours holds back chapters published less than this many hours
+	// ago until a later run, for sites that expose a publish time. 0 downloads new
+	// chapters straight away.
+	DelayNewChapterHours int `json:"delay_new_chapter_hours,omitempty"`
+
+	// SessionCookies and SessionHeaders are sent with every chapter list and image
+	// request of this series only, eg: the login session of an age gated series.
+	// SessionCookies is a Cookie header value ("name=value; other=value").
+	// SENSITIVE: these are credentials, stored in plain text in the bookmarks file
+	// and never logged.
+	SessionCookies string            `json:"session_cookies,omitempty"`
+	SessionHeaders map[string]string `json:"session_headers,omitempty"`
+
+	// Notes is free text the user keeps about the series (eg: "moved to mangadex
+	// after asura dropped it", "rtl"). Kansho only stores and shows it.
+	Notes string `json:"notes,omitempty"`
+
+	// Tags group series for filtering and bulk downloads (eg: "ongoing",
+	// "favorites"), lowercase, see NormalizeTags
+	Tags []string `json:"tags,omitempty"`
+
+	// CheckIntervalHours is how often the update scheduler checks the series for new
+	// chapters. 0 uses the auto_check_interval_hours setting, below 0 never checks
+	// it automatically.
+	CheckIntervalHours int `json:"check_interval_hours,omitempty"`
+
+	// ImageQuality is ImageQualityOriginal or ImageQualityDataSaver, the quality of
+	// the pages on sites that serve more than one (MangaDex). Empty uses the site's
+	// setting, eg: mangadex_image_quality.
+	ImageQuality string `json:"image_quality,omitempty"`
+
+	// Languages are the translations downloaded on sites that offer several
+	// (MangaDex language codes such as "en" or "es-la"), most preferred first.
+	// Empty downloads English.
+	Languages []string `json:"languages,omitempty"`
+
+	// ScanlationGroups are the groups whose release of a chapter is downloaded when
+	// several groups released it (MangaDex), most preferred first, by name or ID.
+	// Releases by other groups are picked in the order the site lists them.
+	ScanlationGroups []string `json:"scanlation_groups,omitempty"`
+}
+
+// RequestExtras returns the session cookies and headers to add to the requests of
+// the series to its site
+func (b *Bookmarks) RequestExtras() parser.RequestExtras {
+	return parser.ParseRequestExtras(b.Url, b.SessionCookies, b.SessionHeaders)
+}
+
+// Bookmark sync modes
+const (
+	SyncModeAppend = "append"
+	SyncModeFull   = "full"
+)
+
+// Image qualities, ImageQualityDataSaver pages are recompressed by the site to use
+// a fraction of the bandwidth
+const (
+	ImageQualityOriginal  = "original"
+	ImageQualityDataSaver = "data-saver"
+)
+
+// UsesDataSaver reports whether the pages of the series are downloaded in data saver
+// quality: its own image_quality, or siteDefault when it has none
+func (b *Bookmarks) UsesDataSaver(siteDefault string) bool {
+	quality := b.ImageQuality
+	if quality == "" {
+		quality = siteDefault
+	}
+	return quality == ImageQualityDataSaver
 }
 
 // load bookmarks return custom struct
 func LoadBookmarks() Manga {
+	mangaStruct, _ := LoadBookmarksWithSkipped()
+	return mangaStruct
+}
+
+// LoadBookmarksWithSkipped loads bookmarks tolerating malformed entries, the good
+// entries are returned along with the number of entries that could not be loaded.
+// When entries are skipped the original file is backed up first so a later save
+// does not silently lose them, once per distinct content of the file.
+func LoadBookmarksWithSkipped() (Manga, int) {
+	if LoadSettings().Storage == StorageSQLite {
+		bookmarks, err := DefaultStore().ListBookmarks()
+		if err != nil {
+			log.Printf("error loading bookmarks: %v", err)
+		}
+		return Manga{Manga: bookmarks}, 0
+	}
+
 	bookmarksLocation, err := verifyConfigFiles()
 	if err != nil {
 		log.Printf("error verifying config files: %v", err)
-		return Manga{}
+		return Manga{}, 0
 	}
 
-	file, err := os.Open(bookmarksLocation)
+	bookmarksFileMu.Lock()
+	byteValues, err := readBookmarksFile(bookmarksLocation)
+	bookmarksFileMu.Unlock()
 	if err != nil {
-		log.Printf("error loading bookmarks file: %v", err)
-		return Manga{}
+		log.Printf("error reading bookmarks file: %v", err)
+		return Manga{}, 0
 	}
-	defer file.Close()
 
-	byteValues, err := io.ReadAll(file)
+	mangaStruct, skipped, err := DecodeBookmarks(byteValues)
 	if err != nil {
-		log.Printf("error reading bookmarks file: %v", err)
-		return Manga{}
+		log.Printf("error unmarshalling bookmarks: %v", err)
 	}
 
-	var mangaStruct Manga
-	if err := json.Unmarshal(byteValues, &mangaStruct); err != nil {
-		log.Printf("error unmarshalling bookmarks: %v", err)
+	if skipped > 0 {
+		log.Printf("⚠️ %d bookmark entries could not be loaded, %d loaded", skipped, len(mangaStruct.Manga))
+		if backupPath, err := backupCorruptBookmarks(bookmarksLocation, byteValues); err != nil {
+			log.Printf("error backing up bookmarks file: %v", err)
+		} else if backupPath != "" {
+			log.Printf("Original bookmarks file backed up to '%s'", backupPath)
+		}
 	}
 
-	return mangaStruct
+	return mangaStruct, skipped
 }
 
-// Save bookmark to file (always saves to ~/.config/kansho/bookmarks.json)
-func SaveBookmarks(data Manga) error {
-	bookmarksDir, err := verifyConfigDirectory()
+// backupCorruptBookmarks writes data to a new <bookmarks>.corrupt-<timestamp> file
+// and returns its path, or "" when the newest backup already holds the same data:
+// the file is loaded on every start and list refresh, one copy is enough until it
+// changes.
+func backupCorruptBookmarks(bookmarksLocation string, data []byte) (string, error) {
+	prefix := bookmarksLocation + ".corrupt-"
+	backups, err := filepath.Glob(prefix + "*")
 	if err != nil {
-		log.Fatalf("error verifying config directory: %v", err)
+		return "", err
+	}
+	if len(backups) > 0 {
+		// The timestamp sorts the backups oldest first
+		sort.Strings(backups)
+		if newest, err := os.ReadFile(backups[len(backups)-1]); err == nil && bytes.Equal(newest, data) {
+			return "", nil
+		}
 	}
 
-	bookmarksFile := filepath.Join(bookmarksDir, "bookmarks.json")
+	stamp := time.Now().Format("20060102-150405")
+	backupPath := prefix + stamp
+	for n := 2; ; n++ {
+		// A second backup within the same second must not replace the first
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			break
+		}
+		backupPath = fmt.Sprintf("%s%s-%d", prefix, stamp, n)
+	}
+	return backupPath, os.WriteFile(backupPath, data, 0644)
+}
+
+// readBookmarksFile reads the bookmarks file, caller must hold bookmarksFileMu
+func readBookmarksFile(bookmarksLocation string) ([]byte, error) {
+	file, err := os.Open(bookmarksLocation)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// DecodeBookmarks decodes a bookmarks file entry by entry so one malformed entry
+// does not take the whole library down. Entries with invalid field types are
+// skipped and counted; a syntax error stops decoding but keeps every entry read
+// before it (counted as one skipped entry since the rest cannot be recovered).
+func DecodeBookmarks(data []byte) (Manga, int, error) {
+	var mangaStruct Manga
+	if err := json.Unmarshal(data, &mangaStruct); err == nil {
+		return mangaStruct, 0, nil
+	}
+
+	mangaStruct = Manga{}
+	skipped := 0
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('{') {
+		return mangaStruct, 0, fmt.Errorf("bookmarks file is not a JSON object")
+	}
+
+	for decoder.More() {
+		keyTok, err := decoder.Token()
+		if err != nil {
+			return mangaStruct, skipped + 1, fmt.Errorf("failed to read bookmarks key: %w", err)
+		}
+
+		if key, _ := keyTok.(string); key != "manga" {
+			var ignored json.RawMessage
+			if err := decoder.Decode(&ignored); err != nil {
+				return mangaStruct, skipped, fmt.Errorf("failed to read bookmarks field %v: %w", keyTok, err)
+			}
+			continue
+		}
+
+		if tok, err := decoder.Token(); err != nil || tok != json.Delim('[') {
+			return mangaStruct, skipped + 1, fmt.Errorf("bookmarks \"manga\" field is not a list")
+		}
 
-	// Marshal to JSON
-	jsonData, err := json.MarshalIndent(data, "", "  ")
+		for index := 0; decoder.More(); index++ {
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				log.Printf("bookmark entry %d is not valid JSON, remaining entries cannot be read: %v", index, err)
+				return mangaStruct, skipped + 1, fmt.Errorf("bookmark entry %d: %w", index, err)
+			}
+
+			var bookmark Bookmarks
+			if err := json.Unmarshal(raw, &bookmark); err != nil {
+				log.Printf("skipping malformed bookmark entry %d: %v", index, err)
+				skipped++
+				continue
+			}
+			mangaStruct.Manga = append(mangaStruct.Manga, bookmark)
+		}
+
+		if _, err := decoder.Token(); err != nil {
+			return mangaStruct, skipped, fmt.Errorf("failed to close bookmarks list: %w", err)
+		}
+	}
+
+	return mangaStruct, skipped, nil
+}
+
+// Save bookmarks to ~/.config/kansho/bookmarks.json, or to the database when the
+// storage setting is sqlite
+func SaveBookmarks(data Manga) error {
+	if LoadSettings().Storage == StorageSQLite {
+		return DefaultStore().ReplaceBookmarks(data.Manga)
+	}
+
+	bookmarksDir, err := verifyConfigDirectory()
 	if err != nil {
-		return err
+		log.Fatalf("error verifying config directory: %v", err)
 	}
 
-	// Write to file
-	return os.WriteFile(bookmarksFile, jsonData, 0644)
+	return writeBookmarksFile(filepath.Join(bookmarksDir, "bookmarks.json"), data)
 }
 
 // check config directory exists or create it
@@ -4,10 +4,33 @@ import (
 	"context"
 	"fmt"
 	"log"
+
+	"kansho/parser"
 )
 
+// ProgressEvent is one progress report of a site download
+type ProgressEvent struct {
+	Status   string
+	Fraction float64 // progress of the whole run, 0.0 to 1.0
+
+	// ChapterNum is the number of the chapter being downloaded, CurrentDownload its
+	// position among the chapters of this run (1 based) and TotalChapters the number
+	// of chapters found on the site. Zero when the event is not about a chapter.
+	ChapterNum      int
+	CurrentDownload int
+	TotalChapters   int
+
+	// ImageIndex is the page of the chapter being downloaded (1 based) out of
+	// ImageTotal, both zero outside of page downloads
+	ImageIndex int
+	ImageTotal int
+}
+
+// ProgressFunc receives the progress events of a site download, it may be nil
+type ProgressFunc func(ProgressEvent)
+
 // SiteDownloadFunc is the function signature for site-specific download functions
-type SiteDownloadFunc func(context.Context, *Bookmarks, func(string, float64, int, int, int)) error
+type SiteDownloadFunc func(context.Context, *Bookmarks, ProgressFunc) error
 
 // registeredSites maps site names to their download functions
 var registeredSites = make(map[string]SiteDownloadFunc)
@@ -20,13 +43,35 @@ func RegisterSite(siteName string, downloadFunc SiteDownloadFunc) {
 }
 
 // ExecuteSiteDownload dispatches to the appropriate site-specific download function
-func ExecuteSiteDownload(ctx context.Context, manga *Bookmarks, progressCallback func(string, float64, int, int, int)) error {
+func ExecuteSiteDownload(ctx context.Context, manga *Bookmarks, progressCallback ProgressFunc) error {
 	downloadFunc, exists := registeredSites[manga.Site]
 	if !exists {
 		log.Printf("[Queue] ERROR: Site '%s' not registered. Available sites: %v", manga.Site, getRegisteredSiteNames())
 		return fmt.Errorf("download not supported for site: %s (not registered)", manga.Site)
 	}
 
+	// Fail fast with a clear error rather than deep in the chapter loop when the
+	// library drive is not mounted or the folder is read-only
+	if err := CheckLibraryLocation(manga.Location); err != nil {
+		log.Printf("[Queue] ⚠️ Skipping %s: %v", manga.Title, err)
+		return err
+	}
+
+	// Apply the image Accept, conversion and logging settings here so every site,
+	// manager based or not, uses them
+	settings := LoadSettings()
+	parser.SetImageAccept(settings.ImageAccept)
+	parser.SetVerboseLogging(settings.VerboseLogging)
+	parser.SetKeepLosslessWebP(settings.KeepLosslessWebP)
+	parser.SetOptimizeJPEG(settings.OptimizeJPEG)
+	parser.SetCbzExtensionRule(settings.CbzExtensionRule)
+	if err := parser.SetChapterFilenameTemplate(settings.ChapterFilenameTemplate); err != nil {
+		log.Printf("[Queue] ⚠️ %v", err)
+	}
+
+	// Applied after the CF bypass data by every request of this series
+	ctx = parser.WithRequestExtras(ctx, manga.RequestExtras())
+
 	log.Printf("[Queue] Dispatching download for site: %s", manga.Site)
 	return downloadFunc(ctx, manga, progressCallback)
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"kansho/cf"
 )
@@ -14,30 +15,79 @@ import (
 type DownloadTask struct {
 	ID            string    // Unique ID for this task
 	Manga         Bookmarks // Changed from pointer to value - this creates a copy!
-	Status        string    // "queued", "downloading", "completed", "cancelled", "failed", "waiting_cf"
+	Status        string    // "queued", "downloading", "completed", "completed_with_errors", "cancelled", "failed", "waiting_cf", "waiting_confirm"
 	Progress      float64   // 0.0 to 1.0
 	StatusMessage string
 	CancelFunc    context.CancelFunc
 	Error         error
 
+	// Result is what the last finished run did, zero until a run finishes
+	Result DownloadResult
+
+	// skipper abandons the chapter in progress without cancelling the task
+	skipper *ChapterSkipper
+
+	// runLog holds the messages of the current run, replaced when the task starts
+	runLog *RunLog
+
+	// renumberConfirmed is set by ConfirmTask, the next run skips the renumber check
+	renumberConfirmed bool
+
+	// retryFailedOnly runs only download the chapters earlier runs recorded as failed
+	retryFailedOnly bool
+
+	// redownload runs download redownloadChapters again even though they are in the
+	// library, every local chapter when it is empty, see AddRedownloadTask
+	redownload         bool
+	redownloadChapters []string
+
+	// Priority tasks start before any normal queued task, see AddPriorityTask
+	Priority bool
+
+	// refreshChapterList runs scrape the chapter list even when a cached one is fresh
+	refreshChapterList bool
+
+	// unattended tasks are not started when CFPreflight says they would stop at a
+	// challenge, see AddUnattendedTask
+	unattended bool
+
 	// Chapter tracking
 	ActualChapter   int
 	CurrentDownload int
 	TotalFound      int
+
+	// Page of the current chapter being downloaded out of ImageTotal, zero outside
+	// of page downloads
+	ImageIndex int
+	ImageTotal int
+
+	// New chapters this run will fetch and how many of them have finished, for the
+	// aggregate progress. PlanKnown is false until the download has compared the
+	// site's chapter list with the library.
+	ChaptersPlanned int
+	ChaptersDone    int
+	PlanKnown       bool
+
+	// batch groups tasks queued together, see AggregateProgress
+	batch int
 }
 
-// DownloadQueue manages FIFO download queue
+// DownloadQueue manages FIFO download queue. Up to maxConcurrent series are
+// downloaded at once, tasks still start in the order they were queued.
 type DownloadQueue struct {
-	tasks        []*DownloadTask
-	mu           sync.RWMutex
-	processing   bool
-	processingMu sync.Mutex
-
-	// Callbacks for UI updates
-	onTaskAdded   func(*DownloadTask)
-	onTaskUpdated func(*DownloadTask)
-	onTaskRemoved func(string)
-	onQueueEmpty  func()
+	tasks         []*DownloadTask
+	mu            sync.RWMutex
+	running       int // number of tasks currently being executed
+	maxConcurrent int
+	processingMu  sync.Mutex
+	batch         int // current batch, a new one starts when a task is added to an idle queue
+	nextID        int // suffix of the next task ID, never reused once tasks are removed
+
+	// Callbacks for UI updates, the SetCallbacks slot plus any Subscribe listeners
+	listenersMu    sync.RWMutex
+	callbacks      QueueListener
+	listeners      map[int]QueueListener
+	nextListenerID int
 }
 
 // Global download queue instance
@@ -48,30 +98,93 @@ var queueOnce sync.Once
 func GetDownloadQueue() *DownloadQueue {
 	queueOnce.Do(func() {
 		globalQueue = &DownloadQueue{
-			tasks: make([]*DownloadTask, 0),
+			tasks:         make([]*DownloadTask, 0),
+			maxConcurrent: LoadSettings().MaxConcurrentSeries,
 		}
 	})
 	return globalQueue
 }
 
-// SetCallbacks sets the UI update callbacks
+// SetCallbacks sets the UI update callbacks. Views that may be closed and reopened
+// should use Subscribe instead, which supports several listeners at once.
 func (q *DownloadQueue) SetCallbacks(
 	onAdded func(*DownloadTask),
 	onUpdated func(*DownloadTask),
 	onRemoved func(string),
 	onEmpty func(),
 ) {
-	q.mu.Lock()
-	defer q.mu.Unlock()
+	q.listenersMu.Lock()
+	defer q.listenersMu.Unlock()
+
+	q.callbacks = QueueListener{
+		OnTaskAdded:   onAdded,
+		OnTaskUpdated: onUpdated,
+		OnTaskRemoved: onRemoved,
+		OnQueueEmpty:  onEmpty,
+	}
+}
 
-	q.onTaskAdded = onAdded
-	q.onTaskUpdated = onUpdated
-	q.onTaskRemoved = onRemoved
-	q.onQueueEmpty = onEmpty
+// SetMaxConcurrent sets how many series may download at the same time, values
+// below 1 are treated as 1. Takes effect the next time a task is started.
+func (q *DownloadQueue) SetMaxConcurrent(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	q.processingMu.Lock()
+	q.maxConcurrent = n
+	q.processingMu.Unlock()
+
+	log.Printf("[Queue] Max concurrent series set to %d", n)
+	go q.processQueue()
 }
 
 // AddTask adds a manga download to the queue
 func (q *DownloadQueue) AddTask(manga *Bookmarks) (*DownloadTask, error) {
+	return q.addTask(manga, taskOptions{})
+}
+
+// AddPriorityTask adds a manga download that starts as soon as a worker slot is
+// free, ahead of the tasks already waiting in the queue. Downloads in progress are
+// not interrupted. The user asked for this series explicitly, so its chapter list
+// is scraped afresh rather than taken from the cache.
+func (q *DownloadQueue) AddPriorityTask(manga *Bookmarks) (*DownloadTask, error) {
+	return q.addTask(manga, taskOptions{priority: true, refreshChapterList: true})
+}
+
+// AddRetryFailedTask queues a download of only the chapters of manga that earlier
+// runs recorded as failed, skipped or short, the site's chapter list is not checked
+// for new ones
+func (q *DownloadQueue) AddRetryFailedTask(manga *Bookmarks) (*DownloadTask, error) {
+	return q.addTask(manga, taskOptions{retryFailedOnly: true})
+}
+
+// AddRedownloadTask queues a download that fetches chapters of manga again even
+// though they are in the library, overwriting their CBZ files, for a site that
+// re-uploaded fixed pages. Without chapters (cbz names) every local chapter is
+// downloaded again. New chapters are downloaded as usual, from a fresh chapter list.
+func (q *DownloadQueue) AddRedownloadTask(manga *Bookmarks, chapters ...string) (*DownloadTask, error) {
+	return q.addTask(manga, taskOptions{redownload: true, redownloadChapters: chapters, refreshChapterList: true})
+}
+
+// AddUnattendedTask adds a manga download no one is watching, such as a bulk
+// recheck. A series on a CF protected site without a usable cf_clearance is left
+// "waiting_cf" instead of opening a browser, RetryTask then runs it as a normal task.
+func (q *DownloadQueue) AddUnattendedTask(manga *Bookmarks) (*DownloadTask, error) {
+	return q.addTask(manga, taskOptions{unattended: true})
+}
+
+// taskOptions are the ways the Add*Task variants differ
+type taskOptions struct {
+	retryFailedOnly    bool
+	redownload         bool
+	redownloadChapters []string
+	priority           bool
+	refreshChapterList bool
+	unattended         bool
+}
+
+func (q *DownloadQueue) addTask(manga *Bookmarks, opts taskOptions) (*DownloadTask, error) {
 	q.mu.Lock()
 
 	// Check if this manga is already in queue
@@ -86,22 +199,34 @@ func (q *DownloadQueue) AddTask(manga *Bookmarks) (*DownloadTask, error) {
 	// This prevents the task from being affected by changes to the original bookmarks
 	mangaCopy := *manga
 
+	if !q.hasActiveTasks() {
+		q.batch++
+	}
+
 	task := &DownloadTask{
-		ID:            fmt.Sprintf("%s-%d", manga.Shortname, len(q.tasks)),
+		ID:            fmt.Sprintf("%s-%d", manga.Shortname, q.nextID),
 		Manga:         mangaCopy, // Store the copy, not a pointer
 		Status:        "queued",
 		StatusMessage: "Waiting in queue...",
 		Progress:      0.0,
+		batch:         q.batch,
+		Priority:      opts.priority,
+
+		retryFailedOnly:    opts.retryFailedOnly,
+		redownload:         opts.redownload,
+		redownloadChapters: opts.redownloadChapters,
+		refreshChapterList: opts.refreshChapterList,
+		unattended:         opts.unattended,
 	}
 
+	q.nextID++
 	q.tasks = append(q.tasks, task)
+	snapshot := *task
 	q.mu.Unlock()
 
 	log.Printf("[Queue] Added task: %s (%s) - Location: %s", task.Manga.Title, task.ID, task.Manga.Location)
 
-	if q.onTaskAdded != nil {
-		q.onTaskAdded(task)
-	}
+	q.notifyTaskAdded(snapshot)
 
 	// Start processing if not already running
 	go q.processQueue()
@@ -109,31 +234,86 @@ func (q *DownloadQueue) AddTask(manga *Bookmarks) (*DownloadTask, error) {
 	return task, nil
 }
 
+// hasActiveTasks reports whether any task is queued, downloading or waiting on CF
+// or a renumber confirmation. Caller must hold q.mu.
+func (q *DownloadQueue) hasActiveTasks() bool {
+	for _, task := range q.tasks {
+		if taskActive(task.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+// taskActive reports whether a task of this status is still to run: queued,
+// downloading, or waiting for the user to solve a challenge or confirm a renumber
+func taskActive(status string) bool {
+	switch status {
+	case "queued", "downloading", "waiting_cf", "waiting_confirm":
+		return true
+	}
+	return false
+}
+
 // RetryTask retries a task that failed due to CF challenge
 func (q *DownloadQueue) RetryTask(id string) error {
 	q.mu.Lock()
-	defer q.mu.Unlock()
 
 	for _, task := range q.tasks {
 		if task.ID == id {
-			if task.Status == "waiting_cf" || task.Status == "failed" {
+			if task.Status == "waiting_cf" || task.Status == "failed" || task.Status == "completed_with_errors" {
 				log.Printf("[Queue] Retrying task: %s", task.Manga.Title)
 				task.Status = "queued"
 				task.StatusMessage = "Retrying..."
 				task.Error = nil
+				// Asked for by the user, a challenge may open the browser now
+				task.unattended = false
+				snapshot := *task
+				q.mu.Unlock()
 
-				if q.onTaskUpdated != nil {
-					q.onTaskUpdated(task)
-				}
+				q.notifyTaskUpdated(snapshot)
 
 				// Restart queue processing
 				go q.processQueue()
 				return nil
 			}
-			return fmt.Errorf("task cannot be retried (status: %s)", task.Status)
+			status := task.Status
+			q.mu.Unlock()
+			return fmt.Errorf("task cannot be retried (status: %s)", status)
+		}
+	}
+
+	q.mu.Unlock()
+	return fmt.Errorf("task not found: %s", id)
+}
+
+// ConfirmTask queues a task held as "waiting_confirm" again, this time downloading
+// even though its chapter numbering looked shifted
+func (q *DownloadQueue) ConfirmTask(id string) error {
+	q.mu.Lock()
+
+	for _, task := range q.tasks {
+		if task.ID == id {
+			if task.Status != "waiting_confirm" {
+				status := task.Status
+				q.mu.Unlock()
+				return fmt.Errorf("task is not waiting for confirmation (status: %s)", status)
+			}
+			log.Printf("[Queue] Download confirmed despite renumbering: %s", task.Manga.Title)
+			task.Status = "queued"
+			task.StatusMessage = "Confirmed, queued..."
+			task.Error = nil
+			task.renumberConfirmed = true
+			snapshot := *task
+			q.mu.Unlock()
+
+			q.notifyTaskUpdated(snapshot)
+			go q.processQueue()
+			return nil
 		}
 	}
 
+	q.mu.Unlock()
 	return fmt.Errorf("task not found: %s", id)
 }
 
@@ -160,6 +340,20 @@ func (q *DownloadQueue) GetTask(id string) *DownloadTask {
 	return nil
 }
 
+// GetRunLog returns the run log of a task's current or last run, nil when the task
+// is unknown or has not started yet
+func (q *DownloadQueue) GetRunLog(id string) *RunLog {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for _, task := range q.tasks {
+		if task.ID == id {
+			return task.runLog
+		}
+	}
+	return nil
+}
+
 // CancelTask cancels a specific task (either downloading or queued)
 func (q *DownloadQueue) CancelTask(id string) error {
 	q.mu.Lock()
@@ -172,15 +366,16 @@ func (q *DownloadQueue) CancelTask(id string) error {
 				task.Status = "cancelled"
 				task.StatusMessage = "Cancelling..."
 
-				// Notify UI immediately before the slow context cancellation unwinds
-				if q.onTaskUpdated != nil {
-					q.onTaskUpdated(task)
-				}
+				cancelFunc := task.CancelFunc
+				snapshot := *task
 				q.mu.Unlock()
 
+				// Notify UI immediately before the slow context cancellation unwinds
+				q.notifyTaskUpdated(snapshot)
+
 				// Trigger cancellation - the download will notice and return quickly now
 				// thanks to context-aware retry sleeps and rate limiter waits
-				task.CancelFunc()
+				cancelFunc()
 
 				// The executeTask goroutine will set the final status when it returns
 				return nil
@@ -191,9 +386,7 @@ func (q *DownloadQueue) CancelTask(id string) error {
 
 				q.mu.Unlock()
 
-				if q.onTaskRemoved != nil {
-					q.onTaskRemoved(id)
-				}
+				q.notifyTaskRemoved(id)
 				return nil
 			} else {
 				q.mu.Unlock()
@@ -206,14 +399,46 @@ func (q *DownloadQueue) CancelTask(id string) error {
 	return fmt.Errorf("task not found: %s", id)
 }
 
+// SkipChapter abandons the chapter the task is currently downloading, the task
+// carries on with its next chapter
+func (q *DownloadQueue) SkipChapter(id string) error {
+	q.mu.Lock()
+	var task *DownloadTask
+	for _, t := range q.tasks {
+		if t.ID == id {
+			task = t
+			break
+		}
+	}
+	if task == nil {
+		q.mu.Unlock()
+		return fmt.Errorf("task not found: %s", id)
+	}
+	if task.Status != "downloading" || task.skipper == nil {
+		status := task.Status
+		q.mu.Unlock()
+		return fmt.Errorf("task is not downloading (status: %s)", status)
+	}
+	skipper := task.skipper
+	title := task.Manga.Title
+	q.mu.Unlock()
+
+	if !skipper.Skip() {
+		return fmt.Errorf("no chapter is being downloaded right now")
+	}
+	log.Printf("[Queue] Skipping current chapter of: %s", title)
+	return nil
+}
+
 // CancelAll cancels all tasks
 func (q *DownloadQueue) CancelAll() {
 	q.mu.Lock()
 
 	log.Printf("[Queue] Cancelling all tasks (%d total)", len(q.tasks))
 
-	// Step 1: Immediately mark all tasks as cancelled and notify UI
+	// Step 1: Immediately mark all tasks as cancelled
 	var cancelFuncs []context.CancelFunc
+	snapshots := make([]DownloadTask, 0, len(q.tasks))
 	for _, task := range q.tasks {
 		if task.Status == "downloading" && task.CancelFunc != nil {
 			task.Status = "cancelled"
@@ -223,135 +448,286 @@ func (q *DownloadQueue) CancelAll() {
 			task.Status = "cancelled"
 			task.StatusMessage = "Cancelled by user"
 		}
-
-		if q.onTaskUpdated != nil {
-			q.onTaskUpdated(task)
-		}
+		snapshots = append(snapshots, *task)
 	}
 
 	q.mu.Unlock()
 
-	// Step 2: Trigger context cancellations (no lock held)
+	// Step 2: Notify UI, then trigger context cancellations (no lock held)
+	for _, snapshot := range snapshots {
+		q.notifyTaskUpdated(snapshot)
+	}
 	for _, cancel := range cancelFuncs {
 		cancel()
 	}
 }
 
+// RemoveFinishedTask removes a single task that has finished, whether it completed,
+// failed or was cancelled, so its series can be queued again
+func (q *DownloadQueue) RemoveFinishedTask(id string) error {
+	q.mu.Lock()
+	for i, task := range q.tasks {
+		if task.ID != id {
+			continue
+		}
+		switch task.Status {
+		case "completed", "completed_with_errors", "cancelled", "failed":
+		default:
+			q.mu.Unlock()
+			return fmt.Errorf("task has not finished (status: %s)", task.Status)
+		}
+		if task.CancelFunc != nil {
+			// Cancelled but the download has not returned yet
+			q.mu.Unlock()
+			return fmt.Errorf("task is still being cancelled")
+		}
+		q.tasks = append(q.tasks[:i], q.tasks[i+1:]...)
+		q.mu.Unlock()
+
+		q.notifyTaskRemoved(id)
+		return nil
+	}
+	q.mu.Unlock()
+	return fmt.Errorf("task not found: %s", id)
+}
+
 // RemoveCompletedTasks removes all completed or cancelled tasks
 func (q *DownloadQueue) RemoveCompletedTasks() {
 	q.mu.Lock()
-	defer q.mu.Unlock()
 
 	newTasks := make([]*DownloadTask, 0)
+	var removed []string
 	for _, task := range q.tasks {
-		if task.Status == "queued" || task.Status == "downloading" || task.Status == "waiting_cf" {
+		if taskActive(task.Status) {
 			newTasks = append(newTasks, task)
 		} else {
-			if q.onTaskRemoved != nil {
-				q.onTaskRemoved(task.ID)
-			}
+			removed = append(removed, task.ID)
 		}
 	}
 
 	q.tasks = newTasks
 	log.Printf("[Queue] Cleaned up completed tasks, %d remaining", len(q.tasks))
-}
+	q.mu.Unlock()
 
-// processQueue processes tasks in FIFO order
-func (q *DownloadQueue) processQueue() {
-	q.processingMu.Lock()
-	if q.processing {
-		q.processingMu.Unlock()
-		return // Already processing
+	for _, id := range removed {
+		q.notifyTaskRemoved(id)
 	}
-	q.processing = true
-	q.processingMu.Unlock()
+}
 
-	defer func() {
+// processQueue starts queued tasks in FIFO order until the concurrency limit is
+// reached. Each finished task calls back into processQueue to start the next one.
+func (q *DownloadQueue) processQueue() {
+	for {
 		q.processingMu.Lock()
-		q.processing = false
-		q.processingMu.Unlock()
-	}()
+		limit := q.maxConcurrent
+		if limit < 1 {
+			limit = 1
+		}
+		if q.running >= limit {
+			q.processingMu.Unlock()
+			return // All worker slots busy
+		}
 
-	for {
 		task := q.getNextTask()
 		if task == nil {
-			log.Println("[Queue] No more tasks to process")
-			if q.onQueueEmpty != nil {
-				q.onQueueEmpty()
+			idle := q.running == 0
+			q.processingMu.Unlock()
+
+			if idle {
+				log.Println("[Queue] No more tasks to process")
+				q.notifyQueueEmpty()
 			}
-			break
+			return
 		}
+		q.running++
+		q.processingMu.Unlock()
 
 		log.Printf("[Queue] Processing task: %s (Location: %s)", task.Manga.Title, task.Manga.Location)
-		q.executeTask(task)
-
-		// Check if we should continue
-		q.mu.RLock()
-		hasMore := false
-		for _, t := range q.tasks {
-			if t.Status == "queued" {
-				hasMore = true
-				break
-			}
-		}
-		q.mu.RUnlock()
+		go func(task *DownloadTask) {
+			q.executeTask(task)
 
-		if !hasMore {
-			break
-		}
+			q.processingMu.Lock()
+			q.running--
+			q.processingMu.Unlock()
+
+			q.processQueue()
+		}(task)
 	}
 }
 
-// getNextTask gets the next queued task
+// getNextTask claims the next queued task, priority tasks first, marking it as
+// downloading so a concurrent worker can't pick up the same task
 func (q *DownloadQueue) getNextTask() *DownloadTask {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
+	var next *DownloadTask
 	for _, task := range q.tasks {
 		if task.Status == "queued" {
-			return task
+			if task.Priority {
+				next = task
+				break
+			}
+			if next == nil {
+				next = task
+			}
 		}
 	}
-	return nil
+	if next != nil {
+		next.Status = "downloading"
+		next.StatusMessage = "Starting download..."
+	}
+	return next
+}
+
+// refreshClearance solves the Cloudflare challenge err stopped the download of task
+// at in a browser window (the registered ClearanceRefreshFunc), and reports whether
+// the download can run again with the new cf_clearance. It does nothing for other
+// errors, unattended tasks or without cf.ClearanceRefreshEnabled. A failed refresh
+// opens the challenge in the default browser instead and the task waits on CF.
+func (q *DownloadQueue) refreshClearance(ctx context.Context, task *DownloadTask, runLog *RunLog, err error) bool {
+	var cfErr *cf.CfChallengeError
+	if !errors.As(err, &cfErr) || !cf.ClearanceRefreshEnabled(ctx) {
+		return false
+	}
+	q.mu.Lock()
+	unattended := task.unattended
+	if !unattended {
+		task.StatusMessage = "Cloudflare challenge - solve it in the browser window"
+	}
+	snapshot := *task
+	q.mu.Unlock()
+	if unattended {
+		return false
+	}
+	q.notifyTaskUpdated(snapshot)
+	runLog.Printf("Cloudflare challenge at %s, refreshing cf_clearance", cfErr.URL)
+
+	if _, err := clearanceRefresher(ctx, cfErr.URL); err != nil {
+		log.Printf("[Queue] ⚠️ cf_clearance refresh for %s failed: %v", task.Manga.Title, err)
+		runLog.Printf("Cloudflare clearance refresh failed: %v", err)
+		if ctx.Err() == nil && !errors.Is(err, cf.ErrBrowserDisabled) {
+			cf.OpenInBrowser(cfErr.URL)
+		}
+		return false
+	}
+
+	q.mu.Lock()
+	task.StatusMessage = "Cloudflare challenge solved, resuming download..."
+	snapshot = *task
+	q.mu.Unlock()
+	runLog.Add("Cloudflare challenge solved, resuming download")
+	q.notifyTaskUpdated(snapshot)
+	return true
 }
 
 // executeTask executes a download task
 func (q *DownloadQueue) executeTask(task *DownloadTask) {
+	q.mu.RLock()
+	unattended := task.unattended
+	q.mu.RUnlock()
+	if unattended {
+		if err := CFPreflight(&task.Manga); err != nil {
+			q.mu.Lock()
+			task.Status = "waiting_cf"
+			task.StatusMessage = "Skipped: no valid Cloudflare cookie, retry after importing one"
+			task.Error = err
+			snapshot := *task
+			q.mu.Unlock()
+			q.notifyTaskUpdated(snapshot)
+			return
+		}
+	}
+
 	// Create cancellable context
 	ctx, cancel := context.WithCancel(context.Background())
+	skipper := &ChapterSkipper{}
+	ctx = WithChapterSkipper(ctx, skipper)
+	runLog := NewRunLog(RunLogCapacity)
+	ctx = withRunLog(ctx, runLog)
+	q.mu.RLock()
+	if task.renumberConfirmed {
+		ctx = WithRenumberConfirmed(ctx)
+	}
+	if task.retryFailedOnly {
+		ctx = WithRetryFailedOnly(ctx)
+	}
+	if task.redownload {
+		ctx = WithRedownload(ctx, task.redownloadChapters...)
+	}
+	if task.refreshChapterList {
+		ctx = WithChapterListRefresh(ctx)
+	}
+	q.mu.RUnlock()
+	ctx = withClearanceRefresh(ctx, LoadSettings())
+	ctx = withChapterReporter(ctx,
+		func(planned int) {
+			q.mu.Lock()
+			task.ChaptersPlanned = planned
+			task.ChaptersDone = 0
+			task.PlanKnown = true
+			snapshot := *task
+			q.mu.Unlock()
+			q.notifyTaskUpdated(snapshot)
+		},
+		func() {
+			q.mu.Lock()
+			task.ChaptersDone++
+			snapshot := *task
+			q.mu.Unlock()
+			q.notifyTaskUpdated(snapshot)
+		},
+	)
 
 	q.mu.Lock()
 	task.Status = "downloading"
 	task.StatusMessage = "Starting download..."
 	task.CancelFunc = cancel
+	task.skipper = skipper
+	task.runLog = runLog
+	snapshot := *task
 	q.mu.Unlock()
 
-	if q.onTaskUpdated != nil {
-		q.onTaskUpdated(task)
-	}
+	runLog.Printf("Starting download of %s to %s", task.Manga.Title, task.Manga.Location)
+	q.notifyTaskUpdated(snapshot)
 
 	// Progress callback
-	progressCallback := func(status string, progress float64, actualChapter, currentDownload, totalFound int) {
+	progressCallback := func(event ProgressEvent) {
 		q.mu.Lock()
-		task.Progress = progress
-		task.StatusMessage = status
-		task.ActualChapter = actualChapter
-		task.CurrentDownload = currentDownload
-		task.TotalFound = totalFound
+		changed := event.Status != task.StatusMessage
+		task.Progress = event.Fraction
+		task.StatusMessage = event.Status
+		task.ActualChapter = event.ChapterNum
+		task.CurrentDownload = event.CurrentDownload
+		task.TotalFound = event.TotalChapters
+		task.ImageIndex = event.ImageIndex
+		task.ImageTotal = event.ImageTotal
+		snapshot := *task
 		q.mu.Unlock()
 
-		if q.onTaskUpdated != nil {
-			q.onTaskUpdated(task)
+		// Image progress repeats the same status, only log when it says something new
+		if changed {
+			runLog.Add(event.Status)
 		}
+		q.notifyTaskUpdated(snapshot)
 	}
 
 	// CRITICAL: Pass a pointer to the manga copy
 	// This ensures the download uses the snapshot taken when the task was created
 	log.Printf("[Queue] Starting download for: %s to location: %s", task.Manga.Title, task.Manga.Location)
-	err := ExecuteSiteDownload(ctx, &task.Manga, progressCallback)
+	started := time.Now()
+	result, err := RunSiteDownload(ctx, &task.Manga, progressCallback)
+	if q.refreshClearance(ctx, task, runLog, err) {
+		result, err = RunSiteDownload(ctx, &task.Manga, progressCallback)
+	} else if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// Cancelled while the challenge was being solved
+		err = ctx.Err()
+	}
+
+	RecordRemoteChapters(task.Manga.Url, result.RemoteChapters)
 
 	q.mu.Lock()
+	task.Result = result
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			task.Status = "cancelled"
@@ -362,20 +738,38 @@ func (q *DownloadQueue) executeTask(task *DownloadTask) {
 			if errors.As(err, &cfErr) {
 				task.Status = "waiting_cf"
 				task.StatusMessage = "Cloudflare challenge detected - browser opened"
+				if cf.ClearanceRefreshEnabled(ctx) {
+					task.StatusMessage = "Cloudflare challenge not solved - retry to try again"
+				}
 				task.Error = cfErr
 
 				log.Printf("[Queue] CF challenge detected for %s (URL: %s)", task.Manga.Title, cfErr.URL)
 
+				snapshot := *task
 				q.mu.Unlock()
-				if q.onTaskUpdated != nil {
-					q.onTaskUpdated(task)
-				}
+				runLog.Printf("Cloudflare challenge at %s", cfErr.URL)
+				q.notifyTaskUpdated(snapshot)
 				return
 			}
 
-			task.Status = "failed"
-			task.StatusMessage = fmt.Sprintf("Error: %v", err)
-			task.Error = err
+			var renumberErr *RenumberSuspectedError
+			var incompleteErr *IncompleteDownloadError
+			if errors.As(err, &renumberErr) {
+				// The chapter list no longer lines up with the library, the user decides
+				task.Status = "waiting_confirm"
+				task.StatusMessage = "Needs confirmation: " + renumberErr.Error()
+				task.Error = err
+			} else if errors.As(err, &incompleteErr) {
+				// The run finished but skipped some chapters
+				task.Status = "completed_with_errors"
+				task.StatusMessage = incompleteErr.Summary.Message()
+				task.Error = err
+				task.Progress = 1.0
+			} else {
+				task.Status = "failed"
+				task.StatusMessage = fmt.Sprintf("Error: %v", err)
+				task.Error = err
+			}
 		}
 	} else {
 		task.Status = "completed"
@@ -383,11 +777,14 @@ func (q *DownloadQueue) executeTask(task *DownloadTask) {
 		task.Progress = 1.0
 	}
 	task.CancelFunc = nil
+	task.skipper = nil
+	status, message := task.Status, task.StatusMessage
+	snapshot = *task
 	q.mu.Unlock()
 
-	if q.onTaskUpdated != nil {
-		q.onTaskUpdated(task)
-	}
+	runLog.Printf("Finished (%s): %s", status, message)
+	q.notifyTaskUpdated(snapshot)
+	RecordDownloadRun(task.Manga, status, message, started)
 
-	log.Printf("[Queue] Task completed: %s (status: %s)", task.Manga.Title, task.Status)
+	log.Printf("[Queue] Task completed: %s (status: %s)", task.Manga.Title, status)
 }
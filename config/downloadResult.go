@@ -0,0 +1,65 @@
+package config
+
+import (
+	"context"
+	"time"
+)
+
+// DownloadResult is the outcome of one download run of a series, for callers that
+// need the numbers rather than the final message (history, notifications, tests)
+type DownloadResult struct {
+	// NewChapters is how many chapters the run set out to download
+	NewChapters int
+	Succeeded   int
+	Failed      int
+
+	// Skipped chapters were abandoned by the user mid-download
+	Skipped int
+
+	// Excluded chapters are missing from the library but were left out on purpose:
+	// pruned by the keep-latest retention, held back by the new chapter delay or left
+	// for a later run by max_chapters_per_run
+	Excluded int
+
+	Duration time.Duration
+
+	// ChapterErrors holds why each failed chapter failed, by CBZ name
+	ChapterErrors map[string]error
+
+	// RemoteChapters is how many chapters the site lists, 0 when the run did not get
+	// as far as the chapter list
+	RemoteChapters int
+}
+
+// Result turns the summary into a DownloadResult, Duration is set by RunSiteDownload
+func (s *DownloadSummary) Result(newChapters, excluded int) DownloadResult {
+	return DownloadResult{
+		NewChapters:   newChapters,
+		Succeeded:     s.Succeeded,
+		Failed:        s.Failed,
+		Skipped:       s.Skipped,
+		Excluded:      excluded,
+		ChapterErrors: s.ChapterErrors,
+	}
+}
+
+type downloadResultKey struct{}
+
+// ReportDownloadResult hands the outcome of a site download to RunSiteDownload. Does
+// nothing when the download was not started through RunSiteDownload.
+func ReportDownloadResult(ctx context.Context, result DownloadResult) {
+	if holder, ok := ctx.Value(downloadResultKey{}).(*DownloadResult); ok {
+		*holder = result
+	}
+}
+
+// RunSiteDownload runs the series' site download like ExecuteSiteDownload and also
+// returns what the run did. Sites that do not report a result (or fail before they
+// get to plan the run) leave every count at zero, Duration is always set.
+func RunSiteDownload(ctx context.Context, manga *Bookmarks, progressCallback ProgressFunc) (DownloadResult, error) {
+	var result DownloadResult
+	started := time.Now()
+	err := ExecuteSiteDownload(context.WithValue(ctx, downloadResultKey{}, &result), manga, progressCallback)
+	result.Duration = time.Since(started)
+	return result, err
+}
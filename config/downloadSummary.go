@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DownloadSummary tallies the outcome of every chapter attempted during a single
+// series download run, so the final status reflects chapters that were skipped
+type DownloadSummary struct {
+	Attempted      int
+	Succeeded      int
+	Failed         int
+	FailedChapters []string
+
+	// Skipped chapters were abandoned by the user, they are retried on the next run
+	Skipped         int
+	SkippedChapters []string
+
+	// ShortChapters were downloaded but have far fewer pages than their neighbours
+	ShortChapters []string
+
+	// ChapterErrors are the errors of the chapters recorded with FailWith
+	ChapterErrors map[string]error
+}
+
+// Success records a chapter that was downloaded and packaged
+func (s *DownloadSummary) Success(cbzName string) {
+	s.Attempted++
+	s.Succeeded++
+}
+
+// Fail records a chapter that was skipped because of an error
+func (s *DownloadSummary) Fail(cbzName string) {
+	s.Attempted++
+	s.Failed++
+	s.FailedChapters = append(s.FailedChapters, cbzName)
+}
+
+// FailWith records a failed chapter along with why it failed
+func (s *DownloadSummary) FailWith(cbzName string, err error) {
+	s.Fail(cbzName)
+	if s.ChapterErrors == nil {
+		s.ChapterErrors = make(map[string]error)
+	}
+	s.ChapterErrors[cbzName] = err
+}
+
+// Skip records a chapter the user skipped while it was downloading
+func (s *DownloadSummary) Skip(cbzName string) {
+	s.Attempted++
+	s.Skipped++
+	s.SkippedChapters = append(s.SkippedChapters, cbzName)
+}
+
+// Short flags a downloaded chapter whose page count looks incomplete
+func (s *DownloadSummary) Short(cbzName string) {
+	s.ShortChapters = append(s.ShortChapters, cbzName)
+}
+
+// Message returns the final progress message for the run
+func (s *DownloadSummary) Message() string {
+	notes := ""
+	if s.Skipped > 0 {
+		notes = fmt.Sprintf(", %d skipped", s.Skipped)
+	}
+	if len(s.ShortChapters) > 0 {
+		notes += fmt.Sprintf(", %d with suspiciously few pages", len(s.ShortChapters))
+	}
+	if s.Failed == 0 {
+		return fmt.Sprintf("Download complete! Downloaded %d chapters%s", s.Succeeded, notes)
+	}
+	return fmt.Sprintf("Download finished with errors: %d of %d chapters downloaded, %d failed%s",
+		s.Succeeded, s.Attempted, s.Failed, notes)
+}
+
+// Err returns an *IncompleteDownloadError when any chapter failed, nil otherwise
+func (s *DownloadSummary) Err() error {
+	if s.Failed == 0 {
+		return nil
+	}
+	return &IncompleteDownloadError{Summary: *s}
+}
+
+// IncompleteDownloadError is returned by a site download when the run finished but
+// one or more chapters could not be downloaded. The queue reports these tasks as
+// "completed_with_errors" rather than "completed" or "failed".
+type IncompleteDownloadError struct {
+	Summary DownloadSummary
+}
+
+func (e *IncompleteDownloadError) Error() string {
+	return fmt.Sprintf("%d of %d chapters failed: %s",
+		e.Summary.Failed, e.Summary.Attempted, strings.Join(e.Summary.FailedChapters, ", "))
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"kansho/parser"
+)
+
+// LibraryUnavailableError is returned when a bookmark's download folder cannot be
+// used, eg: it is on a drive that is not mounted or the folder is read-only. The
+// download stops before anything is fetched.
+type LibraryUnavailableError struct {
+	Location string
+	Err      error
+}
+
+func (e *LibraryUnavailableError) Error() string {
+	return fmt.Sprintf("library folder not accessible: %s: %v", e.Location, e.Err)
+}
+
+func (e *LibraryUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// CheckLibraryLocation makes sure location is a writable directory. A missing series
+// folder is created when its parent exists, a missing parent is reported instead
+// since that usually means the library drive is not mounted and creating the path
+// would write the series to the wrong disk.
+func CheckLibraryLocation(location string) error {
+	if location == "" {
+		return &LibraryUnavailableError{Location: location, Err: errors.New("no download location set")}
+	}
+
+	dir, err := parser.ExpandPath(location)
+	if err != nil {
+		return &LibraryUnavailableError{Location: location, Err: err}
+	}
+
+	info, err := os.Stat(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		parent := filepath.Dir(dir)
+		if _, parentErr := os.Stat(parent); parentErr != nil {
+			return &LibraryUnavailableError{Location: location, Err: fmt.Errorf("parent folder %s does not exist (drive not mounted?)", parent)}
+		}
+		if err := os.Mkdir(dir, 0755); err != nil {
+			return &LibraryUnavailableError{Location: location, Err: err}
+		}
+		return checkWritable(location, dir)
+	}
+	if err != nil {
+		return &LibraryUnavailableError{Location: location, Err: err}
+	}
+	if !info.IsDir() {
+		return &LibraryUnavailableError{Location: location, Err: errors.New("not a folder")}
+	}
+
+	return checkWritable(location, dir)
+}
+
+// checkWritable creates and removes a file in dir, permission bits alone do not
+// catch read-only mounts
+func checkWritable(location, dir string) error {
+	probe, err := os.CreateTemp(dir, ".kansho-write-check-*")
+	if err != nil {
+		return &LibraryUnavailableError{Location: location, Err: fmt.Errorf("folder is not writable: %w", err)}
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
@@ -0,0 +1,418 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"kansho/parser"
+)
+
+// mihonSite maps a kansho site to the web address Mihon sources for it use. Mihon
+// stores manga URLs relative to the source, so base is also what they are resolved
+// against.
+type mihonSite struct {
+	site string
+	base string
+
+	// path rewrites a Mihon manga path into the one kansho expects, nil keeps it
+	path func(string) string
+}
+
+var mihonSites = []mihonSite{
+	{site: "asurascans", base: "https://asurascans.com"},
+	{site: "mgeko", base: "https://www.mgeko.cc"},
+	{site: "stonescape", base: "https://stonescape.xyz"},
+	{site: "ravenscans", base: "https://ravenscans.org"},
+	{site: "hls", base: "https://honeylemonsoda.xyz"},
+	{site: "manhuaus", base: "https://manhuaus.com"},
+	{site: "kunmanga", base: "https://www.kunmanga.online"},
+	{site: "mangakatana", base: "https://mangakatana.com"},
+	{site: "cubari", base: "https://cubari.moe"},
+	{site: "flamecomics", base: "https://flamecomics.xyz"},
+	{site: "weebcentral", base: "https://weebcentral.com"},
+	{site: "philiascans", base: "https://philiascans.org"},
+	{site: "mangadex", base: "https://mangadex.org", path: func(p string) string {
+		// The MangaDex extension stores "/manga/<id>", the site page is "/title/<id>"
+		if id, ok := strings.CutPrefix(p, "/manga/"); ok {
+			return "/title/" + id
+		}
+		return p
+	}},
+}
+
+// MihonImportReport describes what ImportMihonBackupWithReport did with each entry
+// of a backup
+type MihonImportReport struct {
+	Total       int      // library entries in the backup
+	Unsupported []string // "Title (Source)" of entries whose source kansho has no site for
+}
+
+// mihonEntry is one library entry read from a backup, whatever its format
+type mihonEntry struct {
+	title  string
+	url    string
+	source string // source name, "" if the backup does not list it
+}
+
+// ImportMihonBackup reads a Tachiyomi/Mihon backup and returns a bookmark for every
+// library entry whose source maps to a kansho site, see ImportMihonBackupWithReport
+func ImportMihonBackup(path, libraryRoot string) ([]Bookmarks, error) {
+	bookmarks, _, err := ImportMihonBackupWithReport(path, libraryRoot)
+	return bookmarks, err
+}
+
+// ImportMihonBackupWithReport reads a Tachiyomi/Mihon backup (.tachibk/.proto.gz
+// protobuf, or the older JSON backups, gzipped or not) and maps the library to kansho
+// bookmarks. Sources are matched by the hostname of the manga URL, or by source name
+// for the relative URLs Mihon normally stores. Each bookmark downloads into
+// "<libraryRoot>/<Title>", the directories are not created here.
+//
+// Entries from unsupported sources are skipped and listed in the report. Manga that
+// are only in the backup for their reading history (not in the library) are ignored.
+func ImportMihonBackupWithReport(path, libraryRoot string) ([]Bookmarks, MihonImportReport, error) {
+	var report MihonImportReport
+
+	if strings.TrimSpace(libraryRoot) == "" {
+		return nil, report, fmt.Errorf("no library directory given")
+	}
+	root, err := parser.ExpandPath(libraryRoot)
+	if err != nil {
+		return nil, report, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, report, fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	entries, err := decodeMihonBackup(data)
+	if err != nil {
+		return nil, report, fmt.Errorf("failed to read backup %s: %w", filepath.Base(path), err)
+	}
+	report.Total = len(entries)
+
+	var bookmarks []Bookmarks
+	for _, entry := range entries {
+		site, mangaURL, ok := mihonSiteFor(entry)
+		if !ok {
+			source := entry.source
+			if source == "" {
+				source = "unknown source"
+			}
+			log.Printf("[Import] Skipping %q: no kansho site for source %s (%s)", entry.title, source, entry.url)
+			report.Unsupported = append(report.Unsupported, fmt.Sprintf("%s (%s)", entry.title, source))
+			continue
+		}
+
+		title := strings.TrimSpace(entry.title)
+		folder := deviceTitle(title)
+		if folder == "" {
+			folder = site
+		}
+		bookmarks = append(bookmarks, Bookmarks{
+			Title:    title,
+			Url:      mangaURL,
+			Site:     site,
+			Location: filepath.Join(root, folder),
+		})
+	}
+
+	log.Printf("[Import] Mihon backup %s: %d of %d series mapped, %d unsupported",
+		filepath.Base(path), len(bookmarks), report.Total, len(report.Unsupported))
+	return bookmarks, report, nil
+}
+
+// mihonSiteFor returns the kansho site and absolute manga URL for a backup entry
+func mihonSiteFor(entry mihonEntry) (string, string, bool) {
+	u, err := url.Parse(strings.TrimSpace(entry.url))
+	if err != nil {
+		return "", "", false
+	}
+
+	for _, candidate := range mihonSites {
+		base, _ := url.Parse(candidate.base)
+
+		if u.Host != "" {
+			if !sameSiteHost(u.Hostname(), base.Hostname()) {
+				continue
+			}
+		} else if !sameSiteSource(entry.source, candidate.site, base.Hostname()) {
+			continue
+		}
+
+		resolved := *base
+		resolved.Path = u.Path
+		if !strings.HasPrefix(resolved.Path, "/") {
+			resolved.Path = "/" + resolved.Path
+		}
+		if candidate.path != nil {
+			resolved.Path = candidate.path(resolved.Path)
+		}
+		resolved.RawQuery = u.RawQuery
+		return candidate.site, resolved.String(), true
+	}
+	return "", "", false
+}
+
+// sameSiteHost reports whether host belongs to the site at siteHost, ignoring "www."
+// and subdomains
+func sameSiteHost(host, siteHost string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	siteHost = strings.TrimPrefix(strings.ToLower(siteHost), "www.")
+	return host == siteHost || strings.HasSuffix(host, "."+siteHost)
+}
+
+// sameSiteSource reports whether a Mihon source name is the site, eg: "Asura Scans"
+// is asurascans and "Kun Manga" is kunmanga.online
+func sameSiteSource(source, site, siteHost string) bool {
+	key := letterKey(source)
+	return key != "" && (key == site || key == hostKey(siteHost))
+}
+
+// hostKey returns the name part of a hostname, eg: "www.kunmanga.online" -> "kunmanga"
+func hostKey(host string) string {
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return letterKey(host)
+	}
+	return letterKey(labels[len(labels)-2])
+}
+
+// letterKey lowercases s and keeps only letters and digits, eg: "Asura Scans" -> "asurascans"
+func letterKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// decodeMihonBackup detects the backup format and returns its library entries
+func decodeMihonBackup(data []byte) ([]mihonEntry, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		data, err = io.ReadAll(gz)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress backup: %w", err)
+		}
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return decodeMihonJSON(trimmed)
+	}
+	return decodeMihonProto(data)
+}
+
+// Field numbers of the Mihon backup protobuf (Backup, BackupManga, BackupSource)
+const (
+	mihonBackupManga   = 1
+	mihonBackupSources = 101
+
+	mihonMangaSource   = 1
+	mihonMangaURL      = 2
+	mihonMangaTitle    = 3
+	mihonMangaFavorite = 100
+
+	mihonSourceName = 1
+	mihonSourceID   = 2
+)
+
+// decodeMihonProto reads the fields kansho needs from a protobuf backup
+func decodeMihonProto(data []byte) ([]mihonEntry, error) {
+	type protoManga struct {
+		source   uint64
+		entry    mihonEntry
+		favorite bool
+	}
+
+	var mangas []protoManga
+	sources := make(map[uint64]string)
+
+	err := walkProto(data, func(field int, value uint64, raw []byte) error {
+		switch field {
+		case mihonBackupManga:
+			manga := protoManga{favorite: true}
+			err := walkProto(raw, func(field int, value uint64, raw []byte) error {
+				switch field {
+				case mihonMangaSource:
+					manga.source = value
+				case mihonMangaURL:
+					manga.entry.url = string(raw)
+				case mihonMangaTitle:
+					manga.entry.title = string(raw)
+				case mihonMangaFavorite:
+					manga.favorite = value != 0
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("manga entry %d: %w", len(mangas), err)
+			}
+			mangas = append(mangas, manga)
+
+		case mihonBackupSources:
+			var name string
+			var id uint64
+			err := walkProto(raw, func(field int, value uint64, raw []byte) error {
+				switch field {
+				case mihonSourceName:
+					name = string(raw)
+				case mihonSourceID:
+					id = value
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("source entry: %w", err)
+			}
+			sources[id] = name
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("not a Mihon backup: %w", err)
+	}
+
+	var entries []mihonEntry
+	for _, manga := range mangas {
+		if !manga.favorite {
+			continue
+		}
+		manga.entry.source = sources[manga.source]
+		entries = append(entries, manga.entry)
+	}
+	return entries, nil
+}
+
+// walkProto calls fn for every field of a protobuf message. Varint and fixed width
+// values are passed as value, length delimited ones (strings, nested messages) as raw.
+func walkProto(data []byte, fn func(field int, value uint64, raw []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("invalid field key")
+		}
+		data = data[n:]
+		field := int(key >> 3)
+
+		var value uint64
+		var raw []byte
+		switch key & 7 {
+		case 0: // varint
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid varint in field %d", field)
+			}
+			data = data[n:]
+		case 1: // 64-bit
+			if len(data) < 8 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			value = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case 2: // length delimited
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			raw = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case 5: // 32-bit
+			if len(data) < 4 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			value = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d in field %d", key&7, field)
+		}
+
+		if err := fn(field, value, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeMihonJSON reads a JSON backup: the Mihon protobuf layout serialized as JSON,
+// or the legacy Tachiyomi v2 backup ("mangas" plus "extensions")
+func decodeMihonJSON(data []byte) ([]mihonEntry, error) {
+	var backup struct {
+		BackupManga []struct {
+			Source   json.Number `json:"source"`
+			URL      string      `json:"url"`
+			Title    string      `json:"title"`
+			Favorite *bool       `json:"favorite"`
+		} `json:"backupManga"`
+		BackupSources []struct {
+			Name     string      `json:"name"`
+			SourceID json.Number `json:"sourceId"`
+		} `json:"backupSources"`
+
+		// Legacy Tachiyomi: "manga" is [url, title, source, viewer, chapterFlags] and
+		// "extensions" are "<source id>:<name>"
+		Mangas []struct {
+			Manga []json.RawMessage `json:"manga"`
+		} `json:"mangas"`
+		Extensions []string `json:"extensions"`
+	}
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("invalid JSON backup: %w", err)
+	}
+
+	sources := make(map[string]string)
+	for _, source := range backup.BackupSources {
+		sources[source.SourceID.String()] = source.Name
+	}
+	for _, extension := range backup.Extensions {
+		if id, name, ok := strings.Cut(extension, ":"); ok {
+			sources[id] = name
+		}
+	}
+
+	var entries []mihonEntry
+	for _, manga := range backup.BackupManga {
+		if manga.Favorite != nil && !*manga.Favorite {
+			continue
+		}
+		entries = append(entries, mihonEntry{
+			title:  manga.Title,
+			url:    manga.URL,
+			source: sources[manga.Source.String()],
+		})
+	}
+
+	for i, legacy := range backup.Mangas {
+		if len(legacy.Manga) < 3 {
+			return nil, fmt.Errorf("legacy manga entry %d is incomplete", i)
+		}
+		var entry mihonEntry
+		var source json.Number
+		if json.Unmarshal(legacy.Manga[0], &entry.url) != nil ||
+			json.Unmarshal(legacy.Manga[1], &entry.title) != nil ||
+			json.Unmarshal(legacy.Manga[2], &source) != nil {
+			return nil, fmt.Errorf("legacy manga entry %d is malformed", i)
+		}
+		entry.source = sources[source.String()]
+		entries = append(entries, entry)
+	}
+
+	if len(backup.BackupManga) == 0 && len(backup.Mangas) == 0 && len(backup.BackupSources) == 0 {
+		return nil, fmt.Errorf("no library found in JSON backup")
+	}
+	return entries, nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kansho/parser"
+)
+
+// MirrorChapter copies a newly created chapter cbz in the series Location to every
+// MirrorLocations folder of manga, creating the folders as needed. Mirroring is best
+// effort: a mirror that fails is logged and skipped, the chapter in Location stays
+// downloaded. The returned error joins one error per failed mirror.
+func MirrorChapter(manga *Bookmarks, cbzPath string) error {
+	if manga == nil || len(manga.MirrorLocations) == 0 {
+		return nil
+	}
+
+	cbzName := filepath.Base(cbzPath)
+	var errs []error
+	for _, mirror := range manga.MirrorLocations {
+		mirror = strings.TrimSpace(mirror)
+		if mirror == "" {
+			continue
+		}
+		if err := mirrorChapterTo(mirror, cbzPath); err != nil {
+			log.Printf("[Mirror] ⚠️ %s: failed to copy %s to %s: %v", manga.Title, cbzName, mirror, err)
+			errs = append(errs, fmt.Errorf("%s: %w", mirror, err))
+			continue
+		}
+		log.Printf("[Mirror] ✓ %s: copied %s to %s", manga.Title, cbzName, mirror)
+	}
+	return errors.Join(errs...)
+}
+
+// mirrorChapterTo copies cbzPath into the mirror folder under the same name
+func mirrorChapterTo(mirror, cbzPath string) error {
+	dir, err := parser.ExpandPath(mirror)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	dst := filepath.Join(dir, filepath.Base(cbzPath))
+	if sameFile(cbzPath, dst) {
+		// The mirror is the series folder itself
+		return nil
+	}
+	return copyFile(cbzPath, dst)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"kansho/parser"
+)
+
+// FixSeriesPageOrder checks every chapter of a bookmarked series with
+// parser.FixCbzPageOrder and rewrites the ones whose pages would be read out of
+// order, eg: chapters downloaded before page names were zero-padded. Each rewritten
+// chapter keeps its original as a ".bak" file next to it. Returns the chapters that
+// were fixed; a chapter that fails does not stop the rest, the returned error joins
+// one error per failed chapter.
+func FixSeriesPageOrder(manga *Bookmarks) ([]string, error) {
+	if manga == nil || manga.Location == "" {
+		return nil, fmt.Errorf("series has no download location")
+	}
+
+	location, err := parser.ExpandPath(manga.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	chapters, err := parser.LocalChapterList(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chapters of %s: %w", manga.Title, err)
+	}
+
+	var fixed []string
+	var errs []error
+	for _, chapter := range chapters {
+		ok, err := parser.FixCbzPageOrder(filepath.Join(location, chapter))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", chapter, err))
+			continue
+		}
+		if ok {
+			log.Printf("[PageOrder] %s: fixed page order of %s", manga.Title, chapter)
+			fixed = append(fixed, chapter)
+		}
+	}
+
+	log.Printf("[PageOrder] %s: %d of %d chapters needed fixing", manga.Title, len(fixed), len(chapters))
+	return fixed, errors.Join(errs...)
+}
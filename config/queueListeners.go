@@ -0,0 +1,126 @@
+package config
+
+// QueueListener receives download queue events. Any nil field is skipped.
+// Callbacks run on the goroutine that changed the task, UI code must hop onto
+// the UI thread itself (eg: fyne.Do). OnTaskAdded and OnTaskUpdated get a copy
+// of the task taken under the queue lock, it is safe to read and keep but does
+// not follow later changes; callbacks run with the lock released.
+type QueueListener struct {
+	OnTaskAdded   func(*DownloadTask)
+	OnTaskUpdated func(*DownloadTask)
+	OnTaskRemoved func(string)
+	OnQueueEmpty  func()
+}
+
+// Subscribe registers a listener for queue events alongside the SetCallbacks
+// slot. Tasks keep running when nobody is listening, so a view that is closed
+// and reopened rebuilds itself from GetTaskSnapshots and subscribes again.
+// The returned func removes the listener.
+func (q *DownloadQueue) Subscribe(listener QueueListener) (unsubscribe func()) {
+	q.listenersMu.Lock()
+	defer q.listenersMu.Unlock()
+
+	if q.listeners == nil {
+		q.listeners = make(map[int]QueueListener)
+	}
+	id := q.nextListenerID
+	q.nextListenerID++
+	q.listeners[id] = listener
+
+	return func() {
+		q.listenersMu.Lock()
+		defer q.listenersMu.Unlock()
+		delete(q.listeners, id)
+	}
+}
+
+// currentListeners returns the SetCallbacks slot followed by every subscriber
+func (q *DownloadQueue) currentListeners() []QueueListener {
+	q.listenersMu.RLock()
+	defer q.listenersMu.RUnlock()
+
+	all := make([]QueueListener, 0, len(q.listeners)+1)
+	all = append(all, q.callbacks)
+	for _, l := range q.listeners {
+		all = append(all, l)
+	}
+	return all
+}
+
+// notifyTaskAdded passes each listener its own copy of snapshot, which the caller
+// took while holding q.mu. Caller must not hold q.mu.
+func (q *DownloadQueue) notifyTaskAdded(snapshot DownloadTask) {
+	for _, l := range q.currentListeners() {
+		if l.OnTaskAdded != nil {
+			task := snapshot
+			l.OnTaskAdded(&task)
+		}
+	}
+}
+
+// notifyTaskUpdated is notifyTaskAdded for OnTaskUpdated
+func (q *DownloadQueue) notifyTaskUpdated(snapshot DownloadTask) {
+	for _, l := range q.currentListeners() {
+		if l.OnTaskUpdated != nil {
+			task := snapshot
+			l.OnTaskUpdated(&task)
+		}
+	}
+}
+
+func (q *DownloadQueue) notifyTaskRemoved(id string) {
+	for _, l := range q.currentListeners() {
+		if l.OnTaskRemoved != nil {
+			l.OnTaskRemoved(id)
+		}
+	}
+}
+
+func (q *DownloadQueue) notifyQueueEmpty() {
+	for _, l := range q.currentListeners() {
+		if l.OnQueueEmpty != nil {
+			l.OnQueueEmpty()
+		}
+	}
+}
+
+// GetTaskSnapshots returns a copy of every task's current state, taken under the
+// queue lock so progress, status and message are consistent with each other.
+// Views use this to rebuild their progress bars when opened.
+func (q *DownloadQueue) GetTaskSnapshots() []DownloadTask {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	snapshots := make([]DownloadTask, len(q.tasks))
+	for i, task := range q.tasks {
+		snapshots[i] = *task
+	}
+	return snapshots
+}
+
+// GetTaskSnapshot returns a copy of a single task's current state
+func (q *DownloadQueue) GetTaskSnapshot(id string) (DownloadTask, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for _, task := range q.tasks {
+		if task.ID == id {
+			return *task, true
+		}
+	}
+	return DownloadTask{}, false
+}
+
+// TaskSnapshotForManga returns a copy of the task queued for the series with the
+// given title, the queue holds at most one task per title
+func (q *DownloadQueue) TaskSnapshotForManga(title string) (DownloadTask, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for _, task := range q.tasks {
+		if task.Manga.Title == title {
+			return *task, true
+		}
+	}
+	return DownloadTask{}, false
+}
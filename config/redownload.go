@@ -0,0 +1,30 @@
+package config
+
+import "context"
+
+type redownloadKey struct{}
+
+// WithRedownload returns a context whose download fetches the given chapters (cbz
+// names) again even though they are in the library, overwriting their CBZ files.
+// Without chapters every local chapter still listed by the site is downloaded again.
+func WithRedownload(ctx context.Context, chapters ...string) context.Context {
+	set := make(map[string]bool, len(chapters))
+	for _, chapter := range chapters {
+		set[chapter] = true
+	}
+	return context.WithValue(ctx, redownloadKey{}, set)
+}
+
+// RedownloadRequested reports whether ctx carries a WithRedownload request, for
+// shortcuts that would skip the download before the chapters are looked at
+func RedownloadRequested(ctx context.Context) bool {
+	_, ok := ctx.Value(redownloadKey{}).(map[string]bool)
+	return ok
+}
+
+// Redownload reports whether the download in ctx fetches cbzName again when the
+// library already has it
+func Redownload(ctx context.Context, cbzName string) bool {
+	set, ok := ctx.Value(redownloadKey{}).(map[string]bool)
+	return ok && (len(set) == 0 || set[cbzName])
+}
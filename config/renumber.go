@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"kansho/parser"
+)
+
+const (
+	// renumberMinLocal is how many chapters a series needs on disk before its chapter
+	// list is checked for renumbering, fewer say nothing about the numbering
+	renumberMinLocal = 3
+
+	// renumberMinOverlap is the share of local chapters the site must still list
+	// under the same names or numbers, below it the run would download the series again
+	renumberMinOverlap = 0.5
+
+	// renumberShiftMatch is the share of local chapters that must line up with the
+	// site's list once its numbers are moved up by one to call it a shift
+	renumberShiftMatch = 0.9
+)
+
+// RenumberSuspectedError stops a download whose chapter list no longer lines up with
+// the chapters on disk, see DetectRenumber. The queue holds these tasks as
+// "waiting_confirm" until the user confirms the download.
+type RenumberSuspectedError struct {
+	Local   int // chapters on disk
+	Matched int // of those, listed by the site under the same name or number
+	New     int // chapters the run would download
+}
+
+func (e *RenumberSuspectedError) Error() string {
+	return fmt.Sprintf("chapter numbering looks shifted: only %d of %d local chapters match the site, %d would be downloaded",
+		e.Matched, e.Local, e.New)
+}
+
+// DetectRenumber reports whether the remote chapter list looks renumbered against the
+// local chapters (both cbz filenames), eg: the site inserted a "chapter 0" prologue.
+// Local chapters are matched by name or chapter number (parser.MatchLocalChapters),
+// names of an earlier chapter filename template still count as listed.
+// It is suspicious when less than half of the local chapters are still listed, or when
+// the remote numbers are the local ones shifted down by one: a new chapter below every
+// local one while the highest local chapter is gone. A series that only gained or lost
+// chapters at the end is never flagged.
+func DetectRenumber(local, remote []string) bool {
+	if len(local) < renumberMinLocal || len(remote) == 0 {
+		return false
+	}
+
+	if float64(len(parser.MatchLocalChapters(local, remote))) < renumberMinOverlap*float64(len(local)) {
+		return true
+	}
+
+	localNums := chapterNumbers(local)
+	remoteNums := chapterNumbers(remote)
+	if len(localNums) < renumberMinLocal || len(remoteNums) == 0 {
+		return false
+	}
+
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for num := range localNums {
+		lowest = math.Min(lowest, num)
+		highest = math.Max(highest, num)
+	}
+	newBelow := false
+	for num := range remoteNums {
+		if num < lowest && !localNums[num] {
+			newBelow = true
+			break
+		}
+	}
+	if !newBelow || remoteNums[highest] {
+		return false
+	}
+
+	shifted := 0
+	for num := range localNums {
+		if remoteNums[num-1] {
+			shifted++
+		}
+	}
+	return float64(shifted) >= renumberShiftMatch*float64(len(localNums))
+}
+
+// CheckRenumber returns a *RenumberSuspectedError when DetectRenumber flags the
+// remote chapter list, unless the user already confirmed the download in ctx
+func CheckRenumber(ctx context.Context, local, remote []string) error {
+	if RenumberConfirmed(ctx) || !DetectRenumber(local, remote) {
+		return nil
+	}
+
+	matched := len(parser.MatchLocalChapters(local, remote))
+	return &RenumberSuspectedError{Local: len(local), Matched: matched, New: len(remote) - matched}
+}
+
+type renumberConfirmedKey struct{}
+
+// WithRenumberConfirmed returns a context whose download skips the renumber check,
+// after the user confirmed it
+func WithRenumberConfirmed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, renumberConfirmedKey{}, true)
+}
+
+// RenumberConfirmed reports whether the download in ctx was confirmed by the user
+func RenumberConfirmed(ctx context.Context) bool {
+	confirmed, _ := ctx.Value(renumberConfirmedKey{}).(bool)
+	return confirmed
+}
+
+// chapterNumbers returns the set of chapter numbers in names, names without one are
+// left out
+func chapterNumbers(names []string) map[float64]bool {
+	nums := make(map[float64]bool, len(names))
+	for _, name := range names {
+		if num, ok := parser.ChapterSortValue(name); ok {
+			nums[num] = true
+		}
+	}
+	return nums
+}
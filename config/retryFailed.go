@@ -0,0 +1,18 @@
+package config
+
+import "context"
+
+type retryFailedKey struct{}
+
+// WithRetryFailedOnly returns a context whose download only fetches the chapters
+// recorded as failed by earlier runs (see parser.FailedChapters) instead of checking
+// the site's whole chapter list
+func WithRetryFailedOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryFailedKey{}, true)
+}
+
+// RetryFailedOnly reports whether the download in ctx only retries failed chapters
+func RetryFailedOnly(ctx context.Context) bool {
+	retry, _ := ctx.Value(retryFailedKey{}).(bool)
+	return retry
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RunLogCapacity is how many lines a task's run log keeps, older lines are evicted
+const RunLogCapacity = 500
+
+// RunLogLine is one message of a download run
+type RunLogLine struct {
+	Time    time.Time
+	Message string
+}
+
+// String formats the line the way the run log panel shows it
+func (l RunLogLine) String() string {
+	return l.Time.Format("15:04:05") + "  " + l.Message
+}
+
+// RunLog is an in-memory ring buffer of the messages of one download run. The queue
+// gives every run its own, so a view can follow a single series without reading
+// the global log file.
+type RunLog struct {
+	mu          sync.Mutex
+	lines       []RunLogLine // ring, next is where the next line goes once it is full
+	next        int
+	capacity    int
+	subscribers map[int]func(RunLogLine)
+	nextID      int
+}
+
+// NewRunLog creates a run log keeping the last capacity lines, values below 1 use
+// RunLogCapacity
+func NewRunLog(capacity int) *RunLog {
+	if capacity < 1 {
+		capacity = RunLogCapacity
+	}
+	return &RunLog{capacity: capacity}
+}
+
+// Add appends a message, evicting the oldest line when the log is full, and passes
+// it to every subscriber
+func (r *RunLog) Add(message string) {
+	line := RunLogLine{Time: time.Now(), Message: message}
+
+	r.mu.Lock()
+	if len(r.lines) < r.capacity {
+		r.lines = append(r.lines, line)
+	} else {
+		r.lines[r.next] = line
+		r.next = (r.next + 1) % r.capacity
+	}
+	subscribers := make([]func(RunLogLine), 0, len(r.subscribers))
+	for _, fn := range r.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	r.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(line)
+	}
+}
+
+// Printf adds a formatted message
+func (r *RunLog) Printf(format string, args ...any) {
+	r.Add(fmt.Sprintf(format, args...))
+}
+
+// Lines returns the kept lines, oldest first
+func (r *RunLog) Lines() []RunLogLine {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lines := make([]RunLogLine, 0, len(r.lines))
+	lines = append(lines, r.lines[r.next:]...)
+	return append(lines, r.lines[:r.next]...)
+}
+
+// Subscribe calls fn with every line added from now on, on the goroutine adding
+// it. Current lines are not replayed, read them with Lines first. The returned
+// func removes the subscriber.
+func (r *RunLog) Subscribe(fn func(RunLogLine)) (unsubscribe func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.subscribers == nil {
+		r.subscribers = make(map[int]func(RunLogLine))
+	}
+	id := r.nextID
+	r.nextID++
+	r.subscribers[id] = fn
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subscribers, id)
+	}
+}
+
+type runLogKey struct{}
+
+// withRunLog returns a context whose download writes its run messages to runLog
+func withRunLog(ctx context.Context, runLog *RunLog) context.Context {
+	return context.WithValue(ctx, runLogKey{}, runLog)
+}
+
+// RunLogf adds a message to the run log of the queued download running with ctx.
+// Without one (eg: downloads started outside the queue) it does nothing, the
+// caller still logs globally as usual.
+func RunLogf(ctx context.Context, format string, args ...any) {
+	if runLog, _ := ctx.Value(runLogKey{}).(*RunLog); runLog != nil {
+		runLog.Printf(format, args...)
+	}
+}
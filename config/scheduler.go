@@ -0,0 +1,153 @@
+package config
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// schedulerTick is how often the update scheduler looks for series that are due
+const schedulerTick = time.Minute
+
+// CheckInterval returns how often manga is checked for new chapters automatically:
+// its own check_interval_hours, or the auto_check_interval_hours setting when it has
+// none. 0 means the series is never checked automatically.
+func CheckInterval(manga *Bookmarks, settings Settings) time.Duration {
+	hours := manga.CheckIntervalHours
+	if hours == 0 {
+		hours = settings.AutoCheckIntervalHours
+	}
+	if hours < 1 {
+		return 0
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// UpdateScheduler queues bookmarked series for download once their check interval
+// has passed since their last run, so a kansho left running picks up new chapters
+// on its own. The last run comes from the download history, it survives restarts.
+type UpdateScheduler struct {
+	queue *DownloadQueue
+
+	mu     sync.Mutex
+	stop   chan struct{}
+	queued map[string]time.Time // url -> when the scheduler last queued the series
+}
+
+// NewUpdateScheduler returns a scheduler adding its downloads to queue, see Start
+func NewUpdateScheduler(queue *DownloadQueue) *UpdateScheduler {
+	return &UpdateScheduler{queue: queue, queued: make(map[string]time.Time)}
+}
+
+// Start checks for due series in the background every minute until Stop. The
+// settings are read on every check, so changing the interval needs no restart.
+// Starting a running scheduler does nothing.
+func (s *UpdateScheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	go s.run(s.stop)
+	log.Printf("[Scheduler] Started")
+}
+
+// Stop ends the background checks, downloads already queued keep going
+func (s *UpdateScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	s.stop = nil
+	log.Printf("[Scheduler] Stopped")
+}
+
+func (s *UpdateScheduler) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	s.QueueDue(time.Now())
+	for {
+		select {
+		case <-ticker.C:
+			s.QueueDue(time.Now())
+		case <-stop:
+			return
+		}
+	}
+}
+
+// DueSeries returns the bookmarks to check at now: those with a check interval
+// whose last run, or last queueing by the scheduler, is at least that long ago.
+// A series that never ran is due straight away. Completed series are left out.
+func (s *UpdateScheduler) DueSeries(mangas []Bookmarks, now time.Time) []*Bookmarks {
+	settings := LoadSettings()
+
+	var lastRuns map[string]time.Time
+	var due []*Bookmarks
+	for i := range mangas {
+		manga := &mangas[i]
+		interval := CheckInterval(manga, settings)
+		if interval == 0 || manga.Completed {
+			continue
+		}
+
+		if lastRuns == nil {
+			lastRuns = latestRuns()
+		}
+		last := lastRuns[manga.Url]
+		s.mu.Lock()
+		if queued := s.queued[manga.Url]; queued.After(last) {
+			last = queued
+		}
+		s.mu.Unlock()
+
+		if now.Sub(last) >= interval {
+			due = append(due, manga)
+		}
+	}
+	return due
+}
+
+// QueueDue adds every bookmark DueSeries returns to the download queue the way
+// Recheck All does. A finished task left in the queue for the series is removed
+// first, one still queued or downloading is left alone. Returns how many were queued.
+func (s *UpdateScheduler) QueueDue(now time.Time) int {
+	due := s.DueSeries(LoadBookmarks().Manga, now)
+	if len(due) == 0 {
+		return 0
+	}
+
+	for _, manga := range due {
+		if task, ok := s.queue.TaskSnapshotForManga(manga.Title); ok {
+			// Fails for a task that has not finished, queueBulk then skips the series
+			s.queue.RemoveFinishedTask(task.ID)
+		}
+		s.mu.Lock()
+		s.queued[manga.Url] = now
+		s.mu.Unlock()
+	}
+
+	queued, failed := s.queue.queueBulk(due, "[Scheduler]")
+	log.Printf("[Scheduler] Queued %d due series, %d not queued", queued, failed)
+	return queued
+}
+
+// latestRuns returns when each series last started a download run, by url
+func latestRuns() map[string]time.Time {
+	runs, err := DefaultStore().RunHistory("", 0)
+	if err != nil {
+		log.Printf("[Scheduler] ⚠️ Failed to read the download history: %v", err)
+	}
+
+	latest := make(map[string]time.Time)
+	for _, run := range runs {
+		if run.Started.After(latest[run.URL]) {
+			latest[run.URL] = run.Started
+		}
+	}
+	return latest
+}
@@ -0,0 +1,305 @@
+package config
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kansho/parser"
+)
+
+// BundleManifestName is the manifest written into every series bundle
+const BundleManifestName = "manifest.json"
+
+// coverNames are the cover image names looked for in a series folder, the same
+// names Komga and Kavita pick up
+var coverNames = []string{"cover", "folder", "poster"}
+
+// bundleImageExts are the page formats copied into a combined bundle
+var bundleImageExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+	".avif": true,
+	".gif":  true,
+}
+
+// BundleManifest describes the contents of a series bundle
+type BundleManifest struct {
+	Title    string          `json:"title"`
+	Site     string          `json:"site"`
+	URL      string          `json:"url"`
+	Combined bool            `json:"combined"`
+	Cover    string          `json:"cover,omitempty"`
+	Chapters []BundleChapter `json:"chapters"`
+	Created  time.Time       `json:"created"`
+}
+
+// BundleChapter is one chapter of a bundle. File is the chapter cbz in a per-chapter
+// bundle; in a combined bundle FirstPage is the first page of the chapter.
+type BundleChapter struct {
+	Chapter   string `json:"chapter"`
+	File      string `json:"file,omitempty"`
+	FirstPage string `json:"first_page,omitempty"`
+	Pages     int    `json:"pages"`
+}
+
+// ExportSeriesBundle writes a whole series into the single archive dst for sharing:
+// the cover, every chapter and a manifest.json. The cover is a cover/folder/poster
+// image in the series folder, or the first page of the first chapter.
+//
+// Without combined the archive holds the chapter cbz files as they are. With combined
+// dst is one big cbz: the pages of every chapter in chapter then page order, renumbered
+// 00000, 00001... with the cover first.
+func ExportSeriesBundle(manga *Bookmarks, dst string, combined bool) error {
+	if manga == nil || manga.Location == "" {
+		return fmt.Errorf("series has no download location")
+	}
+
+	location, err := parser.ExpandPath(manga.Location)
+	if err != nil {
+		return err
+	}
+	dst, err = parser.ExpandPath(dst)
+	if err != nil {
+		return err
+	}
+
+	chapters, err := parser.LocalChapterList(location)
+	if err != nil {
+		return fmt.Errorf("failed to list chapters of %s: %w", manga.Title, err)
+	}
+	if len(chapters) == 0 {
+		return fmt.Errorf("%s has no downloaded chapters", manga.Title)
+	}
+	parser.SortChaptersNumeric(chapters)
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".kansho-bundle-*")
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	manifest := BundleManifest{
+		Title:    manga.Title,
+		Site:     manga.Site,
+		URL:      manga.Url,
+		Combined: combined,
+		Created:  time.Now().UTC(),
+	}
+
+	zw := zip.NewWriter(tmp)
+	err = writeSeriesBundle(zw, location, chapters, &manifest)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to bundle %s: %w", manga.Title, err)
+	}
+
+	os.Chmod(tmpName, 0644)
+	if err := os.Rename(tmpName, dst); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	log.Printf("[Export] %s: bundled %d chapters into %s (combined: %v)", manga.Title, len(chapters), dst, combined)
+	return nil
+}
+
+// BundleFileName returns the default file name for a bundle of manga, a .cbz when
+// combined since readers open it as one long chapter, otherwise a .zip
+func BundleFileName(manga *Bookmarks, combined bool) string {
+	title := deviceTitle(manga.Title)
+	if title == "" {
+		title = "series"
+	}
+	if combined {
+		return title + ".cbz"
+	}
+	return title + ".zip"
+}
+
+// writeSeriesBundle adds the cover, chapters and manifest to zw
+func writeSeriesBundle(zw *zip.Writer, location string, chapters []string, manifest *BundleManifest) error {
+	cover, coverExt, err := seriesCover(location, chapters[0])
+	if err != nil {
+		return err
+	}
+
+	page := 0
+	if cover != nil {
+		manifest.Cover = "cover" + coverExt
+		if manifest.Combined {
+			manifest.Cover = fmt.Sprintf("%05d%s", page, coverExt)
+			page++
+		}
+		if err := writeBundleEntry(zw, manifest.Cover, cover, zip.Store); err != nil {
+			return err
+		}
+	}
+
+	for _, chapter := range chapters {
+		src := filepath.Join(location, chapter)
+		label := strings.TrimSuffix(chapter, filepath.Ext(chapter))
+
+		if !manifest.Combined {
+			pages, err := parser.CbzPageCount(src)
+			if err != nil {
+				return fmt.Errorf("%s: %w", chapter, err)
+			}
+			if err := copyBundleFile(zw, chapter, src); err != nil {
+				return fmt.Errorf("%s: %w", chapter, err)
+			}
+			manifest.Chapters = append(manifest.Chapters, BundleChapter{Chapter: label, File: chapter, Pages: pages})
+			continue
+		}
+
+		entry := BundleChapter{Chapter: label}
+		err := forEachCbzPage(src, func(name string, r io.Reader) error {
+			pageName := fmt.Sprintf("%05d%s", page, strings.ToLower(path.Ext(name)))
+			if entry.Pages == 0 {
+				entry.FirstPage = pageName
+			}
+			page++
+			entry.Pages++
+
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: pageName, Method: zip.Store, Modified: time.Now()})
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, r)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", chapter, err)
+		}
+		manifest.Chapters = append(manifest.Chapters, entry)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeBundleEntry(zw, BundleManifestName, data, zip.Deflate)
+}
+
+// seriesCover returns the cover image of the series: a cover image file in the
+// series folder, or else the first page of firstChapter. Returns nil when the
+// chapter has no pages either.
+func seriesCover(location, firstChapter string) ([]byte, string, error) {
+	entries, err := os.ReadDir(location)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, name := range coverNames {
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			stem := strings.ToLower(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+			if entry.IsDir() || stem != name || !bundleImageExts[ext] {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(location, entry.Name()))
+			return data, ext, err
+		}
+	}
+
+	var cover []byte
+	var coverExt string
+	errFound := fmt.Errorf("cover found")
+	err = forEachCbzPage(filepath.Join(location, firstChapter), func(name string, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		cover, coverExt = data, strings.ToLower(path.Ext(name))
+		return errFound
+	})
+	if err != nil && err != errFound {
+		return nil, "", fmt.Errorf("failed to read cover from %s: %w", firstChapter, err)
+	}
+	return cover, coverExt, nil
+}
+
+// forEachCbzPage calls fn with every image page of the cbz at cbzPath in reading
+// order, metadata such as ComicInfo.xml is skipped. Stops at the first error fn
+// returns.
+func forEachCbzPage(cbzPath string, fn func(name string, r io.Reader) error) error {
+	zr, err := zip.OpenReader(cbzPath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File)
+	var names []string
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !bundleImageExts[strings.ToLower(path.Ext(f.Name))] {
+			continue
+		}
+		files[f.Name] = f
+		names = append(names, f.Name)
+	}
+	parser.SortPageFiles(names)
+
+	for _, name := range names {
+		r, err := files[name].Open()
+		if err != nil {
+			return err
+		}
+		err = fn(name, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBundleEntry adds data to zw as name
+func writeBundleEntry(zw *zip.Writer, name string, data []byte, method uint16) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// copyBundleFile adds the file at src to zw as name, stored since cbz files are
+// already compressed
+func copyBundleFile(zw *zip.Writer, name, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: info.ModTime()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"kansho/parser"
+)
+
+// ExportSeriesToDevice copies every cbz of a bookmarked series into destDir so the
+// folder can be sideloaded onto an e-reader as is. When rename is true the copies are
+// named "<Title> <chapter>.cbz" (eg: "ch012.cbz" -> "Solo Leveling 012.cbz"), otherwise
+// the original filenames are kept. Existing files in destDir with the same name are
+// replaced, the source chapters are never modified.
+//
+// A chapter that fails to copy does not stop the export; the returned error joins one
+// error per failed file.
+func ExportSeriesToDevice(manga *Bookmarks, destDir string, rename bool) error {
+	if manga == nil || manga.Location == "" {
+		return fmt.Errorf("series has no download location")
+	}
+
+	location, err := parser.ExpandPath(manga.Location)
+	if err != nil {
+		return err
+	}
+	destDir, err = parser.ExpandPath(destDir)
+	if err != nil {
+		return err
+	}
+
+	chapters, err := parser.LocalChapterList(location)
+	if err != nil {
+		return fmt.Errorf("failed to list chapters of %s: %w", manga.Title, err)
+	}
+	if len(chapters) == 0 {
+		return fmt.Errorf("%s has no downloaded chapters", manga.Title)
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	title := deviceTitle(manga.Title)
+	var errs []error
+	copied := 0
+	for _, chapter := range chapters {
+		name := chapter
+		if rename && title != "" {
+			name = fmt.Sprintf("%s %s.cbz", title, deviceChapterLabel(chapter))
+		}
+
+		src := filepath.Join(location, chapter)
+		dst := filepath.Join(destDir, name)
+		if sameFile(src, dst) {
+			errs = append(errs, fmt.Errorf("%s: export directory is the series directory", chapter))
+			continue
+		}
+		if err := copyFile(src, dst); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", chapter, err))
+			continue
+		}
+		copied++
+	}
+
+	log.Printf("[Export] %s: copied %d of %d chapters to %s", manga.Title, copied, len(chapters), destDir)
+	return errors.Join(errs...)
+}
+
+// ArchiveSeries repacks every chapter of a bookmarked series into destDir with
+// parser.RepackCbz at the given deflate level, for long-term storage of PNG heavy
+// series. The chapters in the library are left untouched and keep their names.
+// Like ExportSeriesToDevice, a chapter that fails does not stop the rest.
+func ArchiveSeries(manga *Bookmarks, destDir string, level int) error {
+	if manga == nil || manga.Location == "" {
+		return fmt.Errorf("series has no download location")
+	}
+
+	location, err := parser.ExpandPath(manga.Location)
+	if err != nil {
+		return err
+	}
+	destDir, err = parser.ExpandPath(destDir)
+	if err != nil {
+		return err
+	}
+
+	chapters, err := parser.LocalChapterList(location)
+	if err != nil {
+		return fmt.Errorf("failed to list chapters of %s: %w", manga.Title, err)
+	}
+	if len(chapters) == 0 {
+		return fmt.Errorf("%s has no downloaded chapters", manga.Title)
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	var errs []error
+	var before, after int64
+	for _, chapter := range chapters {
+		src := filepath.Join(location, chapter)
+		dst := filepath.Join(destDir, chapter)
+		if sameFile(src, dst) {
+			errs = append(errs, fmt.Errorf("%s: archive directory is the series directory", chapter))
+			continue
+		}
+		if err := parser.RepackCbz(src, dst, level); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", chapter, err))
+			continue
+		}
+		before += fileSize(src)
+		after += fileSize(dst)
+	}
+
+	log.Printf("[Archive] %s: repacked %d of %d chapters to %s (%d -> %d bytes)",
+		manga.Title, len(chapters)-len(errs), len(chapters), destDir, before, after)
+	return errors.Join(errs...)
+}
+
+// fileSize returns the size of name, 0 when it cannot be read
+func fileSize(name string) int64 {
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// deviceChapterLabel returns the chapter part of a cbz filename, dropping what the
+// chapter filename template adds around it (eg: "ch072.5.cbz" -> "072.5")
+func deviceChapterLabel(fileName string) string {
+	return parser.ChapterLabel(fileName)
+}
+
+// deviceTitle makes a series title safe to use in filenames on common e-reader
+// filesystems (FAT32/exFAT reject the same characters as Windows)
+func deviceTitle(title string) string {
+	title = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r):
+			return ' '
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			return -1
+		}
+		return r
+	}, title)
+
+	title = strings.Join(strings.Fields(title), " ")
+	return strings.TrimRight(title, ". ")
+}
+
+// sameFile reports whether dst already is src, copying onto itself would truncate it
+func sameFile(src, dst string) bool {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return false
+	}
+	dstInfo, err := os.Stat(dst)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(srcInfo, dstInfo)
+}
+
+// copyFile copies src to dst through a temp file in the destination directory, so an
+// interrupted copy never leaves a truncated cbz on the device
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".kansho-export-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	// CreateTemp files are owner-only. FAT formatted devices reject chmod, which
+	// is harmless there since they have no permissions to fix.
+	_ = tmp.Chmod(0644)
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, dst); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"kansho/parser"
+)
+
+// Settings holds application wide options read from ~/.config/kansho/settings.json.
+// The file is optional, missing fields keep their defaults.
+type Settings struct {
+	// MaxConcurrentSeries is how many series the download queue runs at once
+	MaxConcurrentSeries int `json:"max_concurrent_series,omitempty"`
+
+	// WriteComicInfo adds a ComicInfo.xml with the series, chapter number, source
+	// URL, download date and per page metadata (dimensions and double page spreads)
+	// to every chapter downloaded through the manager
+	WriteComicInfo bool `json:"write_comic_info,omitempty"`
+
+	// MangadexForcePort443 requests MangaDex@Home nodes on port 443 only, for
+	// networks that block the other ports nodes are allowed to serve from
+	MangadexForcePort443 bool `json:"mangadex_force_port_443,omitempty"`
+
+	// MangadexFeedConcurrency is how many chapter feed pages of a MangaDex series
+	// are fetched at once, within the API rate limit. 0 uses the default, 1 fetches
+	// them one after another.
+	MangadexFeedConcurrency int `json:"mangadex_feed_concurrency,omitempty"`
+
+	// MangadexImageQuality is "data-saver" to download MangaDex pages recompressed
+	// to a fraction of their size, unless a series sets its own image_quality.
+	// Empty or "original" downloads the full quality pages.
+	MangadexImageQuality string `json:"mangadex_image_quality,omitempty"`
+
+	// SplitTallPagesMaxHeight slices pages taller than this many pixels into
+	// several pages before the CBZ is created, 0 leaves pages untouched
+	SplitTallPagesMaxHeight int `json:"split_tall_pages_max_height,omitempty"`
+
+	// RemoteBrowserURL is the DevTools endpoint of an already running browser
+	// (e.g. ws://127.0.0.1:9222) used instead of launching a local Chrome
+	RemoteBrowserURL string `json:"remote_browser_url,omitempty"`
+
+	// ImageAccept overrides the Accept header sent with image requests, CDNs pick
+	// the served format (WebP, JPEG, ...) from it. Empty uses parser.DefaultImageAccept
+	ImageAccept string `json:"image_accept,omitempty"`
+
+	// RejectShortChapters treats a chapter with far fewer pages than the chapters
+	// downloaded before it as incomplete, it is retried instead of packaged.
+	// Otherwise such chapters are only reported.
+	RejectShortChapters bool `json:"reject_short_chapters,omitempty"`
+
+	// VerboseLogging logs every chapter as it is mapped and normalized. Off by
+	// default, only per batch and summary lines are logged.
+	VerboseLogging bool `json:"verbose_logging,omitempty"`
+
+	// Storage is where bookmarks and the download history are kept: StorageJSON
+	// (default, bookmarks.json) or StorageSQLite (kansho.db, migrated from
+	// bookmarks.json on first use)
+	Storage string `json:"storage,omitempty"`
+
+	// StreamCbz writes each page into the CBZ as soon as it is downloaded instead of
+	// collecting the chapter in a temp dir first, halving the peak disk use of long
+	// chapters. Ignored when SplitTallPagesMaxHeight is set, splitting needs every
+	// page on disk. Interrupted chapters start over rather than resume.
+	StreamCbz bool `json:"stream_cbz,omitempty"`
+
+	// WriteImageManifest writes the image URLs extracted for every chapter to a
+	// <chapter>.urls.txt next to its CBZ, to diff against the site when pages go
+	// missing. The file is written even when the chapter fails to download.
+	WriteImageManifest bool `json:"write_image_manifest,omitempty"`
+
+	// KeepLosslessWebP saves lossless WebP pages as PNG instead of converting them to
+	// JPEG like other pages, JPEG would add artifacts to an image that has none.
+	// Sites keeping native formats (KeepNativeImageFormat) are not affected.
+	KeepLosslessWebP bool `json:"keep_lossless_webp,omitempty"`
+
+	// KeepNativeImageFormat stores asura and kunmanga pages in the CBZ as the site
+	// serves them (eg: WebP) instead of converting them to JPEG, roughly halving the
+	// file size for readers that handle WebP
+	KeepNativeImageFormat bool `json:"keep_native_image_format,omitempty"`
+
+	// OptimizeJPEG writes converted pages as progressive JPEGs with Huffman tables
+	// built for each page, around 10% smaller at the same quality but slower to
+	// encode. Pages the site already serves as JPEG are stored as they are.
+	OptimizeJPEG bool `json:"optimize_jpeg,omitempty"`
+
+	// ImageMemoryBudgetMB caps the page bytes buffered in memory over every download
+	// running, 0 is downloader.DefaultImageMemoryBudget. A page waits for room before
+	// it starts, one outgrowing what is left is spooled to a temp file instead.
+	ImageMemoryBudgetMB int `json:"image_memory_budget_mb,omitempty"`
+
+	// ImageMemoryPerImageMB is the largest page buffered in memory, larger pages are
+	// spooled to a temp file. 0 is downloader.DefaultImageMemoryPerImage.
+	ImageMemoryPerImageMB int `json:"image_memory_per_image_mb,omitempty"`
+
+	// ChapterListCacheMinutes is how long a scraped chapter list is reused by the
+	// next download of the same series, 0 uses downloader.DefaultChapterListCacheTTL
+	// and a negative value scrapes every time
+	ChapterListCacheMinutes int `json:"chapter_list_cache_minutes,omitempty"`
+
+	// MaxChaptersPerRun caps how many chapters a single download of a series
+	// fetches, oldest first, so a long backlog comes in over several runs. 0 is no cap.
+	MaxChaptersPerRun int `json:"max_chapters_per_run,omitempty"`
+
+	// CbzExtensionRule renames page extensions inside CBZs for strict readers:
+	// "lowercase" (.JPG -> .jpg) or "standard" (lowercase and .jpeg -> .jpg). Empty
+	// keeps the names the pages were saved with.
+	CbzExtensionRule string `json:"cbz_extension_rule,omitempty"`
+
+	// ChapterFilenameTemplate names downloaded chapters, eg "Chapter {main:04}{part}.cbz",
+	// see parser.ValidateChapterFilenameTemplate. Empty is ch{main:03}{part}.cbz.
+	// Changing it on an existing library renames nothing, the renumber check asks
+	// before chapters already on disk are downloaded again under the new names.
+	ChapterFilenameTemplate string `json:"chapter_filename_template,omitempty"`
+
+	// SkipSpecialChapters leaves out chapters labeled as specials or extras without a
+	// chapter number ("Volume 3 Extra"), otherwise they are downloaded as sp001.cbz,
+	// sp002.cbz... after the numbered chapters
+	SkipSpecialChapters bool `json:"skip_special_chapters,omitempty"`
+
+	// DefaultLibraryRoot is the parent folder new bookmarks are added to, each series
+	// in its own <root>/<title> folder. The add form starts there, picking another
+	// directory still works. Empty asks for a directory every time.
+	DefaultLibraryRoot string `json:"default_library_root,omitempty"`
+
+	// EnabledSites are the site names offered in the Add Manga site dropdown, empty
+	// offers every site. Bookmarks of other sites still download and stay editable.
+	EnabledSites []string `json:"enabled_sites,omitempty"`
+
+	// SkipCFWithoutCookie leaves series of Cloudflare protected sites waiting in a
+	// bulk recheck when there is no unexpired cf_clearance for them, instead of
+	// opening a browser no one is there to answer. Retrying the task downloads it.
+	SkipCFWithoutCookie bool `json:"skip_cf_without_cookie,omitempty"`
+
+	// ExternalCFBrowser opens a Cloudflare challenge in the default browser, for the
+	// cookie to be imported by hand, instead of solving it in an embedded browser
+	// window that saves the new cf_clearance and resumes the download itself.
+	ExternalCFBrowser bool `json:"external_cf_browser,omitempty"`
+
+	// AutoCheckIntervalHours queues every bookmarked series for download this many
+	// hours after its last run while kansho is running, see UpdateScheduler. A
+	// series' check_interval_hours overrides it. 0 checks only when asked to.
+	AutoCheckIntervalHours int `json:"auto_check_interval_hours,omitempty"`
+
+	// ChapterConcurrency is how many chapters of one series the download manager
+	// fetches at once, 0 or 1 downloads them one after another. Every request still
+	// waits on the rate limiter of its domain, so a site is not hit any faster.
+	ChapterConcurrency int `json:"chapter_concurrency,omitempty"`
+
+	// ImageConcurrency is how many pages of a chapter are in flight at once on sites
+	// that do not set their own (downloader.ConcurrentImageSite), 0 or 1 fetches them
+	// one at a time. The pages are still paced by the domain's rate limiter.
+	ImageConcurrency int `json:"image_concurrency,omitempty"`
+}
+
+// defaultSettings preserves the original one-series-at-a-time queue behaviour
+var defaultSettings = Settings{
+	MaxConcurrentSeries: 1,
+}
+
+// settingsPath returns the location of the settings file
+func settingsPath() string {
+	configDir, err := parser.ExpandPath("~/.config/kansho")
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "settings.json")
+}
+
+// LoadSettings reads the settings file, falling back to defaults for anything
+// missing or invalid
+func LoadSettings() Settings {
+	settings := defaultSettings
+
+	path := settingsPath()
+	if path == "" {
+		return settings
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[Settings] Failed to read %s: %v", path, err)
+		}
+		return settings
+	}
+
+	if err := json.Unmarshal(data, &settings); err != nil {
+		log.Printf("[Settings] Failed to parse %s, using defaults: %v", path, err)
+		return defaultSettings
+	}
+
+	if settings.MaxConcurrentSeries < 1 {
+		settings.MaxConcurrentSeries = defaultSettings.MaxConcurrentSeries
+	}
+	if settings.ChapterFilenameTemplate != "" {
+		if err := parser.ValidateChapterFilenameTemplate(settings.ChapterFilenameTemplate); err != nil {
+			log.Printf("[Settings] Ignoring chapter_filename_template: %v", err)
+			settings.ChapterFilenameTemplate = ""
+		}
+	}
+
+	return settings
+}
+
+var (
+	settingsMu    sync.Mutex
+	settingsHooks []func(Settings)
+)
+
+// UpdateSettings applies change to the settings file and saves it, then notifies
+// everything registered with OnSettingsChanged. Only the values in the file are
+// changed, missing fields keep falling back to their defaults. A file that does not
+// parse is left alone rather than overwritten.
+func UpdateSettings(change func(*Settings)) error {
+	path := settingsPath()
+	if path == "" {
+		return fmt.Errorf("failed to locate the settings file")
+	}
+
+	settingsMu.Lock()
+	var settings Settings
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		settingsMu.Unlock()
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			settingsMu.Unlock()
+			return fmt.Errorf("failed to parse %s, fix or remove it first: %w", path, err)
+		}
+	}
+
+	change(&settings)
+	if settings.ChapterFilenameTemplate != "" {
+		if err := parser.ValidateChapterFilenameTemplate(settings.ChapterFilenameTemplate); err != nil {
+			settingsMu.Unlock()
+			return err
+		}
+	}
+	data, err = json.MarshalIndent(settings, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(path, data)
+	}
+	hooks := append([]func(Settings){}, settingsHooks...)
+	settingsMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	log.Printf("[Settings] Saved %s", path)
+	updated := LoadSettings()
+	for _, hook := range hooks {
+		hook(updated)
+	}
+	return nil
+}
+
+// OnSettingsChanged registers fn to be called with the new settings after every
+// UpdateSettings, eg: so views can refresh what they show
+func OnSettingsChanged(fn func(Settings)) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	settingsHooks = append(settingsHooks, fn)
+}
@@ -0,0 +1,5 @@
+package config
+
+// The pure Go SQLite driver, registered as "sqlite". No cgo, so the sqlite storage
+// setting works in every build.
+import _ "modernc.org/sqlite"
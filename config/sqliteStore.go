@@ -0,0 +1,255 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SQLiteStoreName is the database file the sqlite storage setting uses
+const SQLiteStoreName = "kansho.db"
+
+// sqliteDriverName is the database/sql driver the store opens, see sqliteDriver.go
+const sqliteDriverName = "sqlite"
+
+// jsonMigratedKey marks a database that already received the JSON bookmarks
+const jsonMigratedKey = "json_migrated"
+
+// sqliteSchema creates the tables on first open. Bookmarks are stored as their JSON
+// encoding so new bookmark fields need no schema change.
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS bookmarks (
+		url      TEXT PRIMARY KEY,
+		position INTEGER NOT NULL,
+		data     TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS runs (
+		id       INTEGER PRIMARY KEY AUTOINCREMENT,
+		url      TEXT NOT NULL,
+		title    TEXT NOT NULL,
+		site     TEXT NOT NULL,
+		status   TEXT NOT NULL,
+		message  TEXT NOT NULL,
+		started  INTEGER NOT NULL,
+		finished INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS runs_by_url ON runs (url, started)`,
+	`CREATE TABLE IF NOT EXISTS meta (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	)`,
+}
+
+// sqlExecer is a database or transaction the store's writes run on
+type sqlExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// SQLiteStore keeps bookmarks and the history in a SQLite database, saving or
+// deleting a bookmark only touches its own row
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteStore opens, or creates, the database at path
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open(sqliteDriverName, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	// SQLite allows one writer, a single connection avoids "database is locked"
+	db.SetMaxOpenConns(1)
+
+	for _, statement := range sqliteSchema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create schema in %s: %w", path, err)
+		}
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// ListBookmarks returns every bookmark in list order
+func (s *SQLiteStore) ListBookmarks() ([]Bookmarks, error) {
+	rows, err := s.db.Query(`SELECT data FROM bookmarks ORDER BY position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookmarks: %w", err)
+	}
+	defer rows.Close()
+
+	bookmarks := []Bookmarks{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var manga Bookmarks
+		if err := json.Unmarshal([]byte(data), &manga); err != nil {
+			return nil, fmt.Errorf("failed to decode bookmark: %w", err)
+		}
+		bookmarks = append(bookmarks, manga)
+	}
+	return bookmarks, rows.Err()
+}
+
+// SaveBookmark updates the row of manga in place, or appends it at the end
+func (s *SQLiteStore) SaveBookmark(manga Bookmarks) error {
+	if manga.Url == "" {
+		return fmt.Errorf("bookmark %q has no url", manga.Title)
+	}
+	data, err := json.Marshal(manga)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`INSERT INTO bookmarks (url, position, data)
+		VALUES (?, (SELECT COALESCE(MAX(position), -1) + 1 FROM bookmarks), ?)
+		ON CONFLICT (url) DO UPDATE SET data = excluded.data`, manga.Url, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save bookmark %s: %w", manga.Title, err)
+	}
+	return nil
+}
+
+// DeleteBookmark removes the row with url
+func (s *SQLiteStore) DeleteBookmark(url string) error {
+	if _, err := s.db.Exec(`DELETE FROM bookmarks WHERE url = ?`, url); err != nil {
+		return fmt.Errorf("failed to delete bookmark %s: %w", url, err)
+	}
+	return nil
+}
+
+// ReplaceBookmarks rewrites the bookmarks table in one transaction. A later
+// bookmark with the same URL as an earlier one replaces it.
+func (s *SQLiteStore) ReplaceBookmarks(bookmarks []Bookmarks) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceBookmarks(tx, bookmarks); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// replaceBookmarks clears the bookmarks table of ex and inserts bookmarks in order
+func replaceBookmarks(ex sqlExecer, bookmarks []Bookmarks) error {
+	if _, err := ex.Exec(`DELETE FROM bookmarks`); err != nil {
+		return fmt.Errorf("failed to clear bookmarks: %w", err)
+	}
+	for position, manga := range bookmarks {
+		if manga.Url == "" {
+			return fmt.Errorf("bookmark %q has no url", manga.Title)
+		}
+		data, err := json.Marshal(manga)
+		if err != nil {
+			return err
+		}
+		_, err = ex.Exec(`INSERT INTO bookmarks (url, position, data) VALUES (?, ?, ?)
+			ON CONFLICT (url) DO UPDATE SET data = excluded.data`, manga.Url, position, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to save bookmark %s: %w", manga.Title, err)
+		}
+	}
+	return nil
+}
+
+// RecordRun adds run to the runs table
+func (s *SQLiteStore) RecordRun(run RunRecord) error {
+	return insertRun(s.db, run)
+}
+
+// insertRun adds run to the runs table of ex
+func insertRun(ex sqlExecer, run RunRecord) error {
+	_, err := ex.Exec(`INSERT INTO runs (url, title, site, status, message, started, finished)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		run.URL, run.Title, run.Site, run.Status, run.Message, run.Started.UnixNano(), run.Finished.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to record run of %s: %w", run.Title, err)
+	}
+	return nil
+}
+
+// RunHistory returns the recorded runs of url, newest first
+func (s *SQLiteStore) RunHistory(url string, limit int) ([]RunRecord, error) {
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	rows, err := s.db.Query(`SELECT url, title, site, status, message, started, finished FROM runs
+		WHERE ? = '' OR url = ? ORDER BY started DESC, id DESC LIMIT ?`, url, url, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []RunRecord{}
+	for rows.Next() {
+		var run RunRecord
+		var started, finished int64
+		if err := rows.Scan(&run.URL, &run.Title, &run.Site, &run.Status, &run.Message, &started, &finished); err != nil {
+			return nil, err
+		}
+		run.Started = time.Unix(0, started)
+		run.Finished = time.Unix(0, finished)
+		history = append(history, run)
+	}
+	return history, rows.Err()
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// MigrateJSONToSQLite copies the bookmarks and run history of from into to, once: a
+// database that was migrated before is left alone. bookmarks.json is kept as it was,
+// as a backup. Returns the number of bookmarks copied.
+func MigrateJSONToSQLite(from *JSONStore, to *SQLiteStore) (int, error) {
+	var done string
+	err := to.db.QueryRow(`SELECT value FROM meta WHERE key = ?`, jsonMigratedKey).Scan(&done)
+	if err == nil {
+		return 0, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to read migration state: %w", err)
+	}
+
+	bookmarks, err := from.ListBookmarks()
+	if err != nil {
+		return 0, err
+	}
+	runs, err := from.RunHistory("", 0)
+	if err != nil {
+		return 0, err
+	}
+
+	// All or nothing, a failed migration is simply retried on the next start
+	tx, err := to.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := replaceBookmarks(tx, bookmarks); err != nil {
+		return 0, err
+	}
+	// Oldest first, so ties on the start time keep their order
+	for i := len(runs) - 1; i >= 0; i-- {
+		if err := insertRun(tx, runs[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO meta (key, value) VALUES (?, ?)`, jsonMigratedKey, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("failed to record migration: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(bookmarks), nil
+}
@@ -0,0 +1,317 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Storage backends for the storage setting
+const (
+	StorageJSON   = "json"
+	StorageSQLite = "sqlite"
+)
+
+// maxRunHistory is how many download runs the JSON store keeps, oldest are dropped
+const maxRunHistory = 1000
+
+// Store persists bookmarks and the download history. JSONStore (bookmarks.json) is
+// the default; SQLiteStore updates single rows instead of rewriting one file, for
+// large libraries. Bookmarks are identified by their URL.
+type Store interface {
+	// ListBookmarks returns every bookmark in list order
+	ListBookmarks() ([]Bookmarks, error)
+
+	// SaveBookmark updates the bookmark with the same URL, or appends it
+	SaveBookmark(manga Bookmarks) error
+
+	// DeleteBookmark removes the bookmark with url, a missing one is not an error
+	DeleteBookmark(url string) error
+
+	// ReplaceBookmarks swaps every bookmark for bookmarks, keeping their order
+	ReplaceBookmarks(bookmarks []Bookmarks) error
+
+	// RecordRun adds a finished download run to the history
+	RecordRun(run RunRecord) error
+
+	// RunHistory returns the runs of the series with url, newest first. An empty url
+	// returns the runs of every series, limit <= 0 returns all of them.
+	RunHistory(url string, limit int) ([]RunRecord, error)
+
+	Close() error
+}
+
+// RunRecord is one download run of a series as the queue finished it
+type RunRecord struct {
+	URL      string    `json:"url"`
+	Title    string    `json:"title"`
+	Site     string    `json:"site"`
+	Status   string    `json:"status"`
+	Message  string    `json:"message,omitempty"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+}
+
+// JSONStore keeps bookmarks in bookmarks.json and the history in history.json, both
+// in one directory. Every change rewrites the whole file.
+type JSONStore struct {
+	bookmarksPath string
+	historyPath   string
+	historyMu     sync.Mutex
+}
+
+// NewJSONStore returns a store for the files in dir, they are created on first save
+func NewJSONStore(dir string) *JSONStore {
+	return &JSONStore{
+		bookmarksPath: filepath.Join(dir, "bookmarks.json"),
+		historyPath:   filepath.Join(dir, "history.json"),
+	}
+}
+
+// ListBookmarks returns the bookmarks in the file, malformed entries are skipped
+func (s *JSONStore) ListBookmarks() ([]Bookmarks, error) {
+	bookmarksFileMu.Lock()
+	data, err := readBookmarksFile(s.bookmarksPath)
+	bookmarksFileMu.Unlock()
+	if os.IsNotExist(err) {
+		return []Bookmarks{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmarks: %w", err)
+	}
+
+	manga, skipped, err := DecodeBookmarks(data)
+	if err != nil && len(manga.Manga) == 0 {
+		return nil, fmt.Errorf("failed to decode bookmarks: %w", err)
+	}
+	if skipped > 0 {
+		log.Printf("[Store] ⚠️ %d bookmark entries in %s could not be loaded", skipped, s.bookmarksPath)
+	}
+	if manga.Manga == nil {
+		manga.Manga = []Bookmarks{}
+	}
+	return manga.Manga, nil
+}
+
+// SaveBookmark updates or appends manga and rewrites the file
+func (s *JSONStore) SaveBookmark(manga Bookmarks) error {
+	if manga.Url == "" {
+		return fmt.Errorf("bookmark %q has no url", manga.Title)
+	}
+	bookmarks, err := s.ListBookmarks()
+	if err != nil {
+		return err
+	}
+	for i := range bookmarks {
+		if bookmarks[i].Url == manga.Url {
+			bookmarks[i] = manga
+			return s.ReplaceBookmarks(bookmarks)
+		}
+	}
+	return s.ReplaceBookmarks(append(bookmarks, manga))
+}
+
+// DeleteBookmark removes the bookmarks with url and rewrites the file
+func (s *JSONStore) DeleteBookmark(url string) error {
+	bookmarks, err := s.ListBookmarks()
+	if err != nil {
+		return err
+	}
+	kept := bookmarks[:0]
+	for _, manga := range bookmarks {
+		if manga.Url != url {
+			kept = append(kept, manga)
+		}
+	}
+	return s.ReplaceBookmarks(kept)
+}
+
+// ReplaceBookmarks writes bookmarks as the whole file
+func (s *JSONStore) ReplaceBookmarks(bookmarks []Bookmarks) error {
+	return writeBookmarksFile(s.bookmarksPath, Manga{Manga: bookmarks})
+}
+
+// RecordRun appends run to history.json, keeping the latest maxRunHistory runs
+func (s *JSONStore) RecordRun(run RunRecord) error {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	runs, err := s.readHistory()
+	if err != nil {
+		return err
+	}
+	runs = append(runs, run)
+	if len(runs) > maxRunHistory {
+		runs = runs[len(runs)-maxRunHistory:]
+	}
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.historyPath, data)
+}
+
+// RunHistory returns the recorded runs of url, newest first
+func (s *JSONStore) RunHistory(url string, limit int) ([]RunRecord, error) {
+	s.historyMu.Lock()
+	runs, err := s.readHistory()
+	s.historyMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	history := []RunRecord{}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if url != "" && runs[i].URL != url {
+			continue
+		}
+		history = append(history, runs[i])
+	}
+	// Runs are appended as they finish, concurrent series can finish out of order
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Started.After(history[j].Started)
+	})
+	if limit > 0 && len(history) > limit {
+		history = history[:limit]
+	}
+	return history, nil
+}
+
+// Close does nothing, the files are not held open
+func (s *JSONStore) Close() error {
+	return nil
+}
+
+// readHistory reads every recorded run, caller must hold historyMu
+func (s *JSONStore) readHistory() ([]RunRecord, error) {
+	data, err := os.ReadFile(s.historyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+
+	var runs []RunRecord
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("failed to parse run history: %w", err)
+	}
+	return runs, nil
+}
+
+// writeBookmarksFile writes data to path, one save at a time
+func writeBookmarksFile(path string, data Manga) error {
+	if data.Manga == nil {
+		data.Manga = []Bookmarks{}
+	}
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	bookmarksFileMu.Lock()
+	defer bookmarksFileMu.Unlock()
+	return writeFileAtomic(path, jsonData)
+}
+
+// writeFileAtomic replaces path with data through a temp file, so a crash mid write
+// leaves the previous file rather than a truncated one
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".kansho-save-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	os.Chmod(tmpName, 0644)
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+var (
+	defaultStoreMu sync.Mutex
+	defaultStore   Store
+	defaultKind    string
+	defaultDir     string
+)
+
+// DefaultStore returns the store picked by the storage setting, for the files in
+// ~/.config/kansho. Switching to SQLite migrates the JSON bookmarks into the database
+// the first time. When the database cannot be opened the JSON store is used so
+// bookmarks are never lost.
+func DefaultStore() Store {
+	kind := LoadSettings().Storage
+	configDir, _ := verifyConfigDirectory()
+
+	defaultStoreMu.Lock()
+	defer defaultStoreMu.Unlock()
+	if defaultStore != nil && defaultKind == kind && defaultDir == configDir {
+		return defaultStore
+	}
+	if defaultStore != nil {
+		defaultStore.Close()
+	}
+
+	jsonStore := NewJSONStore(configDir)
+	defaultStore, defaultKind, defaultDir = jsonStore, kind, configDir
+
+	if kind != StorageSQLite {
+		return defaultStore
+	}
+
+	db, err := OpenSQLiteStore(filepath.Join(configDir, SQLiteStoreName))
+	if err != nil {
+		log.Printf("[Store] ⚠️ Using bookmarks.json, SQLite storage is unavailable: %v", err)
+		return defaultStore
+	}
+	migrated, err := MigrateJSONToSQLite(jsonStore, db)
+	if err != nil {
+		db.Close()
+		log.Printf("[Store] ⚠️ Using bookmarks.json, migration to SQLite failed: %v", err)
+		return defaultStore
+	}
+	if migrated > 0 {
+		log.Printf("[Store] ✓ Migrated %d bookmarks from bookmarks.json to %s", migrated, SQLiteStoreName)
+	}
+
+	defaultStore = db
+	return defaultStore
+}
+
+// RecordDownloadRun adds a finished run of manga to the default store's history,
+// failures are only logged since the run itself is over
+func RecordDownloadRun(manga Bookmarks, status, message string, started time.Time) {
+	run := RunRecord{
+		URL:      manga.Url,
+		Title:    manga.Title,
+		Site:     manga.Site,
+		Status:   status,
+		Message:  message,
+		Started:  started,
+		Finished: time.Now(),
+	}
+	if err := DefaultStore().RecordRun(run); err != nil {
+		log.Printf("[Store] ⚠️ Failed to record run of %s: %v", manga.Title, err)
+	}
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"kansho/parser"
+)
+
+const (
+	// minCadenceGap is the smallest gap between two chapter files that counts as a
+	// release interval. Chapters written within the same download run land seconds
+	// apart and say nothing about the series release schedule.
+	minCadenceGap = time.Hour
+
+	// cadenceDueFactor is the fraction of the typical release interval that must
+	// have elapsed since the newest local chapter before a new one is considered due.
+	cadenceDueFactor = 0.8
+
+	// dormantFactor and dormantMinAge decide when a series has gone quiet: no new
+	// chapter for dormantFactor typical intervals and at least dormantMinAge overall.
+	dormantFactor = 6
+	dormantMinAge = 180 * 24 * time.Hour
+)
+
+// LikelyHasUpdate reports whether a bookmarked series is worth scraping based on
+// the release cadence derived from the modification times of its local chapters.
+//
+// Series with too little local history to derive a cadence are always reported as
+// due, so the heuristic only ever skips series it has evidence about.
+func LikelyHasUpdate(manga *Bookmarks) bool {
+	if manga == nil || manga.Location == "" {
+		return true
+	}
+
+	chapters, err := parser.LocalChapterList(manga.Location)
+	if err != nil {
+		log.Printf("[Recheck] %s: cannot list local chapters (%v), treating as due", manga.Title, err)
+		return true
+	}
+
+	location, err := parser.ExpandPath(manga.Location)
+	if err != nil {
+		return true
+	}
+
+	var modTimes []time.Time
+	for _, chapter := range chapters {
+		info, err := os.Stat(filepath.Join(location, chapter))
+		if err != nil {
+			continue
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+
+	if len(modTimes) < 2 {
+		return true
+	}
+
+	sort.Slice(modTimes, func(i, j int) bool { return modTimes[i].Before(modTimes[j]) })
+
+	var gaps []time.Duration
+	for i := 1; i < len(modTimes); i++ {
+		if gap := modTimes[i].Sub(modTimes[i-1]); gap >= minCadenceGap {
+			gaps = append(gaps, gap)
+		}
+	}
+
+	// Every chapter was written in one bulk download, no cadence to go on
+	if len(gaps) == 0 {
+		return true
+	}
+
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	cadence := gaps[len(gaps)/2]
+	sinceLatest := time.Since(modTimes[len(modTimes)-1])
+
+	if sinceLatest > dormantMinAge && sinceLatest > cadence*dormantFactor {
+		log.Printf("[Recheck] %s: dormant (last chapter %s ago, cadence %s)",
+			manga.Title, sinceLatest.Round(time.Hour), cadence.Round(time.Hour))
+		return false
+	}
+
+	due := float64(sinceLatest) >= float64(cadence)*cadenceDueFactor
+	log.Printf("[Recheck] %s: due=%v (last chapter %s ago, cadence %s)",
+		manga.Title, due, sinceLatest.Round(time.Hour), cadence.Round(time.Hour))
+	return due
+}
+
+// BulkUpdateCandidates returns the bookmarks a bulk update should check: every
+// series LikelyHasUpdate reports as due, or every series when force is true.
+// Completed series are always left out, force included.
+func BulkUpdateCandidates(mangas []Bookmarks, force bool) []*Bookmarks {
+	var candidates []*Bookmarks
+	for i := range mangas {
+		manga := &mangas[i]
+
+		if manga.Completed {
+			log.Printf("[Recheck] %s: completed, not checking", manga.Title)
+			continue
+		}
+		if !force && !LikelyHasUpdate(manga) {
+			continue
+		}
+		candidates = append(candidates, manga)
+	}
+	return candidates
+}
+
+// QueueLikelyUpdates adds every bookmark BulkUpdateCandidates returns to the
+// download queue, as unattended tasks when the skip_cf_without_cookie setting is
+// on. Returns the number of series queued and skipped.
+func (q *DownloadQueue) QueueLikelyUpdates(mangas []Bookmarks, force bool) (queued, skipped int) {
+	candidates := BulkUpdateCandidates(mangas, force)
+	queued, failed := q.queueBulk(candidates, "[Recheck]")
+	skipped = len(mangas) - len(candidates) + failed
+
+	log.Printf("[Recheck] Queued %d series, skipped %d (force=%v)", queued, skipped, force)
+	return queued, skipped
+}
+
+// queueBulk adds candidates to the queue as a bulk run, as unattended tasks when
+// the skip_cf_without_cookie setting is on. Returns how many were queued and how
+// many could not be (eg: already queued).
+func (q *DownloadQueue) queueBulk(candidates []*Bookmarks, logPrefix string) (queued, failed int) {
+	add := q.AddTask
+	if LoadSettings().SkipCFWithoutCookie {
+		add = q.AddUnattendedTask
+	}
+	for _, manga := range candidates {
+		if _, err := add(manga); err != nil {
+			log.Printf("%s %s: not queued: %v", logPrefix, manga.Title, err)
+			failed++
+			continue
+		}
+		queued++
+	}
+	return queued, failed
+}
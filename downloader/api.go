@@ -8,12 +8,14 @@ import (
 	"time"
 
 	"kansho/cf"
+	"kansho/parser"
 
 	"github.com/gocolly/colly"
 )
 
 // APIClient handles API-based extraction using colly for better CF support
 type APIClient struct {
+	ctx       context.Context
 	domain    string
 	collector *colly.Collector
 	needsCF   bool
@@ -21,6 +23,12 @@ type APIClient struct {
 
 // NewAPIClient creates a new API client for a specific domain
 func NewAPIClient(domain string, needsCF bool) (*APIClient, error) {
+	return NewAPIClientContext(context.Background(), domain, needsCF)
+}
+
+// NewAPIClientContext creates an API client whose requests are sent with ctx, so
+// cancelling it aborts the ones in flight
+func NewAPIClientContext(ctx context.Context, domain string, needsCF bool) (*APIClient, error) {
 	collector := colly.NewCollector(
 		// MangaDex API requires a non-spoofed User-Agent — see openspec/specs/mangadex/spec.md
 		// https://api.mangadex.org/docs/2-limitations/
@@ -29,8 +37,10 @@ func NewAPIClient(domain string, needsCF bool) (*APIClient, error) {
 	)
 
 	collector.SetRequestTimeout(30 * time.Second)
+	parser.BindCollectorContext(collector, ctx)
 
 	client := &APIClient{
+		ctx:       ctx,
 		domain:    domain,
 		collector: collector,
 		needsCF:   needsCF,
@@ -47,6 +57,41 @@ func NewAPIClient(domain string, needsCF bool) (*APIClient, error) {
 	return client, nil
 }
 
+// Context is the context the client's requests are sent with, for API functions
+// to pace and cancel their own work with
+func (c *APIClient) Context() context.Context {
+	return c.ctx
+}
+
+// visit requests url through the collector, returning as soon as ctx is done. The
+// request itself is aborted when the client's context is.
+func (c *APIClient) visit(ctx context.Context, url string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := c.collector.Visit(url)
+		// Wait for async operations
+		c.collector.Wait()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to visit URL: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // applyCFBypass applies CF bypass data to the collector.
 // We do NOT validate expiry or staleness here — the request result determines
 // whether the data is still good. Pre-flight time-based checks cause false
@@ -98,7 +143,7 @@ func (c *APIClient) FetchJSON(ctx context.Context, url string, result interface{
 			}
 
 			challengeURL := cf.GetChallengeURL(cfInfo, url)
-			cf.OpenInBrowser(challengeURL)
+			cf.OpenChallenge(ctx, challengeURL)
 
 			fetchErr = &cf.CfChallengeError{
 				URL:        challengeURL,
@@ -109,14 +154,14 @@ func (c *APIClient) FetchJSON(ctx context.Context, url string, result interface{
 	})
 
 	c.collector.OnError(func(r *colly.Response, err error) {
-		fetchErr = fmt.Errorf("request failed: %w", err)
+		fetchErr = fmt.Errorf("%w: request failed: %w", ErrNetwork, err)
 
 		// Check for CF challenge on error
 		isCF, cfInfo, _ := cf.DetectFromColly(r)
 		if isCF {
 			log.Printf("[APIClient] CF challenge detected on error")
 			challengeURL := cf.GetChallengeURL(cfInfo, url)
-			cf.OpenInBrowser(challengeURL)
+			cf.OpenChallenge(ctx, challengeURL)
 
 			fetchErr = &cf.CfChallengeError{
 				URL:        challengeURL,
@@ -127,20 +172,17 @@ func (c *APIClient) FetchJSON(ctx context.Context, url string, result interface{
 	})
 
 	// Make the request
-	if err := c.collector.Visit(url); err != nil {
-		return fmt.Errorf("failed to visit URL: %w", err)
+	if err := c.visit(ctx, url); err != nil {
+		return err
 	}
 
-	// Wait for async operations
-	c.collector.Wait()
-
 	// Check for errors
 	if fetchErr != nil {
 		return fetchErr
 	}
 
 	if statusCode != 200 {
-		return fmt.Errorf("API returned status %d: %s", statusCode, string(responseData))
+		return fmt.Errorf("%w: API returned status %d: %s", ErrNetwork, statusCode, string(responseData))
 	}
 
 	// Unmarshal JSON
@@ -178,7 +220,7 @@ func (c *APIClient) FetchRaw(ctx context.Context, url string) ([]byte, error) {
 			}
 
 			challengeURL := cf.GetChallengeURL(cfInfo, url)
-			cf.OpenInBrowser(challengeURL)
+			cf.OpenChallenge(ctx, challengeURL)
 
 			fetchErr = &cf.CfChallengeError{
 				URL:        challengeURL,
@@ -189,14 +231,14 @@ func (c *APIClient) FetchRaw(ctx context.Context, url string) ([]byte, error) {
 	})
 
 	c.collector.OnError(func(r *colly.Response, err error) {
-		fetchErr = fmt.Errorf("request failed: %w", err)
+		fetchErr = fmt.Errorf("%w: request failed: %w", ErrNetwork, err)
 
 		// Check for CF challenge on error
 		isCF, cfInfo, _ := cf.DetectFromColly(r)
 		if isCF {
 			log.Printf("[APIClient] CF challenge detected on error")
 			challengeURL := cf.GetChallengeURL(cfInfo, url)
-			cf.OpenInBrowser(challengeURL)
+			cf.OpenChallenge(ctx, challengeURL)
 
 			fetchErr = &cf.CfChallengeError{
 				URL:        challengeURL,
@@ -207,20 +249,17 @@ func (c *APIClient) FetchRaw(ctx context.Context, url string) ([]byte, error) {
 	})
 
 	// Make the request
-	if err := c.collector.Visit(url); err != nil {
-		return nil, fmt.Errorf("failed to visit URL: %w", err)
+	if err := c.visit(ctx, url); err != nil {
+		return nil, err
 	}
 
-	// Wait for async operations
-	c.collector.Wait()
-
 	// Check for errors
 	if fetchErr != nil {
 		return nil, fetchErr
 	}
 
 	if statusCode != 200 {
-		return nil, fmt.Errorf("API returned status %d: %s", statusCode, string(responseData))
+		return nil, fmt.Errorf("%w: API returned status %d: %s", ErrNetwork, statusCode, string(responseData))
 	}
 
 	return responseData, nil
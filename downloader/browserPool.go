@@ -0,0 +1,356 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"kansho/config"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+// BrowserLauncher starts a browser for the pool and returns the root browser
+// context. Cancelling the returned CancelFunc must shut the browser down.
+type BrowserLauncher func(opts []chromedp.ExecAllocatorOption) (context.Context, context.CancelFunc, error)
+
+// warmBrowser is a running Chrome instance kept alive between navigations
+type warmBrowser struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	inUse  int
+}
+
+// BrowserPool keeps Chrome instances warm so browser based sites do not pay the
+// Chrome cold start on every chapter. Each session handed out is a fresh tab in a
+// browser context of its own (like an incognito window) in a warm browser, thrown
+// away when the tab is released. Tabs in use at the same time never see each
+// other's cookies or cache, and releasing one leaves the others and, with a remote
+// browser, the user's own profile alone.
+//
+// Browsers are keyed by User-Agent because the UA is a launch flag; sites with
+// captured CF entropy get their own browser instance.
+type BrowserPool struct {
+	mu       sync.Mutex
+	browsers map[string]*warmBrowser
+	launch   BrowserLauncher
+	closed   bool
+
+	// launching holds a channel per User-Agent whose browser is being launched, it
+	// is closed once the launch is over. Launches run outside mu.
+	launching map[string]chan struct{}
+
+	// remote is set when tabs live in a shared remote browser, launch flags are
+	// ignored there so the User-Agent is applied per tab instead
+	remote bool
+}
+
+// NewBrowserPool creates an empty pool. A nil launcher uses the default chromedp
+// exec allocator.
+func NewBrowserPool(launch BrowserLauncher) *BrowserPool {
+	if launch == nil {
+		launch = launchExecBrowser
+	}
+	return &BrowserPool{
+		browsers:  make(map[string]*warmBrowser),
+		launch:    launch,
+		launching: make(map[string]chan struct{}),
+	}
+}
+
+var (
+	defaultPoolMu sync.Mutex
+	defaultPool   *BrowserPool
+)
+
+// DefaultBrowserPool returns the process wide browser pool used by NewBrowserSession.
+// When the remote_browser_url setting is set the pool connects to that browser,
+// otherwise a local Chrome is launched.
+func DefaultBrowserPool() *BrowserPool {
+	defaultPoolMu.Lock()
+	defer defaultPoolMu.Unlock()
+
+	if defaultPool == nil {
+		remoteURL := config.LoadSettings().RemoteBrowserURL
+		launch, err := BrowserLauncherFor(remoteURL)
+		if err != nil {
+			log.Printf("[BrowserPool] ⚠️ Ignoring remote browser URL, using local Chrome: %v", err)
+		}
+		defaultPool = NewBrowserPool(launch)
+		defaultPool.remote = launch != nil
+		if defaultPool.remote {
+			log.Printf("[BrowserPool] Using remote browser at %s", remoteURL)
+		}
+	}
+	return defaultPool
+}
+
+// BrowserLauncherFor returns the launcher for a remote DevTools endpoint, or nil
+// (local Chrome) when remoteURL is empty. Invalid URLs return an error.
+func BrowserLauncherFor(remoteURL string) (BrowserLauncher, error) {
+	remoteURL = strings.TrimSpace(remoteURL)
+	if remoteURL == "" {
+		return nil, nil
+	}
+	if err := ValidateRemoteBrowserURL(remoteURL); err != nil {
+		return nil, err
+	}
+	return remoteBrowserLauncher(remoteURL), nil
+}
+
+// ValidateRemoteBrowserURL checks that raw is a DevTools endpoint chromedp can
+// connect to: ws(s)://host:port/devtools/browser/<id>, or ws(s)/http(s)://host:port
+// which is resolved through /json/version
+func ValidateRemoteBrowserURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid remote browser URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "ws", "wss", "http", "https":
+	default:
+		return fmt.Errorf("invalid remote browser URL %q: scheme must be ws, wss, http or https", raw)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("invalid remote browser URL %q: missing host", raw)
+	}
+
+	if _, id, ok := strings.Cut(u.Path, "/devtools/browser/"); ok {
+		if id == "" {
+			return fmt.Errorf("invalid remote browser URL %q: missing browser id", raw)
+		}
+		return nil
+	}
+
+	// Without a browser id the endpoint is discovered via host:port/json/version
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		return fmt.Errorf("invalid remote browser URL %q: missing port", raw)
+	}
+	return nil
+}
+
+// remoteBrowserLauncher connects to an already running browser instead of starting
+// one. Exec allocator options are launch flags and do not apply.
+func remoteBrowserLauncher(remoteURL string) BrowserLauncher {
+	return func(opts []chromedp.ExecAllocatorOption) (context.Context, context.CancelFunc, error) {
+		allocCtx, cancelAlloc := chromedp.NewRemoteAllocator(context.Background(), remoteURL)
+
+		// Connect now so a bad endpoint fails here rather than on the first page.
+		// Cancelling closes our tab and connection, the remote browser keeps running.
+		browserCtx, cancel, err := startBrowser(allocCtx, cancelAlloc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to remote browser: %w", err)
+		}
+		return browserCtx, cancel, nil
+	}
+}
+
+// CloseBrowserPool shuts down every warm browser in the default pool.
+// Should be called before application exit.
+func CloseBrowserPool() {
+	defaultPoolMu.Lock()
+	pool := defaultPool
+	defaultPoolMu.Unlock()
+
+	if pool != nil {
+		pool.Close()
+	}
+}
+
+// launchExecBrowser starts a local Chrome detached from any request context so it
+// outlives the download that first needed it
+func launchExecBrowser(opts []chromedp.ExecAllocatorOption) (context.Context, context.CancelFunc, error) {
+	// A data dir of our own marks the processes as kansho's for KillStrayBrowsers
+	dataDir, err := newBrowserDataDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = append(opts[:len(opts):len(opts)], chromedp.UserDataDir(dataDir))
+
+	allocCtx, cancelExec := chromedp.NewExecAllocator(context.Background(), opts...)
+	// The allocator cancel waits for Chrome to exit, only then is the dir unused
+	cancelAlloc := func() {
+		cancelExec()
+		removeBrowserDataDir(dataDir)
+	}
+
+	// Run with no actions to actually start Chrome, later tabs attach to it
+	browserCtx, cancel, err := startBrowser(allocCtx, cancelAlloc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+	return browserCtx, cancel, nil
+}
+
+// startBrowser opens the root browser context on allocCtx and waits for the browser
+// to come up. The returned cancel closes the browser before its allocator. If the
+// start fails, times out or panics both are cancelled here, so no Chrome is left
+// running without an owner.
+func startBrowser(allocCtx context.Context, cancelAlloc context.CancelFunc) (context.Context, context.CancelFunc, error) {
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	cancel := func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+
+	started := false
+	defer func() {
+		if !started {
+			cancel()
+		}
+	}()
+
+	startCtx, cancelStart := context.WithTimeout(browserCtx, 30*time.Second)
+	defer cancelStart()
+	if err := chromedp.Run(startCtx); err != nil {
+		return nil, nil, err
+	}
+
+	started = true
+	return browserCtx, cancel, nil
+}
+
+// Acquire returns a new tab context in a warm browser for the given User-Agent,
+// launching the browser if none is running yet. The tab is cancelled when ctx is
+// done. The returned release func must be called once the tab is no longer needed.
+func (p *BrowserPool) Acquire(ctx context.Context, userAgent string, opts []chromedp.ExecAllocatorOption) (context.Context, func(), error) {
+	browser, err := p.claimBrowser(userAgent, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The tab is cancelled both by ctx and by release, chromedp's cancel is not
+	// safe to run twice. Cancelling disposes of its browser context too.
+	tabCtx, cancelChromedpTab := chromedp.NewContext(browser.ctx, tabContextOptions(browser.ctx)...)
+	cancelTab := sync.OnceFunc(cancelChromedpTab)
+	stop := context.AfterFunc(ctx, cancelTab)
+
+	if p.remote {
+		if err := chromedp.Run(tabCtx, emulation.SetUserAgentOverride(userAgent)); err != nil {
+			log.Printf("[BrowserPool] Failed to set User-Agent on remote tab: %v", err)
+		}
+	}
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			stop()
+			cancelTab()
+			p.mu.Lock()
+			browser.inUse--
+			p.mu.Unlock()
+		})
+	}
+
+	return tabCtx, release, nil
+}
+
+// claimBrowser returns the warm browser for userAgent with one more tab in use,
+// launching it if needed. Claims for a User-Agent whose browser is being launched
+// wait for that launch, other User-Agents are not held up by it. The lock is
+// released by defer so a panicking launcher does not wedge the pool.
+func (p *BrowserPool) claimBrowser(userAgent string, opts []chromedp.ExecAllocatorOption) (*warmBrowser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		if p.closed {
+			return nil, fmt.Errorf("browser pool is closed")
+		}
+		launching, ok := p.launching[userAgent]
+		if !ok {
+			break
+		}
+		p.mu.Unlock()
+		<-launching
+		p.mu.Lock()
+	}
+
+	browser, ok := p.browsers[userAgent]
+	if ok && browser.ctx.Err() == nil {
+		log.Printf("[BrowserPool] Reusing warm browser (UA: %s, tabs in use: %d)", userAgent, browser.inUse)
+		browser.inUse++
+		return browser, nil
+	}
+
+	sweep := false
+	if ok {
+		// Browser exited or crashed since it was last used, start a new one. A crash
+		// can orphan Chrome's helper processes, sweep them once the old one is shut.
+		log.Printf("[BrowserPool] Warm browser is gone, relaunching (UA: %s)", userAgent)
+		browser.cancel()
+		delete(p.browsers, userAgent)
+		sweep = true
+	}
+
+	browser, err := p.launchBrowser(userAgent, opts, sweep)
+	if err != nil {
+		return nil, err
+	}
+	if p.closed {
+		// Closed while launching, Close could not see this browser to shut it
+		browser.cancel()
+		return nil, fmt.Errorf("browser pool is closed")
+	}
+	p.browsers[userAgent] = browser
+	browser.inUse++
+	return browser, nil
+}
+
+// launchBrowser starts a browser for userAgent with p.mu released, marking the
+// launch in p.launching meanwhile. It is called, and returns or panics, with p.mu
+// held.
+func (p *BrowserPool) launchBrowser(userAgent string, opts []chromedp.ExecAllocatorOption, sweep bool) (*warmBrowser, error) {
+	done := make(chan struct{})
+	p.launching[userAgent] = done
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.launching, userAgent)
+		close(done)
+	}()
+
+	if sweep {
+		sweepStrayBrowsers()
+	}
+	log.Printf("[BrowserPool] Launching browser (UA: %s)", userAgent)
+	rootCtx, cancel, err := p.launch(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &warmBrowser{ctx: rootCtx, cancel: cancel}, nil
+}
+
+// tabContextOptions gives a tab of the browser at browserCtx a new browser context,
+// so its cookies and cache are its own. A root context that is not a started
+// chromedp browser (a test launcher) has none to create.
+func tabContextOptions(browserCtx context.Context) []chromedp.ContextOption {
+	if c := chromedp.FromContext(browserCtx); c == nil || c.Browser == nil {
+		return nil
+	}
+	return []chromedp.ContextOption{chromedp.WithNewBrowserContext()}
+}
+
+// Close shuts down every warm browser, any tabs still in use are cancelled with it
+func (p *BrowserPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+
+	for ua, browser := range p.browsers {
+		log.Printf("[BrowserPool] Closing browser (UA: %s)", ua)
+		browser.cancel()
+	}
+	p.browsers = make(map[string]*warmBrowser)
+}
@@ -0,0 +1,61 @@
+package downloader
+
+import (
+	"context"
+	"log"
+	"maps"
+	"sync"
+	"time"
+
+	"kansho/config"
+)
+
+// DefaultChapterListCacheTTL is how long a scraped chapter list is reused by later
+// downloads of the same series. Scraping a list can mean a browser session or dozens
+// of API pages, checking a series twice in a few minutes rarely finds a new chapter.
+const DefaultChapterListCacheTTL = 10 * time.Minute
+
+// cachedChapterList is a chapter list and when it was scraped
+type cachedChapterList struct {
+	list    ChapterList
+	fetched time.Time
+}
+
+var (
+	chapterListCacheMu sync.Mutex
+	chapterListCache   = map[string]cachedChapterList{}
+)
+
+// CachedChapterList returns the series' chapter list through FetchChapterList, or a
+// copy of the one scraped less than ttl ago. A ttl of 0 or less, or a context set up
+// with config.WithChapterListRefresh, always scrapes. Failed or empty lists are never
+// cached. The result is the caller's to modify.
+func CachedChapterList(ctx context.Context, mangaURL string, site SitePlugin, ttl time.Duration) (ChapterList, error) {
+	key := site.GetSiteName() + " " + mangaURL
+
+	if ttl > 0 && !config.ChapterListRefresh(ctx) {
+		chapterListCacheMu.Lock()
+		cached, ok := chapterListCache[key]
+		chapterListCacheMu.Unlock()
+		if ok && time.Since(cached.fetched) < ttl {
+			log.Printf("[Downloader] Reusing chapter list of %s scraped %v ago", mangaURL, time.Since(cached.fetched).Round(time.Second))
+			config.RunLogf(ctx, "Reusing the chapter list scraped %v ago", time.Since(cached.fetched).Round(time.Second))
+			return cached.list.clone(), nil
+		}
+	}
+
+	list, err := FetchChapterList(ctx, mangaURL, site)
+	if err != nil || len(list.URLs) == 0 {
+		return list, err
+	}
+
+	chapterListCacheMu.Lock()
+	chapterListCache[key] = cachedChapterList{list: list.clone(), fetched: time.Now()}
+	chapterListCacheMu.Unlock()
+	return list, nil
+}
+
+// clone copies the list's maps, the manager deletes the chapters it already has
+func (l ChapterList) clone() ChapterList {
+	return ChapterList{URLs: maps.Clone(l.URLs), Published: maps.Clone(l.Published)}
+}
@@ -7,13 +7,17 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"kansho/cf"
+	"kansho/parser"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 )
@@ -25,9 +29,18 @@ type BrowserSession struct {
 	domain     string
 	needsCF    bool
 	bypassData *cf.BypassData
+	extras     parser.RequestExtras
+
+	// listenOnce registers the listener adding the extras headers, once per tab
+	listenOnce sync.Once
+
+	// login holds the site's stored login session cookies, nil when there is none
+	login *cf.BypassData
 }
 
-// NewBrowserSession creates a new browser session with optional CF bypass
+// NewBrowserSession creates a new browser session with optional CF bypass.
+// The session is a fresh tab in a warm browser from the DefaultBrowserPool,
+// Close releases the tab back to the pool rather than shutting Chrome down.
 func NewBrowserSession(ctx context.Context, domain string, needsCF bool) (*BrowserSession, error) {
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("headless", "new"),
@@ -37,6 +50,9 @@ func NewBrowserSession(ctx context.Context, domain string, needsCF bool) (*Brows
 		chromedp.Flag("disable-dev-shm-usage", true),
 	)
 
+	const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36"
+	userAgent := defaultUserAgent
+
 	var bypassData *cf.BypassData
 	if needsCF {
 		data, err := cf.LoadFromFile(domain)
@@ -47,30 +63,38 @@ func NewBrowserSession(ctx context.Context, domain string, needsCF bool) (*Brows
 			log.Printf("[Browser:%s] ✓ Loaded CF bypass data", domain)
 
 			if ua := strings.TrimSpace(data.Entropy.UserAgent); ua != "" {
-				opts = append(opts, chromedp.UserAgent(ua))
+				userAgent = ua
 				log.Printf("[Browser:%s] Using captured User-Agent: %s", domain, ua)
 			} else {
 				log.Printf("[Browser:%s] WARNING: bypass data has empty User-Agent, falling back to default", domain)
-				opts = append(opts,
-					chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36"),
-				)
 			}
 		}
-	} else {
-		opts = append(opts,
-			chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36"),
-		)
 	}
+	opts = append(opts, chromedp.UserAgent(userAgent))
 
-	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, opts...)
-	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	// Login cookies are not cf bypass data, they are sent whether or not needsCF
+	login, loginErr := cf.LoadLoginSession(domain)
+	if loginErr == nil {
+		log.Printf("[Browser:%s] ✓ Loaded login session", domain)
+	}
+
+	tabCtx, release, err := DefaultBrowserPool().Acquire(ctx, userAgent, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire browser: %w", err)
+	}
+	// The tab lives in the pool's browser, not in ctx, carry over how challenges are solved
+	if cf.ClearanceRefreshEnabled(ctx) {
+		tabCtx = cf.WithClearanceRefresh(tabCtx)
+	}
 
 	session := &BrowserSession{
-		ctx:        browserCtx,
-		cancel:     func() { cancelBrowser(); cancelAlloc() },
+		ctx:        tabCtx,
+		cancel:     release,
 		domain:     domain,
 		needsCF:    needsCF,
 		bypassData: bypassData,
+		extras:     parser.RequestExtrasFrom(ctx),
+		login:      login,
 	}
 
 	return session, nil
@@ -87,14 +111,122 @@ func normalizeDomain(d string) string {
 	return d
 }
 
-// injectCookies builds and injects cookies into the browser
+// injectCookies builds and injects cookies into the browser, the CF bypass cookies
+// and the site login session followed by the bookmark's RequestExtras so those win
 func (bs *BrowserSession) injectCookies(tasks *[]chromedp.Action) int {
-	if bs.bypassData == nil {
+	if bs.bypassData == nil && bs.login == nil && bs.extras.IsZero() {
 		return 0
 	}
 
 	injected := 0
 	var cookies []*network.CookieParam
+	if bs.bypassData != nil {
+		cookies = bs.bypassCookies()
+		injected = len(cookies)
+		cf.LogCFBrowserAction("InjectCookies", bs.domain, len(cookies), true, nil)
+	}
+
+	if bs.login != nil {
+		for _, ck := range bs.login.SessionCookies() {
+			path := ck.Path
+			if path == "" {
+				path = "/"
+			}
+			domain := ck.Domain
+			if domain == "" {
+				domain = bs.domain
+			}
+			cookies = append(cookies, &network.CookieParam{
+				Name:     ck.Name,
+				Value:    ck.Value,
+				Domain:   normalizeDomain(domain),
+				Path:     path,
+				Secure:   ck.Secure,
+				HTTPOnly: ck.HttpOnly,
+			})
+			injected++
+		}
+	}
+
+	// Scoped to the bookmark's host like the extras headers, the browser sends them
+	// nowhere else
+	if bs.extras.Host != "" {
+		for _, ck := range bs.extras.Cookies {
+			cookies = append(cookies, &network.CookieParam{
+				Name:   ck.Name,
+				Value:  ck.Value,
+				Domain: normalizeDomain(bs.extras.Host),
+				Path:   "/",
+			})
+			injected++
+		}
+	}
+	interceptHeaders := bs.extras.Host != "" && len(bs.extras.Headers) > 0
+
+	*tasks = append(*tasks, chromedp.ActionFunc(func(ctx context.Context) error {
+		if len(cookies) > 0 {
+			if err := network.SetCookies(cookies).Do(ctx); err != nil {
+				return err
+			}
+		}
+		if interceptHeaders {
+			// Paused requests of the bookmark's host get the headers added, every
+			// other request (CDN, trackers) goes out as the page made it
+			bs.listenOnce.Do(func() {
+				chromedp.ListenTarget(bs.ctx, func(ev interface{}) {
+					if paused, ok := ev.(*fetch.EventRequestPaused); ok {
+						go bs.continueWithExtras(paused)
+					}
+				})
+			})
+			return fetch.Enable().WithPatterns(extrasHeaderPatterns(bs.extras.Host)).Do(ctx)
+		}
+		return nil
+	}))
+
+	return injected
+}
+
+// extrasHeaderPatterns are the Fetch patterns pausing the requests to host and its
+// subdomains, with or without a port. continueWithExtras checks the host again.
+func extrasHeaderPatterns(host string) []*fetch.RequestPattern {
+	var patterns []*fetch.RequestPattern
+	for _, pattern := range []string{"*://%s/*", "*://%s:*", "*://*.%s/*", "*://*.%s:*"} {
+		patterns = append(patterns, &fetch.RequestPattern{URLPattern: fmt.Sprintf(pattern, host)})
+	}
+	return patterns
+}
+
+// continueWithExtras resumes a request paused by the Fetch patterns, with the
+// bookmark's extras headers added when it goes to the bookmark's host
+func (bs *BrowserSession) continueWithExtras(ev *fetch.EventRequestPaused) {
+	c := chromedp.FromContext(bs.ctx)
+	if c == nil || c.Target == nil {
+		return
+	}
+	resume := fetch.ContinueRequest(ev.RequestID)
+	if target, err := url.Parse(ev.Request.URL); err == nil && bs.extras.AppliesTo(target) {
+		header := make(http.Header, len(ev.Request.Headers)+len(bs.extras.Headers))
+		for name, value := range ev.Request.Headers {
+			header.Set(name, fmt.Sprint(value))
+		}
+		for name, value := range bs.extras.Headers {
+			header.Set(name, value)
+		}
+		entries := make([]*fetch.HeaderEntry, 0, len(header))
+		for name := range header {
+			entries = append(entries, &fetch.HeaderEntry{Name: name, Value: header.Get(name)})
+		}
+		resume = resume.WithHeaders(entries)
+	}
+	if err := resume.Do(cdp.WithExecutor(bs.ctx, c.Target)); err != nil && bs.ctx.Err() == nil {
+		log.Printf("[Browser:%s] Failed to resume paused request: %v", bs.domain, err)
+	}
+}
+
+// bypassCookies returns the cookies of the session's CF bypass data
+func (bs *BrowserSession) bypassCookies() []*network.CookieParam {
+	var cookies []*network.CookieParam
 
 	if bs.bypassData.CfClearanceStruct != nil {
 		domain := normalizeDomain(bs.bypassData.CfClearanceStruct.Domain)
@@ -113,7 +245,6 @@ func (bs *BrowserSession) injectCookies(tasks *[]chromedp.Action) int {
 		}
 
 		cookies = append(cookies, cookie)
-		injected++
 	}
 
 	for _, ck := range bs.bypassData.AllCookies {
@@ -136,16 +267,9 @@ func (bs *BrowserSession) injectCookies(tasks *[]chromedp.Action) int {
 		}
 
 		cookies = append(cookies, cookie)
-		injected++
 	}
 
-	cf.LogCFBrowserAction("InjectCookies", bs.domain, len(cookies), true, nil)
-
-	*tasks = append(*tasks, chromedp.ActionFunc(func(ctx context.Context) error {
-		return network.SetCookies(cookies).Do(ctx)
-	}))
-
-	return injected
+	return cookies
 }
 
 // dumpBrowserCookies logs all cookies currently in Chromium
@@ -217,7 +341,7 @@ func (bs *BrowserSession) NavigateAndEvaluate(url, waitSelector, javascript stri
 			}
 
 			challengeURL := cf.GetChallengeURL(cfInfo, url)
-			cf.OpenInBrowser(challengeURL)
+			cf.OpenChallenge(bs.ctx, challengeURL)
 
 			return &cf.CfChallengeError{
 				URL:        challengeURL,
@@ -294,7 +418,7 @@ func (bs *BrowserSession) Navigate(url string, waitSelector string) error {
 		}
 
 		challengeURL := cf.GetChallengeURL(cfInfo, url)
-		cf.OpenInBrowser(challengeURL)
+		cf.OpenChallenge(bs.ctx, challengeURL)
 
 		return &cf.CfChallengeError{
 			URL:        challengeURL,
@@ -411,6 +535,26 @@ func FetchHTMLBatched(ctx context.Context, url, domain string, needsCF bool, dbg
 
 	log.Printf("[Browser:%s] FetchHTMLBatched complete, HTML length: %d", domain, len(html))
 
+	// The batched fetch has no WaitSelector to fail on, so a challenge page would
+	// otherwise be handed to the site parser as if it were the chapter
+	if isCF, cfInfo, _ := cf.DetectHTML(html); isCF {
+		cf.LogCFBrowserAction("CFChallengeDetected", url, 0, false, nil)
+
+		if session.bypassData != nil {
+			cf.MarkCookieAsFailed(domain)
+			cf.DeleteDomain(domain)
+		}
+
+		challengeURL := cf.GetChallengeURL(cfInfo, url)
+		cf.OpenChallenge(ctx, challengeURL)
+
+		return "", &cf.CfChallengeError{
+			URL:        challengeURL,
+			StatusCode: cfInfo.StatusCode,
+			Indicators: cfInfo.Indicators,
+		}
+	}
+
 	// Save rendered HTML to disk if the site has debugging enabled
 	if dbg != nil && dbg.SaveHTML && dbg.HTMLPath != "" {
 		if err := os.WriteFile(dbg.HTMLPath, []byte(html), 0644); err != nil {
@@ -0,0 +1,131 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"kansho/cf"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// ClearanceRefreshTimeout is how long RefreshClearance waits for the challenge to be
+// solved, long enough for the user to click through a Turnstile checkbox
+const ClearanceRefreshTimeout = 3 * time.Minute
+
+// clearancePollInterval is how often the refresh browser's cookies are read while
+// the challenge runs
+const clearancePollInterval = time.Second
+
+// refreshMu runs one refresh at a time, a second series of the same site waiting on
+// a challenge then uses the cookie the first one captured
+var refreshMu sync.Mutex
+
+// RefreshClearance gets a new cf_clearance for the site of target, a challenge URL
+// or a bare domain: it opens target in a visible browser, waits for the JS or
+// Turnstile challenge to pass, and saves the browser's cookies and user agent as the
+// bypass data of the domain with cf.SaveToFile. Like the login browser it runs in
+// its own data dir, so KillStrayBrowsers finds it when kansho exits mid-challenge.
+// Returns cf.ErrBrowserDisabled when cf.BrowserOpeningDisabled, there is no one to
+// click through a Turnstile.
+func RefreshClearance(ctx context.Context, target string) (*cf.BypassData, error) {
+	if !strings.Contains(target, "://") {
+		target = "https://" + target + "/"
+	}
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid challenge URL %q", target)
+	}
+	domain := parsed.Hostname()
+	if cf.BrowserOpeningDisabled() {
+		return nil, cf.ErrBrowserDisabled
+	}
+
+	started := time.Now()
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+	if data, ok := refreshedSince(domain, started); ok {
+		log.Printf("[CF:%s] Refreshed while waiting, using it", domain)
+		return data, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ClearanceRefreshTimeout)
+	defer cancel()
+	dataDir, err := newBrowserDataDir()
+	if err != nil {
+		return nil, err
+	}
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", false),
+		chromedp.Flag("disable-blink-features", "AutomationControlled"),
+		chromedp.UserDataDir(dataDir),
+	)
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, opts...)
+	defer func() {
+		cancelAlloc()
+		removeBrowserDataDir(dataDir)
+	}()
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	defer cancelBrowser()
+
+	log.Printf("[CF:%s] Opening challenge browser at %s", domain, target)
+	var userAgent string
+	if err := chromedp.Run(browserCtx,
+		chromedp.Navigate(target),
+		chromedp.Evaluate(`navigator.userAgent`, &userAgent),
+	); err != nil {
+		return nil, fmt.Errorf("failed to open challenge page: %w", err)
+	}
+
+	cookieURLs := []string{parsed.Scheme + "://" + parsed.Host + "/", target}
+	ticker := time.NewTicker(clearancePollInterval)
+	defer ticker.Stop()
+	for {
+		var cookies []*network.Cookie
+		err := chromedp.Run(browserCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = network.GetCookies().WithURLs(cookieURLs).Do(ctx)
+			return err
+		}))
+		if err == nil {
+			if data, err := cf.ClearanceFromCookies(target, userAgent, cf.BrowserCookies(cookies)); err == nil {
+				if err := cf.SaveToFile(data, domain); err != nil {
+					return nil, err
+				}
+				log.Printf("[CF:%s] ✓ Refreshed cf_clearance in %v", domain, time.Since(started).Round(time.Second))
+				return data, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("challenge for %s not solved within %v", domain, ClearanceRefreshTimeout)
+			}
+			return nil, ctx.Err()
+		case <-browserCtx.Done():
+			return nil, fmt.Errorf("browser closed before the challenge for %s was solved", domain)
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshedSince returns the stored bypass data of domain when it was captured
+// after since and passes cf.CheckClearance
+func refreshedSince(domain string, since time.Time) (*cf.BypassData, bool) {
+	if cf.CheckClearance(domain) != nil {
+		return nil, false
+	}
+	data, err := cf.LoadFromFile(domain)
+	if err != nil || data.CfClearanceCapturedAt.Before(since) {
+		return nil, false
+	}
+	return data, true
+}
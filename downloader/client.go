@@ -3,6 +3,7 @@ package downloader
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -144,10 +145,14 @@ func (c *HTTPClient) fetchHTMLAttempt(ctx context.Context, targetURL string) (st
 		// Use generic browser headers
 		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36")
 	}
+	for _, cookie := range cf.LoginCookies(targetURL) {
+		req.AddCookie(cookie)
+	}
+	parser.RequestExtrasFrom(ctx).Apply(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%w: %w", ErrNetwork, err)
 	}
 	defer resp.Body.Close()
 
@@ -206,7 +211,7 @@ func (c *HTTPClient) fetchHTMLAttempt(ctx context.Context, targetURL string) (st
 
 		// Open browser for manual solve
 		challengeURL := cf.GetChallengeURL(cfInfo, targetURL)
-		if err := cf.OpenInBrowser(challengeURL); err != nil {
+		if err := cf.OpenChallenge(ctx, challengeURL); err != nil && !errors.Is(err, cf.ErrBrowserDisabled) {
 			return "", fmt.Errorf("CF detected but failed to open browser: %w", err)
 		}
 
@@ -218,7 +223,7 @@ func (c *HTTPClient) fetchHTMLAttempt(ctx context.Context, targetURL string) (st
 	}
 
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return "", fmt.Errorf("%w: unexpected status code: %d", ErrNetwork, resp.StatusCode)
 	}
 
 	return string(bodyBytes), nil
@@ -0,0 +1,44 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// Error kinds returned by the downloader and site plugins. They are wrapped with
+// the technical detail (fmt.Errorf("...: %w", ErrX)) so logs keep the full
+// message while errors.Is lets the UI explain what went wrong.
+//
+// Cloudflare blocks are reported as *cf.CfChallengeError rather than a sentinel
+// because the challenge URL is needed to open the browser.
+var (
+	// ErrNoChaptersFound means the series page was read but listed no chapters
+	ErrNoChaptersFound = errors.New("no chapters found")
+
+	// ErrSiteChanged means the page no longer has the structure the site plugin
+	// expects (selector matched nothing, embedded JSON missing)
+	ErrSiteChanged = errors.New("site layout changed")
+
+	// ErrSiteMaintenance means the site answered with a maintenance or site-wide
+	// error page instead of the series, the run should be retried later
+	ErrSiteMaintenance = errors.New("site is down for maintenance")
+
+	// ErrNetwork means the site could not be reached or returned an error status
+	ErrNetwork = errors.New("network error")
+
+	// ErrDiskFull means the library or temp directory ran out of space
+	ErrDiskFull = errors.New("disk full")
+
+	// ErrShortChapter means a chapter has far fewer pages than the chapters around
+	// it and was rejected as incomplete
+	ErrShortChapter = errors.New("chapter has unusually few pages")
+)
+
+// diskError wraps err with ErrDiskFull when the underlying cause is ENOSPC
+func diskError(err error) error {
+	if err != nil && errors.Is(err, syscall.ENOSPC) && !errors.Is(err, ErrDiskFull) {
+		return fmt.Errorf("%w: %w", ErrDiskFull, err)
+	}
+	return err
+}
@@ -8,9 +8,13 @@ import (
 	"log"
 	"math"
 	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"kansho/cf"
+	"kansho/config"
+	"kansho/models"
 	"kansho/parser"
 
 	"github.com/PuerkitoBio/goquery"
@@ -28,17 +32,35 @@ func DomainFromURL(rawURL, hint string) string {
 	return parsed.Hostname()
 }
 
+// ChapterPublishedKey is the chapter data key sites set to the chapter's publish
+// time (RFC 3339) when the source exposes one, eg: MangaDex publishAt
+const ChapterPublishedKey = "published"
+
+// ChapterList is a site's chapter list: URLs maps cbz filename to chapter URL, and
+// Published holds the publish time of the chapters whose site reported one
+type ChapterList struct {
+	URLs      map[string]string
+	Published map[string]time.Time
+}
+
 // FetchChapterURLs fetches chapter URLs using site's extraction method
 func FetchChapterURLs(ctx context.Context, mangaURL string, site SitePlugin) (map[string]string, error) {
-	chapterMap, err := extractChapters(ctx, mangaURL, site)
+	chapters, err := FetchChapterList(ctx, mangaURL, site)
+	return chapters.URLs, err
+}
+
+// FetchChapterList fetches the chapter list using site's extraction method, with
+// the publish times the site exposes. Retries like FetchChapterImages.
+func FetchChapterList(ctx context.Context, mangaURL string, site SitePlugin) (ChapterList, error) {
+	chapters, err := extractChapters(ctx, mangaURL, site)
 	if err == nil {
-		return chapterMap, nil
+		return chapters, nil
 	}
 
 	var cfErr *cf.CfChallengeError
 	if errors.As(err, &cfErr) {
 		log.Printf("[Downloader] ⚠️ CF challenge detected - returning error to queue")
-		return nil, cfErr
+		return ChapterList{}, cfErr
 	}
 
 	maxRetries := 3
@@ -50,25 +72,25 @@ func FetchChapterURLs(ctx context.Context, mangaURL string, site SitePlugin) (ma
 
 		if !parser.SleepCtx(ctx, backoff) {
 			log.Printf("[Downloader] Chapter fetch cancelled during retry backoff")
-			return nil, ctx.Err()
+			return ChapterList{}, ctx.Err()
 		}
 
-		chapterMap, err := extractChapters(ctx, mangaURL, site)
+		chapters, err := extractChapters(ctx, mangaURL, site)
 		if err == nil {
 			log.Printf("[Downloader] ✓ Success fetching chapters after %d retries", attempt+1)
-			return chapterMap, nil
+			return chapters, nil
 		}
 
 		if errors.As(err, &cfErr) {
 			log.Printf("[Downloader] ⚠️ CF challenge detected - returning error to queue")
-			return nil, cfErr
+			return ChapterList{}, cfErr
 		}
 
 		lastErr = err
 		log.Printf("[Downloader] Failed to fetch chapters (attempt %d/%d): %v", attempt+1, maxRetries, err)
 	}
 
-	return nil, fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
+	return ChapterList{}, fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
 }
 
 // FetchChapterImages fetches image URLs using site's extraction method
@@ -115,7 +137,7 @@ func FetchChapterImages(ctx context.Context, chapterURL string, site SitePlugin)
 }
 
 // extractChapters uses the site's extraction method to get chapters
-func extractChapters(ctx context.Context, mangaURL string, site SitePlugin) (map[string]string, error) {
+func extractChapters(ctx context.Context, mangaURL string, site SitePlugin) (ChapterList, error) {
 	method := site.GetChapterExtractionMethod()
 
 	switch method.Type {
@@ -124,34 +146,265 @@ func extractChapters(ctx context.Context, mangaURL string, site SitePlugin) (map
 	case "html_selector":
 		return extractChaptersWithSelector(ctx, mangaURL, site, method)
 	case "custom":
-		return extractChaptersCustom(ctx, mangaURL, site, method)
+		chapterMap, err := extractChaptersCustom(ctx, mangaURL, site, method)
+		return ChapterList{URLs: chapterMap}, err
 	case "api":
 		return extractChaptersWithAPI(ctx, mangaURL, site, method)
 	default:
-		return nil, fmt.Errorf("unknown extraction type: %s", method.Type)
+		return ChapterList{}, fmt.Errorf("unknown extraction type: %s", method.Type)
 	}
 }
 
+// chapterMapBatch is how many raw chapter entries mapChapterData normalizes between
+// progress lines. Series with thousands of chapters are mapped batch by batch, raw
+// entries are dropped once mapped so they can be freed.
+const chapterMapBatch = 500
+
+// mapChapterData normalizes the raw chapter data of every extraction method into a
+// ChapterList. Specials are named by SpecialChapters, entries the site cannot name
+// are skipped. When two entries normalize to the same filename the last one wins,
+// unless keepFirst is set.
+//
+// Per-chapter lines are only logged with verbose logging on, otherwise one summary
+// line is logged however long the list is.
+func mapChapterData(rawData []map[string]string, mangaURL string, site SitePlugin, keepFirst bool) ChapterList {
+	result := ChapterList{URLs: make(map[string]string, len(rawData))}
+	total := len(rawData)
+	skipped, duplicates := 0, 0
+	var specials []map[string]string
+
+	for start := 0; start < total; start += chapterMapBatch {
+		end := min(start+chapterMapBatch, total)
+		for i := start; i < end; i++ {
+			data := rawData[i]
+			rawData[i] = nil
+
+			if data["num"] == "" && parser.IsSpecialChapter(data["text"]) {
+				specials = append(specials, data)
+				continue
+			}
+
+			filename := site.NormalizeChapterFilename(data)
+			if filename == "" {
+				skipped++
+				parser.Debugf("[Downloader] Skipping chapter entry without a name: %v", data)
+				continue
+			}
+			url := site.NormalizeChapterURL(data["url"], mangaURL)
+
+			if existingURL, exists := result.URLs[filename]; exists {
+				duplicates++
+				if keepFirst {
+					parser.Debugf("[Downloader] Duplicate chapter %s found (existing: %s, new: %s) - keeping first",
+						filename, existingURL, url)
+					continue
+				}
+				parser.Debugf("[Downloader] Duplicate chapter %s found (existing: %s, new: %s) - keeping last",
+					filename, existingURL, url)
+			}
+
+			parser.Debugf("[Downloader] Mapped chapter %s → %s", filename, url)
+			result.add(filename, url, data)
+		}
+		if total > chapterMapBatch {
+			parser.Debugf("[Downloader] Mapped %d/%d chapter entries", end, total)
+		}
+	}
+
+	if len(specials) > 0 {
+		urls := make([]string, len(specials))
+		for i, data := range specials {
+			urls[i] = site.NormalizeChapterURL(data["url"], mangaURL)
+		}
+		names := SpecialChapterNames(urls)
+		if names == nil {
+			skipped += len(specials)
+		}
+		for i, filename := range names {
+			if filename != "" {
+				parser.Debugf("[Downloader] Mapped special %q %s → %s", specials[i]["text"], filename, urls[i])
+				result.add(filename, urls[i], specials[i])
+			}
+		}
+	}
+
+	log.Printf("[Downloader] Mapped %d chapters from %d entries (%d duplicates, %d skipped)",
+		len(result.URLs), total, duplicates, skipped)
+	if duplicates > 0 && !parser.VerboseLogging() {
+		log.Printf("[Downloader] ⚠️ %d duplicate chapter entries, enable verbose_logging to list them", duplicates)
+	}
+	return result
+}
+
+// SpecialChapterNames names the specials of a chapter list (see
+// parser.IsSpecialChapter), given their URLs in the order the site lists them,
+// newest first. The oldest is sp001.cbz so a new special does not rename the ones
+// already downloaded. A URL listed twice is named once, "" for the repeats. Nil
+// when the skip_special_chapters setting leaves specials out.
+func SpecialChapterNames(urls []string) []string {
+	if config.LoadSettings().SkipSpecialChapters {
+		log.Printf("[Downloader] Skipping %d special chapters (skip_special_chapters)", len(urls))
+		return nil
+	}
+
+	names := make([]string, len(urls))
+	seen := make(map[string]bool, len(urls))
+	n := 0
+	for i := len(urls) - 1; i >= 0; i-- {
+		if seen[urls[i]] {
+			continue
+		}
+		seen[urls[i]] = true
+		n++
+		names[i] = parser.SpecialChapterFilename(n)
+	}
+	return names
+}
+
+// add records a chapter from its chapter data under filename, with the publish
+// time when the data has a valid one
+func (l *ChapterList) add(filename, chapterURL string, data map[string]string) {
+	l.URLs[filename] = chapterURL
+
+	raw := strings.TrimSpace(data[ChapterPublishedKey])
+	if raw == "" {
+		return
+	}
+	published, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		log.Printf("[Downloader] ⚠️ Ignoring unparseable publish time %q of %s: %v", raw, filename, err)
+		return
+	}
+	if l.Published == nil {
+		l.Published = make(map[string]time.Time)
+	}
+	l.Published[filename] = published
+}
+
 // extractImages uses the site's extraction method to get images
 func extractImages(ctx context.Context, chapterURL string, site SitePlugin) ([]string, error) {
 	method := site.GetImageExtractionMethod()
 
+	var imageURLs []string
+	var err error
 	switch method.Type {
 	case "javascript":
-		return extractImagesWithJS(ctx, chapterURL, site, method)
+		imageURLs, err = extractImagesWithJS(ctx, chapterURL, site, method)
 	case "html_selector":
-		return extractImagesWithSelector(ctx, chapterURL, site, method)
+		imageURLs, err = extractReaderPages(ctx, chapterURL, site, method, extractImagesWithSelector)
 	case "custom":
-		return extractImagesCustom(ctx, chapterURL, site, method)
+		imageURLs, err = extractReaderPages(ctx, chapterURL, site, method, extractImagesCustom)
 	case "api":
-		return extractImagesWithAPI(ctx, chapterURL, site, method)
+		imageURLs, err = extractImagesWithAPI(ctx, chapterURL, site, method)
 	default:
 		return nil, fmt.Errorf("unknown extraction type: %s", method.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+	imageURLs = TransformImageURLs(imageURLs, method.ImageURLTransforms)
+	return FilterImageHosts(DedupeImageURLs(imageURLs), method.ImageHosts), nil
+}
+
+// TransformImageURLs applies every transform to each image URL in order (see
+// models.ImageURLTransform). Data URIs are left as they are, a transform whose
+// pattern does not compile is logged and skipped.
+func TransformImageURLs(imageURLs []string, transforms []models.ImageURLTransform) []string {
+	if len(transforms) == 0 {
+		return imageURLs
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(transforms))
+	replacements := make([]string, 0, len(transforms))
+	for _, transform := range transforms {
+		re, err := regexp.Compile(transform.Match)
+		if err != nil {
+			log.Printf("[Downloader] ⚠️ Ignoring image URL transform %q: %v", transform.Match, err)
+			continue
+		}
+		patterns = append(patterns, re)
+		replacements = append(replacements, transform.Replace)
+	}
+
+	transformed := make([]string, len(imageURLs))
+	for i, imageURL := range imageURLs {
+		if !parser.IsDataURI(imageURL) {
+			for j, re := range patterns {
+				imageURL = re.ReplaceAllString(imageURL, replacements[j])
+			}
+		}
+		transformed[i] = imageURL
+	}
+	return transformed
+}
+
+// FilterImageHosts drops image URLs whose host is not allowed by hosts (see
+// models.ImageHosts). URLs without a host (relative paths, data URIs) are kept, a
+// nil filter keeps everything.
+func FilterImageHosts(imageURLs []string, hosts *models.ImageHosts) []string {
+	if hosts == nil || len(hosts.Allow) == 0 && len(hosts.Deny) == 0 {
+		return imageURLs
+	}
+
+	kept := imageURLs[:0:0]
+	for _, imageURL := range imageURLs {
+		u, err := url.Parse(imageURL)
+		if err != nil || u.Hostname() == "" {
+			kept = append(kept, imageURL)
+			continue
+		}
+
+		host := u.Hostname()
+		if len(hosts.Allow) > 0 && !matchesImageHost(host, hosts.Allow) || matchesImageHost(host, hosts.Deny) {
+			log.Printf("[Downloader] Dropping image from filtered host %s: %s", host, imageURL)
+			continue
+		}
+		kept = append(kept, imageURL)
+	}
+
+	if len(kept) == 0 && len(imageURLs) > 0 {
+		log.Printf("[Downloader] ⚠️ Image host filter dropped all %d images, check the site's image_hosts config", len(imageURLs))
+	}
+	return kept
+}
+
+// matchesImageHost reports whether host is, or is a subdomain of, any pattern
+func matchesImageHost(host string, patterns []string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(pattern), "*."))
+		if pattern == "" {
+			continue
+		}
+		if host == pattern || strings.HasSuffix(host, "."+pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// DedupeImageURLs drops repeated image URLs keeping the first occurrence, so an
+// image the page renders twice (eg: a banner above and below the chapter) does not
+// become two pages. Only exact URL matches are removed.
+func DedupeImageURLs(imageURLs []string) []string {
+	seen := make(map[string]bool, len(imageURLs))
+	unique := imageURLs[:0:0]
+	for _, imageURL := range imageURLs {
+		if seen[imageURL] {
+			continue
+		}
+		seen[imageURL] = true
+		unique = append(unique, imageURL)
+	}
+
+	if dropped := len(imageURLs) - len(unique); dropped > 0 {
+		log.Printf("[Downloader] Dropped %d duplicate image URLs", dropped)
+	}
+	return unique
 }
 
 // extractChaptersWithJS uses JavaScript evaluation
-func extractChaptersWithJS(ctx context.Context, mangaURL string, site SitePlugin, method *ChapterExtractionMethod) (map[string]string, error) {
+func extractChaptersWithJS(ctx context.Context, mangaURL string, site SitePlugin, method *ChapterExtractionMethod) (ChapterList, error) {
 	// Some sites need the manga URL opened in the user's real browser before
 	// extraction so their browser extension captures CF cookies, even when no
 	// CF challenge is detected on the page (e.g. the main manga page has no
@@ -162,10 +415,10 @@ func extractChaptersWithJS(ctx context.Context, mangaURL string, site SitePlugin
 		domain := DomainFromURL(mangaURL, site.GetDomain())
 		if _, err := cf.LoadFromFile(domain); err != nil {
 			log.Printf("[Downloader] No CF data on disk for %s — opening browser for manual capture", domain)
-			if err := cf.OpenInBrowser(mangaURL); err != nil {
-				return nil, fmt.Errorf("failed to open browser for manual CF prompt: %w", err)
+			if err := cf.OpenChallenge(ctx, mangaURL); err != nil && !errors.Is(err, cf.ErrBrowserDisabled) {
+				return ChapterList{}, fmt.Errorf("failed to open browser for manual CF prompt: %w", err)
 			}
-			return nil, &cf.CfChallengeError{
+			return ChapterList{}, &cf.CfChallengeError{
 				URL:        mangaURL,
 				StatusCode: 0,
 				Indicators: []string{"Manual CF prompt for domain: " + domain},
@@ -181,63 +434,67 @@ func extractChaptersWithJS(ctx context.Context, mangaURL string, site SitePlugin
 
 	session, err := NewBrowserSession(jsCtx, DomainFromURL(mangaURL, site.GetDomain()), site.NeedsCFBypass())
 	if err != nil {
-		return nil, err
+		return ChapterList{}, err
 	}
 	defer session.Close()
 
 	if err := session.NavigateAndEvaluate(mangaURL, method.WaitSelector, method.JavaScript, &rawData); err != nil {
-		return nil, fmt.Errorf("navigation and JavaScript evaluation failed: %w", err)
-	}
-
-	result := make(map[string]string)
-	for _, data := range rawData {
-		filename := site.NormalizeChapterFilename(data)
-		url := site.NormalizeChapterURL(data["url"], mangaURL)
-		result[filename] = url
+		return ChapterList{}, fmt.Errorf("navigation and JavaScript evaluation failed: %w", err)
 	}
 
-	return result, nil
+	return mapChapterData(rawData, mangaURL, site, false), nil
 }
 
 // extractChaptersWithSelector uses HTML parsing
-func extractChaptersWithSelector(ctx context.Context, mangaURL string, site SitePlugin, method *ChapterExtractionMethod) (map[string]string, error) {
+func extractChaptersWithSelector(ctx context.Context, mangaURL string, site SitePlugin, method *ChapterExtractionMethod) (ChapterList, error) {
 	fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	html, err := FetchHTML(fetchCtx, mangaURL, DomainFromURL(mangaURL, site.GetDomain()), site.NeedsCFBypass(), method.WaitSelector)
 	if err != nil {
-		return nil, err
+		return ChapterList{}, err
 	}
 
+	links, err := SelectChapterLinks(html, method.Selector)
+	if err != nil {
+		return ChapterList{}, err
+	}
+	if len(links) == 0 {
+		if err := emptyPageError(ctx, html, mangaURL, site); err != nil {
+			return ChapterList{}, err
+		}
+	}
+
+	return mapChapterData(links, mangaURL, site, false), nil
+}
+
+// SelectChapterLinks returns the href and text of every element matching selector,
+// as chapter data maps ready for NormalizeChapterFilename
+func SelectChapterLinks(html, selector string) ([]map[string]string, error) {
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader([]byte(html)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
-	result := make(map[string]string)
-	doc.Find(method.Selector).Each(func(i int, s *goquery.Selection) {
+	var links []map[string]string
+	doc.Find(selector).Each(func(i int, s *goquery.Selection) {
 		href, exists := s.Attr("href")
 		if !exists {
 			return
 		}
 
-		text := s.Text()
-		data := map[string]string{
+		links = append(links, map[string]string{
 			"url":  href,
-			"text": text,
-		}
-
-		filename := site.NormalizeChapterFilename(data)
-		url := site.NormalizeChapterURL(href, mangaURL)
-		result[filename] = url
+			"text": s.Text(),
+		})
 	})
 
-	return result, nil
+	return links, nil
 }
 
 // extractChaptersCustom uses site's custom parser
 func extractChaptersCustom(ctx context.Context, mangaURL string, site SitePlugin, method *ChapterExtractionMethod) (map[string]string, error) {
-	if method.CustomParser == nil {
+	if method.CustomParser == nil && method.CustomParserContext == nil {
 		return nil, fmt.Errorf("custom parser not provided")
 	}
 
@@ -259,7 +516,37 @@ func extractChaptersCustom(ctx context.Context, mangaURL string, site SitePlugin
 		return nil, fmt.Errorf("failed to get HTML via executor: %w", err)
 	}
 
-	return method.CustomParser(html)
+	chapters, err := method.parse(ctx, html)
+	if len(chapters) == 0 {
+		if pageErr := emptyPageError(ctx, html, mangaURL, site); pageErr != nil {
+			return nil, pageErr
+		}
+	}
+	return chapters, err
+}
+
+// ChallengeError checks a page that yielded no chapters or images for a Cloudflare
+// interstitial. If one is found it is opened for the user to solve (cf.OpenChallenge) and a
+// *cf.CfChallengeError is returned, so the queue waits on CF instead of treating
+// the series as having nothing to download. Returns nil for a genuine empty page.
+func ChallengeError(ctx context.Context, html, pageURL string) error {
+	isCF, cfInfo, _ := cf.DetectHTML(html)
+	if !isCF {
+		return nil
+	}
+
+	log.Printf("[Downloader] ⚠️ Empty result was a Cloudflare challenge page: %s (%v)", pageURL, cfInfo.Indicators)
+
+	challengeURL := cf.GetChallengeURL(cfInfo, pageURL)
+	if err := cf.OpenChallenge(ctx, challengeURL); err != nil {
+		log.Printf("[Downloader] Failed to open browser for CF challenge: %v", err)
+	}
+
+	return &cf.CfChallengeError{
+		URL:        challengeURL,
+		StatusCode: cfInfo.StatusCode,
+		Indicators: cfInfo.Indicators,
+	}
 }
 
 // extractImagesWithJS uses JavaScript evaluation
@@ -282,25 +569,111 @@ func extractImagesWithJS(ctx context.Context, chapterURL string, site SitePlugin
 	return imageURLs, nil
 }
 
+// maxReaderPages bounds how many reader pages of one chapter are followed, in case a
+// next page link never runs out
+const maxReaderPages = 100
+
+// readerPageExtractor extracts the images of one reader page, also returning the
+// page's HTML to look for the next page link in
+type readerPageExtractor func(ctx context.Context, pageURL string, site SitePlugin, method *ImageExtractionMethod) ([]string, string, error)
+
+// extractReaderPages extracts the chapter's images with extract, following the
+// reader's next page links when the method sets NextPageSelector
+func extractReaderPages(ctx context.Context, chapterURL string, site SitePlugin, method *ImageExtractionMethod, extract readerPageExtractor) ([]string, error) {
+	imageURLs, html, err := extract(ctx, chapterURL, site, method)
+	if err != nil || method.NextPageSelector == "" {
+		return imageURLs, err
+	}
+
+	visited := map[string]bool{chapterURL: true}
+	pageURL := chapterURL
+	for page := 2; ; page++ {
+		next := NextReaderPage(html, pageURL, method.NextPageSelector)
+		if next == "" || visited[next] {
+			break
+		}
+		if page > maxReaderPages {
+			log.Printf("[Downloader] ⚠️ Stopped following reader pages of %s after %d pages", chapterURL, maxReaderPages)
+			break
+		}
+		visited[next] = true
+		pageURL = next
+
+		var pageImages []string
+		pageImages, html, err = extract(ctx, pageURL, site, method)
+		if err != nil {
+			return nil, fmt.Errorf("reader page %d of %s: %w", page, chapterURL, err)
+		}
+		log.Printf("[Downloader] Reader page %d of %s: %d images", page, chapterURL, len(pageImages))
+		imageURLs = append(imageURLs, pageImages...)
+	}
+	return imageURLs, nil
+}
+
+// NextReaderPage returns the absolute URL of the first link matching selector in the
+// reader page at pageURL, "" when there is none (the last page)
+func NextReaderPage(html, pageURL, selector string) string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader([]byte(html)))
+	if err != nil {
+		return ""
+	}
+	href := strings.TrimSpace(doc.Find(selector).First().AttrOr("href", ""))
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	next, err := base.Parse(href)
+	if err != nil {
+		return ""
+	}
+	next.Fragment = ""
+	return next.String()
+}
+
 // extractImagesWithSelector uses HTML parsing
-func extractImagesWithSelector(ctx context.Context, chapterURL string, site SitePlugin, method *ImageExtractionMethod) ([]string, error) {
+func extractImagesWithSelector(ctx context.Context, chapterURL string, site SitePlugin, method *ImageExtractionMethod) ([]string, string, error) {
 	fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	html, err := FetchHTML(fetchCtx, chapterURL, DomainFromURL(chapterURL, site.GetDomain()), site.NeedsCFBypass(), method.WaitSelector)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
+	imageURLs, err := SelectImageURLs(html, method.Selector, method.Attribute)
+	if err == nil && len(imageURLs) == 0 {
+		if pageErr := emptyPageError(ctx, html, chapterURL, site); pageErr != nil {
+			return nil, "", pageErr
+		}
+	}
+	return imageURLs, html, err
+}
+
+// MadaraImageAttributes is the attribute order of the WordPress/Madara readers
+// (kunmanga, manhuaus, hls). Their lazy loading plugins differ per site and theme
+// update, so each page tries them all: the lazy attributes first, as src then only
+// holds a placeholder, and srcset last.
+const MadaraImageAttributes = "data-src,data-lazy-src,src,srcset"
+
+// SelectImageURLs returns the image URL of every element matching selector, see
+// ImageURLFromElement for how attribute is read. Images wrapped in a <picture> use
+// the highest quality <source> instead of the (often low resolution) fallback <img>.
+func SelectImageURLs(html, selector, attribute string) ([]string, error) {
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader([]byte(html)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
 	var imageURLs []string
-	doc.Find(method.Selector).Each(func(i int, s *goquery.Selection) {
-		src := s.AttrOr(method.Attribute, "")
-		if src != "" {
+	doc.Find(selector).Each(func(i int, s *goquery.Selection) {
+		if src := bestPictureSource(s); src != "" {
+			imageURLs = append(imageURLs, src)
+			return
+		}
+		if src := ImageURLFromElement(s, attribute); src != "" {
 			imageURLs = append(imageURLs, src)
 		}
 	})
@@ -308,23 +681,104 @@ func extractImagesWithSelector(ctx context.Context, chapterURL string, site Site
 	return imageURLs, nil
 }
 
+// ImageURLFromElement returns the first non empty of the comma separated attributes
+// of img, in order, falling back to src (the only one tried when attribute is "").
+// A srcset or data-srcset value yields its highest scoring candidate. This is the
+// rule the JavaScript extractors of sites.selectorImageJS follow too.
+func ImageURLFromElement(img *goquery.Selection, attribute string) string {
+	for _, attr := range strings.Split(attribute+",src", ",") {
+		attr = strings.TrimSpace(attr)
+		if attr == "" {
+			continue
+		}
+		value := strings.TrimSpace(img.AttrOr(attr, ""))
+		if strings.HasSuffix(attr, "srcset") {
+			var best srcsetCandidate
+			for _, candidate := range parseSrcset(value) {
+				if candidate.score > best.score {
+					best = candidate
+				}
+			}
+			value = best.url
+		}
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// srcsetCandidate is a single "url descriptor" entry of a srcset attribute
+type srcsetCandidate struct {
+	url   string
+	score float64
+}
+
+// parseSrcset splits a srcset attribute into candidates. Width descriptors ("800w")
+// score by width, density descriptors ("2x") by density, no descriptor counts as 1x.
+func parseSrcset(srcset string) []srcsetCandidate {
+	var candidates []srcsetCandidate
+	for _, entry := range strings.Split(srcset, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+
+		candidate := srcsetCandidate{url: fields[0], score: 1}
+		if len(fields) > 1 {
+			descriptor := fields[1]
+			var value float64
+			if _, err := fmt.Sscanf(descriptor[:len(descriptor)-1], "%g", &value); err == nil && value > 0 {
+				candidate.score = value
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// bestPictureSource returns the highest scoring srcset candidate from the <source>
+// siblings of an <img> inside a <picture>, or "" if the image is not in a picture.
+// Equal scores keep document order, which is the page author's format preference.
+func bestPictureSource(img *goquery.Selection) string {
+	picture := img.ParentFiltered("picture")
+	if picture.Length() == 0 {
+		return ""
+	}
+
+	var best srcsetCandidate
+	picture.ChildrenFiltered("source").Each(func(i int, source *goquery.Selection) {
+		srcset := source.AttrOr("srcset", "")
+		if srcset == "" {
+			srcset = source.AttrOr("data-srcset", "")
+		}
+		for _, candidate := range parseSrcset(srcset) {
+			if candidate.score > best.score {
+				best = candidate
+			}
+		}
+	})
+
+	return best.url
+}
+
 // extractImagesCustom uses site's custom parser.
-// If WaitSelector is set, it forces browser rendering via FetchHTMLBatched (chromedp),
+// If WaitSelector or ForceBrowser is set, it forces browser rendering via FetchHTMLBatched (chromedp),
 // which batches navigate + WaitReady + OuterHTML into a single chromedp.Run call.
 // This avoids the sequential context cancellation issue in FetchHTML where Navigate
 // exhausts the parent context before GetHTML can run.
-// If WaitSelector is empty, it uses RequestExecutor (HTTP first, browser fallback)
+// Otherwise it uses RequestExecutor (HTTP first, browser fallback)
 // for efficiency on sites that serve images in SSR HTML.
-func extractImagesCustom(ctx context.Context, chapterURL string, site SitePlugin, method *ImageExtractionMethod) ([]string, error) {
-	if method.CustomParser == nil {
-		return nil, fmt.Errorf("custom parser not provided")
+func extractImagesCustom(ctx context.Context, chapterURL string, site SitePlugin, method *ImageExtractionMethod) ([]string, string, error) {
+	if method.CustomParser == nil && method.CustomParserContext == nil {
+		return nil, "", fmt.Errorf("custom parser not provided")
 	}
 
 	var html string
 	var err error
 
-	if method.WaitSelector != "" {
-		// WaitSelector set: force browser rendering so React/Next.js content is present.
+	if UsesBrowserRendering(method) {
+		// WaitSelector or ForceBrowser set: render in the browser so React/Next.js content is present.
 		// FetchHTMLBatched runs navigate+wait+getHTML in one chromedp.Run call, avoiding
 		// context cancellation between sequential Navigate and GetHTML calls.
 		// The site's Debugger is passed through so HTML can be saved to disk when
@@ -335,7 +789,7 @@ func extractImagesCustom(ctx context.Context, chapterURL string, site SitePlugin
 		}
 		html, err = FetchHTMLBatched(ctx, chapterURL, DomainFromURL(chapterURL, site.GetDomain()), site.NeedsCFBypass(), dbg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get rendered HTML via browser: %w", err)
+			return nil, "", fmt.Errorf("failed to get rendered HTML via browser: %w", err)
 		}
 	} else {
 		// No WaitSelector: use RequestExecutor (HTTP first, browser fallback)
@@ -346,7 +800,7 @@ func extractImagesCustom(ctx context.Context, chapterURL string, site SitePlugin
 
 		exec, err := NewRequestExecutor(chapterURL, site.NeedsCFBypass(), dbg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request executor: %w", err)
+			return nil, "", fmt.Errorf("failed to create request executor: %w", err)
 		}
 
 		fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -354,44 +808,56 @@ func extractImagesCustom(ctx context.Context, chapterURL string, site SitePlugin
 
 		html, err = exec.FetchHTML(fetchCtx, chapterURL, "")
 		if err != nil {
-			return nil, fmt.Errorf("failed to get HTML via executor: %w", err)
+			return nil, "", fmt.Errorf("failed to get HTML via executor: %w", err)
 		}
 	}
 
-	return method.CustomParser(html)
+	imageURLs, err := method.parse(ctx, html)
+	if errors.Is(err, ErrSiteChanged) && method.BrowserFallback && !UsesBrowserRendering(method) {
+		log.Printf("[Downloader] HTTP page could not be parsed (%v), retrying in the browser: %s", err, chapterURL)
+
+		var dbg *Debugger
+		if d, ok := site.(DebugSite); ok {
+			dbg = d.Debugger()
+		}
+		html, err = FetchHTMLBatched(ctx, chapterURL, DomainFromURL(chapterURL, site.GetDomain()), site.NeedsCFBypass(), dbg)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get rendered HTML via browser: %w", err)
+		}
+		imageURLs, err = method.parse(ctx, html)
+	}
+	if len(imageURLs) == 0 {
+		if pageErr := emptyPageError(ctx, html, chapterURL, site); pageErr != nil {
+			return nil, "", pageErr
+		}
+	}
+	return imageURLs, html, err
+}
+
+// UsesBrowserRendering reports whether custom image extraction renders the chapter
+// page in the browser rather than trying plain HTTP first
+func UsesBrowserRendering(method *ImageExtractionMethod) bool {
+	return method.WaitSelector != "" || method.ForceBrowser
 }
 
 // extractChaptersWithAPI uses API-based extraction
-func extractChaptersWithAPI(ctx context.Context, mangaURL string, site SitePlugin, method *ChapterExtractionMethod) (map[string]string, error) {
+func extractChaptersWithAPI(ctx context.Context, mangaURL string, site SitePlugin, method *ChapterExtractionMethod) (ChapterList, error) {
 	if method.APIFunc == nil {
-		return nil, fmt.Errorf("API function not provided")
+		return ChapterList{}, fmt.Errorf("API function not provided")
 	}
 
-	client, err := NewAPIClient(DomainFromURL(mangaURL, site.GetDomain()), site.NeedsCFBypass())
+	client, err := NewAPIClientContext(ctx, DomainFromURL(mangaURL, site.GetDomain()), site.NeedsCFBypass())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create API client: %w", err)
+		return ChapterList{}, fmt.Errorf("failed to create API client: %w", err)
 	}
+	parser.RequestExtrasFrom(ctx).ApplyToCollector(client.collector)
 
 	rawData, err := method.APIFunc(mangaURL, client)
 	if err != nil {
-		return nil, err
-	}
-
-	result := make(map[string]string)
-	for _, data := range rawData {
-		filename := site.NormalizeChapterFilename(data)
-		url := site.NormalizeChapterURL(data["url"], mangaURL)
-
-		if existingURL, exists := result[filename]; exists {
-			log.Printf("[Downloader:API] WARNING: Duplicate chapter %s found (existing: %s, new: %s) - keeping first",
-				filename, existingURL, url)
-			continue
-		}
-
-		result[filename] = url
+		return ChapterList{}, err
 	}
 
-	return result, nil
+	return mapChapterData(rawData, mangaURL, site, true), nil
 }
 
 // extractImagesWithAPI uses API-based extraction
@@ -400,10 +866,11 @@ func extractImagesWithAPI(ctx context.Context, chapterURL string, site SitePlugi
 		return nil, fmt.Errorf("API function not provided")
 	}
 
-	client, err := NewAPIClient(DomainFromURL(chapterURL, site.GetDomain()), site.NeedsCFBypass())
+	client, err := NewAPIClientContext(ctx, DomainFromURL(chapterURL, site.GetDomain()), site.NeedsCFBypass())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create API client: %w", err)
 	}
+	parser.RequestExtrasFrom(ctx).ApplyToCollector(client.collector)
 
 	chapterData := map[string]string{
 		"url": chapterURL,
@@ -0,0 +1,215 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// DefaultImageMemoryBudget is how many bytes of image bodies the shared fetcher
+// holds in memory at once, over every chapter and series downloading at the time
+const DefaultImageMemoryBudget = 256 << 20
+
+// DefaultImageMemoryPerImage is the largest body one image buffers in memory,
+// larger pages are spooled to a temp file
+const DefaultImageMemoryPerImage = 32 << 20
+
+// imageReadChunk is the first part of a body of unknown length the fetcher reserves
+// and reads, the buffer doubles from there
+const imageReadChunk = 256 << 10
+
+// memoryBudget accounts for the image bytes the fetcher holds in memory. Only the
+// first reservation of a body waits for others to be released, growing past it
+// never does: a body that does not fit is spooled to disk instead, so readers each
+// holding part of the budget cannot end up waiting on each other.
+type memoryBudget struct {
+	mu       sync.Mutex
+	total    int64
+	perImage int64
+	used     int64
+	peak     int64
+	freed    chan struct{} // closed and replaced whenever bytes are released
+}
+
+func newMemoryBudget(total, perImage int64) *memoryBudget {
+	b := &memoryBudget{freed: make(chan struct{})}
+	b.setLimits(total, perImage)
+	return b
+}
+
+// setLimits changes the budget, values below 1 use the defaults. Bytes already held
+// stay held, readers just wait longer when the budget shrinks.
+func (b *memoryBudget) setLimits(total, perImage int64) {
+	if total < 1 {
+		total = DefaultImageMemoryBudget
+	}
+	if perImage < 1 {
+		perImage = DefaultImageMemoryPerImage
+	}
+	b.mu.Lock()
+	b.total, b.perImage = total, min(perImage, total)
+	b.mu.Unlock()
+}
+
+func (b *memoryBudget) limits() (total, perImage int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.total, b.perImage
+}
+
+// acquire reserves n bytes, waiting until other bodies release enough of theirs.
+// With nothing held it always succeeds, so a body larger than the budget still goes.
+func (b *memoryBudget) acquire(ctx context.Context, n int64) error {
+	for {
+		b.mu.Lock()
+		if b.used == 0 || b.used+n <= b.total {
+			b.reserve(n)
+			b.mu.Unlock()
+			return nil
+		}
+		freed := b.freed
+		b.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// tryAcquire reserves n bytes when they fit without waiting
+func (b *memoryBudget) tryAcquire(n int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used+n > b.total {
+		return false
+	}
+	b.reserve(n)
+	return true
+}
+
+// reserve adds n bytes to the budget in use, b.mu held
+func (b *memoryBudget) reserve(n int64) {
+	b.used += n
+	b.peak = max(b.peak, b.used)
+}
+
+func (b *memoryBudget) release(n int64) {
+	if n == 0 {
+		return
+	}
+	b.mu.Lock()
+	b.used -= n
+	close(b.freed)
+	b.freed = make(chan struct{})
+	b.mu.Unlock()
+}
+
+// imageBody is a fetched image body, in memory or spooled to a temp file
+type imageBody struct {
+	data []byte
+	file string
+
+	budget *memoryBudget
+	held   int64
+}
+
+// close releases the memory of the body and removes its temp file
+func (b *imageBody) close() {
+	b.budget.release(b.held)
+	b.held, b.data = 0, nil
+	if b.file != "" {
+		os.Remove(b.file)
+	}
+}
+
+// readBody reads an image body within the memory budget: in memory when it fits
+// the per-image limit and the budget has room, otherwise spooled to a temp file.
+// contentLength is -1 when the response did not declare one.
+func (b *memoryBudget) readBody(ctx context.Context, r io.Reader, contentLength int64) (*imageBody, error) {
+	_, perImage := b.limits()
+	body := &imageBody{budget: b}
+	if contentLength > perImage {
+		if err := body.spool(r); err != nil {
+			return nil, err
+		}
+		return body, nil
+	}
+
+	size := int64(imageReadChunk)
+	if contentLength >= 0 {
+		// One byte more so the end of the body is read without growing
+		size = min(contentLength+1, perImage)
+	}
+	if err := b.acquire(ctx, size); err != nil {
+		return nil, err
+	}
+	body.held = size
+	buf := make([]byte, 0, size)
+
+	for {
+		if len(buf) == cap(buf) {
+			grow := min(int64(cap(buf)), perImage-body.held)
+			if grow <= 0 || !b.tryAcquire(grow) {
+				body.data = buf
+				if err := body.spool(r); err != nil {
+					return nil, err
+				}
+				return body, nil
+			}
+			body.held += grow
+			grown := make([]byte, len(buf), int64(cap(buf))+grow)
+			copy(grown, buf)
+			buf = grown
+		}
+
+		n, err := r.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			body.close()
+			return nil, err
+		}
+	}
+
+	body.data = buf
+	if len(buf) == 0 {
+		body.close()
+		return nil, errors.New("empty response body")
+	}
+	return body, nil
+}
+
+// spool writes what was buffered so far and the rest of r to a temp file, and
+// releases the memory of the buffer
+func (b *imageBody) spool(r io.Reader) error {
+	f, err := os.CreateTemp("", "kansho-image-*")
+	if err != nil {
+		b.close()
+		return err
+	}
+	b.file = f.Name()
+
+	written, err := f.Write(b.data)
+	b.budget.release(b.held)
+	b.held, b.data = 0, nil
+	if err == nil {
+		var n int64
+		n, err = io.Copy(f, r)
+		if err == nil && int64(written)+n == 0 {
+			err = errors.New("empty response body")
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		b.close()
+	}
+	return err
+}
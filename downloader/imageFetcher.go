@@ -0,0 +1,214 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"kansho/cf"
+	"kansho/parser"
+)
+
+// DefaultImageFetches is how many images the shared fetcher has in flight at most,
+// over every chapter and series downloading at the time
+const DefaultImageFetches = 8
+
+// imageDomainInterval spaces out the image requests of one domain when the caller
+// does not pace them itself
+const imageDomainInterval = 1500 * time.Millisecond
+
+// defaultImageUserAgent is sent when neither the bookmark nor the CF bypass data
+// has a User-Agent for the image host
+const defaultImageUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36"
+
+// ImageRequest is one page image for ImageFetcher.Fetch
+type ImageRequest struct {
+	URL string
+	// Domain keys the rate limiter and the CF bypass data, usually the series host
+	Domain string
+	// Referer is the reader page the image is shown on, image CDNs check it
+	Referer string
+	// CFBypass sends the cf_clearance cookie and UA captured for Domain
+	CFBypass bool
+	// Wait paces the request, nil waits on the shared limiter of Domain
+	Wait func(ctx context.Context) error
+}
+
+// ImageFetcher downloads page images for every site through one HTTP client, so
+// connections to an image host (HTTP/2 where it offers it) are kept across pages,
+// chapters and series instead of being opened per image. A single semaphore caps
+// the images in flight however many downloads run, and a memory budget the bytes
+// they buffer.
+type ImageFetcher struct {
+	client *http.Client
+	slots  chan struct{}
+	memory *memoryBudget
+}
+
+var sharedImageFetcher = NewImageFetcher(DefaultImageFetches)
+
+// SharedImageFetcher returns the fetcher every image download goes through
+func SharedImageFetcher() *ImageFetcher {
+	return sharedImageFetcher
+}
+
+// NewImageFetcher creates a fetcher with at most maxInFlight requests running,
+// values below 1 mean one at a time
+func NewImageFetcher(maxInFlight int) *ImageFetcher {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+
+	// Enough idle connections per host that every slot can reuse one
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   maxInFlight,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &ImageFetcher{
+		// Long enough for large pages on slow connections
+		client: &http.Client{Transport: transport, Timeout: 60 * time.Second},
+		slots:  make(chan struct{}, maxInFlight),
+		memory: newMemoryBudget(DefaultImageMemoryBudget, DefaultImageMemoryPerImage),
+	}
+}
+
+// SetMemoryBudget limits the image bytes the fetcher buffers in memory: total over
+// every fetch in flight and perImage for one page, values below 1 use the defaults.
+// A page over perImage, or arriving when the budget is used up, is spooled to a
+// temp file. Only the start of a page waits for memory to be freed.
+func (f *ImageFetcher) SetMemoryBudget(total, perImage int64) {
+	f.memory.setLimits(total, perImage)
+}
+
+// PeakBufferedBytes is the most image bytes the fetcher had buffered in memory at
+// once, reserved capacity included
+func (f *ImageFetcher) PeakBufferedBytes() int64 {
+	f.memory.mu.Lock()
+	defer f.memory.mu.Unlock()
+	return f.memory.peak
+}
+
+// Fetch downloads the image after req.Wait and once a slot is free, and returns
+// its bytes. The slot is only held for the request itself, not the pacing wait,
+// and the memory budget only while the body is read: the bytes are the caller's.
+func (f *ImageFetcher) Fetch(ctx context.Context, req ImageRequest) ([]byte, error) {
+	body, err := f.fetchBody(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer body.close()
+	if body.file != "" {
+		return os.ReadFile(body.file)
+	}
+	return body.data, nil
+}
+
+// fetchBody is Fetch returning the body as read within the memory budget, the
+// caller closes it
+func (f *ImageFetcher) fetchBody(ctx context.Context, req ImageRequest) (*imageBody, error) {
+	wait := req.Wait
+	if wait == nil {
+		limiter := parser.SharedRateLimiter(req.Domain, imageDomainInterval)
+		wait = func(ctx context.Context) error {
+			if !limiter.WaitCtx(ctx) {
+				return ctx.Err()
+			}
+			return nil
+		}
+	}
+	if err := wait(ctx); err != nil {
+		return nil, err
+	}
+
+	select {
+	case f.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-f.slots }()
+
+	httpReq, err := f.newRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Drained so the connection goes back to the pool
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("bad response status: %s", resp.Status)
+	}
+	return f.memory.readBody(ctx, resp.Body, resp.ContentLength)
+}
+
+// FetchTo downloads the image and saves it into targetDir the way parser.SaveImage
+// does, the memory budget held until it is saved. A spooled page is saved from its
+// temp file. Inline data: images are decoded without a request.
+func (f *ImageFetcher) FetchTo(ctx context.Context, req ImageRequest, targetDir, filename string, keepNative bool) error {
+	if parser.IsDataURI(req.URL) {
+		return parser.SaveDataURI(req.URL, targetDir, filename, keepNative)
+	}
+	body, err := f.fetchBody(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer body.close()
+	if body.file != "" {
+		return parser.SaveImageFile(body.file, targetDir, filename, keepNative)
+	}
+	return parser.SaveImage(body.data, targetDir, filename, keepNative)
+}
+
+// newRequest builds the image request. Headers are layered from the defaults to
+// the most specific: the bookmark's image UA, then the UA the CF cookie was issued
+// to, and the bookmark's RequestExtras last so they can replace any of them.
+func (f *ImageFetcher) newRequest(ctx context.Context, req ImageRequest) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", parser.ImageAccept())
+	httpReq.Header.Set("User-Agent", defaultImageUserAgent)
+	if userAgent := parser.ImageUserAgent(ctx); userAgent != "" {
+		httpReq.Header.Set("User-Agent", userAgent)
+	}
+	if req.Referer != "" {
+		httpReq.Header.Set("Referer", req.Referer)
+	}
+
+	if req.CFBypass {
+		if data, err := cf.LoadFromFile(req.Domain); err == nil {
+			if userAgent := strings.TrimSpace(data.Entropy.UserAgent); userAgent != "" {
+				httpReq.Header.Set("User-Agent", userAgent)
+			}
+			if clearance := data.CfClearanceStruct; clearance != nil {
+				httpReq.AddCookie(&http.Cookie{Name: clearance.Name, Value: clearance.Value})
+			}
+		}
+	}
+	for _, cookie := range cf.LoginCookies(req.URL) {
+		httpReq.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	parser.RequestExtrasFrom(ctx).Apply(httpReq)
+	return httpReq, nil
+}
@@ -1,8 +1,11 @@
 package downloader
 
 import (
-	//"context"
+	"context"
+
 	"kansho/config"
+	"kansho/models"
+	"kansho/parser"
 )
 
 // ChapterExtractionMethod defines how to extract chapters from a page
@@ -23,8 +26,13 @@ type ChapterExtractionMethod struct {
 	// Receives HTML, returns map[filename]url
 	CustomParser func(html string) (map[string]string, error)
 
+	// CustomParserContext: CustomParser for parsers making requests of their own,
+	// which are sent with the download's context. Used instead of CustomParser.
+	CustomParserContext func(ctx context.Context, html string) (map[string]string, error)
+
 	// For Type="api": Custom API extraction function
-	// Receives base URL and API client, returns raw chapter data
+	// Receives base URL and API client, returns raw chapter data. Requests go
+	// through the client, client.Context() is the download's context.
 	APIFunc func(baseURL string, client *APIClient) ([]map[string]string, error)
 }
 
@@ -43,15 +51,59 @@ type ImageExtractionMethod struct {
 	// WaitSelector: CSS selector to wait for before extraction
 	WaitSelector string
 
+	// ForceBrowser: for Type="custom", always render the page in the browser even
+	// without a WaitSelector. For sites that randomly serve an empty shell over HTTP.
+	ForceBrowser bool
+
+	// BrowserFallback: for Type="custom" fetched over HTTP, render the page in the
+	// browser and parse again when the HTTP page does not have the expected
+	// structure (CustomParser returns ErrSiteChanged)
+	BrowserFallback bool
+
+	// ImageHosts: optional filter dropping page images from other hosts (ads,
+	// trackers), applied to the URLs of every extraction type
+	ImageHosts *models.ImageHosts
+
+	// ImageURLTransforms: optional rewrites of every page image URL before it is
+	// downloaded, eg: thumbnail to full size. Applied before ImageHosts.
+	ImageURLTransforms []models.ImageURLTransform
+
+	// NextPageSelector: for Type="html_selector" and "custom", opts in to readers
+	// that split a chapter over several pages (?page=2). The href of the first
+	// element matching it on each reader page is the next page, its images are
+	// appended until a page has no such link.
+	NextPageSelector string
+
 	// CustomParser: optional function for custom parsing logic
 	// Receives HTML, returns []imageURL
 	CustomParser func(html string) ([]string, error)
 
+	// CustomParserContext: CustomParser for parsers making requests of their own,
+	// which are sent with the download's context. Used instead of CustomParser.
+	CustomParserContext func(ctx context.Context, html string) ([]string, error)
+
 	// For Type="api": Custom API extraction function
-	// Receives chapter URL, chapter data, and API client, returns image URLs
+	// Receives chapter URL, chapter data, and API client, returns image URLs.
+	// client.Context() is the download's context.
 	APIFunc func(chapterURL string, chapterData map[string]string, client *APIClient) ([]string, error)
 }
 
+// parse runs CustomParserContext, or CustomParser when it is not set
+func (m *ChapterExtractionMethod) parse(ctx context.Context, html string) (map[string]string, error) {
+	if m.CustomParserContext != nil {
+		return m.CustomParserContext(ctx, html)
+	}
+	return m.CustomParser(html)
+}
+
+// parse runs CustomParserContext, or CustomParser when it is not set
+func (m *ImageExtractionMethod) parse(ctx context.Context, html string) ([]string, error) {
+	if m.CustomParserContext != nil {
+		return m.CustomParserContext(ctx, html)
+	}
+	return m.CustomParser(html)
+}
+
 // SitePlugin defines the interface that all manga sites must implement.
 // Sites provide ONLY extraction logic - the downloader handles ALL execution.
 type SitePlugin interface {
@@ -78,14 +130,14 @@ type SitePlugin interface {
 	// NormalizeChapterURL converts a raw chapter URL to absolute URL if needed
 	NormalizeChapterURL(rawURL, baseURL string) string
 
-	// NormalizeChapterFilename converts raw chapter data to filename
-	// e.g., "72" -> "ch072.cbz", "72.5" -> "ch072.5.cbz"
+	// NormalizeChapterFilename converts raw chapter data to filename with
+	// parser.ChapterFilename, e.g., "72" -> "ch072.cbz", "72.5" -> "ch072.5.cbz"
+	// An empty filename drops the chapter, for entries with no usable number.
 	NormalizeChapterFilename(chapterData map[string]string) string
 }
 
 // ProgressCallback is called during download to report progress
-// Parameters: status message, progress (0.0-1.0), actual chapter number, current download index, total chapters
-type ProgressCallback func(string, float64, int, int, int)
+type ProgressCallback = config.ProgressFunc
 
 // DownloadConfig holds configuration for a download session
 type DownloadConfig struct {
@@ -112,6 +164,34 @@ type ManualCFPromptSite interface {
 	NeedsManualCFPrompt() bool
 }
 
+// NativeImageSite is implemented by sites whose pages should be stored in the CBZ
+// in their original format (eg: WebP) instead of being re-encoded to JPEG. Most
+// modern readers handle WebP and skipping the conversion roughly halves file size.
+// Sites that do not implement this interface have all pages converted to JPEG.
+type NativeImageSite interface {
+	KeepNativeImageFormat() bool
+}
+
+// ConcurrentImageSite is implemented by sites whose image hosts allow parallel
+// downloads within a known rate budget (eg: MangaDex@Home). The manager keeps up to
+// ImageConcurrency page requests of a chapter in flight, calling WaitImage before
+// each one instead of waiting on the shared per-domain limiter.
+// Sites that do not implement this interface download pages one at a time, or as
+// many as the image_concurrency setting allows, on the per-domain limiter.
+type ConcurrentImageSite interface {
+	ImageConcurrency() int
+	WaitImage(ctx context.Context) error
+}
+
+// ComicInfoSite is implemented by sites that know more about a chapter than its
+// number, such as its title, volume or scanlation group. The fields it sets replace
+// the ones the manager fills in for the ComicInfo.xml of the chapter.
+// Sites that do not implement this interface get the series title, chapter number,
+// chapter URL and download date only.
+type ComicInfoSite interface {
+	ChapterComicInfo(manga *config.Bookmarks, chapterURL, cbzName string) parser.ComicInfo
+}
+
 // Debugger defines optional debugging behavior for a site
 // Sites may return nil if no debugging is required
 type Debugger struct {
@@ -126,3 +206,11 @@ type Debugger struct {
 	SaveHeaders bool
 	HeadersPath string
 }
+
+// ImageURLRefresher is implemented by sites that can hand out fresh image URLs for a
+// chapter more cheaply than extracting them again (eg: an API that re-signs URLs).
+// The manager asks for them when signed page URLs expire mid-chapter. Sites that do
+// not implement this interface have the chapter's images extracted again instead.
+type ImageURLRefresher interface {
+	RefreshImageURLs(ctx context.Context, chapterURL string) ([]string, error)
+}
@@ -0,0 +1,106 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"kansho/cf"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// loginPollInterval is how often the login browser's cookies are read while the
+// user is logging in
+const loginPollInterval = 2 * time.Second
+
+// CaptureLoginSession opens a visible browser on loginURL for the user to log in to
+// the site, and stores the site's cookies as its login session once the user closes
+// the browser window. Downloads from the site (and its subdomains) then send them
+// like cf bypass cookies. Cancelling ctx closes the browser without saving.
+func CaptureLoginSession(ctx context.Context, loginURL string) (*cf.BypassData, error) {
+	parsed, err := url.Parse(loginURL)
+	if err != nil || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid login URL %q", loginURL)
+	}
+	domain := parsed.Hostname()
+	if cf.BrowserOpeningDisabled() {
+		return nil, errors.New("no display to open the login browser on")
+	}
+
+	dataDir, err := newBrowserDataDir()
+	if err != nil {
+		return nil, err
+	}
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", false),
+		chromedp.Flag("disable-blink-features", "AutomationControlled"),
+		chromedp.UserDataDir(dataDir),
+	)
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, opts...)
+	defer func() {
+		cancelAlloc()
+		removeBrowserDataDir(dataDir)
+	}()
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	defer cancelBrowser()
+
+	log.Printf("[Login:%s] Opening login browser at %s", domain, loginURL)
+	var userAgent string
+	if err := chromedp.Run(browserCtx,
+		chromedp.Navigate(loginURL),
+		chromedp.Evaluate(`navigator.userAgent`, &userAgent),
+	); err != nil {
+		return nil, fmt.Errorf("failed to open login page: %w", err)
+	}
+
+	// The cookies are read until the window goes away, the last read is what the
+	// site set by the time the user was done
+	cookieURLs := []string{parsed.Scheme + "://" + parsed.Host + "/", loginURL}
+	var captured []*network.Cookie
+	ticker := time.NewTicker(loginPollInterval)
+	defer ticker.Stop()
+poll:
+	for {
+		var cookies []*network.Cookie
+		err := chromedp.Run(browserCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = network.GetCookies().WithURLs(cookieURLs).Do(ctx)
+			return err
+		}))
+		if err != nil {
+			break
+		}
+		captured = cookies
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-browserCtx.Done():
+			break poll
+		case <-ticker.C:
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if len(captured) == 0 {
+		return nil, fmt.Errorf("no cookies were set by %s, not logged in", domain)
+	}
+
+	data := &cf.BypassData{
+		URL:        loginURL,
+		Domain:     domain,
+		AllCookies: cf.BrowserCookies(captured),
+		Entropy:    cf.Entropy{UserAgent: userAgent},
+	}
+	if err := cf.SaveLoginSession(data, domain); err != nil {
+		return nil, err
+	}
+	log.Printf("[Login:%s] ✓ Saved login session (%d cookies)", domain, len(data.AllCookies))
+	return data, nil
+}
@@ -0,0 +1,80 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultMaintenanceSignatures are phrases that mark a maintenance or site-wide
+// error page. They are only looked for on pages that yielded no chapters or images,
+// so a series page mentioning "maintenance" in a synopsis is never affected.
+var DefaultMaintenanceSignatures = []string{
+	"under maintenance",
+	"undergoing maintenance",
+	"scheduled maintenance",
+	"down for maintenance",
+	"maintenance mode",
+	"we'll be back soon",
+	"we will be back soon",
+	"site is temporarily unavailable",
+	"service temporarily unavailable",
+	"error establishing a database connection",
+}
+
+// MaintenanceSite is implemented by sites whose maintenance or outage page has its
+// own wording, the signatures are checked in addition to the defaults
+type MaintenanceSite interface {
+	MaintenanceSignatures() []string
+}
+
+// MatchMaintenance returns the first signature found (case-insensitively) in the
+// text of html, or false when the page does not look like a maintenance page
+func MatchMaintenance(html string, signatures []string) (string, bool) {
+	text := html
+	if doc, err := goquery.NewDocumentFromReader(strings.NewReader(html)); err == nil {
+		// Match visible text only, scripts and styles can contain anything
+		doc.Find("script, style, noscript").Remove()
+		text = doc.Text()
+	}
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+
+	for _, signature := range signatures {
+		signature = strings.ToLower(strings.TrimSpace(signature))
+		if signature != "" && strings.Contains(text, signature) {
+			return signature, true
+		}
+	}
+	return "", false
+}
+
+// MaintenanceError checks a page that yielded no chapters or images for a
+// maintenance or site-wide error page and returns an error wrapping
+// ErrSiteMaintenance, so the run fails and can be retried later instead of
+// reporting the series as up to date. Returns nil for any other page.
+func MaintenanceError(html, pageURL string, site SitePlugin) error {
+	signatures := DefaultMaintenanceSignatures
+	if m, ok := site.(MaintenanceSite); ok {
+		signatures = append(append([]string{}, m.MaintenanceSignatures()...), signatures...)
+	}
+
+	signature, ok := MatchMaintenance(html, signatures)
+	if !ok {
+		return nil
+	}
+
+	log.Printf("[Downloader] ⚠️ Empty result was a maintenance page: %s (%q)", pageURL, signature)
+	return fmt.Errorf("%s: page says %q: %w", pageURL, signature, ErrSiteMaintenance)
+}
+
+// emptyPageError explains a page that yielded nothing: a Cloudflare challenge or a
+// maintenance page. Returns nil when the page is neither.
+func emptyPageError(ctx context.Context, html, pageURL string, site SitePlugin) error {
+	if err := ChallengeError(ctx, html, pageURL); err != nil {
+		return err
+	}
+	return MaintenanceError(html, pageURL, site)
+}
@@ -2,33 +2,92 @@ package downloader
 
 import (
 	"context"
+	"crypto/sha1"
 	"fmt"
 	"log"
 	"math"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"kansho/config"
 	"kansho/parser"
+	"kansho/validation"
 )
 
 // Manager orchestrates the entire download process
 type Manager struct {
-	config *DownloadConfig
-	domain string
+	config         *DownloadConfig
+	domain         string
+	writeComicInfo bool
+	splitMaxHeight int
+	streamCbz      bool
+	imageManifest  bool
+
+	// pageCounts flags chapters with far fewer pages than the others in this run,
+	// rejectShort retries them instead of packaging them
+	pageCounts    *PageCountMonitor
+	rejectShort   bool
+	shortChapters map[string]bool
+
+	// chapterListTTL is how long a scraped chapter list of the series is reused
+	chapterListTTL time.Duration
+
+	// maxChaptersPerRun caps the chapters one run downloads, 0 is no cap
+	maxChaptersPerRun int
+
+	// excluded counts the missing chapters planNewChapters left out on purpose
+	excluded int
+
+	// chapterConcurrency chapters are downloaded at once, each with up to
+	// imageConcurrency pages in flight unless the site sets its own
+	chapterConcurrency int
+	imageConcurrency   int
+
+	// mu guards shortChapters while chapters download in parallel
+	mu sync.Mutex
 }
 
 // NewManager creates a new download manager
-func NewManager(config *DownloadConfig) *Manager {
+func NewManager(cfg *DownloadConfig) *Manager {
 	// Extract domain from manga URL
-	parsedURL, _ := url.Parse(config.Manga.Url)
+	parsedURL, _ := url.Parse(cfg.Manga.Url)
 	domain := parsedURL.Hostname()
 
+	settings := config.LoadSettings()
+	SharedImageFetcher().SetMemoryBudget(int64(settings.ImageMemoryBudgetMB)<<20, int64(settings.ImageMemoryPerImageMB)<<20)
 	return &Manager{
-		config: config,
-		domain: domain,
+		config:         cfg,
+		domain:         domain,
+		writeComicInfo: settings.WriteComicInfo,
+		splitMaxHeight: settings.SplitTallPagesMaxHeight,
+		streamCbz:      settings.StreamCbz && settings.SplitTallPagesMaxHeight == 0,
+		imageManifest:  settings.WriteImageManifest,
+		pageCounts:     NewPageCountMonitor(),
+		rejectShort:    settings.RejectShortChapters,
+		shortChapters:  make(map[string]bool),
+		chapterListTTL: chapterListTTL(settings.ChapterListCacheMinutes),
+
+		maxChaptersPerRun:  settings.MaxChaptersPerRun,
+		chapterConcurrency: max(settings.ChapterConcurrency, 1),
+		imageConcurrency:   max(settings.ImageConcurrency, 1),
+	}
+}
+
+// chapterListTTL turns the chapter_list_cache_minutes setting into the freshness
+// window of CachedChapterList
+func chapterListTTL(minutes int) time.Duration {
+	switch {
+	case minutes == 0:
+		return DefaultChapterListCacheTTL
+	case minutes < 0:
+		return 0
+	default:
+		return time.Duration(minutes) * time.Minute
 	}
 }
 
@@ -40,63 +99,164 @@ func (m *Manager) Download(ctx context.Context) error {
 
 	log.Printf("[Downloader] Starting download for %s from %s", manga.Title, site.GetSiteName())
 
-	// Step 1: Get all chapter URLs from the site
-	if callback != nil {
-		callback("Fetching chapter list...", 0, 0, 0, 0)
+	// Images are fetched with the UA that cleared CF for the site, whichever host
+	// serves them
+	if userAgent := parser.CapturedUserAgent(m.domain); userAgent != "" {
+		ctx = parser.WithImageUserAgent(ctx, userAgent)
+		log.Printf("[Downloader] Using captured User-Agent for images: %s", userAgent)
 	}
 
-	chapterMap, err := FetchChapterURLs(ctx, manga.Url, site)
-	if err != nil {
-		return fmt.Errorf("failed to get chapter URLs: %w", err)
-	}
-
-	log.Printf("[Downloader] Found %d total chapters", len(chapterMap))
+	// Whichever way the run ends, RunSiteDownload callers learn what it did
+	var summary config.DownloadSummary
+	newChaptersToDownload, remoteChapters := 0, 0
+	m.excluded = 0
+	defer func() {
+		result := summary.Result(newChaptersToDownload, m.excluded)
+		result.RemoteChapters = remoteChapters
+		config.ReportDownloadResult(ctx, result)
+	}()
 
-	// Step 2: Get already downloaded chapters
-	downloadedChapters, err := parser.LocalChapterList(manga.Location)
+	// Steps 1-3: Work out the chapters to download, normally those on the site that
+	// are not in the library yet
+	retryFailed := config.RetryFailedOnly(ctx)
+	plan := m.planNewChapters
+	if retryFailed {
+		plan = m.planFailedChapters
+	}
+	chapterMap, totalChaptersFound, err := plan(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list local chapters: %w", err)
+		return err
+	}
+	if !retryFailed {
+		remoteChapters = totalChaptersFound
 	}
 
-	log.Printf("[Downloader] Found %d already downloaded chapters", len(downloadedChapters))
-
-	totalChaptersFound := len(chapterMap)
+	// A check only run stops once it knows how many chapters the library is missing
+	if config.CheckOnly(ctx) {
+		newChaptersToDownload = len(chapterMap)
+		log.Printf("[Downloader:%s] Check only: %d of %d chapters missing", manga.Title, newChaptersToDownload, totalChaptersFound)
+		return nil
+	}
 
-	// Step 3: Remove already downloaded chapters
-	for _, chapter := range downloadedChapters {
-		delete(chapterMap, chapter)
+	// A long backlog is fetched over several runs, the chapters left out are still
+	// missing from the library next time
+	if left := capChapters(chapterMap, m.maxChaptersPerRun); left > 0 {
+		log.Printf("[Downloader:%s] Capped at %d chapters this run, %d left for later runs", manga.Title, m.maxChaptersPerRun, left)
+		config.RunLogf(ctx, "Downloading the oldest %d chapters, %d left for later runs", m.maxChaptersPerRun, left)
+		m.excluded += left
 	}
 
-	newChaptersToDownload := len(chapterMap)
+	newChaptersToDownload = len(chapterMap)
+	config.ReportChaptersPlanned(ctx, newChaptersToDownload)
 	if newChaptersToDownload == 0 {
-		log.Printf("[Downloader] No new chapters to download")
+		message := "No new chapters to download"
+		if retryFailed {
+			message = "No failed chapters to retry"
+		}
+		log.Printf("[Downloader] %s", message)
+		// A lowered keep-latest still applies to a series that is up to date
+		m.pruneOldChapters()
 		if callback != nil {
-			callback("No new chapters to download", 1.0, 0, 0, totalChaptersFound)
+			callback(config.ProgressEvent{Status: message, Fraction: 1.0, TotalChapters: totalChaptersFound})
 		}
 		return nil
 	}
 
 	log.Printf("[Downloader] %d new chapters to download", newChaptersToDownload)
 	if callback != nil {
-		callback(fmt.Sprintf("Found %d new chapters to download", newChaptersToDownload), 0, 0, 0, totalChaptersFound)
+		callback(config.ProgressEvent{Status: fmt.Sprintf("Found %d new chapters to download", newChaptersToDownload), TotalChapters: totalChaptersFound})
 	}
 
 	// Step 4: Sort chapters
-	sortedChapters, err := parser.SortKeys(chapterMap)
-	if err != nil {
-		return fmt.Errorf("failed to sort chapters: %w", err)
+	sortedChapters := parser.SortChapterKeys(chapterMap)
+
+	// Step 5: Download each chapter, whatever happens the chapters that did not make
+	// it are remembered for a retry
+	var downloaded []string
+	defer func() { m.recordFailedChapters(chapterMap, summary, downloaded) }()
+	if err := m.downloadChapters(ctx, sortedChapters, chapterMap, totalChaptersFound, &summary, &downloaded); err != nil {
+		return err
+	}
+
+	log.Printf("[Downloader] Download complete for %s: %d/%d chapters succeeded, %d failed",
+		manga.Title, summary.Succeeded, summary.Attempted, summary.Failed)
+
+	// Step 6: Apply the keep-latest retention policy, unless chapters failed: the
+	// latest ones may be missing, pruning would count older ones out in their place
+	if summary.Err() == nil {
+		m.pruneOldChapters()
+	}
+	if callback != nil {
+		callback(config.ProgressEvent{
+			Status:          summary.Message(),
+			Fraction:        1.0,
+			CurrentDownload: newChaptersToDownload,
+			TotalChapters:   totalChaptersFound,
+		})
+	}
+
+	return summary.Err()
+}
+
+// pruneOldChapters deletes the chapters beyond the bookmark's keep-latest count
+func (m *Manager) pruneOldChapters() {
+	manga := m.config.Manga
+	if manga.KeepLatest <= 0 {
+		return
+	}
+	if _, err := parser.PruneOldChapters(manga.Location, manga.KeepLatest); err != nil {
+		log.Printf("[Downloader] ⚠️ Failed to prune old chapters for %s: %v", manga.Title, err)
+	}
+}
+
+// downloadChapters downloads sortedChapters, up to m.chapterConcurrency of them at
+// once. Chapters start in order, with more than one worker they finish in any order.
+// The outcome of every chapter is added to summary and the chapters that made it
+// whole to downloaded. Returns the context error once the series is cancelled,
+// after the chapters in progress have stopped.
+func (m *Manager) downloadChapters(ctx context.Context, sortedChapters []string, chapterMap map[string]string, totalChaptersFound int, summary *config.DownloadSummary, downloaded *[]string) error {
+	manga := m.config.Manga
+	callback := m.config.ProgressCallback
+	newChaptersToDownload := len(sortedChapters)
+
+	var (
+		mu    sync.Mutex // guards summary and downloaded
+		wg    sync.WaitGroup
+		slots = make(chan struct{}, m.chapterConcurrency)
+	)
+	if m.chapterConcurrency > 1 {
+		log.Printf("[Downloader:%s] Downloading %d chapters, %d at a time", manga.Title, newChaptersToDownload, m.chapterConcurrency)
+	}
+
+	cancelled := func(idx int) error {
+		wg.Wait()
+		log.Printf("[Downloader:%s] Cancelled - stopping download", manga.Title)
+		if callback != nil {
+			callback(config.ProgressEvent{Status: "Cancelling...", CurrentDownload: idx, TotalChapters: totalChaptersFound})
+		}
+		return ctx.Err()
 	}
 
-	// Step 5: Download each chapter
 	for idx, cbzName := range sortedChapters {
 		select {
+		case slots <- struct{}{}:
 		case <-ctx.Done():
-			log.Printf("[Downloader:%s] Cancelled - stopping download", manga.Title)
-			if callback != nil {
-				callback("Cancelling...", 0, 0, idx, totalChaptersFound)
-			}
-			return ctx.Err()
-		default:
+			return cancelled(idx)
+		}
+		if ctx.Err() != nil {
+			<-slots
+			return cancelled(idx)
+		}
+
+		// Chapter names come from site data, never let one point outside the series folder
+		if _, err := validation.SafeJoin(manga.Location, cbzName); err != nil {
+			log.Printf("[Downloader:%s] ⚠️ Skipping chapter with unsafe name: %v", manga.Title, err)
+			mu.Lock()
+			summary.FailWith(cbzName, err)
+			mu.Unlock()
+			config.ReportChapterDone(ctx)
+			<-slots
+			continue
 		}
 
 		chapterURL := chapterMap[cbzName]
@@ -105,39 +265,266 @@ func (m *Manager) Download(ctx context.Context) error {
 		progress := float64(currentDownload) / float64(newChaptersToDownload)
 
 		if callback != nil {
-			callback(
-				fmt.Sprintf("Downloading chapter %d of %d", actualChapterNum, totalChaptersFound),
-				progress,
-				actualChapterNum,
-				currentDownload,
-				totalChaptersFound,
-			)
+			callback(config.ProgressEvent{
+				Status:          fmt.Sprintf("Downloading chapter %d of %d", actualChapterNum, totalChaptersFound),
+				Fraction:        progress,
+				ChapterNum:      actualChapterNum,
+				CurrentDownload: currentDownload,
+				TotalChapters:   totalChaptersFound,
+			})
 		}
 
 		log.Printf("[Downloader:%s] Starting chapter download: %d/%d", manga.Title, actualChapterNum, totalChaptersFound)
 
-		// Download this chapter with retry
-		err := m.downloadChapterWithRetry(ctx, chapterURL, cbzName, actualChapterNum, currentDownload, totalChaptersFound, newChaptersToDownload, progress)
+		// Download this chapter with retry, in its own context so the user can skip it
+		chapterCtx, endChapter := config.ChapterContext(ctx)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := m.downloadChapterWithRetry(chapterCtx, chapterURL, cbzName, actualChapterNum, currentDownload, totalChaptersFound, newChaptersToDownload, progress)
+			skipped := endChapter()
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case skipped && err != nil:
+				log.Printf("[Downloader:%s] Chapter %s skipped by user", manga.Title, cbzName)
+				config.RunLogf(ctx, "Skipped %s", cbzName)
+				summary.Skip(cbzName)
+			case err != nil:
+				if ctx.Err() != nil {
+					// The series was cancelled, the chapter was not attempted to the end
+					return
+				}
+				log.Printf("[Downloader:%s] Failed to download chapter %s: %v", manga.Title, cbzName, err)
+				config.RunLogf(ctx, "⚠️ Failed %s: %v", cbzName, err)
+				summary.FailWith(cbzName, err)
+			default:
+				summary.Success(cbzName)
+				if m.isShortChapter(cbzName) {
+					summary.Short(cbzName)
+				} else {
+					*downloaded = append(*downloaded, cbzName)
+				}
+				log.Printf("[Downloader:%s] ✓ Completed chapter %s", manga.Title, cbzName)
+				config.RunLogf(ctx, "✓ Completed %s", cbzName)
+			}
+			config.ReportChapterDone(ctx)
+		}()
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+// isShortChapter reports whether downloadChapter kept cbzName although it came up short
+func (m *Manager) isShortChapter(cbzName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.shortChapters[cbzName]
+}
+
+// planNewChapters fetches the site's chapter list and returns the chapters to
+// download along with the number of chapters on the site
+func (m *Manager) planNewChapters(ctx context.Context) (map[string]string, int, error) {
+	manga := m.config.Manga
+	site := m.config.Site
+	callback := m.config.ProgressCallback
+
+	// Step 1: Get all chapter URLs from the site
+	if callback != nil {
+		callback(config.ProgressEvent{Status: "Fetching chapter list..."})
+	}
+
+	chapterList, err := CachedChapterList(ctx, manga.Url, site, m.chapterListTTL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get chapter URLs: %w", err)
+	}
+	chapterMap := chapterList.URLs
+
+	if len(chapterMap) == 0 {
+		return nil, 0, fmt.Errorf("%s: %w", manga.Url, ErrNoChaptersFound)
+	}
+
+	log.Printf("[Downloader] Found %d total chapters", len(chapterMap))
+	config.RunLogf(ctx, "Found %d chapters on %s", len(chapterMap), site.GetSiteName())
+
+	// Step 2: Get already downloaded chapters
+	downloadedChapters, err := parser.LocalChapterList(manga.Location)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list local chapters: %w", err)
+	}
+
+	log.Printf("[Downloader] Found %d already downloaded chapters", len(downloadedChapters))
+
+	// A renumbered site would have every chapter downloaded again under the wrong
+	// numbers, stop and let the user confirm instead
+	if err := config.CheckRenumber(ctx, downloadedChapters, parser.SortChapterKeys(chapterMap)); err != nil {
+		log.Printf("[Downloader:%s] ⚠️ %v - waiting for confirmation", manga.Title, err)
+		return nil, 0, err
+	}
+
+	totalChaptersFound := len(chapterMap)
+
+	// Step 3: Remove already downloaded chapters, plus any deliberately pruned
+	// by the keep-latest retention so they are not fetched again. In full sync
+	// mode local chapters that no longer match the source are kept for re-download,
+	// a forced re-download keeps the chapters it asked for. Local chapters are
+	// matched by number too, a changed chapter_filename_template renames nothing.
+	localKeys := parser.MatchLocalChapters(downloadedChapters, parser.SortChapterKeys(chapterMap))
+	var resync map[string]bool
+	if manga.SyncMode == config.SyncModeFull {
+		resync = m.chaptersToResync(ctx, chapterMap, downloadedChapters, localKeys)
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+	}
+	forced := 0
+	for _, chapter := range downloadedChapters {
+		key, listed := localKeys[chapter]
+		switch {
+		case !listed, resync[key]:
+		case config.Redownload(ctx, chapter):
+			forced++
+		default:
+			delete(chapterMap, key)
+		}
+	}
+	if forced > 0 {
+		log.Printf("[Downloader:%s] Re-downloading %d local chapters", manga.Title, forced)
+		config.RunLogf(ctx, "Re-downloading %d chapters already in the library", forced)
+	}
+
+	prunedChapters, err := parser.PrunedChapterList(manga.Location)
+	if err != nil {
+		log.Printf("[Downloader] ⚠️ Could not read pruned chapter list: %v", err)
+	}
+	for _, key := range parser.MatchLocalChapters(prunedChapters, parser.SortChapterKeys(chapterMap)) {
+		if _, missing := chapterMap[key]; missing {
+			m.excluded++
+			delete(chapterMap, key)
+		}
+	}
+
+	// Chapters posted within the bookmark's delay window wait for a later run, early
+	// releases are often replaced by better scans
+	if manga.DelayNewChapterHours > 0 {
+		delay := time.Duration(manga.DelayNewChapterHours) * time.Hour
+		for _, chapter := range deferRecentChapters(chapterMap, chapterList.Published, delay, time.Now()) {
+			m.excluded++
+			log.Printf("[Downloader] Deferring %s, published %s ago (delay %dh)",
+				chapter, time.Since(chapterList.Published[chapter]).Round(time.Minute), manga.DelayNewChapterHours)
+		}
+	}
+
+	return chapterMap, totalChaptersFound, nil
+}
+
+// capChapters drops all but the max lowest numbered chapters from chapterMap and
+// returns how many were dropped. A max of 0 or less keeps every chapter.
+func capChapters(chapterMap map[string]string, max int) int {
+	if max <= 0 || len(chapterMap) <= max {
+		return 0
+	}
+	sorted := parser.SortChapterKeys(chapterMap)
+	for _, cbzName := range sorted[max:] {
+		delete(chapterMap, cbzName)
+	}
+	return len(sorted) - max
+}
+
+// planFailedChapters returns the chapters earlier runs recorded as failed, they are
+// fetched again from their saved URLs without checking the site's chapter list
+func (m *Manager) planFailedChapters(ctx context.Context) (map[string]string, int, error) {
+	failed, err := parser.FailedChapters(m.config.Manga.Location)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	log.Printf("[Downloader] Retrying %d failed chapters", len(failed))
+	config.RunLogf(ctx, "Retrying %d failed chapters", len(failed))
+	return failed, len(failed), nil
+}
+
+// recordFailedChapters updates the failed chapter list of the series with the
+// chapters of this run that failed, were skipped or came out short, and drops the
+// ones that were downloaded
+func (m *Manager) recordFailedChapters(chapterMap map[string]string, summary config.DownloadSummary, downloaded []string) {
+	failed := make(map[string]string)
+	for _, names := range [][]string{summary.FailedChapters, summary.SkippedChapters, summary.ShortChapters} {
+		for _, name := range names {
+			failed[name] = chapterMap[name]
+		}
+	}
+	if err := parser.UpdateFailedChapters(m.config.Manga.Location, failed, downloaded); err != nil {
+		log.Printf("[Downloader] ⚠️ Could not update failed chapter list: %v", err)
+	}
+}
+
+// deferRecentChapters removes the chapters published less than delay before now from
+// chapterMap and returns them sorted. Chapters without a publish time are kept.
+func deferRecentChapters(chapterMap map[string]string, published map[string]time.Time, delay time.Duration, now time.Time) []string {
+	var deferred []string
+	for chapter := range chapterMap {
+		if at, ok := published[chapter]; ok && now.Sub(at) < delay {
+			deferred = append(deferred, chapter)
+			delete(chapterMap, chapter)
+		}
+	}
+	sort.Strings(deferred)
+	return deferred
+}
+
+// chaptersToResync compares the page count of every local chapter with the source
+// and returns the remote chapters whose pages differ, eg: after a site re-uploads
+// fixed versions. localKeys maps the local chapters to the remote ones, see
+// parser.MatchLocalChapters. Chapters that cannot be checked are left alone.
+func (m *Manager) chaptersToResync(ctx context.Context, chapterMap map[string]string, downloadedChapters []string, localKeys map[string]string) map[string]bool {
+	manga := m.config.Manga
+	callback := m.config.ProgressCallback
+	rateLimiter := parser.SharedRateLimiter(m.domain, 1500*time.Millisecond)
+
+	resync := make(map[string]bool)
+	for idx, cbzName := range downloadedChapters {
+		key, ok := localKeys[cbzName]
+		if !ok {
+			continue
+		}
+		chapterURL := chapterMap[key]
+		if !rateLimiter.WaitCtx(ctx) {
+			return resync
+		}
+
+		if callback != nil {
+			callback(config.ProgressEvent{Status: fmt.Sprintf("Verifying chapter %d of %d", idx+1, len(downloadedChapters)), TotalChapters: len(chapterMap)})
+		}
+
+		localPages, err := parser.CbzPageCount(filepath.Join(manga.Location, cbzName))
 		if err != nil {
-			log.Printf("[Downloader:%s] Failed to download chapter %s: %v", manga.Title, cbzName, err)
+			log.Printf("[Downloader:%s] ⚠️ Cannot read local pages, re-downloading: %v", cbzName, err)
+			resync[key] = true
 			continue
 		}
 
-		log.Printf("[Downloader:%s] ✓ Completed chapter %s", manga.Title, cbzName)
-	}
+		imageURLs, err := FetchChapterImages(ctx, chapterURL, m.config.Site)
+		if err != nil {
+			if ctx.Err() != nil {
+				return resync
+			}
+			log.Printf("[Downloader:%s] ⚠️ Cannot verify against source, keeping local copy: %v", cbzName, err)
+			continue
+		}
 
-	log.Printf("[Downloader] Download complete for %s", manga.Title)
-	if callback != nil {
-		callback(
-			fmt.Sprintf("Download complete! Downloaded %d chapters", newChaptersToDownload),
-			1.0,
-			0,
-			newChaptersToDownload,
-			totalChaptersFound,
-		)
+		if len(imageURLs) != localPages {
+			log.Printf("[Downloader:%s] Page count changed (local %d, source %d), re-downloading", cbzName, localPages, len(imageURLs))
+			resync[key] = true
+		}
 	}
 
-	return nil
+	log.Printf("[Downloader] Full sync: %d of %d local chapters need re-downloading", len(resync), len(downloadedChapters))
+	return resync
 }
 
 // downloadChapterWithRetry downloads a single chapter with retry logic
@@ -150,7 +537,13 @@ func (m *Manager) downloadChapterWithRetry(ctx context.Context, chapterURL, cbzN
 			backoff := time.Duration(math.Pow(2, float64(attempt))) * time.Second
 
 			if cb := m.config.ProgressCallback; cb != nil {
-				cb(fmt.Sprintf("Retrying chapter %d in %v (attempt %d/%d)...", actualChapterNum, backoff, attempt+1, maxRetries), progress, actualChapterNum, currentDownload, totalChaptersFound)
+				cb(config.ProgressEvent{
+					Status:          fmt.Sprintf("Retrying chapter %d in %v (attempt %d/%d)...", actualChapterNum, backoff, attempt+1, maxRetries),
+					Fraction:        progress,
+					ChapterNum:      actualChapterNum,
+					CurrentDownload: currentDownload,
+					TotalChapters:   totalChaptersFound,
+				})
 			}
 
 			log.Printf("[Downloader:%s] Retry %d/%d after %v", cbzName, attempt+1, maxRetries, backoff)
@@ -168,8 +561,14 @@ func (m *Manager) downloadChapterWithRetry(ctx context.Context, chapterURL, cbzN
 			return nil
 		}
 
+		// Cancelled or skipped, retrying cannot help
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		lastErr = err
 		log.Printf("[Downloader:%s] Failed (attempt %d/%d): %v", cbzName, attempt+1, maxRetries, err)
+		config.RunLogf(ctx, "%s attempt %d/%d failed: %v", cbzName, attempt+1, maxRetries, err)
 	}
 
 	return fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
@@ -182,14 +581,16 @@ func (m *Manager) downloadChapter(ctx context.Context, chapterURL, cbzName strin
 	callback := m.config.ProgressCallback
 
 	// Create temp directory
-	chapterDir := filepath.Join("/tmp", site.GetSiteName(), strings.TrimSuffix(cbzName, ".cbz"))
+	chapterDir := ChapterTempDir(site.GetSiteName(), manga, cbzName)
 	if err := os.MkdirAll(chapterDir, 0755); err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
+		return fmt.Errorf("failed to create temp directory: %w", diskError(err))
 	}
-	defer os.RemoveAll(chapterDir)
+	defer parser.CleanupTempDir(chapterDir)
 
 	var imageURLs []string
 	successCount := 0
+	var lastImageErr error
+	var stream *parser.CbzWriter // set when pages go straight into the CBZ
 
 	// For kunmanga specifically, use the browser's network stack to download
 	// images directly — this bypasses Cloudflare's TLS fingerprint checks that
@@ -206,13 +607,16 @@ func (m *Manager) downloadChapter(ctx context.Context, chapterURL, cbzName strin
 		if err != nil {
 			log.Printf("[Downloader:%s] Failed to create browser session, falling back to HTTP: %v", cbzName, err)
 		} else {
-			chapterImages, dlErr := session.DownloadChapterImages(
-				chapterURL,
-				imgMethod.WaitSelector,
-				imgMethod.JavaScript,
-				"",
-			)
-			session.Close()
+			// Close is deferred so the tab goes back to the pool even if the download panics
+			chapterImages, dlErr := func() (*ChapterImages, error) {
+				defer session.Close()
+				return session.DownloadChapterImages(
+					chapterURL,
+					imgMethod.WaitSelector,
+					imgMethod.JavaScript,
+					"",
+				)
+			}()
 
 			if dlErr != nil {
 				log.Printf("[Downloader:%s] Browser download failed, falling back to HTTP: %v", cbzName, dlErr)
@@ -251,16 +655,132 @@ func (m *Manager) downloadChapter(ctx context.Context, chapterURL, cbzName strin
 		}
 
 		if len(imageURLs) == 0 {
-			return fmt.Errorf("no images found")
+			return fmt.Errorf("no images found: %w", ErrSiteChanged)
 		}
 
 		log.Printf("[Downloader:%s] Found %d images", cbzName, len(imageURLs))
 
-		rateLimiter := parser.NewRateLimiter(1500 * time.Millisecond)
-		defer rateLimiter.Stop()
+		// Signed page URLs can expire while the pages before them download, a failed
+		// signed page has the chapter's URLs fetched again before it is retried
+		refreshes := 0
+		refreshSigned := func(imgIdx int) bool {
+			if refreshes >= maxSignedURLRefreshes || !IsSignedImageURL(imageURLs[imgIdx]) {
+				return false
+			}
+			refreshes++
+			fresh, err := RefreshImageURLs(ctx, chapterURL, site, len(imageURLs))
+			if err != nil {
+				log.Printf("[Downloader:%s] ⚠️ Could not refresh signed image URLs: %v", cbzName, err)
+				return false
+			}
+			log.Printf("[Downloader:%s] Refreshed signed image URLs (%d/%d)", cbzName, refreshes, maxSignedURLRefreshes)
+			config.RunLogf(ctx, "Image links of %s expired, fetched fresh ones", cbzName)
+			imageURLs = fresh
+			return true
+		}
+
+		// Streamed chapters are written into the CBZ page by page, each page only
+		// passes through the temp dir on its way in
+		if m.streamCbz {
+			cbzPath, err := validation.SafeJoin(manga.Location, cbzName)
+			if err != nil {
+				return fmt.Errorf("refusing to write CBZ: %w", err)
+			}
+			if stream, err = parser.NewCbzWriter(cbzPath); err != nil {
+				return fmt.Errorf("failed to create CBZ: %w", diskError(err))
+			}
+			defer stream.Abort()
+		}
 
-		for imgIdx, imgURL := range imageURLs {
-			log.Printf("[Downloader:%s] Downloading image %d/%d", cbzName, imgIdx+1, len(imageURLs))
+		// Pages left in the temp directory by a crashed run are reused, the last
+		// one is verified since it may have been cut off mid-write
+		resumed, err := parser.ResumeTempPages(chapterDir)
+		if err != nil {
+			log.Printf("[Downloader:%s] ⚠️ Cannot resume from temp pages, fetching all: %v", cbzName, err)
+			resumed = nil
+		} else if len(resumed) > 0 {
+			log.Printf("[Downloader:%s] Resuming with %d pages from an interrupted run", cbzName, len(resumed))
+		}
+
+		var pending []int
+		for imgIdx := range imageURLs {
+			if _, ok := resumed[fmt.Sprintf("%03d", imgIdx+1)]; ok {
+				delete(resumed, fmt.Sprintf("%03d", imgIdx+1))
+				if err := streamPage(stream, chapterDir, imgIdx+1); err != nil {
+					return fmt.Errorf("failed to create CBZ: %w", diskError(err))
+				}
+				successCount++
+				continue
+			}
+			pending = append(pending, imgIdx)
+		}
+
+		reportImage := func(imgIdx int) {
+			if callback != nil {
+				imgProgress := progress + (float64(imgIdx) / float64(len(imageURLs)) / float64(newChaptersToDownload))
+				callback(config.ProgressEvent{
+					Status:          fmt.Sprintf("Chapter %d/%d: Downloading image %d/%d", actualChapterNum, totalChaptersFound, imgIdx+1, len(imageURLs)),
+					Fraction:        imgProgress,
+					ChapterNum:      actualChapterNum,
+					CurrentDownload: currentDownload,
+					TotalChapters:   totalChaptersFound,
+					ImageIndex:      imgIdx + 1,
+					ImageTotal:      len(imageURLs),
+				})
+			}
+		}
+
+		// Sites with their own rate budget set how many pages are in flight and pace them
+		// themselves, the others use the image_concurrency setting and the domain limiter
+		concurrency, wait := m.imageConcurrency, (func(context.Context) error)(nil)
+		if cs, ok := site.(ConcurrentImageSite); ok && cs.ImageConcurrency() > 1 {
+			concurrency, wait = cs.ImageConcurrency(), cs.WaitImage
+		}
+		if concurrency > 1 {
+			// Pages finish out of order here, the writer holds early ones back
+			var failed []int
+			var streamErr error
+			streamed := func(imgIdx int, err error) error {
+				if err != nil {
+					failed = append(failed, imgIdx)
+					if stream != nil {
+						stream.Skip(imgIdx + 1)
+					}
+					return nil
+				}
+				// Like a sequential page, one that cannot be added fails the chapter
+				if err := streamPage(stream, chapterDir, imgIdx+1); err != nil {
+					if streamErr == nil {
+						streamErr = err
+					}
+					return err
+				}
+				return nil
+			}
+			downloaded, err := m.downloadImagesConcurrently(ctx, concurrency, wait, chapterURL, imageURLs, pending, chapterDir, cbzName, reportImage, streamed)
+			successCount += downloaded
+			if err != nil {
+				lastImageErr = err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if streamErr != nil {
+				return fmt.Errorf("failed to create CBZ: %w", diskError(streamErr))
+			}
+			pending = nil
+
+			// Expired pages are retried one by one below, a streamed CBZ has already
+			// skipped them
+			if stream == nil && len(failed) > 0 && refreshSigned(failed[0]) {
+				sort.Ints(failed)
+				pending = failed
+			}
+		}
+
+		// The fetcher spaces these out on the limiter shared by the domain, so
+		// concurrent series on one site are paced together
+		for _, imgIdx := range pending {
 			select {
 			case <-ctx.Done():
 				log.Printf("[Downloader:%s] Cancelled during image download", cbzName)
@@ -268,38 +788,58 @@ func (m *Manager) downloadChapter(ctx context.Context, chapterURL, cbzName strin
 			default:
 			}
 
-			if !rateLimiter.WaitCtx(ctx) {
-				log.Printf("[Downloader:%s] Cancelled during rate limit wait", cbzName)
-				return ctx.Err()
-			}
+			log.Printf("[Downloader:%s] Downloading image %d/%d", cbzName, imgIdx+1, len(imageURLs))
+			reportImage(imgIdx)
 
-			if callback != nil {
-				imgProgress := progress + (float64(imgIdx) / float64(len(imageURLs)) / float64(newChaptersToDownload))
-				callback(
-					fmt.Sprintf("Chapter %d/%d: Downloading image %d/%d", actualChapterNum, totalChaptersFound, imgIdx+1, len(imageURLs)),
-					imgProgress,
-					actualChapterNum,
-					currentDownload,
-					totalChaptersFound,
-				)
+			err := m.downloadImageWithRetry(ctx, m.imageRequest(imageURLs[imgIdx], chapterURL, nil), chapterDir, fmt.Sprintf("%03d", imgIdx+1))
+			if err != nil && ctx.Err() == nil && refreshSigned(imgIdx) {
+				err = m.downloadImageWithRetry(ctx, m.imageRequest(imageURLs[imgIdx], chapterURL, nil), chapterDir, fmt.Sprintf("%03d", imgIdx+1))
 			}
-
-			filename := fmt.Sprintf("%03d", imgIdx+1)
-			err := m.downloadImageWithRetry(ctx, imgURL, chapterDir, filename)
 			if err != nil {
 				log.Printf("[Downloader:%s] Failed to download image %d: %v", cbzName, imgIdx+1, err)
+				lastImageErr = err
+				if stream != nil {
+					stream.Skip(imgIdx + 1)
+				}
 			} else {
+				if err := streamPage(stream, chapterDir, imgIdx+1); err != nil {
+					return fmt.Errorf("failed to create CBZ: %w", diskError(err))
+				}
 				successCount++
 			}
 		}
+
+		// Pages beyond the current image list belong to an older version of the chapter
+		for _, stale := range resumed {
+			os.Remove(filepath.Join(chapterDir, stale))
+		}
 	}
 
 	log.Printf("[Downloader:%s] Downloaded %d/%d images", cbzName, successCount, len(imageURLs))
 
+	// Written before the page checks so chapters that come up short have one too
+	if m.imageManifest && len(imageURLs) > 0 {
+		m.writeImageManifest(cbzName, imageURLs)
+	}
+
 	if successCount == 0 {
+		if lastImageErr != nil {
+			return fmt.Errorf("no images downloaded successfully: %w", diskError(lastImageErr))
+		}
 		return fmt.Errorf("no images downloaded successfully")
 	}
 
+	if short, median := m.pageCounts.Check(successCount); short {
+		if m.rejectShort {
+			return fmt.Errorf("%d pages where recent chapters have %d: %w", successCount, median, ErrShortChapter)
+		}
+		log.Printf("[Downloader:%s] ⚠️ Only %d pages where recent chapters have %d, the chapter may be incomplete", cbzName, successCount, median)
+		config.RunLogf(ctx, "⚠️ %s has only %d pages where recent chapters have %d", cbzName, successCount, median)
+		m.mu.Lock()
+		m.shortChapters[cbzName] = true
+		m.mu.Unlock()
+	}
+
 	// Create CBZ
 	select {
 	case <-ctx.Done():
@@ -309,24 +849,122 @@ func (m *Manager) downloadChapter(ctx context.Context, chapterURL, cbzName strin
 	}
 
 	if callback != nil {
-		callback(
-			fmt.Sprintf("Chapter %d/%d: Creating CBZ file...", actualChapterNum, totalChaptersFound),
-			progress,
-			actualChapterNum,
-			currentDownload,
-			totalChaptersFound,
-		)
+		callback(config.ProgressEvent{
+			Status:          fmt.Sprintf("Chapter %d/%d: Creating CBZ file...", actualChapterNum, totalChaptersFound),
+			Fraction:        progress,
+			ChapterNum:      actualChapterNum,
+			CurrentDownload: currentDownload,
+			TotalChapters:   totalChaptersFound,
+		})
+	}
+
+	if m.splitMaxHeight > 0 {
+		if _, err := parser.SplitTallPages(chapterDir, m.splitMaxHeight); err != nil {
+			log.Printf("[Downloader:%s] ⚠️ Failed to split tall pages: %v", cbzName, err)
+		}
+	}
+
+	info := parser.ComicInfo{
+		Series: manga.Title,
+		Number: parser.ChapterLabel(cbzName),
+		Notes:  "Downloaded by kansho on " + time.Now().Format("2006-01-02"),
+		Web:    chapterURL,
+	}
+	if site, ok := m.config.Site.(ComicInfoSite); ok && m.writeComicInfo {
+		info.Merge(site.ChapterComicInfo(manga, chapterURL, cbzName))
+	}
+	if m.writeComicInfo && stream != nil {
+		stream.SetComicInfo(info)
+	} else if m.writeComicInfo {
+		if err := parser.WriteComicInfo(chapterDir, info); err != nil {
+			log.Printf("[Downloader:%s] ⚠️ Failed to write ComicInfo: %v", cbzName, err)
+		}
 	}
 
-	cbzPath := filepath.Join(manga.Location, cbzName)
-	if err := parser.CreateCbzFromDir(chapterDir, cbzPath); err != nil {
-		return fmt.Errorf("failed to create CBZ: %w", err)
+	cbzPath, err := validation.SafeJoin(manga.Location, cbzName)
+	if err != nil {
+		return fmt.Errorf("refusing to write CBZ: %w", err)
+	}
+	if stream != nil {
+		err = stream.Close()
+	} else {
+		err = parser.PackageChapter(chapterDir, cbzPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create CBZ: %w", diskError(err))
 	}
 
 	log.Printf("[Downloader] ✓ Created CBZ: %s (%d images)", cbzName, successCount)
+
+	// Mirrors are best effort, the chapter is downloaded once it is in Location
+	config.MirrorChapter(manga, cbzPath)
 	return nil
 }
 
+// writeImageManifest writes the chapter's image URLs next to where its CBZ goes,
+// failures are only logged since the manifest is a debugging aid
+func (m *Manager) writeImageManifest(cbzName string, imageURLs []string) {
+	cbzPath, err := validation.SafeJoin(m.config.Manga.Location, cbzName)
+	if err == nil {
+		err = parser.WriteImageManifest(cbzPath, imageURLs)
+	}
+	if err != nil {
+		log.Printf("[Downloader:%s] ⚠️ Failed to write image manifest: %v", cbzName, err)
+	}
+}
+
+// streamPage moves page index from chapterDir into the streamed CBZ. Does nothing
+// when the chapter is not streamed.
+func streamPage(stream *parser.CbzWriter, chapterDir string, index int) error {
+	if stream == nil {
+		return nil
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(chapterDir, fmt.Sprintf("%03d.*", index)))
+	if len(matches) == 0 {
+		return fmt.Errorf("page %d missing from %s", index, chapterDir)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		return err
+	}
+	if err := stream.AddImage(index, data); err != nil {
+		return err
+	}
+	return os.Remove(matches[0])
+}
+
+// ChapterTempDir returns the temporary working directory for a chapter. The path
+// includes a per-series component so series downloading concurrently from the same
+// site never share a directory, even when their chapter filenames match.
+func ChapterTempDir(siteName string, manga *config.Bookmarks, cbzName string) string {
+	return filepath.Join("/tmp", siteName, seriesTempID(manga), strings.TrimSuffix(cbzName, ".cbz"))
+}
+
+// seriesTempID builds a filesystem safe identifier for a series from its title,
+// suffixed with a short hash of its URL and location to keep it unique
+func seriesTempID(manga *config.Bookmarks) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(manga.Title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteRune('-')
+		}
+		if b.Len() >= 40 {
+			break
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		slug = "series"
+	}
+
+	sum := sha1.Sum([]byte(manga.Url + "|" + manga.Location))
+	return fmt.Sprintf("%s-%x", slug, sum[:4])
+}
+
 // guessExtension returns the file extension based on magic bytes
 func guessExtension(data []byte) string {
 	if len(data) < 4 {
@@ -351,8 +989,80 @@ func guessExtension(data []byte) string {
 	return "bin"
 }
 
-// downloadImageWithRetry downloads a single image with retry logic
-func (m *Manager) downloadImageWithRetry(ctx context.Context, imageURL, targetDir, filename string) error {
+// downloadImagesConcurrently downloads the pending pages of a chapter with up to
+// concurrency requests in flight, each one paced by wait (the domain limiter when
+// nil) and still bound by the shared fetcher's global cap.
+// done is called with the outcome of every page, one at a time. An error from done
+// fails that page and stops the remaining downloads, the chapter cannot be
+// completed. Returns the number of pages downloaded and the last error.
+func (m *Manager) downloadImagesConcurrently(ctx context.Context, concurrency int, wait func(context.Context) error, chapterURL string, imageURLs []string, pending []int, chapterDir, cbzName string, report func(imgIdx int), done func(imgIdx int, err error) error) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		downloaded int
+		lastErr    error
+	)
+
+	slots := make(chan struct{}, concurrency)
+	log.Printf("[Downloader:%s] Downloading %d images, %d at a time", cbzName, len(pending), cap(slots))
+
+	for _, imgIdx := range pending {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return downloaded, ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(imgIdx int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			report(imgIdx)
+			err := m.downloadImageWithRetry(ctx, m.imageRequest(imageURLs[imgIdx], chapterURL, wait), chapterDir, fmt.Sprintf("%03d", imgIdx+1))
+
+			mu.Lock()
+			defer mu.Unlock()
+			if doneErr := done(imgIdx, err); doneErr != nil && err == nil {
+				err = doneErr
+				cancel()
+			}
+			if err != nil {
+				log.Printf("[Downloader:%s] Failed to download image %d: %v", cbzName, imgIdx+1, err)
+				lastErr = err
+				return
+			}
+			downloaded++
+		}(imgIdx)
+	}
+
+	wg.Wait()
+	return downloaded, lastErr
+}
+
+// imageRequest is the shared fetcher request for a page of the chapter at chapterURL,
+// wait is nil for the domain limiter
+func (m *Manager) imageRequest(imageURL, chapterURL string, wait func(context.Context) error) ImageRequest {
+	req := ImageRequest{
+		URL:      imageURL,
+		Domain:   m.domain,
+		CFBypass: m.config.Site.NeedsCFBypass(),
+		Wait:     wait,
+	}
+	// API sites pass chapter IDs rather than reader pages
+	if strings.HasPrefix(chapterURL, "http://") || strings.HasPrefix(chapterURL, "https://") {
+		req.Referer = chapterURL
+	}
+	return req
+}
+
+// downloadImageWithRetry downloads a single image through the shared fetcher with
+// retry logic
+func (m *Manager) downloadImageWithRetry(ctx context.Context, req ImageRequest, targetDir, filename string) error {
 	maxRetries := 3
 	var lastErr error
 
@@ -365,35 +1075,30 @@ func (m *Manager) downloadImageWithRetry(ctx context.Context, imageURL, targetDi
 			}
 		}
 
-		// Use parser's download function with CF support if needed
-		if m.config.Site.NeedsCFBypass() {
-			err := parser.DownloadConvertToJPGRenameCf(ctx, filename, imageURL, targetDir, m.domain)
-			if err == nil {
-				return nil
-			}
-			lastErr = err
-		} else {
-			err := parser.DownloadConvertToJPGRename(ctx, filename, imageURL, targetDir)
-			if err == nil {
-				return nil
-			}
-			lastErr = err
+		// Sites that keep native images skip the JPEG re-encode entirely
+		err := SharedImageFetcher().FetchTo(ctx, req, targetDir, filename, m.keepNativeImages())
+		if err == nil {
+			return nil
 		}
+		lastErr = err
 	}
 
 	return lastErr
 }
 
+// keepNativeImages reports whether the site wants pages stored in their original format
+func (m *Manager) keepNativeImages() bool {
+	if ns, ok := m.config.Site.(NativeImageSite); ok {
+		return ns.KeepNativeImageFormat()
+	}
+	return false
+}
+
 // extractChapterNumber extracts the numeric chapter number from filenames like "ch001.cbz"
 func extractChapterNumber(filename string) int {
-	name := strings.TrimSuffix(filename, ".cbz")
-	name = strings.TrimPrefix(name, "ch")
-	parts := strings.Split(name, ".")
-	if len(parts) == 0 {
+	num, _, _, err := parser.ParseChapterNumber(filename)
+	if err != nil {
 		return 0
 	}
-
-	var chapterNum int
-	fmt.Sscanf(parts[0], "%d", &chapterNum)
-	return chapterNum
+	return num
 }
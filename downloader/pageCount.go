@@ -0,0 +1,53 @@
+package downloader
+
+import (
+	"sort"
+	"sync"
+
+	"kansho/parser"
+)
+
+const (
+	// pageCountHistory is how many recent chapters the median is taken over
+	pageCountHistory = 9
+
+	// pageCountMinHistory is how many chapters must be seen before any are judged
+	pageCountMinHistory = 3
+)
+
+// PageCountMonitor tracks the page counts of the chapters downloaded in one run and
+// flags chapters with far fewer pages than their neighbours, which usually means
+// the scraper only caught part of the chapter (eg: lazy loading was cut short).
+type PageCountMonitor struct {
+	mu     sync.Mutex
+	recent []int
+}
+
+// NewPageCountMonitor creates a monitor with no history
+func NewPageCountMonitor() *PageCountMonitor {
+	return &PageCountMonitor{}
+}
+
+// Check compares pages with the median of the recent chapters and reports whether
+// the chapter looks short. Chapters that look fine are added to the history, short
+// ones are not so a run of bad chapters cannot drag the median down.
+func (p *PageCountMonitor) Check(pages int) (short bool, median int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.recent) >= pageCountMinHistory {
+		sorted := append([]int(nil), p.recent...)
+		sort.Ints(sorted)
+		median = sorted[len(sorted)/2]
+
+		if parser.IsShortOfMedian(pages, median) {
+			return true, median
+		}
+	}
+
+	p.recent = append(p.recent, pages)
+	if len(p.recent) > pageCountHistory {
+		p.recent = p.recent[1:]
+	}
+	return false, median
+}
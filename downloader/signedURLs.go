@@ -0,0 +1,57 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// maxSignedURLRefreshes is how many times a chapter's signed image URLs are fetched
+// again before its failed pages are given up on
+const maxSignedURLRefreshes = 2
+
+// signedURLParams are query parameters CDNs use to sign or expire a URL (CloudFront,
+// S3, Akamai and the plain token/expires pairs of smaller hosts)
+var signedURLParams = map[string]bool{
+	"token": true, "expires": true, "expiry": true, "exp": true,
+	"signature": true, "sig": true, "hmac": true, "hdnts": true,
+	"policy": true, "key-pair-id": true,
+	"x-amz-signature": true, "x-amz-expires": true, "x-goog-signature": true,
+}
+
+// IsSignedImageURL reports whether an image URL carries a signature or an expiry in
+// its query, such URLs stop working a while after the chapter page handed them out
+func IsSignedImageURL(imageURL string) bool {
+	parsed, err := url.Parse(imageURL)
+	if err != nil {
+		return false
+	}
+	for key := range parsed.Query() {
+		if signedURLParams[strings.ToLower(key)] {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshImageURLs returns a fresh list of the chapter's image URLs, from the site's
+// ImageURLRefresher when it has one, otherwise by extracting the chapter's images
+// again. The list must have as many pages as before, or the pages downloaded so far
+// could no longer be matched to it.
+func RefreshImageURLs(ctx context.Context, chapterURL string, site SitePlugin, pages int) ([]string, error) {
+	var fresh []string
+	var err error
+	if refresher, ok := site.(ImageURLRefresher); ok {
+		fresh, err = refresher.RefreshImageURLs(ctx, chapterURL)
+	} else {
+		fresh, err = FetchChapterImages(ctx, chapterURL, site)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(fresh) != pages {
+		return nil, fmt.Errorf("chapter now has %d images instead of %d", len(fresh), pages)
+	}
+	return fresh, nil
+}
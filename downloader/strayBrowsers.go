@@ -0,0 +1,146 @@
+package downloader
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// browserDataDirPrefix names the user data dir of every Chrome the pool launches,
+// followed by the pid of the kansho process that owns it. The dir shows up in the
+// command line of Chrome and its helper processes, which is how stray ones are found.
+const browserDataDirPrefix = "kansho-chrome-"
+
+// liveDataDirs are the data dirs of browsers this process still has running
+var (
+	liveDataDirsMu sync.Mutex
+	liveDataDirs   = map[string]bool{}
+)
+
+// newBrowserDataDir creates the user data dir for a browser launched by this process
+func newBrowserDataDir() (string, error) {
+	dir, err := os.MkdirTemp("", fmt.Sprintf("%s%d-", browserDataDirPrefix, os.Getpid()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create browser data dir: %w", err)
+	}
+	liveDataDirsMu.Lock()
+	liveDataDirs[dir] = true
+	liveDataDirsMu.Unlock()
+	return dir, nil
+}
+
+// removeBrowserDataDir forgets and deletes dir once its browser has exited
+func removeBrowserDataDir(dir string) {
+	liveDataDirsMu.Lock()
+	delete(liveDataDirs, dir)
+	liveDataDirsMu.Unlock()
+	if err := os.RemoveAll(dir); err != nil {
+		log.Printf("[BrowserPool] ⚠️ Failed to remove browser data dir %s: %v", dir, err)
+	}
+}
+
+// KillStrayBrowsers kills Chrome processes kansho launched that no longer belong to
+// a running browser: those left behind by a kansho process that has exited, and
+// helpers of a browser this process has already shut down. Browsers in use, and
+// those of another running kansho, are left alone. Their leftover data dirs under
+// the temp dir are removed too. Returns the number of processes killed.
+//
+// Processes are found through /proc, on other platforms this does nothing.
+func KillStrayBrowsers() (int, error) {
+	if runtime.GOOS != "linux" {
+		return 0, nil
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	killed := 0
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "cmdline"))
+		if err != nil {
+			continue // exited meanwhile or not ours to read
+		}
+		dir, ok := browserDataDirArg(cmdline)
+		if !ok || !isStrayDataDir(dir) {
+			continue
+		}
+
+		proc, err := os.FindProcess(pid)
+		if err != nil {
+			continue
+		}
+		if err := proc.Kill(); err != nil {
+			log.Printf("[BrowserPool] ⚠️ Failed to kill stray browser process %d: %v", pid, err)
+			continue
+		}
+		log.Printf("[BrowserPool] Killed stray browser process %d (%s)", pid, dir)
+		killed++
+	}
+
+	// Data dirs of exited kansho processes, Chrome is gone but the dir stays behind
+	dirs, _ := filepath.Glob(filepath.Join(os.TempDir(), browserDataDirPrefix+"*"))
+	for _, dir := range dirs {
+		if owner, ok := dataDirOwner(dir); ok && owner != os.Getpid() && !processExists(owner) {
+			os.RemoveAll(dir)
+		}
+	}
+
+	return killed, nil
+}
+
+// sweepStrayBrowsers runs KillStrayBrowsers, only logging failures
+func sweepStrayBrowsers() {
+	if _, err := KillStrayBrowsers(); err != nil {
+		log.Printf("[BrowserPool] ⚠️ Stray browser cleanup failed: %v", err)
+	}
+}
+
+// browserDataDirArg returns the kansho data dir a NUL separated command line runs with
+func browserDataDirArg(cmdline []byte) (string, bool) {
+	for _, arg := range bytes.Split(cmdline, []byte{0}) {
+		dir, ok := strings.CutPrefix(string(arg), "--user-data-dir=")
+		if ok && strings.HasPrefix(filepath.Base(dir), browserDataDirPrefix) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// isStrayDataDir reports whether the browser using dir has lost its owner
+func isStrayDataDir(dir string) bool {
+	owner, ok := dataDirOwner(dir)
+	if !ok {
+		return false
+	}
+	if owner != os.Getpid() {
+		return !processExists(owner)
+	}
+	liveDataDirsMu.Lock()
+	defer liveDataDirsMu.Unlock()
+	return !liveDataDirs[dir]
+}
+
+// dataDirOwner parses the owning pid out of a kansho data dir name
+func dataDirOwner(dir string) (int, bool) {
+	rest := strings.TrimPrefix(filepath.Base(dir), browserDataDirPrefix)
+	pid, _, _ := strings.Cut(rest, "-")
+	owner, err := strconv.Atoi(pid)
+	return owner, err == nil && owner > 0
+}
+
+func processExists(pid int) bool {
+	_, err := os.Stat(filepath.Join("/proc", strconv.Itoa(pid)))
+	return err == nil
+}
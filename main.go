@@ -21,6 +21,7 @@ import (
 	"fyne.io/fyne/v2/driver/desktop"
 
 	"kansho/config"
+	"kansho/downloader"
 	"kansho/ui"
 )
 
@@ -57,6 +58,18 @@ func main() {
 			log.Println("[UI] Kansho Logs opened (GUI)")
 			ui.ShowLogWindow(kanshoApp)
 		}),
+		fyne.NewMenuItem("Reload Site Config", func() {
+			log.Println("[UI] Reload site config triggered (GUI)")
+			ui.ShowReloadSiteConfigDialog(myWindow)
+		}),
+		fyne.NewMenuItem("Site Login", func() {
+			log.Println("[UI] Site login opened (GUI)")
+			ui.ShowSiteLoginDialog(myWindow)
+		}),
+		fyne.NewMenuItem("Package Folders", func() {
+			log.Println("[UI] Package folders triggered (GUI)")
+			ui.ShowPackageFoldersDialog(myWindow)
+		}),
 	)
 
 	helpMenu := fyne.NewMenu("Help",
@@ -79,6 +92,19 @@ func main() {
 			log.Println("[UI] Import Bookmarks triggered (GUI)")
 			ui.ShowImportBookmarksDialog(kanshoApp, myWindow)
 		}),
+		fyne.NewMenuItem("Import Mihon Backup", func() {
+			log.Println("[UI] Import Mihon backup triggered (GUI)")
+			ui.ShowImportMihonBackupDialog(myWindow)
+		}),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Recheck All", func() {
+			log.Println("[UI] Recheck all triggered (GUI)")
+			ui.ShowRecheckAllDialog(myWindow, false)
+		}),
+		fyne.NewMenuItem("Recheck All (Force)", func() {
+			log.Println("[UI] Forced recheck all triggered (GUI)")
+			ui.ShowRecheckAllDialog(myWindow, true)
+		}),
 	)
 
 	mainMenu := fyne.NewMainMenu(fileMenu, bookmarksMenu, helpMenu)
@@ -116,7 +142,33 @@ func main() {
 		ui.ShowConfigWindow(kanshoApp)
 	})
 
+	// -------------------------------------------------------------------------
+	// SYSTEM TRAY
+	// -------------------------------------------------------------------------
+	// With automatic update checks on, closing the window keeps kansho running in
+	// the tray so the scheduler can pick up new chapters
+	desk, hasTray := kanshoApp.(desktop.App)
+	if hasTray {
+		desk.SetSystemTrayIcon(appIcon)
+		desk.SetSystemTrayMenu(fyne.NewMenu("Kansho",
+			fyne.NewMenuItem("Show Kansho", func() {
+				myWindow.Show()
+				myWindow.RequestFocus()
+			}),
+			fyne.NewMenuItem("Recheck All", func() {
+				log.Println("[UI] Recheck all triggered (tray)")
+				myWindow.Show()
+				ui.ShowRecheckAllDialog(myWindow, false)
+			}),
+		))
+	}
+
 	myWindow.SetCloseIntercept(func() {
+		if hasTray && config.LoadSettings().AutoCheckIntervalHours > 0 {
+			log.Println("[UI] Window closed, automatic update checks keep running in the tray")
+			myWindow.Hide()
+			return
+		}
 		log.Println("[UI] User closed application (File menu)")
 		kanshoApp.Quit()
 	})
@@ -128,6 +180,25 @@ func main() {
 	content := ui.BuildMainLayout(myWindow)
 	myWindow.SetContent(content)
 
+	// Chrome left running by an earlier kansho that crashed or was killed
+	go func() {
+		if killed, err := downloader.KillStrayBrowsers(); err != nil {
+			log.Printf("[BrowserPool] ⚠️ Stray browser cleanup failed: %v", err)
+		} else if killed > 0 {
+			log.Printf("[BrowserPool] Killed %d stray browser processes", killed)
+		}
+	}()
+
+	// Queue series for download as their check interval passes
+	scheduler := config.NewUpdateScheduler(config.GetDownloadQueue())
+	scheduler.Start()
+
+	// Shut down any warm chromedp browsers when the app exits
+	kanshoApp.Lifecycle().SetOnStopped(func() {
+		scheduler.Stop()
+		downloader.CloseBrowserPool()
+	})
+
 	// Show the window and run the event loop
 	myWindow.ShowAndRun()
 }
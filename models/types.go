@@ -10,13 +10,53 @@ type RequiredFields struct {
 	Location  bool `json:"location"`  // Whether a location/path is required
 }
 
+// SiteSelectors holds the CSS selectors a site's extractors use to find chapters
+// and page images. Empty fields fall back to the selectors hardcoded in the site,
+// so a broken selector can be fixed from config without a code change.
+type SiteSelectors struct {
+	ChapterList    string `json:"chapter_list,omitempty"`    // Selector for chapter links on the series page
+	Image          string `json:"image,omitempty"`           // Selector for page images on the chapter page
+	ImageAttribute string `json:"image_attribute,omitempty"` // Attribute holding the image URL (e.g., "data-src"), or several tried in order ("data-src,src")
+}
+
+// ImageHosts restricts which hosts a site's page images may come from, so ad and
+// tracker images on the reader page are not saved as chapter pages. A pattern
+// matches the host and its subdomains ("example.com" matches "cdn.example.com"),
+// a leading "*." is optional. With Allow set only matching hosts are kept, Deny
+// drops matching hosts either way.
+type ImageHosts struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// ImageURLTransform rewrites a scraped page image URL before it is downloaded, eg:
+// strip the size suffix of a Madara thumbnail ("-350x500.jpg" -> ".jpg") to get the
+// full resolution image. Match is a Go regular expression, Replace may use $1 style
+// references to its groups.
+type ImageURLTransform struct {
+	Match   string `json:"match"`
+	Replace string `json:"replace"`
+}
+
 // Site represents a manga source website configuration.
 // Each site has different requirements for what data is needed to track manga.
 // The DisplayName is shown to users, while Name is used internally.
 type Site struct {
-	Name           string         `json:"name"`            // Internal identifier (e.g., "mangadex")
-	DisplayName    string         `json:"display_name"`    // User-facing name (e.g., "MangaDex")
-	RequiredFields RequiredFields `json:"required_fields"` // Which fields this site requires
+	Name           string         `json:"name"`                  // Internal identifier (e.g., "mangadex")
+	DisplayName    string         `json:"display_name"`          // User-facing name (e.g., "MangaDex")
+	RequiredFields RequiredFields `json:"required_fields"`       // Which fields this site requires
+	Selectors      *SiteSelectors `json:"selectors,omitempty"`   // Optional extractor selector overrides
+	ImageHosts     *ImageHosts    `json:"image_hosts,omitempty"` // Optional page image host filter
+
+	// Optional page image URL rewrites, applied in order
+	ImageURLTransforms []ImageURLTransform `json:"image_url_transforms,omitempty"`
+
+	// Optional login page for sites that need an account, offered by Site Login
+	LoginURL string `json:"login_url,omitempty"`
+
+	// Optional selector of the reader's next page link, for sites splitting a
+	// chapter's images over several reader pages (?page=2)
+	ReaderNextPage string `json:"reader_next_page,omitempty"`
 }
 
 // SitesConfig represents the root structure of the sites.json configuration file.
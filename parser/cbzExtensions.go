@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CBZ entry extension rules, see SetCbzExtensionRule
+const (
+	CbzExtensionsAsIs      = ""          // entry names are written as the pages are named
+	CbzExtensionsLowercase = "lowercase" // 001.JPG -> 001.jpg
+	CbzExtensionsStandard  = "standard"  // lowercase, and 001.jpeg -> 001.jpg
+)
+
+var (
+	cbzExtensionRuleMu sync.RWMutex
+	cbzExtensionRule   string
+)
+
+// SetCbzExtensionRule sets how CreateCbzFromDir names page entries, for strict
+// readers that only open lowercase .jpg/.png pages. An unknown rule is logged and
+// leaves the names as they are.
+func SetCbzExtensionRule(rule string) {
+	switch rule {
+	case CbzExtensionsAsIs, CbzExtensionsLowercase, CbzExtensionsStandard:
+	default:
+		log.Printf("[CBZ] ⚠️ Unknown extension rule %q, keeping page names as they are", rule)
+		rule = CbzExtensionsAsIs
+	}
+	cbzExtensionRuleMu.Lock()
+	cbzExtensionRule = rule
+	cbzExtensionRuleMu.Unlock()
+}
+
+// CbzExtensionRule returns the rule set with SetCbzExtensionRule
+func CbzExtensionRule() string {
+	cbzExtensionRuleMu.RLock()
+	defer cbzExtensionRuleMu.RUnlock()
+	return cbzExtensionRule
+}
+
+// NormalizeCbzEntryName applies rule to the extension of a page file name, the rest
+// of the name is left alone
+func NormalizeCbzEntryName(name, rule string) string {
+	if rule == CbzExtensionsAsIs {
+		return name
+	}
+	ext := filepath.Ext(name)
+	normalized := strings.ToLower(ext)
+	if rule == CbzExtensionsStandard && (normalized == ".jpeg" || normalized == ".jpe") {
+		normalized = ".jpg"
+	}
+	return strings.TrimSuffix(name, ext) + normalized
+}
@@ -0,0 +1,243 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// CbzWriter writes a CBZ page by page as images are downloaded, the streaming
+// counterpart of CreateCbzFromDir: a chapter never has all its pages sitting in a
+// temp dir next to the finished archive. Pages are numbered from 1 and written in
+// that order whatever order they arrive in, a page that comes in early is held in
+// memory only until the pages before it are added or skipped.
+//
+// The archive is built in a temp file next to zipName and renamed into place by
+// Close, so an interrupted chapter never shows up as downloaded.
+type CbzWriter struct {
+	mu        sync.Mutex
+	zipName   string
+	tmp       *os.File
+	zw        *zip.Writer
+	next      int            // number of the next page to write
+	pending   map[int][]byte // pages that arrived ahead of next
+	skipped   map[int]bool   // pages that will never arrive
+	pages     []ComicPageInfo
+	comicInfo *ComicInfo
+	done      bool
+	err       error // first write error, every later call returns it
+}
+
+// NewCbzWriter starts a CBZ that Close will write to zipName
+func NewCbzWriter(zipName string) (*CbzWriter, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(zipName), ".kansho-cbz-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cbz: %w", err)
+	}
+	return &CbzWriter{
+		zipName: zipName,
+		tmp:     tmp,
+		zw:      zip.NewWriter(tmp),
+		next:    1,
+		pending: make(map[int][]byte),
+		skipped: make(map[int]bool),
+	}, nil
+}
+
+// AddImage adds page index, data must already be in its final format (JPEG or the
+// site's native format). It is written straight away when every page before it is
+// in, otherwise it waits in memory.
+func (w *CbzWriter) AddImage(index int, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.usable(); err != nil {
+		return err
+	}
+	if _, dup := w.pending[index]; index < w.next || dup || w.skipped[index] {
+		return fmt.Errorf("page %d already added to cbz", index)
+	}
+
+	w.pending[index] = data
+	return w.flush()
+}
+
+// Skip marks page index as missing (eg: its download failed) so the pages after it
+// are not held back waiting for it
+func (w *CbzWriter) Skip(index int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.usable(); err != nil {
+		return err
+	}
+	if index >= w.next {
+		w.skipped[index] = true
+	}
+	return w.flush()
+}
+
+// SetComicInfo adds a ComicInfo.xml when the archive is closed, its page list is
+// filled in from the pages written
+func (w *CbzWriter) SetComicInfo(info ComicInfo) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.comicInfo = &info
+}
+
+// Pages returns how many pages have been written to the archive so far
+func (w *CbzWriter) Pages() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pages)
+}
+
+// Close writes any pages still held back, in order and leaving out the gaps of
+// pages never added, then moves the finished CBZ to zipName
+func (w *CbzWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.usable(); err != nil {
+		return err
+	}
+
+	indexes := make([]int, 0, len(w.pending))
+	for index := range w.pending {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		if err := w.writePage(index, w.pending[index]); err != nil {
+			return w.fail(err)
+		}
+		delete(w.pending, index)
+	}
+
+	if w.comicInfo != nil {
+		if err := w.writeComicInfo(); err != nil {
+			return w.fail(err)
+		}
+	}
+	if err := w.zw.Close(); err != nil {
+		return w.fail(err)
+	}
+	if err := w.tmp.Close(); err != nil {
+		return w.fail(err)
+	}
+
+	// CreateTemp files are owner-only, match the CBZs CreateCbzFromDir creates
+	os.Chmod(w.tmp.Name(), 0644)
+	if err := os.Rename(w.tmp.Name(), w.zipName); err != nil {
+		return w.fail(err)
+	}
+	w.done = true
+	return nil
+}
+
+// Abort discards the archive, zipName is left untouched. Safe to call after Close,
+// which makes it a convenient defer.
+func (w *CbzWriter) Abort() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.done {
+		return
+	}
+	w.done = true
+	w.tmp.Close()
+	os.Remove(w.tmp.Name())
+}
+
+// usable returns the error that makes the writer unusable, if any
+func (w *CbzWriter) usable() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.done {
+		return fmt.Errorf("cbz %s is already closed", filepath.Base(w.zipName))
+	}
+	return nil
+}
+
+// fail records err so every later call reports it, the caller should Abort
+func (w *CbzWriter) fail(err error) error {
+	w.err = fmt.Errorf("failed to write cbz %s: %w", filepath.Base(w.zipName), err)
+	return w.err
+}
+
+// flush writes the pages that are next in line
+func (w *CbzWriter) flush() error {
+	for {
+		if w.skipped[w.next] {
+			delete(w.skipped, w.next)
+			w.next++
+			continue
+		}
+		data, ok := w.pending[w.next]
+		if !ok {
+			return nil
+		}
+		if err := w.writePage(w.next, data); err != nil {
+			return w.fail(err)
+		}
+		delete(w.pending, w.next)
+		w.next++
+	}
+}
+
+// writePage adds one page entry, named like the pages SaveImage writes to disk
+func (w *CbzWriter) writePage(index int, data []byte) error {
+	format, err := detectImageFormat(data)
+	if err != nil {
+		return fmt.Errorf("page %d: %w", index, err)
+	}
+	ext := format
+	if format == "jpeg" {
+		ext = "jpg"
+	}
+
+	entry, err := w.zw.Create(fmt.Sprintf("%03d.%s", index, ext))
+	if err != nil {
+		return err
+	}
+	if _, err := entry.Write(data); err != nil {
+		return err
+	}
+
+	page := ComicPageInfo{Image: len(w.pages)}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		page.ImageWidth = cfg.Width
+		page.ImageHeight = cfg.Height
+		page.DoublePage = cfg.Width > cfg.Height
+	}
+	w.pages = append(w.pages, page)
+	return nil
+}
+
+// writeComicInfo adds ComicInfo.xml listing the pages written, like WriteComicInfo
+func (w *CbzWriter) writeComicInfo() error {
+	info := *w.comicInfo
+	info.PageCount = len(w.pages)
+	if len(w.pages) > 0 {
+		info.Pages = &ComicPages{Page: w.pages}
+	}
+
+	data, err := xml.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ComicInfo: %w", err)
+	}
+
+	entry, err := w.zw.Create(ComicInfoFileName)
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(append([]byte(xml.Header), data...))
+	return err
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultChapterFilenameTemplate names chapters ch001.cbz, ch091.5.cbz, the names
+// kansho has always written
+const DefaultChapterFilenameTemplate = "ch{main:03}{part}.cbz"
+
+// chapterTemplate is a parsed chapter filename template: literal text around the
+// chapter number, which is zero padded to width and followed by its part
+type chapterTemplate struct {
+	source         string
+	prefix, suffix string
+	width          int
+	re             *regexp.Regexp // matches the names the template renders
+}
+
+var (
+	// templatePlaceholderRe finds the {...} placeholders of a template
+	templatePlaceholderRe = regexp.MustCompile(`\{([^{}]*)\}`)
+	// templateMainRe is the {main} placeholder, with an optional padding width
+	templateMainRe = regexp.MustCompile(`^main(?::0?(\d))?$`)
+
+	defaultChapterTemplate = mustParseChapterTemplate(DefaultChapterFilenameTemplate)
+
+	chapterTemplateMu     sync.RWMutex
+	activeChapterTemplate = defaultChapterTemplate
+)
+
+// SetChapterFilenameTemplate sets the template chapter filenames are rendered with,
+// see ValidateChapterFilenameTemplate. "" restores the default. An invalid template
+// is an error and leaves the current one in place.
+func SetChapterFilenameTemplate(tmpl string) error {
+	t := defaultChapterTemplate
+	if tmpl != "" {
+		var err error
+		if t, err = parseChapterTemplate(tmpl); err != nil {
+			return err
+		}
+	}
+	chapterTemplateMu.Lock()
+	activeChapterTemplate = t
+	chapterTemplateMu.Unlock()
+	return nil
+}
+
+// ChapterFilenameTemplate returns the template chapter filenames are rendered with
+func ChapterFilenameTemplate() string {
+	return currentChapterTemplate().source
+}
+
+// currentChapterTemplate returns the template set with SetChapterFilenameTemplate
+func currentChapterTemplate() *chapterTemplate {
+	chapterTemplateMu.RLock()
+	defer chapterTemplateMu.RUnlock()
+	return activeChapterTemplate
+}
+
+// ValidateChapterFilenameTemplate checks a chapter filename template. It holds a
+// {main} placeholder, padded to N digits as {main:N} (1-9), directly followed by
+// {part}, which renders ".5" for chapter 12.5 and nothing for chapter 12, and it
+// ends in ".cbz". Names it renders must read back as the same chapter with
+// ParseChapterNumber, so "Chapter {main:04}{part}.cbz" is fine but
+// "{main:03}5{part}.cbz" is not.
+func ValidateChapterFilenameTemplate(tmpl string) error {
+	_, err := parseChapterTemplate(tmpl)
+	return err
+}
+
+// ChapterFilename renders the cbz filename of a chapter with the current template.
+// main is the chapter number as the site gives it, part whatever follows its dot
+// ("" for none, "5" for chapter 12.5). main is zero padded like fmt's %03s would.
+func ChapterFilename(main, part string) string {
+	return currentChapterTemplate().render(main, part)
+}
+
+// ChapterLabel is the chapter number of a cbz filename as written in it, for
+// ComicInfo and exported names: "ch072.5.cbz" is "072.5". A name that is not a
+// chapter of the current template loses only its extension and any "ch" prefix.
+func ChapterLabel(cbzName string) string {
+	if t := currentChapterTemplate(); t != defaultChapterTemplate {
+		if m := t.re.FindStringSubmatch(cbzName); m != nil {
+			if m[2] != "" {
+				return m[1] + "." + m[2]
+			}
+			return m[1]
+		}
+	}
+	return strings.TrimPrefix(strings.TrimSuffix(cbzName, filepath.Ext(cbzName)), "ch")
+}
+
+// render is ChapterFilename for template t
+func (t *chapterTemplate) render(main, part string) string {
+	if len(main) < t.width {
+		main = strings.Repeat("0", t.width-len(main)) + main
+	}
+	if part != "" {
+		main += "." + part
+	}
+	return t.prefix + main + t.suffix
+}
+
+// match reads the chapter number of a name rendered by t
+func (t *chapterTemplate) match(cbzName string) (digits, part string, ok bool) {
+	m := t.re.FindStringSubmatch(cbzName)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+func mustParseChapterTemplate(tmpl string) *chapterTemplate {
+	t, err := parseChapterTemplate(tmpl)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// parseChapterTemplate parses and validates tmpl, see ValidateChapterFilenameTemplate
+func parseChapterTemplate(tmpl string) (*chapterTemplate, error) {
+	locs := templatePlaceholderRe.FindAllStringSubmatchIndex(tmpl, -1)
+	if len(locs) != 2 {
+		return nil, fmt.Errorf("chapter filename template %q must hold {main} and {part} once each", tmpl)
+	}
+	main := templateMainRe.FindStringSubmatch(tmpl[locs[0][2]:locs[0][3]])
+	if main == nil || tmpl[locs[1][2]:locs[1][3]] != "part" {
+		return nil, fmt.Errorf("chapter filename template %q must hold {main} (or {main:N}) followed by {part}", tmpl)
+	}
+	if locs[0][1] != locs[1][0] {
+		return nil, fmt.Errorf("chapter filename template %q: {part} must directly follow {main}", tmpl)
+	}
+
+	t := &chapterTemplate{source: tmpl, prefix: tmpl[:locs[0][0]], suffix: tmpl[locs[1][1]:]}
+	if main[1] != "" {
+		t.width, _ = strconv.Atoi(main[1])
+		if t.width == 0 {
+			return nil, fmt.Errorf("chapter filename template %q: padding width must be 1 to 9", tmpl)
+		}
+	}
+	for _, literal := range []string{t.prefix, t.suffix} {
+		if strings.ContainsAny(literal, "{}/\\:*?\"<>|") {
+			return nil, fmt.Errorf("chapter filename template %q: %q is not allowed in a filename", tmpl, literal)
+		}
+	}
+	if !strings.HasSuffix(strings.ToLower(t.suffix), ".cbz") {
+		return nil, fmt.Errorf("chapter filename template %q must end in .cbz", tmpl)
+	}
+	t.re = regexp.MustCompile(`(?i)^` + regexp.QuoteMeta(t.prefix) + `(\d+)(?:\.(\d+))?` + regexp.QuoteMeta(t.suffix) + `$`)
+
+	// The names must read back as the chapters they were rendered for
+	for _, sample := range []struct{ main, part string }{{"1", ""}, {"91", "5"}, {"100", "10"}, {"1000", ""}} {
+		name := t.render(sample.main, sample.part)
+		gotMain, gotPart, _, err := parseChapterNumber(name, t)
+		if err != nil || strconv.Itoa(gotMain) != sample.main || gotPart != sample.part {
+			return nil, fmt.Errorf("chapter filename template %q: %q does not read back as chapter %s%s", tmpl, name, sample.main, dotted(sample.part))
+		}
+	}
+	return t, nil
+}
+
+// dotted is part with its leading dot, "" for none
+func dotted(part string) string {
+	if part == "" {
+		return ""
+	}
+	return "." + part
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// chapterNameRe matches the base of a cbz filename: an optional season prefix, an
+// optional "ch" and the chapter number with at most one part after a dot
+var chapterNameRe = regexp.MustCompile(`^(?:s\d+)?(?:ch)?(\d+)(?:\.(\d+))?$`)
+
+// ParseChapterNumber reads the chapter number of a cbz filename the way the sites
+// name them: "ch091.5.cbz" is main 91, part "5" and value 91.5. The part is kept
+// as written, value reads it as decimals so ch100.10 and ch100.1 share the value
+// 100.1. A season prefix ("s02ch045.cbz") is skipped, the number is the one within
+// the season. Names rendered by a custom chapter filename template are read too,
+// see SetChapterFilenameTemplate. Names with anything else around the number are
+// an error.
+func ParseChapterNumber(cbzName string) (main int, part string, value float64, err error) {
+	t := currentChapterTemplate()
+	if t == defaultChapterTemplate {
+		// The default template renders names chapterNameRe already reads
+		t = nil
+	}
+	return parseChapterNumber(cbzName, t)
+}
+
+// parseChapterNumber is ParseChapterNumber also reading the names of chapter
+// filename template t, when not nil
+func parseChapterNumber(cbzName string, t *chapterTemplate) (main int, part string, value float64, err error) {
+	name := cbzName
+	// Only a real extension is dropped, "ch091.5" has none
+	if ext := filepath.Ext(name); ext != "" && !isDigits(ext[1:]) {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	var digits string
+	ok := false
+	if m := chapterNameRe.FindStringSubmatch(name); m != nil {
+		digits, part, ok = m[1], m[2], true
+	} else if t != nil {
+		digits, part, ok = t.match(cbzName)
+	}
+	if !ok {
+		return 0, "", 0, fmt.Errorf("%q is not a chapter filename", cbzName)
+	}
+	main, err = strconv.Atoi(digits)
+	if err != nil {
+		return 0, "", 0, fmt.Errorf("chapter number of %q: %w", cbzName, err)
+	}
+
+	number := digits
+	if part != "" {
+		number += "." + part
+	}
+	value, err = strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, "", 0, fmt.Errorf("chapter number of %q: %w", cbzName, err)
+	}
+	return main, part, value, nil
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchLocalChapters maps the local cbz names to the remote chapter they hold: the
+// remote chapter of the same name, else the one with the same chapter number
+// (ChapterSortValue), so a library named by an earlier chapter filename template
+// still counts as downloaded. A number several remote chapters share matches by
+// name only. Local chapters the site does not list are left out.
+func MatchLocalChapters(local, remote []string) map[string]string {
+	remoteNames := make(map[string]bool, len(remote))
+	byNumber := make(map[float64]string, len(remote))
+	shared := make(map[float64]bool)
+	for _, name := range remote {
+		remoteNames[name] = true
+		if num, ok := ChapterSortValue(name); ok {
+			if _, seen := byNumber[num]; seen {
+				shared[num] = true
+			}
+			byNumber[num] = name
+		}
+	}
+
+	matched := make(map[string]string, len(local))
+	for _, name := range local {
+		if remoteNames[name] {
+			matched[name] = name
+			continue
+		}
+		if num, ok := ChapterSortValue(name); ok && !shared[num] {
+			if remoteName, listed := byNumber[num]; listed {
+				matched[name] = remoteName
+			}
+		}
+	}
+	return matched
+}
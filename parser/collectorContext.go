@@ -0,0 +1,24 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gocolly/colly"
+)
+
+// contextTransport sends every request with ctx
+type contextTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// BindCollectorContext sends every request c makes with ctx, so cancelling ctx
+// aborts the ones in flight. Colly builds its requests without a context.
+func BindCollectorContext(c *colly.Collector, ctx context.Context) {
+	c.WithTransport(&contextTransport{base: http.DefaultTransport, ctx: ctx})
+}
@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// ComicInfoFileName is the metadata file readers look for at the root of a cbz
+const ComicInfoFileName = "ComicInfo.xml"
+
+// ComicInfo is the subset of the ComicRack ComicInfo.xml schema written into cbz
+// files. Fields are in schema order, readers validating against the XSD need it.
+type ComicInfo struct {
+	XMLName    xml.Name    `xml:"ComicInfo"`
+	Title      string      `xml:"Title,omitempty"`
+	Series     string      `xml:"Series,omitempty"`
+	Number     string      `xml:"Number,omitempty"`
+	Volume     int         `xml:"Volume,omitempty"`
+	Notes      string      `xml:"Notes,omitempty"`
+	Translator string      `xml:"Translator,omitempty"` // the scanlation group
+	Web        string      `xml:"Web,omitempty"`
+	PageCount  int         `xml:"PageCount,omitempty"`
+	Pages      *ComicPages `xml:"Pages,omitempty"`
+}
+
+// Merge fills in the text and volume fields of info that other sets, keeping the
+// rest. The page list is left alone, it always comes from the pages written.
+func (info *ComicInfo) Merge(other ComicInfo) {
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&info.Title, other.Title},
+		{&info.Series, other.Series},
+		{&info.Number, other.Number},
+		{&info.Notes, other.Notes},
+		{&info.Translator, other.Translator},
+		{&info.Web, other.Web},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	if other.Volume > 0 {
+		info.Volume = other.Volume
+	}
+}
+
+// ComicPages is the <Pages> list of per page metadata
+type ComicPages struct {
+	Page []ComicPageInfo `xml:"Page"`
+}
+
+// ComicPageInfo describes a single page. Image is the zero based page index in
+// reading order. DoublePage marks spreads so readers show them across both halves.
+type ComicPageInfo struct {
+	Image       int  `xml:"Image,attr"`
+	ImageWidth  int  `xml:"ImageWidth,attr,omitempty"`
+	ImageHeight int  `xml:"ImageHeight,attr,omitempty"`
+	DoublePage  bool `xml:"DoublePage,attr,omitempty"`
+}
+
+// PageInfoFromDir builds the page list for every image in sourceDir, in the same
+// order CreateCbzFromDir adds them. Only the image headers are read to get the
+// dimensions, pages wider than they are tall are flagged as double pages. A file
+// that does not decode is left out but keeps its index, the CBZ still holds it.
+func PageInfoFromDir(sourceDir string) ([]ComicPageInfo, error) {
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && entry.Name() != ComicInfoFileName {
+			files = append(files, entry.Name())
+		}
+	}
+	SortPageFiles(files)
+
+	var pages []ComicPageInfo
+	for i, file := range files {
+		cfg, err := decodeImageConfig(filepath.Join(sourceDir, file))
+		if err != nil {
+			log.Printf("[ComicInfo] Skipping %s: %v", file, err)
+			continue
+		}
+
+		pages = append(pages, ComicPageInfo{
+			Image:       i,
+			ImageWidth:  cfg.Width,
+			ImageHeight: cfg.Height,
+			DoublePage:  cfg.Width > cfg.Height,
+		})
+	}
+
+	return pages, nil
+}
+
+// decodeImageConfig reads the dimensions of an image file without decoding its pixels
+func decodeImageConfig(path string) (image.Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	return cfg, err
+}
+
+// WriteComicInfo fills in the page list for the images in sourceDir and writes
+// ComicInfo.xml next to them, so CreateCbzFromDir packages it with the chapter
+func WriteComicInfo(sourceDir string, info ComicInfo) error {
+	pages, err := PageInfoFromDir(sourceDir)
+	if err != nil {
+		return err
+	}
+
+	info.PageCount = len(pages)
+	if len(pages) > 0 {
+		info.Pages = &ComicPages{Page: pages}
+	}
+
+	data, err := xml.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ComicInfo: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+
+	if err := os.WriteFile(filepath.Join(sourceDir, ComicInfoFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write ComicInfo: %w", err)
+	}
+	return nil
+}
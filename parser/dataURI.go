@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// IsDataURI reports whether src is an inline data: URI rather than a URL to fetch
+func IsDataURI(src string) bool {
+	return len(src) > 5 && strings.EqualFold(src[:5], "data:")
+}
+
+// DecodeDataURI returns the payload of a data: URI such as
+// "data:image/png;base64,iVBORw0...". Both base64 and percent-encoded payloads
+// are supported.
+func DecodeDataURI(src string) ([]byte, error) {
+	if !IsDataURI(src) {
+		return nil, fmt.Errorf("not a data URI")
+	}
+
+	header, payload, ok := strings.Cut(src[5:], ",")
+	if !ok {
+		return nil, fmt.Errorf("malformed data URI: missing ','")
+	}
+
+	if !strings.HasSuffix(strings.ToLower(header), ";base64") {
+		data, err := url.PathUnescape(payload)
+		if err != nil {
+			return nil, fmt.Errorf("malformed data URI payload: %w", err)
+		}
+		return []byte(data), nil
+	}
+
+	// Inline images are sometimes wrapped or unpadded, strip whitespace and accept both
+	payload = strings.Join(strings.Fields(payload), "")
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("malformed base64 in data URI: %w", err)
+	}
+	return data, nil
+}
+
+// SaveDataURI decodes an inline image and saves it like a downloaded one
+func SaveDataURI(src, targetDir, filename string, keepNative bool) error {
+	imgBytes, err := DecodeDataURI(src)
+	if err != nil {
+		return err
+	}
+	if len(imgBytes) == 0 {
+		return fmt.Errorf("empty data URI image")
+	}
+	return SaveImage(imgBytes, targetDir, filename, keepNative)
+}
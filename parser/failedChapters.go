@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// failedSidecarName is the file kept in each manga directory listing the chapters
+// of the last runs that failed, were skipped or came out short, with their URLs so
+// they can be retried without fetching the chapter list again
+const failedSidecarName = ".kansho-failed.json"
+
+// FailedChapters returns the chapters recorded as failed in rootDir, cbz name to
+// chapter URL. A missing sidecar file simply means nothing failed.
+func FailedChapters(rootDir string) (map[string]string, error) {
+	expandedPath, err := ExpandPath(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(expandedPath, failedSidecarName))
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read failed chapter list: %w", err)
+	}
+
+	failed := map[string]string{}
+	if err := json.Unmarshal(data, &failed); err != nil {
+		return nil, fmt.Errorf("failed to parse failed chapter list: %w", err)
+	}
+	return failed, nil
+}
+
+// UpdateFailedChapters adds the chapters that failed in a run to the failed list of
+// rootDir and removes the ones that were downloaded, chapters the run did not try
+// keep their entry. The sidecar is deleted once nothing is left to retry.
+func UpdateFailedChapters(rootDir string, failed map[string]string, succeeded []string) error {
+	recorded, err := FailedChapters(rootDir)
+	if err != nil {
+		return err
+	}
+	for name, url := range failed {
+		recorded[name] = url
+	}
+	for _, name := range succeeded {
+		delete(recorded, name)
+	}
+
+	expandedPath, err := ExpandPath(rootDir)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(expandedPath, failedSidecarName)
+
+	if len(recorded) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove failed chapter list: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal failed chapter list: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write failed chapter list: %w", err)
+	}
+	return nil
+}
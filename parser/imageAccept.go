@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultImageAccept is sent with every image request unless the image_accept
+// setting overrides it. Image CDNs negotiate the format on this header, WebP is
+// preferred because it is usually the smallest format every page decoder here
+// understands. AVIF is deliberately not advertised, it cannot be decoded for JPEG
+// conversion.
+const DefaultImageAccept = "image/webp,image/png,image/jpeg;q=0.9,image/*;q=0.8,*/*;q=0.5"
+
+var (
+	imageAcceptMu sync.RWMutex
+	imageAccept   = DefaultImageAccept
+)
+
+// SetImageAccept sets the Accept header used for image requests, an empty value
+// restores DefaultImageAccept
+func SetImageAccept(accept string) {
+	accept = strings.TrimSpace(accept)
+	if accept == "" {
+		accept = DefaultImageAccept
+	}
+
+	imageAcceptMu.Lock()
+	imageAccept = accept
+	imageAcceptMu.Unlock()
+}
+
+// ImageAccept returns the Accept header currently used for image requests
+func ImageAccept() string {
+	imageAcceptMu.RLock()
+	defer imageAcceptMu.RUnlock()
+	return imageAccept
+}
+
+// NewImageRequest creates a GET request for an image with the image Accept header set,
+// plus the ImageUserAgent and the bookmark's RequestExtras carried by ctx
+func NewImageRequest(ctx context.Context, imageURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ImageAccept())
+	if userAgent := ImageUserAgent(ctx); userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	RequestExtrasFrom(ctx).Apply(req)
+	return req, nil
+}
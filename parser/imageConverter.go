@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"errors"
@@ -46,7 +47,9 @@ func detectImageFormat(data []byte) (string, error) {
 }
 
 // ConvertImageToJPEG converts image bytes to JPEG and saves to outputPath
-// If already JPEG, saves directly without re-encoding
+// If already JPEG, saves directly without re-encoding. With SetKeepLosslessWebP on,
+// a lossless WebP is saved as PNG under outputPath's name with a .png extension so
+// the conversion adds no JPEG artifacts.
 func ConvertImageToJPEG(imgBytes []byte, outputPath string) error {
 	if len(imgBytes) == 0 {
 		return errors.New("empty image data")
@@ -62,27 +65,39 @@ func ConvertImageToJPEG(imgBytes []byte, outputPath string) error {
 		return saveRawBytes(imgBytes, outputPath)
 	}
 
-	// Decode the image based on format
-	var img image.Image
-	reader := bytes.NewReader(imgBytes)
+	img, err := decodeImage(format, bytes.NewReader(imgBytes))
+	if err != nil {
+		return err
+	}
 
+	if format == "webp" && KeepLosslessWebP() && IsLosslessWebP(imgBytes) {
+		return savePNG(img, strings.TrimSuffix(outputPath, filepath.Ext(outputPath))+".png")
+	}
+
+	// Save as JPEG with quality 90
+	return saveJPEG(img, outputPath)
+}
+
+// decodeImage decodes a PNG, GIF or WebP image, the formats ConvertImageToJPEG
+// re-encodes
+func decodeImage(format string, r io.Reader) (image.Image, error) {
+	var img image.Image
+	var err error
 	switch format {
 	case "png":
-		img, err = png.Decode(reader)
+		img, err = png.Decode(r)
 	case "gif":
-		img, err = gif.Decode(reader)
+		img, err = gif.Decode(r)
 	case "webp":
-		img, err = webp.Decode(reader)
+		img, err = webp.Decode(r)
 	default:
-		return errors.New("unsupported image format: " + format)
+		return nil, errors.New("unsupported image format: " + format)
 	}
 
 	if err != nil {
-		return errors.New("failed to decode " + format + " image: " + err.Error())
+		return nil, errors.New("failed to decode " + format + " image: " + err.Error())
 	}
-
-	// Save as JPEG with quality 90
-	return imaging.Save(img, outputPath, imaging.JPEGQuality(90))
+	return img, nil
 }
 
 // downloadAndConvertToJPGWithRetry downloads with retry logic
@@ -108,7 +123,11 @@ func downloadAndConvertToJPGWithRetry(imageURL, targetDir string, maxRetries int
 
 // downloadAndConvertToJPG downloads an image from imageURL, converts to JPG if needed, and saves it inside targetDir
 func downloadAndConvertToJPG(imageURL, targetDir string) error {
-	resp, err := http.Get(imageURL)
+	req, err := NewImageRequest(context.Background(), imageURL)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -149,6 +168,22 @@ func DownloadAndConvertToJPG(imageURL, targetDir string) error {
 // DownloadConvertToJPGRename downloads an image, converts to JPEG, and saves it.
 // Uses the provided context for cancellation support.
 func DownloadConvertToJPGRename(ctx context.Context, filename, imageURL, targetDir string) error {
+	return downloadRenameWithRetry(ctx, filename, imageURL, targetDir, false)
+}
+
+// DownloadRenameNative downloads an image and saves it in its original format
+// without any JPEG re-encode.
+func DownloadRenameNative(ctx context.Context, filename, imageURL, targetDir string) error {
+	return downloadRenameWithRetry(ctx, filename, imageURL, targetDir, true)
+}
+
+// downloadRenameWithRetry wraps downloadConvertToJPGRenameCtx with retry logic
+func downloadRenameWithRetry(ctx context.Context, filename, imageURL, targetDir string, keepNative bool) error {
+	// Inline images need no request, and decoding is not worth retrying
+	if IsDataURI(imageURL) {
+		return SaveDataURI(imageURL, targetDir, filename, keepNative)
+	}
+
 	var lastErr error
 	maxRetries := 3
 
@@ -157,7 +192,7 @@ func DownloadConvertToJPGRename(ctx context.Context, filename, imageURL, targetD
 			log.Printf("Retry attempt %d/%d for: %s", attempt, maxRetries, imageURL)
 		}
 
-		err := downloadConvertToJPGRenameCtx(ctx, filename, imageURL, targetDir)
+		err := downloadConvertToJPGRenameCtx(ctx, filename, imageURL, targetDir, keepNative)
 		if err == nil {
 			return nil
 		}
@@ -170,8 +205,8 @@ func DownloadConvertToJPGRename(ctx context.Context, filename, imageURL, targetD
 }
 
 // downloadConvertToJPGRenameCtx is the context-aware internal function without retry
-func downloadConvertToJPGRenameCtx(ctx context.Context, filename, imageURL, targetDir string) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+func downloadConvertToJPGRenameCtx(ctx context.Context, filename, imageURL, targetDir string, keepNative bool) error {
+	req, err := NewImageRequest(ctx, imageURL)
 	if err != nil {
 		return err
 	}
@@ -194,19 +229,142 @@ func downloadConvertToJPGRenameCtx(ctx context.Context, filename, imageURL, targ
 		return errors.New("empty response body")
 	}
 
-	// pad the image filename to 3 digits
-	paddedFileName := padFileName(filename + ".jpg")
+	// Pad, convert (unless keeping native) and save
+	return SaveImage(imgBytes, targetDir, filename, keepNative)
+}
 
-	// join the padded dir / filename back together
-	outputFile := filepath.Join(targetDir, paddedFileName)
+// pagePartPrefix starts the temp name a page is written under until it is complete,
+// see writePageFile
+const pagePartPrefix = ".page-part-"
 
-	// Convert and save
-	return ConvertImageToJPEG(imgBytes, outputFile)
+// writePageFile writes a page with write to a temp file next to outputPath and
+// renames it into place once complete. Pages of a chapter are written
+// concurrently, a crash must not leave any of them half written under its final
+// name for ResumeTempPages to trust.
+func writePageFile(outputPath string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(outputPath), pagePartPrefix+"*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// CreateTemp files are owner-only, match the pages written before
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), outputPath)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
 }
 
 // saveRawBytes saves bytes directly to file without conversion
 func saveRawBytes(data []byte, outputPath string) error {
-	return os.WriteFile(outputPath, data, 0644)
+	return writePageFile(outputPath, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// savePNG writes img to outputPath as a PNG
+func savePNG(img image.Image, outputPath string) error {
+	return writePageFile(outputPath, func(w io.Writer) error {
+		return imaging.Encode(w, img, imaging.PNG)
+	})
+}
+
+// SaveImage writes downloaded image bytes into targetDir under the padded filename.
+// When keepNative is false the image is converted to JPEG (via ConvertImageToJPEG),
+// otherwise the original bytes are written untouched with an extension matching
+// their detected format (eg: asura WebP pages stay WebP inside the CBZ).
+func SaveImage(imgBytes []byte, targetDir, filename string, keepNative bool) error {
+	if !keepNative {
+		return ConvertImageToJPEG(imgBytes, filepath.Join(targetDir, padFileName(filename+".jpg")))
+	}
+
+	if len(imgBytes) == 0 {
+		return errors.New("empty image data")
+	}
+
+	format, err := detectImageFormat(imgBytes)
+	if err != nil {
+		return err
+	}
+
+	ext := format
+	if format == "jpeg" {
+		ext = "jpg"
+	}
+
+	return saveRawBytes(imgBytes, filepath.Join(targetDir, padFileName(filename+"."+ext)))
+}
+
+// imageHeaderSize is how much of a spooled image SaveImageFile reads to tell its
+// format, enough for the WebP chunks IsLosslessWebP looks at
+const imageHeaderSize = 64 << 10
+
+// SaveImageFile is SaveImage for an image spooled to srcPath, for pages too large to
+// buffer. The file is streamed to its place, only a page converted to JPEG is
+// decoded into memory.
+func SaveImageFile(srcPath, targetDir, filename string, keepNative bool) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	header := make([]byte, imageHeaderSize)
+	n, err := io.ReadFull(src, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	if n == 0 {
+		return errors.New("empty image data")
+	}
+	header = header[:n]
+	format, err := detectImageFormat(header)
+	if err != nil {
+		return err
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// JPEG pages are saved as they are either way
+	if keepNative || format == "jpeg" {
+		ext := format
+		if format == "jpeg" {
+			ext = "jpg"
+		}
+		return copyToFile(src, filepath.Join(targetDir, padFileName(filename+"."+ext)))
+	}
+
+	img, err := decodeImage(format, bufio.NewReader(src))
+	if err != nil {
+		return err
+	}
+	outputPath := filepath.Join(targetDir, padFileName(filename+".jpg"))
+	if format == "webp" && KeepLosslessWebP() && IsLosslessWebP(header) {
+		return savePNG(img, strings.TrimSuffix(outputPath, filepath.Ext(outputPath))+".png")
+	}
+	return saveJPEG(img, outputPath)
+}
+
+// copyToFile writes everything r holds to a new file at outputPath
+func copyToFile(r io.Reader, outputPath string) error {
+	return writePageFile(outputPath, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
 }
 
 // DownloadConvertToJPGRenameCf downloads an image using Cloudflare bypass,
@@ -230,6 +388,21 @@ func saveRawBytes(data []byte, outputPath string) error {
 // 3. Convert to JPEG if needed (reuses ConvertImageToJPEG)
 // 4. Save with padded filename (reuses padFileName)
 func DownloadConvertToJPGRenameCf(ctx context.Context, filename, imageURL, targetDir, domain string) error {
+	return downloadRenameCfWithRetry(ctx, filename, imageURL, targetDir, domain, false)
+}
+
+// DownloadRenameNativeCf is the Cloudflare bypass counterpart of DownloadRenameNative,
+// the image is saved in its original format without any JPEG re-encode.
+func DownloadRenameNativeCf(ctx context.Context, filename, imageURL, targetDir, domain string) error {
+	return downloadRenameCfWithRetry(ctx, filename, imageURL, targetDir, domain, true)
+}
+
+// downloadRenameCfWithRetry wraps downloadConvertToJPGRenameCfCtx with retry logic
+func downloadRenameCfWithRetry(ctx context.Context, filename, imageURL, targetDir, domain string, keepNative bool) error {
+	if IsDataURI(imageURL) {
+		return SaveDataURI(imageURL, targetDir, filename, keepNative)
+	}
+
 	var lastErr error
 	maxRetries := 3
 
@@ -238,7 +411,7 @@ func DownloadConvertToJPGRenameCf(ctx context.Context, filename, imageURL, targe
 			log.Printf("Retry attempt %d/%d for: %s", attempt, maxRetries, imageURL)
 		}
 
-		err := downloadConvertToJPGRenameCfCtx(ctx, filename, imageURL, targetDir, domain)
+		err := downloadConvertToJPGRenameCfCtx(ctx, filename, imageURL, targetDir, domain, keepNative)
 		if err == nil {
 			return nil
 		}
@@ -251,7 +424,7 @@ func DownloadConvertToJPGRenameCf(ctx context.Context, filename, imageURL, targe
 }
 
 // downloadConvertToJPGRenameCfCtx is the context-aware internal function without retry logic
-func downloadConvertToJPGRenameCfCtx(ctx context.Context, filename, imageURL, targetDir, domain string) error {
+func downloadConvertToJPGRenameCfCtx(ctx context.Context, filename, imageURL, targetDir, domain string, keepNative bool) error {
 	// Create a new Colly collector for this download with extended timeout for large images
 	c := colly.NewCollector(
 		colly.UserAgent("Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36"),
@@ -260,6 +433,10 @@ func downloadConvertToJPGRenameCfCtx(ctx context.Context, filename, imageURL, ta
 
 	// Set longer timeout for large image downloads (60 seconds to handle slow connections)
 	c.SetRequestTimeout(60 * time.Second)
+	BindCollectorContext(c, ctx)
+	if userAgent := ImageUserAgent(ctx); userAgent != "" {
+		c.UserAgent = userAgent
+	}
 
 	// Load CF bypass data for the provided domain
 	bypassData, err := cf.LoadFromFile(domain)
@@ -267,6 +444,12 @@ func downloadConvertToJPGRenameCfCtx(ctx context.Context, filename, imageURL, ta
 		log.Printf("No bypass data found for domain: %s", domain)
 		// Continue anyway - maybe the site doesn't need bypass for images
 	} else {
+		// The UA must match the browser that cleared CF, whichever kind of bypass
+		// data was captured
+		if userAgent := strings.TrimSpace(bypassData.Entropy.UserAgent); userAgent != "" {
+			c.UserAgent = userAgent
+		}
+
 		// CRITICAL FIX: Ensure cookie domain has dot prefix for subdomain support
 		if bypassData.CfClearanceStruct != nil {
 			originalDomain := bypassData.CfClearanceStruct.Domain
@@ -291,13 +474,15 @@ func downloadConvertToJPGRenameCfCtx(ctx context.Context, filename, imageURL, ta
 
 			c.SetCookies(imageURL, []*http.Cookie{httpCookie})
 
-			// Set User-Agent
-			c.UserAgent = bypassData.Entropy.UserAgent
-
 			log.Printf("✓ Applied CF bypass with cookie domain: %s for URL: %s", bypassData.CfClearanceStruct.Domain, imageURL)
 		}
 	}
 
+	c.OnRequest(func(r *colly.Request) {
+		r.Headers.Set("Accept", ImageAccept())
+	})
+	RequestExtrasFrom(ctx).ApplyToCollector(c)
+
 	// Variables to capture response
 	var imgBytes []byte
 	var downloadErr error
@@ -352,9 +537,12 @@ func downloadConvertToJPGRenameCfCtx(ctx context.Context, filename, imageURL, ta
 	default:
 	}
 
-	// Visit the image URL (Colly doesn't natively support context propagation,
-	// but has a 60s request timeout set above as a safety net)
+	// Visit the image URL, cancelling ctx aborts the request
 	visitErr := c.Visit(imageURL)
+	if err := ctx.Err(); err != nil {
+		log.Printf("Image download cancelled: %s", imageURL)
+		return err
+	}
 	if visitErr != nil {
 		log.Printf("Failed to visit image URL: %v, url=%s", visitErr, imageURL)
 		return errors.New("failed to visit URL: " + visitErr.Error())
@@ -379,16 +567,10 @@ func downloadConvertToJPGRenameCfCtx(ctx context.Context, filename, imageURL, ta
 		return errors.New("empty response body")
 	}
 
-	// Pad the image filename to 3 digits (reuse existing helper)
-	paddedFileName := padFileName(filename + ".jpg")
-
-	// Join the padded dir / filename back together
-	outputFile := filepath.Join(targetDir, paddedFileName)
-
-	// Convert and save (reuse existing function)
-	convertErr := ConvertImageToJPEG(imgBytes, outputFile)
+	// Pad, convert (unless keeping native) and save
+	convertErr := SaveImage(imgBytes, targetDir, filename, keepNative)
 	if convertErr != nil {
-		log.Printf("Failed to convert/save image: %v, url=%s, output=%s", convertErr, imageURL, outputFile)
+		log.Printf("Failed to convert/save image: %v, url=%s, dir=%s", convertErr, imageURL, targetDir)
 		return convertErr
 	}
 
@@ -408,6 +590,10 @@ func downloadConvertToJPGRenameCfCtx(ctx context.Context, filename, imageURL, ta
 // Returns:
 //   - error: Any error encountered during download/conversion, nil on success
 func DownloadConvertToJPGRenameCfWithCollector(c *colly.Collector, filename, imageURL, targetDir string) error {
+	if IsDataURI(imageURL) {
+		return SaveDataURI(imageURL, targetDir, filename, false)
+	}
+
 	// Variables to capture response
 	var imgBytes []byte
 	var downloadErr error
@@ -416,6 +602,11 @@ func DownloadConvertToJPGRenameCfWithCollector(c *colly.Collector, filename, ima
 	// This prevents callback conflicts when reusing the same collector
 	imgCollector := c.Clone()
 
+	// Clone does not copy callbacks, so the Accept header is set on the clone
+	imgCollector.OnRequest(func(r *colly.Request) {
+		r.Headers.Set("Accept", ImageAccept())
+	})
+
 	// Handle successful response
 	imgCollector.OnResponse(func(r *colly.Response) {
 		if r.StatusCode != 200 {
@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ImageManifestPath returns where the image manifest of the CBZ at cbzPath goes, a
+// sidecar next to it (eg: ch001.cbz -> ch001.urls.txt)
+func ImageManifestPath(cbzPath string) string {
+	return strings.TrimSuffix(cbzPath, ".cbz") + ".urls.txt"
+}
+
+// WriteImageManifest writes the image URLs extracted for the chapter at cbzPath to
+// its sidecar, one per line in download order so line N is page N. Data URIs are
+// shortened to their media type and length, the page bytes are not worth keeping.
+func WriteImageManifest(cbzPath string, urls []string) error {
+	var b strings.Builder
+	for _, u := range urls {
+		if IsDataURI(u) {
+			mediaType, _, _ := strings.Cut(u, ",")
+			u = fmt.Sprintf("%s,... (%d bytes)", mediaType, len(u))
+		}
+		b.WriteString(u)
+		b.WriteByte('\n')
+	}
+
+	if err := os.WriteFile(ImageManifestPath(cbzPath), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write image manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"context"
+	"strings"
+
+	"kansho/cf"
+)
+
+type imageUserAgentKey struct{}
+
+// WithImageUserAgent returns a context whose image requests send userAgent. An empty
+// userAgent returns ctx unchanged, image requests then keep their default.
+func WithImageUserAgent(ctx context.Context, userAgent string) context.Context {
+	if userAgent = strings.TrimSpace(userAgent); userAgent == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, imageUserAgentKey{}, userAgent)
+}
+
+// ImageUserAgent returns the User-Agent image requests in ctx send, "" for the default
+func ImageUserAgent(ctx context.Context) string {
+	userAgent, _ := ctx.Value(imageUserAgentKey{}).(string)
+	return userAgent
+}
+
+// CapturedUserAgent returns the User-Agent captured with the CF bypass data of domain,
+// "" when there is none. CF ties cf_clearance to the browser that solved the
+// challenge, image requests sent with another UA can be rejected.
+func CapturedUserAgent(domain string) string {
+	data, err := cf.LoadFromFile(domain)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(data.Entropy.UserAgent)
+}
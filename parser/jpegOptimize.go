@@ -0,0 +1,649 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"math/bits"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+var (
+	optimizeJPEGMu sync.RWMutex
+	optimizeJPEG   bool
+)
+
+// SetOptimizeJPEG makes converted pages be written by EncodeOptimizedJPEG instead of
+// the standard library's baseline encoder. Off by default, the baseline encode is
+// about twice as fast.
+func SetOptimizeJPEG(on bool) {
+	optimizeJPEGMu.Lock()
+	optimizeJPEG = on
+	optimizeJPEGMu.Unlock()
+}
+
+// OptimizeJPEG reports whether converted pages are written as optimized JPEGs
+func OptimizeJPEG() bool {
+	optimizeJPEGMu.RLock()
+	defer optimizeJPEGMu.RUnlock()
+	return optimizeJPEG
+}
+
+// saveJPEG writes img to outputPath at quality 90 with the encoder SetOptimizeJPEG
+// selects
+func saveJPEG(img image.Image, outputPath string) error {
+	optimize := OptimizeJPEG()
+	return writePageFile(outputPath, func(w io.Writer) error {
+		if !optimize {
+			return imaging.Encode(w, img, imaging.JPEG, imaging.JPEGQuality(90))
+		}
+		return EncodeOptimizedJPEG(w, img, 90)
+	})
+}
+
+// unzig maps the zigzag order of JPEG coefficients to their natural order
+var unzig = [64]int{
+	0, 1, 8, 16, 9, 2, 3, 10,
+	17, 24, 32, 25, 18, 11, 4, 5,
+	12, 19, 26, 33, 40, 48, 41, 34,
+	27, 20, 13, 6, 7, 14, 21, 28,
+	35, 42, 49, 56, 57, 50, 43, 36,
+	29, 22, 15, 23, 30, 37, 44, 51,
+	58, 59, 52, 45, 38, 31, 39, 46,
+	53, 60, 61, 54, 47, 55, 62, 63,
+}
+
+// baseQuant are the luminance and chrominance tables of the JPEG spec (Annex K) in
+// natural order, scaled by quality the same way the standard library does so both
+// encoders lose the same detail
+var baseQuant = [2][64]int{
+	{
+		16, 11, 10, 16, 24, 40, 51, 61,
+		12, 12, 14, 19, 26, 58, 60, 55,
+		14, 13, 16, 24, 40, 57, 69, 56,
+		14, 17, 22, 29, 51, 87, 80, 62,
+		18, 22, 37, 56, 68, 109, 103, 77,
+		24, 35, 55, 64, 81, 104, 113, 92,
+		49, 64, 78, 87, 103, 121, 120, 101,
+		72, 92, 95, 98, 112, 100, 103, 99,
+	},
+	{
+		17, 18, 24, 47, 99, 99, 99, 99,
+		18, 21, 26, 66, 99, 99, 99, 99,
+		24, 26, 56, 99, 99, 99, 99, 99,
+		47, 66, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+	},
+}
+
+// dctCos[u][x] is C(u)/2 * cos((2x+1)uπ/16), one dimension of the 8x8 forward DCT
+var dctCos = func() (t [8][8]float64) {
+	for u := 0; u < 8; u++ {
+		c := 0.5
+		if u == 0 {
+			c = 0.5 / math.Sqrt2
+		}
+		for x := 0; x < 8; x++ {
+			t[u][x] = c * math.Cos(float64(2*x+1)*float64(u)*math.Pi/16)
+		}
+	}
+	return t
+}()
+
+// jpegComponent is one color plane as quantized DCT blocks
+type jpegComponent struct {
+	id    byte
+	h, v  int // sampling factors
+	quant int // quantization and DC Huffman table
+	// blocksW x blocksH blocks are stored, padded out to whole MCUs. A scan of this
+	// component alone covers only the scanW x scanH blocks holding image pixels.
+	blocksW, blocksH int
+	scanW, scanH     int
+	coefs            []int16
+}
+
+// jpegScan is one scan of the progressive script: the DC of every component, or a
+// band of AC coefficients (zigzag ss..se) of one component
+type jpegScan struct {
+	comps  []int
+	ss, se int
+}
+
+// optimizedJPEG is an image transformed and quantized, ready to be written scan by
+// scan
+type optimizedJPEG struct {
+	width, height int
+	mcusX, mcusY  int
+	quant         [2][64]int
+	comps         []*jpegComponent
+}
+
+// EncodeOptimizedJPEG writes m as a progressive JPEG whose Huffman tables are built
+// from the image's own symbol counts, one table per scan, rather than the generic
+// tables of the spec. At the same quality it keeps the detail of the standard
+// library's baseline encoder in around 10% fewer bytes on scanned pages, taking
+// about twice as long. Gray images are written with a single component, others as
+// YCbCr 4:2:0.
+func EncodeOptimizedJPEG(w io.Writer, m image.Image, quality int) error {
+	b := m.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 || b.Dx() > 65535 || b.Dy() > 65535 {
+		return fmt.Errorf("cannot encode a %dx%d image as JPEG", b.Dx(), b.Dy())
+	}
+	quality = max(1, min(quality, 100))
+	j := newOptimizedJPEG(m, quality)
+
+	script := []jpegScan{
+		{comps: []int{0}, ss: 0, se: 0},
+		{comps: []int{0}, ss: 1, se: 5},
+		{comps: []int{0}, ss: 6, se: 63},
+	}
+	if len(j.comps) == 3 {
+		// Low luminance frequencies first, they draw the page outline on screen
+		script = []jpegScan{
+			{comps: []int{0, 1, 2}, ss: 0, se: 0},
+			{comps: []int{0}, ss: 1, se: 5},
+			{comps: []int{1}, ss: 1, se: 63},
+			{comps: []int{2}, ss: 1, se: 63},
+			{comps: []int{0}, ss: 6, se: 63},
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0xFF, 0xD8})
+	j.writeDQT(&buf)
+	j.writeSOF2(&buf)
+	for _, scan := range script {
+		// Count the symbols first, then write them with the tables built from the counts
+		counter := &huffmanCounter{}
+		j.encodeScan(scan, counter)
+		var tables [2]*huffmanCode
+		for t := range tables {
+			if counter.used[t] {
+				tables[t] = newHuffmanCode(counter.freq[t])
+			}
+		}
+		j.writeDHT(&buf, scan, tables)
+		j.writeSOS(&buf, scan)
+
+		out := &huffmanWriter{w: jpegBitWriter{buf: &buf}, tables: tables}
+		j.encodeScan(scan, out)
+		out.w.flush()
+	}
+	buf.Write([]byte{0xFF, 0xD9})
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// newOptimizedJPEG converts m to YCbCr (or keeps it gray), pads the planes to whole
+// MCUs by repeating the last row and column, and quantizes every block
+func newOptimizedJPEG(m image.Image, quality int) *optimizedJPEG {
+	b := m.Bounds()
+	j := &optimizedJPEG{width: b.Dx(), height: b.Dy()}
+	scale := 200 - quality*2
+	if quality < 50 {
+		scale = 5000 / quality
+	}
+	for t := range j.quant {
+		for i, q := range baseQuant[t] {
+			j.quant[t][i] = max(1, min((q*scale+50)/100, 255))
+		}
+	}
+
+	if gray, ok := m.(*image.Gray); ok {
+		j.mcusX, j.mcusY = ceilDiv(j.width, 8), ceilDiv(j.height, 8)
+		stride, rows := 8*j.mcusX, 8*j.mcusY
+		plane := make([]uint8, stride*rows)
+		for y := 0; y < rows; y++ {
+			sy := b.Min.Y + min(y, j.height-1)
+			for x := 0; x < stride; x++ {
+				plane[y*stride+x] = gray.Pix[gray.PixOffset(b.Min.X+min(x, j.width-1), sy)]
+			}
+		}
+		j.comps = []*jpegComponent{{
+			id: 1, h: 1, v: 1, quant: 0,
+			blocksW: j.mcusX, blocksH: j.mcusY, scanW: j.mcusX, scanH: j.mcusY,
+			coefs: j.transform(plane, stride, j.mcusX, j.mcusY, 0),
+		}}
+		return j
+	}
+
+	// Drawn onto RGBA for the fast paths of draw, alpha ends up premultiplied
+	// against black like the standard library encoder does it
+	rgba := image.NewRGBA(image.Rect(0, 0, j.width, j.height))
+	draw.Draw(rgba, rgba.Bounds(), m, b.Min, draw.Src)
+
+	j.mcusX, j.mcusY = ceilDiv(j.width, 16), ceilDiv(j.height, 16)
+	stride, rows := 16*j.mcusX, 16*j.mcusY
+	yPlane := make([]uint8, stride*rows)
+	cbFull := make([]uint8, stride*rows)
+	crFull := make([]uint8, stride*rows)
+	for y := 0; y < rows; y++ {
+		sy := min(y, j.height-1)
+		for x := 0; x < stride; x++ {
+			o := rgba.PixOffset(min(x, j.width-1), sy)
+			i := y*stride + x
+			yPlane[i], cbFull[i], crFull[i] = color.RGBToYCbCr(rgba.Pix[o], rgba.Pix[o+1], rgba.Pix[o+2])
+		}
+	}
+
+	chromaW, chromaH := ceilDiv(ceilDiv(j.width, 2), 8), ceilDiv(ceilDiv(j.height, 2), 8)
+	j.comps = []*jpegComponent{
+		{
+			id: 1, h: 2, v: 2, quant: 0,
+			blocksW: 2 * j.mcusX, blocksH: 2 * j.mcusY,
+			scanW: ceilDiv(j.width, 8), scanH: ceilDiv(j.height, 8),
+			coefs: j.transform(yPlane, stride, 2*j.mcusX, 2*j.mcusY, 0),
+		},
+		{
+			id: 2, h: 1, v: 1, quant: 1,
+			blocksW: j.mcusX, blocksH: j.mcusY, scanW: chromaW, scanH: chromaH,
+			coefs: j.transform(downsample2x2(cbFull, stride, rows), stride/2, j.mcusX, j.mcusY, 1),
+		},
+		{
+			id: 3, h: 1, v: 1, quant: 1,
+			blocksW: j.mcusX, blocksH: j.mcusY, scanW: chromaW, scanH: chromaH,
+			coefs: j.transform(downsample2x2(crFull, stride, rows), stride/2, j.mcusX, j.mcusY, 1),
+		},
+	}
+	return j
+}
+
+// downsample2x2 averages every 2x2 square of a plane with even dimensions
+func downsample2x2(plane []uint8, stride, rows int) []uint8 {
+	halfStride := stride / 2
+	out := make([]uint8, halfStride*(rows/2))
+	for y := 0; y < rows/2; y++ {
+		top, bottom := plane[2*y*stride:], plane[(2*y+1)*stride:]
+		for x := 0; x < halfStride; x++ {
+			sum := int(top[2*x]) + int(top[2*x+1]) + int(bottom[2*x]) + int(bottom[2*x+1])
+			out[y*halfStride+x] = uint8((sum + 2) / 4)
+		}
+	}
+	return out
+}
+
+// transform runs the forward DCT over the blocksW x blocksH blocks of plane and
+// quantizes them with table quant, coefficients are stored in natural order
+func (j *optimizedJPEG) transform(plane []uint8, stride, blocksW, blocksH, quant int) []int16 {
+	coefs := make([]int16, blocksW*blocksH*64)
+	q := &j.quant[quant]
+	var rows [64]float64
+	for by := 0; by < blocksH; by++ {
+		for bx := 0; bx < blocksW; bx++ {
+			base := by*8*stride + bx*8
+			for y := 0; y < 8; y++ {
+				line := plane[base+y*stride : base+y*stride+8]
+				for u := 0; u < 8; u++ {
+					sum := 0.0
+					for x, p := range line {
+						sum += dctCos[u][x] * (float64(p) - 128)
+					}
+					rows[y*8+u] = sum
+				}
+			}
+
+			block := coefs[(by*blocksW+bx)*64:]
+			for v := 0; v < 8; v++ {
+				for u := 0; u < 8; u++ {
+					sum := 0.0
+					for y := 0; y < 8; y++ {
+						sum += dctCos[v][y] * rows[y*8+u]
+					}
+					block[v*8+u] = int16(math.Round(sum / float64(q[v*8+u])))
+				}
+			}
+		}
+	}
+	return coefs
+}
+
+// huffmanSink receives the symbols and extra bits of a scan, counted in the first
+// pass and written in the second
+type huffmanSink interface {
+	symbol(table, sym int)
+	bits(value uint32, n int)
+}
+
+// encodeScan feeds the symbols of one scan to out. Only first scans are written,
+// every coefficient goes out in full (no successive approximation).
+func (j *optimizedJPEG) encodeScan(scan jpegScan, out huffmanSink) {
+	if scan.ss == 0 {
+		j.encodeDCScan(scan, out)
+		return
+	}
+	j.encodeACScan(j.comps[scan.comps[0]], scan.ss, scan.se, out)
+}
+
+func (j *optimizedJPEG) encodeDCScan(scan jpegScan, out huffmanSink) {
+	preds := make([]int, len(j.comps))
+	emit := func(ci, block int) {
+		c := j.comps[ci]
+		dc := int(c.coefs[block*64])
+		n, extra := huffmanCategory(dc - preds[ci])
+		preds[ci] = dc
+		out.symbol(c.quant, n)
+		out.bits(extra, n)
+	}
+
+	if len(scan.comps) == 1 {
+		ci := scan.comps[0]
+		c := j.comps[ci]
+		for by := 0; by < c.scanH; by++ {
+			for bx := 0; bx < c.scanW; bx++ {
+				emit(ci, by*c.blocksW+bx)
+			}
+		}
+		return
+	}
+
+	// Interleaved scans go MCU by MCU, each component's blocks in raster order
+	for my := 0; my < j.mcusY; my++ {
+		for mx := 0; mx < j.mcusX; mx++ {
+			for _, ci := range scan.comps {
+				c := j.comps[ci]
+				for v := 0; v < c.v; v++ {
+					for h := 0; h < c.h; h++ {
+						emit(ci, (my*c.v+v)*c.blocksW+mx*c.h+h)
+					}
+				}
+			}
+		}
+	}
+}
+
+// encodeACScan codes the ss..se band of c. Blocks whose remaining coefficients are
+// all zero are grouped into end-of-band runs, which is where progressive files
+// save most of their bytes.
+func (j *optimizedJPEG) encodeACScan(c *jpegComponent, ss, se int, out huffmanSink) {
+	eobRun := 0
+	flushRun := func() {
+		if eobRun == 0 {
+			return
+		}
+		n := bits.Len(uint(eobRun)) - 1
+		out.symbol(0, n<<4)
+		out.bits(uint32(eobRun)&(1<<n-1), n)
+		eobRun = 0
+	}
+
+	for by := 0; by < c.scanH; by++ {
+		for bx := 0; bx < c.scanW; bx++ {
+			block := c.coefs[(by*c.blocksW+bx)*64:]
+			zeros := 0
+			for k := ss; k <= se; k++ {
+				value := int(block[unzig[k]])
+				if value == 0 {
+					zeros++
+					continue
+				}
+				flushRun()
+				for zeros > 15 {
+					out.symbol(0, 0xF0)
+					zeros -= 16
+				}
+				n, extra := huffmanCategory(value)
+				out.symbol(0, zeros<<4|n)
+				out.bits(extra, n)
+				zeros = 0
+			}
+			if zeros > 0 {
+				eobRun++
+				if eobRun == 0x7FFF {
+					flushRun()
+				}
+			}
+		}
+	}
+	flushRun()
+}
+
+// huffmanCategory returns the size category of a coefficient and the bits that
+// follow its symbol, negative values are sent as their ones' complement
+func huffmanCategory(value int) (int, uint32) {
+	magnitude := value
+	if value < 0 {
+		magnitude = -value
+		value--
+	}
+	n := bits.Len(uint(magnitude))
+	return n, uint32(value) & (1<<n - 1)
+}
+
+// huffmanCounter counts the symbols of a scan per table
+type huffmanCounter struct {
+	freq [2][257]int
+	used [2]bool
+}
+
+func (c *huffmanCounter) symbol(table, sym int) {
+	c.freq[table][sym]++
+	c.used[table] = true
+}
+
+func (c *huffmanCounter) bits(uint32, int) {}
+
+// huffmanCode is a Huffman table as written to DHT and the code of every symbol
+type huffmanCode struct {
+	counts [16]byte // codes of each length 1..16
+	values []byte   // symbols by code length
+	code   [256]uint32
+	size   [256]int
+}
+
+// newHuffmanCode builds the optimal table for the symbol counts, limited to 16 bit
+// codes, following Annex K.2 of the JPEG spec. Index 256 is a reserved symbol so no
+// real symbol gets the all ones code.
+func newHuffmanCode(counts [257]int) *huffmanCode {
+	freq := counts
+	freq[256] = 1
+	var codeSize [257]int
+	var others [257]int
+	for i := range others {
+		others[i] = -1
+	}
+
+	for {
+		// The two least frequent, ties going to the higher symbol
+		c1, c2 := -1, -1
+		for i, f := range freq {
+			if f > 0 && (c1 < 0 || f <= freq[c1]) {
+				c1 = i
+			}
+		}
+		for i, f := range freq {
+			if f > 0 && i != c1 && (c2 < 0 || f <= freq[c2]) {
+				c2 = i
+			}
+		}
+		if c2 < 0 {
+			break
+		}
+
+		freq[c1] += freq[c2]
+		freq[c2] = 0
+		codeSize[c1]++
+		for others[c1] >= 0 {
+			c1 = others[c1]
+			codeSize[c1]++
+		}
+		others[c1] = c2
+		codeSize[c2]++
+		for others[c2] >= 0 {
+			c2 = others[c2]
+			codeSize[c2]++
+		}
+	}
+
+	var lengths [258]int
+	for _, size := range codeSize {
+		if size > 0 {
+			lengths[size]++
+		}
+	}
+	// Codes longer than 16 bits are folded into shorter lengths
+	for i := len(lengths) - 1; i > 16; i-- {
+		for lengths[i] > 0 {
+			j := i - 2
+			for lengths[j] == 0 {
+				j--
+			}
+			lengths[i] -= 2
+			lengths[i-1]++
+			lengths[j+1] += 2
+			lengths[j]--
+		}
+	}
+	// The reserved symbol holds one of the longest codes
+	for i := 16; i > 0; i-- {
+		if lengths[i] > 0 {
+			lengths[i]--
+			break
+		}
+	}
+
+	h := &huffmanCode{}
+	for i := range h.counts {
+		h.counts[i] = byte(lengths[i+1])
+	}
+	for size := 1; size < len(lengths); size++ {
+		for sym := 0; sym < 256; sym++ {
+			if codeSize[sym] == size {
+				h.values = append(h.values, byte(sym))
+			}
+		}
+	}
+
+	code, k := uint32(0), 0
+	for length := 1; length <= 16; length++ {
+		for n := 0; n < int(h.counts[length-1]); n++ {
+			sym := h.values[k]
+			h.code[sym], h.size[sym] = code, length
+			code++
+			k++
+		}
+		code <<= 1
+	}
+	return h
+}
+
+// jpegBitWriter writes entropy coded data, stuffing a zero byte after every 0xFF
+type jpegBitWriter struct {
+	buf *bytes.Buffer
+	acc uint32
+	n   int
+}
+
+func (w *jpegBitWriter) write(value uint32, n int) {
+	w.acc = w.acc<<n | value&(1<<n-1)
+	w.n += n
+	for w.n >= 8 {
+		b := byte(w.acc >> (w.n - 8))
+		w.buf.WriteByte(b)
+		if b == 0xFF {
+			w.buf.WriteByte(0)
+		}
+		w.n -= 8
+	}
+	w.acc &= 1<<w.n - 1
+}
+
+// flush pads the last byte with ones, as the spec asks at the end of a scan
+func (w *jpegBitWriter) flush() {
+	if w.n > 0 {
+		w.write(1<<(8-w.n)-1, 8-w.n)
+	}
+}
+
+// huffmanWriter writes the symbols of a scan with the tables built for it
+type huffmanWriter struct {
+	w      jpegBitWriter
+	tables [2]*huffmanCode
+}
+
+func (h *huffmanWriter) symbol(table, sym int) {
+	t := h.tables[table]
+	h.w.write(t.code[sym], t.size[sym])
+}
+
+func (h *huffmanWriter) bits(value uint32, n int) {
+	if n > 0 {
+		h.w.write(value, n)
+	}
+}
+
+// writeMarker writes a marker segment, length included
+func writeMarker(buf *bytes.Buffer, marker byte, payload []byte) {
+	n := len(payload) + 2
+	buf.Write([]byte{0xFF, marker, byte(n >> 8), byte(n)})
+	buf.Write(payload)
+}
+
+func (j *optimizedJPEG) writeDQT(buf *bytes.Buffer) {
+	tables := 1
+	if len(j.comps) == 3 {
+		tables = 2
+	}
+	var payload []byte
+	for t := 0; t < tables; t++ {
+		payload = append(payload, byte(t))
+		for k := 0; k < 64; k++ {
+			payload = append(payload, byte(j.quant[t][unzig[k]]))
+		}
+	}
+	writeMarker(buf, 0xDB, payload)
+}
+
+func (j *optimizedJPEG) writeSOF2(buf *bytes.Buffer) {
+	payload := []byte{8, byte(j.height >> 8), byte(j.height), byte(j.width >> 8), byte(j.width), byte(len(j.comps))}
+	for _, c := range j.comps {
+		payload = append(payload, c.id, byte(c.h<<4|c.v), byte(c.quant))
+	}
+	writeMarker(buf, 0xC2, payload)
+}
+
+// writeDHT defines the tables of the next scan: DC tables by their index for a DC
+// scan, AC table 0 otherwise
+func (j *optimizedJPEG) writeDHT(buf *bytes.Buffer, scan jpegScan, tables [2]*huffmanCode) {
+	class := byte(0)
+	if scan.ss > 0 {
+		class = 1
+	}
+	var payload []byte
+	for t, table := range tables {
+		if table == nil {
+			continue
+		}
+		payload = append(payload, class<<4|byte(t))
+		payload = append(payload, table.counts[:]...)
+		payload = append(payload, table.values...)
+	}
+	writeMarker(buf, 0xC4, payload)
+}
+
+func (j *optimizedJPEG) writeSOS(buf *bytes.Buffer, scan jpegScan) {
+	payload := []byte{byte(len(scan.comps))}
+	for _, ci := range scan.comps {
+		c := j.comps[ci]
+		table := byte(0)
+		if scan.ss == 0 {
+			table = byte(c.quant) << 4
+		}
+		payload = append(payload, c.id, table)
+	}
+	payload = append(payload, byte(scan.ss), byte(scan.se), 0)
+	writeMarker(buf, 0xDA, payload)
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// fileOpAttempts is how many times a CBZ is written before the chapter fails
+	fileOpAttempts = 3
+
+	// fileOpRetryDelay is the wait before the first retry of a CBZ write, doubled
+	// for every further retry
+	fileOpRetryDelay = 250 * time.Millisecond
+
+	// cleanupAttempts and cleanupRetryDelay pace the background retries of a temp
+	// dir that could not be removed straight away
+	cleanupAttempts   = 5
+	cleanupRetryDelay = 2 * time.Second
+)
+
+// pendingCleanups are the temp dirs waiting for a background removal retry
+var (
+	pendingCleanupsMu sync.Mutex
+	pendingCleanups   = map[string]bool{}
+)
+
+// PackageChapter packs the pages in chapterDir into the CBZ at cbzPath, retrying a
+// failed write (a full disk being cleared, a file briefly locked by a virus scanner
+// or indexer), and then removes chapterDir through CleanupTempDir. chapterDir is
+// only removed once the CBZ exists, on failure cleaning up is left to the caller.
+func PackageChapter(chapterDir, cbzPath string) error {
+	err := RetryFileOp(func() error {
+		return CreateCbzFromDir(chapterDir, cbzPath)
+	})
+	if err != nil {
+		return err
+	}
+	CleanupTempDir(chapterDir)
+	return nil
+}
+
+// RetryFileOp runs op until it succeeds, up to three attempts with a short doubling
+// delay between them. Returns the last error when every attempt failed.
+func RetryFileOp(op func() error) error {
+	var err error
+	delay := fileOpRetryDelay
+	for attempt := 1; attempt <= fileOpAttempts; attempt++ {
+		if err = op(); err == nil {
+			return nil
+		}
+		if attempt < fileOpAttempts {
+			log.Printf("[Parser] ⚠️ Attempt %d/%d failed, retrying in %v: %v", attempt, fileOpAttempts, delay, err)
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return fmt.Errorf("failed after %d attempts: %w", fileOpAttempts, err)
+}
+
+// CleanupTempDir removes a chapter temp dir. When that fails (eg: a file handle
+// that lingers on Windows) the removal is retried in the background for a while,
+// unless the dir is written to again meanwhile, which means a new download of the
+// chapter took it over and will clean it up itself.
+func CleanupTempDir(dir string) {
+	err := os.RemoveAll(dir)
+	if err == nil {
+		return
+	}
+	log.Printf("[Parser] ⚠️ Failed to remove temp dir %s, retrying in the background: %v", dir, err)
+
+	info, statErr := os.Stat(dir)
+	if statErr != nil {
+		return
+	}
+
+	pendingCleanupsMu.Lock()
+	if pendingCleanups[dir] {
+		pendingCleanupsMu.Unlock()
+		return
+	}
+	pendingCleanups[dir] = true
+	pendingCleanupsMu.Unlock()
+
+	go retryCleanup(dir, info.ModTime())
+}
+
+// retryCleanup is the background part of CleanupTempDir
+func retryCleanup(dir string, modTime time.Time) {
+	defer func() {
+		pendingCleanupsMu.Lock()
+		delete(pendingCleanups, dir)
+		pendingCleanupsMu.Unlock()
+	}()
+
+	delay := cleanupRetryDelay
+	for attempt := 1; attempt <= cleanupAttempts; attempt++ {
+		time.Sleep(delay)
+		delay *= 2
+
+		info, err := os.Stat(dir)
+		if os.IsNotExist(err) {
+			return
+		}
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return // taken over by a new download
+		}
+		if err := os.RemoveAll(dir); err == nil {
+			log.Printf("[Parser] ✓ Removed temp dir %s", dir)
+			return
+		} else if attempt == cleanupAttempts {
+			log.Printf("[Parser] ⚠️ Giving up removing temp dir %s: %v", dir, err)
+			return
+		}
+		// What did get removed changed the dir, start comparing from here
+		if info, err = os.Stat(dir); err != nil {
+			return
+		}
+		modTime = info.ModTime()
+	}
+}
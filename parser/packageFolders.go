@@ -0,0 +1,225 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PackageOptions control how PackageImageFolders packages each folder
+type PackageOptions struct {
+	// OutputDir is where the CBZs are written, the root folder when empty
+	OutputDir string
+	// Series goes into the ComicInfo of every CBZ, the root folder name when empty
+	Series string
+	// KeepNative writes the pages untouched, otherwise they are converted to JPEG
+	// the way chapters of most sites are
+	KeepNative bool
+	// Overwrite replaces a CBZ that already exists, otherwise the folder is skipped
+	Overwrite bool
+}
+
+// packageImageExts are the page extensions PackageImageFolders picks up, anything
+// else in a folder (notes, thumbs.db, a ComicInfo.xml of another tool) is ignored
+var packageImageExts = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".webp": true, ".gif": true,
+}
+
+// folderChapterRe finds the chapter number in a folder name, the last number in it
+// so "Vol 2 Chapter 12.5" is chapter 12.5
+var folderChapterRe = regexp.MustCompile(`(\d+)(?:\.(\d+))?\D*$`)
+
+// PackageImageFolders packs every subfolder of root holding images into a CBZ, as
+// kansho would have downloaded it: pages in numeric order renamed 001, 002...,
+// a ComicInfo.xml and a name from the chapter number in the folder name
+// ("Chapter 12" becomes ch012.cbz, a folder without a number keeps its name).
+// Folders that would get the same name, eg: "Vol 1 Ch 1" and "Vol 2 Ch 1", are
+// reported and none of them is packaged. The folders themselves are left untouched.
+// A failing folder does not stop the others, the errors are returned together.
+func PackageImageFolders(root string, opts PackageOptions) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		outputDir = root
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	series := opts.Series
+	if series == "" {
+		series = filepath.Base(filepath.Clean(root))
+	}
+
+	var errs []error
+	fail := func(folder string, err error) {
+		log.Printf("[Package] ⚠️ %s: %v", folder, err)
+		errs = append(errs, fmt.Errorf("%s: %w", folder, err))
+	}
+
+	// Every folder's name is worked out first, so a name two folders share is
+	// caught before either overwrites or shadows the other
+	var folders []string
+	pagesOf := make(map[string][]string)
+	sharing := make(map[string][]string) // cbz name -> folders
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		pages, err := folderPages(filepath.Join(root, entry.Name()))
+		if err != nil {
+			fail(entry.Name(), err)
+			continue
+		}
+		if len(pages) == 0 {
+			log.Printf("[Package] Skipping %s: no images", entry.Name())
+			continue
+		}
+		name, _ := folderCbzName(entry.Name())
+		folders = append(folders, entry.Name())
+		pagesOf[entry.Name()] = pages
+		sharing[name] = append(sharing[name], entry.Name())
+	}
+
+	for _, folder := range folders {
+		name, _ := folderCbzName(folder)
+		if len(sharing[name]) > 1 {
+			var others []string
+			for _, other := range sharing[name] {
+				if other != folder {
+					others = append(others, other)
+				}
+			}
+			fail(folder, fmt.Errorf("would be packaged as %s like %s, rename the folders apart", name, strings.Join(others, ", ")))
+			continue
+		}
+		if err := packageImageFolder(filepath.Join(root, folder), pagesOf[folder], outputDir, series, opts); err != nil {
+			fail(folder, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// packageImageFolder stages the pages of one folder in a temp dir and packages it
+func packageImageFolder(folder string, pages []string, outputDir, series string, opts PackageOptions) error {
+	name, number := folderCbzName(filepath.Base(folder))
+	cbzPath := filepath.Join(outputDir, name)
+	if _, err := os.Stat(cbzPath); err == nil && !opts.Overwrite {
+		log.Printf("[Package] Skipping %s: %s already exists", folder, name)
+		return nil
+	}
+
+	staging, err := os.MkdirTemp(outputDir, ".kansho-package-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	for i, page := range pages {
+		data, err := os.ReadFile(filepath.Join(folder, page))
+		if err != nil {
+			CleanupTempDir(staging)
+			return err
+		}
+		if err := SaveImage(data, staging, strconv.Itoa(i+1), opts.KeepNative); err != nil {
+			CleanupTempDir(staging)
+			return fmt.Errorf("page %s: %w", page, err)
+		}
+	}
+
+	info := ComicInfo{Series: series, Number: number, Title: filepath.Base(folder)}
+	if err := WriteComicInfo(staging, info); err != nil {
+		CleanupTempDir(staging)
+		return err
+	}
+	if err := PackageChapter(staging, cbzPath); err != nil {
+		CleanupTempDir(staging)
+		return err
+	}
+	log.Printf("[Package] ✓ %s → %s (%d pages)", folder, name, len(pages))
+	return nil
+}
+
+// folderPages lists the images in folder in reading order. Names are compared
+// number by number, so page2.jpg comes before page10.jpg even without padding.
+func folderPages(folder string) ([]string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var pages []string
+	for _, entry := range entries {
+		if !entry.IsDir() && packageImageExts[strings.ToLower(filepath.Ext(entry.Name()))] {
+			pages = append(pages, entry.Name())
+		}
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		return numericNameLess(pages[i], pages[j])
+	})
+	return pages, nil
+}
+
+// folderCbzName is the CBZ filename and ComicInfo number for a folder of pages:
+// the chapter filename of the last number in the folder name, or the folder name
+// itself when it has none
+func folderCbzName(folder string) (name, number string) {
+	m := folderChapterRe.FindStringSubmatch(folder)
+	if m == nil {
+		return folder + ".cbz", ""
+	}
+	main, err := strconv.Atoi(m[1])
+	if err != nil {
+		return folder + ".cbz", ""
+	}
+	number = strconv.Itoa(main)
+	name = ChapterFilename(number, m[2])
+	if m[2] != "" {
+		number += "." + m[2]
+	}
+	return name, number
+}
+
+// numericNameLess orders two filenames comparing runs of digits by their value and
+// everything else case insensitively
+func numericNameLess(a, b string) bool {
+	x, y := strings.ToLower(a), strings.ToLower(b)
+	for x != "" && y != "" {
+		dx, dy := digitRun(x), digitRun(y)
+		if dx > 0 && dy > 0 {
+			nx := strings.TrimLeft(x[:dx], "0")
+			ny := strings.TrimLeft(y[:dy], "0")
+			if len(nx) != len(ny) {
+				return len(nx) < len(ny)
+			}
+			if nx != ny {
+				return nx < ny
+			}
+			x, y = x[dx:], y[dy:]
+			continue
+		}
+		if x[0] != y[0] {
+			return x[0] < y[0]
+		}
+		x, y = x[1:], y[1:]
+	}
+	if len(x) != len(y) {
+		return len(x) < len(y)
+	}
+	return a < b
+}
+
+// digitRun is the length of the run of ASCII digits s starts with
+func digitRun(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// PageOrderBackupSuffix is appended to the name of the original cbz FixCbzPageOrder
+// keeps next to the rewritten one (eg: "ch001.cbz.bak")
+const PageOrderBackupSuffix = ".bak"
+
+// pageOrderPlan is how the entries of a cbz are rewritten in reading order
+type pageOrderPlan struct {
+	entries []*zip.File          // numbered pages in reading order, then everything else
+	names   map[*zip.File]string // new names of the numbered pages
+	wrong   bool                 // whether a reader would show the pages out of order
+}
+
+// CbzPageOrderWrong reports whether the numbered pages of the cbz at cbzPath would be
+// read out of order. Readers sort pages by name, so unpadded names ("1.jpg",
+// "10.jpg", "2.jpg") are wrong, as are archives whose entries are not stored in page
+// order. Non numbered entries such as ComicInfo.xml are ignored.
+func CbzPageOrderWrong(cbzPath string) (bool, error) {
+	zr, err := zip.OpenReader(cbzPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open cbz: %w", err)
+	}
+	defer zr.Close()
+
+	return planPageOrder(zr.File).wrong, nil
+}
+
+// FixCbzPageOrder rewrites the cbz at cbzPath with its numbered pages renamed to
+// zero-padded names (001.jpg, 002.jpg...) in SortPageFiles order and stored in that
+// order, other entries keep their names and follow the pages. Page data is copied
+// without recompressing.
+//
+// This replaces the chapter, so the original is kept as cbzPath+PageOrderBackupSuffix.
+// Archives already in order are left untouched and false is returned.
+func FixCbzPageOrder(cbzPath string) (bool, error) {
+	zr, err := zip.OpenReader(cbzPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open cbz: %w", err)
+	}
+	defer zr.Close()
+
+	plan := planPageOrder(zr.File)
+	if !plan.wrong {
+		return false, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cbzPath), ".kansho-reorder-*")
+	if err != nil {
+		return false, fmt.Errorf("failed to create reordered cbz: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if err := writePageOrder(plan, tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return false, fmt.Errorf("failed to reorder %s: %w", filepath.Base(cbzPath), err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return false, err
+	}
+	// Windows cannot rename a file that is still open
+	zr.Close()
+
+	os.Chmod(tmpName, 0644)
+	backup := cbzPath + PageOrderBackupSuffix
+	if err := os.Rename(cbzPath, backup); err != nil {
+		os.Remove(tmpName)
+		return false, fmt.Errorf("failed to back up %s: %w", filepath.Base(cbzPath), err)
+	}
+	if err := os.Rename(tmpName, cbzPath); err != nil {
+		// Put the original back rather than leave the chapter missing
+		os.Rename(backup, cbzPath)
+		os.Remove(tmpName)
+		return false, err
+	}
+	return true, nil
+}
+
+// planPageOrder works out the reading order of files and the padded page names
+func planPageOrder(files []*zip.File) pageOrderPlan {
+	var pages, other []*zip.File
+	var entryOrder []string
+	for _, f := range files {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if _, ok := leadingNumber(path.Base(f.Name)); ok {
+			pages = append(pages, f)
+			entryOrder = append(entryOrder, f.Name)
+		} else {
+			other = append(other, f)
+		}
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pageFileLess(path.Base(pages[i].Name), path.Base(pages[j].Name))
+	})
+
+	readingOrder := make([]string, len(pages))
+	for i, f := range pages {
+		readingOrder[i] = f.Name
+	}
+	byName := slices.Clone(entryOrder)
+	sort.Strings(byName)
+
+	plan := pageOrderPlan{
+		entries: append(pages, other...),
+		names:   make(map[*zip.File]string, len(pages)),
+		wrong:   !slices.Equal(byName, readingOrder) || !slices.Equal(entryOrder, readingOrder),
+	}
+
+	width := max(3, len(strconv.Itoa(len(pages))))
+	for i, f := range pages {
+		name := fmt.Sprintf("%0*d%s", width, i+1, path.Ext(f.Name))
+		if dir := path.Dir(f.Name); dir != "." {
+			name = path.Join(dir, name)
+		}
+		plan.names[f] = name
+	}
+	return plan
+}
+
+// writePageOrder writes the entries of plan to out as a new archive, copying the
+// compressed data as is
+func writePageOrder(plan pageOrderPlan, out io.Writer) error {
+	zw := zip.NewWriter(out)
+	for _, f := range plan.entries {
+		header := f.FileHeader
+		if name, ok := plan.names[f]; ok {
+			// Extra fields may carry the old name (Info-ZIP unicode path)
+			header.Name = name
+			header.Extra = nil
+		}
+
+		w, err := zw.CreateRaw(&header)
+		if err != nil {
+			return err
+		}
+		r, err := f.OpenRaw()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	return zw.Close()
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -87,26 +88,34 @@ func SortKeys(inputMap map[string]string) ([]string, error) {
 }
 
 // Sorts map keys numerically (for image indices like "0", "1", "10", "20")
-// Unlike SortKeys which sorts alphabetically, this converts keys to integers before sorting
+// Unlike SortKeys which sorts alphabetically, this sorts by the leading integer of each
+// key, ignoring zero padding and any extension ("007.jpg", "7", "10.webp"). Keys with
+// the same number are ordered by their text, keys without a leading number come last
+// in alphabetical order so the result is always deterministic.
 func SortKeysNumeric(inputMap map[string]string) ([]string, error) {
 	type keyVal struct {
-		key string
-		num int
+		key     string
+		num     int
+		numeric bool
 	}
 
 	var items []keyVal
 
 	for key := range inputMap {
-		num, err := strconv.Atoi(key)
-		if err != nil {
-			return nil, fmt.Errorf("key %s is not a valid integer: %w", key, err)
-		}
-		items = append(items, keyVal{key: key, num: num})
+		num, ok := leadingNumber(path.Base(filepath.ToSlash(strings.TrimSpace(key))))
+		items = append(items, keyVal{key: key, num: num, numeric: ok})
 	}
 
-	// Sort by numeric value
+	// Sort by numeric value, non-numeric keys after every numeric one
 	sort.Slice(items, func(i, j int) bool {
-		return items[i].num < items[j].num
+		a, b := items[i], items[j]
+		if a.numeric != b.numeric {
+			return a.numeric
+		}
+		if a.numeric && a.num != b.num {
+			return a.num < b.num
+		}
+		return a.key < b.key
 	})
 
 	// Extract sorted keys
@@ -146,14 +155,74 @@ func padFileName(inputFileName string) string {
 	return outputFileName
 }
 
+// leadingNumber returns the integer at the start of a page filename (eg: "012.webp" -> 12)
+func leadingNumber(fileName string) (int, bool) {
+	stem := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	end := 0
+	for end < len(stem) && stem[end] >= '0' && stem[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0, false
+	}
+	num, err := strconv.Atoi(stem[:end])
+	if err != nil {
+		return 0, false
+	}
+	return num, true
+}
+
+// SortPageFiles sorts page filenames in reading order. Numbered pages are ordered by
+// their number, any non numbered files (eg: cover.jpg) follow alphabetically.
+func SortPageFiles(files []string) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return pageFileLess(files[i], files[j])
+	})
+}
+
+// pageFileLess is the SortPageFiles order of two page filenames
+func pageFileLess(a, b string) bool {
+	numA, okA := leadingNumber(a)
+	numB, okB := leadingNumber(b)
+
+	switch {
+	case okA && okB && numA != numB:
+		return numA < numB
+	case okA != okB:
+		return okA
+	default:
+		return a < b
+	}
+}
+
+// CbzPageCount returns the number of image pages in a CBZ, metadata such as
+// ComicInfo.xml is not counted
+func CbzPageCount(cbzPath string) (int, error) {
+	zr, err := zip.OpenReader(cbzPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open cbz: %w", err)
+	}
+	defer zr.Close()
+
+	pages := 0
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || path.Base(f.Name) == ComicInfoFileName {
+			continue
+		}
+		pages++
+	}
+	return pages, nil
+}
+
 // create cbz file from source directory that ONLY contains image files
 // imput sourceDir is scanned and sorted to add files to cbz in order, note it is expected that the soureDir is the
-// temp dir that ONLY contains image files
-func CreateCbzFromDir(sourceDir, zipName string) error {
+// temp dir that ONLY contains image files. A cbz that fails part way is removed again, see PackageChapter for
+// the retrying version the downloaders use.
+func CreateCbzFromDir(sourceDir, zipName string) (err error) {
 	// Read all directory entries
 	entries, err := os.ReadDir(sourceDir)
 	if err != nil {
-		log.Fatalf("failed to read directory: %v", err)
+		return fmt.Errorf("failed to read directory: %w", err)
 	}
 
 	// Collect all file names (skip directories)
@@ -164,22 +233,43 @@ func CreateCbzFromDir(sourceDir, zipName string) error {
 		}
 	}
 
-	// Sort files alphabetically for ordered inclusion
-	sort.Strings(files)
+	// Sort files by page number for ordered inclusion, this keeps pages in order
+	// regardless of extension (mixed jpg/webp/png) or missing zero padding
+	SortPageFiles(files)
 
 	// Create output cbz (zip) file
 	zipFile, err := os.Create(zipName)
 	if err != nil {
-		log.Fatalf("parser.CreateCbzFromDir() - failed to create cbz file: %v", err)
+		return fmt.Errorf("failed to create cbz file: %w", err)
 	}
-	defer zipFile.Close()
+	// A half written cbz would pass for a downloaded chapter
+	defer func() {
+		if err != nil {
+			zipFile.Close()
+			os.Remove(zipName)
+		}
+	}()
 
 	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	rule := CbzExtensionRule()
+	entryNames := make(map[string]bool, len(files))
+	for _, file := range files {
+		entryNames[file] = true
+	}
 
-	// Add each file to the zip archive
+	// Add each file to the zip archive, in page order whatever the entries end up named
 	for _, file := range files {
 		filePath := filepath.Join(sourceDir, file)
+		entryName := NormalizeCbzEntryName(file, rule)
+		if entryName != file {
+			if entryNames[entryName] {
+				// eg: 001.JPG next to 001.jpg, a second entry of the same name is not allowed
+				log.Printf("[CBZ] ⚠️ %s would clash with another page as %s, keeping its name", file, entryName)
+				entryName = file
+			} else {
+				entryNames[entryName] = true
+			}
+		}
 
 		err := func() error {
 			f, err := os.Open(filePath)
@@ -188,7 +278,7 @@ func CreateCbzFromDir(sourceDir, zipName string) error {
 			}
 			defer f.Close()
 
-			w, err := zipWriter.Create(file)
+			w, err := zipWriter.Create(entryName)
 			if err != nil {
 				return err
 			}
@@ -197,9 +287,15 @@ func CreateCbzFromDir(sourceDir, zipName string) error {
 			return err
 		}()
 		if err != nil {
-			log.Fatalf("error adding %s to cbz: %v", filePath, err)
+			return fmt.Errorf("error adding %s to cbz: %w", filePath, err)
 		}
 	}
 
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to write cbz file: %w", err)
+	}
+	if err := zipFile.Close(); err != nil {
+		return fmt.Errorf("failed to write cbz file: %w", err)
+	}
 	return nil
 }
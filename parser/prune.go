@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// prunedSidecarName is the file kept in each manga directory listing chapters that
+// were deliberately deleted by PruneOldChapters, so they are not downloaded again
+const prunedSidecarName = ".kansho-pruned.json"
+
+// seasonSortStride separates seasons in ChapterSortValue, chapter numbers restart
+// each season so season 2 must sort after every chapter of season 1
+const seasonSortStride = 1e6
+
+// seasonChapterRe matches season-prefixed cbz names (eg: "s02ch045.cbz")
+var seasonChapterRe = regexp.MustCompile(`^s(\d+)ch(.+)$`)
+
+// ChapterSortValue returns the chapter number of a cbz filename (eg: "ch072.5.cbz" -> 72.5).
+// Season-prefixed names sort after unprefixed chapters, in season order, and
+// specials (sp001.cbz) after both. False when the name has no chapter number.
+func ChapterSortValue(fileName string) (float64, bool) {
+	if n, ok := SpecialChapterNumber(fileName); ok {
+		return specialSortOffset + float64(n), true
+	}
+
+	_, _, num, err := ParseChapterNumber(fileName)
+	if err != nil {
+		return 0, false
+	}
+
+	season := 0.0
+	if m := seasonChapterRe.FindStringSubmatch(fileName); m != nil {
+		s, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, false
+		}
+		season = float64(s)
+	}
+	return season*seasonSortStride + num, true
+}
+
+// SortChaptersNumeric sorts cbz filenames in ascending chapter order, filenames
+// without a parsable chapter number sort first, alphabetically
+func SortChaptersNumeric(chapters []string) {
+	sort.SliceStable(chapters, func(i, j int) bool {
+		numI, okI := ChapterSortValue(chapters[i])
+		numJ, okJ := ChapterSortValue(chapters[j])
+
+		switch {
+		case okI && okJ && numI != numJ:
+			return numI < numJ
+		case okI != okJ:
+			return okJ
+		default:
+			return chapters[i] < chapters[j]
+		}
+	})
+}
+
+// SortChapterKeys returns the chapter filenames of chapterMap in ascending chapter
+// order. Unlike SortKeys, ch1000.cbz sorts after ch999.cbz once a series outgrows the
+// three digit padding.
+func SortChapterKeys(chapterMap map[string]string) []string {
+	chapters := make([]string, 0, len(chapterMap))
+	for name := range chapterMap {
+		chapters = append(chapters, name)
+	}
+	SortChaptersNumeric(chapters)
+	return chapters
+}
+
+// PrunedChapterList returns the chapters previously removed from rootDir by
+// PruneOldChapters. A missing sidecar file simply means nothing was pruned.
+func PrunedChapterList(rootDir string) ([]string, error) {
+	expandedPath, err := ExpandPath(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(expandedPath, prunedSidecarName))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pruned chapter list: %w", err)
+	}
+
+	var pruned []string
+	if err := json.Unmarshal(data, &pruned); err != nil {
+		return nil, fmt.Errorf("failed to parse pruned chapter list: %w", err)
+	}
+	return pruned, nil
+}
+
+// PruneOldChapters deletes the oldest (lowest numbered) cbz files in rootDir so that
+// only the latest keep chapters remain. Deleted chapters are recorded in the pruned
+// sidecar so the downloader does not fetch them again. keep <= 0 disables pruning.
+// Returns the chapters that were removed.
+func PruneOldChapters(rootDir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+
+	expandedPath, err := ExpandPath(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	chapters, err := LocalChapterList(expandedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chapters) <= keep {
+		return nil, nil
+	}
+
+	SortChaptersNumeric(chapters)
+	toRemove := chapters[:len(chapters)-keep]
+
+	// Record before deleting so an interrupted prune never leads to a re-download
+	pruned, err := PrunedChapterList(expandedPath)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]struct{}, len(pruned))
+	for _, name := range pruned {
+		known[name] = struct{}{}
+	}
+	for _, name := range toRemove {
+		if _, ok := known[name]; !ok {
+			pruned = append(pruned, name)
+		}
+	}
+	SortChaptersNumeric(pruned)
+
+	data, err := json.MarshalIndent(pruned, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pruned chapter list: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(expandedPath, prunedSidecarName), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write pruned chapter list: %w", err)
+	}
+
+	var removed []string
+	for _, name := range toRemove {
+		if err := os.Remove(filepath.Join(expandedPath, name)); err != nil {
+			log.Printf("[Prune] Failed to remove %s: %v", name, err)
+			continue
+		}
+		removed = append(removed, name)
+	}
+
+	log.Printf("[Prune] Removed %d old chapters from %s (keeping latest %d)", len(removed), expandedPath, keep)
+	return removed, nil
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
@@ -76,3 +77,26 @@ func SleepCtx(ctx context.Context, d time.Duration) bool {
 		return false
 	}
 }
+
+var (
+	sharedLimiters   = make(map[string]*RateLimiter)
+	sharedLimitersMu sync.Mutex
+)
+
+// SharedRateLimiter returns the process wide rate limiter for key (typically a
+// domain), creating it with interval on first use. Every caller waiting on the
+// same key shares one tick stream, so concurrent downloads against a domain are
+// spaced out together rather than each at the full rate.
+//
+// Shared limiters live for the life of the process, do not call Stop on them.
+func SharedRateLimiter(key string, interval time.Duration) *RateLimiter {
+	sharedLimitersMu.Lock()
+	defer sharedLimitersMu.Unlock()
+
+	if rl, ok := sharedLimiters[key]; ok {
+		return rl
+	}
+	rl := NewRateLimiter(interval)
+	sharedLimiters[key] = rl
+	return rl
+}
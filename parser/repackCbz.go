@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultRepackLevel is the deflate level archival repacks use unless told otherwise
+const DefaultRepackLevel = flate.BestCompression
+
+// repackStoredExts are formats that are already compressed, deflating them again
+// costs time and saves nothing so they are stored as is
+var repackStoredExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".webp": true,
+	".avif": true,
+	".gif":  true,
+}
+
+// RepackCbz rewrites the CBZ at src to dst for long-term storage: PNG pages have
+// their pixel data re-encoded at level (flate.BestSpeed to flate.BestCompression),
+// losslessly, and are kept as is when that is not smaller. PNG pages and metadata are
+// then deflated at level, other pages (JPEG, WebP...) are stored unchanged. Entries keep their
+// order and names so readers see exactly the same pages. Any zip reader can open
+// the result, only the compression changes.
+//
+// dst may equal src; the new archive is written to a temp file and only replaces dst
+// once complete.
+func RepackCbz(src, dst string, level int) error {
+	if level < flate.BestSpeed || level > flate.BestCompression {
+		return fmt.Errorf("invalid compression level %d, must be %d-%d", level, flate.BestSpeed, flate.BestCompression)
+	}
+
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("failed to open cbz: %w", err)
+	}
+	defer zr.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".kansho-repack-*")
+	if err != nil {
+		return fmt.Errorf("failed to create repacked cbz: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if err := repackEntries(zr, tmp, level); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to repack %s: %w", filepath.Base(src), err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	// CreateTemp files are owner-only, match the CBZs the downloader creates
+	os.Chmod(tmpName, 0644)
+	if err := os.Rename(tmpName, dst); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// repackEntries copies every entry of zr into a new archive written to out
+func repackEntries(zr *zip.ReadCloser, out io.Writer, level int) error {
+	zw := zip.NewWriter(out)
+	zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+
+	for _, f := range zr.File {
+		header := &zip.FileHeader{
+			Name:     f.Name,
+			Comment:  f.Comment,
+			Modified: f.Modified,
+			Method:   zip.Deflate,
+		}
+		header.SetMode(f.Mode())
+		ext := strings.ToLower(path.Ext(f.Name))
+		if f.FileInfo().IsDir() || repackStoredExts[ext] {
+			header.Method = zip.Store
+		}
+
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if ext == ".png" {
+			var data []byte
+			data, err = io.ReadAll(r)
+			if err == nil {
+				_, err = w.Write(recompressPNG(data, level))
+			}
+		} else {
+			_, err = io.Copy(w, r)
+		}
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+// pngColorChunks change how a PNG's pixels are displayed, the png encoder does not
+// write them back so a page carrying one is left as is
+var pngColorChunks = map[string]bool{
+	"gAMA": true,
+	"cHRM": true,
+	"sRGB": true,
+	"iCCP": true,
+}
+
+// recompressPNG re-encodes the pixels of a PNG page with the png encoder setting
+// closest to the deflate level. Returns data unchanged when it does not decode, has
+// colour space chunks or the result is not smaller, a page is never made bigger or
+// shown differently.
+func recompressPNG(data []byte, level int) []byte {
+	if hasPNGChunk(data, pngColorChunks) {
+		return data
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return data
+	}
+
+	encoder := png.Encoder{CompressionLevel: png.DefaultCompression}
+	switch {
+	case level <= flate.BestSpeed:
+		encoder.CompressionLevel = png.BestSpeed
+	case level >= flate.BestCompression:
+		encoder.CompressionLevel = png.BestCompression
+	}
+
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, img); err != nil || buf.Len() >= len(data) {
+		return data
+	}
+	return buf.Bytes()
+}
+
+// hasPNGChunk reports whether the PNG data holds a chunk of one of the given types,
+// or is too malformed to tell
+func hasPNGChunk(data []byte, types map[string]bool) bool {
+	const signatureLen = 8
+	for pos := signatureLen; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		if types[chunkType] || length < 0 || length > len(data) {
+			return true
+		}
+		if chunkType == "IDAT" || chunkType == "IEND" {
+			// Colour space chunks must come before the image data
+			return false
+		}
+		pos += 12 + length
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"kansho/cf"
+
+	"github.com/gocolly/colly"
+)
+
+// RequestExtras are the cookies and headers a bookmark adds to every chapter list and
+// image request of its series, eg: the session of a logged in user for age gated or
+// premium series. They are applied after any CF bypass data, so a cookie or header
+// set here wins. The values are credentials and must never be logged, and are only
+// sent to the bookmark's host and its subdomains, never to an image CDN elsewhere.
+type RequestExtras struct {
+	Cookies []*http.Cookie
+	Headers map[string]string
+
+	// Host is the host of the bookmark's URL, www and apex hosts count as the same
+	Host string
+}
+
+type requestExtrasKey struct{}
+
+// ParseRequestExtras builds the RequestExtras of the series at seriesURL from a
+// Cookie header value ("name=value; other=value", as copied from the browser) and a
+// set of headers. Malformed cookies are dropped.
+func ParseRequestExtras(seriesURL, cookies string, headers map[string]string) RequestExtras {
+	extras := RequestExtras{Host: cf.NormalizeDomain(seriesURL)}
+	if cookies = strings.TrimSpace(cookies); cookies != "" {
+		for _, part := range strings.Split(cookies, ";") {
+			parsed, err := http.ParseCookie(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			extras.Cookies = append(extras.Cookies, parsed...)
+		}
+	}
+	for name, value := range headers {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if extras.Headers == nil {
+			extras.Headers = make(map[string]string)
+		}
+		extras.Headers[name] = value
+	}
+	return extras
+}
+
+// IsZero reports whether there is nothing to add
+func (e RequestExtras) IsZero() bool {
+	return len(e.Cookies) == 0 && len(e.Headers) == 0
+}
+
+// WithRequestExtras returns a context whose requests carry extras
+func WithRequestExtras(ctx context.Context, extras RequestExtras) context.Context {
+	if extras.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, requestExtrasKey{}, extras)
+}
+
+// RequestExtrasFrom returns the extras of ctx, zero when there are none
+func RequestExtrasFrom(ctx context.Context) RequestExtras {
+	extras, _ := ctx.Value(requestExtrasKey{}).(RequestExtras)
+	return extras
+}
+
+// AppliesTo reports whether the extras are sent to target: its host is the
+// bookmark's host or a subdomain of it
+func (e RequestExtras) AppliesTo(target *url.URL) bool {
+	if e.Host == "" || target == nil {
+		return false
+	}
+	host := cf.NormalizeDomain(target.Hostname())
+	return host == e.Host || strings.HasSuffix(host, "."+e.Host)
+}
+
+// Apply sets the headers on req and merges the cookies into its Cookie header,
+// replacing cookies of the same name. Requests to other hosts are left alone, see
+// AppliesTo.
+func (e RequestExtras) Apply(req *http.Request) {
+	if !e.AppliesTo(req.URL) {
+		return
+	}
+	e.applyHeader(req.Header)
+}
+
+func (e RequestExtras) applyHeader(h http.Header) {
+	for name, value := range e.Headers {
+		h.Set(name, value)
+	}
+	if len(e.Cookies) == 0 {
+		return
+	}
+
+	override := make(map[string]bool, len(e.Cookies))
+	for _, cookie := range e.Cookies {
+		override[cookie.Name] = true
+	}
+	var parts []string
+	for _, line := range h.Values("Cookie") {
+		existing, err := http.ParseCookie(line)
+		if err != nil {
+			continue
+		}
+		for _, cookie := range existing {
+			if !override[cookie.Name] {
+				parts = append(parts, cookie.String())
+			}
+		}
+	}
+	for _, cookie := range e.Cookies {
+		parts = append(parts, cookie.String())
+	}
+	h.Set("Cookie", strings.Join(parts, "; "))
+}
+
+// ApplyToCollector applies the extras to every request c makes. Register it after
+// any CF bypass callbacks so these values are applied last.
+func (e RequestExtras) ApplyToCollector(c *colly.Collector) {
+	if e.IsZero() {
+		return
+	}
+	c.OnRequest(func(r *colly.Request) {
+		if e.AppliesTo(r.URL) {
+			e.applyHeader(*r.Headers)
+		}
+	})
+}
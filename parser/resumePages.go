@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResumeTempPages returns the pages an interrupted run left in a chapter temp
+// directory, keyed by page name without extension (eg: "003" -> "003.jpg"), so the
+// downloader only fetches what is missing.
+//
+// Pages are written under a temp name and renamed into place once complete (see
+// writePageFile), so however many were in flight when the run stopped, none is left
+// half written under its page name. Leftover temp files are deleted. The last page
+// is still fully decoded and deleted if that fails, as a guard for pages written
+// directly, the others are trusted as is to keep resuming fast.
+func ResumeTempPages(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == ComicInfoFileName {
+			continue
+		}
+		if strings.HasPrefix(name, pagePartPrefix) {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return nil, fmt.Errorf("failed to remove incomplete page %s: %w", name, err)
+			}
+			continue
+		}
+		if _, ok := leadingNumber(name); !ok {
+			continue
+		}
+		files = append(files, name)
+	}
+	SortPageFiles(files)
+
+	if len(files) > 0 {
+		last := files[len(files)-1]
+		if err := decodeImageFile(filepath.Join(dir, last)); err != nil {
+			log.Printf("[Resume] Last page %s is incomplete (%v), fetching it again", last, err)
+			if err := os.Remove(filepath.Join(dir, last)); err != nil {
+				return nil, fmt.Errorf("failed to remove incomplete page %s: %w", last, err)
+			}
+			files = files[:len(files)-1]
+		}
+	}
+
+	pages := make(map[string]string, len(files))
+	for _, file := range files {
+		pages[strings.TrimSuffix(file, filepath.Ext(file))] = file
+	}
+	return pages, nil
+}
+
+// decodeImageFile fully decodes an image file, reporting truncated or corrupt data
+func decodeImageFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, _, err = image.Decode(f)
+	return err
+}
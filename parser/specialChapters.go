@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// specialSortOffset puts specials after every numbered and season-prefixed chapter
+// in ChapterSortValue
+const specialSortOffset = 1e12
+
+var (
+	// specialLabelRe matches the words sites label extras and specials with
+	specialLabelRe = regexp.MustCompile(`(?i)\b(?:extras?|specials?|bonus|omake|side[ -]?stor(?:y|ies)|one[ -]?shot|afterword|illustrations?)\b`)
+	// numberedChapterRe matches a chapter or episode number in a label, which makes
+	// "Chapter 45.5 - Side Story" a numbered chapter rather than a special
+	numberedChapterRe = regexp.MustCompile(`(?i)\b(?:chapter|ch|episode|ep)\.?\s*\d`)
+	// specialChapterRe matches the filenames of SpecialChapterFilename
+	specialChapterRe = regexp.MustCompile(`(?i)^sp(\d+)\.cbz$`)
+)
+
+// IsSpecialChapter reports whether a chapter label names a special rather than a
+// numbered chapter: "Volume 3 Extra", "Special", "Omake 2". A label with a chapter
+// number in it ("Chapter 12 Special") is a numbered chapter.
+func IsSpecialChapter(label string) bool {
+	return specialLabelRe.MatchString(label) && !numberedChapterRe.MatchString(label)
+}
+
+// SpecialChapterFilename is the cbz filename of the n-th special of a series,
+// sp001.cbz for the first. Specials keep this form whatever the chapter filename
+// template is, ChapterSortValue relies on it to sort them last.
+func SpecialChapterFilename(n int) string {
+	return fmt.Sprintf("sp%03d.cbz", n)
+}
+
+// SpecialChapterNumber returns n for a filename of SpecialChapterFilename
+func SpecialChapterNumber(cbzName string) (int, bool) {
+	m := specialChapterRe.FindStringSubmatch(cbzName)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// splitSearchFraction is how far above the max height a cut may move looking
+	// for a blank row, as a fraction of the max height
+	splitSearchFraction = 0.25
+
+	// blankRowTolerance is the largest luminance spread (0-255) a row may have and
+	// still count as blank gutter space between panels
+	blankRowTolerance = 8
+)
+
+// SplitTallPages slices every image in dir taller than maxHeight into several
+// pages, then renumbers all pages (001, 002, ...) so reading order is kept. Cuts
+// are moved up to the nearest blank row where one exists, so panels and speech
+// bubbles are not split, otherwise a fixed maxHeight cut is used.
+//
+// Slices of PNG pages are written as PNG, everything else as JPEG. Pages that do
+// not need splitting keep their original bytes. maxHeight <= 0 does nothing.
+// Returns the number of pages in dir afterwards.
+func SplitTallPages(dir string, maxHeight int) (int, error) {
+	if maxHeight <= 0 {
+		return 0, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && entry.Name() != ComicInfoFileName {
+			files = append(files, entry.Name())
+		}
+	}
+	SortPageFiles(files)
+
+	// Write every output page under a staging name first, so renumbering never
+	// overwrites a page that has not been processed yet
+	var staged []string
+	split := 0
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		ext := strings.ToLower(filepath.Ext(file))
+
+		cfg, err := decodeImageConfig(path)
+		if err != nil || cfg.Height <= maxHeight {
+			stagedName := fmt.Sprintf(".split-%04d%s", len(staged), ext)
+			if err := os.Rename(path, filepath.Join(dir, stagedName)); err != nil {
+				return 0, fmt.Errorf("failed to stage %s: %w", file, err)
+			}
+			staged = append(staged, stagedName)
+			continue
+		}
+
+		img, err := imaging.Open(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+
+		outExt := ".jpg"
+		if ext == ".png" {
+			outExt = ".png"
+		}
+
+		cuts := stripCuts(img, maxHeight)
+		bounds := img.Bounds()
+		top := bounds.Min.Y
+		for _, cut := range cuts {
+			slice := imaging.Crop(img, image.Rect(bounds.Min.X, top, bounds.Max.X, cut))
+			stagedName := fmt.Sprintf(".split-%04d%s", len(staged), outExt)
+			var err error
+			if outExt == ".jpg" {
+				err = saveJPEG(slice, filepath.Join(dir, stagedName))
+			} else {
+				err = savePNG(slice, filepath.Join(dir, stagedName))
+			}
+			if err != nil {
+				return 0, fmt.Errorf("failed to save slice of %s: %w", file, err)
+			}
+			staged = append(staged, stagedName)
+			top = cut
+		}
+
+		if err := os.Remove(path); err != nil {
+			return 0, fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+		log.Printf("[Split] %s (%dx%d) split into %d pages", file, cfg.Width, cfg.Height, len(cuts))
+		split++
+	}
+
+	for i, stagedName := range staged {
+		final := fmt.Sprintf("%03d%s", i+1, filepath.Ext(stagedName))
+		if err := os.Rename(filepath.Join(dir, stagedName), filepath.Join(dir, final)); err != nil {
+			return 0, fmt.Errorf("failed to renumber %s: %w", stagedName, err)
+		}
+	}
+
+	if split > 0 {
+		log.Printf("[Split] Split %d tall pages, chapter now has %d pages", split, len(staged))
+	}
+	return len(staged), nil
+}
+
+// stripCuts returns the bottom edge of every slice for a tall image, the last cut
+// is always the bottom of the image
+func stripCuts(img image.Image, maxHeight int) []int {
+	gray := image.NewGray(img.Bounds())
+	draw.Draw(gray, gray.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	bounds := gray.Bounds()
+	minSlice := maxHeight - int(float64(maxHeight)*splitSearchFraction)
+
+	var cuts []int
+	top := bounds.Min.Y
+	for bounds.Max.Y-top > maxHeight {
+		cut := top + maxHeight
+		for y := top + maxHeight; y >= top+minSlice; y-- {
+			if blankRow(gray, y) {
+				cut = y
+				break
+			}
+		}
+		cuts = append(cuts, cut)
+		top = cut
+	}
+	return append(cuts, bounds.Max.Y)
+}
+
+// blankRow reports whether row y is a single flat colour (gutter between panels)
+func blankRow(gray *image.Gray, y int) bool {
+	bounds := gray.Bounds()
+	row := gray.Pix[(y-bounds.Min.Y)*gray.Stride : (y-bounds.Min.Y)*gray.Stride+bounds.Dx()]
+
+	lo, hi := row[0], row[0]
+	for _, v := range row[1:] {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+		if int(hi)-int(lo) > blankRowTolerance {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"log"
+	"sync"
+)
+
+var (
+	verboseMu sync.RWMutex
+	verbose   bool
+)
+
+// SetVerboseLogging turns per-entry debug logging on or off. It is off by default,
+// a series with thousands of chapters would otherwise log a line for every one.
+func SetVerboseLogging(on bool) {
+	verboseMu.Lock()
+	verbose = on
+	verboseMu.Unlock()
+}
+
+// VerboseLogging reports whether per-entry debug logging is on
+func VerboseLogging() bool {
+	verboseMu.RLock()
+	defer verboseMu.RUnlock()
+	return verbose
+}
+
+// Debugf logs like log.Printf when verbose logging is on and does nothing otherwise
+func Debugf(format string, args ...any) {
+	if VerboseLogging() {
+		log.Printf(format, args...)
+	}
+}
@@ -0,0 +1,215 @@
+package parser
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// shortPageRatio flags a chapter with fewer pages than this fraction of the
+	// median, eg: 4 pages when the other chapters have 20
+	shortPageRatio = 0.4
+
+	// shortPageMinMedian keeps series with naturally tiny chapters (4koma, covers)
+	// from being flagged over a page or two of difference
+	shortPageMinMedian = 6
+
+	// jpegTailWindow is how close to the end of a JPEG its end of image marker must
+	// be, some encoders pad the file after it
+	jpegTailWindow = 1024
+)
+
+// IsShortOfMedian reports whether a chapter of pages pages has far fewer than the
+// median of the chapters it is compared with, which usually means the scraper only
+// caught part of it (eg: lazy loading was cut short)
+func IsShortOfMedian(pages, median int) bool {
+	return median >= shortPageMinMedian && float64(pages) < float64(median)*shortPageRatio
+}
+
+// ChapterIssue is a problem VerifyLibrary found with one chapter
+type ChapterIssue struct {
+	Chapter string // cbz name
+	Problem string
+}
+
+// LibraryReport is what VerifyLibrary found in a series folder
+type LibraryReport struct {
+	Chapters int // how many CBZ files were checked
+	Issues   []ChapterIssue
+
+	// Unlisted is how many chapters have no image manifest, their page count was
+	// only compared with the other chapters and not with what the site listed
+	Unlisted int
+}
+
+// VerifyLibrary opens every CBZ in location and reports archives that cannot be
+// read, zero-byte or truncated pages, and chapters with fewer pages than the site
+// listed (from the image manifest, when one was written) or far fewer than the
+// other chapters have. Every page is read in full, so a large library takes a while;
+// ctx stops the scan between chapters. The manifest is only written with the
+// write_image_manifest setting on, Unlisted counts the chapters checked without one.
+func VerifyLibrary(ctx context.Context, location string) (LibraryReport, error) {
+	var report LibraryReport
+	location, err := ExpandPath(location)
+	if err != nil {
+		return report, err
+	}
+	chapters, err := LocalChapterList(location)
+	if err != nil {
+		return report, err
+	}
+	SortChaptersNumeric(chapters)
+
+	pageCounts := make(map[string]int, len(chapters))
+	for _, cbzName := range chapters {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.Chapters++
+
+		cbzPath := filepath.Join(location, cbzName)
+		pages, problems := VerifyCbz(cbzPath)
+		for _, problem := range problems {
+			report.Issues = append(report.Issues, ChapterIssue{Chapter: cbzName, Problem: problem})
+		}
+		if pages < 0 {
+			continue
+		}
+		pageCounts[cbzName] = pages
+
+		listed, err := manifestPageCount(cbzPath)
+		if err != nil {
+			report.Unlisted++
+		} else if pages < listed {
+			report.Issues = append(report.Issues, ChapterIssue{
+				Chapter: cbzName,
+				Problem: fmt.Sprintf("has %d of the %d pages the site listed", pages, listed),
+			})
+		}
+	}
+
+	if median, ok := medianPageCount(pageCounts); ok {
+		for _, cbzName := range chapters {
+			if pages, read := pageCounts[cbzName]; read && IsShortOfMedian(pages, median) {
+				report.Issues = append(report.Issues, ChapterIssue{
+					Chapter: cbzName,
+					Problem: fmt.Sprintf("has only %d pages where the other chapters have %d", pages, median),
+				})
+			}
+		}
+	}
+	return report, nil
+}
+
+// VerifyCbz reads every page of the CBZ at cbzPath and returns how many it has and
+// what is wrong with them. Pages is -1 when the archive itself cannot be read.
+func VerifyCbz(cbzPath string) (pages int, problems []string) {
+	zr, err := zip.OpenReader(cbzPath)
+	if err != nil {
+		return -1, []string{fmt.Sprintf("corrupted archive: %v", err)}
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || path.Base(f.Name) == ComicInfoFileName {
+			continue
+		}
+		pages++
+		if problem := verifyPage(f); problem != "" {
+			problems = append(problems, fmt.Sprintf("page %s %s", f.Name, problem))
+		}
+	}
+	if pages == 0 {
+		problems = append(problems, "has no pages")
+	}
+	return pages, problems
+}
+
+// verifyPage reads a page entry in full, so the archive checksum is verified too, and
+// returns what is wrong with it or "" when it looks whole
+func verifyPage(f *zip.File) string {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Sprintf("cannot be read: %v", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Sprintf("is damaged: %v", err)
+	}
+	if len(data) == 0 {
+		return "is empty (zero bytes)"
+	}
+
+	format, err := detectImageFormat(data)
+	if err != nil {
+		return "is not an image"
+	}
+	if !imageComplete(format, data) {
+		return fmt.Sprintf("is a truncated %s", strings.ToUpper(format))
+	}
+	return ""
+}
+
+// imageComplete reports whether data of the given format ends the way a whole file
+// of that format does, without decoding it
+func imageComplete(format string, data []byte) bool {
+	switch format {
+	case "jpeg":
+		tail := data[max(0, len(data)-jpegTailWindow):]
+		return bytes.Contains(tail, []byte{0xFF, 0xD9})
+	case "png":
+		// The IEND chunk: its length, type and CRC close every PNG
+		tail := data[max(0, len(data)-64):]
+		return bytes.Contains(tail, []byte("IEND"))
+	case "gif":
+		return bytes.HasSuffix(bytes.TrimRight(data, "\x00"), []byte{0x3B})
+	case "webp":
+		return int64(binary.LittleEndian.Uint32(data[4:8]))+8 <= int64(len(data))
+	}
+	return true
+}
+
+// manifestPageCount returns how many image URLs the manifest sidecar of the CBZ at
+// cbzPath lists, the pages the site had when the chapter was downloaded
+func manifestPageCount(cbzPath string) (int, error) {
+	f, err := os.Open(ImageManifestPath(cbzPath))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) != "" {
+			count++
+		}
+	}
+	return count, scanner.Err()
+}
+
+// medianPageCount returns the median of the page counts, when there are enough
+// chapters for it to mean anything
+func medianPageCount(pageCounts map[string]int) (int, bool) {
+	if len(pageCounts) < 3 {
+		return 0, false
+	}
+	counts := make([]int, 0, len(pageCounts))
+	for _, pages := range pageCounts {
+		counts = append(counts, pages)
+	}
+	sort.Ints(counts)
+	return counts[len(counts)/2], true
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+var (
+	keepLosslessMu sync.RWMutex
+	keepLossless   bool
+)
+
+// SetKeepLosslessWebP makes ConvertImageToJPEG save lossless WebP pages as PNG
+// instead of JPEG. Off by default, every page then ends up a JPEG.
+func SetKeepLosslessWebP(on bool) {
+	keepLosslessMu.Lock()
+	keepLossless = on
+	keepLosslessMu.Unlock()
+}
+
+// KeepLosslessWebP reports whether lossless WebP pages are kept lossless
+func KeepLosslessWebP() bool {
+	keepLosslessMu.RLock()
+	defer keepLosslessMu.RUnlock()
+	return keepLossless
+}
+
+// IsLosslessWebP reports whether data is a WebP whose image is VP8L (lossless)
+// coded. Simple files start with the image chunk, extended (VP8X) files are walked
+// until the image chunk is found. Lossy VP8 images, with or without an ALPH
+// chunk, and anything that is not a WebP return false.
+func IsLosslessWebP(data []byte) bool {
+	if len(data) < 16 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return false
+	}
+
+	for offset := 12; offset+8 <= len(data); {
+		switch string(data[offset : offset+4]) {
+		case "VP8L":
+			return true
+		case "VP8 ":
+			return false
+		}
+
+		// Chunk payloads are padded to an even size
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		if size < 0 || size > len(data) {
+			return false
+		}
+		offset += 8 + size + size%2
+	}
+	return false
+}
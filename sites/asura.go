@@ -2,18 +2,22 @@ package sites
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"regexp"
 	"strings"
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/parser"
 )
 
 type AsuraSite struct{}
 
 var _ downloader.SitePlugin = (*AsuraSite)(nil)
+var _ downloader.NativeImageSite = (*AsuraSite)(nil)
 
 func (a *AsuraSite) GetSiteName() string { return "asurascans" }
 func (a *AsuraSite) GetDomain() string   { return "asurascans.com" }
@@ -47,13 +51,24 @@ func (a *AsuraSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMe
 func (a *AsuraSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
 	return &downloader.ImageExtractionMethod{
 		// No WaitSelector → HTTP path (plain Astro SSR, no JS needed).
-		// Image URLs are embedded as JSON in astro-island props in the SSR HTML.
-		Type:         "custom",
-		WaitSelector: "",
-		CustomParser: parseAsuraImages,
+		// Image URLs are embedded as JSON in astro-island props or __NEXT_DATA__
+		// in the SSR HTML, the browser is only used when neither parses.
+		Type:               "custom",
+		ImageHosts:         siteImageHosts(nil, a.GetSiteName()),
+		NextPageSelector:   siteReaderNextPage(nil, a.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(nil, a.GetSiteName()),
+		WaitSelector:       "",
+		BrowserFallback:    true,
+		CustomParser:       parseAsuraImages,
 	}
 }
 
+// KeepNativeImageFormat keeps asura's WebP pages as WebP inside the CBZ when the
+// keep_native_image_format setting is on
+func (a *AsuraSite) KeepNativeImageFormat() bool {
+	return config.LoadSettings().KeepNativeImageFormat
+}
+
 func (a *AsuraSite) Debugger() *downloader.Debugger {
 	return &downloader.Debugger{
 		SaveHTML: false,
@@ -61,7 +76,7 @@ func (a *AsuraSite) Debugger() *downloader.Debugger {
 	}
 }
 
-func AsuraDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
+func AsuraDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
 	cfg := &downloader.DownloadConfig{
 		Manga:            manga,
 		Site:             &AsuraSite{},
@@ -106,13 +121,23 @@ var (
 	// Extracts each chapter number from the chapters array.
 	// Format: &quot;number&quot;:[0,93]
 	asuraChapterNumInPropRe = regexp.MustCompile(`&quot;number&quot;:\[0,(\d+)\]`)
+
+	// Chapters of later seasons carry a string slug instead of a plain number and are
+	// linked as /comics/{series_slug}/chapter/s2-45.
+	// Formats: &quot;number&quot;:[0,&quot;s2-45&quot;] and .../chapter/s2-45&quot;
+	asuraChapterSlugInPropRe = regexp.MustCompile(`&quot;number&quot;:\[0,&quot;([^&]+)&quot;\]`)
+	asuraChapterLinkRe       = regexp.MustCompile(`/comics/[^/&"]+/chapter/([^/&"?#]+)`)
+
+	// Splits a chapter slug into an optional season and the chapter number.
+	// Matches "45", "45.5", "s2-45", "s02-45.5", "season-2-chapter-45", "s2-ch45".
+	asuraChapterSlugRe = regexp.MustCompile(`(?i)^(?:s(?:eason)?-?(\d+)-)?(?:ch(?:apter)?-?)?(\d+(?:\.\d+)?)$`)
 )
 
 func parseAsuraChapters(html string) (map[string]string, error) {
 	// Find the ChapterListReact props blob
 	m := asuraChapterListPropsRe.FindStringSubmatch(html)
 	if len(m) < 2 {
-		return nil, fmt.Errorf("asura: ChapterListReact props not found in HTML")
+		return nil, fmt.Errorf("asura: ChapterListReact props not found in HTML: %w", downloader.ErrSiteChanged)
 	}
 	props := m[1]
 
@@ -128,19 +153,24 @@ func parseAsuraChapters(html string) (map[string]string, error) {
 	seriesSlug := pm[1]
 	log.Printf("[Asura] Series slug: %s", seriesSlug)
 
-	// Extract all chapter numbers
-	numMatches := asuraChapterNumInPropRe.FindAllStringSubmatch(props, -1)
-	if len(numMatches) == 0 {
+	// Extract all chapter numbers, plain integers first, then season-prefixed slugs
+	var slugs []string
+	for _, re := range []*regexp.Regexp{asuraChapterNumInPropRe, asuraChapterSlugInPropRe, asuraChapterLinkRe} {
+		for _, nm := range re.FindAllStringSubmatch(props, -1) {
+			slugs = append(slugs, nm[1])
+		}
+	}
+	if len(slugs) == 0 {
 		return nil, fmt.Errorf("asura: no chapter numbers found in props")
 	}
 
 	result := make(map[string]string)
 	seen := make(map[string]bool)
 
-	for _, nm := range numMatches {
-		numStr := nm[1]
-		filename := asuraChapterFilenameFromInt(numStr)
-		if filename == "unknown.cbz" {
+	for _, slug := range slugs {
+		filename, ok := asuraChapterFilenameFromSlug(slug)
+		if !ok {
+			log.Printf("[Asura] Skipping chapter with unrecognised number: %s", slug)
 			continue
 		}
 		if seen[filename] {
@@ -148,13 +178,13 @@ func parseAsuraChapters(html string) (map[string]string, error) {
 		}
 		seen[filename] = true
 
-		url := fmt.Sprintf("https://asurascans.com/comics/%s/chapter/%s", seriesSlug, numStr)
-		log.Printf("[Asura] Found chapter: %s -> %s", filename, url)
+		url := fmt.Sprintf("https://asurascans.com/comics/%s/chapter/%s", seriesSlug, slug)
+		parser.Debugf("[Asura] Found chapter: %s -> %s", filename, url)
 		result[filename] = url
 	}
 
 	if len(result) == 0 {
-		return nil, fmt.Errorf("asura: no chapters found")
+		return nil, fmt.Errorf("asura: %w", downloader.ErrNoChaptersFound)
 	}
 	log.Printf("[Asura] Found %d chapters", len(result))
 	return result, nil
@@ -190,10 +220,20 @@ var (
 )
 
 func parseAsuraImages(html string) ([]string, error) {
+	// Next.js builds of the reader embed the pages in __NEXT_DATA__
+	if jsonText, err := extractNextDataJSON(html); err == nil {
+		urls, err := asuraImagesFromNextData(jsonText)
+		if err == nil && len(urls) > 0 {
+			log.Printf("[Asura] Found %d images in __NEXT_DATA__", len(urls))
+			return urls, nil
+		}
+		log.Printf("[Asura] __NEXT_DATA__ has no chapter images (%v), trying astro props", err)
+	}
+
 	// Find the ChapterReader props blob
 	pm := asuraReaderPropsRe.FindStringSubmatch(html)
 	if len(pm) < 2 {
-		return nil, fmt.Errorf("asura: ChapterReader props not found in HTML")
+		return nil, fmt.Errorf("asura: ChapterReader props not found in HTML: %w", downloader.ErrSiteChanged)
 	}
 
 	// Unescape HTML entities so the URL regex can match
@@ -207,7 +247,7 @@ func parseAsuraImages(html string) ([]string, error) {
 		unescaped := strings.ReplaceAll(html, "&quot;", `"`)
 		matches = asuraCDNImageRe.FindAllString(unescaped, -1)
 		if len(matches) == 0 {
-			return nil, fmt.Errorf("asura: no chapter images found in HTML")
+			return nil, fmt.Errorf("asura: no chapter images found in HTML: %w", downloader.ErrSiteChanged)
 		}
 	}
 
@@ -226,15 +266,64 @@ func parseAsuraImages(html string) ([]string, error) {
 	return urls, nil
 }
 
+// asuraImagesFromNextData walks the __NEXT_DATA__ JSON token by token, so page order
+// is kept (decoding into maps would lose it), and returns every string value that is
+// a chapter image URL on the asura CDN
+func asuraImagesFromNextData(jsonText string) ([]string, error) {
+	dec := json.NewDecoder(strings.NewReader(jsonText))
+
+	seen := make(map[string]bool)
+	var urls []string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("asura: invalid __NEXT_DATA__ JSON: %w", err)
+		}
+
+		value, ok := tok.(string)
+		if !ok || seen[value] {
+			continue
+		}
+		if asuraCDNImageRe.FindString(value) == value {
+			seen[value] = true
+			urls = append(urls, value)
+		}
+	}
+	return urls, nil
+}
+
 // ---------------- HELPERS ----------------
 
-// asuraChapterFilename converts a chapter number string (e.g. "92", "92.5") to
-// a zero-padded CBZ filename (e.g. "ch092.cbz", "ch092.5.cbz").
+// asuraChapterFilename converts a chapter number string (e.g. "92", "92.5", "s2-45")
+// to a zero-padded CBZ filename (e.g. "ch092.cbz", "ch092.5.cbz", "s02ch045.cbz").
 func asuraChapterFilename(numStr string) string {
-	if numStr == "" {
-		return "unknown.cbz"
+	if filename, ok := asuraChapterFilenameFromSlug(numStr); ok {
+		return filename
 	}
-	return asuraChapterFilenameFromInt(numStr)
+	return "unknown.cbz"
+}
+
+// asuraChapterFilenameFromSlug converts a chapter slug from a chapter URL to a CBZ
+// filename. Plain numbers use the chapter filename template; season-prefixed slugs
+// get the season in front (e.g. "s2-45" -> "s02ch045.cbz") because chapter numbers
+// may restart each season. The season prefix sorts after every unprefixed chapter
+// and in season order, so season names always keep that form.
+func asuraChapterFilenameFromSlug(slug string) (string, bool) {
+	m := asuraChapterSlugRe.FindStringSubmatch(strings.TrimSpace(slug))
+	if m == nil {
+		return "", false
+	}
+	if m[1] == "" {
+		return asuraChapterFilenameFromInt(m[2]), true
+	}
+	main, part, _ := strings.Cut(m[2], ".")
+	if part != "" {
+		part = "." + part
+	}
+	return fmt.Sprintf("s%02sch%03s%s.cbz", m[1], main, part), true
 }
 
 func asuraChapterFilenameFromInt(numStr string) string {
@@ -242,10 +331,6 @@ func asuraChapterFilenameFromInt(numStr string) string {
 		return "unknown.cbz"
 	}
 	// Handle decimal chapter numbers like "92.5"
-	main, part := numStr, ""
-	if idx := strings.IndexByte(numStr, '.'); idx >= 0 {
-		main = numStr[:idx]
-		part = numStr[idx:]
-	}
-	return fmt.Sprintf("ch%03s%s.cbz", main, part)
+	main, part, _ := strings.Cut(numStr, ".")
+	return parser.ChapterFilename(main, part)
 }
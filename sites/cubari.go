@@ -13,6 +13,7 @@ import (
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/parser"
 )
 
 type CubariSite struct{}
@@ -46,30 +47,34 @@ func (s *CubariSite) NormalizeChapterFilename(chapterData map[string]string) str
 	}
 	num, _ := strconv.ParseFloat(ch, 64)
 	if num == float64(int(num)) {
-		return fmt.Sprintf("ch%03d.cbz", int(num))
+		return parser.ChapterFilename(strconv.Itoa(int(num)), "")
 	}
-	return fmt.Sprintf("ch%03.1f.cbz", num)
+	main, part, _ := strings.Cut(strconv.FormatFloat(num, 'f', 1, 64), ".")
+	return parser.ChapterFilename(main, part)
 }
 
 func (s *CubariSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
 	return &downloader.ChapterExtractionMethod{
 		Type:         "custom",
 		WaitSelector: "",
-		CustomParser: func(html string) (map[string]string, error) {
+		CustomParserContext: func(ctx context.Context, html string) (map[string]string, error) {
 			var dbg *downloader.Debugger
 			if d, ok := any(s).(downloader.DebugSite); ok {
 				dbg = d.Debugger()
 			}
-			return parseCubariChapters(html, dbg)
+			return parseCubariChapters(ctx, html, dbg)
 		},
 	}
 }
 
 func (s *CubariSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
 	return &downloader.ImageExtractionMethod{
-		Type:         "custom",
-		WaitSelector: "",
-		CustomParser: parseCubariImages,
+		Type:               "custom",
+		ImageHosts:         siteImageHosts(nil, s.GetSiteName()),
+		NextPageSelector:   siteReaderNextPage(nil, s.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(nil, s.GetSiteName()),
+		WaitSelector:       "",
+		CustomParser:       parseCubariImages,
 	}
 }
 
@@ -85,7 +90,7 @@ func (s *CubariSite) Debugger() *downloader.Debugger {
 // Download entrypoint
 // -------------------------
 
-func CubariDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
+func CubariDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
 	site := &CubariSite{}
 
 	cfg := &downloader.DownloadConfig{
@@ -102,7 +107,7 @@ func CubariDownloadChapters(ctx context.Context, manga *config.Bookmarks, progre
 // Chapter extraction
 // -------------------------
 
-func parseCubariChapters(html string, dbg *downloader.Debugger) (map[string]string, error) {
+func parseCubariChapters(ctx context.Context, html string, dbg *downloader.Debugger) (map[string]string, error) {
 	// Try normal Cubari series first
 	jsonText, err := extractNextDataJSON(html)
 	if err == nil {
@@ -123,7 +128,7 @@ func parseCubariChapters(html string, dbg *downloader.Debugger) (map[string]stri
 		return nil, fmt.Errorf("Cubari: failed to create executor: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 
 	rawJSON, err := exec.FetchHTML(ctx, gistURL, "")
@@ -148,7 +153,7 @@ func parseCubariGistJSON(jsonText string) (map[string]string, error) {
 
 	chaptersRaw, ok := root["chapters"].(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("Cubari: chapters not found in gist JSON")
+		return nil, fmt.Errorf("Cubari: chapters not found in gist JSON: %w", downloader.ErrSiteChanged)
 	}
 
 	result := make(map[string]string)
@@ -174,16 +179,16 @@ func parseCubariGistJSON(jsonText string) (map[string]string, error) {
 			// Build full API URL
 			chapterURL := "https://cubari.moe" + apiPath
 
-			filename := fmt.Sprintf("ch%03d.cbz", atoiSafe(chapterKey))
+			filename := parser.ChapterFilename(strconv.Itoa(atoiSafe(chapterKey)), "")
 			result[filename] = chapterURL
 
-			log.Printf("[Cubari] Found chapter %s → %s", filename, chapterURL)
+			parser.Debugf("[Cubari] Found chapter %s → %s", filename, chapterURL)
 			break
 		}
 	}
 
 	if len(result) == 0 {
-		return nil, fmt.Errorf("Cubari: no usable chapters found in gist JSON")
+		return nil, fmt.Errorf("Cubari: no usable chapters found in gist JSON: %w", downloader.ErrNoChaptersFound)
 	}
 
 	return result, nil
@@ -201,7 +206,7 @@ func parseCubariSeriesJSON(jsonText string) (map[string]string, error) {
 
 	chaptersRaw := dig(root, "props", "pageProps", "series", "chapters")
 	if chaptersRaw == nil {
-		return nil, fmt.Errorf("Cubari: chapters not found in series JSON")
+		return nil, fmt.Errorf("Cubari: chapters not found in series JSON: %w", downloader.ErrSiteChanged)
 	}
 
 	chapters, ok := chaptersRaw.(map[string]interface{})
@@ -221,10 +226,10 @@ func parseCubariSeriesJSON(jsonText string) (map[string]string, error) {
 		id := fmt.Sprintf("%v", ch["id"])
 
 		chapterURL := "https://cubari.moe/read/" + id + "/"
-		filename := fmt.Sprintf("ch%03d.cbz", atoiSafe(chapterNum))
+		filename := parser.ChapterFilename(strconv.Itoa(atoiSafe(chapterNum)), "")
 
 		result[filename] = chapterURL
-		log.Printf("[Cubari] Found chapter %s → %s", filename, chapterURL)
+		parser.Debugf("[Cubari] Found chapter %s → %s", filename, chapterURL)
 	}
 
 	return result, nil
@@ -242,7 +247,7 @@ func parseCubariImages(html string) ([]string, error) {
 	}
 
 	if len(arr) == 0 {
-		return nil, fmt.Errorf("Cubari: no images found in API response")
+		return nil, fmt.Errorf("Cubari: no images found in API response: %w", downloader.ErrSiteChanged)
 	}
 
 	log.Printf("[Cubari] Found %d images", len(arr))
@@ -279,7 +284,7 @@ func parseNormalCubariImages(jsonText string) ([]string, error) {
 	}
 
 	if firstGroup == nil {
-		return nil, fmt.Errorf("Cubari: no image groups found")
+		return nil, fmt.Errorf("Cubari: no image groups found: %w", downloader.ErrSiteChanged)
 	}
 
 	var images []string
@@ -327,7 +332,7 @@ func parseGistChapterImages(jsonText string, chapterNum string) ([]string, error
 	}
 
 	if firstGroup == nil {
-		return nil, fmt.Errorf("Cubari: no image groups found in gist chapter")
+		return nil, fmt.Errorf("Cubari: no image groups found in gist chapter: %w", downloader.ErrSiteChanged)
 	}
 
 	var images []string
@@ -341,20 +346,11 @@ func parseGistChapterImages(jsonText string, chapterNum string) ([]string, error
 	return images, nil
 }
 
-func extractNextDataJSON(html string) (string, error) {
-	re := regexp.MustCompile(`<script id="__NEXT_DATA__" type="application/json">(.+?)</script>`)
-	m := re.FindStringSubmatch(html)
-	if len(m) < 2 {
-		return "", fmt.Errorf("Cubari: __NEXT_DATA__ JSON not found")
-	}
-	return m[1], nil
-}
-
 func extractGistRawURL(html string) (string, error) {
 	re := regexp.MustCompile(`read/gist/([A-Za-z0-9\-_]+)`)
 	m := re.FindStringSubmatch(html)
 	if len(m) < 2 {
-		return "", fmt.Errorf("Cubari: gist ID not found")
+		return "", fmt.Errorf("Cubari: gist ID not found: %w", downloader.ErrSiteChanged)
 	}
 
 	encoded := m[1]
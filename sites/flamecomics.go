@@ -10,6 +10,7 @@ import (
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/parser"
 )
 
 type FlameComicsSite struct{}
@@ -48,7 +49,7 @@ func (s *FlameComicsSite) NormalizeChapterFilename(data map[string]string) strin
 		ch = extractFlameChapterNumber(data["url"])
 	}
 
-	return fmt.Sprintf("ch%03d.cbz", ch)
+	return parser.ChapterFilename(strconv.Itoa(ch), "")
 }
 
 func (s *FlameComicsSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
@@ -60,8 +61,11 @@ func (s *FlameComicsSite) GetChapterExtractionMethod() *downloader.ChapterExtrac
 
 func (s *FlameComicsSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
 	return &downloader.ImageExtractionMethod{
-		Type:         "custom",
-		CustomParser: parseFlameComicsImages,
+		Type:               "custom",
+		ImageHosts:         siteImageHosts(nil, s.GetSiteName()),
+		NextPageSelector:   siteReaderNextPage(nil, s.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(nil, s.GetSiteName()),
+		CustomParser:       parseFlameComicsImages,
 	}
 }
 
@@ -77,7 +81,7 @@ func (s *FlameComicsSite) Debugger() *downloader.Debugger {
 // Download entrypoint
 // -------------------------
 
-func FlameComicsDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
+func FlameComicsDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
 	site := &FlameComicsSite{}
 
 	cfg := &downloader.DownloadConfig{
@@ -117,7 +121,7 @@ func parseFlameComicsChapters(html string) (map[string]string, error) {
 	re := regexp.MustCompile(`<script id="__NEXT_DATA__" type="application/json">(.+?)</script>`)
 	matches := re.FindStringSubmatch(html)
 	if len(matches) < 2 {
-		return nil, fmt.Errorf("FlameComics: __NEXT_DATA__ script not found")
+		return nil, fmt.Errorf("FlameComics: __NEXT_DATA__ script not found: %w", downloader.ErrSiteChanged)
 	}
 
 	// Parse the JSON
@@ -143,12 +147,12 @@ func parseFlameComicsChapters(html string) (map[string]string, error) {
 		// FlameComics uses /series/{series_id}/{token} for chapter pages
 		url := fmt.Sprintf("https://flamecomics.xyz/series/%d/%s", seriesID, ch.Token)
 
-		filename := fmt.Sprintf("ch%03d.cbz", chapterNum)
+		filename := parser.ChapterFilename(strconv.Itoa(chapterNum), "")
 		result[filename] = url
 	}
 
 	if len(result) == 0 {
-		return nil, fmt.Errorf("FlameComics: no chapters found in __NEXT_DATA__")
+		return nil, fmt.Errorf("FlameComics: %w in __NEXT_DATA__", downloader.ErrNoChaptersFound)
 	}
 
 	return result, nil
@@ -271,7 +275,7 @@ func parseFlameComicsImages(html string) ([]string, error) {
 	}
 
 	if len(images) == 0 {
-		return nil, fmt.Errorf("FlameComics: no images found - series may be dropped or unavailable")
+		return nil, fmt.Errorf("FlameComics: no images found - series may be dropped or unavailable: %w", downloader.ErrSiteChanged)
 	}
 
 	return images, nil
@@ -12,10 +12,22 @@ import (
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/models"
+	"kansho/parser"
 )
 
 // KunmangaSite implements the SitePlugin interface for kunmanga sites
-type KunmangaSite struct{}
+type KunmangaSite struct {
+	// sitesConfig is the site config pinned when the download started, nil uses
+	// the current config
+	sitesConfig *models.SitesConfig
+}
+
+// NewKunmangaSite returns a KunmangaSite pinned to the current site config, selector
+// overrides reloaded while it is in use do not affect it
+func NewKunmangaSite() *KunmangaSite {
+	return &KunmangaSite{sitesConfig: pinSitesConfig()}
+}
 
 // Ensure KunmangaSite implements SitePlugin
 var _ downloader.SitePlugin = (*KunmangaSite)(nil)
@@ -23,6 +35,9 @@ var _ downloader.SitePlugin = (*KunmangaSite)(nil)
 // Ensure KunmangaSite implements ManualCFPromptSite
 var _ downloader.ManualCFPromptSite = (*KunmangaSite)(nil)
 
+// Ensure KunmangaSite implements NativeImageSite
+var _ downloader.NativeImageSite = (*KunmangaSite)(nil)
+
 // GetSiteName returns the site identifier
 func (k *KunmangaSite) GetSiteName() string {
 	return "kunmanga"
@@ -44,6 +59,13 @@ func (k *KunmangaSite) NeedsCFBypass() bool {
 // trigger a CF challenge — the cookies are needed for image CDN requests.
 func (k *KunmangaSite) NeedsManualCFPrompt() bool { return true }
 
+// KeepNativeImageFormat follows the keep_native_image_format setting, when on the
+// HTTP fallback path stores pages in their original format, matching the browser
+// download path which already writes the raw image bytes.
+func (k *KunmangaSite) KeepNativeImageFormat() bool {
+	return config.LoadSettings().KeepNativeImageFormat
+}
+
 // GetChapterExtractionMethod returns HOW to extract chapters.
 // The new kunmanga.online site loads chapters dynamically via an internal
 // JSON API, so we use the "api" extraction type to fetch them directly.
@@ -56,20 +78,25 @@ func (k *KunmangaSite) GetChapterExtractionMethod() *downloader.ChapterExtractio
 	}
 }
 
+// kunmangaDefaultSelectors are used unless overridden in the site config
+var kunmangaDefaultSelectors = models.SiteSelectors{
+	Image:          "div.reading-content img",
+	ImageAttribute: downloader.MadaraImageAttributes,
+}
+
 // GetImageExtractionMethod returns HOW to extract images
 // Downloader will execute this - we just provide the JavaScript
 func (k *KunmangaSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	selectors := siteSelectors(k.sitesConfig, k.GetSiteName(), kunmangaDefaultSelectors)
+
 	return &downloader.ImageExtractionMethod{
-		Type:         "javascript",
-		WaitSelector: "div.reading-content img",
-		JavaScript: `
-			[...document.querySelectorAll('div.reading-content img')]
-			.map(img => {
-				const src = img.src;
-				return src.trim();
-			})
-			.filter(src => src !== '')
-		`,
+		Type:               "javascript",
+		ImageHosts:         siteImageHosts(k.sitesConfig, k.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(k.sitesConfig, k.GetSiteName()),
+		Selector:           selectors.Image,
+		Attribute:          selectors.ImageAttribute,
+		WaitSelector:       selectors.Image,
+		JavaScript:         selectorImageJS(selectors),
 	}
 }
 
@@ -110,7 +137,7 @@ func (k *KunmangaSite) fetchChaptersViaAPI(baseURL string, client *downloader.AP
 		apiURL := fmt.Sprintf("%s?page=%d", apiBase, page)
 
 		var resp kunmangaChapterResponse
-		if err := client.FetchJSON(context.Background(), apiURL, &resp); err != nil {
+		if err := client.FetchJSON(client.Context(), apiURL, &resp); err != nil {
 			return nil, fmt.Errorf("[kunmanga] failed to fetch page %d: %w", page, err)
 		}
 
@@ -188,7 +215,7 @@ func (k *KunmangaSite) NormalizeChapterFilename(data map[string]string) string {
 	matches := re.FindStringSubmatch(chapterURL)
 	if len(matches) == 0 {
 		log.Printf("[Kunmanga] WARNING: Could not parse chapter number from URL: %s", chapterURL)
-		return "ch000.cbz"
+		return parser.ChapterFilename("0", "")
 	}
 
 	mainNum := matches[1] // main chapter number
@@ -201,19 +228,14 @@ func (k *KunmangaSite) NormalizeChapterFilename(data map[string]string) string {
 	// Remove leading dot (if any)
 	normalizedPart = strings.TrimPrefix(normalizedPart, ".")
 
-	// Final filename: pad main number to 3 digits
-	filename := fmt.Sprintf("ch%03s", mainNum)
-	if normalizedPart != "" {
-		filename += "." + normalizedPart
-	}
-
-	log.Printf("[Kunmanga] Normalized: %s → %s.cbz", chapterURL, filename)
-	return filename + ".cbz"
+	filename := parser.ChapterFilename(mainNum, normalizedPart)
+	parser.Debugf("[Kunmanga] Normalized: %s → %s", chapterURL, filename)
+	return filename
 }
 
 // KunmangaDownloadChapters is the entry point called by the download queue
-func KunmangaDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
-	site := &KunmangaSite{}
+func KunmangaDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
+	site := NewKunmangaSite()
 
 	cfg := &downloader.DownloadConfig{
 		Manga:            manga,
@@ -5,17 +5,102 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/parser"
 )
 
 const (
 	mangadexAPIBase = "https://api.mangadex.org"
+
+	// mangadexImageConcurrency is how many pages of a chapter are fetched at once
+	// from MangaDex@Home nodes
+	mangadexImageConcurrency = 4
+
+	// mangadexFeedConcurrency is how many feed pages are fetched at once once the
+	// first page gave the total, unless the settings say otherwise
+	mangadexFeedConcurrency = 4
+)
+
+// MangaDex rate limit endpoints, see https://api.mangadex.org/docs/2-limitations/
+const (
+	MangadexEndpointAPI    = "api"     // global API limit per IP
+	MangadexEndpointAtHome = "at-home" // GET /at-home/server/{id}
+	MangadexEndpointImage  = "image"   // page requests to MangaDex@Home nodes
 )
 
+// MangadexBudget is the number of requests allowed within a rolling window
+type MangadexBudget struct {
+	Requests int
+	Window   time.Duration
+}
+
+// mangadexBudgets are the documented limits (5/s for the API, 40/min for @Home
+// server lookups). Image nodes are not covered by the API limits, the budget there
+// only keeps parallel downloads polite.
+var mangadexBudgets = map[string]MangadexBudget{
+	MangadexEndpointAPI:    {Requests: 5, Window: time.Second},
+	MangadexEndpointAtHome: {Requests: 40, Window: time.Minute},
+	MangadexEndpointImage:  {Requests: 10, Window: time.Second},
+}
+
+// MangadexRateLimiter enforces a rolling window budget per MangaDex endpoint. Unlike
+// the generic ticker limiter, callers may wait concurrently: each Wait reserves the
+// earliest slot that keeps the endpoint within its budget, so up to Requests calls
+// can proceed at once and the rest are spread over the window.
+type MangadexRateLimiter struct {
+	mu      sync.Mutex
+	budgets map[string]MangadexBudget
+	granted map[string][]time.Time // reserved start times, oldest first, at most Requests long
+}
+
+// NewMangadexRateLimiter creates a limiter for the given budgets. Endpoints without a
+// budget are not limited.
+func NewMangadexRateLimiter(budgets map[string]MangadexBudget) *MangadexRateLimiter {
+	return &MangadexRateLimiter{
+		budgets: budgets,
+		granted: make(map[string][]time.Time),
+	}
+}
+
+// mangadexLimiter is shared by every MangaDex download in the process, the limits
+// are per IP so concurrent series must draw from the same budget
+var mangadexLimiter = NewMangadexRateLimiter(mangadexBudgets)
+
+// Wait blocks until a request to endpoint fits in its budget, or ctx is done
+func (l *MangadexRateLimiter) Wait(ctx context.Context, endpoint string) error {
+	budget, ok := l.budgets[endpoint]
+	if !ok || budget.Requests <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := now
+	granted := l.granted[endpoint]
+	if len(granted) >= budget.Requests {
+		// The request Requests places back must have left the window first
+		if earliest := granted[len(granted)-budget.Requests].Add(budget.Window); earliest.After(slot) {
+			slot = earliest
+		}
+		granted = granted[len(granted)-budget.Requests+1:]
+	}
+	l.granted[endpoint] = append(granted, slot)
+	l.mu.Unlock()
+
+	if delay := slot.Sub(now); delay > 0 {
+		if !parser.SleepCtx(ctx, delay) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
 // MangaDex API response structures
 type MangaDexChapterList struct {
 	Result   string            `json:"result"`
@@ -27,9 +112,20 @@ type MangaDexChapterList struct {
 }
 
 type MangaDexChapter struct {
-	ID         string                    `json:"id"`
-	Type       string                    `json:"type"`
-	Attributes MangaDexChapterAttributes `json:"attributes"`
+	ID            string                    `json:"id"`
+	Type          string                    `json:"type"`
+	Attributes    MangaDexChapterAttributes `json:"attributes"`
+	Relationships []MangaDexRelationship    `json:"relationships"`
+}
+
+// MangaDexRelationship links a chapter to its scanlation groups, uploader and manga.
+// Attributes are only filled in for the types requested with includes[].
+type MangaDexRelationship struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes *struct {
+		Name string `json:"name"`
+	} `json:"attributes,omitempty"`
 }
 
 type MangaDexChapterAttributes struct {
@@ -38,6 +134,7 @@ type MangaDexChapterAttributes struct {
 	Title              string  `json:"title"`
 	TranslatedLanguage string  `json:"translatedLanguage"`
 	Pages              int     `json:"pages"`
+	PublishAt          string  `json:"publishAt"`
 }
 
 type MangaDexAtHomeResponse struct {
@@ -52,13 +149,79 @@ type MangaDexAtHomeChapterData struct {
 	DataSaver []string `json:"dataSaver"`
 }
 
-// MangadexSite implements the SitePlugin interface for MangaDex
+// MangaDexAggregate is the /manga/{id}/aggregate response, a compact
+// volume -> chapter index that is far cheaper than paging the full feed
+type MangaDexAggregate struct {
+	Result  string                             `json:"result"`
+	Volumes map[string]MangaDexAggregateVolume `json:"volumes"`
+}
+
+type MangaDexAggregateVolume struct {
+	Volume   string                              `json:"volume"`
+	Count    int                                 `json:"count"`
+	Chapters map[string]MangaDexAggregateChapter `json:"chapters"`
+}
+
+type MangaDexAggregateChapter struct {
+	Chapter string `json:"chapter"`
+	ID      string `json:"id"`
+	Count   int    `json:"count"`
+}
+
+// LatestChapter returns the highest numbered chapter in the aggregate.
+// Chapters without a number (reported by MangaDex as "none") are ignored.
+func (a *MangaDexAggregate) LatestChapter() float64 {
+	latest := 0.0
+	for _, volume := range a.Volumes {
+		for _, chapter := range volume.Chapters {
+			num, err := strconv.ParseFloat(strings.TrimSpace(chapter.Chapter), 64)
+			if err != nil {
+				continue
+			}
+			if num > latest {
+				latest = num
+			}
+		}
+	}
+	return latest
+}
+
+// MangadexSite implements the SitePlugin interface for MangaDex.
+// Pages are downloaded concurrently within the MangaDex@Home rate budget.
 type MangadexSite struct {
 	mangaID string
+
+	// forcePort443 asks the @Home API for a node on port 443, for users whose
+	// network blocks the non-standard ports some nodes use
+	forcePort443 bool
+
+	// feedConcurrency is how many feed pages are fetched at once, see
+	// CollectMangadexFeedConcurrent
+	feedConcurrency int
+
+	// dataSaver downloads the recompressed dataSaver pages instead of the originals
+	dataSaver bool
+
+	// languages are the translations listed, in order of preference, and groups the
+	// scanlation groups preferred when a chapter has several releases, see
+	// SelectMangadexChapters
+	languages []string
+	groups    []string
 }
 
 // Ensure MangadexSite implements SitePlugin
 var _ downloader.SitePlugin = (*MangadexSite)(nil)
+var _ downloader.ConcurrentImageSite = (*MangadexSite)(nil)
+
+// ImageConcurrency returns how many pages are downloaded at once
+func (m *MangadexSite) ImageConcurrency() int {
+	return mangadexImageConcurrency
+}
+
+// WaitImage waits for the image budget before each page request
+func (m *MangadexSite) WaitImage(ctx context.Context) error {
+	return mangadexLimiter.Wait(ctx, MangadexEndpointImage)
+}
 
 // GetSiteName returns the site identifier
 func (m *MangadexSite) GetSiteName() string {
@@ -88,8 +251,7 @@ func (m *MangadexSite) GetChapterExtractionMethod() *downloader.ChapterExtractio
 
 			log.Printf("<%s> Found %d total chapters on site", m.GetSiteName(), len(allChapters))
 
-			// Convert to map format
-			var chapters []map[string]string
+			var available []MangaDexChapter
 			for _, chapter := range allChapters {
 				if chapter.Attributes.Chapter == nil {
 					log.Printf("<%s> WARNING: Chapter has no number, skipping (ID: %s)", m.GetSiteName(), chapter.ID)
@@ -102,13 +264,27 @@ func (m *MangadexSite) GetChapterExtractionMethod() *downloader.ChapterExtractio
 						m.GetSiteName(), *chapter.Attributes.Chapter, chapter.ID)
 					continue
 				}
+				available = append(available, chapter)
+			}
+
+			// One release per chapter number, so the manager sees no duplicates
+			selected := SelectMangadexChapters(available, m.feedLanguages(), m.groups)
+			if dropped := len(available) - len(selected); dropped > 0 {
+				log.Printf("<%s> Picked one of several releases for %d chapters (languages %v, groups %v)",
+					m.GetSiteName(), dropped, m.feedLanguages(), m.groups)
+			}
 
+			// Convert to map format
+			var chapters []map[string]string
+			for _, chapter := range selected {
 				chapterNum := *chapter.Attributes.Chapter
 				chapters = append(chapters, map[string]string{
 					"num": chapterNum,
 					"id":  chapter.ID,
 					// Store the ID in the URL field so we can access it later
 					"url": chapter.ID,
+
+					downloader.ChapterPublishedKey: chapter.Attributes.PublishAt,
 				})
 			}
 
@@ -120,7 +296,9 @@ func (m *MangadexSite) GetChapterExtractionMethod() *downloader.ChapterExtractio
 // GetImageExtractionMethod returns HOW to extract images
 func (m *MangadexSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
 	return &downloader.ImageExtractionMethod{
-		Type: "api",
+		Type:               "api",
+		ImageHosts:         siteImageHosts(nil, m.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(nil, m.GetSiteName()),
 		APIFunc: func(chapterURL string, chapterData map[string]string, client *downloader.APIClient) ([]string, error) {
 			// The chapterURL is actually the chapter ID stored earlier
 			chapterID := chapterURL
@@ -151,39 +329,34 @@ func (m *MangadexSite) NormalizeChapterFilename(data map[string]string) string {
 	// Split on decimal point
 	parts := strings.Split(chapterNum, ".")
 
-	// Parse main chapter number
-	mainNum := parts[0]
-
-	// Pad main number to 3 digits
-	filename := fmt.Sprintf("ch%03s", mainNum)
-
-	// Add decimal part if it exists
+	// Parse main chapter number and the decimal part if it exists
+	mainNum, partNum := parts[0], ""
 	if len(parts) > 1 {
-		filename += "." + parts[1]
+		partNum = parts[1]
 	}
 
-	log.Printf("[Mangadex] Normalized: %s → %s.cbz", chapterNum, filename)
-	return filename + ".cbz"
+	filename := parser.ChapterFilename(mainNum, partNum)
+	parser.Debugf("[Mangadex] Normalized: %s → %s", chapterNum, filename)
+	return filename
 }
 
 // getAllChaptersAPI retrieves all chapters for a manga with pagination using APIClient
 func (m *MangadexSite) getAllChaptersAPI(client *downloader.APIClient) ([]MangaDexChapter, error) {
-	var allChapters []MangaDexChapter
-	offset := 0
-	limit := 100 // MangaDex allows up to 100 per request
-
-	for {
+	fetch := func(ctx context.Context, offset, limit int) (MangaDexChapterList, error) {
 		// Build API URL with pagination and filters
 		// Use url.Values so brackets in param names (e.g. contentRating[], order[chapter]) are
 		// percent-encoded — Go 1.24+ rejects raw brackets in URL query strings.
 		u, err := url.Parse(fmt.Sprintf("%s/manga/%s/feed", mangadexAPIBase, m.mangaID))
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse base URL: %w", err)
+			return MangaDexChapterList{}, fmt.Errorf("failed to parse base URL: %w", err)
 		}
 		q := u.Query()
 		q.Set("limit", fmt.Sprintf("%d", limit))
 		q.Set("offset", fmt.Sprintf("%d", offset))
-		q.Set("translatedLanguage[]", "en")
+		for _, language := range m.feedLanguages() {
+			q.Add("translatedLanguage[]", language)
+		}
+		q.Add("includes[]", "scanlation_group")
 		q.Set("order[chapter]", "asc")
 		q.Set("contentRating[]", "safe")
 		q.Add("contentRating[]", "suggestive")
@@ -193,55 +366,339 @@ func (m *MangadexSite) getAllChaptersAPI(client *downloader.APIClient) ([]MangaD
 
 		log.Printf("<mangadex> Fetching chapters: offset=%d, limit=%d", offset, limit)
 
+		// Every attempt, retries included, draws from the API budget
+		if err := mangadexLimiter.Wait(ctx, MangadexEndpointAPI); err != nil {
+			return MangaDexChapterList{}, err
+		}
+
 		var chapterList MangaDexChapterList
-		if err := client.FetchJSON(context.Background(), apiURL, &chapterList); err != nil {
-			return nil, fmt.Errorf("failed to fetch chapters: %w", err)
+		if err := client.FetchJSON(ctx, apiURL, &chapterList); err != nil {
+			return MangaDexChapterList{}, fmt.Errorf("failed to fetch chapters: %w", err)
 		}
+		return chapterList, nil
+	}
+
+	concurrency := m.feedConcurrency
+	if concurrency < 1 {
+		concurrency = mangadexFeedConcurrency
+	}
+	allChapters, gaps, err := CollectMangadexFeedConcurrent(client.Context(), fetch, mangadexFeedRetryDelay, concurrency)
+	if err != nil {
+		return nil, err
+	}
+	for _, gap := range gaps {
+		log.Printf("<mangadex> ⚠️ Chapters %d-%d of the feed could not be listed, they are picked up on the next run: %v",
+			gap.Offset+1, gap.Offset+gap.Limit, gap.Err)
+	}
+
+	log.Printf("<mangadex> Successfully retrieved %d total chapters", len(allChapters))
+	return allChapters, nil
+}
+
+const (
+	// mangadexFeedLimit is the page size of feed requests, the most MangaDex allows
+	mangadexFeedLimit = 100
+
+	// mangadexFeedAttempts is how many times one feed page is requested before it is
+	// given up as a gap
+	mangadexFeedAttempts = 3
+)
+
+// mangadexFeedRetryDelay is the wait before the first retry of a feed page, doubled
+// for every further retry
+var mangadexFeedRetryDelay = 2 * time.Second
+
+// MangadexFeedPage fetches the feed page of limit chapters starting at offset
+type MangadexFeedPage func(ctx context.Context, offset, limit int) (MangaDexChapterList, error)
+
+// MangadexFeedGap is a feed page that still failed after every retry
+type MangadexFeedGap struct {
+	Offset int
+	Limit  int
+	Err    error
+}
+
+// CollectMangadexFeed pages through a manga feed with fetch one page at a time, see
+// CollectMangadexFeedConcurrent
+func CollectMangadexFeed(ctx context.Context, fetch MangadexFeedPage, retryDelay time.Duration) ([]MangaDexChapter, []MangadexFeedGap, error) {
+	return CollectMangadexFeedConcurrent(ctx, fetch, retryDelay, 1)
+}
 
-		log.Printf("<mangadex> Retrieved %d chapters (total: %d)", len(chapterList.Data), chapterList.Total)
+// CollectMangadexFeedConcurrent pages through a manga feed with fetch. The first page
+// holds the feed total and is the only one required, the remaining pages are then
+// fetched up to concurrency at once (fetch is expected to wait for the API budget)
+// and the chapters are returned in feed order whatever order the pages come back in.
+// A page that fails to fetch or decode is retried, with retryDelay doubling between
+// attempts; a page that keeps failing is skipped and returned as a gap so one bad
+// page does not lose the rest of the list.
+func CollectMangadexFeedConcurrent(ctx context.Context, fetch MangadexFeedPage, retryDelay time.Duration, concurrency int) ([]MangaDexChapter, []MangadexFeedGap, error) {
+	first, err := fetchMangadexFeedPage(ctx, fetch, 0, retryDelay)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Printf("<mangadex> Retrieved %d chapters (total: %d)", len(first.Data), first.Total)
+	if len(first.Data) == 0 {
+		return nil, nil, nil
+	}
 
-		allChapters = append(allChapters, chapterList.Data...)
+	var offsets []int
+	for offset := mangadexFeedLimit; offset < first.Total; offset += mangadexFeedLimit {
+		offsets = append(offsets, offset)
+	}
 
-		// Check if we've retrieved all chapters
-		if len(allChapters) >= chapterList.Total {
-			break
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	pages := make([]MangaDexChapterList, len(offsets))
+	errs := make([]error, len(offsets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, offset := range offsets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			pages[i], errs[i] = fetchMangadexFeedPage(ctx, fetch, offset, retryDelay)
+			if errs[i] == nil {
+				log.Printf("<mangadex> Retrieved %d chapters at offset %d", len(pages[i].Data), offset)
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+
+	allChapters := first.Data
+	var gaps []MangadexFeedGap
+	for i, offset := range offsets {
+		if errs[i] != nil {
+			gaps = append(gaps, MangadexFeedGap{Offset: offset, Limit: mangadexFeedLimit, Err: errs[i]})
+			continue
 		}
+		// A page past the end comes back empty when the feed shrank while paging
+		allChapters = append(allChapters, pages[i].Data...)
+	}
 
-		offset += limit
+	return allChapters, gaps, nil
+}
 
-		// Rate limiting - be nice to MangaDex API
-		time.Sleep(250 * time.Millisecond)
+// fetchMangadexFeedPage fetches the feed page at offset, retrying failures with
+// retryDelay doubling between attempts
+func fetchMangadexFeedPage(ctx context.Context, fetch MangadexFeedPage, offset int, retryDelay time.Duration) (MangaDexChapterList, error) {
+	var page MangaDexChapterList
+	var err error
+	for attempt := 0; attempt < mangadexFeedAttempts; attempt++ {
+		if attempt > 0 {
+			delay := retryDelay * time.Duration(1<<(attempt-1))
+			log.Printf("<mangadex> Retrying chapters at offset %d in %v (attempt %d/%d): %v",
+				offset, delay, attempt+1, mangadexFeedAttempts, err)
+			if !parser.SleepCtx(ctx, delay) {
+				return MangaDexChapterList{}, ctx.Err()
+			}
+		}
+		if page, err = fetch(ctx, offset, mangadexFeedLimit); err == nil {
+			return page, nil
+		}
 	}
+	return MangaDexChapterList{}, err
+}
 
-	log.Printf("<mangadex> Successfully retrieved %d total chapters", len(allChapters))
-	return allChapters, nil
+// MangadexAtHomeURL builds the @Home server request for a chapter. With forcePort443
+// the API only hands out nodes reachable over HTTPS on port 443.
+func MangadexAtHomeURL(chapterID string, forcePort443 bool) string {
+	apiURL := fmt.Sprintf("%s/at-home/server/%s", mangadexAPIBase, chapterID)
+	if forcePort443 {
+		apiURL += "?forcePort443=true"
+	}
+	return apiURL
 }
 
 // getChapterImagesAPI retrieves image URLs for a specific chapter using APIClient
 func (m *MangadexSite) getChapterImagesAPI(chapterID string, client *downloader.APIClient) ([]string, error) {
 	// Get the @Home server URL and image list
-	apiURL := fmt.Sprintf("%s/at-home/server/%s", mangadexAPIBase, chapterID)
+	apiURL := MangadexAtHomeURL(chapterID, m.forcePort443)
 
 	log.Printf("<mangadex> Fetching image list for chapter: %s", chapterID)
 
+	// @Home lookups count against both their own budget and the global API limit
+	if err := mangadexLimiter.Wait(client.Context(), MangadexEndpointAtHome); err != nil {
+		return nil, err
+	}
+	if err := mangadexLimiter.Wait(client.Context(), MangadexEndpointAPI); err != nil {
+		return nil, err
+	}
+
 	var atHomeResp MangaDexAtHomeResponse
-	if err := client.FetchJSON(context.Background(), apiURL, &atHomeResp); err != nil {
+	if err := client.FetchJSON(client.Context(), apiURL, &atHomeResp); err != nil {
 		return nil, fmt.Errorf("failed to fetch @Home data: %w", err)
 	}
 
-	// Build full image URLs
+	imageURLs := MangadexImageURLs(atHomeResp, m.dataSaver)
+	log.Printf("<mangadex> Found %d images for chapter %s (data saver: %v)", len(imageURLs), chapterID, m.dataSaver)
+	return imageURLs, nil
+}
+
+// mangadexDefaultLanguage is the translation listed when a series sets no languages
+const mangadexDefaultLanguage = "en"
+
+// feedLanguages returns the translations to list, the bookmark's or English
+func (m *MangadexSite) feedLanguages() []string {
+	if len(m.languages) == 0 {
+		return []string{mangadexDefaultLanguage}
+	}
+	return m.languages
+}
+
+// SelectMangadexChapters keeps one release of every chapter number, in feed order.
+// Of several releases the one in the earliest of languages wins, then the one by
+// the earliest of groups (scanlation group names or IDs, case is ignored), then
+// the first in the feed.
+func SelectMangadexChapters(chapters []MangaDexChapter, languages, groups []string) []MangaDexChapter {
+	rank := func(chapter MangaDexChapter) (language, group int) {
+		language, group = len(languages), len(groups)
+		for i, l := range languages {
+			if strings.EqualFold(l, chapter.Attributes.TranslatedLanguage) {
+				language = i
+				break
+			}
+		}
+		for i, g := range groups {
+			if mangadexChapterByGroup(chapter, g) {
+				group = i
+				break
+			}
+		}
+		return language, group
+	}
+
+	var selected []MangaDexChapter
+	index := make(map[string]int, len(chapters)) // chapter number -> position in selected
+	for _, chapter := range chapters {
+		if chapter.Attributes.Chapter == nil {
+			continue
+		}
+		num := *chapter.Attributes.Chapter
+		i, seen := index[num]
+		if !seen {
+			index[num] = len(selected)
+			selected = append(selected, chapter)
+			continue
+		}
+
+		language, group := rank(chapter)
+		keptLanguage, keptGroup := rank(selected[i])
+		if language < keptLanguage || (language == keptLanguage && group < keptGroup) {
+			selected[i] = chapter
+		}
+	}
+	return selected
+}
+
+// mangadexChapterByGroup reports whether group (a name or ID) released the chapter
+func mangadexChapterByGroup(chapter MangaDexChapter, group string) bool {
+	group = strings.TrimSpace(group)
+	for _, rel := range chapter.Relationships {
+		if rel.Type != "scanlation_group" {
+			continue
+		}
+		if strings.EqualFold(rel.ID, group) || (rel.Attributes != nil && strings.EqualFold(rel.Attributes.Name, group)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MangadexImageURLs builds the page URLs of an @Home response, the full quality
+// pages or with dataSaver the recompressed ones. A chapter without dataSaver pages
+// falls back to the full quality ones.
+func MangadexImageURLs(atHome MangaDexAtHomeResponse, dataSaver bool) []string {
+	quality, files := "data", atHome.Chapter.Data
+	if dataSaver && len(atHome.Chapter.DataSaver) > 0 {
+		quality, files = "data-saver", atHome.Chapter.DataSaver
+	}
+
 	var imageURLs []string
-	for _, filename := range atHomeResp.Chapter.Data {
-		imageURL := fmt.Sprintf("%s/data/%s/%s", atHomeResp.BaseUrl, atHomeResp.Chapter.Hash, filename)
-		imageURLs = append(imageURLs, imageURL)
+	for _, filename := range files {
+		imageURLs = append(imageURLs, fmt.Sprintf("%s/%s/%s/%s", atHome.BaseUrl, quality, atHome.Chapter.Hash, filename))
 	}
+	return imageURLs
+}
 
-	log.Printf("<mangadex> Found %d images for chapter %s", len(imageURLs), chapterID)
-	return imageURLs, nil
+// MangadexLatestChapter returns the latest chapter number available for the manga
+// in any of the given languages, using the aggregate endpoint (a single API call)
+func MangadexLatestChapter(ctx context.Context, mangaID string, languages []string) (float64, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/manga/%s/aggregate", mangadexAPIBase, mangaID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	q := u.Query()
+	for _, language := range languages {
+		q.Add("translatedLanguage[]", language)
+	}
+	u.RawQuery = q.Encode()
+
+	client, err := downloader.NewAPIClientContext(ctx, "api.mangadex.org", false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create API client: %w", err)
+	}
+
+	var aggregate MangaDexAggregate
+	if err := client.FetchJSON(ctx, u.String(), &aggregate); err != nil {
+		return 0, fmt.Errorf("failed to fetch aggregate: %w", err)
+	}
+
+	return aggregate.LatestChapter(), nil
+}
+
+// mangadexLocalLatestChapter returns the highest chapter number among the local
+// cbz files (eg: ch072.5.cbz -> 72.5), false if there are no parsable chapters
+func mangadexLocalLatestChapter(location string) (float64, bool) {
+	chapters, err := parser.LocalChapterList(location)
+	if err != nil {
+		return 0, false
+	}
+
+	latest, found := 0.0, false
+	for _, chapter := range chapters {
+		_, _, num, err := parser.ParseChapterNumber(chapter)
+		if err != nil {
+			continue
+		}
+		if !found || num > latest {
+			latest, found = num, true
+		}
+	}
+	return latest, found
+}
+
+// MangadexNeedsFeed returns why the download of manga in ctx must page the whole
+// feed even when nothing is newer than the latest local chapter, empty when the
+// aggregate check may skip it
+func MangadexNeedsFeed(ctx context.Context, manga *config.Bookmarks) string {
+	if manga.SyncMode == config.SyncModeFull {
+		return "Full sync"
+	}
+	if config.RedownloadRequested(ctx) {
+		return "Re-download requested"
+	}
+	if config.RetryFailedOnly(ctx) {
+		return "Retrying failed chapters"
+	}
+	// Failed chapters can sit below the latest local one, normal runs retry them
+	if failed, err := parser.FailedChapters(manga.Location); err == nil && len(failed) > 0 {
+		return "Failed chapters to retry"
+	}
+	return ""
 }
 
 // MangadexDownloadChapters is the entry point called by the download queue
-func MangadexDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
+func MangadexDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
 	// Extract manga ID from URL
 	mangaID, err := extractMangaDexID(manga.Url)
 	if err != nil {
@@ -250,8 +707,37 @@ func MangadexDownloadChapters(ctx context.Context, manga *config.Bookmarks, prog
 
 	log.Printf("<%s> Extracted manga ID: %s", manga.Site, mangaID)
 
+	// Check the aggregate before paging the whole feed, if nothing is newer than
+	// the latest local chapter there is nothing to download. This does not backfill
+	// gaps below the latest local chapter, runs that look at local chapters page
+	// the feed, see MangadexNeedsFeed.
+	settings := config.LoadSettings()
 	site := &MangadexSite{
-		mangaID: mangaID,
+		mangaID:         mangaID,
+		forcePort443:    settings.MangadexForcePort443,
+		feedConcurrency: settings.MangadexFeedConcurrency,
+		dataSaver:       manga.UsesDataSaver(settings.MangadexImageQuality),
+		languages:       config.NormalizeTags(manga.Languages),
+		groups:          manga.ScanlationGroups,
+	}
+
+	if reason := MangadexNeedsFeed(ctx, manga); reason != "" {
+		log.Printf("<%s> %s, skipping the aggregate check", manga.Site, reason)
+	} else if localLatest, ok := mangadexLocalLatestChapter(manga.Location); ok {
+		remoteLatest, err := MangadexLatestChapter(ctx, mangaID, site.feedLanguages())
+		if err != nil {
+			log.Printf("<%s> Aggregate check failed, falling back to full feed: %v", manga.Site, err)
+		} else if remoteLatest <= localLatest {
+			// Without the feed the site's chapter count is unknown, RemoteChapters is
+			// left 0 so the count recorded by the last full run is kept
+			log.Printf("<%s> Up to date (remote latest %g, local latest %g), skipping feed", manga.Site, remoteLatest, localLatest)
+			if progressCallback != nil {
+				progressCallback(config.ProgressEvent{Status: "No new chapters to download", Fraction: 1.0})
+			}
+			return nil
+		} else {
+			log.Printf("<%s> New chapters available (remote latest %g, local latest %g)", manga.Site, remoteLatest, localLatest)
+		}
 	}
 
 	cfg := &downloader.DownloadConfig{
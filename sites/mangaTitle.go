@@ -0,0 +1,224 @@
+package sites
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"kansho/downloader"
+	"kansho/parser"
+	"kansho/validation"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// titleSiteSuffixRe matches the trailing " - Asura Scans", " | Read Manga Online"
+// style segments sites append to the page title
+var titleSiteSuffixRe = regexp.MustCompile(`(?i)\s+[-|–—:]\s+[^-|–—:]*\b(scans?|comics?|manga|manhwa|manhua|webtoons?|online|read|free)\b[^-|–—:]*$`)
+
+// titleSeparatorRe matches the separators between the series name and site name
+var titleSeparatorRe = regexp.MustCompile(`\s+[-|–—:]\s+`)
+
+// titleReadPrefixRe matches "Read " in front of page titles like "Read Solo Leveling Manga Online"
+var titleReadPrefixRe = regexp.MustCompile(`(?i)^read\s+`)
+
+// titleReadSuffixRe matches " Manga Online" / " Online" / " Manhwa" left at the end of the title
+var titleReadSuffixRe = regexp.MustCompile(`(?i)\s+(manga|manhwa|manhua)?\s*(online|free)(\s+free)?$`)
+
+// FetchMangaTitle reads the series title from a bookmark URL. MangaDex titles come
+// from the API, every other site from the series page og:title or <title>.
+// The result is sanitized and safe to use as a folder name.
+func FetchMangaTitle(mangaURL string) (string, error) {
+	parsedURL, err := url.Parse(strings.TrimSpace(mangaURL))
+	if err != nil || parsedURL.Hostname() == "" {
+		return "", fmt.Errorf("invalid manga URL: %s", mangaURL)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if strings.HasSuffix(parsedURL.Hostname(), "mangadex.org") {
+		return fetchMangadexTitle(ctx, parsedURL.String())
+	}
+
+	exec, err := downloader.NewRequestExecutor(parsedURL.String(), false, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request executor: %w", err)
+	}
+
+	pageHTML, err := exec.FetchHTML(ctx, parsedURL.String(), "")
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch series page: %w", err)
+	}
+
+	title := TitleFromHTML(pageHTML, parsedURL.Hostname())
+	if title == "" {
+		return "", fmt.Errorf("no title found on %s", parsedURL.Hostname())
+	}
+	return title, nil
+}
+
+// TitleFromHTML returns the sanitized series title from a series page served by
+// host, preferring og:title over <title> since it rarely carries the site name
+func TitleFromHTML(pageHTML, host string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(pageHTML))
+	if err != nil {
+		return ""
+	}
+
+	candidates := []string{
+		doc.Find(`meta[property="og:title"]`).AttrOr("content", ""),
+		doc.Find(`meta[name="twitter:title"]`).AttrOr("content", ""),
+		doc.Find("title").First().Text(),
+	}
+	for _, candidate := range candidates {
+		if title := SanitizeTitle(stripSiteDecoration(candidate, host)); title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+// stripSiteDecoration removes the site name and "Read ... Online" wording that
+// sites wrap around the series name in page titles
+func stripSiteDecoration(title, host string) string {
+	title = html.UnescapeString(strings.TrimSpace(title))
+	brand := hostBrand(host)
+	for {
+		stripped := titleSiteSuffixRe.ReplaceAllString(title, "")
+		if stripped == title && brand != "" {
+			if loc := titleSeparatorRe.FindAllStringIndex(title, -1); len(loc) > 0 {
+				last := loc[len(loc)-1]
+				if strings.Contains(lettersOnly(title[last[1]:]), brand) {
+					stripped = title[:last[0]]
+				}
+			}
+		}
+		if stripped == title || stripped == "" {
+			break
+		}
+		title = stripped
+	}
+	title = titleReadPrefixRe.ReplaceAllString(title, "")
+	return titleReadSuffixRe.ReplaceAllString(title, "")
+}
+
+// hostBrand returns the site name part of a hostname, eg: "www.manhuaus.com" -> "manhuaus"
+func hostBrand(host string) string {
+	labels := strings.Split(strings.ToLower(host), ".")
+	if len(labels) < 2 {
+		return lettersOnly(host)
+	}
+	return lettersOnly(labels[len(labels)-2])
+}
+
+// lettersOnly lowercases s and drops everything but letters and digits
+func lettersOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// SanitizeTitle collapses whitespace and drops control characters plus characters
+// that are not allowed in folder names on Windows
+func SanitizeTitle(title string) string {
+	title = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r):
+			return ' '
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			return -1
+		}
+		return r
+	}, title)
+
+	title = strings.Join(strings.Fields(title), " ")
+	return strings.TrimRight(title, ". ")
+}
+
+// LibraryLocation returns the folder of the series title inside the library root:
+// <root>/<title> with the title sanitized for a folder name and a leading ~ in root
+// expanded
+func LibraryLocation(root, title string) (string, error) {
+	root, err := parser.ExpandPath(strings.TrimSpace(root))
+	if err != nil {
+		return "", err
+	}
+	name := SanitizeTitle(title)
+	if name == "" {
+		return "", fmt.Errorf("title %q has no characters usable in a folder name", title)
+	}
+	return validation.SafeJoin(root, name)
+}
+
+// mangadexMangaResponse is the part of the /manga/{id} response holding titles
+type mangadexMangaResponse struct {
+	Data struct {
+		Attributes struct {
+			Title     map[string]string   `json:"title"`
+			AltTitles []map[string]string `json:"altTitles"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// fetchMangadexTitle reads the title through the MangaDex API
+func fetchMangadexTitle(ctx context.Context, mangaURL string) (string, error) {
+	mangaID, err := extractMangaDexID(mangaURL)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := downloader.NewAPIClientContext(ctx, "api.mangadex.org", false)
+	if err != nil {
+		return "", fmt.Errorf("failed to create API client: %w", err)
+	}
+
+	data, err := client.FetchRaw(ctx, fmt.Sprintf("%s/manga/%s", mangadexAPIBase, mangaID))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch manga: %w", err)
+	}
+	return MangadexTitleFromResponse(data)
+}
+
+// MangadexTitleFromResponse returns the English title from a /manga/{id} response,
+// falling back to an English alt title and then any title at all
+func MangadexTitleFromResponse(data []byte) (string, error) {
+	var resp mangadexMangaResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse manga response: %w", err)
+	}
+	attrs := resp.Data.Attributes
+
+	var candidates []string
+	candidates = append(candidates, attrs.Title["en"])
+	for _, alt := range attrs.AltTitles {
+		candidates = append(candidates, alt["en"])
+	}
+
+	// Map order is random, sort the languages so the fallback is stable
+	languages := make([]string, 0, len(attrs.Title))
+	for lang := range attrs.Title {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	for _, lang := range languages {
+		candidates = append(candidates, attrs.Title[lang])
+	}
+
+	for _, candidate := range candidates {
+		if title := SanitizeTitle(candidate); title != "" {
+			return title, nil
+		}
+	}
+	return "", fmt.Errorf("manga response has no title")
+}
@@ -0,0 +1,110 @@
+package sites
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// trailingSlash is what a site expects at the end of a series URL
+type trailingSlash int
+
+const (
+	slashKeep trailingSlash = iota
+	slashAdd
+	slashStrip
+)
+
+// mangaURLRule is what NormalizeMangaURL checks and fixes in a site's series URLs.
+// A host also matches its subdomains (www.mgeko.cc for mgeko.cc).
+type mangaURLRule struct {
+	hosts []string
+	slash trailingSlash
+}
+
+// mangaURLRules are keyed by site name, sites without a rule (hls) accept any host.
+// WordPress/Madara sites link their series with a trailing slash, the others without.
+var mangaURLRules = map[string]mangaURLRule{
+	"mgeko":       {hosts: []string{"mgeko.cc"}, slash: slashStrip},
+	"manhuaus":    {hosts: []string{"manhuaus.com"}, slash: slashAdd},
+	"kunmanga":    {hosts: []string{"kunmanga.online"}, slash: slashAdd},
+	"asurascans":  {hosts: []string{"asurascans.com", "asuracomic.net"}, slash: slashStrip},
+	"mangakatana": {hosts: []string{"mangakatana.com"}, slash: slashAdd},
+	"mangadex":    {hosts: []string{"mangadex.org"}, slash: slashStrip},
+	"stonescape":  {hosts: []string{"stonescape.xyz"}, slash: slashStrip},
+	"ravenscans":  {hosts: []string{"ravenscans.org", "ravenscans.com"}, slash: slashAdd},
+	"cubari":      {hosts: []string{"cubari.moe"}, slash: slashAdd},
+	"flamecomics": {hosts: []string{"flamecomics.xyz"}, slash: slashStrip},
+	"weebcentral": {hosts: []string{"weebcentral.com"}, slash: slashStrip},
+	"philiascans": {hosts: []string{"philiascans.org"}, slash: slashAdd},
+}
+
+// trackingParams are query parameters added by share buttons and ad links, no site
+// needs them to find a series
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "yclid": true,
+	"igshid": true, "mc_cid": true, "mc_eid": true, "_ga": true,
+	"ref": true, "ref_src": true,
+}
+
+// NormalizeMangaURL cleans up a series URL pasted for the given site before it is
+// saved: surrounding spaces, the fragment and tracking parameters (utm_*, fbclid, ...)
+// are dropped, the host is lowercased and checked against the site's hosts, and the
+// trailing slash is added or removed the way the site links its series. Other query
+// parameters are kept.
+func NormalizeMangaURL(site, rawURL string) (string, error) {
+	trimmed := strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(trimmed)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("%q is not a valid URL", trimmed)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("%q is not a web URL", trimmed)
+	}
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+
+	rule, known := mangaURLRules[site]
+	if known && !hostMatches(parsed.Hostname(), rule.hosts) {
+		return "", fmt.Errorf("%s is not a %s URL (expected %s)", parsed.Hostname(), site, strings.Join(rule.hosts, " or "))
+	}
+
+	// Only re-encode the query when something was dropped, Encode sorts the keys
+	query := parsed.Query()
+	tracked := false
+	for key := range query {
+		lower := strings.ToLower(key)
+		if trackingParams[lower] || strings.HasPrefix(lower, "utm_") {
+			query.Del(key)
+			tracked = true
+		}
+	}
+	if tracked {
+		parsed.RawQuery = query.Encode()
+	}
+	parsed.ForceQuery = false
+
+	switch rule.slash {
+	case slashAdd:
+		parsed.Path = strings.TrimRight(parsed.Path, "/") + "/"
+		if parsed.RawPath != "" {
+			parsed.RawPath = strings.TrimRight(parsed.RawPath, "/") + "/"
+		}
+	case slashStrip:
+		parsed.Path = strings.TrimRight(parsed.Path, "/")
+		parsed.RawPath = strings.TrimRight(parsed.RawPath, "/")
+	}
+
+	return parsed.String(), nil
+}
+
+// hostMatches reports whether host is one of hosts or a subdomain of one
+func hostMatches(host string, hosts []string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
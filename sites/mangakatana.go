@@ -9,6 +9,7 @@ import (
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/parser"
 )
 
 // MangakatanaSite implements the SitePlugin interface for mangakatana.com
@@ -53,9 +54,12 @@ func (m *MangakatanaSite) GetChapterExtractionMethod() *downloader.ChapterExtrac
 // Uses custom HTTP fetch + regex to extract image URLs directly.
 func (m *MangakatanaSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
 	return &downloader.ImageExtractionMethod{
-		Type:         "custom",
-		WaitSelector: "",
-		CustomParser: parseMangakatanaImages,
+		Type:               "custom",
+		ImageHosts:         siteImageHosts(nil, m.GetSiteName()),
+		NextPageSelector:   siteReaderNextPage(nil, m.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(nil, m.GetSiteName()),
+		WaitSelector:       "",
+		CustomParser:       parseMangakatanaImages,
 	}
 }
 
@@ -64,7 +68,7 @@ func parseMangakatanaImages(html string) ([]string, error) {
 	re := regexp.MustCompile(`var\s+thzq\s*=\s*\[([^\]]+)\]`)
 	match := re.FindStringSubmatch(html)
 	if len(match) < 2 {
-		return nil, fmt.Errorf("[MangaKatana] var thzq not found in page HTML")
+		return nil, fmt.Errorf("[MangaKatana] var thzq not found in page HTML: %w", downloader.ErrSiteChanged)
 	}
 
 	urlRe := regexp.MustCompile(`'([^']+)'`)
@@ -131,20 +135,18 @@ func (m *MangakatanaSite) NormalizeChapterFilename(data map[string]string) strin
 		partNum = ""
 	}
 
-	filename := fmt.Sprintf("ch%03s", mainNum)
-	if subNum != "" {
-		filename += "." + subNum
-	}
+	part := subNum
 	if partNum != "" {
-		filename += "." + partNum
+		part = strings.TrimPrefix(part+"."+partNum, ".")
 	}
 
-	log.Printf("[MangaKatana] Normalized: %s → %s.cbz", text, filename)
-	return filename + ".cbz"
+	filename := parser.ChapterFilename(mainNum, part)
+	parser.Debugf("[MangaKatana] Normalized: %s → %s", text, filename)
+	return filename
 }
 
 // MangakatanaDownloadChapters is the entry point called by the download queue
-func MangakatanaDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
+func MangakatanaDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
 	site := &MangakatanaSite{}
 
 	cfg := &downloader.DownloadConfig{
@@ -8,14 +8,33 @@ import (
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/models"
+	"kansho/parser"
 )
 
 // ManhuausSite implements the SitePlugin interface for manhuaus sites
-type ManhuausSite struct{}
+type ManhuausSite struct {
+	// sitesConfig is the site config pinned when the download started, nil uses
+	// the current config
+	sitesConfig *models.SitesConfig
+}
+
+// NewManhuausSite returns a ManhuausSite pinned to the current site config, selector
+// overrides reloaded while it is in use do not affect it
+func NewManhuausSite() *ManhuausSite {
+	return &ManhuausSite{sitesConfig: pinSitesConfig()}
+}
 
 // Ensure ManhuausSite implements SitePlugin
 var _ downloader.SitePlugin = (*ManhuausSite)(nil)
 
+// manhuausDefaultSelectors are used unless overridden in the site config
+var manhuausDefaultSelectors = models.SiteSelectors{
+	ChapterList:    "li.wp-manga-chapter a",
+	Image:          "div.reading-content img",
+	ImageAttribute: downloader.MadaraImageAttributes,
+}
+
 // GetSiteName returns the site identifier
 func (m *ManhuausSite) GetSiteName() string {
 	return "manhuaus"
@@ -34,41 +53,46 @@ func (m *ManhuausSite) NeedsCFBypass() bool {
 // GetChapterExtractionMethod returns HOW to extract chapters
 // Downloader will execute this - we just provide the JavaScript
 func (m *ManhuausSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	selectors := siteSelectors(m.sitesConfig, m.GetSiteName(), manhuausDefaultSelectors)
+
 	return &downloader.ChapterExtractionMethod{
 		Type:         "javascript",
-		WaitSelector: "li.wp-manga-chapter a",
-		JavaScript: `
-			[...document.querySelectorAll('li.wp-manga-chapter a')]
+		Selector:     selectors.ChapterList,
+		WaitSelector: selectors.ChapterList,
+		JavaScript: fmt.Sprintf(`
+			[...document.querySelectorAll(%s)]
 			.map(a => {
 				const href = a.href;
+				const text = a.textContent.trim();
 				const match = href.match(/chapter-([\d.]+)/);
-				if (match) {
-					return { 
-						num: match[1], 
-						url: href 
+				// Slug URLs without a number fall back to the link text
+				if (match || /\d/.test(text)) {
+					return {
+						num: match ? match[1] : '',
+						url: href,
+						text: text
 					};
 				}
 				return null;
 			})
 			.filter(x => x !== null)
-		`,
+		`, jsString(selectors.ChapterList)),
 	}
 }
 
 // GetImageExtractionMethod returns HOW to extract images
 // Downloader will execute this - we just provide the JavaScript
 func (m *ManhuausSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	selectors := siteSelectors(m.sitesConfig, m.GetSiteName(), manhuausDefaultSelectors)
+
 	return &downloader.ImageExtractionMethod{
-		Type:         "javascript",
-		WaitSelector: "div.reading-content img",
-		JavaScript: `
-			[...document.querySelectorAll('div.reading-content img')]
-			.map(img => {
-				const src = img.getAttribute('data-src') || img.src;
-				return src.trim();
-			})
-			.filter(src => src !== '')
-		`,
+		Type:               "javascript",
+		ImageHosts:         siteImageHosts(m.sitesConfig, m.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(m.sitesConfig, m.GetSiteName()),
+		Selector:           selectors.Image,
+		Attribute:          selectors.ImageAttribute,
+		WaitSelector:       selectors.Image,
+		JavaScript:         selectorImageJS(selectors),
 	}
 }
 
@@ -83,28 +107,27 @@ func (m *ManhuausSite) NormalizeChapterURL(rawURL, baseURL string) string {
 // PARSING LOGIC ONLY - returns a string
 func (m *ManhuausSite) NormalizeChapterFilename(data map[string]string) string {
 	num := data["num"]
-
-	var filename string
-
-	// Handle decimal chapters (e.g., "1.5")
-	if strings.Contains(num, ".") {
-		parts := strings.SplitN(num, ".", 2)
-		// Pad integer part to 3 digits
-		intPart := parts[0]
-		decimalPart := parts[1]
-		filename = fmt.Sprintf("ch%03s.%s", intPart, decimalPart)
-	} else {
-		// Standard chapter number - pad to 3 digits
-		filename = fmt.Sprintf("ch%03s", num)
+	if num == "" {
+		textNum, ok := chapterNumberFromText(data["text"])
+		if !ok {
+			log.Printf("[Manhuaus] WARNING: Could not parse chapter number from URL or text, skipping: %s (%q)", data["url"], data["text"])
+			return ""
+		}
+		log.Printf("[Manhuaus] No chapter number in URL %s, using link text %q", data["url"], data["text"])
+		num = textNum
 	}
 
-	log.Printf("[Manhuaus] Normalized: %s → %s.cbz", num, filename)
-	return filename + ".cbz"
+	// Decimal chapters (e.g., "1.5") keep their decimals as the part
+	intPart, decimalPart, _ := strings.Cut(num, ".")
+
+	filename := parser.ChapterFilename(intPart, decimalPart)
+	parser.Debugf("[Manhuaus] Normalized: %s → %s", num, filename)
+	return filename
 }
 
 // ManhuausDownloadChapters is the entry point called by the download queue
-func ManhuausDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
-	site := &ManhuausSite{}
+func ManhuausDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
+	site := NewManhuausSite()
 
 	cfg := &downloader.DownloadConfig{
 		Manga:            manga,
@@ -9,10 +9,22 @@ import (
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/models"
+	"kansho/parser"
 )
 
 // MgekoSite implements the SitePlugin interface for mgeko.cc
-type MgekoSite struct{}
+type MgekoSite struct {
+	// sitesConfig is the site config pinned when the download started, nil uses
+	// the current config
+	sitesConfig *models.SitesConfig
+}
+
+// NewMgekoSite returns a MgekoSite pinned to the current site config, selector
+// overrides reloaded while it is in use do not affect it
+func NewMgekoSite() *MgekoSite {
+	return &MgekoSite{sitesConfig: pinSitesConfig()}
+}
 
 // Ensure MgekoSite implements SitePlugin
 var _ downloader.SitePlugin = (*MgekoSite)(nil)
@@ -35,33 +47,45 @@ func (m *MgekoSite) NeedsCFBypass() bool {
 	return true // Mgeko uses CF protection
 }
 
+// mgekoDefaultSelectors are used unless overridden in the site config
+var mgekoDefaultSelectors = models.SiteSelectors{
+	ChapterList:    "ul.chapter-list li a",
+	Image:          "#chapter-reader img",
+	ImageAttribute: "src",
+}
+
 // GetChapterExtractionMethod returns HOW to extract chapters
 // Uses JavaScript to properly support CF bypass detection
 func (m *MgekoSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	selectors := siteSelectors(m.sitesConfig, m.GetSiteName(), mgekoDefaultSelectors)
+
 	return &downloader.ChapterExtractionMethod{
 		Type:         "javascript",
-		WaitSelector: "ul.chapter-list li a",
-		JavaScript: `
-			[...document.querySelectorAll('ul.chapter-list li a')]
+		Selector:     selectors.ChapterList,
+		WaitSelector: selectors.ChapterList,
+		JavaScript: fmt.Sprintf(`
+			[...document.querySelectorAll(%s)]
 			.map(a => ({
 				url: a.href,
 				text: a.textContent.trim()
 			}))
-		`,
+		`, jsString(selectors.ChapterList)),
 	}
 }
 
 // GetImageExtractionMethod returns HOW to extract images
 // Uses JavaScript to extract image URLs from the chapter page
 func (m *MgekoSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	selectors := siteSelectors(m.sitesConfig, m.GetSiteName(), mgekoDefaultSelectors)
+
 	return &downloader.ImageExtractionMethod{
-		Type:         "javascript",
-		WaitSelector: "#chapter-reader img",
-		JavaScript: `
-			[...document.querySelectorAll('#chapter-reader img')]
-			.map(img => img.src)
-			.filter(src => src && src.trim() !== '')
-		`,
+		Type:               "javascript",
+		ImageHosts:         siteImageHosts(m.sitesConfig, m.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(m.sitesConfig, m.GetSiteName()),
+		Selector:           selectors.Image,
+		Attribute:          selectors.ImageAttribute,
+		WaitSelector:       selectors.Image,
+		JavaScript:         selectorImageJS(selectors),
 	}
 }
 
@@ -87,6 +111,17 @@ func (m *MgekoSite) NormalizeChapterFilename(data map[string]string) string {
 	re := regexp.MustCompile(`chapter[-_\.]?(\d+)((?:[-_\.]\d+)*)`)
 
 	matches := re.FindStringSubmatch(url)
+	if len(matches) == 0 {
+		// Slug URLs without a number: the link text usually reads "Chapter N"
+		if textNum, ok := chapterNumberFromText(data["text"]); ok {
+			mainNum, part, _ := strings.Cut(textNum, ".")
+			matches = []string{url, mainNum, ""}
+			if part != "" {
+				matches[2] = "." + part
+			}
+			log.Printf("[Mgeko] No chapter number in URL %s, using link text %q", url, data["text"])
+		}
+	}
 	if len(matches) == 0 {
 		// Fallback: use a sanitized version of the URL
 		sanitized := strings.ReplaceAll(url, "/", "-")
@@ -105,19 +140,14 @@ func (m *MgekoSite) NormalizeChapterFilename(data map[string]string) string {
 	// Remove leading dot (if any) unconditionally
 	normalizedPart = strings.TrimPrefix(normalizedPart, ".")
 
-	// Final filename: pad main number to 3 digits
-	filename := fmt.Sprintf("ch%03s", mainNum)
-	if normalizedPart != "" {
-		filename += "." + normalizedPart
-	}
-
-	log.Printf("[Mgeko] Normalized: %s → %s.cbz", url, filename)
-	return filename + ".cbz"
+	filename := parser.ChapterFilename(mainNum, normalizedPart)
+	parser.Debugf("[Mgeko] Normalized: %s → %s", url, filename)
+	return filename
 }
 
 // MgekoDownloadChapters is the entry point called by the download queue
-func MgekoDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
-	site := &MgekoSite{}
+func MgekoDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
+	site := NewMgekoSite()
 
 	cfg := &downloader.DownloadConfig{
 		Manga:            manga,
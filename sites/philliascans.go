@@ -9,6 +9,7 @@ import (
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/parser"
 )
 
 // PhiliaScansSite implements SitePlugin for philiascans.org.
@@ -78,8 +79,11 @@ func (p *PhiliaScansSite) GetChapterExtractionMethod() *downloader.ChapterExtrac
 // every chapter is "9999.webp" — a subscribe banner — and is filtered out.
 func (p *PhiliaScansSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
 	return &downloader.ImageExtractionMethod{
-		Type:         "custom",
-		CustomParser: parsePhiliaScansImages,
+		Type:               "custom",
+		ImageHosts:         siteImageHosts(nil, p.GetSiteName()),
+		NextPageSelector:   siteReaderNextPage(nil, p.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(nil, p.GetSiteName()),
+		CustomParser:       parsePhiliaScansImages,
 	}
 }
 
@@ -119,13 +123,9 @@ func (p *PhiliaScansSite) NormalizeChapterFilename(chapterData map[string]string
 		partNum = matches[2]
 	}
 
-	filename := fmt.Sprintf("ch%03s", mainNum)
-	if partNum != "" {
-		filename += "." + partNum
-	}
-
-	log.Printf("[PhiliaScans] Normalized: %q → %s.cbz", text, filename)
-	return filename + ".cbz"
+	filename := parser.ChapterFilename(mainNum, partNum)
+	parser.Debugf("[PhiliaScans] Normalized: %q → %s", text, filename)
+	return filename
 }
 
 // -------------------------
@@ -153,7 +153,7 @@ func parsePhiliaScansChapters(html string) (map[string]string, error) {
 
 	matches := chapterRe.FindAllStringSubmatch(html, -1)
 	if len(matches) == 0 {
-		return nil, fmt.Errorf("PhiliaScans: no free chapters found in HTML")
+		return nil, fmt.Errorf("PhiliaScans: no free chapters found in HTML: %w", downloader.ErrNoChaptersFound)
 	}
 
 	site := &PhiliaScansSite{}
@@ -215,7 +215,7 @@ func parsePhiliaScansImages(html string) ([]string, error) {
 	imgMatches := imgRe.FindAllStringSubmatch(searchHTML, -1)
 
 	if len(imgMatches) == 0 {
-		return nil, fmt.Errorf("PhiliaScans: no chapter images found in HTML")
+		return nil, fmt.Errorf("PhiliaScans: no chapter images found in HTML: %w", downloader.ErrSiteChanged)
 	}
 
 	seen := make(map[string]bool)
@@ -240,7 +240,7 @@ func parsePhiliaScansImages(html string) ([]string, error) {
 	}
 
 	if len(images) == 0 {
-		return nil, fmt.Errorf("PhiliaScans: no usable images found after filtering")
+		return nil, fmt.Errorf("PhiliaScans: no usable images found after filtering: %w", downloader.ErrSiteChanged)
 	}
 
 	log.Printf("[PhiliaScans] Found %d chapter images", len(images))
@@ -252,7 +252,7 @@ func parsePhiliaScansImages(html string) ([]string, error) {
 // -------------------------
 
 // PhiliaScansDownloadChapters is the public entry point called by the queue/UI layer.
-func PhiliaScansDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
+func PhiliaScansDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
 	site := &PhiliaScansSite{}
 
 	cfg := &downloader.DownloadConfig{
@@ -11,6 +11,7 @@ import (
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/parser"
 )
 
 // RavenscansSite implements the SitePlugin interface for ravenscans.com
@@ -55,8 +56,11 @@ func (r *RavenscansSite) GetChapterExtractionMethod() *downloader.ChapterExtract
 // GetImageExtractionMethod returns HOW to extract images
 func (r *RavenscansSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
 	return &downloader.ImageExtractionMethod{
-		Type:         "custom",
-		WaitSelector: "",
+		Type:               "custom",
+		ImageHosts:         siteImageHosts(nil, r.GetSiteName()),
+		NextPageSelector:   siteReaderNextPage(nil, r.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(nil, r.GetSiteName()),
+		WaitSelector:       "",
 		CustomParser: func(html string) ([]string, error) {
 			return parseRavenScansImages(html)
 		},
@@ -115,28 +119,19 @@ func (r *RavenscansSite) NormalizeChapterFilename(data map[string]string) string
 		fracPart = parts[1]
 	}
 
-	// Pad the whole part to 3 digits
 	wholeNum, err := strconv.Atoi(wholePart)
 	if err != nil {
 		log.Printf("[Ravenscans] WARNING: error converting whole part to int: %v", err)
 		return fmt.Sprintf("ch%s.cbz", chapterNum)
 	}
-	paddedWhole := fmt.Sprintf("%03d", wholeNum)
-
-	// Compose final chapter name string
-	var filename string
-	if fracPart != "" {
-		filename = fmt.Sprintf("ch%s.%s", paddedWhole, fracPart)
-	} else {
-		filename = fmt.Sprintf("ch%s", paddedWhole)
-	}
 
-	log.Printf("[Ravenscans] Normalized: %s → %s.cbz", chapterNum, filename)
-	return filename + ".cbz"
+	filename := parser.ChapterFilename(strconv.Itoa(wholeNum), fracPart)
+	parser.Debugf("[Ravenscans] Normalized: %s → %s", chapterNum, filename)
+	return filename
 }
 
 // RavenscansDownloadChapters is the entry point called by the download queue
-func RavenscansDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
+func RavenscansDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
 	site := &RavenscansSite{}
 
 	cfg := &downloader.DownloadConfig{
@@ -157,7 +152,7 @@ func parseRavenScansImages(html string) ([]string, error) {
 	log.Printf("[Ravenscans] DEBUG: Regex found %d matches", len(matches))
 
 	if len(matches) == 0 {
-		return nil, fmt.Errorf("[Ravenscans] ERROR: No chapter images found in HTML")
+		return nil, fmt.Errorf("[Ravenscans] ERROR: No chapter images found in HTML: %w", downloader.ErrSiteChanged)
 	}
 
 	type imgInfo struct {
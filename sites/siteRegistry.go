@@ -2,6 +2,7 @@ package sites
 
 import (
 	"kansho/config"
+	"kansho/downloader"
 )
 
 // init() is called automatically when the package is imported
@@ -21,6 +22,29 @@ func init() {
 	config.RegisterSite("weebcentral", WeebcentralDownloadChapters) // Implements downloader interface
 	config.RegisterSite("philiascans", PhiliaScansDownloadChapters)
 
+	// Unattended runs check these for a usable cf_clearance before starting
+	for name, site := range map[string]downloader.SitePlugin{
+		"mgeko":       &MgekoSite{},
+		"manhuaus":    &ManhuausSite{},
+		"kunmanga":    &KunmangaSite{},
+		"asurascans":  &AsuraSite{},
+		"mangakatana": &MangakatanaSite{},
+		"mangadex":    &MangadexSite{},
+		"stonescape":  &StonescapeSite{},
+		"ravenscans":  &RavenscansSite{},
+		"cubari":      &CubariSite{},
+		"flamecomics": &FlameComicsSite{},
+		"weebcentral": &WeebcentralSite{},
+		"philiascans": &PhiliaScansSite{},
+	} {
+		if site.NeedsCFBypass() {
+			config.RegisterCFProtected(name)
+		}
+	}
+
+	// Challenges the queue's downloads meet are solved in a browser window
+	config.RegisterClearanceRefresher(downloader.RefreshClearance)
+
 	// Add new sites here in the future:
 	// config.RegisterSite("newsite", NewsiteDownloadChapters)
 }
@@ -4,10 +4,17 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
+	"kansho/downloader"
 	"kansho/models"
+	"kansho/parser"
 )
 
 //go:embed sites.json
@@ -19,7 +26,16 @@ func GetEmbeddedSitesJSON() ([]byte, error) {
 	return embeddedFS.ReadFile("sites.json")
 }
 
-// LoadSitesConfig loads the manga site configuration from the embedded sites.json
+var (
+	sitesConfigMu     sync.RWMutex
+	sitesConfig       *models.SitesConfig
+	sitesReloadHooks  []func(models.SitesConfig)
+	sitesReloadHookMu sync.Mutex
+)
+
+// LoadSitesConfig returns the manga site configuration: the embedded sites.json
+// merged with any user overrides. The config is read once and cached, call
+// ReloadSitesConfig to pick up edits to the user sites.json without restarting.
 // This configuration determines which manga sites are supported and what information
 // is required when adding manga from each site
 //
@@ -28,6 +44,69 @@ func GetEmbeddedSitesJSON() ([]byte, error) {
 //
 // The sites.json file is embedded into the binary at compile time
 func LoadSitesConfig() models.SitesConfig {
+	sitesConfigMu.RLock()
+	cached := sitesConfig
+	sitesConfigMu.RUnlock()
+
+	if cached == nil {
+		sitesConfigMu.Lock()
+		if sitesConfig == nil {
+			loaded := readSitesConfig()
+			sitesConfig = &loaded
+		}
+		cached = sitesConfig
+		sitesConfigMu.Unlock()
+	}
+
+	return copySitesConfig(*cached)
+}
+
+// ReloadSitesConfig re-reads the embedded and user site config, replaces the cached
+// copy and notifies everything registered with OnSitesConfigReload. Downloads that
+// already pinned a config (see pinSitesConfig) keep using it until they finish.
+func ReloadSitesConfig() models.SitesConfig {
+	loaded := readSitesConfig()
+
+	sitesConfigMu.Lock()
+	sitesConfig = &loaded
+	sitesConfigMu.Unlock()
+
+	log.Printf("[Sites] Reloaded sites config: %d sites", len(loaded.Sites))
+
+	sitesReloadHookMu.Lock()
+	hooks := append([]func(models.SitesConfig){}, sitesReloadHooks...)
+	sitesReloadHookMu.Unlock()
+
+	for _, hook := range hooks {
+		hook(copySitesConfig(loaded))
+	}
+
+	return copySitesConfig(loaded)
+}
+
+// OnSitesConfigReload registers fn to be called with the new config after every
+// ReloadSitesConfig, eg: so views can refresh their site dropdowns
+func OnSitesConfigReload(fn func(models.SitesConfig)) {
+	sitesReloadHookMu.Lock()
+	defer sitesReloadHookMu.Unlock()
+	sitesReloadHooks = append(sitesReloadHooks, fn)
+}
+
+// pinSitesConfig returns a snapshot of the current config for a site to hold for
+// the length of a download, so a reload mid-run can't change its selectors
+func pinSitesConfig() *models.SitesConfig {
+	pinned := LoadSitesConfig()
+	return &pinned
+}
+
+// copySitesConfig copies the site list so callers can't modify the cached config
+func copySitesConfig(cfg models.SitesConfig) models.SitesConfig {
+	cfg.Sites = append([]models.Site(nil), cfg.Sites...)
+	return cfg
+}
+
+// readSitesConfig loads the embedded sites.json and applies the user overrides
+func readSitesConfig() models.SitesConfig {
 	// Get the embedded sites.json content
 	byteValues, err := GetEmbeddedSitesJSON()
 	if err != nil {
@@ -42,27 +121,237 @@ func LoadSitesConfig() models.SitesConfig {
 		return models.SitesConfig{}
 	}
 
+	applyUserSiteOverrides(&sitesConfig)
+
 	return sitesConfig
 }
 
-// extractChapterNumber extracts the numeric chapter number from filenames like "ch001.cbz" or "ch091.2.cbz"
-func extractChapterNumber(filename string) int {
-	// Remove .cbz extension
-	name := strings.TrimSuffix(filename, ".cbz")
+// userSitesConfigPath is the optional user edited sites.json, it only supplies
+// overrides (selectors, image hosts, image URL transforms, the login URL and the
+// reader next page selector) for sites already in the embedded config
+func userSitesConfigPath() (string, error) {
+	configDir, err := parser.ExpandPath("~/.config/kansho")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "sites.json"), nil
+}
 
-	// Remove "ch" prefix
-	name = strings.TrimPrefix(name, "ch")
+// applyUserSiteOverrides merges selector overrides from the user sites.json into
+// the embedded config. A missing user file is not an error.
+func applyUserSiteOverrides(sitesConfig *models.SitesConfig) {
+	path, err := userSitesConfigPath()
+	if err != nil {
+		return
+	}
+
+	byteValues, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
 
-	// Split on dots to get main chapter number
-	parts := strings.Split(name, ".")
-	if len(parts) == 0 {
-		return 0
+	var userConfig models.SitesConfig
+	if err := json.Unmarshal(byteValues, &userConfig); err != nil {
+		fmt.Printf("error unmarshalling user sites config %s: %v\n", path, err)
+		return
 	}
 
-	// Parse the first part as the chapter number
-	var chapterNum int
-	fmt.Sscanf(parts[0], "%d", &chapterNum)
-	return chapterNum
+	for _, override := range userConfig.Sites {
+		if override.Selectors == nil && override.ImageHosts == nil && override.ImageURLTransforms == nil && override.LoginURL == "" && override.ReaderNextPage == "" {
+			continue
+		}
+		for i := range sitesConfig.Sites {
+			if sitesConfig.Sites[i].Name != override.Name {
+				continue
+			}
+			if override.Selectors != nil {
+				selectors := *override.Selectors
+				sitesConfig.Sites[i].Selectors = &selectors
+			}
+			if override.ImageHosts != nil {
+				hosts := *override.ImageHosts
+				sitesConfig.Sites[i].ImageHosts = &hosts
+			}
+			if override.ImageURLTransforms != nil {
+				sitesConfig.Sites[i].ImageURLTransforms = append([]models.ImageURLTransform(nil), override.ImageURLTransforms...)
+			}
+			if override.LoginURL != "" {
+				sitesConfig.Sites[i].LoginURL = override.LoginURL
+			}
+			if override.ReaderNextPage != "" {
+				sitesConfig.Sites[i].ReaderNextPage = override.ReaderNextPage
+			}
+		}
+	}
+}
+
+// siteImageHosts returns the page image host filter for siteName from pinned (or the
+// current config when pinned is nil), nil when the site has none
+func siteImageHosts(pinned *models.SitesConfig, siteName string) *models.ImageHosts {
+	cfg := pinned
+	if cfg == nil {
+		current := LoadSitesConfig()
+		cfg = &current
+	}
+
+	for _, site := range cfg.Sites {
+		if site.Name == siteName && site.ImageHosts != nil {
+			hosts := *site.ImageHosts
+			return &hosts
+		}
+	}
+	return nil
+}
+
+// siteReaderNextPage returns the reader next page selector for siteName from pinned
+// (or the current config when pinned is nil), "" when its chapters are one page
+func siteReaderNextPage(pinned *models.SitesConfig, siteName string) string {
+	cfg := pinned
+	if cfg == nil {
+		current := LoadSitesConfig()
+		cfg = &current
+	}
+
+	for _, site := range cfg.Sites {
+		if site.Name == siteName {
+			return site.ReaderNextPage
+		}
+	}
+	return ""
+}
+
+// siteImageURLTransforms returns the page image URL transforms for siteName from
+// pinned (or the current config when pinned is nil), nil when the site has none
+func siteImageURLTransforms(pinned *models.SitesConfig, siteName string) []models.ImageURLTransform {
+	cfg := pinned
+	if cfg == nil {
+		current := LoadSitesConfig()
+		cfg = &current
+	}
+
+	for _, site := range cfg.Sites {
+		if site.Name == siteName && len(site.ImageURLTransforms) > 0 {
+			return append([]models.ImageURLTransform(nil), site.ImageURLTransforms...)
+		}
+	}
+	return nil
+}
+
+// siteSelectors returns the selectors for siteName from pinned (or the current config
+// when pinned is nil), any field not set in the site config falls back to the given
+// defaults (the selectors hardcoded in the site)
+func siteSelectors(pinned *models.SitesConfig, siteName string, defaults models.SiteSelectors) models.SiteSelectors {
+	selectors := defaults
+
+	cfg := pinned
+	if cfg == nil {
+		current := LoadSitesConfig()
+		cfg = &current
+	}
+
+	for _, site := range cfg.Sites {
+		if site.Name != siteName || site.Selectors == nil {
+			continue
+		}
+		if site.Selectors.ChapterList != "" {
+			selectors.ChapterList = site.Selectors.ChapterList
+		}
+		if site.Selectors.Image != "" {
+			selectors.Image = site.Selectors.Image
+		}
+		if site.Selectors.ImageAttribute != "" {
+			selectors.ImageAttribute = site.Selectors.ImageAttribute
+		}
+		break
+	}
+
+	return selectors
+}
+
+// selectorImageJS builds the image extraction JavaScript for sites whose pages are
+// plain <img> tags under a reader container. The image attribute may list several
+// attributes separated by commas, tried in order before img.src the way
+// downloader.ImageURLFromElement does; "src" is the resolved (absolute) img.src and
+// a srcset gives its largest candidate. Images inside a <picture> prefer the largest
+// <source> srcset candidate, the same rule downloader.SelectImageURLs applies to
+// static HTML.
+func selectorImageJS(selectors models.SiteSelectors) string {
+	return fmt.Sprintf(`
+			[...document.querySelectorAll(%s)]
+			.map(img => {
+				const bestOf = srcset => {
+					let best = null;
+					for (const entry of (srcset || '').split(',')) {
+						const [url, descriptor] = entry.trim().split(/\s+/);
+						if (!url) continue;
+						const score = parseFloat(descriptor) > 0 ? parseFloat(descriptor) : 1;
+						if (!best || score > best.score) best = { url, score };
+					}
+					return best;
+				};
+
+				const picture = img.parentElement;
+				if (picture && picture.tagName === 'PICTURE') {
+					let best = null;
+					for (const source of picture.querySelectorAll(':scope > source')) {
+						const candidate = bestOf(source.getAttribute('srcset') || source.getAttribute('data-srcset'));
+						if (candidate && (!best || candidate.score > best.score)) best = candidate;
+					}
+					if (best) return new URL(best.url, document.baseURI).href;
+				}
+
+				for (const attr of (%s + ',src').split(',').map(a => a.trim()).filter(a => a)) {
+					let src = '';
+					if (attr === 'src') {
+						src = img.getAttribute('src') ? img.src : '';
+					} else if (attr.endsWith('srcset')) {
+						const best = bestOf(img.getAttribute(attr));
+						src = best ? new URL(best.url, document.baseURI).href : '';
+					} else {
+						src = img.getAttribute(attr) || '';
+					}
+					if (src.trim()) return src.trim();
+				}
+				return '';
+			})
+			.filter(src => src !== '')
+		`, jsString(selectors.Image), jsString(selectors.ImageAttribute))
+}
+
+// jsString quotes a selector or attribute name for safe use inside extraction JavaScript
+func jsString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// nextDataRe captures the JSON Next.js embeds for hydration. Attribute order varies
+// between Next.js versions, so only the id is matched.
+var nextDataRe = regexp.MustCompile(`(?s)<script[^>]*\bid="__NEXT_DATA__"[^>]*>(.+?)</script>`)
+
+// extractNextDataJSON returns the __NEXT_DATA__ JSON of a Next.js page, which holds
+// the page props (chapter lists, image URLs) in plain HTTP responses
+func extractNextDataJSON(html string) (string, error) {
+	m := nextDataRe.FindStringSubmatch(html)
+	if len(m) < 2 {
+		return "", fmt.Errorf("__NEXT_DATA__ JSON not found: %w", downloader.ErrSiteChanged)
+	}
+	return m[1], nil
+}
+
+// chapterTextRe matches the chapter number in link text such as "Chapter 72",
+// "Ch. 72.5" or "Episode 12 - Part 2"
+var chapterTextRe = regexp.MustCompile(`(?i)\b(?:chapter|ch|episode|ep)\b\.?\s*(\d+)((?:[.\-_]\d+)*)`)
+
+// chapterNumberFromText reads the chapter number from a chapter link's text, for
+// sites whose chapter URLs use slugs without a number. Part separators are
+// normalized to dots (eg: "Chapter 72-5" -> "72.5").
+func chapterNumberFromText(text string) (string, bool) {
+	m := chapterTextRe.FindStringSubmatch(text)
+	if m == nil {
+		return "", false
+	}
+	part := strings.NewReplacer("-", ".", "_", ".").Replace(m[2])
+	return m[1] + part, true
 }
 
 func atoiSafe(s string) int {
@@ -9,6 +9,7 @@ import (
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/parser"
 )
 
 // StonescapeSite implements the SitePlugin interface for stonescape.xyz
@@ -75,7 +76,7 @@ func (s *StonescapeSite) GetChapterExtractionMethod() *downloader.ChapterExtract
 			log.Printf("[Stonescape] Fetching series info: %s", seriesURL)
 
 			var seriesResp stonescapeSeriesResponse
-			if err := client.FetchJSON(context.Background(), seriesURL, &seriesResp); err != nil {
+			if err := client.FetchJSON(client.Context(), seriesURL, &seriesResp); err != nil {
 				return nil, fmt.Errorf("[Stonescape] failed to fetch series info: %w", err)
 			}
 			if seriesResp.SeriesID == "" {
@@ -87,11 +88,11 @@ func (s *StonescapeSite) GetChapterExtractionMethod() *downloader.ChapterExtract
 			log.Printf("[Stonescape] Fetching chapters: %s", chaptersURL)
 
 			var chaptersResp stonescapeChaptersResponse
-			if err := client.FetchJSON(context.Background(), chaptersURL, &chaptersResp); err != nil {
+			if err := client.FetchJSON(client.Context(), chaptersURL, &chaptersResp); err != nil {
 				return nil, fmt.Errorf("[Stonescape] failed to fetch chapters: %w", err)
 			}
 			if len(chaptersResp.Chapters) == 0 {
-				return nil, fmt.Errorf("[Stonescape] no chapters found for series %q", seriesResp.SeriesID)
+				return nil, fmt.Errorf("[Stonescape] %w for series %q", downloader.ErrNoChaptersFound, seriesResp.SeriesID)
 			}
 
 			// Build raw chapter data.
@@ -104,7 +105,7 @@ func (s *StonescapeSite) GetChapterExtractionMethod() *downloader.ChapterExtract
 					"num": ch.ChapterNumber,
 					"url": ch.ChapterID,
 				})
-				log.Printf("[Stonescape] Found chapter: %s → %s", ch.ChapterNumber, ch.ChapterID)
+				parser.Debugf("[Stonescape] Found chapter: %s → %s", ch.ChapterNumber, ch.ChapterID)
 			}
 
 			return result, nil
@@ -117,17 +118,19 @@ func (s *StonescapeSite) GetChapterExtractionMethod() *downloader.ChapterExtract
 // returns the sorted page image URLs for that chapter.
 func (s *StonescapeSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
 	return &downloader.ImageExtractionMethod{
-		Type: "api",
+		Type:               "api",
+		ImageHosts:         siteImageHosts(nil, s.GetSiteName()),
+		ImageURLTransforms: siteImageURLTransforms(nil, s.GetSiteName()),
 		APIFunc: func(chapterID string, chapterData map[string]string, client *downloader.APIClient) ([]string, error) {
 			pagesURL := fmt.Sprintf("https://stonescape.xyz/api/chapters/%s/pages", chapterID)
 			log.Printf("[Stonescape] Fetching pages: %s", pagesURL)
 
 			var pagesResp stonescapePagesResponse
-			if err := client.FetchJSON(context.Background(), pagesURL, &pagesResp); err != nil {
+			if err := client.FetchJSON(client.Context(), pagesURL, &pagesResp); err != nil {
 				return nil, fmt.Errorf("[Stonescape] failed to fetch pages: %w", err)
 			}
 			if len(pagesResp.Pages) == 0 {
-				return nil, fmt.Errorf("[Stonescape] no pages found for chapter %q", chapterID)
+				return nil, fmt.Errorf("[Stonescape] no pages found for chapter %q: %w", chapterID, downloader.ErrSiteChanged)
 			}
 
 			// Sort by pageNumber (insertion sort — typically already sorted but be safe)
@@ -171,22 +174,12 @@ func (s *StonescapeSite) NormalizeChapterFilename(data map[string]string) string
 	}
 
 	whole := matches[1]
-	decimal := matches[2]
-
-	// Pad integer part to 3 digits
-	padded := fmt.Sprintf("%03s", whole)
-
-	var fileName string
-	if decimal != "" && decimal != "00" {
-		// e.g. "1.50" → "ch001.5"  (trim trailing zeros from decimal)
-		trimmed := strings.TrimRight(decimal, "0")
-		fileName = fmt.Sprintf("ch%s.%s", padded, trimmed)
-	} else {
-		fileName = fmt.Sprintf("ch%s", padded)
-	}
+	// e.g. "1.50" → "ch001.5" (trim trailing zeros from decimal)
+	decimal := strings.TrimRight(matches[2], "0")
 
-	log.Printf("[Stonescape] Normalized: %s → %s.cbz", num, fileName)
-	return fileName + ".cbz"
+	fileName := parser.ChapterFilename(whole, decimal)
+	parser.Debugf("[Stonescape] Normalized: %s → %s", num, fileName)
+	return fileName
 }
 
 // --- Helpers ---
@@ -207,7 +200,7 @@ func stonescapeExtractSlug(mangaURL string) (string, error) {
 // --- Entry point ---
 
 // StonescapeDownloadChapters is the entry point called by the download queue.
-func StonescapeDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
+func StonescapeDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
 	site := &StonescapeSite{}
 
 	cfg := &downloader.DownloadConfig{
@@ -9,6 +9,7 @@ import (
 
 	"kansho/config"
 	"kansho/downloader"
+	"kansho/parser"
 )
 
 type WeebcentralSite struct{}
@@ -51,8 +52,8 @@ func (w *WeebcentralSite) Debugger() *downloader.Debugger {
 // The response is plain HTML with <a href="/chapters/...">Chapter N</a> links.
 func (w *WeebcentralSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
 	return &downloader.ChapterExtractionMethod{
-		Type:         "custom",
-		CustomParser: parseWeebcentralChapters,
+		Type:                "custom",
+		CustomParserContext: parseWeebcentralChapters,
 	}
 }
 
@@ -66,8 +67,11 @@ func (w *WeebcentralSite) GetChapterExtractionMethod() *downloader.ChapterExtrac
 // We include reading_style=long_strip in the URL to get all images at once.
 func (w *WeebcentralSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
 	return &downloader.ImageExtractionMethod{
-		Type:         "custom",
-		CustomParser: parseWeebcentralImages,
+		Type:                "custom",
+		ImageHosts:          siteImageHosts(nil, w.GetSiteName()),
+		NextPageSelector:    siteReaderNextPage(nil, w.GetSiteName()),
+		ImageURLTransforms:  siteImageURLTransforms(nil, w.GetSiteName()),
+		CustomParserContext: parseWeebcentralImages,
 	}
 }
 
@@ -101,19 +105,10 @@ func (w *WeebcentralSite) NormalizeChapterFilename(data map[string]string) strin
 		partNum = matches[2]
 	}
 
-	// Use "ch" for both chapters and episodes to keep filenames consistent
-	prefix := "ch"
-	// if strings.Contains(strings.ToLower(text), "episode") {
-	// 	prefix = "ep"
-	// }
-
-	filename := fmt.Sprintf("%s%03s", prefix, mainNum)
-	if partNum != "" {
-		filename += "." + partNum
-	}
-
-	log.Printf("[WeebCentral] Normalized: %s → %s.cbz", text, filename)
-	return filename + ".cbz"
+	// Chapters and episodes are named alike to keep filenames consistent
+	filename := parser.ChapterFilename(mainNum, partNum)
+	parser.Debugf("[WeebCentral] Normalized: %s → %s", text, filename)
+	return filename
 }
 
 // -------------------------
@@ -126,7 +121,7 @@ func (w *WeebcentralSite) NormalizeChapterFilename(data map[string]string) strin
 //	hx-get="https://weebcentral.com/series/{ID}/full-chapter-list"
 //
 // That endpoint returns a plain HTML fragment with all chapter <a> links.
-func parseWeebcentralChapters(html string) (map[string]string, error) {
+func parseWeebcentralChapters(ctx context.Context, html string) (map[string]string, error) {
 	// Find the full-chapter-list endpoint URL from the "Show All Chapters" button
 	endpointRe := regexp.MustCompile(`hx-get="(https://weebcentral\.com/series/[^"]+/full-chapter-list[^"]*)"`)
 	matches := endpointRe.FindStringSubmatch(html)
@@ -147,7 +142,6 @@ func parseWeebcentralChapters(html string) (map[string]string, error) {
 		return nil, fmt.Errorf("WeebCentral: failed to create executor for chapter list: %w", err)
 	}
 
-	ctx := context.Background()
 	fullListHTML, err := exec.FetchHTML(ctx, fullListURL, "")
 	if err != nil {
 		return nil, fmt.Errorf("WeebCentral: failed to fetch full chapter list: %w", err)
@@ -217,7 +211,7 @@ func extractChapterLinksSimple(html string) (map[string]string, error) {
 	}
 
 	if len(result) == 0 {
-		return nil, fmt.Errorf("WeebCentral: no chapters found in HTML")
+		return nil, fmt.Errorf("WeebCentral: %w in HTML", downloader.ErrNoChaptersFound)
 	}
 
 	log.Printf("[WeebCentral] Found %d chapters (simple parser)", len(result))
@@ -237,7 +231,7 @@ func extractChapterLinksSimple(html string) (map[string]string, error) {
 //
 // The server requires a reading_style parameter (missing = 400 Bad Request).
 // We append reading_style=long_strip which returns all images in a single response.
-func parseWeebcentralImages(html string) ([]string, error) {
+func parseWeebcentralImages(ctx context.Context, html string) ([]string, error) {
 	// Extract the images HTMX endpoint from the chapter page
 	endpointRe := regexp.MustCompile(`hx-get="(https://weebcentral\.com/chapters/[^"]+/images\?[^"]+)"`)
 	matches := endpointRe.FindStringSubmatch(html)
@@ -247,7 +241,7 @@ func parseWeebcentralImages(html string) ([]string, error) {
 		relRe := regexp.MustCompile(`hx-get="(/chapters/[^"]+/images\?[^"]+)"`)
 		relMatches := relRe.FindStringSubmatch(html)
 		if len(relMatches) < 2 {
-			return nil, fmt.Errorf("WeebCentral: no images endpoint found in chapter page")
+			return nil, fmt.Errorf("WeebCentral: no images endpoint found in chapter page: %w", downloader.ErrSiteChanged)
 		}
 		matches = []string{relMatches[0], "https://weebcentral.com" + relMatches[1]}
 	}
@@ -266,7 +260,6 @@ func parseWeebcentralImages(html string) ([]string, error) {
 		return nil, fmt.Errorf("WeebCentral: failed to create executor for images: %w", err)
 	}
 
-	ctx := context.Background()
 	imagesHTML, err := exec.FetchHTML(ctx, imagesURL, "")
 	if err != nil {
 		return nil, fmt.Errorf("WeebCentral: failed to fetch images: %w", err)
@@ -299,7 +292,7 @@ func extractImageURLs(html string) ([]string, error) {
 	}
 
 	if len(images) == 0 {
-		return nil, fmt.Errorf("WeebCentral: no images found in response")
+		return nil, fmt.Errorf("WeebCentral: no images found in response: %w", downloader.ErrSiteChanged)
 	}
 
 	log.Printf("[WeebCentral] Found %d images", len(images))
@@ -310,7 +303,7 @@ func extractImageURLs(html string) ([]string, error) {
 // Download entrypoint
 // -------------------------
 
-func WeebcentralDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback func(string, float64, int, int, int)) error {
+func WeebcentralDownloadChapters(ctx context.Context, manga *config.Bookmarks, progressCallback config.ProgressFunc) error {
 	site := &WeebcentralSite{}
 
 	cfg := &downloader.DownloadConfig{
@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"math"
+	"testing"
+
+	"kansho/config"
+)
+
+func TestQueueAggregateProgress(t *testing.T) {
+	tasks := []config.DownloadTask{
+		{Status: "downloading", PlanKnown: true, ChaptersPlanned: 10, ChaptersDone: 3},
+		{Status: "downloading", PlanKnown: true, ChaptersPlanned: 5, ChaptersDone: 0},
+		{Status: "downloading"}, // still fetching its chapter list
+		{Status: "queued"},
+	}
+
+	agg := config.AggregateTasks(tasks)
+	if agg.Series != 4 || agg.SeriesDone != 0 || agg.Listing != 2 {
+		t.Fatalf("series counts = %+v", agg)
+	}
+	if agg.ChaptersPlanned != 15 || agg.ChaptersDone != 3 {
+		t.Fatalf("chapters = %d/%d, want 3/15", agg.ChaptersDone, agg.ChaptersPlanned)
+	}
+	if got := agg.Fraction(); math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("fraction = %v, want 0.2", got)
+	}
+
+	// Tasks advance independently: the lister reports its plan, another finishes
+	tasks[1].ChaptersDone = 5
+	tasks[1].Status = "completed"
+	tasks[2].PlanKnown = true
+	tasks[2].ChaptersPlanned = 5
+	tasks[2].ChaptersDone = 2
+
+	agg = config.AggregateTasks(tasks)
+	if agg.ChaptersPlanned != 20 || agg.ChaptersDone != 10 || agg.Listing != 1 || agg.SeriesDone != 1 {
+		t.Fatalf("after updates = %+v, want 10/20 with 1 listing and 1 done", agg)
+	}
+	if got, want := agg.String(), "10/20 chapters across 4 series (1 still checking for new chapters)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// A series that fails part way counts as done at what it reached
+	tasks[0].ChaptersDone = 4
+	tasks[0].Status = "failed"
+	tasks[2].ChaptersDone = 5
+	tasks[2].Status = "completed"
+	tasks[3].Status = "completed" // no new chapters, never reported a plan
+
+	agg = config.AggregateTasks(tasks)
+	if agg.ChaptersPlanned != 14 || agg.ChaptersDone != 14 {
+		t.Errorf("finished chapters = %d/%d, want 14/14", agg.ChaptersDone, agg.ChaptersPlanned)
+	}
+	if agg.Fraction() != 1 {
+		t.Errorf("fraction = %v, want 1 once every series finished", agg.Fraction())
+	}
+}
+
+func TestQueueAggregateProgressNoPlans(t *testing.T) {
+	tasks := []config.DownloadTask{
+		{Status: "completed"},
+		{Status: "downloading"},
+	}
+
+	agg := config.AggregateTasks(tasks)
+	if got := agg.Fraction(); got != 0.5 {
+		t.Errorf("fraction = %v, want share of finished series 0.5", got)
+	}
+	if (config.AggregateProgress{}).Fraction() != 0 {
+		t.Error("empty batch should report 0")
+	}
+}
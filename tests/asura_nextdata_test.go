@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"kansho/downloader"
+	"kansho/sites"
+)
+
+func TestAsuraImages_NextData(t *testing.T) {
+	html := `<html><head></head><body><div id="__next"></div>
+<script id="__NEXT_DATA__" type="application/json" crossorigin="anonymous">{"props":{"pageProps":{
+"chapter":{"number":97,"cover":"https://cdn.asurascans.com/asura-images/covers/solo.webp",
+"pages":[
+{"order":1,"url":"https://cdn.asurascans.com/asura-images/chapters/solo-leveling/97/273284.webp"},
+{"order":2,"url":"https://cdn.asurascans.com/asura-images/chapters/solo-leveling/97/9ea848.webp"},
+{"order":3,"url":"https://cdn.asurascans.com/asura-images/chapters/solo-leveling/97/003.jpg"},
+{"order":2,"url":"https://cdn.asurascans.com/asura-images/chapters/solo-leveling/97/9ea848.webp"}
+]}}},"page":"/comics/[slug]/chapter/[number]","buildId":"x"}</script></body></html>`
+
+	method := (&sites.AsuraSite{}).GetImageExtractionMethod()
+	if downloader.UsesBrowserRendering(method) {
+		t.Fatal("asura images should be fetched over HTTP first")
+	}
+	if !method.BrowserFallback {
+		t.Fatal("asura should fall back to the browser when the HTTP page cannot be parsed")
+	}
+
+	urls, err := method.CustomParser(html)
+	if err != nil {
+		t.Fatalf("CustomParser: %v", err)
+	}
+
+	want := []string{
+		"https://cdn.asurascans.com/asura-images/chapters/solo-leveling/97/273284.webp",
+		"https://cdn.asurascans.com/asura-images/chapters/solo-leveling/97/9ea848.webp",
+		"https://cdn.asurascans.com/asura-images/chapters/solo-leveling/97/003.jpg",
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Fatalf("urls = %v, want %v", urls, want)
+	}
+}
+
+func TestAsuraImages_NoEmbeddedDataIsSiteChange(t *testing.T) {
+	method := (&sites.AsuraSite{}).GetImageExtractionMethod()
+
+	_, err := method.CustomParser(`<html><body><div id="__next"></div></body></html>`)
+	if !errors.Is(err, downloader.ErrSiteChanged) {
+		t.Fatalf("err = %v, want ErrSiteChanged so the browser fallback runs", err)
+	}
+}
@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"kansho/parser"
+	"kansho/sites"
+)
+
+// asuraChapterListHTML wraps chapter entries in a ChapterListReact astro-island the
+// way asura serialises its props
+func asuraChapterListHTML(chapters ...string) string {
+	props := `{&quot;publicUrl&quot;:[0,&quot;/comics/solo-max-level-5e7a1c2b&quot;],&quot;chapters&quot;:[1,[`
+	for i, chapter := range chapters {
+		if i > 0 {
+			props += ","
+		}
+		props += `[0,{` + chapter + `}]`
+	}
+	props += `]]}`
+	return `<astro-island component-url="/_astro/ChapterListReact.js" props="` + props + `"></astro-island>`
+}
+
+func TestAsuraChapterParser_SeasonPrefixes(t *testing.T) {
+	html := asuraChapterListHTML(
+		`&quot;number&quot;:[0,2]`,
+		`&quot;number&quot;:[0,44]`,
+		`&quot;number&quot;:[0,&quot;s2-1&quot;],&quot;url&quot;:[0,&quot;/comics/solo-max-level-5e7a1c2b/chapter/s2-1&quot;]`,
+		`&quot;number&quot;:[0,&quot;s2-10.5&quot;]`,
+		`&quot;url&quot;:[0,&quot;/comics/solo-max-level-5e7a1c2b/chapter/season-3-chapter-4&quot;]`,
+		`&quot;number&quot;:[0,&quot;extra-story&quot;]`,
+	)
+
+	site := &sites.AsuraSite{}
+	chapters, err := site.GetChapterExtractionMethod().CustomParser(html)
+	if err != nil {
+		t.Fatalf("CustomParser: %v", err)
+	}
+
+	base := "https://asurascans.com/comics/solo-max-level-5e7a1c2b/chapter/"
+	want := map[string]string{
+		"ch002.cbz":      base + "2",
+		"ch044.cbz":      base + "44",
+		"s02ch001.cbz":   base + "s2-1",
+		"s02ch010.5.cbz": base + "s2-10.5",
+		"s03ch004.cbz":   base + "season-3-chapter-4",
+	}
+	if !reflect.DeepEqual(chapters, want) {
+		t.Fatalf("chapters = %v, want %v", chapters, want)
+	}
+
+	// Download order (lexical) keeps seasons after the unprefixed chapters
+	names, _ := parser.SortKeys(chapters)
+	wantOrder := []string{"ch002.cbz", "ch044.cbz", "s02ch001.cbz", "s02ch010.5.cbz", "s03ch004.cbz"}
+	if !reflect.DeepEqual(names, wantOrder) {
+		t.Fatalf("order = %v, want %v", names, wantOrder)
+	}
+}
+
+func TestAsuraChapterFilename_Standard(t *testing.T) {
+	site := &sites.AsuraSite{}
+	tests := map[string]string{
+		"92":     "ch092.cbz",
+		"92.5":   "ch092.5.cbz",
+		"1204":   "ch1204.cbz",
+		"s2-45":  "s02ch045.cbz",
+		"S12-3":  "s12ch003.cbz",
+		"prolog": "unknown.cbz",
+	}
+	for number, want := range tests {
+		if got := site.NormalizeChapterFilename(map[string]string{"number": number}); got != want {
+			t.Errorf("NormalizeChapterFilename(%q) = %q, want %q", number, got, want)
+		}
+	}
+}
+
+func Test_PruneOldChapters_SeasonOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeChapters(t, dir, "ch050.cbz", "ch099.cbz", "s02ch001.cbz", "s02ch002.cbz")
+
+	removed, err := parser.PruneOldChapters(dir, 2)
+	if err != nil {
+		t.Fatalf("PruneOldChapters: %v", err)
+	}
+	sort.Strings(removed)
+	if want := []string{"ch050.cbz", "ch099.cbz"}; !reflect.DeepEqual(removed, want) {
+		t.Fatalf("removed = %v, want %v", removed, want)
+	}
+}
@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+)
+
+func TestClassifyAvailability(t *testing.T) {
+	tests := []struct {
+		local, remote int
+		want          config.ChapterAvailability
+		label         string
+	}{
+		{local: 10, remote: 13, want: config.ChapterAvailability{State: config.AvailabilityBehind, Behind: 3}, label: "behind 3"},
+		{local: 10, remote: 10, want: config.ChapterAvailability{State: config.AvailabilityUpToDate}, label: "up to date"},
+		// Local extras (specials, chapters the site dropped) still count as caught up
+		{local: 12, remote: 10, want: config.ChapterAvailability{State: config.AvailabilityUpToDate}, label: "up to date"},
+		{local: 0, remote: 5, want: config.ChapterAvailability{State: config.AvailabilityBehind, Behind: 5}, label: "behind 5"},
+		{local: 4, remote: 0, want: config.ChapterAvailability{State: config.AvailabilityUnknown}, label: "unknown"},
+		{local: 4, remote: -1, want: config.ChapterAvailability{State: config.AvailabilityUnknown}, label: "unknown"},
+	}
+	for _, tt := range tests {
+		got := config.ClassifyAvailability(tt.local, tt.remote)
+		if got != tt.want || got.Label() != tt.label {
+			t.Errorf("ClassifyAvailability(%d, %d) = %+v %q, want %+v %q", tt.local, tt.remote, got, got.Label(), tt.want, tt.label)
+		}
+	}
+}
+
+func Test_CheckRemoteChapters_DownloadsNothing(t *testing.T) {
+	const siteName = "availability-test-site"
+
+	mock := newMockMangaSite(t, map[int]int{1: 1, 2: 1, 3: 1})
+	site := &mockSitePlugin{}
+	manga := config.Bookmarks{Title: "Mock Availability Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: siteName}
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(downloader.ChapterTempDir(site.GetSiteName(), &manga, "ch001.cbz"))) })
+	writeChapters(t, manga.Location, "ch001.cbz")
+
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		return downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site, ProgressCallback: progress}).Download(ctx)
+	})
+
+	if got := config.SeriesAvailability(manga); got.State != config.AvailabilityUnknown {
+		t.Errorf("before a check = %q, want unknown", got.Label())
+	}
+
+	got, err := config.CheckRemoteChapters(context.Background(), manga)
+	if err != nil {
+		t.Fatalf("CheckRemoteChapters: %v", err)
+	}
+	if got.Label() != "behind 2" {
+		t.Errorf("after the check = %q, want behind 2", got.Label())
+	}
+	if hits := mock.images(); hits != 0 {
+		t.Errorf("the check fetched %d images", hits)
+	}
+
+	// The count is kept, a series keeping only its latest chapter is caught up
+	manga.KeepLatest = 1
+	if got := config.SeriesAvailability(manga); got.State != config.AvailabilityUpToDate {
+		t.Errorf("keep latest 1 = %q, want up to date", got.Label())
+	}
+}
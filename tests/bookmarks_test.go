@@ -0,0 +1,137 @@
+package integration
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/config"
+)
+
+func Test_DecodeBookmarks_SkipsMalformedEntry(t *testing.T) {
+	data := []byte(`{
+  "manga": [
+    {
+      "title": "Good Series",
+      "url": "https://example.com/good",
+      "chapters": "",
+      "location": "/library/good",
+      "site": "mgeko",
+      "shortname": ""
+    },
+    {
+      "title": "Broken Series",
+      "url": ["not", "a", "string"],
+      "chapters": 12,
+      "location": "/library/broken",
+      "site": "mgeko"
+    }
+  ]
+}`)
+
+	manga, skipped, err := config.DecodeBookmarks(data)
+	if err != nil {
+		t.Fatalf("DecodeBookmarks returned error: %v", err)
+	}
+	if skipped != 1 {
+		t.Fatalf("expected 1 skipped entry, got %d", skipped)
+	}
+	if len(manga.Manga) != 1 || manga.Manga[0].Title != "Good Series" {
+		t.Fatalf("expected only the valid entry to load, got %+v", manga.Manga)
+	}
+}
+
+func Test_DecodeBookmarks_KeepsEntriesBeforeSyntaxError(t *testing.T) {
+	data := []byte(`{"manga": [{"title": "Good Series", "site": "mgeko"}, {"title": "Truncated", "site": `)
+
+	manga, skipped, err := config.DecodeBookmarks(data)
+	if err == nil {
+		t.Fatalf("expected an error for truncated bookmarks")
+	}
+	if skipped != 1 {
+		t.Fatalf("expected 1 skipped entry, got %d", skipped)
+	}
+	if len(manga.Manga) != 1 || manga.Manga[0].Title != "Good Series" {
+		t.Fatalf("expected the entry before the syntax error to load, got %+v", manga.Manga)
+	}
+}
+
+func Test_Bookmarks_NotesRoundTrip(t *testing.T) {
+	// Written before bookmarks had notes
+	old := []byte(`{"manga": [{"title": "Old Series", "url": "https://example.com/old", "site": "mgeko"}]}`)
+	manga, skipped, err := config.DecodeBookmarks(old)
+	if err != nil || skipped != 0 {
+		t.Fatalf("DecodeBookmarks of a file without notes = %d skipped, %v", skipped, err)
+	}
+	if len(manga.Manga) != 1 || manga.Manga[0].Notes != "" {
+		t.Fatalf("expected the old entry to load without notes, got %+v", manga.Manga)
+	}
+
+	noted := manga.Manga[0]
+	noted.Notes = "switched to mangadex after asura dropped it\nrtl"
+	for name, open := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			if err := store.SaveBookmark(noted); err != nil {
+				t.Fatalf("SaveBookmark: %v", err)
+			}
+			bookmarks, err := store.ListBookmarks()
+			if err != nil {
+				t.Fatalf("ListBookmarks: %v", err)
+			}
+			if len(bookmarks) != 1 || bookmarks[0].Notes != noted.Notes {
+				t.Fatalf("ListBookmarks = %+v, want the notes back", bookmarks)
+			}
+		})
+	}
+}
+
+func Test_LoadBookmarksWithSkipped_BacksUpOncePerContent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	dir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	bookmarksFile := filepath.Join(dir, "bookmarks.json")
+	broken := []byte(`{"manga": [{"title": "Good", "url": "https://example.com/good"}, {"title": "Broken", "url": 12}]}`)
+	if err := os.WriteFile(bookmarksFile, broken, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	backups := func() []string {
+		t.Helper()
+		matches, err := filepath.Glob(bookmarksFile + ".corrupt-*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return matches
+	}
+
+	// Every load of the same broken file keeps the one backup
+	for i := 0; i < 3; i++ {
+		if manga, skipped := config.LoadBookmarksWithSkipped(); skipped != 1 || len(manga.Manga) != 1 {
+			t.Fatalf("load %d = %d bookmarks, %d skipped", i, len(manga.Manga), skipped)
+		}
+	}
+	first := backups()
+	if len(first) != 1 {
+		t.Fatalf("backups after three loads = %v, want one", first)
+	}
+	if data, _ := os.ReadFile(first[0]); !bytes.Equal(data, broken) {
+		t.Errorf("backup holds %q", data)
+	}
+
+	// A different broken file is backed up again, even within the same second
+	changed := bytes.Replace(broken, []byte(`"Good"`), []byte(`"Renamed"`), 1)
+	if err := os.WriteFile(bookmarksFile, changed, 0644); err != nil {
+		t.Fatal(err)
+	}
+	config.LoadBookmarksWithSkipped()
+	config.LoadBookmarksWithSkipped()
+	if got := backups(); len(got) != 2 {
+		t.Errorf("backups after the file changed = %v, want two", got)
+	}
+}
@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"kansho/config"
+)
+
+// TestBookmarkStoreConcurrentAccess edits bookmarks while other goroutines read them
+// and queue-style copies are taken, as the UI and downloads do. Run with -race.
+func TestBookmarkStoreConcurrentAccess(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var initial config.Manga
+	for i := 0; i < 20; i++ {
+		initial.Manga = append(initial.Manga, config.Bookmarks{
+			Title:    fmt.Sprintf("Series %02d", i),
+			Site:     "mgeko",
+			Location: fmt.Sprintf("/library/series-%02d", i),
+		})
+	}
+	store := config.NewBookmarkStore(initial)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				store.Update(i%20, func(manga *config.Bookmarks) {
+					manga.KeepLatest = i
+					manga.Completed = i%2 == 0
+					manga.Location = fmt.Sprintf("/library/%d/%d", w, i)
+				})
+				if i%50 == 0 {
+					if err := store.Save(); err != nil {
+						t.Errorf("Save: %v", err)
+					}
+				}
+			}
+		}(w)
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if manga, ok := store.Get(i % store.Len()); ok {
+					_ = manga.Location + manga.Title
+				}
+				for _, manga := range store.All() {
+					_ = manga.KeepLatest
+				}
+				config.BulkUpdateCandidates(store.All(), true)
+				config.LoadBookmarks()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			store.Add(config.Bookmarks{Title: fmt.Sprintf("Added %02d", i)})
+			// "Added" sorts before "Series", so it is the first entry once sorted
+			store.SortByTitle()
+			store.Delete(0)
+		}
+	}()
+	wg.Wait()
+
+	for _, manga := range store.All() {
+		if manga.Site != "mgeko" {
+			t.Errorf("unexpected bookmark left after adds and deletes: %+v", manga)
+		}
+	}
+
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	saved := config.LoadBookmarks()
+	if len(saved.Manga) != 20 {
+		t.Fatalf("saved %d bookmarks, want 20", len(saved.Manga))
+	}
+}
+
+func TestBookmarkStoreReturnsCopies(t *testing.T) {
+	store := config.NewBookmarkStore(config.Manga{Manga: []config.Bookmarks{{Title: "A", Location: "/a"}}})
+
+	manga, ok := store.Get(0)
+	if !ok {
+		t.Fatal("Get(0) found nothing")
+	}
+	manga.Location = "/changed"
+	all := store.All()
+	all[0].Title = "changed"
+
+	if got, _ := store.Get(0); got.Location != "/a" || got.Title != "A" {
+		t.Errorf("store changed through a copy: %+v", got)
+	}
+
+	if !store.Update(0, func(m *config.Bookmarks) { m.Location = "/b" }) {
+		t.Fatal("Update(0) found nothing")
+	}
+	if manga.Location != "/changed" {
+		t.Error("copy taken before Update was changed by it")
+	}
+	if _, ok := store.Get(1); ok {
+		t.Error("Get out of range returned a bookmark")
+	}
+	if store.Update(-1, func(*config.Bookmarks) {}) || store.Delete(5) {
+		t.Error("out of range Update/Delete reported success")
+	}
+}
@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"kansho/config"
+)
+
+func Test_Bookmarks_TagsRoundTrip(t *testing.T) {
+	// Written before bookmarks had tags
+	old := []byte(`{"manga": [{"title": "Old Series", "url": "https://example.com/old", "site": "mgeko"}]}`)
+	manga, skipped, err := config.DecodeBookmarks(old)
+	if err != nil || skipped != 0 {
+		t.Fatalf("DecodeBookmarks of a file without tags = %d skipped, %v", skipped, err)
+	}
+	if len(manga.Manga) != 1 || len(manga.Manga[0].Tags) != 0 {
+		t.Fatalf("expected the old entry to load without tags, got %+v", manga.Manga)
+	}
+
+	tagged := manga.Manga[0]
+	tagged.Tags = config.ParseTags(" Ongoing, isekai,,ONGOING , favorites")
+	if want := []string{"ongoing", "isekai", "favorites"}; !slices.Equal(tagged.Tags, want) {
+		t.Fatalf("ParseTags = %q, want %q", tagged.Tags, want)
+	}
+	for name, open := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			if err := store.SaveBookmark(tagged); err != nil {
+				t.Fatalf("SaveBookmark: %v", err)
+			}
+			bookmarks, err := store.ListBookmarks()
+			if err != nil {
+				t.Fatalf("ListBookmarks: %v", err)
+			}
+			if len(bookmarks) != 1 || !slices.Equal(bookmarks[0].Tags, tagged.Tags) {
+				t.Fatalf("ListBookmarks = %+v, want the tags back", bookmarks)
+			}
+		})
+	}
+}
+
+func Test_QueueTagged_SelectsTaggedSeries(t *testing.T) {
+	const siteName = "tags-test-site"
+	t.Setenv("HOME", t.TempDir())
+
+	var mu sync.Mutex
+	var downloaded []string
+	done := make(chan struct{}, 8)
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		mu.Lock()
+		downloaded = append(downloaded, manga.Title)
+		mu.Unlock()
+		done <- struct{}{}
+		return nil
+	})
+	queue := config.GetDownloadQueue()
+	defer queue.RemoveCompletedTasks()
+
+	mangas := []config.Bookmarks{
+		{Title: "Tags Isekai Ongoing", Site: siteName, Location: t.TempDir(), Tags: []string{"isekai", "ongoing"}},
+		{Title: "Tags Romance", Site: siteName, Location: t.TempDir(), Tags: []string{"romance"}},
+		{Title: "Tags Isekai Finished", Site: siteName, Location: t.TempDir(), Tags: []string{"isekai"}, Completed: true},
+		{Title: "Tags Untagged", Site: siteName, Location: t.TempDir()},
+		{Title: "Tags Isekai Mixed Case", Site: siteName, Location: t.TempDir(), Tags: []string{"Isekai"}},
+	}
+
+	if got, want := config.AllTags(mangas), []string{"isekai", "ongoing", "romance"}; !slices.Equal(got, want) {
+		t.Errorf("AllTags = %q, want %q", got, want)
+	}
+
+	queued, skipped := queue.QueueTagged(mangas, "isekai")
+	if queued != 2 || skipped != 1 {
+		t.Fatalf("QueueTagged = %d queued, %d skipped, want 2 and 1 (completed)", queued, skipped)
+	}
+	for range 2 {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("tagged series were not downloaded")
+		}
+	}
+	mu.Lock()
+	sort.Strings(downloaded)
+	mu.Unlock()
+	if want := []string{"Tags Isekai Mixed Case", "Tags Isekai Ongoing"}; !slices.Equal(downloaded, want) {
+		t.Errorf("downloaded %q, want %q", downloaded, want)
+	}
+
+	// Still in the queue from the first run, so skipped the second time
+	if queued, skipped := queue.QueueTagged(mangas, "isekai"); queued != 0 || skipped != 3 {
+		t.Errorf("second QueueTagged = %d queued, %d skipped, want 0 and 3", queued, skipped)
+	}
+}
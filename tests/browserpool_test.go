@@ -0,0 +1,414 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"kansho/downloader"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+func Test_BrowserPool_ReusesWarmBrowser(t *testing.T) {
+	launches := 0
+	var roots []context.Context
+
+	// Fake launcher: counts allocator creations without starting Chrome
+	pool := downloader.NewBrowserPool(func(opts []chromedp.ExecAllocatorOption) (context.Context, context.CancelFunc, error) {
+		launches++
+		ctx, cancel := context.WithCancel(context.Background())
+		roots = append(roots, ctx)
+		return ctx, cancel, nil
+	})
+
+	const ua = "kansho-test-agent"
+	for i := 0; i < 3; i++ {
+		tabCtx, release, err := pool.Acquire(context.Background(), ua, nil)
+		if err != nil {
+			t.Fatalf("navigation %d: Acquire failed: %v", i+1, err)
+		}
+		release()
+		if tabCtx.Err() == nil {
+			t.Fatalf("navigation %d: tab context still live after release", i+1)
+		}
+	}
+
+	if launches != 1 {
+		t.Fatalf("expected 1 browser launch across 3 navigations, got %d", launches)
+	}
+
+	// A different User-Agent needs its own browser
+	_, release, err := pool.Acquire(context.Background(), "other-agent", nil)
+	if err != nil {
+		t.Fatalf("Acquire with second UA failed: %v", err)
+	}
+	release()
+	if launches != 2 {
+		t.Fatalf("expected 2 browser launches after second UA, got %d", launches)
+	}
+
+	pool.Close()
+	for i, root := range roots {
+		if root.Err() == nil {
+			t.Fatalf("browser %d still running after Close", i+1)
+		}
+	}
+
+	if _, _, err := pool.Acquire(context.Background(), ua, nil); err == nil {
+		t.Fatalf("expected Acquire to fail on a closed pool")
+	}
+}
+
+func Test_BrowserPool_AlwaysCancelsAllocator(t *testing.T) {
+	var cancels []int
+	var crash []context.CancelFunc
+	panicNext := false
+
+	// Fake launcher: each browser records when its allocator cancel runs, crash[i]
+	// kills browser i from the outside
+	pool := downloader.NewBrowserPool(func(opts []chromedp.ExecAllocatorOption) (context.Context, context.CancelFunc, error) {
+		if panicNext {
+			panicNext = false
+			panic("launcher blew up")
+		}
+		i := len(cancels)
+		cancels = append(cancels, 0)
+		ctx, cancel := context.WithCancel(context.Background())
+		crash = append(crash, cancel)
+		return ctx, func() { cancels[i]++; cancel() }, nil
+	})
+
+	const ua = "kansho-test-agent"
+
+	// A timed out navigation takes its tab down with it
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	tabCtx, release, err := pool.Acquire(ctx, ua, nil)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	<-ctx.Done()
+	cancel()
+	select {
+	case <-tabCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("tab still live after its request context timed out")
+	}
+	release()
+
+	// A panic while the tab is in use still releases it through the deferred release
+	func() {
+		defer func() { recover() }()
+		tabCtx, release, err = pool.Acquire(context.Background(), ua, nil)
+		if err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+		defer release()
+		panic("navigation blew up")
+	}()
+	if tabCtx.Err() == nil {
+		t.Error("tab still live after a panic during navigation")
+	}
+
+	// The browser crashes, the next Acquire cancels its allocator before relaunching
+	crash[0]()
+	if _, release, err = pool.Acquire(context.Background(), ua, nil); err != nil {
+		t.Fatalf("Acquire after crash: %v", err)
+	}
+	release()
+	if len(cancels) != 2 || cancels[0] != 1 {
+		t.Fatalf("after a crash: launches = %d, allocator cancels of the crashed browser = %v", len(cancels), cancels)
+	}
+
+	// A panicking launcher leaves the pool usable
+	panicNext = true
+	func() {
+		defer func() { recover() }()
+		pool.Acquire(context.Background(), "panic-agent", nil)
+	}()
+	done := make(chan error, 1)
+	go func() {
+		_, release, err := pool.Acquire(context.Background(), "panic-agent", nil)
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Acquire after a launcher panic: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pool is wedged after a launcher panic")
+	}
+
+	pool.Close()
+	for i, n := range cancels {
+		if n != 1 {
+			t.Errorf("allocator cancel of browser %d ran %d times, want once", i+1, n)
+		}
+	}
+}
+
+// blockingLauncher is a fake launcher whose first launch waits for unblock,
+// started is signalled as each launch begins
+type blockingLauncher struct {
+	mu       sync.Mutex
+	launches int
+	roots    []context.Context
+	started  chan struct{}
+	unblock  chan struct{}
+}
+
+func newBlockingLauncher() *blockingLauncher {
+	return &blockingLauncher{started: make(chan struct{}, 10), unblock: make(chan struct{})}
+}
+
+func (l *blockingLauncher) launch(opts []chromedp.ExecAllocatorOption) (context.Context, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.mu.Lock()
+	l.launches++
+	first := l.launches == 1
+	l.roots = append(l.roots, ctx)
+	l.mu.Unlock()
+
+	l.started <- struct{}{}
+	if first {
+		<-l.unblock
+	}
+	return ctx, cancel, nil
+}
+
+func (l *blockingLauncher) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.launches
+}
+
+func Test_BrowserPool_LaunchesOutsideLock(t *testing.T) {
+	launcher := newBlockingLauncher()
+	pool := downloader.NewBrowserPool(launcher.launch)
+	defer pool.Close()
+
+	acquire := func(ua string) <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, release, err := pool.Acquire(context.Background(), ua, nil)
+			if err == nil {
+				release()
+			}
+			done <- err
+		}()
+		return done
+	}
+
+	slow := acquire("slow-agent")
+	<-launcher.started
+	sameUA := acquire("slow-agent")
+
+	// Another User-Agent is not held up by the slow launch
+	select {
+	case err := <-acquire("other-agent"):
+		if err != nil {
+			t.Fatalf("Acquire with another UA: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Acquire with another UA waited for a slow launch")
+	}
+
+	close(launcher.unblock)
+	for _, done := range []<-chan error{slow, sameUA} {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Acquire: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Acquire did not return after the launch finished")
+		}
+	}
+
+	// The second claim for the slow UA waited for its launch instead of starting one
+	if n := launcher.count(); n != 2 {
+		t.Errorf("expected 2 browser launches, got %d", n)
+	}
+}
+
+func Test_BrowserPool_CloseDuringLaunch(t *testing.T) {
+	launcher := newBlockingLauncher()
+	pool := downloader.NewBrowserPool(launcher.launch)
+
+	done := make(chan error, 1)
+	go func() {
+		_, release, err := pool.Acquire(context.Background(), "slow-agent", nil)
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	<-launcher.started
+
+	closed := make(chan struct{})
+	go func() {
+		pool.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close waited for a browser launch")
+	}
+
+	close(launcher.unblock)
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected Acquire to fail once the pool closed during its launch")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Acquire did not return after the launch finished")
+	}
+
+	launcher.mu.Lock()
+	defer launcher.mu.Unlock()
+	if launcher.roots[0].Err() == nil {
+		t.Error("browser launched while the pool closed is still running")
+	}
+}
+
+// requireChrome skips the test unless a Chrome chromedp can launch is installed
+func requireChrome(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("Chrome is only looked up on linux")
+	}
+	for _, name := range []string{"headless_shell", "headless-shell", "chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return
+		}
+	}
+	t.Skip("no Chrome installed")
+}
+
+func Test_BrowserPool_ReleaseKeepsOtherTabsCookies(t *testing.T) {
+	requireChrome(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name := r.URL.Query().Get("set"); name != "" {
+			http.SetCookie(w, &http.Cookie{Name: name, Value: "1", Path: "/"})
+		}
+		w.Write([]byte("<html><body>ok</body></html>"))
+	}))
+	defer server.Close()
+
+	pool := downloader.NewBrowserPool(nil)
+	defer pool.Close()
+	opts := chromedp.DefaultExecAllocatorOptions[:]
+	const ua = "kansho-test-agent"
+
+	// cookieNames returns the names of the cookies tab holds for the server
+	cookieNames := func(tab context.Context) map[string]bool {
+		t.Helper()
+		var cookies []*network.Cookie
+		err := chromedp.Run(tab, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = network.GetCookies().WithURLs([]string{server.URL + "/"}).Do(ctx)
+			return err
+		}))
+		if err != nil {
+			t.Fatalf("reading cookies: %v", err)
+		}
+		names := make(map[string]bool)
+		for _, ck := range cookies {
+			names[ck.Name] = true
+		}
+		return names
+	}
+
+	first, releaseFirst, err := pool.Acquire(context.Background(), ua, opts)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer releaseFirst()
+	if err := chromedp.Run(first, chromedp.Navigate(server.URL+"/?set=first")); err != nil {
+		t.Fatalf("first tab: %v", err)
+	}
+
+	second, releaseSecond, err := pool.Acquire(context.Background(), ua, opts)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if err := chromedp.Run(second, chromedp.Navigate(server.URL+"/?set=second")); err != nil {
+		t.Fatalf("second tab: %v", err)
+	}
+	if names := cookieNames(second); names["first"] {
+		t.Error("second tab sees the cookie of the first one")
+	}
+	releaseSecond()
+
+	if names := cookieNames(first); !names["first"] || names["second"] {
+		t.Errorf("first tab holds cookies %v after the second was released, want only its own", names)
+	}
+}
+
+func TestKillStrayBrowsers(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("stray browsers are found through /proc")
+	}
+
+	// A pid that has exited stands in for a kansho that crashed
+	gone := exec.Command("true")
+	if err := gone.Run(); err != nil {
+		t.Skipf("cannot run true: %v", err)
+	}
+	deadOwner := gone.Process.Pid
+
+	runFake := func(owner int) *exec.Cmd {
+		dataDir := filepath.Join(os.TempDir(), fmt.Sprintf("kansho-chrome-%d-test", owner))
+		// The trailing true stops the shell exec'ing sleep and losing the flag
+		cmd := exec.Command("sh", "-c", "sleep 30; true", "chrome", "--user-data-dir="+dataDir)
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("starting fake browser: %v", err)
+		}
+		t.Cleanup(func() { cmd.Process.Kill(); cmd.Wait() })
+		return cmd
+	}
+	stray := runFake(deadOwner)
+	// Owned by a kansho that is still running, here the test's parent process
+	alive := runFake(os.Getppid())
+	time.Sleep(100 * time.Millisecond)
+
+	killed, err := downloader.KillStrayBrowsers()
+	if err != nil {
+		t.Fatalf("KillStrayBrowsers: %v", err)
+	}
+	if killed != 1 {
+		t.Errorf("killed %d processes, want the 1 stray", killed)
+	}
+
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- stray.Wait() }()
+	select {
+	case err := <-waitErr:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Errorf("stray browser exited with %v, want killed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stray browser still running")
+	}
+
+	if _, err := os.Stat(fmt.Sprintf("/proc/%d", alive.Process.Pid)); err != nil {
+		t.Error("browser of a running kansho was killed")
+	}
+}
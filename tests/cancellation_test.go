@@ -0,0 +1,120 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kansho/downloader"
+)
+
+// newHangingServer answers no request until the test ends. Its channel receives a
+// value for every request aborted by the client.
+func newHangingServer(t *testing.T) (*httptest.Server, <-chan struct{}) {
+	t.Helper()
+	release := make(chan struct{})
+	aborted := make(chan struct{}, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			aborted <- struct{}{}
+		case <-release:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	return srv, aborted
+}
+
+// hangingAPISite lists its chapters with an API call that never answers
+type hangingAPISite struct {
+	skipSite
+	api string
+}
+
+func (s *hangingAPISite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			var feed map[string]any
+			if err := client.FetchJSON(client.Context(), s.api+"/feed", &feed); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		},
+	}
+}
+
+// hangingParserSite parses its chapter pages with a request of its own that never answers
+type hangingParserSite struct {
+	mockSitePlugin
+	api string
+}
+
+func (s *hangingParserSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	return &downloader.ImageExtractionMethod{
+		Type: "custom",
+		CustomParserContext: func(ctx context.Context, html string) ([]string, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.api+"/images", nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			resp.Body.Close()
+			return nil, nil
+		},
+	}
+}
+
+// waitAborted fails the test unless the hanging server saw its request aborted soon
+func waitAborted(t *testing.T, aborted <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-aborted:
+	case <-time.After(2 * time.Second):
+		t.Error("the request in flight was not aborted")
+	}
+}
+
+func TestCancellation_AbortsRequestsInFlight(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	t.Run("api client", func(t *testing.T) {
+		srv, aborted := newHangingServer(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(200*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := downloader.FetchChapterList(ctx, "http://127.0.0.1/series", &hangingAPISite{api: srv.URL})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("FetchChapterList = %v, want context.Canceled", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("FetchChapterList returned %v after the start, want it right after the cancel", elapsed)
+		}
+		waitAborted(t, aborted)
+	})
+
+	t.Run("custom parser", func(t *testing.T) {
+		mock := newMockMangaSite(t, map[int]int{1: 1})
+		srv, aborted := newHangingServer(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(200*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := downloader.FetchChapterImages(ctx, mock.server.URL+"/chapter/1", &hangingParserSite{api: srv.URL})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("FetchChapterImages = %v, want context.Canceled", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("FetchChapterImages returned %v after the start, want it right after the cancel", elapsed)
+		}
+		waitAborted(t, aborted)
+	})
+}
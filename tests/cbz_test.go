@@ -0,0 +1,187 @@
+package integration
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+	"kansho/sites"
+)
+
+// minimal RIFF/WEBP header, enough for magic byte detection
+var webpBytes = []byte{'R', 'I', 'F', 'F', 0x1a, 0, 0, 0, 'W', 'E', 'B', 'P', 'V', 'P', '8', 'L'}
+
+// minimal JPEG header, enough for magic byte detection
+var jpegBytes = []byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 0x10, 'J', 'F', 'I', 'F', 0, 1, 1, 0}
+
+func Test_CreateCbzFromDir_NativeWebPOrder(t *testing.T) {
+	srcDir := t.TempDir()
+
+	// Native WebP pages saved without re-encoding keep their .webp extension
+	for _, page := range []string{"1", "2", "10"} {
+		if err := parser.SaveImage(webpBytes, srcDir, page, true); err != nil {
+			t.Fatalf("SaveImage(%s) failed: %v", page, err)
+		}
+	}
+	// Mixed extension page in the middle of the chapter
+	if err := parser.SaveImage(jpegBytes, srcDir, "3", true); err != nil {
+		t.Fatalf("SaveImage(3) failed: %v", err)
+	}
+	// Unpadded original filename must still sort by page number
+	if err := os.WriteFile(filepath.Join(srcDir, "4.webp"), webpBytes, 0644); err != nil {
+		t.Fatalf("failed to write 4.webp: %v", err)
+	}
+
+	cbzPath := filepath.Join(t.TempDir(), "ch001.cbz")
+	if err := parser.CreateCbzFromDir(srcDir, cbzPath); err != nil {
+		t.Fatalf("CreateCbzFromDir failed: %v", err)
+	}
+
+	reader, err := zip.OpenReader(cbzPath)
+	if err != nil {
+		t.Fatalf("failed to open cbz: %v", err)
+	}
+	defer reader.Close()
+
+	want := []string{"001.webp", "002.webp", "003.jpg", "4.webp", "010.webp"}
+	if len(reader.File) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(reader.File))
+	}
+	for i, f := range reader.File {
+		if f.Name != want[i] {
+			t.Fatalf("entry %d: expected %s, got %s", i, want[i], f.Name)
+		}
+	}
+}
+
+func Test_PackageChapter_RetriesTransientCreateError(t *testing.T) {
+	chapterDir := filepath.Join(t.TempDir(), "ch001")
+	if err := os.MkdirAll(chapterDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.SaveImage(jpegBytes, chapterDir, "1", true); err != nil {
+		t.Fatalf("SaveImage: %v", err)
+	}
+
+	// Something briefly sits where the CBZ goes, the first attempt cannot create it
+	cbzPath := filepath.Join(t.TempDir(), "ch001.cbz")
+	if err := os.Mkdir(cbzPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		os.Remove(cbzPath)
+	}()
+
+	if err := parser.PackageChapter(chapterDir, cbzPath); err != nil {
+		t.Fatalf("PackageChapter: %v", err)
+	}
+	reader, err := zip.OpenReader(cbzPath)
+	if err != nil {
+		t.Fatalf("failed to open cbz: %v", err)
+	}
+	defer reader.Close()
+	if len(reader.File) != 1 || reader.File[0].Name != "001.jpg" {
+		t.Errorf("cbz holds %d entries, want the one page", len(reader.File))
+	}
+	if _, err := os.Stat(chapterDir); !os.IsNotExist(err) {
+		t.Errorf("temp dir left behind after packaging: %v", err)
+	}
+}
+
+func Test_PackageChapter_KeepsPagesWhenCreateKeepsFailing(t *testing.T) {
+	chapterDir := t.TempDir()
+	if err := parser.SaveImage(jpegBytes, chapterDir, "1", true); err != nil {
+		t.Fatalf("SaveImage: %v", err)
+	}
+
+	calls := 0
+	err := parser.RetryFileOp(func() error {
+		calls++
+		return parser.CreateCbzFromDir(chapterDir, filepath.Join(chapterDir, "missing", "ch001.cbz"))
+	})
+	if err == nil {
+		t.Fatal("writing into a missing directory succeeded")
+	}
+	if calls != 3 {
+		t.Errorf("tried %d times, want 3", calls)
+	}
+
+	if err := parser.PackageChapter(chapterDir, filepath.Join(chapterDir, "missing", "ch001.cbz")); err == nil {
+		t.Fatal("PackageChapter into a missing directory succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(chapterDir, "001.jpg")); err != nil {
+		t.Errorf("pages removed after a failed package: %v", err)
+	}
+}
+
+func Test_CreateCbzFromDir_NormalizesExtensions(t *testing.T) {
+	srcDir := t.TempDir()
+	for _, name := range []string{"1.JPEG", "2.jpg", "3.PNG", "4.Jpg", "10.jpeg", "ComicInfo.xml"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), jpegBytes, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := map[string][]string{
+		parser.CbzExtensionsAsIs:      {"1.JPEG", "2.jpg", "3.PNG", "4.Jpg", "10.jpeg", "ComicInfo.xml"},
+		parser.CbzExtensionsLowercase: {"1.jpeg", "2.jpg", "3.png", "4.jpg", "10.jpeg", "ComicInfo.xml"},
+		parser.CbzExtensionsStandard:  {"1.jpg", "2.jpg", "3.png", "4.jpg", "10.jpg", "ComicInfo.xml"},
+	}
+	defer parser.SetCbzExtensionRule(parser.CbzExtensionsAsIs)
+	for rule, want := range cases {
+		parser.SetCbzExtensionRule(rule)
+		cbzPath := filepath.Join(t.TempDir(), "ch001.cbz")
+		if err := parser.CreateCbzFromDir(srcDir, cbzPath); err != nil {
+			t.Fatalf("rule %q: CreateCbzFromDir: %v", rule, err)
+		}
+		if names, _ := readZipEntries(t, cbzPath); !slices.Equal(names, want) {
+			t.Errorf("rule %q: entries = %v, want %v", rule, names, want)
+		}
+	}
+
+	// Lowercasing 1.JPG would clash with 1.jpg, the page keeps its name
+	clashDir := t.TempDir()
+	for _, name := range []string{"1.jpg", "1.JPG", "2.jpg"} {
+		os.WriteFile(filepath.Join(clashDir, name), jpegBytes, 0644)
+	}
+	parser.SetCbzExtensionRule(parser.CbzExtensionsLowercase)
+	cbzPath := filepath.Join(t.TempDir(), "clash.cbz")
+	if err := parser.CreateCbzFromDir(clashDir, cbzPath); err != nil {
+		t.Fatalf("CreateCbzFromDir: %v", err)
+	}
+	if names, _ := readZipEntries(t, cbzPath); !slices.Equal(names, []string{"1.JPG", "1.jpg", "2.jpg"}) {
+		t.Errorf("entries with a clash = %v", names)
+	}
+}
+
+func Test_KeepNativeImageFormat_FollowsSetting(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	nativeSites := map[string]downloader.NativeImageSite{
+		"asura":    &sites.AsuraSite{},
+		"kunmanga": &sites.KunmangaSite{},
+	}
+	for name, site := range nativeSites {
+		if site.KeepNativeImageFormat() {
+			t.Errorf("%s: pages should be converted to JPEG by default", name)
+		}
+	}
+
+	if err := config.UpdateSettings(func(settings *config.Settings) {
+		settings.KeepNativeImageFormat = true
+	}); err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	for name, site := range nativeSites {
+		if !site.KeepNativeImageFormat() {
+			t.Errorf("%s: keep_native_image_format should keep pages in their native format", name)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+func TestCbzWriter_OrdersOutOfOrderPages(t *testing.T) {
+	dir := t.TempDir()
+	cbzPath := filepath.Join(dir, "ch001.cbz")
+
+	w, err := parser.NewCbzWriter(cbzPath)
+	if err != nil {
+		t.Fatalf("NewCbzWriter: %v", err)
+	}
+	defer w.Abort()
+
+	// Page i is i*10 pixels wide, page 4 never downloads
+	page := func(i int) []byte { return encodePNG(t, i*10, 20) }
+	for _, i := range []int{3, 5, 1} {
+		if err := w.AddImage(i, page(i)); err != nil {
+			t.Fatalf("AddImage(%d): %v", i, err)
+		}
+	}
+	if n := w.Pages(); n != 1 {
+		t.Errorf("%d pages written after pages 3, 5 and 1 arrived, want only page 1", n)
+	}
+	if err := w.AddImage(2, page(2)); err != nil {
+		t.Fatalf("AddImage(2): %v", err)
+	}
+	if err := w.Skip(4); err != nil {
+		t.Fatalf("Skip(4): %v", err)
+	}
+	if n := w.Pages(); n != 4 {
+		t.Errorf("%d pages written once the gap was skipped, want 4", n)
+	}
+	if err := w.AddImage(2, page(2)); err == nil {
+		t.Error("adding page 2 twice succeeded")
+	}
+	w.AddImage(7, page(7))
+	w.SetComicInfo(parser.ComicInfo{Series: "Streamed", Number: "001"})
+
+	if _, err := os.Stat(cbzPath); !os.IsNotExist(err) {
+		t.Fatalf("cbz exists before Close: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	names, data := readZipEntries(t, cbzPath)
+	want := []string{"001.png", "002.png", "003.png", "005.png", "007.png", parser.ComicInfoFileName}
+	if !slices.Equal(names, want) {
+		t.Fatalf("entries = %v, want %v", names, want)
+	}
+	for _, name := range want[:5] {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data[name]))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if wantWidth := int(name[2]-'0') * 10; cfg.Width != wantWidth {
+			t.Errorf("%s is %d wide, holds the wrong page (want %d)", name, cfg.Width, wantWidth)
+		}
+	}
+
+	var info parser.ComicInfo
+	if err := xml.Unmarshal(data[parser.ComicInfoFileName], &info); err != nil {
+		t.Fatalf("ComicInfo: %v", err)
+	}
+	if info.PageCount != 5 || info.Pages == nil || info.Pages.Page[3].ImageWidth != 50 {
+		t.Errorf("ComicInfo = %+v, want the 5 written pages", info)
+	}
+
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, ".kansho-cbz-*")); len(leftovers) != 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestCbzWriter_AbortLeavesNothing(t *testing.T) {
+	dir := t.TempDir()
+	w, err := parser.NewCbzWriter(filepath.Join(dir, "ch001.cbz"))
+	if err != nil {
+		t.Fatalf("NewCbzWriter: %v", err)
+	}
+	if err := w.AddImage(1, encodePNG(t, 4, 4)); err != nil {
+		t.Fatalf("AddImage: %v", err)
+	}
+	if err := w.AddImage(2, []byte("not an image")); err == nil {
+		t.Error("AddImage accepted data that is not an image")
+	}
+	w.Abort()
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("aborted writer left %d files behind", len(entries))
+	}
+}
+
+func Test_MockSite_StreamedCbz(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"stream_cbz": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := newMockMangaSite(t, map[int]int{1: 3})
+	site := &mockSitePlugin{}
+	manga := &config.Bookmarks{Title: "Mock Streamed Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: site.GetSiteName()}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(tempDir)) })
+
+	if err := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site}).Download(context.Background()); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	assertMockLibrary(t, mock, manga.Location)
+
+	if leftovers, _ := filepath.Glob(filepath.Join(manga.Location, ".kansho-cbz-*")); len(leftovers) != 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
+
+func Test_MockSite_StreamedCbzRetriesUnstreamablePage(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"stream_cbz": true, "image_concurrency": 3}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := newMockMangaSite(t, map[int]int{1: 3})
+	site := &mockSitePlugin{}
+	manga := &config.Bookmarks{Title: "Mock Streamed Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: site.GetSiteName()}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(tempDir)) })
+
+	// Page 2 downloads fine but cannot be read back into the CBZ (the directory
+	// sorts before 002.jpg), the attempt fails instead of packaging the chapter
+	// without it and the retry starts from a clean temp dir
+	if err := os.MkdirAll(filepath.Join(tempDir, "002.d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site}).Download(context.Background()); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	assertMockLibrary(t, mock, manga.Location)
+}
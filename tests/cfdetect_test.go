@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"testing"
+
+	"kansho/cf"
+)
+
+func Test_DetectHTML_ChallengeVariants(t *testing.T) {
+	cases := []struct {
+		name string
+		html string
+		want bool
+	}{
+		{
+			name: "just a moment interstitial",
+			html: `<!DOCTYPE html><html><head><title>Just a moment...</title></head><body><noscript>Enable JavaScript and cookies to continue</noscript></body></html>`,
+			want: true,
+		},
+		{
+			name: "title split over lines",
+			html: "<html><head><title>\n  Just a moment...\n</title></head><body></body></html>",
+			want: true,
+		},
+		{
+			name: "attention required block page",
+			html: `<html><head><title>Attention Required! | Cloudflare</title></head><body><h1>Sorry, you have been blocked</h1></body></html>`,
+			want: true,
+		},
+		{
+			name: "please wait interstitial",
+			html: `<html><head><title>Please Wait... | Cloudflare</title></head><body></body></html>`,
+			want: true,
+		},
+		{
+			name: "managed challenge with neutral title",
+			html: `<html><head><title>example.com</title></head><body><script>window._cf_chl_opt={cvId:'3',cType:'managed'};</script>` +
+				`<script src="/cdn-cgi/challenge-platform/h/g/orchestrate/chl_page/v1?ray=abc"></script></body></html>`,
+			want: true,
+		},
+		{
+			name: "normal page with comment phrase and jsd script",
+			html: `<html><head><title>Solo Leveling Chapter 12</title></head><body>` +
+				`<div class="comment">i was at 19 just a moment ago</div>` +
+				`<script src="/cdn-cgi/challenge-platform/scripts/jsd/main.js"></script>` +
+				`<div class="reading-content"><img src="https://cdn.example.com/1.jpg"></div></body></html>`,
+			want: false,
+		},
+		{
+			name: "empty chapter list page",
+			html: `<html><head><title>Series - No chapters yet</title></head><body><ul class="chapters"></ul></body></html>`,
+			want: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, info, err := cf.DetectHTML(tc.html)
+			if err != nil {
+				t.Fatalf("DetectHTML error: %v", err)
+			}
+			if got != tc.want {
+				var indicators []string
+				if info != nil {
+					indicators = info.Indicators
+				}
+				t.Fatalf("DetectHTML = %v, want %v (indicators %v)", got, tc.want, indicators)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package integration
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/cf"
+
+	"github.com/gocolly/colly"
+)
+
+func TestNormalizeDomain(t *testing.T) {
+	for in, want := range map[string]string{
+		"example.test":                      "example.test",
+		"www.example.test":                  "example.test",
+		"WWW.Example.Test.":                 "example.test",
+		"https://www.example.test/series/1": "example.test",
+		"http://example.test:8080":          "example.test",
+		".www.example.test":                 "example.test",
+		"cdn.example.test":                  "cdn.example.test",
+		"www.test":                          "www.test",
+		"127.0.0.1":                         "127.0.0.1",
+	} {
+		if got := cf.NormalizeDomain(in); got != want {
+			t.Errorf("NormalizeDomain(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func bypassDataFor(cookieDomain string) *cf.BypassData {
+	return &cf.BypassData{
+		Type:              cf.ProtectionCookie,
+		Domain:            cookieDomain,
+		Headers:           map[string]string{},
+		Entropy:           cf.Entropy{UserAgent: capturedTestUA},
+		CfClearanceStruct: &cf.CfClearanceCookie{Name: "cf_clearance", Value: "token", Domain: cookieDomain, Path: "/"},
+		AllCookies:        []cf.Cookie{{Name: "__cf_bm", Value: "bm", Domain: cookieDomain, Path: "/"}},
+	}
+}
+
+func TestBypassData_SharedAcrossWWWAndApex(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	dir := filepath.Join(config, "kansho", "cf")
+
+	// Captured on www, looked up by the apex the bookmark points at
+	if err := cf.SaveToFile(bypassDataFor("www.example.test"), "https://www.example.test/"); err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "example.test.json")); err != nil {
+		t.Fatalf("data not stored under the normalized key: %v", err)
+	}
+	for _, domain := range []string{"example.test", "www.example.test"} {
+		if _, err := cf.LoadFromFile(domain); err != nil {
+			t.Errorf("LoadFromFile(%q): %v", domain, err)
+		}
+		if err := cf.CheckClearance(domain); err != nil {
+			t.Errorf("CheckClearance(%q): %v", domain, err)
+		}
+	}
+	if err := cf.DeleteDomain("www.example.test"); err != nil {
+		t.Fatalf("DeleteDomain: %v", err)
+	}
+	if _, err := cf.LoadFromFile("example.test"); err == nil {
+		t.Error("data still loads after DeleteDomain")
+	}
+
+	// A file saved under www before keys were normalized is still found, and
+	// replaced by the normalized one on the next save
+	legacy, err := json.Marshal(bypassDataFor("www.example.test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "www.example.test.json"), legacy, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cf.LoadFromFile("example.test"); err != nil {
+		t.Fatalf("legacy www file not found for the apex: %v", err)
+	}
+	if err := cf.MarkCookieAsFailed("example.test"); err != nil {
+		t.Fatalf("MarkCookieAsFailed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "www.example.test.json")); !os.IsNotExist(err) {
+		t.Errorf("legacy file left behind after saving: %v", err)
+	}
+	if domains, _ := cf.ListStoredDomains(); len(domains) != 1 || domains[0] != "example.test" {
+		t.Errorf("stored domains = %v, want [example.test]", domains)
+	}
+
+	// The apex redirects to www: the collector must hold the cookies for both
+	c := colly.NewCollector()
+	if err := cf.ApplyToCollector(c, "http://example.test/series"); err != nil {
+		t.Fatalf("ApplyToCollector: %v", err)
+	}
+	for _, target := range []string{"http://example.test/series", "https://www.example.test/series"} {
+		got := map[string]string{}
+		for _, cookie := range c.Cookies(target) {
+			got[cookie.Name] = cookie.Value
+		}
+		if got["cf_clearance"] != "token" || got["__cf_bm"] != "bm" {
+			t.Errorf("cookies for %s = %v, want cf_clearance and __cf_bm", target, got)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kansho/cf"
+	"kansho/config"
+)
+
+func Test_UnattendedTask_SkipsCFSiteWithExpiredCookie(t *testing.T) {
+	const siteName = "cfpreflight-test-site"
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	// Bypass data whose cf_clearance expired an hour ago
+	expired := time.Now().Add(-time.Hour)
+	data, err := json.Marshal(cf.BypassData{
+		Type:              cf.ProtectionCookie,
+		Domain:            "cfpreflight.example",
+		CfClearanceStruct: &cf.CfClearanceCookie{Name: "cf_clearance", Value: "stale", Domain: "cfpreflight.example", Path: "/", Expires: &expired},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(home, ".config", "kansho", "cf")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cfpreflight.example.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var runs atomic.Int32
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		runs.Add(1)
+		return nil
+	})
+	config.RegisterCFProtected(siteName)
+
+	queue := config.GetDownloadQueue()
+	updates := make(chan config.DownloadTask, 8)
+	unsubscribe := queue.Subscribe(config.QueueListener{
+		OnTaskUpdated: func(tk *config.DownloadTask) {
+			if tk.Manga.Site == siteName && (tk.Status == "waiting_cf" || tk.Status == "completed") {
+				updates <- *tk
+			}
+		},
+	})
+	defer unsubscribe()
+	defer queue.RemoveCompletedTasks()
+
+	waitStatus := func(status string) config.DownloadTask {
+		t.Helper()
+		select {
+		case task := <-updates:
+			if task.Status != status {
+				t.Fatalf("task %s (%s), want %s", task.Status, task.StatusMessage, status)
+			}
+			return task
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the task to be %s", status)
+		}
+		return config.DownloadTask{}
+	}
+
+	manga := &config.Bookmarks{Title: "CF Preflight", Site: siteName, Url: "https://cfpreflight.example/series", Location: t.TempDir()}
+	task, err := queue.AddUnattendedTask(manga)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	skipped := waitStatus("waiting_cf")
+	var skipErr *config.CFSkippedError
+	if !errors.As(skipped.Error, &skipErr) || skipErr.Domain != "cfpreflight.example" {
+		t.Errorf("task error = %v, want a CFSkippedError for cfpreflight.example", skipped.Error)
+	}
+	if runs.Load() != 0 {
+		t.Fatalf("site download ran %d times for a skipped task", runs.Load())
+	}
+
+	// Retried by the user the task runs, and may face the challenge interactively
+	if err := queue.RetryTask(task.ID); err != nil {
+		t.Fatalf("RetryTask: %v", err)
+	}
+	waitStatus("completed")
+	if runs.Load() != 1 {
+		t.Errorf("site download ran %d times after the retry, want 1", runs.Load())
+	}
+}
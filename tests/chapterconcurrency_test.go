@@ -0,0 +1,104 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"kansho/config"
+	"kansho/downloader"
+)
+
+// poolSite serves three single page chapters through API extraction
+type poolSite struct{ skipSite }
+
+func (s *poolSite) GetSiteName() string { return "chapterpooltest" }
+
+func (s *poolSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			var chapters []map[string]string
+			for n := 1; n <= 3; n++ {
+				chapters = append(chapters, map[string]string{"url": fmt.Sprintf("%s/chapter/%d", s.base, n), "number": fmt.Sprint(n)})
+			}
+			return chapters, nil
+		},
+	}
+}
+
+func Test_Manager_DownloadsChaptersInParallel(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"chapter_concurrency": 3}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	page := encodePNG(t, 8, 8)
+	var mu sync.Mutex
+	var starts []time.Time
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		// Held longer than the domain limiter interval, so the next chapter's page is
+		// requested while this one is still downloading
+		time.Sleep(2 * time.Second)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(page)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	site := &poolSite{skipSite{base: server.URL}}
+	manga := &config.Bookmarks{
+		Title:    "Chapter Pool Test",
+		Url:      server.URL + "/series",
+		Location: t.TempDir(),
+		Site:     site.GetSiteName(),
+	}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(filepath.Dir(tempDir))) })
+
+	manager := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site})
+	if err := manager.Download(context.Background()); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	for n := 1; n <= 3; n++ {
+		if _, err := os.Stat(filepath.Join(manga.Location, fmt.Sprintf("ch00%d.cbz", n))); err != nil {
+			t.Errorf("chapter %d: %v", n, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxInFlight < 2 {
+		t.Errorf("at most %d page requests in flight, want chapters downloading in parallel", maxInFlight)
+	}
+	// The domain limiter still spaces the requests out
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < time.Second {
+			t.Errorf("requests %d and %d were %v apart, want them paced by the domain limiter", i, i+1, gap)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+// publishedSite is resumeSite with three chapters, two of them carrying a publish time
+type publishedSite struct {
+	resumeSite
+	published map[string]time.Time
+}
+
+func (s *publishedSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			var chapters []map[string]string
+			for _, number := range []string{"1", "2", "3"} {
+				data := map[string]string{"url": s.imageBase + "/chapter/" + number, "number": number}
+				if at, ok := s.published[number]; ok {
+					data[downloader.ChapterPublishedKey] = at.Format(time.RFC3339)
+				}
+				chapters = append(chapters, data)
+			}
+			return chapters, nil
+		},
+	}
+}
+
+func Test_Manager_DelaysRecentChapters(t *testing.T) {
+	served := encodePNG(t, 30, 40)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(served)
+	}))
+	defer server.Close()
+
+	site := &publishedSite{
+		resumeSite: resumeSite{imageBase: server.URL},
+		published: map[string]time.Time{
+			"1": time.Now().Add(-72 * time.Hour),
+			"2": time.Now().Add(-2 * time.Hour),
+		},
+	}
+	manga := &config.Bookmarks{
+		Title:                "Delay Test",
+		Url:                  server.URL + "/series",
+		Location:             t.TempDir(),
+		Site:                 site.GetSiteName(),
+		DelayNewChapterHours: 24,
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(filepath.Dir(downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")))
+	})
+
+	manager := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site})
+	if err := manager.Download(context.Background()); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	chapters, err := parser.LocalChapterList(manga.Location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"ch001.cbz": true, "ch003.cbz": true}
+	if len(chapters) != len(want) {
+		t.Fatalf("downloaded %v, want the old and the untimed chapter only", chapters)
+	}
+	for _, chapter := range chapters {
+		if !want[chapter] {
+			t.Errorf("%s was downloaded inside the delay window", chapter)
+		}
+	}
+
+	// Without a delay the held back chapter is picked up on the next run
+	manga.DelayNewChapterHours = 0
+	manager = downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site})
+	if err := manager.Download(context.Background()); err != nil {
+		t.Fatalf("Download without delay: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(manga.Location, "ch002.cbz")); err != nil {
+		t.Errorf("deferred chapter not downloaded once the delay is off: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+	"kansho/sites"
+)
+
+func TestChapterFilename_Templates(t *testing.T) {
+	defer parser.SetChapterFilenameTemplate("")
+
+	cases := []struct {
+		template string
+		main     string
+		part     string
+		want     string
+	}{
+		{"", "1", "", "ch001.cbz"},
+		{"", "91", "5", "ch091.5.cbz"},
+		{"", "1000", "", "ch1000.cbz"},
+		{"", "72", "5.1", "ch072.5.1.cbz"},
+		{"Chapter {main:04}{part}.cbz", "12", "", "Chapter 0012.cbz"},
+		{"Chapter {main:04}{part}.cbz", "12", "5", "Chapter 0012.5.cbz"},
+		{"{main:04}{part}.cbz", "7", "10", "0007.10.cbz"},
+		{"c{main}{part}.CBZ", "7", "", "c7.CBZ"},
+	}
+	for _, c := range cases {
+		if err := parser.SetChapterFilenameTemplate(c.template); err != nil {
+			t.Fatalf("SetChapterFilenameTemplate(%q): %v", c.template, err)
+		}
+		if got := parser.ChapterFilename(c.main, c.part); got != c.want {
+			t.Errorf("%q: ChapterFilename(%q, %q) = %q, want %q", c.template, c.main, c.part, got, c.want)
+		}
+	}
+
+	// Sites render their names through the template
+	if err := parser.SetChapterFilenameTemplate("Chapter {main:04}{part}.cbz"); err != nil {
+		t.Fatal(err)
+	}
+	site := sites.NewMgekoSite()
+	if got := site.NormalizeChapterFilename(map[string]string{"url": "https://www.mgeko.cc/read-manga/x/chapter-72-5"}); got != "Chapter 0072.5.cbz" {
+		t.Errorf("mgeko chapter 72-5 = %q, want Chapter 0072.5.cbz", got)
+	}
+	if got := parser.ChapterLabel("Chapter 0072.5.cbz"); got != "0072.5" {
+		t.Errorf("ChapterLabel = %q, want 0072.5", got)
+	}
+
+	for _, template := range []string{
+		"ch{main:03}.cbz", "ch{part}{main}.cbz", "ch{main:03}-{part}.cbz", "ch{main:03}{part}.zip",
+		"ch{main:0}{part}.cbz", "{chapter}{part}.cbz", "ch{main}{part}{main}.cbz", "vol/{main}{part}.cbz",
+		"ch{main:03}{part}5.cbz", "{ch{main}{part}.cbz",
+	} {
+		if err := parser.ValidateChapterFilenameTemplate(template); err == nil {
+			t.Errorf("ValidateChapterFilenameTemplate(%q) = nil, want an error", template)
+		}
+		if err := parser.SetChapterFilenameTemplate(template); err == nil {
+			t.Errorf("SetChapterFilenameTemplate(%q) = nil, want an error", template)
+		}
+	}
+	if got := parser.ChapterFilenameTemplate(); got != "Chapter {main:04}{part}.cbz" {
+		t.Errorf("template after invalid ones = %q, want it unchanged", got)
+	}
+}
+
+func TestChapterFilename_LocalListsAndSorting(t *testing.T) {
+	defer parser.SetChapterFilenameTemplate("")
+
+	for _, template := range []string{"Chapter {main:04}{part}.cbz", "{main:02}{part}.cbz"} {
+		if err := parser.SetChapterFilenameTemplate(template); err != nil {
+			t.Fatal(err)
+		}
+		chapters := [][2]string{{"100", "10"}, {"2", ""}, {"91", "5"}, {"1000", ""}, {"100", "9"}, {"91", ""}}
+		dir := t.TempDir()
+		for _, c := range chapters {
+			if err := os.WriteFile(filepath.Join(dir, parser.ChapterFilename(c[0], c[1])), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cover.jpg"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		local, err := parser.LocalChapterList(dir)
+		if err != nil {
+			t.Fatalf("LocalChapterList: %v", err)
+		}
+		parser.SortChaptersNumeric(local)
+		var want []string
+		for _, c := range [][2]string{{"2", ""}, {"91", ""}, {"91", "5"}, {"100", "10"}, {"100", "9"}, {"1000", ""}} {
+			want = append(want, parser.ChapterFilename(c[0], c[1]))
+		}
+		if !slices.Equal(local, want) {
+			t.Errorf("%q: sorted local chapters = %v, want %v", template, local, want)
+		}
+
+		for _, name := range want {
+			if _, ok := parser.ChapterSortValue(name); !ok {
+				t.Errorf("%q: ChapterSortValue(%q) has no chapter number", template, name)
+			}
+		}
+		if _, _, value, err := parser.ParseChapterNumber(parser.ChapterFilename("91", "5")); err != nil || value != 91.5 {
+			t.Errorf("%q: chapter 91.5 read back as %v, %v", template, value, err)
+		}
+	}
+}
+
+func TestChapterFilenameTemplateSetting(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(configDir, "settings.json")
+	if err := os.WriteFile(path, []byte(`{"chapter_filename_template": "ch{main:03}.cbz"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.LoadSettings().ChapterFilenameTemplate; got != "" {
+		t.Errorf("invalid template loaded as %q, want it ignored", got)
+	}
+
+	if err := config.UpdateSettings(func(settings *config.Settings) {
+		settings.ChapterFilenameTemplate = "{main}.cbz"
+	}); err == nil {
+		t.Error("UpdateSettings saved an invalid template")
+	}
+	if err := config.UpdateSettings(func(settings *config.Settings) {
+		settings.ChapterFilenameTemplate = "Chapter {main:04}{part}.cbz"
+	}); err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	if got := config.LoadSettings().ChapterFilenameTemplate; got != "Chapter {main:04}{part}.cbz" {
+		t.Errorf("template = %q, want the saved one", got)
+	}
+}
+
+// templateSite lists chapters 1 to 6 named by the chapter filename template
+type templateSite struct {
+	resumeSite
+}
+
+func (s *templateSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			var chapters []map[string]string
+			for number := 1; number <= 6; number++ {
+				chapters = append(chapters, map[string]string{"url": fmt.Sprintf("%s/chapter/%d", s.imageBase, number), "number": fmt.Sprint(number)})
+			}
+			return chapters, nil
+		},
+	}
+}
+
+func (s *templateSite) NormalizeChapterFilename(data map[string]string) string {
+	return parser.ChapterFilename(data["number"], "")
+}
+
+func TestChapterFilename_NewTemplateKeepsLibrary(t *testing.T) {
+	defer parser.SetChapterFilenameTemplate("")
+	t.Setenv("HOME", t.TempDir())
+
+	page := encodePNG(t, 4, 4)
+	var mu sync.Mutex
+	var images []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		images = append(images, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	// Chapters 1 to 5 were downloaded under the default template
+	location := t.TempDir()
+	for _, name := range chapterNames(1, 5) {
+		writeStoredCbz(t, filepath.Join(location, name), sampleCbzEntries(t))
+	}
+	if err := parser.SetChapterFilenameTemplate("Chapter {main:04}{part}.cbz"); err != nil {
+		t.Fatal(err)
+	}
+
+	site := &templateSite{resumeSite{imageBase: server.URL}}
+	manga := &config.Bookmarks{Title: "Template Test", Url: server.URL + "/series", Location: location}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "Chapter 0006.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(filepath.Dir(tempDir))) })
+	if err := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site}).Download(context.Background()); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	// Only the new chapter is downloaded, under the new template
+	mu.Lock()
+	defer mu.Unlock()
+	if len(images) != 3 {
+		t.Errorf("%d image requests, want the 3 pages of chapter 6 only", len(images))
+	}
+	local, err := parser.LocalChapterList(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.SortChaptersNumeric(local)
+	if want := append(chapterNames(1, 5), "Chapter 0006.cbz"); !slices.Equal(local, want) {
+		t.Errorf("library = %q, want %q", local, want)
+	}
+}
@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kansho/config"
+	"kansho/downloader"
+)
+
+// countingListSite is the mock site counting how often its chapter list is scraped
+type countingListSite struct {
+	mockSitePlugin
+	scrapes atomic.Int32
+}
+
+func (s *countingListSite) GetSiteName() string { return "chapterlistcachetest" }
+
+func (s *countingListSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "custom",
+		CustomParser: func(html string) (map[string]string, error) {
+			s.scrapes.Add(1)
+			return mockChapterLinks(html)
+		},
+	}
+}
+
+func Test_CachedChapterList_ReusedWithinWindow(t *testing.T) {
+	mock := newMockMangaSite(t, map[int]int{1: 1, 2: 1})
+	site := &countingListSite{}
+	seriesURL := mock.server.URL + "/series"
+	const ttl = 500 * time.Millisecond
+
+	fetch := func(ctx context.Context) downloader.ChapterList {
+		t.Helper()
+		list, err := downloader.CachedChapterList(ctx, seriesURL, site, ttl)
+		if err != nil {
+			t.Fatalf("CachedChapterList: %v", err)
+		}
+		return list
+	}
+
+	first := fetch(context.Background())
+	if len(first.URLs) != 2 || site.scrapes.Load() != 1 {
+		t.Fatalf("first fetch: %d chapters after %d scrapes", len(first.URLs), site.scrapes.Load())
+	}
+	// Dropping local chapters from the result must not touch the cached list
+	delete(first.URLs, "ch001.cbz")
+
+	mock.pages[3] = 1
+	if cached := fetch(context.Background()); len(cached.URLs) != 2 || site.scrapes.Load() != 1 {
+		t.Errorf("within the window: %d chapters after %d scrapes, want the 2 cached ones and no scrape", len(cached.URLs), site.scrapes.Load())
+	}
+
+	if forced := fetch(config.WithChapterListRefresh(context.Background())); len(forced.URLs) != 3 || site.scrapes.Load() != 2 {
+		t.Errorf("forced refresh: %d chapters after %d scrapes, want 3 after 2", len(forced.URLs), site.scrapes.Load())
+	}
+
+	time.Sleep(ttl + 100*time.Millisecond)
+	mock.pages[4] = 1
+	if expired := fetch(context.Background()); len(expired.URLs) != 4 || site.scrapes.Load() != 3 {
+		t.Errorf("after the window: %d chapters after %d scrapes, want 4 after 3", len(expired.URLs), site.scrapes.Load())
+	}
+
+	// A ttl of 0 turns the cache off
+	if _, err := downloader.CachedChapterList(context.Background(), seriesURL, site, 0); err != nil || site.scrapes.Load() != 4 {
+		t.Errorf("ttl 0: %d scrapes (err %v), want a scrape", site.scrapes.Load(), err)
+	}
+}
@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"slices"
+	"testing"
+
+	"kansho/parser"
+)
+
+func TestParseChapterNumber(t *testing.T) {
+	cases := []struct {
+		name  string
+		main  int
+		part  string
+		value float64
+	}{
+		{"ch001.cbz", 1, "", 1},
+		{"ch091.5.cbz", 91, "5", 91.5},
+		{"ch100.10.cbz", 100, "10", 100.1},
+		{"ch1000.cbz", 1000, "", 1000},
+		{"ch000.cbz", 0, "", 0},
+		// Numbers within a season, without an extension or a ch prefix
+		{"s02ch045.cbz", 45, "", 45},
+		{"ch091.5", 91, "5", 91.5},
+		{"072.CBZ", 72, "", 72},
+	}
+	for _, c := range cases {
+		main, part, value, err := parser.ParseChapterNumber(c.name)
+		if err != nil {
+			t.Errorf("ParseChapterNumber(%q): %v", c.name, err)
+			continue
+		}
+		if main != c.main || part != c.part || value != c.value {
+			t.Errorf("ParseChapterNumber(%q) = %d, %q, %v, want %d, %q, %v", c.name, main, part, value, c.main, c.part, c.value)
+		}
+	}
+
+	for _, name := range []string{
+		"", "ch.cbz", "chabc.cbz", "ch12a.cbz", "ch-3.cbz", "ch+3.cbz", "ch1e3.cbz",
+		"ch012.1.2.cbz", "ch012..5.cbz", "ch012.cbz.bak", "cover.jpg", "chapter12.cbz",
+		"ch99999999999999999999.cbz",
+	} {
+		if main, part, value, err := parser.ParseChapterNumber(name); err == nil {
+			t.Errorf("ParseChapterNumber(%q) = %d, %q, %v, want an error", name, main, part, value)
+		}
+	}
+}
+
+func TestSortChaptersNumeric_UsesChapterNumbers(t *testing.T) {
+	chapters := []string{"ch1000.cbz", "ch091.5.cbz", "s02ch001.cbz", "notes.cbz", "ch091.cbz", "ch100.10.cbz", "ch100.9.cbz", "ch002.cbz"}
+	parser.SortChaptersNumeric(chapters)
+	want := []string{"notes.cbz", "ch002.cbz", "ch091.cbz", "ch091.5.cbz", "ch100.10.cbz", "ch100.9.cbz", "ch1000.cbz", "s02ch001.cbz"}
+	if !slices.Equal(chapters, want) {
+		t.Errorf("sorted = %v, want %v", chapters, want)
+	}
+}
@@ -0,0 +1,134 @@
+package integration
+
+import (
+	"archive/zip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kansho/config"
+	"kansho/downloader"
+)
+
+// skipSite serves two single page chapters through API extraction
+type skipSite struct {
+	base string
+}
+
+func (s *skipSite) GetSiteName() string { return "skiptest" }
+func (s *skipSite) GetDomain() string   { return "127.0.0.1" }
+func (s *skipSite) NeedsCFBypass() bool { return false }
+
+func (s *skipSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			return []map[string]string{
+				{"url": s.base + "/chapter/1", "number": "1"},
+				{"url": s.base + "/chapter/2", "number": "2"},
+			}, nil
+		},
+	}
+}
+
+func (s *skipSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	return &downloader.ImageExtractionMethod{
+		Type: "api",
+		APIFunc: func(chapterURL string, chapterData map[string]string, client *downloader.APIClient) ([]string, error) {
+			return []string{s.base + "/img/" + path.Base(chapterURL)}, nil
+		},
+	}
+}
+
+func (s *skipSite) NormalizeChapterURL(rawURL, baseURL string) string { return rawURL }
+
+func (s *skipSite) NormalizeChapterFilename(data map[string]string) string {
+	return "ch00" + data["number"] + ".cbz"
+}
+
+func Test_Manager_SkipChapterContinuesSeries(t *testing.T) {
+	page := encodePNG(t, 8, 8)
+	stuck := make(chan struct{}, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Chapter 1's page never arrives until the request is abandoned
+		if strings.HasSuffix(r.URL.Path, "/img/1") {
+			select {
+			case stuck <- struct{}{}:
+			default:
+			}
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	site := &skipSite{base: server.URL}
+	manga := &config.Bookmarks{
+		Title:    "Skip Test",
+		Url:      server.URL + "/series",
+		Location: t.TempDir(),
+		Site:     site.GetSiteName(),
+	}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(filepath.Dir(tempDir))) })
+
+	skipper := &config.ChapterSkipper{}
+	ctx := config.WithChapterSkipper(context.Background(), skipper)
+
+	go func() {
+		select {
+		case <-stuck:
+			skipper.Skip()
+		case <-time.After(20 * time.Second):
+		}
+	}()
+
+	var lastMessage string
+	manager := downloader.NewManager(&downloader.DownloadConfig{
+		Manga: manga,
+		Site:  site,
+		ProgressCallback: func(event config.ProgressEvent) {
+			lastMessage = event.Status
+		},
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- manager.Download(ctx) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Download: %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("download did not finish after skipping the stuck chapter")
+	}
+
+	if _, err := os.Stat(filepath.Join(manga.Location, "ch001.cbz")); !os.IsNotExist(err) {
+		t.Errorf("skipped chapter should have no cbz, stat err = %v", err)
+	}
+
+	zr, err := zip.OpenReader(filepath.Join(manga.Location, "ch002.cbz"))
+	if err != nil {
+		t.Fatalf("next chapter was not downloaded: %v", err)
+	}
+	zr.Close()
+
+	if !strings.Contains(lastMessage, "1 skipped") {
+		t.Errorf("final message %q should report the skipped chapter", lastMessage)
+	}
+
+	// Nothing is running any more, so there is nothing to skip
+	if skipper.Skip() {
+		t.Error("Skip reported success with no chapter in progress")
+	}
+}
@@ -0,0 +1,44 @@
+package integration
+
+import (
+	"testing"
+
+	"kansho/sites"
+)
+
+func TestChapterNumberFromText_Manhuaus(t *testing.T) {
+	site := sites.NewManhuausSite()
+	tests := []struct {
+		name string
+		data map[string]string
+		want string
+	}{
+		{"number in URL", map[string]string{"num": "12", "url": "https://manhuaus.com/manga/x/chapter-12/", "text": "Chapter 12"}, "ch012.cbz"},
+		{"slug URL, number in text", map[string]string{"num": "", "url": "https://manhuaus.com/manga/x/the-finale/", "text": "Chapter 45"}, "ch045.cbz"},
+		{"slug URL, decimal in text", map[string]string{"num": "", "url": "https://manhuaus.com/manga/x/side-story/", "text": "Ch. 45.5 - Side Story"}, "ch045.5.cbz"},
+		{"no number anywhere", map[string]string{"num": "", "url": "https://manhuaus.com/manga/x/notice/", "text": "Notice 2024"}, ""},
+	}
+	for _, tt := range tests {
+		if got := site.NormalizeChapterFilename(tt.data); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChapterNumberFromText_Mgeko(t *testing.T) {
+	site := sites.NewMgekoSite()
+	tests := []struct {
+		name string
+		data map[string]string
+		want string
+	}{
+		{"number in URL", map[string]string{"url": "https://www.mgeko.cc/read-manga/x/chapter-72-5", "text": "Chapter 72-5"}, "ch072.5.cbz"},
+		{"slug URL, number in text", map[string]string{"url": "https://www.mgeko.cc/read-manga/x/epilogue", "text": "Episode 101"}, "ch101.cbz"},
+		{"slug URL, part in text", map[string]string{"url": "https://www.mgeko.cc/read-manga/x/special", "text": "Chapter 7-2 Special"}, "ch007.2.cbz"},
+	}
+	for _, tt := range tests {
+		if got := site.NormalizeChapterFilename(tt.data); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kansho/cf"
+	"kansho/config"
+	"kansho/downloader"
+)
+
+func TestClearanceFromCookies_SavesUsableClearance(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	const pageURL = "https://refresh.example.com/series/1"
+	const userAgent = "Mozilla/5.0 (X11; Linux x86_64) Chrome/140.0.0.0"
+	expires := time.Now().Add(24 * time.Hour)
+	cookies := []cf.Cookie{
+		{Name: "__cf_bm", Value: "bm", Domain: ".refresh.example.com", Path: "/"},
+		{Name: "cf_clearance", Value: "fresh", Domain: ".refresh.example.com", Path: "/", Secure: true, HTTPOnly: true, ExpirationDate: float64(expires.Unix())},
+	}
+
+	// The challenge has not passed while there is no cf_clearance
+	if _, err := cf.ClearanceFromCookies(pageURL, userAgent, cookies[:1]); err == nil {
+		t.Fatal("ClearanceFromCookies without a cf_clearance succeeded")
+	}
+
+	data, err := cf.ClearanceFromCookies(pageURL, userAgent, cookies)
+	if err != nil {
+		t.Fatalf("ClearanceFromCookies: %v", err)
+	}
+	if data.Type != cf.ProtectionCookie || data.Domain != "refresh.example.com" || data.Entropy.UserAgent != userAgent {
+		t.Errorf("bypass data = type %q, domain %q, user agent %q", data.Type, data.Domain, data.Entropy.UserAgent)
+	}
+	if data.CfClearance != "fresh" || data.CfClearanceStruct.Expires == nil || data.CfClearanceStruct.Expires.Unix() != expires.Unix() {
+		t.Errorf("cf_clearance = %q expiring %v, want %q expiring %v", data.CfClearance, data.CfClearanceStruct.Expires, "fresh", expires)
+	}
+	if len(data.AllCookies) != 2 {
+		t.Errorf("%d cookies kept, want both", len(data.AllCookies))
+	}
+
+	if err := cf.SaveToFile(data, data.Domain); err != nil {
+		t.Fatal(err)
+	}
+	if err := cf.CheckClearance(data.Domain); err != nil {
+		t.Errorf("CheckClearance of the refreshed data: %v", err)
+	}
+	// A challenge after the refresh marks it failed without tripping over the headers
+	if err := cf.MarkCookieAsFailed(data.Domain); err != nil {
+		t.Errorf("MarkCookieAsFailed: %v", err)
+	}
+}
+
+func TestRefreshClearance_NeedsABrowser(t *testing.T) {
+	t.Setenv(cf.NoBrowserEnv, "1")
+	if _, err := downloader.RefreshClearance(context.Background(), "refresh.example.com"); !errors.Is(err, cf.ErrBrowserDisabled) {
+		t.Errorf("RefreshClearance = %v, want ErrBrowserDisabled", err)
+	}
+}
+
+func TestOpenChallenge_LeavesChallengeToRefresh(t *testing.T) {
+	marker := fakeOpener(t)
+	t.Setenv(cf.NoBrowserEnv, "0")
+
+	if err := cf.OpenChallenge(cf.WithClearanceRefresh(context.Background()), "https://example.com/challenge"); err != nil {
+		t.Fatalf("OpenChallenge: %v", err)
+	}
+	if waitForFile(marker, 300*time.Millisecond) {
+		t.Fatal("default browser was opened although the challenge is solved in the embedded one")
+	}
+
+	// Outside a queue download (eg: fetching a title for a new bookmark) nobody
+	// else solves the challenge
+	if err := cf.OpenChallenge(context.Background(), "https://example.com/challenge"); err != nil {
+		t.Fatalf("OpenChallenge: %v", err)
+	}
+	if !waitForFile(marker, 5*time.Second) {
+		t.Fatal("default browser was not opened outside a download that refreshes clearance")
+	}
+}
+
+func TestDownloadQueue_RefreshesClearanceAndResumes(t *testing.T) {
+	const siteName = "clearance-refresh-test-site"
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(cf.NoBrowserEnv, "1")
+
+	var refreshed []string
+	config.RegisterClearanceRefresher(func(ctx context.Context, target string) (*cf.BypassData, error) {
+		refreshed = append(refreshed, target)
+		return &cf.BypassData{}, nil
+	})
+	defer config.RegisterClearanceRefresher(downloader.RefreshClearance)
+
+	var runs atomic.Int32
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		if !cf.ClearanceRefreshEnabled(ctx) {
+			return errors.New("queue download does not leave challenges to the refresh")
+		}
+		if runs.Add(1) == 1 {
+			return &cf.CfChallengeError{URL: "https://refresh.example.com/challenge", StatusCode: 403}
+		}
+		return nil
+	})
+
+	queue := config.GetDownloadQueue()
+	finished := make(chan config.DownloadTask, 4)
+	unsubscribe := queue.Subscribe(config.QueueListener{
+		OnTaskUpdated: func(tk *config.DownloadTask) {
+			if tk.Manga.Site == siteName && tk.CancelFunc == nil && tk.Status != "queued" {
+				finished <- *tk
+			}
+		},
+	})
+	defer unsubscribe()
+	defer queue.RemoveCompletedTasks()
+
+	manga := &config.Bookmarks{Title: "Clearance Refresh", Site: siteName, Url: "https://refresh.example.com/series", Location: t.TempDir()}
+	if _, err := queue.AddTask(manga); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case task := <-finished:
+		if task.Status != "completed" {
+			t.Fatalf("task %s: %s", task.Status, task.StatusMessage)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the download")
+	}
+	if runs.Load() != 2 || len(refreshed) != 1 || refreshed[0] != "https://refresh.example.com/challenge" {
+		t.Errorf("%d runs, refreshed %v; want the challenge refreshed once and the download run again", runs.Load(), refreshed)
+	}
+}
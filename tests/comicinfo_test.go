@@ -0,0 +1,178 @@
+package integration
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+func writePNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.White)
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create %s: %v", path, err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("encode %s: %v", path, err)
+	}
+}
+
+func Test_WriteComicInfo_DoublePageFlags(t *testing.T) {
+	dir := t.TempDir()
+
+	// Pages 002 and 010 are landscape spreads, 010 sorts after 009 numerically
+	writePNG(t, filepath.Join(dir, "001.png"), 80, 120)
+	writePNG(t, filepath.Join(dir, "002.png"), 160, 120)
+	writePNG(t, filepath.Join(dir, "003.png"), 80, 120)
+	writePNG(t, filepath.Join(dir, "9.png"), 80, 120)
+	writePNG(t, filepath.Join(dir, "10.png"), 160, 120)
+
+	if err := parser.WriteComicInfo(dir, parser.ComicInfo{Series: "Test Series", Number: "001"}); err != nil {
+		t.Fatalf("WriteComicInfo: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, parser.ComicInfoFileName))
+	if err != nil {
+		t.Fatalf("read ComicInfo.xml: %v", err)
+	}
+
+	var info parser.ComicInfo
+	if err := xml.Unmarshal(data, &info); err != nil {
+		t.Fatalf("unmarshal ComicInfo.xml: %v", err)
+	}
+
+	if info.Series != "Test Series" || info.PageCount != 5 || info.Pages == nil {
+		t.Fatalf("unexpected ComicInfo: %+v", info)
+	}
+
+	wantDouble := []bool{false, true, false, false, true}
+	for i, page := range info.Pages.Page {
+		if page.Image != i {
+			t.Errorf("page %d: Image index = %d", i, page.Image)
+		}
+		if page.DoublePage != wantDouble[i] {
+			t.Errorf("page %d (%dx%d): DoublePage = %v, want %v", i, page.ImageWidth, page.ImageHeight, page.DoublePage, wantDouble[i])
+		}
+	}
+
+	// ComicInfo.xml must land in the cbz after the pages
+	cbzPath := filepath.Join(t.TempDir(), "ch001.cbz")
+	if err := parser.CreateCbzFromDir(dir, cbzPath); err != nil {
+		t.Fatalf("CreateCbzFromDir: %v", err)
+	}
+	reader, err := zip.OpenReader(cbzPath)
+	if err != nil {
+		t.Fatalf("failed to open cbz: %v", err)
+	}
+	defer reader.Close()
+
+	if last := reader.File[len(reader.File)-1].Name; last != parser.ComicInfoFileName {
+		t.Errorf("last cbz entry = %s, want %s", last, parser.ComicInfoFileName)
+	}
+}
+
+func Test_PageInfoFromDir_KeepsIndexPastUndecodableFile(t *testing.T) {
+	dir := t.TempDir()
+
+	writePNG(t, filepath.Join(dir, "001.png"), 80, 120)
+	if err := os.WriteFile(filepath.Join(dir, "002.png"), []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writePNG(t, filepath.Join(dir, "003.png"), 160, 120)
+
+	pages, err := parser.PageInfoFromDir(dir)
+	if err != nil {
+		t.Fatalf("PageInfoFromDir: %v", err)
+	}
+
+	// 002 is still packaged, so the spread after it is the third CBZ page
+	want := []parser.ComicPageInfo{
+		{Image: 0, ImageWidth: 80, ImageHeight: 120},
+		{Image: 2, ImageWidth: 160, ImageHeight: 120, DoublePage: true},
+	}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %+v, want %+v", pages, want)
+	}
+}
+
+// comicInfoSite names its chapters and credits a scanlation group
+type comicInfoSite struct{ mockSitePlugin }
+
+func (s *comicInfoSite) ChapterComicInfo(manga *config.Bookmarks, chapterURL, cbzName string) parser.ComicInfo {
+	return parser.ComicInfo{Title: "The First One", Volume: 2, Translator: "Mock Scans"}
+}
+
+func Test_Manager_WritesChapterComicInfo(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"write_comic_info": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := newMockMangaSite(t, map[int]int{1: 2})
+	site := &comicInfoSite{}
+	manga := &config.Bookmarks{Title: "Mock ComicInfo Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: site.GetSiteName()}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(tempDir)) })
+
+	if err := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site}).Download(context.Background()); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	reader, err := zip.OpenReader(filepath.Join(manga.Location, "ch001.cbz"))
+	if err != nil {
+		t.Fatalf("failed to open cbz: %v", err)
+	}
+	defer reader.Close()
+	var info parser.ComicInfo
+	for _, f := range reader.File {
+		if f.Name != parser.ComicInfoFileName {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = xml.NewDecoder(rc).Decode(&info)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("decode ComicInfo.xml: %v", err)
+		}
+	}
+
+	want := parser.ComicInfo{
+		XMLName:    info.XMLName,
+		Title:      "The First One",
+		Series:     manga.Title,
+		Number:     "001",
+		Volume:     2,
+		Notes:      "Downloaded by kansho on " + time.Now().Format("2006-01-02"),
+		Translator: "Mock Scans",
+		Web:        mock.server.URL + "/chapter/1",
+		PageCount:  2,
+		Pages:      info.Pages,
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("ComicInfo = %+v, want %+v", info, want)
+	}
+}
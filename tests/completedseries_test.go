@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"kansho/config"
+)
+
+func TestBulkUpdateSkipsCompletedSeries(t *testing.T) {
+	mangas := []config.Bookmarks{
+		{Title: "Ongoing", Location: t.TempDir()},
+		{Title: "Finished", Location: t.TempDir(), Completed: true},
+		{Title: "Also Ongoing", Location: t.TempDir()},
+	}
+
+	// Forced bulk runs skip completed series too
+	for _, force := range []bool{false, true} {
+		candidates := config.BulkUpdateCandidates(mangas, force)
+		if len(candidates) != 2 {
+			t.Fatalf("force=%v: got %d candidates, want 2", force, len(candidates))
+		}
+		for _, manga := range candidates {
+			if manga.Completed {
+				t.Errorf("force=%v: completed series %q was queued for a bulk update", force, manga.Title)
+			}
+		}
+	}
+}
+
+func TestManualDownloadIncludesCompletedSeries(t *testing.T) {
+	const siteName = "completed-test-site"
+
+	downloaded := make(chan string, 1)
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		downloaded <- manga.Title
+		return nil
+	})
+
+	queue := config.GetDownloadQueue()
+	defer queue.RemoveCompletedTasks()
+
+	if _, err := queue.AddTask(&config.Bookmarks{Title: "Finished", Site: siteName, Location: t.TempDir(), Completed: true}); err != nil {
+		t.Fatalf("AddTask refused a completed series: %v", err)
+	}
+
+	select {
+	case title := <-downloaded:
+		if title != "Finished" {
+			t.Errorf("downloaded %q, want the completed series", title)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("completed series was not downloaded when queued manually")
+	}
+}
+
+func TestCompletedFlagRoundTrip(t *testing.T) {
+	data, err := json.Marshal(config.Manga{Manga: []config.Bookmarks{{Title: "Finished", Completed: true}, {Title: "Ongoing"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, skipped, err := config.DecodeBookmarks(data)
+	if err != nil || skipped != 0 {
+		t.Fatalf("DecodeBookmarks: %v (skipped %d)", err, skipped)
+	}
+	if !decoded.Manga[0].Completed || decoded.Manga[1].Completed {
+		t.Errorf("completed flags = %v, %v", decoded.Manga[0].Completed, decoded.Manga[1].Completed)
+	}
+}
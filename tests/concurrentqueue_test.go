@@ -0,0 +1,106 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"kansho/config"
+	"kansho/downloader"
+)
+
+func Test_DownloadQueue_ConcurrentSeries(t *testing.T) {
+	const siteName = "concurrent-test-site"
+
+	var (
+		mu       sync.Mutex
+		active   int
+		peak     int
+		tempDirs = map[string]string{}
+	)
+	bothStarted := make(chan struct{})
+	var startOnce sync.WaitGroup
+	startOnce.Add(2)
+	go func() { startOnce.Wait(); close(bothStarted) }()
+
+	// Fake site: each series "downloads" the same chapter name into its temp dir and
+	// holds its slot until both series are running
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		dir := downloader.ChapterTempDir(siteName, manga, "ch001.cbz")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		mu.Lock()
+		active++
+		if active > peak {
+			peak = active
+		}
+		tempDirs[manga.Title] = dir
+		mu.Unlock()
+
+		startOnce.Done()
+		select {
+		case <-bothStarted:
+		case <-time.After(3 * time.Second):
+		}
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return nil
+	})
+
+	results := make(chan string, 2)
+	queue := config.GetDownloadQueue()
+	queue.SetCallbacks(nil, func(tk *config.DownloadTask) {
+		if tk.Manga.Site == siteName && tk.Status != "queued" && tk.Status != "downloading" {
+			results <- tk.Manga.Title + ":" + tk.Status
+		}
+	}, nil, nil)
+	defer queue.SetCallbacks(nil, nil, nil, nil)
+	defer queue.RemoveCompletedTasks()
+
+	queue.SetMaxConcurrent(2)
+	defer queue.SetMaxConcurrent(1)
+
+	root := t.TempDir()
+	for _, title := range []string{"Concurrent Series A", "Concurrent Series B"} {
+		if _, err := queue.AddTask(&config.Bookmarks{
+			Title:    title,
+			Site:     siteName,
+			Url:      "https://example.com/" + title,
+			Location: root + "/" + title,
+		}); err != nil {
+			t.Fatalf("AddTask(%s): %v", title, err)
+		}
+	}
+
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-results:
+			got[r] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for tasks, got %v", got)
+		}
+	}
+
+	for _, want := range []string{"Concurrent Series A:completed", "Concurrent Series B:completed"} {
+		if !got[want] {
+			t.Errorf("missing result %q, got %v", want, got)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != 2 {
+		t.Errorf("peak concurrent series = %d, want 2", peak)
+	}
+	if tempDirs["Concurrent Series A"] == tempDirs["Concurrent Series B"] {
+		t.Errorf("temp dirs collide: %s", tempDirs["Concurrent Series A"])
+	}
+}
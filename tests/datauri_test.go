@@ -0,0 +1,65 @@
+package integration
+
+import (
+	"context"
+	"encoding/base64"
+	"image"
+	_ "image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/parser"
+)
+
+func Test_DownloadDataURIImage(t *testing.T) {
+	dir := t.TempDir()
+	uri := "data:image/png;base64," + base64.StdEncoding.EncodeToString(encodePNG(t, 12, 34))
+
+	// No server involved: a network request for a data URI would fail outright
+	if err := parser.DownloadConvertToJPGRename(context.Background(), "1", uri, dir); err != nil {
+		t.Fatalf("DownloadConvertToJPGRename: %v", err)
+	}
+	if err := parser.DownloadRenameNative(context.Background(), "2", uri, dir); err != nil {
+		t.Fatalf("DownloadRenameNative: %v", err)
+	}
+
+	for _, page := range []struct {
+		name   string
+		format string
+	}{
+		{"001.jpg", "jpeg"},
+		{"002.png", "png"},
+	} {
+		f, err := os.Open(filepath.Join(dir, page.name))
+		if err != nil {
+			t.Fatalf("expected page %s: %v", page.name, err)
+		}
+		cfg, format, err := image.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: decode: %v", page.name, err)
+		}
+		if format != page.format || cfg.Width != 12 || cfg.Height != 34 {
+			t.Errorf("%s: got %s %dx%d, want %s 12x34", page.name, format, cfg.Width, cfg.Height, page.format)
+		}
+	}
+}
+
+func Test_DecodeDataURI(t *testing.T) {
+	if !parser.IsDataURI("DATA:image/gif;base64,R0lG") || parser.IsDataURI("https://example.com/data:x") {
+		t.Fatal("IsDataURI misclassified input")
+	}
+
+	got, err := parser.DecodeDataURI("data:text/plain,hello%20page")
+	if err != nil || string(got) != "hello page" {
+		t.Errorf("percent-encoded payload: got %q, %v", got, err)
+	}
+
+	if _, err := parser.DecodeDataURI("data:image/png;base64"); err == nil {
+		t.Error("expected error for data URI without payload")
+	}
+	if _, err := parser.DecodeDataURI("data:image/png;base64,@@@"); err == nil {
+		t.Error("expected error for invalid base64")
+	}
+}
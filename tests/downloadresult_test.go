@@ -0,0 +1,49 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+func Test_RunSiteDownload_ReportsResult(t *testing.T) {
+	const siteName = "downloadresult-test-site"
+
+	// Chapter 2 lists no pages, so one of the three new chapters fails
+	mock := newMockMangaSite(t, map[int]int{1: 1, 2: 0, 3: 1})
+	site := &mockSitePlugin{}
+	manga := &config.Bookmarks{Title: "Mock Result Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: siteName}
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz"))) })
+
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		return downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site, ProgressCallback: progress}).Download(ctx)
+	})
+
+	result, err := config.RunSiteDownload(context.Background(), manga, nil)
+	if err == nil {
+		t.Fatal("run with a failed chapter returned no error")
+	}
+	if result.NewChapters != 3 || result.Succeeded != 2 || result.Failed != 1 {
+		t.Errorf("result = %d new, %d succeeded, %d failed, want 3, 2, 1", result.NewChapters, result.Succeeded, result.Failed)
+	}
+	if len(result.ChapterErrors) != 1 || result.ChapterErrors["ch002.cbz"] == nil {
+		t.Errorf("ChapterErrors = %v, want only ch002.cbz", result.ChapterErrors)
+	}
+	if result.Duration <= 0 {
+		t.Errorf("Duration = %v", result.Duration)
+	}
+
+	local, err := parser.LocalChapterList(manga.Location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(local) != 2 {
+		t.Errorf("library holds %v, want the two good chapters", local)
+	}
+
+}
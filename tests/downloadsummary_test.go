@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"kansho/config"
+)
+
+func Test_DownloadSummary_CompletedWithErrors(t *testing.T) {
+	const siteName = "summary-test-site"
+
+	// Fake site: five chapters, two of them fail
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		var summary config.DownloadSummary
+		for i := 1; i <= 5; i++ {
+			name := fmt.Sprintf("ch%03d.cbz", i)
+			if i == 2 || i == 4 {
+				summary.Fail(name)
+				continue
+			}
+			summary.Success(name)
+		}
+		progress(config.ProgressEvent{Status: summary.Message(), Fraction: 1.0, CurrentDownload: 5, TotalChapters: 5})
+		return summary.Err()
+	})
+
+	// Capture the final state from the update callback, which runs on the task's
+	// own goroutine once the status has been set
+	type finalState struct {
+		status, message string
+		err             error
+	}
+	done := make(chan finalState, 1)
+
+	queue := config.GetDownloadQueue()
+	queue.SetCallbacks(nil, func(tk *config.DownloadTask) {
+		if tk.Manga.Site != siteName || tk.Status == "queued" || tk.Status == "downloading" {
+			return
+		}
+		select {
+		case done <- finalState{tk.Status, tk.StatusMessage, tk.Error}:
+		default:
+		}
+	}, nil, nil)
+	defer queue.SetCallbacks(nil, nil, nil, nil)
+	defer queue.RemoveCompletedTasks()
+
+	if _, err := queue.AddTask(&config.Bookmarks{
+		Title:    "Summary Test Series",
+		Site:     siteName,
+		Location: t.TempDir(),
+	}); err != nil {
+		t.Fatalf("AddTask: %v", err)
+	}
+
+	var final finalState
+	select {
+	case final = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the task to finish")
+	}
+	status, message, taskErr := final.status, final.message, final.err
+
+	if status != "completed_with_errors" {
+		t.Fatalf("status = %q, want completed_with_errors (message %q)", status, message)
+	}
+	if !strings.Contains(message, "3 of 5") || !strings.Contains(message, "2 failed") {
+		t.Errorf("status message %q does not summarise the run", message)
+	}
+
+	var incomplete *config.IncompleteDownloadError
+	if !errors.As(taskErr, &incomplete) {
+		t.Fatalf("task error %v is not an IncompleteDownloadError", taskErr)
+	}
+	if got := incomplete.Summary.FailedChapters; len(got) != 2 || got[0] != "ch002.cbz" || got[1] != "ch004.cbz" {
+		t.Errorf("failed chapters = %v", got)
+	}
+}
+
+func Test_DownloadSummary_AllSucceeded(t *testing.T) {
+	var summary config.DownloadSummary
+	summary.Success("ch001.cbz")
+	summary.Success("ch002.cbz")
+
+	if err := summary.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+	if msg := summary.Message(); msg != "Download complete! Downloaded 2 chapters" {
+		t.Errorf("Message() = %q", msg)
+	}
+}
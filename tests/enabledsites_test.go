@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"kansho/config"
+	"kansho/models"
+	"kansho/ui"
+)
+
+func TestSiteDropdownOptions_OnlyEnabledSites(t *testing.T) {
+	sitesConfig := models.SitesConfig{Sites: []models.Site{
+		{Name: "mgeko", DisplayName: "mgeko"},
+		{Name: "cfotz", DisplayName: "Childhood Friend of the Zenith"},
+		{Name: "mangadex", DisplayName: "mangadex"},
+		{Name: "weebcentral", DisplayName: "weebcentral"},
+	}}
+
+	if got := ui.SiteDropdownOptions(sitesConfig, nil, ""); len(got) != 4 {
+		t.Errorf("no enabled sites set offers %v, want every site", got)
+	}
+
+	enabled := []string{"weebcentral", "cfotz", "removed-site"}
+	if got, want := ui.SiteDropdownOptions(sitesConfig, enabled, ""), []string{"Childhood Friend of the Zenith", "weebcentral"}; !slices.Equal(got, want) {
+		t.Errorf("options = %v, want %v in config order", got, want)
+	}
+
+	// Editing a bookmark of a disabled site still offers its site
+	if got, want := ui.SiteDropdownOptions(sitesConfig, enabled, "mgeko"), []string{"mgeko", "Childhood Friend of the Zenith", "weebcentral"}; !slices.Equal(got, want) {
+		t.Errorf("options while editing a mgeko bookmark = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateSettings_KeepsOtherSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(configDir, "settings.json")
+	if err := os.WriteFile(path, []byte(`{"write_comic_info": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var notified []string
+	config.OnSettingsChanged(func(settings config.Settings) {
+		notified = settings.EnabledSites
+	})
+
+	if err := config.UpdateSettings(func(settings *config.Settings) {
+		settings.EnabledSites = []string{"mangadex", "weebcentral"}
+	}); err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	settings := config.LoadSettings()
+	if !settings.WriteComicInfo || !slices.Equal(settings.EnabledSites, []string{"mangadex", "weebcentral"}) {
+		t.Errorf("settings after update = %+v", settings)
+	}
+	if !slices.Equal(notified, settings.EnabledSites) {
+		t.Errorf("listener got %v, want the saved sites", notified)
+	}
+
+	// A broken file is not replaced
+	os.WriteFile(path, []byte(`{"write_comic_info": tru`), 0644)
+	if err := config.UpdateSettings(func(settings *config.Settings) { settings.EnabledSites = nil }); err == nil {
+		t.Error("UpdateSettings overwrote a settings file it could not parse")
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"write_comic_info": tru` {
+		t.Errorf("settings file changed to %s", data)
+	}
+}
@@ -0,0 +1,25 @@
+package integration
+
+import (
+	"testing"
+
+	"kansho/downloader"
+)
+
+func Test_UsesBrowserRendering(t *testing.T) {
+	cases := []struct {
+		name   string
+		method downloader.ImageExtractionMethod
+		want   bool
+	}{
+		{"http first by default", downloader.ImageExtractionMethod{Type: "custom"}, false},
+		{"wait selector", downloader.ImageExtractionMethod{Type: "custom", WaitSelector: "img.page"}, true},
+		{"force browser without wait selector", downloader.ImageExtractionMethod{Type: "custom", ForceBrowser: true}, true},
+	}
+
+	for _, tc := range cases {
+		if got := downloader.UsesBrowserRendering(&tc.method); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
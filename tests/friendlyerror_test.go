@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"kansho/cf"
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/ui"
+)
+
+func Test_FriendlyError_Mapping(t *testing.T) {
+	summary := config.DownloadSummary{}
+	summary.Success("ch001.cbz")
+	summary.Fail("ch002.cbz")
+
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"no chapters", fmt.Errorf("failed to get chapter URLs: asura: %w", downloader.ErrNoChaptersFound), "No chapters were found"},
+		{"site changed", fmt.Errorf("failed to get chapter images: %w", downloader.ErrSiteChanged), "page layout has changed"},
+		{"maintenance", fmt.Errorf("failed to get chapter URLs: %w", downloader.ErrSiteMaintenance), "down for maintenance"},
+		{"network", fmt.Errorf("failed after 3 retries: %w: unexpected status code: 503", downloader.ErrNetwork), "Could not reach the site"},
+		{"dial error", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, "Could not reach the site"},
+		{"permission denied", &os.PathError{Op: "open", Path: "/lib", Err: syscall.EACCES}, "Download failed"},
+		{"cloudflare", fmt.Errorf("wrapped: %w", &cf.CfChallengeError{URL: "https://example.com", StatusCode: 403}), "Cloudflare is blocking"},
+		{"disk full sentinel", fmt.Errorf("failed to create CBZ: %w", downloader.ErrDiskFull), "disk is full"},
+		{"disk full errno", fmt.Errorf("failed to create CBZ: %w", &os.PathError{Op: "write", Path: "/lib/ch001.cbz", Err: syscall.ENOSPC}), "disk is full"},
+		{"library missing", &config.LibraryUnavailableError{Location: "/mnt/usb/Manga/Solo", Err: os.ErrNotExist}, "library folder /mnt/usb/Manga/Solo is not accessible"},
+		{"incomplete", summary.Err(), "1 of 2 chapters downloaded"},
+		{"cancelled", fmt.Errorf("download: %w", context.Canceled), "cancelled"},
+		{"unknown", errors.New("scrape error: something odd"), "Download failed: scrape error: something odd"},
+	}
+
+	for _, tc := range cases {
+		got := ui.FriendlyError(tc.err)
+		if !strings.Contains(got, tc.want) {
+			t.Errorf("%s: expected message containing %q, got %q", tc.name, tc.want, got)
+		}
+	}
+
+	if got := ui.FriendlyError(nil); got != "" {
+		t.Errorf("nil error: expected empty message, got %q", got)
+	}
+}
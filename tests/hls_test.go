@@ -0,0 +1,61 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"kansho/config"
+	"kansho/sites"
+)
+
+func TestHlsUsesBookmarkSeriesURL(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var mu sync.Mutex
+	var visited []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		visited = append(visited, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><ul>
+			<li class="item"><a href="/another-series/chapter-2/">Chapter 2</a></li>
+			<li class="item"><a href="/another-series/chapter-1/">Chapter 1</a></li>
+		</ul></body></html>`))
+	}))
+	defer server.Close()
+
+	// Both chapters are already downloaded, so only the chapter list is fetched
+	location := t.TempDir()
+	for _, name := range []string{"ch001.cbz", "ch002.cbz"} {
+		writeStoredCbz(t, filepath.Join(location, name), []cbzEntry{{"001.jpg", []byte("page")}})
+	}
+
+	manga := &config.Bookmarks{
+		Title:    "Another Series",
+		Site:     sites.HLS_SITE,
+		Url:      server.URL + "/series/another-series/",
+		Location: location,
+	}
+
+	var total int
+	err := sites.HlsDownloadChapters(context.Background(), manga, func(event config.ProgressEvent) {
+		total = event.TotalChapters
+	})
+	if err != nil {
+		t.Fatalf("HlsDownloadChapters: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(visited) != 1 || visited[0] != "/series/another-series/" {
+		t.Errorf("visited %v, want only the bookmark's series page", visited)
+	}
+	if total != 2 {
+		t.Errorf("found %d chapters on the series page, want 2", total)
+	}
+}
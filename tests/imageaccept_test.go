@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"kansho/parser"
+)
+
+// acceptRecorder serves a PNG and records the Accept header of every request
+func acceptRecorder(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	page := encodePNG(t, 4, 4)
+
+	var (
+		mu      sync.Mutex
+		accepts []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		accepts = append(accepts, r.Header.Get("Accept"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(page)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), accepts...)
+	}
+}
+
+func TestImageAccept_SetOnImageRequests(t *testing.T) {
+	srv, accepts := acceptRecorder(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	parser.SetImageAccept("")
+	if err := parser.DownloadConvertToJPGRename(ctx, "1", srv.URL+"/1.png", dir); err != nil {
+		t.Fatalf("DownloadConvertToJPGRename: %v", err)
+	}
+	if err := parser.DownloadRenameNative(ctx, "2", srv.URL+"/2.png", dir); err != nil {
+		t.Fatalf("DownloadRenameNative: %v", err)
+	}
+	if err := parser.DownloadAndConvertToJPG(srv.URL+"/3.png", dir); err != nil {
+		t.Fatalf("DownloadAndConvertToJPG: %v", err)
+	}
+	// No bypass data is stored for this domain, the collector path still runs
+	if err := parser.DownloadConvertToJPGRenameCf(ctx, "4", srv.URL+"/4.png", dir, "accept-test.invalid"); err != nil {
+		t.Fatalf("DownloadConvertToJPGRenameCf: %v", err)
+	}
+
+	got := accepts()
+	if len(got) != 4 {
+		t.Fatalf("expected 4 image requests, got %d", len(got))
+	}
+	for i, accept := range got {
+		if accept != parser.DefaultImageAccept {
+			t.Errorf("request %d Accept = %q, want %q", i, accept, parser.DefaultImageAccept)
+		}
+	}
+}
+
+func TestImageAccept_Configurable(t *testing.T) {
+	srv, accepts := acceptRecorder(t)
+	t.Cleanup(func() { parser.SetImageAccept("") })
+
+	parser.SetImageAccept("image/jpeg")
+	if err := parser.DownloadRenameNative(context.Background(), "1", srv.URL+"/1.png", t.TempDir()); err != nil {
+		t.Fatalf("DownloadRenameNative: %v", err)
+	}
+	if got := accepts(); len(got) != 1 || got[0] != "image/jpeg" {
+		t.Fatalf("Accept headers = %v, want [image/jpeg]", got)
+	}
+}
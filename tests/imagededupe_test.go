@@ -0,0 +1,41 @@
+package integration
+
+import (
+	"slices"
+	"testing"
+
+	"kansho/downloader"
+)
+
+func TestDedupeImageURLs(t *testing.T) {
+	urls := []string{
+		"https://cdn.example.com/banner.jpg",
+		"https://cdn.example.com/ch1/01.jpg",
+		"https://cdn.example.com/ch1/02.jpg",
+		"https://cdn.example.com/ch1/01.jpg",
+		"https://cdn.example.com/ch1/03.jpg",
+		"https://cdn.example.com/banner.jpg",
+	}
+
+	got := downloader.DedupeImageURLs(urls)
+	want := []string{
+		"https://cdn.example.com/banner.jpg",
+		"https://cdn.example.com/ch1/01.jpg",
+		"https://cdn.example.com/ch1/02.jpg",
+		"https://cdn.example.com/ch1/03.jpg",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("DedupeImageURLs = %v, want %v", got, want)
+	}
+
+	// The input is left alone, callers may still hold it
+	if urls[3] != "https://cdn.example.com/ch1/01.jpg" {
+		t.Error("DedupeImageURLs modified its input")
+	}
+
+	// Same path with a different query is a different image
+	distinct := []string{"https://cdn.example.com/p.jpg?v=1", "https://cdn.example.com/p.jpg?v=2"}
+	if got := downloader.DedupeImageURLs(distinct); len(got) != 2 {
+		t.Errorf("URLs differing by query were merged: %v", got)
+	}
+}
@@ -0,0 +1,162 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kansho/downloader"
+)
+
+// noImageWait skips the domain limiter so the requests race for the fetcher's slots
+func noImageWait(context.Context) error { return nil }
+
+func Test_ImageFetcher_CapsInFlightAndReusesConnections(t *testing.T) {
+	const maxInFlight = 3
+	page := encodePNG(t, 4, 4)
+
+	var (
+		inFlight, peak atomic.Int32
+		conns          atomic.Int32
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(page)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	fetcher := downloader.NewImageFetcher(maxInFlight)
+	// Two rounds, as two chapters one after the other
+	for round := 0; round < 2; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < 4*maxInFlight; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := downloader.ImageRequest{URL: server.URL + "/img", Domain: "fetcher-test.invalid", Wait: noImageWait}
+				if _, err := fetcher.Fetch(context.Background(), req); err != nil {
+					t.Errorf("Fetch: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	if got := peak.Load(); got > maxInFlight || got < 2 {
+		t.Errorf("peak of %d requests in flight, want up to %d and more than one", got, maxInFlight)
+	}
+	if got := conns.Load(); got > maxInFlight {
+		t.Errorf("%d connections opened for %d requests, want at most %d reused ones", got, 8*maxInFlight, maxInFlight)
+	}
+	if downloader.SharedImageFetcher() != downloader.SharedImageFetcher() {
+		t.Error("SharedImageFetcher returned different fetchers")
+	}
+}
+
+func TestImageFetcher_SendsRefererAndAccept(t *testing.T) {
+	page := encodePNG(t, 4, 4)
+	var referer, accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		referer, accept = r.Header.Get("Referer"), r.Header.Get("Accept")
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	req := downloader.ImageRequest{URL: server.URL + "/1.png", Domain: "fetcher-test.invalid", Referer: server.URL + "/chapter-1", Wait: noImageWait}
+	if err := downloader.NewImageFetcher(1).FetchTo(context.Background(), req, t.TempDir(), "1", true); err != nil {
+		t.Fatalf("FetchTo: %v", err)
+	}
+	if referer != server.URL+"/chapter-1" {
+		t.Errorf("Referer = %q, want the chapter page", referer)
+	}
+	if accept == "" {
+		t.Error("no Accept header sent")
+	}
+}
+
+func TestImageFetcher_StaysWithinMemoryBudget(t *testing.T) {
+	const budget, perImage = 1 << 20, 512 << 10
+	// Spooled pages go to a temp dir of their own, to check none is left behind
+	t.Setenv("TMPDIR", t.TempDir())
+
+	// JPEG magic in front of noise, saved as it is whatever the settings
+	pages := make(map[string][]byte)
+	rng := rand.New(rand.NewSource(1))
+	for i, size := range []int{3 << 20, 2 << 20, 3 << 20, 300 << 10, 200 << 10, 400 << 10} {
+		page := make([]byte, size)
+		rng.Read(page)
+		copy(page, []byte{0xFF, 0xD8, 0xFF, 0xE0})
+		pages[fmt.Sprintf("/%d.jpg", i+1)] = page
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := pages[r.URL.Path]
+		if strings.HasPrefix(r.URL.Path, "/2") || strings.HasPrefix(r.URL.Path, "/5") {
+			// Chunked, without a Content-Length for the fetcher to plan with
+			w.Write(page[:64<<10])
+			w.(http.Flusher).Flush()
+			page = page[64<<10:]
+		}
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	fetcher := downloader.NewImageFetcher(len(pages))
+	fetcher.SetMemoryBudget(budget, perImage)
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for name := range pages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := downloader.ImageRequest{URL: server.URL + name, Domain: "fetcher-test.invalid", Wait: noImageWait}
+			base := strings.TrimSuffix(strings.TrimPrefix(name, "/"), ".jpg")
+			if err := fetcher.FetchTo(context.Background(), req, dir, base, false); err != nil {
+				t.Errorf("FetchTo %s: %v", name, err)
+			}
+			if data, err := fetcher.Fetch(context.Background(), req); err != nil || !bytes.Equal(data, pages[name]) {
+				t.Errorf("Fetch %s: %d bytes, %v", name, len(data), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for name, page := range pages {
+		saved, err := os.ReadFile(filepath.Join(dir, "00"+strings.TrimPrefix(name, "/")))
+		if err != nil || !bytes.Equal(saved, page) {
+			t.Errorf("%s saved as %d bytes (%v), want the %d fetched", name, len(saved), err, len(page))
+		}
+	}
+	if peak := fetcher.PeakBufferedBytes(); peak > budget || peak == 0 {
+		t.Errorf("peak of %d bytes buffered, want some and at most the budget of %d", peak, budget)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(os.TempDir(), "kansho-image-*")); len(leftovers) > 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
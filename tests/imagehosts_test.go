@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"slices"
+	"testing"
+
+	"kansho/downloader"
+	"kansho/models"
+)
+
+var mixedImageURLs = []string{
+	"https://cdn.example.com/ch1/01.webp",
+	"https://ads.tracker.net/banner.gif",
+	"https://img2.cdn.example.com/ch1/02.webp",
+	"https://pixel.adnetwork.io/p.png?id=1",
+	"/uploads/ch1/03.webp",
+	"https://cdn.example.com/ch1/04.webp",
+}
+
+func TestFilterImageHostsAllow(t *testing.T) {
+	got := downloader.FilterImageHosts(mixedImageURLs, &models.ImageHosts{Allow: []string{"*.cdn.example.com", "CDN.example.com"}})
+	want := []string{
+		"https://cdn.example.com/ch1/01.webp",
+		"https://img2.cdn.example.com/ch1/02.webp",
+		"/uploads/ch1/03.webp",
+		"https://cdn.example.com/ch1/04.webp",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("allow filter = %v, want %v", got, want)
+	}
+}
+
+func TestFilterImageHostsDeny(t *testing.T) {
+	got := downloader.FilterImageHosts(mixedImageURLs, &models.ImageHosts{Deny: []string{"tracker.net", "adnetwork.io"}})
+	if len(got) != 4 || slices.Contains(got, "https://ads.tracker.net/banner.gif") {
+		t.Errorf("deny filter = %v", got)
+	}
+
+	// Deny wins over allow
+	got = downloader.FilterImageHosts(mixedImageURLs, &models.ImageHosts{
+		Allow: []string{"example.com"},
+		Deny:  []string{"img2.cdn.example.com"},
+	})
+	if slices.Contains(got, "https://img2.cdn.example.com/ch1/02.webp") || len(got) != 3 {
+		t.Errorf("allow+deny filter = %v", got)
+	}
+}
+
+func TestFilterImageHostsNoFilter(t *testing.T) {
+	if got := downloader.FilterImageHosts(mixedImageURLs, nil); !slices.Equal(got, mixedImageURLs) {
+		t.Errorf("nil filter changed the list: %v", got)
+	}
+
+	// "example.com" must not match "notexample.com"
+	got := downloader.FilterImageHosts([]string{"https://notexample.com/a.jpg"}, &models.ImageHosts{Allow: []string{"example.com"}})
+	if len(got) != 0 {
+		t.Errorf("suffix without a dot matched: %v", got)
+	}
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+// manifestSite serves one chapter whose pages come back from the API out of
+// alphabetical order, with a data URI page between them
+type manifestSite struct {
+	resumeSite
+	pages []string
+}
+
+func (s *manifestSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	return &downloader.ImageExtractionMethod{
+		Type: "api",
+		APIFunc: func(chapterURL string, chapterData map[string]string, client *downloader.APIClient) ([]string, error) {
+			return s.pages, nil
+		},
+	}
+}
+
+func Test_Manager_WritesImageManifestInDownloadOrder(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"write_image_manifest": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	page := encodePNG(t, 4, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	dataURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(page)
+	site := &manifestSite{
+		resumeSite: resumeSite{imageBase: server.URL},
+		pages:      []string{server.URL + "/img/zz.png", dataURI, server.URL + "/img/aa.png"},
+	}
+	manga := &config.Bookmarks{Title: "Manifest Test", Url: server.URL + "/series", Location: t.TempDir(), Site: site.GetSiteName()}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(filepath.Dir(tempDir))) })
+
+	if err := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site}).Download(context.Background()); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	cbzPath := filepath.Join(manga.Location, "ch001.cbz")
+	manifest, err := os.ReadFile(parser.ImageManifestPath(cbzPath))
+	if err != nil {
+		t.Fatalf("reading manifest: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(manifest), "\n"), "\n")
+	if len(lines) != 3 || lines[0] != site.pages[0] || lines[2] != site.pages[2] {
+		t.Fatalf("manifest =\n%s\nwant the 3 page URLs in download order", manifest)
+	}
+	if !strings.HasPrefix(lines[1], "data:image/png;base64,...") {
+		t.Errorf("data URI line = %q, want it shortened", lines[1])
+	}
+
+	names, _ := readZipEntries(t, cbzPath)
+	if len(names) != 3 {
+		t.Errorf("cbz has %d pages, want 3", len(names))
+	}
+
+	// The sidecar is not mistaken for a downloaded chapter
+	local, err := parser.LocalChapterList(manga.Location)
+	if err != nil || len(local) != 1 {
+		t.Errorf("LocalChapterList = %v, %v, want only ch001.cbz", local, err)
+	}
+}
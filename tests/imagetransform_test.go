@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"kansho/downloader"
+	"kansho/models"
+	"kansho/sites"
+)
+
+// madaraThumbnailTransform strips the WordPress size suffix of a resized image
+var madaraThumbnailTransform = models.ImageURLTransform{
+	Match:   `-\d+x\d+(\.\w+)$`,
+	Replace: "$1",
+}
+
+func TestTransformImageURLs_ThumbnailToFull(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	override := `{"sites": [{"name": "manhuaus", "image_url_transforms": [{"match": "-\\d+x\\d+(\\.\\w+)$", "replace": "$1"}]}]}`
+	overridePath := filepath.Join(configDir, "sites.json")
+	if err := os.WriteFile(overridePath, []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Remove(overridePath)
+		sites.ReloadSitesConfig()
+	})
+	sites.ReloadSitesConfig()
+
+	method := (&sites.ManhuausSite{}).GetImageExtractionMethod()
+	if !slices.Equal(method.ImageURLTransforms, []models.ImageURLTransform{madaraThumbnailTransform}) {
+		t.Fatalf("configured transforms = %+v", method.ImageURLTransforms)
+	}
+
+	got := downloader.TransformImageURLs([]string{
+		"https://cdn.example.com/wp-content/uploads/ch1/01-350x500.jpg",
+		"https://cdn.example.com/wp-content/uploads/ch1/02.jpg",
+	}, method.ImageURLTransforms)
+	want := []string{
+		"https://cdn.example.com/wp-content/uploads/ch1/01.jpg",
+		"https://cdn.example.com/wp-content/uploads/ch1/02.jpg",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("TransformImageURLs = %v, want %v", got, want)
+	}
+}
+
+func TestTransformImageURLs_NoOpByDefault(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	sites.ReloadSitesConfig()
+
+	if transforms := (&sites.ManhuausSite{}).GetImageExtractionMethod().ImageURLTransforms; transforms != nil {
+		t.Errorf("embedded config has transforms %+v, want none", transforms)
+	}
+	if got := downloader.TransformImageURLs(mixedImageURLs, nil); !slices.Equal(got, mixedImageURLs) {
+		t.Errorf("no transforms changed the list: %v", got)
+	}
+
+	// A bad pattern is skipped, the good one still applies, data URIs are left alone
+	got := downloader.TransformImageURLs(
+		[]string{"https://cdn.example.com/01-350x500.png", "data:image/png;base64,AAAA-1x1.png"},
+		[]models.ImageURLTransform{{Match: "("}, madaraThumbnailTransform},
+	)
+	want := []string{"https://cdn.example.com/01.png", "data:image/png;base64,AAAA-1x1.png"}
+	if !slices.Equal(got, want) {
+		t.Errorf("TransformImageURLs = %v, want %v", got, want)
+	}
+}
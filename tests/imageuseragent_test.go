@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"kansho/cf"
+	"kansho/config"
+	"kansho/downloader"
+)
+
+const capturedTestUA = "Mozilla/5.0 (X11; Linux x86_64) CapturedForTest/1.0"
+
+// uaSite serves one single page chapter from the test server, with or without the
+// CF bypass image path
+type uaSite struct {
+	resumeSite
+	needsCF bool
+}
+
+func (s *uaSite) NeedsCFBypass() bool { return s.needsCF }
+
+func (s *uaSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	return &downloader.ImageExtractionMethod{
+		Type: "api",
+		APIFunc: func(chapterURL string, chapterData map[string]string, client *downloader.APIClient) ([]string, error) {
+			return []string{s.imageBase + "/img/1"}, nil
+		},
+	}
+}
+
+// writeBypassData stores CF bypass data with the captured UA for the host of rawURL
+func writeBypassData(t *testing.T, rawURL string) {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(cf.BypassData{
+		Type:              cf.ProtectionCookie,
+		Domain:            u.Hostname(),
+		Entropy:           cf.Entropy{UserAgent: capturedTestUA},
+		CfClearanceStruct: &cf.CfClearanceCookie{Name: "cf_clearance", Value: "test", Domain: u.Hostname(), Path: "/"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(configDir, "kansho", "cf")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, u.Hostname()+".json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Manager_ImageRequestsUseCapturedUserAgent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	page := encodePNG(t, 4, 4)
+
+	var (
+		mu  sync.Mutex
+		uas []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/img/1" {
+			mu.Lock()
+			uas = append(uas, r.Header.Get("User-Agent"))
+			mu.Unlock()
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(page)
+	}))
+	defer server.Close()
+	writeBypassData(t, server.URL)
+
+	for _, needsCF := range []bool{false, true} {
+		site := &uaSite{resumeSite: resumeSite{imageBase: server.URL}, needsCF: needsCF}
+		manga := &config.Bookmarks{Title: "UA Test", Url: server.URL + "/series", Location: t.TempDir(), Site: site.GetSiteName()}
+		tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+		t.Cleanup(func() { os.RemoveAll(filepath.Dir(filepath.Dir(tempDir))) })
+
+		if err := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site}).Download(context.Background()); err != nil {
+			t.Fatalf("Download (needsCF=%v): %v", needsCF, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(uas) != 2 {
+		t.Fatalf("got %d image requests, want 2", len(uas))
+	}
+	for i, ua := range uas {
+		if ua != capturedTestUA {
+			t.Errorf("image request %d User-Agent = %q, want the captured %q", i, ua, capturedTestUA)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package integration
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/parser"
+)
+
+// mangaPage draws something close to a scanned page: panel borders, lettering,
+// a screentone gradient and scanner noise. Odd dimensions exercise the MCU padding.
+func mangaPage(gray bool) image.Image {
+	const w, h = 613, 901
+	rng := rand.New(rand.NewSource(1))
+	shade := func(x, y int) (uint8, uint8, uint8) {
+		v := 245.0
+		switch {
+		case x%300 < 4 || y%440 < 4:
+			v = 10 // panel borders
+		case y%440 > 40 && y%440 < 120 && x%300 > 30 && x%300 < 200 && (x/3+y/7)%5 < 2:
+			v = 30 // lettering
+		case y%440 > 200:
+			// screentone darkening down the panel
+			v = 245 - float64(y%440-200)/2*float64((x/4+y/4)%2)
+		}
+		v += rng.NormFloat64() * 4
+		c := uint8(max(0, min(255, v)))
+		if gray {
+			return c, c, c
+		}
+		return c, uint8(max(0, min(255, v*0.9))), uint8(max(0, min(255, v*0.8)))
+	}
+
+	if gray {
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				c, _, _ := shade(x, y)
+				img.SetGray(x, y, color.Gray{Y: c})
+			}
+		}
+		return img
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b := shade(x, y)
+			img.SetRGBA(x, y, color.RGBA{R: r, G: g, B: b, A: 255})
+		}
+	}
+	return img
+}
+
+// psnr compares the luminance of two images of the same size
+func psnr(t *testing.T, a, b image.Image) float64 {
+	t.Helper()
+	if a.Bounds().Size() != b.Bounds().Size() {
+		t.Fatalf("decoded %v, want %v", b.Bounds().Size(), a.Bounds().Size())
+	}
+	var sum float64
+	ab, bb := a.Bounds(), b.Bounds()
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			ya := color.GrayModel.Convert(a.At(ab.Min.X+x, ab.Min.Y+y)).(color.Gray).Y
+			yb := color.GrayModel.Convert(b.At(bb.Min.X+x, bb.Min.Y+y)).(color.Gray).Y
+			d := float64(ya) - float64(yb)
+			sum += d * d
+		}
+	}
+	return 10 * math.Log10(255*255/(sum/float64(ab.Dx()*ab.Dy())))
+}
+
+func TestEncodeOptimizedJPEG_SmallerAndDecodable(t *testing.T) {
+	for _, gray := range []bool{true, false} {
+		page := mangaPage(gray)
+
+		var baseline, optimized bytes.Buffer
+		if err := jpeg.Encode(&baseline, page, &jpeg.Options{Quality: 90}); err != nil {
+			t.Fatal(err)
+		}
+		if err := parser.EncodeOptimizedJPEG(&optimized, page, 90); err != nil {
+			t.Fatalf("EncodeOptimizedJPEG (gray=%v): %v", gray, err)
+		}
+		if optimized.Len() >= baseline.Len() {
+			t.Errorf("gray=%v: optimized %d bytes, baseline %d, want smaller", gray, optimized.Len(), baseline.Len())
+		}
+
+		decoded, err := jpeg.Decode(bytes.NewReader(optimized.Bytes()))
+		if err != nil {
+			t.Fatalf("gray=%v: optimized JPEG does not decode: %v", gray, err)
+		}
+		baselineDecoded, _ := jpeg.Decode(bytes.NewReader(baseline.Bytes()))
+		got, want := psnr(t, page, decoded), psnr(t, page, baselineDecoded)
+		if got < want-0.5 {
+			t.Errorf("gray=%v: optimized PSNR %.2fdB, baseline %.2fdB", gray, got, want)
+		}
+	}
+}
+
+func TestConvertImageToJPEG_OptimizeSetting(t *testing.T) {
+	var src bytes.Buffer
+	if err := png.Encode(&src, mangaPage(false)); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	defer parser.SetOptimizeJPEG(false)
+
+	sizes := map[bool]int64{}
+	for _, optimize := range []bool{false, true} {
+		parser.SetOptimizeJPEG(optimize)
+		out := filepath.Join(dir, "page.jpg")
+		if err := parser.ConvertImageToJPEG(src.Bytes(), out); err != nil {
+			t.Fatalf("ConvertImageToJPEG (optimize=%v): %v", optimize, err)
+		}
+		info, err := os.Stat(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes[optimize] = info.Size()
+	}
+	if sizes[true] >= sizes[false] {
+		t.Errorf("optimized page %d bytes, default %d", sizes[true], sizes[false])
+	}
+}
@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+// largeListSite lists count chapters newest first, padded to three digits like the
+// real sites, and logs every normalization at debug level like they do
+type largeListSite struct {
+	resumeSite
+	count int
+}
+
+func (s *largeListSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			chapters := make([]map[string]string, 0, s.count+1)
+			for number := s.count; number >= 1; number-- {
+				chapters = append(chapters, map[string]string{
+					"url":    fmt.Sprintf("https://example.test/chapter/%d", number),
+					"number": strconv.Itoa(number),
+				})
+			}
+			// A repeated entry, as sites with a "first chapter" link produce
+			chapters = append(chapters, map[string]string{"url": "https://example.test/first", "number": "1"})
+			return chapters, nil
+		},
+	}
+}
+
+func (s *largeListSite) NormalizeChapterFilename(data map[string]string) string {
+	filename := fmt.Sprintf("ch%03s.cbz", data["number"])
+	parser.Debugf("[LargeList] Normalized: %s → %s", data["number"], filename)
+	return filename
+}
+
+// fetchLogged fetches the chapter list of site and returns it with the log output
+func fetchLogged(t *testing.T, site downloader.SitePlugin, verbose bool) (downloader.ChapterList, string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	parser.SetVerboseLogging(verbose)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		parser.SetVerboseLogging(false)
+	}()
+
+	list, err := downloader.FetchChapterList(context.Background(), "https://example.test/series", site)
+	if err != nil {
+		t.Fatalf("FetchChapterList: %v", err)
+	}
+	return list, buf.String()
+}
+
+func Test_FetchChapterList_LargeList(t *testing.T) {
+	const count = 5000
+	site := &largeListSite{count: count}
+
+	list, output := fetchLogged(t, site, false)
+	if len(list.URLs) != count {
+		t.Fatalf("got %d chapters, want %d", len(list.URLs), count)
+	}
+	if got := list.URLs["ch001.cbz"]; got != "https://example.test/chapter/1" {
+		t.Errorf("ch001.cbz = %s, want the first entry kept over the duplicate", got)
+	}
+
+	// Default verbosity logs a summary, not a line per chapter
+	if lines := strings.Count(output, "\n"); lines > 10 {
+		t.Errorf("logged %d lines for %d chapters, want a handful:\n%s", lines, count, output)
+	}
+	if strings.Contains(output, "Normalized:") {
+		t.Errorf("per chapter lines logged with verbose logging off")
+	}
+
+	_, verboseOutput := fetchLogged(t, site, true)
+	if lines := strings.Count(verboseOutput, "Normalized:"); lines < count {
+		t.Errorf("verbose logging logged %d normalizations, want one per entry", lines)
+	}
+}
+
+func Test_SortChapterKeys_PastThreeDigits(t *testing.T) {
+	const count = 5000
+	chapterMap := make(map[string]string, count)
+	for number := 1; number <= count; number++ {
+		chapterMap[fmt.Sprintf("ch%03d.cbz", number)] = ""
+	}
+
+	sorted := parser.SortChapterKeys(chapterMap)
+	if len(sorted) != count {
+		t.Fatalf("got %d chapters, want %d", len(sorted), count)
+	}
+	for i, name := range sorted {
+		if want := fmt.Sprintf("ch%03d.cbz", i+1); name != want {
+			t.Fatalf("sorted[%d] = %s, want %s", i, name, want)
+		}
+	}
+}
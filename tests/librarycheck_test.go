@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kansho/config"
+)
+
+func TestCheckLibraryLocationMissingDrive(t *testing.T) {
+	// Neither the series folder nor its parent exist, like an unmounted drive
+	location := filepath.Join(t.TempDir(), "unmounted", "Manga", "Solo Leveling")
+
+	err := config.CheckLibraryLocation(location)
+	var libraryErr *config.LibraryUnavailableError
+	if !errors.As(err, &libraryErr) {
+		t.Fatalf("expected LibraryUnavailableError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "library folder not accessible") || libraryErr.Location != location {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Dir(location)); !os.IsNotExist(statErr) {
+		t.Error("the missing library path should not have been created")
+	}
+}
+
+func TestCheckLibraryLocationCreatesSeriesFolder(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "New Series")
+
+	if err := config.CheckLibraryLocation(location); err != nil {
+		t.Fatalf("new series folder under an existing library: %v", err)
+	}
+	if info, err := os.Stat(location); err != nil || !info.IsDir() {
+		t.Errorf("series folder was not created: %v", err)
+	}
+	if entries, _ := os.ReadDir(location); len(entries) != 0 {
+		t.Errorf("write check left files behind: %v", entries)
+	}
+}
+
+func TestCheckLibraryLocationReadOnly(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+
+	location := t.TempDir()
+	if err := os.Chmod(location, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(location, 0755) })
+
+	err := config.CheckLibraryLocation(location)
+	var libraryErr *config.LibraryUnavailableError
+	if !errors.As(err, &libraryErr) || !strings.Contains(err.Error(), "not writable") {
+		t.Errorf("expected a not writable LibraryUnavailableError, got %v", err)
+	}
+}
+
+func TestCheckLibraryLocationNotAFolder(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "series")
+	if err := os.WriteFile(location, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var libraryErr *config.LibraryUnavailableError
+	if err := config.CheckLibraryLocation(location); !errors.As(err, &libraryErr) {
+		t.Errorf("expected LibraryUnavailableError for a file, got %v", err)
+	}
+}
+
+func TestExecuteSiteDownloadChecksLibrary(t *testing.T) {
+	called := false
+	config.RegisterSite("librarycheck-test", func(ctx context.Context, manga *config.Bookmarks, cb config.ProgressFunc) error {
+		called = true
+		return nil
+	})
+
+	manga := &config.Bookmarks{Title: "Offline", Site: "librarycheck-test", Location: filepath.Join(t.TempDir(), "gone", "Offline")}
+	err := config.ExecuteSiteDownload(context.Background(), manga, nil)
+
+	var libraryErr *config.LibraryUnavailableError
+	if !errors.As(err, &libraryErr) {
+		t.Errorf("expected LibraryUnavailableError, got %v", err)
+	}
+	if called {
+		t.Error("site download ran despite the missing library")
+	}
+}
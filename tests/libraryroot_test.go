@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/config"
+	"kansho/sites"
+)
+
+func TestLibraryLocation(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := filepath.Join(home, "Manga")
+
+	tests := []struct {
+		root, title, want string
+	}{
+		{root, "Solo Leveling", filepath.Join(root, "Solo Leveling")},
+		{root, "Re:Zero  - Starting Life?", filepath.Join(root, "ReZero - Starting Life")},
+		{root, "  Tower\tof God...", filepath.Join(root, "Tower of God")},
+		{root, "../../etc", filepath.Join(root, "....etc")},
+		{"~/Manga", "Omniscient Reader", filepath.Join(root, "Omniscient Reader")},
+	}
+	for _, tt := range tests {
+		got, err := sites.LibraryLocation(tt.root, tt.title)
+		if err != nil {
+			t.Errorf("LibraryLocation(%q, %q): %v", tt.root, tt.title, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("LibraryLocation(%q, %q) = %q, want %q", tt.root, tt.title, got, tt.want)
+		}
+	}
+
+	for _, title := range []string{"", "...", `<>:"|?*`} {
+		if got, err := sites.LibraryLocation(root, title); err == nil {
+			t.Errorf("LibraryLocation(%q) = %q, want an error", title, got)
+		}
+	}
+}
+
+func TestDefaultLibraryRootSetting(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if root := config.LoadSettings().DefaultLibraryRoot; root != "" {
+		t.Fatalf("default library root = %q, want unset", root)
+	}
+
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"default_library_root": "~/Manga"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if root := config.LoadSettings().DefaultLibraryRoot; root != "~/Manga" {
+		t.Errorf("default library root = %q, want ~/Manga", root)
+	}
+}
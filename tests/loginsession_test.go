@@ -0,0 +1,110 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kansho/cf"
+	"kansho/downloader"
+
+	"github.com/gocolly/colly"
+)
+
+func Test_LoginSession_PersistedAndApplied(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var gotCookies, gotUA string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCookies = r.Header.Get("Cookie")
+		gotUA = r.Header.Get("User-Agent")
+		w.Write([]byte("<html><body>members only</body></html>"))
+	}))
+	defer server.Close()
+	host := strings.Split(strings.TrimPrefix(server.URL, "http://"), ":")[0]
+
+	session := &cf.BypassData{
+		URL:    server.URL + "/login",
+		Domain: host,
+		AllCookies: []cf.Cookie{
+			{Name: "session_id", Value: "abc123", Path: "/"},
+			{Name: "remember_me", Value: "yes", Path: "/", ExpirationDate: float64(time.Now().Add(time.Hour).Unix())},
+			{Name: "old_token", Value: "gone", Path: "/", ExpirationDate: float64(time.Now().Add(-time.Hour).Unix())},
+		},
+		Entropy: cf.Entropy{UserAgent: capturedTestUA},
+	}
+	if err := cf.SaveLoginSession(session, host); err != nil {
+		t.Fatalf("SaveLoginSession: %v", err)
+	}
+
+	loaded, err := cf.LoadLoginSession(host)
+	if err != nil {
+		t.Fatalf("LoadLoginSession: %v", err)
+	}
+	if loaded.Type != cf.ProtectionLogin || len(loaded.AllCookies) != 3 || loaded.CapturedAt == "" {
+		t.Errorf("loaded session = %+v", loaded)
+	}
+
+	// With no cf bypass data stored the collector still gets the session
+	c := colly.NewCollector()
+	if err := cf.ApplyToCollector(c, server.URL+"/series"); err != nil {
+		t.Fatalf("ApplyToCollector: %v", err)
+	}
+	if err := c.Visit(server.URL + "/series"); err != nil {
+		t.Fatalf("Visit: %v", err)
+	}
+	if !strings.Contains(gotCookies, "session_id=abc123") || !strings.Contains(gotCookies, "remember_me=yes") {
+		t.Errorf("collector sent cookies %q, want the login session", gotCookies)
+	}
+	if strings.Contains(gotCookies, "old_token") {
+		t.Errorf("collector sent the expired cookie: %q", gotCookies)
+	}
+	if gotUA != capturedTestUA {
+		t.Errorf("collector User-Agent = %q, want the login browser's", gotUA)
+	}
+
+	// The HTML client sends it too
+	gotCookies = ""
+	client, err := downloader.NewHTTPClient(host, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.FetchHTML(context.Background(), server.URL+"/series"); err != nil {
+		t.Fatalf("FetchHTML: %v", err)
+	}
+	if !strings.Contains(gotCookies, "session_id=abc123") {
+		t.Errorf("HTTP client sent cookies %q, want the login session", gotCookies)
+	}
+
+	// Clearing a stale cf_clearance must not log the user out
+	cf.DeleteDomain(host)
+	if _, err := cf.LoadLoginSession(host); err != nil {
+		t.Errorf("login session gone after DeleteDomain: %v", err)
+	}
+	if err := cf.DeleteLoginSession(host); err != nil {
+		t.Fatalf("DeleteLoginSession: %v", err)
+	}
+	if cookies := cf.LoginCookies(server.URL); cookies != nil {
+		t.Errorf("LoginCookies after logout = %v", cookies)
+	}
+}
+
+func TestLoginSession_ParentDomain(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if err := cf.SaveLoginSession(&cf.BypassData{Domain: "example.com", AllCookies: []cf.Cookie{{Name: "sid", Value: "1"}}}, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cf.LoadLoginSession("www.example.com"); err != nil {
+		t.Errorf("www.example.com: %v, want the example.com session", err)
+	}
+	if _, err := cf.LoadLoginSession("other.com"); err == nil {
+		t.Error("other.com found the example.com session")
+	}
+	if cookies := cf.LoginCookies("https://cdn.www.example.com/page"); len(cookies) != 1 {
+		t.Errorf("LoginCookies = %v, want the example.com session", cookies)
+	}
+}
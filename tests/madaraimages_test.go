@@ -0,0 +1,49 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"kansho/downloader"
+	"kansho/sites"
+)
+
+func Test_MadaraSites_ImageAttributeFallback(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	sites.ReloadSitesConfig()
+
+	variants := map[string]string{
+		"data-src":               `<img data-src=" https://cdn.example.com/1.jpg " src="placeholder.gif">`,
+		"data-lazy-src":          `<img data-lazy-src="https://cdn.example.com/1.jpg" src="data:image/gif;base64,R0lGOD">`,
+		"src":                    `<img src="https://cdn.example.com/1.jpg">`,
+		"srcset":                 `<img srcset="https://cdn.example.com/1-small.jpg 400w, https://cdn.example.com/1.jpg 1200w">`,
+		"empty data-src":         `<img data-src="" src="https://cdn.example.com/1.jpg">`,
+		"data-src before srcset": `<img data-src="https://cdn.example.com/1.jpg" srcset="https://cdn.example.com/other.jpg 2x">`,
+	}
+
+	methods := map[string]*downloader.ImageExtractionMethod{
+		"kunmanga": (&sites.KunmangaSite{}).GetImageExtractionMethod(),
+		"manhuaus": (&sites.ManhuausSite{}).GetImageExtractionMethod(),
+	}
+	for site, method := range methods {
+		if method.Attribute != downloader.MadaraImageAttributes {
+			t.Errorf("%s image attribute = %q, want %q", site, method.Attribute, downloader.MadaraImageAttributes)
+		}
+		for variant, img := range variants {
+			html := fmt.Sprintf(`<html><body><div class="reading-content">%s</div></body></html>`, img)
+			images, err := downloader.SelectImageURLs(html, method.Selector, method.Attribute)
+			if err != nil {
+				t.Fatalf("%s: SelectImageURLs: %v", site, err)
+			}
+			if len(images) != 1 || images[0] != "https://cdn.example.com/1.jpg" {
+				t.Errorf("%s, %s: got %v", site, variant, images)
+			}
+		}
+	}
+
+	// An image with none of the attributes is not a page
+	images, _ := downloader.SelectImageURLs(`<div class="reading-content"><img alt="ad"></div>`, "div.reading-content img", downloader.MadaraImageAttributes)
+	if len(images) != 0 {
+		t.Errorf("image without a URL attribute gave %v", images)
+	}
+}
@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"errors"
+	"testing"
+
+	"kansho/downloader"
+)
+
+const wordpressDBErrorHTML = `<!DOCTYPE html><html><head><title>Database Error</title></head>
+<body><h1>Error establishing a database connection</h1></body></html>`
+
+const maintenanceBannerHTML = `<html><head><title>Kunmanga</title>
+<script>var msg = "nothing to see";</script></head>
+<body><div class="banner">
+  <h2>We&#39;ll be back soon!</h2>
+  <p>The site is currently   UNDER
+  maintenance, please check back later.</p>
+</div></body></html>`
+
+// maintenanceSite adds its own outage wording to the default signatures
+type maintenanceSite struct{ resumeSite }
+
+func (s *maintenanceSite) MaintenanceSignatures() []string {
+	return []string{"the reactor is being refuelled"}
+}
+
+func TestMaintenanceError(t *testing.T) {
+	site := &resumeSite{}
+
+	for name, html := range map[string]string{
+		"wordpress db error": wordpressDBErrorHTML,
+		"maintenance banner": maintenanceBannerHTML,
+	} {
+		err := downloader.MaintenanceError(html, "https://example.com/manga/x", site)
+		if !errors.Is(err, downloader.ErrSiteMaintenance) {
+			t.Errorf("%s: expected ErrSiteMaintenance, got %v", name, err)
+		}
+	}
+}
+
+func TestMaintenanceErrorIgnoresNormalPages(t *testing.T) {
+	site := &resumeSite{}
+
+	pages := []string{
+		`<html><body><div class="chapter-list"></div></body></html>`,
+		// Signature words in a script are not visible text
+		`<html><body><script>if (down) showBanner("under maintenance")</script><p>No chapters yet</p></body></html>`,
+	}
+	for _, html := range pages {
+		if err := downloader.MaintenanceError(html, "https://example.com/manga/x", site); err != nil {
+			t.Errorf("normal page reported as maintenance: %v", err)
+		}
+	}
+}
+
+func TestMaintenanceErrorSiteSignatures(t *testing.T) {
+	html := `<html><body><p>The reactor is being refuelled. Back at noon.</p></body></html>`
+
+	if err := downloader.MaintenanceError(html, "https://example.com", &resumeSite{}); err != nil {
+		t.Errorf("site specific wording matched without the site signatures: %v", err)
+	}
+	if err := downloader.MaintenanceError(html, "https://example.com", &maintenanceSite{}); !errors.Is(err, downloader.ErrSiteMaintenance) {
+		t.Errorf("expected ErrSiteMaintenance from site signatures, got %v", err)
+	}
+
+	if signature, ok := downloader.MatchMaintenance(maintenanceBannerHTML, []string{"check back later"}); !ok || signature != "check back later" {
+		t.Errorf("MatchMaintenance with custom signatures = %q, %v", signature, ok)
+	}
+}
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"kansho/config"
+	"kansho/parser"
+	"kansho/sites"
+)
+
+const sampleMangadexAggregate = `{
+  "result": "ok",
+  "volumes": {
+    "none": {
+      "volume": "none",
+      "count": 3,
+      "chapters": {
+        "none": {"chapter": "none", "id": "c0", "count": 1},
+        "101":  {"chapter": "101", "id": "c101", "count": 1},
+        "101.5": {"chapter": "101.5", "id": "c1015", "count": 1}
+      }
+    },
+    "1": {
+      "volume": "1",
+      "count": 2,
+      "chapters": {
+        "1": {"chapter": "1", "id": "c1", "count": 1},
+        "2": {"chapter": "2", "id": "c2", "count": 2}
+      }
+    }
+  }
+}`
+
+func Test_MangadexAggregate_LatestChapter(t *testing.T) {
+	var aggregate sites.MangaDexAggregate
+	if err := json.Unmarshal([]byte(sampleMangadexAggregate), &aggregate); err != nil {
+		t.Fatalf("failed to decode aggregate: %v", err)
+	}
+
+	if len(aggregate.Volumes) != 2 {
+		t.Fatalf("expected 2 volumes, got %d", len(aggregate.Volumes))
+	}
+
+	if got := aggregate.LatestChapter(); got != 101.5 {
+		t.Fatalf("LatestChapter() = %v, want 101.5", got)
+	}
+}
+
+func Test_MangadexNeedsFeed_ForcedRuns(t *testing.T) {
+	manga := &config.Bookmarks{Title: "Up To Date", Site: "mangadex", SyncMode: config.SyncModeAppend}
+	if reason := sites.MangadexNeedsFeed(context.Background(), manga); reason != "" {
+		t.Errorf("a normal run needs the feed: %q, want the aggregate check", reason)
+	}
+	if reason := sites.MangadexNeedsFeed(config.WithRedownload(context.Background(), "ch001.cbz"), manga); reason == "" {
+		t.Error("a re-download may be skipped by the aggregate check")
+	}
+	if reason := sites.MangadexNeedsFeed(config.WithRetryFailedOnly(context.Background()), manga); reason == "" {
+		t.Error("retrying failed chapters may be skipped by the aggregate check")
+	}
+
+	// A full sync re-verifies the page counts of every local chapter
+	full := &config.Bookmarks{Title: "Up To Date", Site: "mangadex", SyncMode: config.SyncModeFull}
+	if reason := sites.MangadexNeedsFeed(context.Background(), full); reason == "" {
+		t.Error("a full sync may be skipped by the aggregate check")
+	}
+
+	// Chapters that failed last run are retried by a normal run, they need the feed
+	failed := &config.Bookmarks{Title: "Up To Date", Site: "mangadex", SyncMode: config.SyncModeAppend, Location: t.TempDir()}
+	if err := parser.UpdateFailedChapters(failed.Location, map[string]string{"ch002.cbz": "https://mangadex.org/chapter/2"}, nil); err != nil {
+		t.Fatalf("UpdateFailedChapters: %v", err)
+	}
+	if reason := sites.MangadexNeedsFeed(context.Background(), failed); reason == "" {
+		t.Error("a series with failed chapters may be skipped by the aggregate check")
+	}
+}
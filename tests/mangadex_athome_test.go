@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"kansho/config"
+	"kansho/sites"
+)
+
+func Test_MangadexAtHomeURL_ForcePort443(t *testing.T) {
+	plain, err := url.Parse(sites.MangadexAtHomeURL("chapter-id", false))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if plain.Query().Has("forcePort443") {
+		t.Errorf("forcePort443 set without the option: %s", plain)
+	}
+
+	forced, err := url.Parse(sites.MangadexAtHomeURL("chapter-id", true))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := forced.Query().Get("forcePort443"); got != "true" {
+		t.Errorf("forcePort443 = %q, want true (%s)", got, forced)
+	}
+	if forced.Path != plain.Path {
+		t.Errorf("path changed: %s vs %s", forced.Path, plain.Path)
+	}
+}
+
+func Test_Settings_MangadexForcePort443(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if config.LoadSettings().MangadexForcePort443 {
+		t.Fatal("forcePort443 should default to off")
+	}
+
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"mangadex_force_port_443": true}`), 0644); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	if !config.LoadSettings().MangadexForcePort443 {
+		t.Error("forcePort443 not read from settings.json")
+	}
+}
+
+func Test_MangadexImageURLs_DataSaver(t *testing.T) {
+	atHome := sites.MangaDexAtHomeResponse{
+		BaseUrl: "https://node.example",
+		Chapter: sites.MangaDexAtHomeChapterData{
+			Hash:      "abc",
+			Data:      []string{"1-full.png", "2-full.png"},
+			DataSaver: []string{"1-small.jpg", "2-small.jpg"},
+		},
+	}
+
+	if got, want := sites.MangadexImageURLs(atHome, false), []string{
+		"https://node.example/data/abc/1-full.png",
+		"https://node.example/data/abc/2-full.png",
+	}; !slices.Equal(got, want) {
+		t.Errorf("full quality urls = %q, want %q", got, want)
+	}
+	if got, want := sites.MangadexImageURLs(atHome, true), []string{
+		"https://node.example/data-saver/abc/1-small.jpg",
+		"https://node.example/data-saver/abc/2-small.jpg",
+	}; !slices.Equal(got, want) {
+		t.Errorf("data saver urls = %q, want %q", got, want)
+	}
+
+	// Without data saver pages the originals are downloaded
+	atHome.Chapter.DataSaver = nil
+	if got := sites.MangadexImageURLs(atHome, true); len(got) != 2 || !strings.Contains(got[0], "/data/") {
+		t.Errorf("urls without data saver pages = %q, want the full quality ones", got)
+	}
+}
+
+func Test_Bookmarks_UsesDataSaver(t *testing.T) {
+	for _, tc := range []struct {
+		series, setting string
+		want            bool
+	}{
+		{"", "", false},
+		{"", config.ImageQualityDataSaver, true},
+		{config.ImageQualityOriginal, config.ImageQualityDataSaver, false},
+		{config.ImageQualityDataSaver, "", true},
+		{config.ImageQualityDataSaver, config.ImageQualityOriginal, true},
+	} {
+		manga := config.Bookmarks{ImageQuality: tc.series}
+		if got := manga.UsesDataSaver(tc.setting); got != tc.want {
+			t.Errorf("image_quality %q with setting %q: data saver = %v, want %v", tc.series, tc.setting, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"kansho/sites"
+)
+
+// fakeMangadexFeed serves a feed of total chapters in pages, failing the page at an
+// offset as many times as failures says. It counts the requests per offset.
+func fakeMangadexFeed(total int, failures map[int]int) (sites.MangadexFeedPage, map[int]int) {
+	requests := map[int]int{}
+	return func(ctx context.Context, offset, limit int) (sites.MangaDexChapterList, error) {
+		requests[offset]++
+		if failures[offset] > 0 {
+			failures[offset]--
+			return sites.MangaDexChapterList{}, errors.New("failed to fetch chapters: unexpected end of JSON input")
+		}
+
+		page := sites.MangaDexChapterList{Result: "ok", Limit: limit, Offset: offset, Total: total}
+		for i := offset; i < min(offset+limit, total); i++ {
+			page.Data = append(page.Data, sites.MangaDexChapter{ID: fmt.Sprintf("c%d", i)})
+		}
+		return page, nil
+	}, requests
+}
+
+func Test_CollectMangadexFeed_RetriesFailedPage(t *testing.T) {
+	fetch, requests := fakeMangadexFeed(250, map[int]int{100: 1})
+
+	chapters, gaps, err := sites.CollectMangadexFeed(context.Background(), fetch, 0)
+	if err != nil {
+		t.Fatalf("CollectMangadexFeed: %v", err)
+	}
+	if len(gaps) != 0 {
+		t.Errorf("gaps = %+v, want none", gaps)
+	}
+	if len(chapters) != 250 {
+		t.Fatalf("collected %d chapters, want 250", len(chapters))
+	}
+	for i, chapter := range chapters {
+		if chapter.ID != fmt.Sprintf("c%d", i) {
+			t.Fatalf("chapter %d is %s, pages out of order", i, chapter.ID)
+		}
+	}
+	if requests[0] != 1 || requests[100] != 2 || requests[200] != 1 {
+		t.Errorf("requests per offset = %v, want page 2 fetched twice", requests)
+	}
+}
+
+func Test_CollectMangadexFeed_SkipsPageThatKeepsFailing(t *testing.T) {
+	fetch, _ := fakeMangadexFeed(250, map[int]int{100: 10})
+
+	chapters, gaps, err := sites.CollectMangadexFeed(context.Background(), fetch, 0)
+	if err != nil {
+		t.Fatalf("CollectMangadexFeed: %v", err)
+	}
+	if len(chapters) != 150 {
+		t.Errorf("collected %d chapters, want the 150 of pages 1 and 3", len(chapters))
+	}
+	if len(gaps) != 1 || gaps[0].Offset != 100 || gaps[0].Err == nil {
+		t.Errorf("gaps = %+v, want page 2", gaps)
+	}
+
+	// Without the first page the total is unknown, that is an error
+	fetch, _ = fakeMangadexFeed(250, map[int]int{0: 10})
+	if _, _, err := sites.CollectMangadexFeed(context.Background(), fetch, 0); err == nil {
+		t.Error("CollectMangadexFeed succeeded without the first page")
+	}
+}
+
+func Test_CollectMangadexFeedConcurrent_KeepsFeedOrder(t *testing.T) {
+	const total = 730 // 8 pages, the last one short
+
+	var (
+		mu       sync.Mutex
+		requests = map[int]int{}
+		inFlight int
+		peak     int
+	)
+	// Later pages answer sooner, so they come back out of order
+	fetch := func(ctx context.Context, offset, limit int) (sites.MangaDexChapterList, error) {
+		mu.Lock()
+		requests[offset]++
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(time.Duration(total-offset) * 20 * time.Microsecond)
+		page := sites.MangaDexChapterList{Result: "ok", Limit: limit, Offset: offset, Total: total}
+		for i := offset; i < min(offset+limit, total); i++ {
+			page.Data = append(page.Data, sites.MangaDexChapter{ID: fmt.Sprintf("c%d", i)})
+		}
+		return page, nil
+	}
+
+	for _, concurrency := range []int{1, 3, 16} {
+		clear(requests)
+		peak = 0
+
+		chapters, gaps, err := sites.CollectMangadexFeedConcurrent(context.Background(), fetch, 0, concurrency)
+		if err != nil {
+			t.Fatalf("concurrency %d: %v", concurrency, err)
+		}
+		if len(gaps) != 0 {
+			t.Errorf("concurrency %d: gaps = %+v, want none", concurrency, gaps)
+		}
+		if len(chapters) != total {
+			t.Fatalf("concurrency %d: collected %d chapters, want %d", concurrency, len(chapters), total)
+		}
+		for i, chapter := range chapters {
+			if chapter.ID != fmt.Sprintf("c%d", i) {
+				t.Fatalf("concurrency %d: chapter %d is %s, pages out of order", concurrency, i, chapter.ID)
+			}
+		}
+		if len(requests) != 8 {
+			t.Errorf("concurrency %d: requested %d pages, want 8", concurrency, len(requests))
+		}
+		for offset, n := range requests {
+			if n != 1 {
+				t.Errorf("concurrency %d: page at offset %d requested %d times", concurrency, offset, n)
+			}
+		}
+		if peak > concurrency {
+			t.Errorf("concurrency %d: %d pages fetched at once", concurrency, peak)
+		}
+	}
+}
+
+func Test_SelectMangadexChapters_FollowsPreferences(t *testing.T) {
+	// Feed entries as the API returns them with includes[]=scanlation_group
+	feed := `[
+		{"id": "1-a", "attributes": {"chapter": "1", "translatedLanguage": "en"}, "relationships": [{"id": "g-a", "type": "scanlation_group", "attributes": {"name": "Alpha Scans"}}]},
+		{"id": "1-b", "attributes": {"chapter": "1", "translatedLanguage": "en"}, "relationships": [{"id": "g-b", "type": "scanlation_group", "attributes": {"name": "Beta Scans"}}]},
+		{"id": "2-a", "attributes": {"chapter": "2", "translatedLanguage": "en"}, "relationships": [{"id": "g-a", "type": "scanlation_group", "attributes": {"name": "Alpha Scans"}}]},
+		{"id": "2-es", "attributes": {"chapter": "2", "translatedLanguage": "es-la"}, "relationships": [{"id": "g-b", "type": "scanlation_group", "attributes": {"name": "Beta Scans"}}]},
+		{"id": "3-c", "attributes": {"chapter": "3", "translatedLanguage": "en"}, "relationships": [{"id": "g-c", "type": "scanlation_group"}]},
+		{"id": "3-d", "attributes": {"chapter": "3", "translatedLanguage": "en"}, "relationships": [{"id": "g-d", "type": "scanlation_group"}]}
+	]`
+	var chapters []sites.MangaDexChapter
+	if err := json.Unmarshal([]byte(feed), &chapters); err != nil {
+		t.Fatal(err)
+	}
+	ids := func(selected []sites.MangaDexChapter) []string {
+		var out []string
+		for _, chapter := range selected {
+			out = append(out, chapter.ID)
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		name              string
+		languages, groups []string
+		want              []string
+	}{
+		{"feed order without preferences", nil, nil, []string{"1-a", "2-a", "3-c"}},
+		{"preferred group by name", []string{"en"}, []string{"beta scans"}, []string{"1-b", "2-a", "3-c"}},
+		{"preferred group by id", []string{"en"}, []string{"g-d", "g-b"}, []string{"1-b", "2-a", "3-d"}},
+		{"language before group", []string{"es-la", "en"}, []string{"Alpha Scans"}, []string{"1-a", "2-es", "3-c"}},
+	} {
+		if got := ids(sites.SelectMangadexChapters(chapters, tc.languages, tc.groups)); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: selected %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"kansho/sites"
+)
+
+func TestMangadexRateLimiterConcurrent(t *testing.T) {
+	const (
+		requests = 4
+		window   = 300 * time.Millisecond
+		callers  = 14
+	)
+
+	limiter := sites.NewMangadexRateLimiter(map[string]sites.MangadexBudget{
+		sites.MangadexEndpointImage: {Requests: requests, Window: window},
+	})
+
+	var (
+		mu    sync.Mutex
+		times []time.Time
+		wg    sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Wait(context.Background(), sites.MangadexEndpointImage); err != nil {
+				t.Errorf("Wait: %v", err)
+				return
+			}
+			mu.Lock()
+			times = append(times, time.Now())
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(times) != callers {
+		t.Fatalf("expected %d grants, got %d", callers, len(times))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	// No window may contain more than the budget
+	const tolerance = 15 * time.Millisecond
+	for i := 0; i+requests < len(times); i++ {
+		if gap := times[i+requests].Sub(times[i]); gap < window-tolerance {
+			t.Errorf("grants %d and %d only %v apart, budget allows %d per %v", i, i+requests, gap, requests, window)
+		}
+	}
+
+	// The first budget's worth proceeds at once rather than serially
+	if first := times[requests-1].Sub(start); first > 100*time.Millisecond {
+		t.Errorf("first %d grants took %v, expected them to run concurrently", requests, first)
+	}
+}
+
+func TestMangadexRateLimiterCancel(t *testing.T) {
+	limiter := sites.NewMangadexRateLimiter(map[string]sites.MangadexBudget{
+		sites.MangadexEndpointAtHome: {Requests: 1, Window: time.Minute},
+	})
+
+	if err := limiter.Wait(context.Background(), sites.MangadexEndpointAtHome); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	if err := limiter.Wait(ctx, sites.MangadexEndpointAtHome); err == nil {
+		t.Fatal("expected Wait to fail once the context is done")
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("cancelled Wait blocked for %v", elapsed)
+	}
+
+	// Endpoints without a budget are not limited
+	if err := limiter.Wait(context.Background(), sites.MangadexEndpointAPI); err != nil {
+		t.Errorf("unbudgeted endpoint: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"testing"
+
+	"kansho/sites"
+)
+
+func Test_TitleFromHTML(t *testing.T) {
+	cases := []struct {
+		name string
+		host string
+		html string
+		want string
+	}{
+		{
+			"og:title preferred", "asuracomic.net",
+			`<html><head><title>Solo Leveling - Asura Scans</title>
+			<meta property="og:title" content="Solo Leveling: Ragnarok"></head></html>`,
+			"Solo Leveling Ragnarok",
+		},
+		{
+			"site suffix stripped from title", "asuracomic.net",
+			`<html><head><title>Mercenary Enrollment | Asura Scans</title></head></html>`,
+			"Mercenary Enrollment",
+		},
+		{
+			"read online wording stripped", "kunmanga.com",
+			`<html><head><meta property="og:title" content="Read Tower of God Manhwa Online Free"></head></html>`,
+			"Tower of God",
+		},
+		{
+			"entities and whitespace", "manhuaus.com",
+			"<html><head><title>  Kaguya&#45;sama \n Love   Is War  &ndash; Manhuaus </title></head></html>",
+			"Kaguya-sama Love Is War",
+		},
+		{
+			"illegal folder characters", "example.com",
+			`<html><head><meta property="og:title" content="Who/What? &quot;Hero&quot;*"></head></html>`,
+			"WhoWhat Hero",
+		},
+		{
+			"no title", "example.com",
+			`<html><head></head><body>nothing</body></html>`,
+			"",
+		},
+	}
+
+	for _, tc := range cases {
+		if got := sites.TitleFromHTML(tc.html, tc.host); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func Test_MangadexTitleFromResponse(t *testing.T) {
+	english := []byte(`{"result":"ok","data":{"id":"x","attributes":{
+		"title":{"en":"  The Apothecary Diaries "},
+		"altTitles":[{"ja":"薬屋のひとりごと"}]}}}`)
+	if got, err := sites.MangadexTitleFromResponse(english); err != nil || got != "The Apothecary Diaries" {
+		t.Errorf("english title: got %q, %v", got, err)
+	}
+
+	altOnly := []byte(`{"data":{"attributes":{
+		"title":{"ja-ro":"Kusuriya no Hitorigoto"},
+		"altTitles":[{"ja":"薬屋のひとりごと"},{"en":"The Apothecary Diaries"}]}}}`)
+	if got, err := sites.MangadexTitleFromResponse(altOnly); err != nil || got != "The Apothecary Diaries" {
+		t.Errorf("english alt title: got %q, %v", got, err)
+	}
+
+	romanized := []byte(`{"data":{"attributes":{"title":{"ja-ro":"Kusuriya no Hitorigoto"},"altTitles":[]}}}`)
+	if got, err := sites.MangadexTitleFromResponse(romanized); err != nil || got != "Kusuriya no Hitorigoto" {
+		t.Errorf("fallback title: got %q, %v", got, err)
+	}
+
+	if _, err := sites.MangadexTitleFromResponse([]byte(`{"data":{"attributes":{"title":{}}}}`)); err == nil {
+		t.Error("expected error for response without title")
+	}
+}
@@ -0,0 +1,45 @@
+package integration
+
+import (
+	"testing"
+
+	"kansho/sites"
+)
+
+func TestNormalizeMangaURL(t *testing.T) {
+	cases := []struct {
+		site, raw, want string
+	}{
+		// Madara style sites keep a trailing slash
+		{"mangakatana", " https://mangakatana.com/manga/abc.123?utm_source=discord&utm_medium=share ", "https://mangakatana.com/manga/abc.123/"},
+		{"manhuaus", "https://ManhuaUS.com/manga/x//#comments", "https://manhuaus.com/manga/x/"},
+		{"kunmanga", "https://www.kunmanga.online/manga/ugly-complex?fbclid=abc", "https://www.kunmanga.online/manga/ugly-complex/"},
+		// The others drop it, other query parameters stay
+		{"weebcentral", "https://weebcentral.com/series/01J76XY7E9FNDZ1DBBM6PBJPFK/One-Piece/?ref=home", "https://weebcentral.com/series/01J76XY7E9FNDZ1DBBM6PBJPFK/One-Piece"},
+		{"mangadex", "https://mangadex.org/title/abc/slug/?tab=chapters&gclid=x", "https://mangadex.org/title/abc/slug?tab=chapters"},
+		{"mgeko", "https://www.mgeko.cc/manga/title/?lang=en", "https://www.mgeko.cc/manga/title?lang=en"},
+		// Sites without rules only lose the tracking bits
+		{"hls", "https://stream.example.com/live/index.m3u8/?utm_campaign=x", "https://stream.example.com/live/index.m3u8/"},
+	}
+	for _, c := range cases {
+		got, err := sites.NormalizeMangaURL(c.site, c.raw)
+		if err != nil {
+			t.Errorf("NormalizeMangaURL(%s, %q): %v", c.site, c.raw, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("NormalizeMangaURL(%s, %q) = %q, want %q", c.site, c.raw, got, c.want)
+		}
+	}
+
+	for _, c := range []struct{ site, raw string }{
+		{"weebcentral", "https://mangadex.org/title/abc"},
+		{"mangadex", "https://fakemangadex.org/title/abc"},
+		{"mangakatana", "mangakatana.com/manga/abc"},
+		{"mgeko", "ftp://www.mgeko.cc/manga/title"},
+	} {
+		if got, err := sites.NormalizeMangaURL(c.site, c.raw); err == nil {
+			t.Errorf("NormalizeMangaURL(%s, %q) = %q, want an error", c.site, c.raw, got)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+func Test_Manager_MaxChaptersPerRun(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"max_chapters_per_run": 2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := newMockMangaSite(t, map[int]int{1: 1, 2: 1, 3: 1})
+	site := &mockSitePlugin{}
+	manga := &config.Bookmarks{Title: "Mock Capped Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: site.GetSiteName()}
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz"))) })
+
+	run := func() []string {
+		t.Helper()
+		if err := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site}).Download(context.Background()); err != nil {
+			t.Fatalf("Download: %v", err)
+		}
+		local, err := parser.LocalChapterList(manga.Location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(local)
+		return local
+	}
+
+	if local, want := run(), []string{"ch001.cbz", "ch002.cbz"}; !slices.Equal(local, want) {
+		t.Errorf("first run downloaded %v, want the oldest two %v", local, want)
+	}
+	// The next run picks up where the cap stopped
+	if local, want := run(), []string{"ch001.cbz", "ch002.cbz", "ch003.cbz"}; !slices.Equal(local, want) {
+		t.Errorf("second run left %v, want %v", local, want)
+	}
+	if hits := mock.images(); hits != 3 {
+		t.Errorf("fetched %d images, want each page once", hits)
+	}
+}
@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"kansho/config"
+)
+
+// protoField appends one protobuf field to buf: strings and nested messages as
+// []byte/string, everything else as a varint
+func protoField(buf []byte, field int, value any) []byte {
+	switch v := value.(type) {
+	case string:
+		return protoField(buf, field, []byte(v))
+	case []byte:
+		buf = binary.AppendUvarint(buf, uint64(field)<<3|2)
+		buf = binary.AppendUvarint(buf, uint64(len(v)))
+		return append(buf, v...)
+	case int:
+		buf = binary.AppendUvarint(buf, uint64(field)<<3)
+		return binary.AppendUvarint(buf, uint64(v))
+	}
+	panic("unsupported proto value")
+}
+
+func mihonManga(source int, url, title string, extra ...[]byte) []byte {
+	var m []byte
+	m = protoField(m, 1, source)
+	m = protoField(m, 2, url)
+	m = protoField(m, 3, title)
+	m = protoField(m, 5, "Some Author") // unused fields are skipped
+	for _, e := range extra {
+		m = append(m, e...)
+	}
+	return m
+}
+
+func mihonSource(id int, name string) []byte {
+	var s []byte
+	s = protoField(s, 1, name)
+	return protoField(s, 2, id)
+}
+
+func TestImportMihonBackup(t *testing.T) {
+	const (
+		asuraID   = 6247824327199706550
+		dexID     = 2499283573021220255
+		unknownID = 1234
+		favorite  = 100 // BackupManga.favorite
+	)
+
+	var backup []byte
+	backup = protoField(backup, 1, mihonManga(asuraID, "/series/solo-leveling-1234", "Solo Leveling"))
+	backup = protoField(backup, 1, mihonManga(dexID, "/manga/a96676e5-8ae2-425e-b549-7f15dd34a6d8", "Frieren: Beyond Journey's End"))
+	backup = protoField(backup, 1, mihonManga(unknownID, "/comic/123", "Some Webtoon"))
+	backup = protoField(backup, 1, mihonManga(0, "https://www.kunmanga.online/manga/absolute/", "Absolute URL"))
+	// Manga only kept for its reading history, not in the library
+	backup = protoField(backup, 1, mihonManga(asuraID, "/series/dropped", "Dropped", protoField(nil, favorite, 0)))
+	backup = protoField(backup, 101, mihonSource(asuraID, "Asura Scans"))
+	backup = protoField(backup, 101, mihonSource(dexID, "MangaDex"))
+	backup = protoField(backup, 101, mihonSource(unknownID, "Some Source"))
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(backup)
+	w.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "library.tachibk")
+	if err := os.WriteFile(path, gz.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	root := filepath.Join(dir, "Manga")
+	bookmarks, report, err := config.ImportMihonBackupWithReport(path, root)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	want := []config.Bookmarks{
+		{Title: "Solo Leveling", Site: "asurascans", Url: "https://asurascans.com/series/solo-leveling-1234", Location: filepath.Join(root, "Solo Leveling")},
+		{Title: "Frieren: Beyond Journey's End", Site: "mangadex", Url: "https://mangadex.org/title/a96676e5-8ae2-425e-b549-7f15dd34a6d8", Location: filepath.Join(root, "Frieren Beyond Journey's End")},
+		{Title: "Absolute URL", Site: "kunmanga", Url: "https://www.kunmanga.online/manga/absolute/", Location: filepath.Join(root, "Absolute URL")},
+	}
+	if len(bookmarks) != len(want) {
+		t.Fatalf("got %d bookmarks, want %d: %+v", len(bookmarks), len(want), bookmarks)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(bookmarks[i], want[i]) {
+			t.Errorf("bookmark %d = %+v, want %+v", i, bookmarks[i], want[i])
+		}
+	}
+
+	if report.Total != 4 {
+		t.Errorf("report.Total = %d, want 4 library entries", report.Total)
+	}
+	if len(report.Unsupported) != 1 || report.Unsupported[0] != "Some Webtoon (Some Source)" {
+		t.Errorf("report.Unsupported = %v", report.Unsupported)
+	}
+}
+
+func TestImportMihonBackupLegacyJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tachiyomi.json")
+	legacy := `{"version":2,
+		"mangas":[{"manga":["/manga/abc/","Katana Series",5555,0,0]}],
+		"extensions":["5555:MangaKatana"]}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bookmarks, err := config.ImportMihonBackup(path, "/library")
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].Site != "mangakatana" || bookmarks[0].Url != "https://mangakatana.com/manga/abc/" {
+		t.Errorf("bookmarks = %+v", bookmarks)
+	}
+}
+
+func TestImportMihonBackupRejectsGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notabackup.tachibk")
+	os.WriteFile(path, []byte{0x0f, 0xff, 0xff}, 0644)
+
+	if _, err := config.ImportMihonBackup(path, "/library"); err == nil {
+		t.Error("expected an error for a file that is not a backup")
+	}
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+func Test_Manager_MirrorsCreatedCbz(t *testing.T) {
+	served := encodePNG(t, 30, 40)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(served)
+	}))
+	defer server.Close()
+
+	// A regular file where the mirror folder should be, so that mirror cannot be created
+	blocked := filepath.Join(t.TempDir(), "not-a-folder")
+	if err := os.WriteFile(blocked, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nas := filepath.Join(t.TempDir(), "nas", "Mirror Test")
+
+	site := &resumeSite{imageBase: server.URL}
+	manga := &config.Bookmarks{
+		Title:           "Mirror Test",
+		Url:             server.URL + "/series",
+		Location:        t.TempDir(),
+		Site:            site.GetSiteName(),
+		MirrorLocations: []string{filepath.Join(blocked, "sub"), nas},
+	}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(tempDir)) })
+
+	manager := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site})
+	if err := manager.Download(context.Background()); err != nil {
+		t.Fatalf("Download failed because of a broken mirror: %v", err)
+	}
+
+	for _, dir := range []string{manga.Location, nas} {
+		pages, err := parser.CbzPageCount(filepath.Join(dir, "ch001.cbz"))
+		if err != nil {
+			t.Fatalf("ch001.cbz missing from %s: %v", dir, err)
+		}
+		if pages != 3 {
+			t.Errorf("%s/ch001.cbz has %d pages, want 3", dir, pages)
+		}
+	}
+
+	// Skip logic only looks at the primary location
+	chapters, err := parser.LocalChapterList(manga.Location)
+	if err != nil || len(chapters) != 1 {
+		t.Errorf("LocalChapterList(primary) = %v, %v", chapters, err)
+	}
+}
+
+func TestMirrorChapterReportsFailures(t *testing.T) {
+	series := t.TempDir()
+	cbzPath := filepath.Join(series, "ch002.cbz")
+	writeStoredCbz(t, cbzPath, []cbzEntry{{"001.jpg", []byte("page")}})
+
+	blocked := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(blocked, nil, 0644)
+	good := t.TempDir()
+
+	manga := &config.Bookmarks{Title: "Mirror", Location: series, MirrorLocations: []string{blocked, "", series, good}}
+	err := config.MirrorChapter(manga, cbzPath)
+	if err == nil {
+		t.Fatal("expected an error for the mirror that is a file")
+	}
+	if _, statErr := os.Stat(filepath.Join(good, "ch002.cbz")); statErr != nil {
+		t.Errorf("a failed mirror stopped the others: %v", statErr)
+	}
+	if pages, _ := parser.CbzPageCount(cbzPath); pages != 1 {
+		t.Error("mirroring onto the series folder itself damaged the chapter")
+	}
+
+	if err := config.MirrorChapter(&config.Bookmarks{Title: "No mirrors"}, cbzPath); err != nil {
+		t.Errorf("no mirrors configured: %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+// mockMangaSite is an httptest.Server serving a tiny manga: a series page listing the
+// chapters newest first, one page per chapter with its images, and the images. Page
+// p of chapter c is a PNG p*10 pixels wide and c pixels high, so the order of the
+// pages in a CBZ can be read back from their size.
+type mockMangaSite struct {
+	server *httptest.Server
+	pages  map[int]int // chapter number -> page count
+
+	mu        sync.Mutex
+	imageHits int
+}
+
+func newMockMangaSite(t *testing.T, pages map[int]int) *mockMangaSite {
+	t.Helper()
+	m := &mockMangaSite{pages: pages}
+	m.server = httptest.NewServer(http.HandlerFunc(m.serve))
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockMangaSite) serve(w http.ResponseWriter, r *http.Request) {
+	base := "http://" + r.Host
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.URL.Path == "/series":
+		chapters := make([]int, 0, len(m.pages))
+		for chapter := range m.pages {
+			chapters = append(chapters, chapter)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(chapters)))
+
+		var b strings.Builder
+		b.WriteString(`<html><body><ul class="chapters">`)
+		for _, chapter := range chapters {
+			fmt.Fprintf(&b, `<li><a href="%s/chapter/%d">Chapter %d</a></li>`, base, chapter, chapter)
+		}
+		b.WriteString(`</ul></body></html>`)
+		w.Write([]byte(b.String()))
+
+	case len(parts) == 2 && parts[0] == "chapter":
+		chapter, _ := strconv.Atoi(parts[1])
+		pages, ok := m.pages[chapter]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var b strings.Builder
+		b.WriteString(`<html><body><div class="pages">`)
+		for page := 1; page <= pages; page++ {
+			fmt.Fprintf(&b, `<img src="%s/img/%d/%d.png">`, base, chapter, page)
+		}
+		b.WriteString(`</div></body></html>`)
+		w.Write([]byte(b.String()))
+
+	case len(parts) == 3 && parts[0] == "img":
+		chapter, _ := strconv.Atoi(parts[1])
+		page, _ := strconv.Atoi(strings.TrimSuffix(parts[2], ".png"))
+		if page < 1 || page > m.pages[chapter] {
+			http.NotFound(w, r)
+			return
+		}
+		m.mu.Lock()
+		m.imageHits++
+		m.mu.Unlock()
+		data, err := encodeMockPage(page, chapter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *mockMangaSite) images() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.imageHits
+}
+
+// mockChapterLinks parses the series page into chapter filename -> chapter URL
+func mockChapterLinks(html string) (map[string]string, error) {
+	links, err := downloader.SelectChapterLinks(html, "ul.chapters a")
+	if err != nil {
+		return nil, err
+	}
+	chapters := make(map[string]string, len(links))
+	for _, link := range links {
+		number := strings.TrimSpace(strings.TrimPrefix(link["text"], "Chapter"))
+		chapters[fmt.Sprintf("ch%03s.cbz", number)] = link["url"]
+	}
+	return chapters, nil
+}
+
+// mockSitePlugin downloads the mock site through the download manager, fetching its
+// pages over HTTP with the custom extraction type
+type mockSitePlugin struct {
+	resumeSite
+}
+
+func (s *mockSitePlugin) GetSiteName() string         { return "mocksite" }
+func (s *mockSitePlugin) KeepNativeImageFormat() bool { return false }
+
+func (s *mockSitePlugin) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{Type: "custom", CustomParser: mockChapterLinks}
+}
+
+func (s *mockSitePlugin) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	return &downloader.ImageExtractionMethod{
+		Type: "custom",
+		CustomParser: func(html string) ([]string, error) {
+			return downloader.SelectImageURLs(html, "div.pages img", "src")
+		},
+	}
+}
+
+// mockSiteDownload downloads the mock site the way the hand written site functions
+// do: list, skip local chapters, sort, download and convert every page, pack the CBZ
+func mockSiteDownload(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+	seriesHTML, err := mockFetch(manga.Url)
+	if err != nil {
+		return err
+	}
+	chapterMap, err := mockChapterLinks(seriesHTML)
+	if err != nil {
+		return err
+	}
+
+	downloaded, err := parser.LocalChapterList(manga.Location)
+	if err != nil {
+		return fmt.Errorf("failed to list files in %s: %v", manga.Location, err)
+	}
+	for _, chapter := range downloaded {
+		delete(chapterMap, chapter)
+	}
+
+	sortedChapters, err := parser.SortKeys(chapterMap)
+	if err != nil {
+		return err
+	}
+	for _, cbzName := range sortedChapters {
+		chapterHTML, err := mockFetch(chapterMap[cbzName])
+		if err != nil {
+			return err
+		}
+		imageURLs, err := downloader.SelectImageURLs(chapterHTML, "div.pages img", "src")
+		if err != nil {
+			return err
+		}
+
+		chapterDir := downloader.ChapterTempDir(manga.Site, manga, cbzName)
+		if err := os.MkdirAll(chapterDir, 0755); err != nil {
+			return err
+		}
+		// Unpadded names, CreateCbzFromDir has to put page 10 after page 9
+		for i, imageURL := range imageURLs {
+			if err := parser.DownloadConvertToJPGRename(ctx, strconv.Itoa(i+1), imageURL, chapterDir); err != nil {
+				return err
+			}
+		}
+		if err := parser.CreateCbzFromDir(chapterDir, filepath.Join(manga.Location, cbzName)); err != nil {
+			return err
+		}
+		os.RemoveAll(chapterDir)
+	}
+	return nil
+}
+
+func mockFetch(pageURL string) (string, error) {
+	resp, err := http.Get(pageURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", pageURL, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+// encodeMockPage returns page of chapter as a PNG, see mockMangaSite
+func encodeMockPage(page, chapter int) ([]byte, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, page*10, chapter)))
+	return buf.Bytes(), err
+}
+
+// assertMockLibrary checks dir holds one CBZ per chapter of mock, with every page in
+// order
+func assertMockLibrary(t *testing.T, mock *mockMangaSite, dir string) {
+	t.Helper()
+	local, err := parser.LocalChapterList(dir)
+	if err != nil {
+		t.Fatalf("LocalChapterList: %v", err)
+	}
+	if len(local) != len(mock.pages) {
+		t.Fatalf("library has %v, want %d chapters", local, len(mock.pages))
+	}
+
+	for chapter, pages := range mock.pages {
+		path := filepath.Join(dir, fmt.Sprintf("ch%03d.cbz", chapter))
+		names, data := readZipEntries(t, path)
+		if len(names) != pages {
+			t.Errorf("%s has %d pages, want %d", filepath.Base(path), len(names), pages)
+			continue
+		}
+		for i, name := range names {
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(data[name]))
+			if err != nil {
+				t.Errorf("%s %s: %v", filepath.Base(path), name, err)
+				continue
+			}
+			if cfg.Width != (i+1)*10 || cfg.Height != chapter {
+				t.Errorf("%s entry %d (%s) is %dx%d, want page %d of chapter %d",
+					filepath.Base(path), i, name, cfg.Width, cfg.Height, i+1, chapter)
+			}
+		}
+	}
+}
+
+// The manager rate limits images per domain, so its mock manga is kept small
+func Test_MockSite_ManagerRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mock := newMockMangaSite(t, map[int]int{1: 2, 2: 1})
+	site := &mockSitePlugin{}
+	config.RegisterSite(site.GetSiteName(), func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		return downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site, ProgressCallback: progress}).Download(ctx)
+	})
+
+	manga := &config.Bookmarks{Title: "Mock Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: site.GetSiteName()}
+	if err := config.ExecuteSiteDownload(context.Background(), manga, nil); err != nil {
+		t.Fatalf("ExecuteSiteDownload: %v", err)
+	}
+	assertMockLibrary(t, mock, manga.Location)
+
+	// A second run finds every chapter on disk and fetches no images
+	before := mock.images()
+	if err := config.ExecuteSiteDownload(context.Background(), manga, nil); err != nil {
+		t.Fatalf("second ExecuteSiteDownload: %v", err)
+	}
+	if fetched := mock.images() - before; fetched != 0 {
+		t.Errorf("second run fetched %d images, want none", fetched)
+	}
+}
+
+// Chapter 10 has more than nine pages, so chapter and page sorting past one digit
+// are both covered
+func Test_MockSite_SiteFunctionRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mock := newMockMangaSite(t, map[int]int{1: 3, 2: 2, 10: 11})
+	config.RegisterSite("mocksite-func", mockSiteDownload)
+
+	manga := &config.Bookmarks{Title: "Mock Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: "mocksite-func"}
+	if err := config.ExecuteSiteDownload(context.Background(), manga, nil); err != nil {
+		t.Fatalf("ExecuteSiteDownload: %v", err)
+	}
+	assertMockLibrary(t, mock, manga.Location)
+
+	// Only the missing chapter is downloaded again, the others are left alone
+	first := filepath.Join(manga.Location, "ch001.cbz")
+	kept, err := os.Stat(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(manga.Location, "ch010.cbz")); err != nil {
+		t.Fatal(err)
+	}
+
+	before := mock.images()
+	if err := config.ExecuteSiteDownload(context.Background(), manga, nil); err != nil {
+		t.Fatalf("second ExecuteSiteDownload: %v", err)
+	}
+	assertMockLibrary(t, mock, manga.Location)
+
+	if fetched := mock.images() - before; fetched != mock.pages[10] {
+		t.Errorf("second run fetched %d images, want only the %d of chapter 10", fetched, mock.pages[10])
+	}
+	if after, err := os.Stat(first); err != nil || !after.ModTime().Equal(kept.ModTime()) {
+		t.Errorf("ch001.cbz was rewritten by the second run")
+	}
+}
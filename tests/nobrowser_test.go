@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"kansho/cf"
+)
+
+// fakeOpener puts an xdg-open on PATH that records each launch in a marker file
+func fakeOpener(t *testing.T) (marker string) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("fake xdg-open only works on linux")
+	}
+
+	bin := t.TempDir()
+	marker = filepath.Join(t.TempDir(), "launched")
+	script := "#!/bin/sh\necho \"$1\" >> " + marker + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "xdg-open"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	return marker
+}
+
+func waitForFile(path string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return false
+}
+
+func TestNoBrowser_EnvDisablesOpening(t *testing.T) {
+	marker := fakeOpener(t)
+	t.Setenv("DISPLAY", ":0")
+	t.Setenv(cf.NoBrowserEnv, "1")
+
+	err := cf.OpenInBrowser("https://example.com/challenge")
+	if !errors.Is(err, cf.ErrBrowserDisabled) {
+		t.Fatalf("OpenInBrowser error = %v, want ErrBrowserDisabled", err)
+	}
+	if waitForFile(marker, 300*time.Millisecond) {
+		t.Fatal("browser was launched although it is disabled")
+	}
+}
+
+func TestNoBrowser_HeadlessDefaultsOn(t *testing.T) {
+	marker := fakeOpener(t)
+	t.Setenv(cf.NoBrowserEnv, "")
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	if !cf.BrowserOpeningDisabled() {
+		t.Fatal("expected browser opening to be disabled without a display")
+	}
+	if err := cf.OpenInBrowser("https://example.com/"); !errors.Is(err, cf.ErrBrowserDisabled) {
+		t.Fatalf("OpenInBrowser error = %v, want ErrBrowserDisabled", err)
+	}
+	if waitForFile(marker, 300*time.Millisecond) {
+		t.Fatal("browser was launched on a headless host")
+	}
+}
+
+func TestNoBrowser_KillSwitchFile(t *testing.T) {
+	fakeOpener(t)
+	t.Setenv(cf.NoBrowserEnv, "")
+	t.Setenv("DISPLAY", ":0")
+
+	if cf.BrowserOpeningDisabled() {
+		t.Fatal("browser opening should be enabled with a display and no kill-switch")
+	}
+
+	configDir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "no-browser"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !cf.BrowserOpeningDisabled() {
+		t.Fatal("kill-switch file should disable browser opening")
+	}
+}
+
+func TestNoBrowser_EnvForcesOpening(t *testing.T) {
+	marker := fakeOpener(t)
+	t.Setenv(cf.NoBrowserEnv, "0")
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	if err := cf.OpenInBrowser("https://example.com/"); err != nil {
+		t.Fatalf("OpenInBrowser: %v", err)
+	}
+	if !waitForFile(marker, 5*time.Second) {
+		t.Fatal("KANSHO_NO_BROWSER=0 should open the browser even when headless")
+	}
+}
@@ -0,0 +1,144 @@
+package integration
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"kansho/parser"
+)
+
+func TestPackageImageFolders(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "Some Series")
+	// Page widths tell the pages apart once they are renamed
+	folders := map[string]map[string]int{
+		"Chapter 2":  {"page10.png": 30, "page2.png": 20, "page1.png": 10, "notes.txt": 0},
+		"Vol 1 12.5": {"002.png": 50, "001.png": 40},
+		"Extras":     {"cover.png": 60},
+		"empty":      {"readme.txt": 0},
+	}
+	for folder, files := range folders {
+		if err := os.MkdirAll(filepath.Join(root, folder), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, width := range files {
+			data := []byte("not an image")
+			if width > 0 {
+				data = encodePNG(t, width, 80)
+			}
+			if err := os.WriteFile(filepath.Join(root, folder, name), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := parser.PackageImageFolders(root, parser.PackageOptions{}); err != nil {
+		t.Fatalf("PackageImageFolders: %v", err)
+	}
+
+	want := map[string]struct {
+		number string
+		widths []int
+	}{
+		"ch002.cbz":   {"2", []int{10, 20, 30}},
+		"ch012.5.cbz": {"12.5", []int{40, 50}},
+		"Extras.cbz":  {"", []int{60}},
+	}
+	var cbzs []string
+	entries, _ := os.ReadDir(root)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			cbzs = append(cbzs, entry.Name())
+		} else if _, ok := folders[entry.Name()]; !ok {
+			t.Errorf("left behind directory %s", entry.Name())
+		}
+	}
+	if len(cbzs) != len(want) {
+		t.Fatalf("packaged %v, want %d CBZs", cbzs, len(want))
+	}
+
+	for name, w := range want {
+		zr, err := zip.OpenReader(filepath.Join(root, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		var pages []string
+		var widths []int
+		var info parser.ComicInfo
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Name == parser.ComicInfoFileName {
+				if err := xml.NewDecoder(rc).Decode(&info); err != nil {
+					t.Errorf("%s: ComicInfo: %v", name, err)
+				}
+			} else {
+				cfg, _, err := image.DecodeConfig(rc)
+				if err != nil {
+					t.Errorf("%s: page %s: %v", name, f.Name, err)
+				}
+				pages = append(pages, f.Name)
+				widths = append(widths, cfg.Width)
+			}
+			rc.Close()
+		}
+		zr.Close()
+
+		if !slices.Equal(widths, w.widths) {
+			t.Errorf("%s: page widths %v, want %v", name, widths, w.widths)
+		}
+		if pages[0] != "001.jpg" {
+			t.Errorf("%s: pages %v, want 001.jpg onwards", name, pages)
+		}
+		if info.Series != "Some Series" || info.Number != w.number || info.PageCount != len(w.widths) {
+			t.Errorf("%s: ComicInfo %+v, want series %q number %q", name, info, "Some Series", w.number)
+		}
+	}
+
+	// The source folders are not modified
+	if files, _ := os.ReadDir(filepath.Join(root, "Chapter 2")); len(files) != 4 {
+		t.Errorf("Chapter 2 holds %d files after packaging, want 4", len(files))
+	}
+}
+
+func TestPackageImageFolders_ReportsSharedNames(t *testing.T) {
+	root := t.TempDir()
+	for _, folder := range []string{"Vol 1 Ch 1", "Vol 2 Ch 1", "Vol 2 Ch 2"} {
+		if err := os.MkdirAll(filepath.Join(root, folder), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, folder, "001.png"), encodePNG(t, 10, 10), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := parser.PackageImageFolders(root, parser.PackageOptions{Overwrite: true})
+	if err == nil {
+		t.Fatal("PackageImageFolders packaged two volumes' chapter 1 under one name")
+	}
+	for _, folder := range []string{"Vol 1 Ch 1", "Vol 2 Ch 1"} {
+		if !strings.Contains(err.Error(), folder+": would be packaged as ch001.cbz") {
+			t.Errorf("error %q does not report %s", err, folder)
+		}
+	}
+
+	// Neither volume's chapter 1 wins, the other chapters are packaged
+	var cbzs []string
+	entries, _ := os.ReadDir(root)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			cbzs = append(cbzs, entry.Name())
+		}
+	}
+	if !slices.Equal(cbzs, []string{"ch002.cbz"}) {
+		t.Errorf("packaged %v, want only ch002.cbz", cbzs)
+	}
+}
@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+)
+
+func TestPageCountMonitor_FlagsShortChapter(t *testing.T) {
+	monitor := downloader.NewPageCountMonitor()
+
+	counts := []int{22, 25, 20, 24, 6, 23, 21}
+	var flagged []int
+	for i, pages := range counts {
+		if short, median := monitor.Check(pages); short {
+			flagged = append(flagged, i)
+			if median != 24 {
+				t.Errorf("chapter %d: median = %d, want 24", i, median)
+			}
+		}
+	}
+
+	if len(flagged) != 1 || flagged[0] != 4 {
+		t.Fatalf("flagged chapters %v, want only index 4", flagged)
+	}
+}
+
+func TestPageCountMonitor_LeavesSmallAndEarlyChaptersAlone(t *testing.T) {
+	// Not enough history yet to judge the 2 page chapter
+	monitor := downloader.NewPageCountMonitor()
+	for _, pages := range []int{30, 2, 28} {
+		if short, _ := monitor.Check(pages); short {
+			t.Fatalf("%d pages flagged before enough history", pages)
+		}
+	}
+
+	// Series with tiny chapters vary by a page or two, nothing is flagged
+	monitor = downloader.NewPageCountMonitor()
+	for _, pages := range []int{4, 5, 4, 1, 4} {
+		if short, _ := monitor.Check(pages); short {
+			t.Fatalf("%d pages flagged in a series of tiny chapters", pages)
+		}
+	}
+}
+
+func TestPageCountMonitor_SummaryReportsShortChapters(t *testing.T) {
+	var summary config.DownloadSummary
+	summary.Success("ch001.cbz")
+	summary.Success("ch002.cbz")
+	summary.Short("ch002.cbz")
+
+	if msg := summary.Message(); !strings.Contains(msg, "1 with suspiciously few pages") {
+		t.Fatalf("message %q does not report the short chapter", msg)
+	}
+	if summary.Err() != nil {
+		t.Fatal("a reported short chapter should not fail the run")
+	}
+}
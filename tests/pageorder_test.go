@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/config"
+	"kansho/parser"
+)
+
+func TestFixCbzPageOrder(t *testing.T) {
+	dir := t.TempDir()
+	cbzPath := filepath.Join(dir, "ch001.cbz")
+
+	// Unpadded names stored in the lexical order an old download produced
+	original := []cbzEntry{
+		{"1.jpg", []byte("page 1")},
+		{"10.jpg", []byte("page 10")},
+		{"11.png", []byte("page 11")},
+		{"2.jpg", []byte("page 2")},
+		{parser.ComicInfoFileName, []byte("<ComicInfo/>")},
+		{"3.jpg", []byte("page 3")},
+	}
+	writeStoredCbz(t, cbzPath, original)
+
+	wrong, err := parser.CbzPageOrderWrong(cbzPath)
+	if err != nil || !wrong {
+		t.Fatalf("CbzPageOrderWrong = %v, %v, want true", wrong, err)
+	}
+
+	fixed, err := parser.FixCbzPageOrder(cbzPath)
+	if err != nil || !fixed {
+		t.Fatalf("FixCbzPageOrder = %v, %v", fixed, err)
+	}
+
+	want := []struct{ name, data string }{
+		{"001.jpg", "page 1"},
+		{"002.jpg", "page 2"},
+		{"003.jpg", "page 3"},
+		{"004.jpg", "page 10"},
+		{"005.png", "page 11"},
+		{parser.ComicInfoFileName, "<ComicInfo/>"},
+	}
+	zr, err := zip.OpenReader(cbzPath)
+	if err != nil {
+		t.Fatalf("rewritten cbz does not open: %v", err)
+	}
+	defer zr.Close()
+	if len(zr.File) != len(want) {
+		t.Fatalf("rewritten cbz has %d entries, want %d", len(zr.File), len(want))
+	}
+	for i, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		if f.Name != want[i].name || string(data) != want[i].data {
+			t.Errorf("entry %d = %s (%q), want %s (%q)", i, f.Name, data, want[i].name, want[i].data)
+		}
+	}
+
+	// The original is kept untouched as the backup
+	backup := cbzPath + parser.PageOrderBackupSuffix
+	if pages, err := parser.CbzPageCount(backup); err != nil || pages != 5 {
+		t.Errorf("backup = %d pages, %v", pages, err)
+	}
+
+	if wrong, _ := parser.CbzPageOrderWrong(cbzPath); wrong {
+		t.Error("rewritten cbz still reported out of order")
+	}
+	if again, err := parser.FixCbzPageOrder(cbzPath); err != nil || again {
+		t.Errorf("second fix = %v, %v, want an in-order cbz left alone", again, err)
+	}
+}
+
+func TestFixSeriesPageOrderSkipsOrderedChapters(t *testing.T) {
+	series := t.TempDir()
+	writeStoredCbz(t, filepath.Join(series, "ch001.cbz"), []cbzEntry{
+		{"001.jpg", []byte("a")}, {"002.jpg", []byte("b")}, {"010.jpg", []byte("c")},
+	})
+	writeStoredCbz(t, filepath.Join(series, "ch002.cbz"), []cbzEntry{
+		{"2.jpg", []byte("b")}, {"1.jpg", []byte("a")},
+	})
+	before, _ := os.ReadFile(filepath.Join(series, "ch001.cbz"))
+
+	fixed, err := config.FixSeriesPageOrder(&config.Bookmarks{Title: "Order", Location: series})
+	if err != nil {
+		t.Fatalf("FixSeriesPageOrder: %v", err)
+	}
+	if len(fixed) != 1 || fixed[0] != "ch002.cbz" {
+		t.Errorf("fixed = %v, want [ch002.cbz]", fixed)
+	}
+
+	after, _ := os.ReadFile(filepath.Join(series, "ch001.cbz"))
+	if string(before) != string(after) {
+		t.Error("an ordered chapter was rewritten")
+	}
+	if _, err := os.Stat(filepath.Join(series, "ch001.cbz"+parser.PageOrderBackupSuffix)); !os.IsNotExist(err) {
+		t.Error("an ordered chapter got a backup")
+	}
+
+	chapters, _ := parser.LocalChapterList(series)
+	if len(chapters) != 2 {
+		t.Errorf("backups show up as chapters: %v", chapters)
+	}
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"path/filepath"
+	"testing"
+
+	"kansho/validation"
+)
+
+func Test_SafeJoin_RejectsTraversal(t *testing.T) {
+	root := t.TempDir()
+
+	rejected := []string{
+		"../../etc",
+		"..",
+		"Solo Leveling/../../../etc",
+		"/etc/cron.d",
+		`..\..\Windows`,
+		"ch001/../../x.cbz",
+		"",
+		"  ",
+	}
+	for _, name := range rejected {
+		if path, err := validation.SafeJoin(root, name); err == nil {
+			t.Errorf("%q: expected rejection, got %s", name, path)
+		}
+	}
+
+	allowed := map[string]string{
+		"Solo Leveling":     filepath.Join(root, "Solo Leveling"),
+		"ch072.5.cbz":       filepath.Join(root, "ch072.5.cbz"),
+		"Re..Zero":          filepath.Join(root, "Re..Zero"),
+		"...And Then There": filepath.Join(root, "...And Then There"),
+	}
+	for name, want := range allowed {
+		got, err := validation.SafeJoin(root, name)
+		if err != nil || got != want {
+			t.Errorf("%q: expected %s, got %s (%v)", name, want, got, err)
+		}
+	}
+}
+
+func Test_EnsureWithin(t *testing.T) {
+	root := t.TempDir()
+
+	if err := validation.EnsureWithin(root, filepath.Join(root, "a", "b.cbz")); err != nil {
+		t.Errorf("nested path rejected: %v", err)
+	}
+	if err := validation.EnsureWithin(root, filepath.Join(root, "a", "..", "..", "escape")); err == nil {
+		t.Error("expected rejection of path resolving above root")
+	}
+	if err := validation.EnsureWithin(root, "/etc/passwd"); err == nil {
+		t.Error("expected rejection of absolute path outside root")
+	}
+	if err := validation.EnsureWithin(root, root+"-sibling"); err == nil {
+		t.Error("expected rejection of sibling directory sharing the root prefix")
+	}
+}
+
+func Test_ValidateLocation(t *testing.T) {
+	for _, location := range []string{"/home/user/manga/../../etc", "manga/Solo Leveling", "~/manga/../.."} {
+		if err := validation.ValidateLocation(location); err == nil {
+			t.Errorf("%q: expected rejection", location)
+		}
+	}
+	for _, location := range []string{"/home/user/manga/Solo Leveling", "~/manga/Re..Zero", ""} {
+		if err := validation.ValidateLocation(location); err != nil {
+			t.Errorf("%q: unexpected rejection: %v", location, err)
+		}
+	}
+}
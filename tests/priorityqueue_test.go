@@ -0,0 +1,141 @@
+package integration
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"kansho/config"
+)
+
+func Test_DownloadQueue_PriorityTaskAndCancel(t *testing.T) {
+	const siteName = "priority-test-site"
+
+	var (
+		mu      sync.Mutex
+		started []string
+	)
+	release := make(chan struct{})
+	running := make(chan string, 4)
+
+	// Fake site: the blocker holds the only worker slot until released, the other
+	// series block until their task is cancelled
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		mu.Lock()
+		started = append(started, manga.Title)
+		mu.Unlock()
+		running <- manga.Title
+
+		if manga.Title == "Priority Blocker" {
+			<-release
+			return nil
+		}
+		progress(config.ProgressEvent{Status: "Downloading chapter 1", Fraction: 0.5, ChapterNum: 1, CurrentDownload: 1, TotalChapters: 2})
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	queue := config.GetDownloadQueue()
+	finished := make(chan config.DownloadTask, 4)
+	reported := make(map[string]bool)
+	unsubscribe := queue.Subscribe(config.QueueListener{
+		OnTaskUpdated: func(tk *config.DownloadTask) {
+			// Cancelling reports "cancelled" straight away, the download returning
+			// reports it again with the final message. The snapshot is the latest
+			// state, so an earlier update can already see it: report each task once.
+			snapshot, ok := queue.GetTaskSnapshot(tk.ID)
+			if !ok || snapshot.Manga.Site != siteName || (snapshot.Status != "completed" && snapshot.StatusMessage != "Cancelled by user") {
+				return
+			}
+			mu.Lock()
+			first := !reported[snapshot.ID]
+			reported[snapshot.ID] = true
+			mu.Unlock()
+			if first {
+				finished <- snapshot
+			}
+		},
+	})
+	defer unsubscribe()
+	defer queue.RemoveCompletedTasks()
+
+	series := func(title string) *config.Bookmarks {
+		return &config.Bookmarks{Title: title, Site: siteName, Url: "https://example.com/" + title, Location: t.TempDir()}
+	}
+	waitRunning := func(want string) {
+		t.Helper()
+		select {
+		case got := <-running:
+			if got != want {
+				t.Fatalf("%q started, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q to start", want)
+		}
+	}
+	waitFinished := func(title, status string) config.DownloadTask {
+		t.Helper()
+		select {
+		case task := <-finished:
+			if task.Manga.Title != title || task.Status != status {
+				t.Fatalf("%q finished %s, want %q %s", task.Manga.Title, task.Status, title, status)
+			}
+			return task
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q to finish", title)
+		}
+		return config.DownloadTask{}
+	}
+
+	if _, err := queue.AddTask(series("Priority Blocker")); err != nil {
+		t.Fatal(err)
+	}
+	waitRunning("Priority Blocker")
+	if _, err := queue.AddTask(series("Priority Normal")); err != nil {
+		t.Fatal(err)
+	}
+	urgent, err := queue.AddPriorityTask(series("Priority Urgent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The row finds its task by title, and can't queue the series a second time
+	if snapshot, ok := queue.TaskSnapshotForManga("Priority Urgent"); !ok || snapshot.ID != urgent.ID || !snapshot.Priority {
+		t.Errorf("TaskSnapshotForManga = %+v, %v", snapshot, ok)
+	}
+	if _, err := queue.AddPriorityTask(series("Priority Urgent")); err == nil {
+		t.Error("queued the same series twice")
+	}
+
+	close(release)
+	waitFinished("Priority Blocker", "completed")
+	waitRunning("Priority Urgent")
+
+	if err := queue.CancelTask(urgent.ID); err != nil {
+		t.Fatalf("CancelTask: %v", err)
+	}
+	waitFinished("Priority Urgent", "cancelled")
+	waitRunning("Priority Normal")
+
+	// The finished run is cleared so the row can update the series again
+	if err := queue.RemoveFinishedTask(urgent.ID); err != nil {
+		t.Fatalf("RemoveFinishedTask: %v", err)
+	}
+	if _, ok := queue.TaskSnapshotForManga("Priority Urgent"); ok {
+		t.Error("removed task still in the queue")
+	}
+	normal, _ := queue.TaskSnapshotForManga("Priority Normal")
+	if err := queue.RemoveFinishedTask(normal.ID); err == nil {
+		t.Error("removed a task that is still downloading")
+	}
+	queue.CancelTask(normal.ID)
+	waitFinished("Priority Normal", "cancelled")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"Priority Blocker", "Priority Urgent", "Priority Normal"}; !slices.Equal(started, want) {
+		t.Errorf("start order = %v, want %v", started, want)
+	}
+}
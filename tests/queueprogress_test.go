@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kansho/config"
+)
+
+func Test_DownloadQueue_ProgressSurvivesReattach(t *testing.T) {
+	const siteName = "progress-test-site"
+
+	reported := make(chan struct{})
+	proceed := make(chan struct{})
+
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		progress(config.ProgressEvent{Status: "Downloading chapter 1 of 4", Fraction: 0.25, ChapterNum: 1, CurrentDownload: 1, TotalChapters: 4})
+		progress(config.ProgressEvent{Status: "Downloading chapter 2 of 4", Fraction: 0.5, ChapterNum: 2, CurrentDownload: 2, TotalChapters: 4, ImageIndex: 3, ImageTotal: 12})
+		close(reported)
+
+		// Nobody is listening while the "window" is closed
+		<-proceed
+
+		progress(config.ProgressEvent{Status: "Downloading chapter 4 of 4", Fraction: 0.9, ChapterNum: 4, CurrentDownload: 4, TotalChapters: 4})
+		return nil
+	})
+
+	queue := config.GetDownloadQueue()
+	defer queue.RemoveCompletedTasks()
+
+	task, err := queue.AddTask(&config.Bookmarks{Title: "Progress Test Series", Site: siteName, Location: t.TempDir()})
+	if err != nil {
+		t.Fatalf("AddTask: %v", err)
+	}
+
+	select {
+	case <-reported:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for progress")
+	}
+
+	// Reopened view rebuilds from the stored progress
+	snapshot, ok := queue.GetTaskSnapshot(task.ID)
+	if !ok {
+		t.Fatal("task missing from queue")
+	}
+	if snapshot.Progress != 0.5 || snapshot.StatusMessage != "Downloading chapter 2 of 4" || snapshot.ActualChapter != 2 {
+		t.Fatalf("stored progress = %.2f %q ch%d, want latest callback value", snapshot.Progress, snapshot.StatusMessage, snapshot.ActualChapter)
+	}
+	if snapshot.ImageIndex != 3 || snapshot.ImageTotal != 12 {
+		t.Errorf("stored image progress = %d/%d, want 3/12", snapshot.ImageIndex, snapshot.ImageTotal)
+	}
+
+	// ...then subscribes for subsequent updates
+	updates := make(chan config.DownloadTask, 16)
+	unsubscribe := queue.Subscribe(config.QueueListener{
+		OnTaskUpdated: func(tk *config.DownloadTask) {
+			if tk.ID != task.ID {
+				return
+			}
+			if s, ok := queue.GetTaskSnapshot(tk.ID); ok {
+				updates <- s
+			}
+		},
+	})
+	defer unsubscribe()
+
+	close(proceed)
+
+	var sawProgress, sawCompleted bool
+	timeout := time.After(5 * time.Second)
+	for !sawCompleted {
+		select {
+		case s := <-updates:
+			if s.Progress == 0.9 {
+				sawProgress = true
+			}
+			if s.Status == "completed" {
+				sawCompleted = true
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for updates (progress seen: %v)", sawProgress)
+		}
+	}
+
+	if !sawProgress {
+		t.Error("reattached listener missed the progress update")
+	}
+	if final, _ := queue.GetTaskSnapshot(task.ID); final.Progress != 1.0 {
+		t.Errorf("final progress = %.2f, want 1.0", final.Progress)
+	}
+}
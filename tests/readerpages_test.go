@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"kansho/downloader"
+)
+
+// pagedReaderSite reads the chapter images of pagedReader's server, following its
+// next page links
+type pagedReaderSite struct {
+	mockSitePlugin
+}
+
+func (s *pagedReaderSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	method := s.mockSitePlugin.GetImageExtractionMethod()
+	method.NextPageSelector = "a.next-page"
+	return method
+}
+
+// pagedReader serves one chapter split over two reader pages. Page 2 links back to
+// page 1 as its "next" page, which must not be followed round in circles.
+func pagedReader(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := "http://" + r.Host
+		switch r.URL.Query().Get("page") {
+		case "", "1":
+			fmt.Fprintf(w, `<html><body><div class="pages"><img src="%[1]s/img/1.png"><img src="%[1]s/img/2.png"></div>
+				<a class="next-page" href="/chapter/1?page=2#top">Next</a></body></html>`, base)
+		case "2":
+			fmt.Fprintf(w, `<html><body><div class="pages"><img src="%[1]s/img/3.png"><img src="%[1]s/img/4.png"></div>
+				<a class="next-page" href="/chapter/1">Next</a></body></html>`, base)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_FetchChapterImages_FollowsReaderPages(t *testing.T) {
+	server := pagedReader(t)
+	chapterURL := server.URL + "/chapter/1"
+
+	images, err := downloader.FetchChapterImages(context.Background(), chapterURL, &pagedReaderSite{})
+	if err != nil {
+		t.Fatalf("FetchChapterImages: %v", err)
+	}
+	var want []string
+	for page := 1; page <= 4; page++ {
+		want = append(want, fmt.Sprintf("%s/img/%d.png", server.URL, page))
+	}
+	if !slices.Equal(images, want) {
+		t.Errorf("images = %v, want both reader pages in order %v", images, want)
+	}
+
+	// Without the opt-in only the first reader page is read
+	images, err = downloader.FetchChapterImages(context.Background(), chapterURL, &mockSitePlugin{})
+	if err != nil {
+		t.Fatalf("FetchChapterImages without pagination: %v", err)
+	}
+	if !slices.Equal(images, want[:2]) {
+		t.Errorf("images without pagination = %v, want %v", images, want[:2])
+	}
+}
+
+func TestNextReaderPage(t *testing.T) {
+	tests := []struct {
+		name, html, want string
+	}{
+		{"relative", `<a class="next" href="?page=3">Next</a>`, "https://example.com/read/ch-1?page=3"},
+		{"absolute", `<a class="next" href="https://cdn.example.com/read/ch-1/2">Next</a>`, "https://cdn.example.com/read/ch-1/2"},
+		{"first match wins", `<a class="next" href="/read/ch-1/3"></a><a class="next" href="/read/ch-1/9"></a>`, "https://example.com/read/ch-1/3"},
+		{"last page", `<span class="next disabled">Next</span>`, ""},
+		{"placeholder link", `<a class="next" href="#">Next</a>`, ""},
+		{"script link", `<a class="next" href="javascript:void(0)">Next</a>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := downloader.NextReaderPage(tt.html, "https://example.com/read/ch-1?page=2", ".next"); got != tt.want {
+				t.Errorf("NextReaderPage = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
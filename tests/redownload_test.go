@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+type redownloadSite struct{ mockSitePlugin }
+
+func (s *redownloadSite) GetSiteName() string { return "redownloadtest" }
+
+func Test_DownloadQueue_RedownloadOverwritesLocalChapters(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mock := newMockMangaSite(t, map[int]int{1: 1, 2: 1, 3: 1})
+	site := &redownloadSite{}
+	config.RegisterSite(site.GetSiteName(), func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		return downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site, ProgressCallback: progress}).Download(ctx)
+	})
+
+	manga := &config.Bookmarks{Title: "Redownload Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: site.GetSiteName()}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(tempDir)) })
+
+	done := make(chan *config.DownloadTask, 1)
+	queue := config.GetDownloadQueue()
+	unsubscribe := queue.Subscribe(config.QueueListener{
+		OnTaskUpdated: func(tk *config.DownloadTask) {
+			if tk.Manga.Site == site.GetSiteName() && (tk.Status == "completed" || tk.Status == "failed") {
+				done <- tk
+			}
+		},
+	})
+	defer unsubscribe()
+	defer queue.RemoveCompletedTasks()
+
+	// run queues a task with add and returns how many pages it fetched
+	run := func(add func() (*config.DownloadTask, error)) int {
+		t.Helper()
+		before := mock.images()
+		task, err := add()
+		if err != nil {
+			t.Fatalf("add task: %v", err)
+		}
+		select {
+		case finished := <-done:
+			if finished.Status != "completed" {
+				t.Fatalf("download finished %s: %s", finished.Status, finished.StatusMessage)
+			}
+		case <-time.After(20 * time.Second):
+			t.Fatal("timed out waiting for the download")
+		}
+		if err := queue.RemoveFinishedTask(task.ID); err != nil {
+			t.Fatal(err)
+		}
+		return mock.images() - before
+	}
+
+	if hits := run(func() (*config.DownloadTask, error) { return queue.AddTask(manga) }); hits != 3 {
+		t.Fatalf("first download fetched %d pages, want 3", hits)
+	}
+	if hits := run(func() (*config.DownloadTask, error) { return queue.AddTask(manga) }); hits != 0 {
+		t.Errorf("a normal download fetched %d pages of chapters in the library", hits)
+	}
+
+	// A broken local copy of chapter 2 is replaced, the other chapters are left alone
+	broken := filepath.Join(manga.Location, "ch002.cbz")
+	if err := os.WriteFile(broken, []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if hits := run(func() (*config.DownloadTask, error) { return queue.AddRedownloadTask(manga, "ch002.cbz") }); hits != 1 {
+		t.Errorf("re-downloading one chapter fetched %d pages, want 1", hits)
+	}
+	if pages, err := parser.CbzPageCount(broken); err != nil || pages != 1 {
+		t.Errorf("ch002.cbz after the re-download: %d pages, %v", pages, err)
+	}
+
+	if hits := run(func() (*config.DownloadTask, error) { return queue.AddRedownloadTask(manga) }); hits != 3 {
+		t.Errorf("re-downloading the series fetched %d pages, want 3", hits)
+	}
+	assertMockLibrary(t, mock, manga.Location)
+}
@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"kansho/downloader"
+)
+
+func Test_BrowserLauncherFor_LocalWhenUnset(t *testing.T) {
+	launch, err := downloader.BrowserLauncherFor("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if launch != nil {
+		t.Fatal("expected nil launcher (local Chrome) when no remote URL is set")
+	}
+}
+
+func Test_BrowserLauncherFor_ConnectsToRemote(t *testing.T) {
+	var versionRequests atomic.Int32
+
+	// Fake DevTools endpoint: records discovery requests, then refuses them so no
+	// real browser is needed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json/version" {
+			versionRequests.Add(1)
+		}
+		http.Error(w, "no browser here", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	launch, err := downloader.BrowserLauncherFor(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if launch == nil {
+		t.Fatal("expected remote launcher when a remote URL is set")
+	}
+
+	if _, _, err := launch(nil); err == nil {
+		t.Fatal("expected connection error from fake endpoint")
+	}
+	if versionRequests.Load() == 0 {
+		t.Fatal("remote launcher never contacted the DevTools endpoint")
+	}
+}
+
+func Test_ValidateRemoteBrowserURL(t *testing.T) {
+	cases := []struct {
+		url   string
+		valid bool
+	}{
+		{"ws://127.0.0.1:9222", true},
+		{"http://browser.lan:9222/", true},
+		{"ws://127.0.0.1:9222/devtools/browser/3f2a-11", true},
+		{"wss://browser.example.com/devtools/browser/abc", true},
+		{"ws://127.0.0.1:9222/devtools/browser/", false},
+		{"ws://127.0.0.1", false},
+		{"ftp://127.0.0.1:9222", false},
+		{"127.0.0.1:9222", false},
+		{"ws://:9222", false},
+	}
+
+	for _, tc := range cases {
+		err := downloader.ValidateRemoteBrowserURL(tc.url)
+		if tc.valid && err != nil {
+			t.Errorf("%s: expected valid, got %v", tc.url, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%s: expected validation error", tc.url)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kansho/config"
+	"kansho/downloader"
+)
+
+// chapterNames returns ch%03d.cbz names for the chapters from first to last
+func chapterNames(first, last int) []string {
+	var names []string
+	for number := first; number <= last; number++ {
+		names = append(names, fmt.Sprintf("ch%03d.cbz", number))
+	}
+	return names
+}
+
+func Test_DetectRenumber(t *testing.T) {
+	local := chapterNames(1, 20)
+
+	tests := []struct {
+		name   string
+		local  []string
+		remote []string
+		want   bool
+	}{
+		{"unchanged", local, chapterNames(1, 20), false},
+		{"new chapters", local, chapterNames(1, 25), false},
+		{"site dropped old chapters", local, chapterNames(5, 22), false},
+		{"shifted by a chapter 0", local, chapterNames(0, 19), true},
+		{"renamed entirely", local, []string{"s01ch001.cbz", "s01ch002.cbz", "s01ch003.cbz"}, true},
+		{"too few local chapters", chapterNames(1, 2), chapterNames(0, 1), false},
+		{"no local chapters", nil, chapterNames(0, 19), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := config.DetectRenumber(tt.local, tt.remote); got != tt.want {
+				t.Errorf("DetectRenumber = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_CheckRenumber_Confirmed(t *testing.T) {
+	local, remote := chapterNames(1, 20), chapterNames(0, 19)
+
+	var renumberErr *config.RenumberSuspectedError
+	if err := config.CheckRenumber(context.Background(), local, remote); !errors.As(err, &renumberErr) {
+		t.Fatalf("CheckRenumber = %v, want a RenumberSuspectedError", err)
+	}
+	if renumberErr.Local != 20 || renumberErr.Matched != 19 || renumberErr.New != 1 {
+		t.Errorf("RenumberSuspectedError = %+v", renumberErr)
+	}
+
+	if err := config.CheckRenumber(config.WithRenumberConfirmed(context.Background()), local, remote); err != nil {
+		t.Errorf("CheckRenumber after confirmation = %v", err)
+	}
+}
+
+// shiftedSite lists chapters 0 to 4, the library has 1 to 5
+type shiftedSite struct {
+	resumeSite
+}
+
+func (s *shiftedSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			var chapters []map[string]string
+			for number := 0; number <= 4; number++ {
+				chapters = append(chapters, map[string]string{"url": fmt.Sprintf("https://example.test/%d", number), "number": fmt.Sprint(number)})
+			}
+			return chapters, nil
+		},
+	}
+}
+
+func (s *shiftedSite) NormalizeChapterFilename(data map[string]string) string {
+	return fmt.Sprintf("ch%03s.cbz", data["number"])
+}
+
+func Test_Manager_StopsOnRenumberedSeries(t *testing.T) {
+	location := t.TempDir()
+	for _, name := range chapterNames(1, 5) {
+		writeStoredCbz(t, filepath.Join(location, name), sampleCbzEntries(t))
+	}
+
+	manga := &config.Bookmarks{Title: "Renumber Test", Url: "https://example.test/series", Location: location}
+	manager := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: &shiftedSite{}})
+
+	var renumberErr *config.RenumberSuspectedError
+	if err := manager.Download(context.Background()); !errors.As(err, &renumberErr) {
+		t.Fatalf("Download = %v, want a RenumberSuspectedError", err)
+	}
+}
+
+func Test_DownloadQueue_KeepsTaskWaitingForConfirmation(t *testing.T) {
+	const siteName = "renumber-queue-test-site"
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		if !config.RenumberConfirmed(ctx) {
+			return &config.RenumberSuspectedError{Local: 5, Matched: 1, New: 5}
+		}
+		return nil
+	})
+
+	queue := config.GetDownloadQueue()
+	updates := make(chan string, 8)
+	unsubscribe := queue.Subscribe(config.QueueListener{
+		OnTaskUpdated: func(tk *config.DownloadTask) {
+			if tk.Manga.Site == siteName {
+				updates <- tk.Status
+			}
+		},
+	})
+	defer unsubscribe()
+	defer queue.RemoveCompletedTasks()
+
+	waitStatus := func(want string) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case status := <-updates:
+				if status == want {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for the task to be %s", want)
+			}
+		}
+	}
+
+	manga := &config.Bookmarks{Title: "Renumber Queue", Site: siteName, Url: "https://example.test/renumber-queue", Location: t.TempDir()}
+	task, err := queue.AddTask(manga)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitStatus("waiting_confirm")
+
+	// Clearing the finished runs keeps the task the user still has to answer
+	queue.RemoveCompletedTasks()
+	if snapshot, ok := queue.GetTaskSnapshot(task.ID); !ok || snapshot.Status != "waiting_confirm" {
+		t.Fatalf("task after RemoveCompletedTasks = %+v, %v", snapshot, ok)
+	}
+
+	if err := queue.ConfirmTask(task.ID); err != nil {
+		t.Fatalf("ConfirmTask: %v", err)
+	}
+	waitStatus("completed")
+}
@@ -0,0 +1,196 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/config"
+	"kansho/parser"
+)
+
+type cbzEntry struct {
+	name string
+	data []byte
+}
+
+// writeStoredCbz writes entries uncompressed and in the given order
+func writeStoredCbz(t *testing.T, path string, entries []cbzEntry) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(e.data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeChapterCbz packs entries the way a download does, with CreateCbzFromDir
+func writeChapterCbz(t *testing.T, path string, entries []cbzEntry) {
+	t.Helper()
+	pages := t.TempDir()
+	for _, e := range entries {
+		if err := os.WriteFile(filepath.Join(pages, e.name), e.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := parser.CreateCbzFromDir(pages, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// encodeFastPNG encodes a gradient page at png.BestSpeed, as a site serving
+// quickly encoded PNGs would. Deflating the file again saves next to nothing.
+func encodeFastPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8((x*x + y*y) / 50)
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: v / 2, B: 255 - v, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// samePixels reports whether two encoded images decode to the same pixels
+func samePixels(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	imgA, _, errA := image.Decode(bytes.NewReader(a))
+	imgB, _, errB := image.Decode(bytes.NewReader(b))
+	if errA != nil || errB != nil {
+		t.Fatalf("decode: %v, %v", errA, errB)
+	}
+	if imgA.Bounds() != imgB.Bounds() {
+		return false
+	}
+	for y := imgA.Bounds().Min.Y; y < imgA.Bounds().Max.Y; y++ {
+		for x := imgA.Bounds().Min.X; x < imgA.Bounds().Max.X; x++ {
+			if color.NRGBAModel.Convert(imgA.At(x, y)) != color.NRGBAModel.Convert(imgB.At(x, y)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func sampleCbzEntries(t *testing.T) []cbzEntry {
+	return []cbzEntry{
+		{"001.png", encodeFastPNG(t, 400, 600)},
+		{"002.jpg", []byte("\xff\xd8\xff\xe0 not really a jpeg")},
+		{"003.png", encodePNG(t, 400, 900)},
+		{"010.webp", []byte("RIFF....WEBPVP8 ")},
+		{parser.ComicInfoFileName, []byte("<ComicInfo><Title>Test</Title></ComicInfo>")},
+	}
+}
+
+func TestRepackCbz(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "ch001.cbz")
+	dst := filepath.Join(dir, "archive", "ch001.cbz")
+	os.Mkdir(filepath.Dir(dst), 0755)
+
+	entries := sampleCbzEntries(t)
+	writeChapterCbz(t, src, entries)
+
+	if err := parser.RepackCbz(src, dst, flate.BestCompression); err != nil {
+		t.Fatalf("RepackCbz: %v", err)
+	}
+
+	zr, err := zip.OpenReader(dst)
+	if err != nil {
+		t.Fatalf("repacked cbz does not open: %v", err)
+	}
+	defer zr.Close()
+
+	if len(zr.File) != len(entries) {
+		t.Fatalf("repacked cbz has %d entries, want %d", len(zr.File), len(entries))
+	}
+	for i, f := range zr.File {
+		if f.Name != entries[i].name {
+			t.Errorf("entry %d = %s, want %s (order changed)", i, f.Name, entries[i].name)
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		if filepath.Ext(f.Name) == ".png" {
+			if !samePixels(t, data, entries[i].data) {
+				t.Errorf("%s: pixels changed", f.Name)
+			}
+		} else if !bytes.Equal(data, entries[i].data) {
+			t.Errorf("%s: content changed", f.Name)
+		}
+
+		wantMethod := zip.Deflate
+		if i == 1 || i == 3 {
+			wantMethod = zip.Store
+		}
+		if f.Method != wantMethod {
+			t.Errorf("%s: method %d, want %d", f.Name, f.Method, wantMethod)
+		}
+	}
+
+	// The download deflated the pages already, only re-encoding the PNG saves space
+	srcInfo, _ := os.Stat(src)
+	dstInfo, _ := os.Stat(dst)
+	if dstInfo.Size() > srcInfo.Size()*3/4 {
+		t.Errorf("repacked size %d is not a quarter smaller than %d", dstInfo.Size(), srcInfo.Size())
+	}
+}
+
+func TestRepackCbzInPlaceAndLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ch002.cbz")
+	writeChapterCbz(t, path, sampleCbzEntries(t))
+
+	if err := parser.RepackCbz(path, path, 0); err == nil {
+		t.Error("expected an error for level 0")
+	}
+	if err := parser.RepackCbz(path, path, flate.BestSpeed); err != nil {
+		t.Fatalf("in-place repack: %v", err)
+	}
+	if pages, err := parser.CbzPageCount(path); err != nil || pages != 4 {
+		t.Errorf("in-place repack page count = %d, %v", pages, err)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".kansho-repack-*")); len(leftovers) != 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestArchiveSeries(t *testing.T) {
+	library := t.TempDir()
+	for _, name := range []string{"ch001.cbz", "ch002.cbz"} {
+		writeChapterCbz(t, filepath.Join(library, name), sampleCbzEntries(t))
+	}
+
+	dest := filepath.Join(t.TempDir(), "archive")
+	if err := config.ArchiveSeries(&config.Bookmarks{Title: "Archived", Location: library}, dest, parser.DefaultRepackLevel); err != nil {
+		t.Fatalf("ArchiveSeries: %v", err)
+	}
+	if got := readDirNames(t, dest); len(got) != 2 || got[0] != "ch001.cbz" || got[1] != "ch002.cbz" {
+		t.Errorf("archive contents = %v", got)
+	}
+}
@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+// resumeSite serves a single chapter of three pages through API extraction, so the
+// manager runs without a browser
+type resumeSite struct {
+	imageBase string
+}
+
+var _ downloader.NativeImageSite = (*resumeSite)(nil)
+
+func (s *resumeSite) GetSiteName() string         { return "resumetest" }
+func (s *resumeSite) GetDomain() string           { return "127.0.0.1" }
+func (s *resumeSite) NeedsCFBypass() bool         { return false }
+func (s *resumeSite) KeepNativeImageFormat() bool { return true }
+
+func (s *resumeSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			return []map[string]string{{"url": s.imageBase + "/chapter/1", "number": "1"}}, nil
+		},
+	}
+}
+
+func (s *resumeSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	return &downloader.ImageExtractionMethod{
+		Type: "api",
+		APIFunc: func(chapterURL string, chapterData map[string]string, client *downloader.APIClient) ([]string, error) {
+			return []string{s.imageBase + "/img/1", s.imageBase + "/img/2", s.imageBase + "/img/3"}, nil
+		},
+	}
+}
+
+func (s *resumeSite) NormalizeChapterURL(rawURL, baseURL string) string { return rawURL }
+
+func (s *resumeSite) NormalizeChapterFilename(data map[string]string) string {
+	return "ch00" + data["number"] + ".cbz"
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func Test_Manager_ResumesTempPagesAndRefetchesTruncated(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	served := encodePNG(t, 30, 40)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(served)
+	}))
+	defer server.Close()
+
+	site := &resumeSite{imageBase: server.URL}
+	manga := &config.Bookmarks{
+		Title:    "Resume Test",
+		Url:      server.URL + "/series",
+		Location: t.TempDir(),
+		Site:     site.GetSiteName(),
+	}
+
+	// Simulate a crash: two complete pages and a third cut off mid-write
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(filepath.Dir(tempDir))) })
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	kept := encodePNG(t, 10, 20)
+	for _, name := range []string{"001.png", "002.png"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), kept, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "003.png"), served[:len(served)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site})
+	if err := manager.Download(context.Background()); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if hits["/img/1"] != 0 || hits["/img/2"] != 0 {
+		t.Errorf("intact temp pages were downloaded again: %v", hits)
+	}
+	if hits["/img/3"] != 1 {
+		t.Errorf("truncated page should be downloaded once, got %d", hits["/img/3"])
+	}
+
+	zr, err := zip.OpenReader(filepath.Join(manga.Location, "ch001.cbz"))
+	if err != nil {
+		t.Fatalf("open cbz: %v", err)
+	}
+	defer zr.Close()
+
+	if len(zr.File) != 3 {
+		t.Fatalf("expected 3 pages in cbz, got %d", len(zr.File))
+	}
+	for i, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := png.DecodeConfig(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("%s: corrupt page in cbz: %v", f.Name, err)
+		}
+		wantWidth := 10
+		if i == 2 {
+			wantWidth = 30
+		}
+		if cfg.Width != wantWidth {
+			t.Errorf("%s: expected width %d, got %d", f.Name, wantWidth, cfg.Width)
+		}
+	}
+}
+
+func Test_ResumeTempPages_RemovesIncompleteWrites(t *testing.T) {
+	dir := t.TempDir()
+	if err := parser.SaveImage(encodePNG(t, 10, 20), dir, "001", true); err != nil {
+		t.Fatalf("SaveImage: %v", err)
+	}
+	// A page that was still being written when the run stopped
+	if err := os.WriteFile(filepath.Join(dir, ".page-part-123"), []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pages, err := parser.ResumeTempPages(dir)
+	if err != nil {
+		t.Fatalf("ResumeTempPages: %v", err)
+	}
+	if len(pages) != 1 || pages["001"] != "001.png" {
+		t.Errorf("expected only 001.png to be resumed, got %v", pages)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "001.png" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("expected only 001.png left in the temp dir, got %v", names)
+	}
+}
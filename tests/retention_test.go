@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+func writeChapters(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("cbz"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
+
+func Test_PruneOldChapters_NumericOrder(t *testing.T) {
+	dir := t.TempDir()
+	// Lexical order would put ch100 before ch20
+	writeChapters(t, dir, "ch2.cbz", "ch10.cbz", "ch100.cbz", "ch20.cbz", "ch020.5.cbz")
+
+	removed, err := parser.PruneOldChapters(dir, 2)
+	if err != nil {
+		t.Fatalf("PruneOldChapters: %v", err)
+	}
+
+	wantRemoved := []string{"ch2.cbz", "ch10.cbz", "ch20.cbz"}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Fatalf("removed = %v, want %v", removed, wantRemoved)
+	}
+
+	remaining, err := parser.LocalChapterList(dir)
+	if err != nil {
+		t.Fatalf("LocalChapterList: %v", err)
+	}
+	sort.Strings(remaining)
+	wantRemaining := []string{"ch020.5.cbz", "ch100.cbz"}
+	if !reflect.DeepEqual(remaining, wantRemaining) {
+		t.Fatalf("remaining = %v, want %v", remaining, wantRemaining)
+	}
+}
+
+func Test_PruneOldChapters_NoRefetch(t *testing.T) {
+	dir := t.TempDir()
+	writeChapters(t, dir, "ch1.cbz", "ch2.cbz", "ch3.cbz")
+
+	if _, err := parser.PruneOldChapters(dir, 1); err != nil {
+		t.Fatalf("first prune: %v", err)
+	}
+
+	// Next run: the site lists everything plus a new chapter
+	writeChapters(t, dir, "ch4.cbz")
+	if _, err := parser.PruneOldChapters(dir, 1); err != nil {
+		t.Fatalf("second prune: %v", err)
+	}
+
+	siteChapters := []string{"ch1.cbz", "ch2.cbz", "ch3.cbz", "ch4.cbz", "ch5.cbz"}
+	local, _ := parser.LocalChapterList(dir)
+	pruned, err := parser.PrunedChapterList(dir)
+	if err != nil {
+		t.Fatalf("PrunedChapterList: %v", err)
+	}
+
+	skip := map[string]bool{}
+	for _, name := range append(local, pruned...) {
+		skip[name] = true
+	}
+	var toFetch []string
+	for _, name := range siteChapters {
+		if !skip[name] {
+			toFetch = append(toFetch, name)
+		}
+	}
+
+	if !reflect.DeepEqual(toFetch, []string{"ch5.cbz"}) {
+		t.Fatalf("chapters to fetch = %v, want [ch5.cbz] (pruned = %v)", toFetch, pruned)
+	}
+	if !reflect.DeepEqual(pruned, []string{"ch1.cbz", "ch2.cbz", "ch3.cbz"}) {
+		t.Fatalf("pruned = %v", pruned)
+	}
+}
+
+func Test_PruneOldChapters_Disabled(t *testing.T) {
+	dir := t.TempDir()
+	writeChapters(t, dir, "ch1.cbz", "ch2.cbz")
+
+	removed, err := parser.PruneOldChapters(dir, 0)
+	if err != nil || len(removed) != 0 {
+		t.Fatalf("keep=0 should not prune, removed=%v err=%v", removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".kansho-pruned.json")); !os.IsNotExist(err) {
+		t.Fatalf("sidecar should not be written when pruning is disabled")
+	}
+}
+
+func Test_Manager_PrunesUpToDateSeries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mock := newMockMangaSite(t, map[int]int{1: 1, 2: 1, 3: 1})
+	manga := &config.Bookmarks{Title: "Mock Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: "mocksite", KeepLatest: 1}
+	writeChapters(t, manga.Location, "ch001.cbz", "ch002.cbz", "ch003.cbz")
+
+	// Nothing new on the site, the lowered keep-latest still applies
+	manager := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: &mockSitePlugin{}})
+	if err := manager.Download(context.Background()); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	local, err := parser.LocalChapterList(manga.Location)
+	if err != nil {
+		t.Fatalf("LocalChapterList: %v", err)
+	}
+	if !reflect.DeepEqual(local, []string{"ch003.cbz"}) {
+		t.Errorf("library = %v, want only ch003.cbz", local)
+	}
+}
+
+func Test_Manager_SkipsPruneAfterFailedChapters(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// Chapter 3 lists no pages
+	mock := newMockMangaSite(t, map[int]int{1: 1, 2: 1, 3: 0})
+
+	manga := &config.Bookmarks{Title: "Mock Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: "mocksite", KeepLatest: 1}
+	writeChapters(t, manga.Location, "ch001.cbz", "ch002.cbz")
+
+	// The latest chapter fails, pruning now would keep ch002 in place of the
+	// missing ch003
+	manager := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: &mockSitePlugin{}})
+	if err := manager.Download(context.Background()); err == nil {
+		t.Fatal("expected the failed chapter to fail the download")
+	}
+
+	local, err := parser.LocalChapterList(manga.Location)
+	if err != nil {
+		t.Fatalf("LocalChapterList: %v", err)
+	}
+	sort.Strings(local)
+	if !reflect.DeepEqual(local, []string{"ch001.cbz", "ch002.cbz"}) {
+		t.Errorf("library = %v, want ch001.cbz and ch002.cbz left alone", local)
+	}
+}
@@ -0,0 +1,120 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+// retryFailedSite is the mock site, its chapter list must never be fetched
+type retryFailedSite struct {
+	mockSitePlugin
+	t *testing.T
+}
+
+func (s *retryFailedSite) GetSiteName() string { return "retryfailedtest" }
+
+func (s *retryFailedSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "custom",
+		CustomParser: func(html string) (map[string]string, error) {
+			s.t.Error("retrying failed chapters fetched the chapter list")
+			return mockChapterLinks(html)
+		},
+	}
+}
+
+func TestUpdateFailedChapters_Merges(t *testing.T) {
+	dir := t.TempDir()
+	if err := parser.UpdateFailedChapters(dir, map[string]string{"ch001.cbz": "u1", "ch002.cbz": "u2"}, nil); err != nil {
+		t.Fatalf("UpdateFailedChapters: %v", err)
+	}
+	// ch001 came through this time, ch003 failed, ch002 was not tried
+	if err := parser.UpdateFailedChapters(dir, map[string]string{"ch003.cbz": "u3"}, []string{"ch001.cbz"}); err != nil {
+		t.Fatalf("UpdateFailedChapters: %v", err)
+	}
+	failed, err := parser.FailedChapters(dir)
+	if err != nil {
+		t.Fatalf("FailedChapters: %v", err)
+	}
+	if want := map[string]string{"ch002.cbz": "u2", "ch003.cbz": "u3"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("failed chapters = %v, want %v", failed, want)
+	}
+
+	// Nothing left to retry, the sidecar goes away
+	if err := parser.UpdateFailedChapters(dir, nil, []string{"ch002.cbz", "ch003.cbz"}); err != nil {
+		t.Fatalf("UpdateFailedChapters: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("%d files left once every chapter was downloaded", len(entries))
+	}
+}
+
+func Test_DownloadQueue_RetryFailedChaptersOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mock := newMockMangaSite(t, map[int]int{1: 1, 2: 1, 3: 1, 4: 1})
+	site := &retryFailedSite{t: t}
+	config.RegisterSite(site.GetSiteName(), func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		return downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site, ProgressCallback: progress}).Download(ctx)
+	})
+
+	manga := &config.Bookmarks{Title: "Retry Failed Manga", Url: mock.server.URL + "/series", Location: t.TempDir(), Site: site.GetSiteName()}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch002.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(tempDir)) })
+
+	// An earlier run failed chapters 2 and 3, 1 and 4 are missing but were never tried
+	failed := map[string]string{
+		"ch002.cbz": mock.server.URL + "/chapter/2",
+		"ch003.cbz": mock.server.URL + "/chapter/3",
+	}
+	if err := parser.UpdateFailedChapters(manga.Location, failed, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan *config.DownloadTask, 1)
+	queue := config.GetDownloadQueue()
+	unsubscribe := queue.Subscribe(config.QueueListener{
+		OnTaskUpdated: func(tk *config.DownloadTask) {
+			if tk.Manga.Site == site.GetSiteName() && (tk.Status == "completed" || tk.Status == "failed") {
+				done <- tk
+			}
+		},
+	})
+	defer unsubscribe()
+	defer queue.RemoveCompletedTasks()
+
+	if _, err := queue.AddRetryFailedTask(manga); err != nil {
+		t.Fatalf("AddRetryFailedTask: %v", err)
+	}
+	select {
+	case task := <-done:
+		if task.Status != "completed" {
+			t.Fatalf("retry finished %s: %s", task.Status, task.StatusMessage)
+		}
+	case <-time.After(20 * time.Second):
+		t.Fatal("timed out waiting for the retry")
+	}
+
+	local, err := parser.LocalChapterList(manga.Location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(local)
+	if want := []string{"ch002.cbz", "ch003.cbz"}; !slices.Equal(local, want) {
+		t.Errorf("library = %v, want only the failed chapters %v", local, want)
+	}
+	if hits := mock.images(); hits != 2 {
+		t.Errorf("fetched %d images, want the 2 pages of the failed chapters", hits)
+	}
+	if left, _ := parser.FailedChapters(manga.Location); len(left) != 0 {
+		t.Errorf("failed chapters still recorded after a clean retry: %v", left)
+	}
+}
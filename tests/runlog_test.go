@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"kansho/config"
+)
+
+func runLogMessages(runLog *config.RunLog) []string {
+	var messages []string
+	for _, line := range runLog.Lines() {
+		messages = append(messages, line.Message)
+	}
+	return messages
+}
+
+func TestRunLog_EvictsOldestPastCapacity(t *testing.T) {
+	runLog := config.NewRunLog(3)
+
+	var streamed []string
+	unsubscribe := runLog.Subscribe(func(line config.RunLogLine) {
+		streamed = append(streamed, line.Message)
+	})
+
+	for i := 1; i <= 5; i++ {
+		runLog.Printf("message %d", i)
+	}
+	if got, want := runLogMessages(runLog), []string{"message 3", "message 4", "message 5"}; !slices.Equal(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+	if len(streamed) != 5 {
+		t.Errorf("subscriber got %d lines, want all 5", len(streamed))
+	}
+
+	unsubscribe()
+	runLog.Add("message 6")
+	if len(streamed) != 5 {
+		t.Error("subscriber still called after unsubscribe")
+	}
+	if got, want := runLogMessages(runLog), []string{"message 4", "message 5", "message 6"}; !slices.Equal(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+
+	// Outside the queue there is no run log, RunLogf is a no-op
+	config.RunLogf(context.Background(), "dropped")
+}
+
+func Test_DownloadQueue_RunLogPerTask(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	const siteName = "runlog-test-site"
+
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		progress(config.ProgressEvent{Status: "Downloading " + manga.Title, Fraction: 0.5, ChapterNum: 1, CurrentDownload: 1, TotalChapters: 1})
+		progress(config.ProgressEvent{Status: "Downloading " + manga.Title, Fraction: 0.6, ChapterNum: 1, CurrentDownload: 1, TotalChapters: 1})
+		config.RunLogf(ctx, "chapter of %s", manga.Title)
+		if strings.HasSuffix(manga.Title, "B") {
+			return fmt.Errorf("site down")
+		}
+		return nil
+	})
+
+	done := make(chan string, 2)
+	queue := config.GetDownloadQueue()
+	unsubscribe := queue.Subscribe(config.QueueListener{
+		OnTaskUpdated: func(tk *config.DownloadTask) {
+			if tk.Manga.Site == siteName && (tk.Status == "completed" || tk.Status == "failed") {
+				done <- tk.ID
+			}
+		},
+	})
+	defer unsubscribe()
+	defer queue.RemoveCompletedTasks()
+
+	ids := map[string]string{}
+	for _, title := range []string{"Run Log Series A", "Run Log Series B"} {
+		task, err := queue.AddTask(&config.Bookmarks{Title: title, Site: siteName, Location: t.TempDir()})
+		if err != nil {
+			t.Fatalf("AddTask(%s): %v", title, err)
+		}
+		ids[title] = task.ID
+	}
+	for range ids {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the downloads")
+		}
+	}
+
+	for title, id := range ids {
+		runLog := queue.GetRunLog(id)
+		if runLog == nil {
+			t.Fatalf("%s has no run log", title)
+		}
+		messages := runLogMessages(runLog)
+		joined := strings.Join(messages, "\n")
+
+		other := "Run Log Series A"
+		if title == other {
+			other = "Run Log Series B"
+		}
+		if strings.Contains(joined, other) {
+			t.Errorf("run log of %s has messages of %s:\n%s", title, other, joined)
+		}
+		if !slices.Contains(messages, "chapter of "+title) {
+			t.Errorf("run log of %s is missing the download's own message:\n%s", title, joined)
+		}
+		// The repeated progress status is logged once
+		if n := strings.Count(joined, "Downloading "+title); n != 1 {
+			t.Errorf("run log of %s has the progress status %d times, want 1", title, n)
+		}
+	}
+
+	if last := runLogMessages(queue.GetRunLog(ids["Run Log Series B"])); !strings.Contains(last[len(last)-1], "failed") {
+		t.Errorf("last line of the failed run = %q, want its final status", last[len(last)-1])
+	}
+}
@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"kansho/config"
+)
+
+func TestUpdateScheduler_QueuesDueSeries(t *testing.T) {
+	const siteName = "scheduler-test-site"
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"auto_check_interval_hours": 24}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var downloaded []string
+	config.RegisterSite(siteName, func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		mu.Lock()
+		downloaded = append(downloaded, manga.Title)
+		mu.Unlock()
+		return nil
+	})
+	queue := config.GetDownloadQueue()
+	defer queue.RemoveCompletedTasks()
+
+	mangas := []config.Bookmarks{
+		{Title: "Scheduled Never Run", Url: "https://example.com/never-run"},
+		{Title: "Scheduled Recent", Url: "https://example.com/recent"},
+		{Title: "Scheduled Own Interval", Url: "https://example.com/own-interval", CheckIntervalHours: 2},
+		{Title: "Scheduled Opted Out", Url: "https://example.com/opted-out", CheckIntervalHours: -1},
+		{Title: "Scheduled Finished", Url: "https://example.com/finished", Completed: true},
+	}
+	for i := range mangas {
+		mangas[i].Site = siteName
+		mangas[i].Location = t.TempDir()
+	}
+	if err := config.SaveBookmarks(config.Manga{Manga: mangas}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for url, ago := range map[string]time.Duration{
+		"https://example.com/recent":       time.Hour,
+		"https://example.com/own-interval": 3 * time.Hour,
+		"https://example.com/opted-out":    100 * time.Hour,
+	} {
+		if err := config.DefaultStore().RecordRun(config.RunRecord{URL: url, Status: "completed", Started: now.Add(-ago), Finished: now.Add(-ago)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scheduler := config.NewUpdateScheduler(queue)
+	// runDue queues what is due at now and waits for those downloads to finish
+	runDue := func(now time.Time) []string {
+		t.Helper()
+		mu.Lock()
+		downloaded = nil
+		mu.Unlock()
+
+		want := scheduler.QueueDue(now)
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			mu.Lock()
+			got := slices.Clone(downloaded)
+			mu.Unlock()
+			if len(got) == want && allFinished(queue, got) {
+				sort.Strings(got)
+				return got
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%d of %d scheduled downloads finished", len(got), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if got, want := runDue(now), []string{"Scheduled Never Run", "Scheduled Own Interval"}; !slices.Equal(got, want) {
+		t.Errorf("first check downloaded %q, want %q", got, want)
+	}
+	// Both just ran, nothing is due again before its interval passes
+	if got := runDue(now.Add(time.Hour)); len(got) != 0 {
+		t.Errorf("an hour later downloaded %q, want nothing", got)
+	}
+	// The finished task of the first run is replaced by a new one
+	if got, want := runDue(now.Add(3*time.Hour)), []string{"Scheduled Own Interval"}; !slices.Equal(got, want) {
+		t.Errorf("three hours later downloaded %q, want %q", got, want)
+	}
+	if got, want := runDue(now.Add(30*time.Hour)), []string{"Scheduled Never Run", "Scheduled Own Interval", "Scheduled Recent"}; !slices.Equal(got, want) {
+		t.Errorf("a day later downloaded %q, want %q", got, want)
+	}
+
+	// Turning the setting off leaves only series with their own interval
+	if err := config.UpdateSettings(func(settings *config.Settings) { settings.AutoCheckIntervalHours = 0 }); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := runDue(now.Add(60*time.Hour)), []string{"Scheduled Own Interval"}; !slices.Equal(got, want) {
+		t.Errorf("with auto checks off downloaded %q, want %q", got, want)
+	}
+}
+
+// allFinished reports whether the queue tasks of every title have finished
+func allFinished(queue *config.DownloadQueue, titles []string) bool {
+	for _, title := range titles {
+		task, ok := queue.TaskSnapshotForManga(title)
+		if !ok || task.Status != "completed" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/downloader"
+	"kansho/models"
+	"kansho/sites"
+)
+
+const sampleMadaraChapterHTML = `<html><body>
+<div class="reading-content">
+  <img src="https://cdn.example.com/old/1.jpg">
+</div>
+<div class="read-container">
+  <img data-lazy-src="https://cdn.example.com/new/1.webp" src="placeholder.gif">
+  <img data-lazy-src="https://cdn.example.com/new/2.webp" src="placeholder.gif">
+</div>
+</body></html>`
+
+func Test_SiteSelectors_ConfigOverride(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	sites.ReloadSitesConfig()
+	site := &sites.ManhuausSite{}
+
+	// Defaults match the previously hardcoded selectors
+	method := site.GetImageExtractionMethod()
+	if method.Selector != "div.reading-content img" || method.Attribute != downloader.MadaraImageAttributes {
+		t.Fatalf("unexpected default selectors: %q / %q", method.Selector, method.Attribute)
+	}
+
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	override := `{"sites": [{"name": "manhuaus", "selectors": {"image": "div.read-container img", "image_attribute": "data-lazy-src"}}]}`
+	if err := os.WriteFile(filepath.Join(configDir, "sites.json"), []byte(override), 0644); err != nil {
+		t.Fatalf("failed to write sites override: %v", err)
+	}
+	sites.ReloadSitesConfig()
+
+	method = site.GetImageExtractionMethod()
+	if method.Selector != "div.read-container img" || method.WaitSelector != "div.read-container img" {
+		t.Fatalf("override selector not used: selector=%q wait=%q", method.Selector, method.WaitSelector)
+	}
+
+	images, err := downloader.SelectImageURLs(sampleMadaraChapterHTML, method.Selector, method.Attribute)
+	if err != nil {
+		t.Fatalf("SelectImageURLs failed: %v", err)
+	}
+
+	want := []string{"https://cdn.example.com/new/1.webp", "https://cdn.example.com/new/2.webp"}
+	if len(images) != len(want) {
+		t.Fatalf("expected %d images, got %d: %v", len(want), len(images), images)
+	}
+	for i := range want {
+		if images[i] != want[i] {
+			t.Fatalf("image %d: expected %s, got %s", i, want[i], images[i])
+		}
+	}
+
+	// Chapter selector was not overridden and keeps its default
+	if got := site.GetChapterExtractionMethod().Selector; got != "li.wp-manga-chapter a" {
+		t.Fatalf("chapter selector changed unexpectedly: %q", got)
+	}
+}
+
+func Test_SelectImageURLs_PictureSource(t *testing.T) {
+	html := `<html><body>
+<div class="reading-content">
+  <picture>
+    <source type="image/webp" srcset="https://cdn.example.com/p1-800.webp 800w, https://cdn.example.com/p1-1600.webp 1600w">
+    <img src="https://cdn.example.com/p1-small.jpg">
+  </picture>
+  <picture>
+    <source type="image/webp" srcset="https://cdn.example.com/p2.webp">
+    <img data-src="https://cdn.example.com/p2.jpg" src="placeholder.gif">
+  </picture>
+  <img data-src="https://cdn.example.com/p3.jpg">
+</div>
+</body></html>`
+
+	images, err := downloader.SelectImageURLs(html, "div.reading-content img", "data-src")
+	if err != nil {
+		t.Fatalf("SelectImageURLs failed: %v", err)
+	}
+
+	want := []string{
+		"https://cdn.example.com/p1-1600.webp",
+		"https://cdn.example.com/p2.webp",
+		"https://cdn.example.com/p3.jpg",
+	}
+	if len(images) != len(want) {
+		t.Fatalf("expected %d images, got %d: %v", len(want), len(images), images)
+	}
+	for i := range want {
+		if images[i] != want[i] {
+			t.Errorf("image %d: expected %s, got %s", i, want[i], images[i])
+		}
+	}
+}
+
+func Test_ReloadSitesConfig_PinnedSiteKeepsSelectors(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	sites.ReloadSitesConfig()
+
+	// A download already in progress holds the config it started with
+	inFlight := sites.NewManhuausSite()
+
+	var notified []string
+	sites.OnSitesConfigReload(func(cfg models.SitesConfig) {
+		for _, site := range cfg.Sites {
+			if site.Name == "manhuaus" && site.Selectors != nil {
+				notified = append(notified, site.Selectors.Image)
+			}
+		}
+	})
+
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	override := `{"sites": [{"name": "manhuaus", "selectors": {"image": "div.read-container img"}}]}`
+	if err := os.WriteFile(filepath.Join(configDir, "sites.json"), []byte(override), 0644); err != nil {
+		t.Fatalf("failed to write sites override: %v", err)
+	}
+
+	// Not visible until reloaded
+	if got := (&sites.ManhuausSite{}).GetImageExtractionMethod().Selector; got != "div.reading-content img" {
+		t.Fatalf("override applied before reload: %q", got)
+	}
+
+	cfg := sites.ReloadSitesConfig()
+	if len(cfg.Sites) == 0 {
+		t.Fatal("reloaded config has no sites")
+	}
+
+	if got := sites.NewManhuausSite().GetImageExtractionMethod().Selector; got != "div.read-container img" {
+		t.Errorf("new download selector = %q, want reloaded override", got)
+	}
+	if got := inFlight.GetImageExtractionMethod().Selector; got != "div.reading-content img" {
+		t.Errorf("in-flight download selector = %q, want original", got)
+	}
+	if len(notified) != 1 || notified[0] != "div.read-container img" {
+		t.Errorf("reload hook saw %v", notified)
+	}
+}
@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/config"
+	"kansho/parser"
+)
+
+// writeBundleSeries creates a three chapter series whose pages carry their chapter
+// and page in the data, chapters and pages are written out of order on purpose
+func writeBundleSeries(t *testing.T, dir string) {
+	t.Helper()
+	writeStoredCbz(t, filepath.Join(dir, "ch10.cbz"), []cbzEntry{
+		{"2.jpg", []byte("ch10-p2")},
+		{"1.jpg", []byte("ch10-p1")},
+	})
+	writeStoredCbz(t, filepath.Join(dir, "ch2.cbz"), []cbzEntry{
+		{"10.jpg", []byte("ch2-p10")},
+		{"9.jpg", []byte("ch2-p9")},
+		{parser.ComicInfoFileName, []byte("<ComicInfo/>")},
+	})
+	writeStoredCbz(t, filepath.Join(dir, "ch1.cbz"), []cbzEntry{
+		{"001.png", []byte("ch1-p1")},
+	})
+}
+
+func readZipEntries(t *testing.T, path string) ([]string, map[string][]byte) {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("bundle does not open: %v", err)
+	}
+	defer zr.Close()
+
+	var names []string
+	data := make(map[string][]byte)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(r)
+		r.Close()
+		names = append(names, f.Name)
+		data[f.Name] = b
+	}
+	return names, data
+}
+
+func TestExportSeriesBundlePerChapter(t *testing.T) {
+	series := t.TempDir()
+	writeBundleSeries(t, series)
+	if err := os.WriteFile(filepath.Join(series, "Cover.jpg"), []byte("cover"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manga := &config.Bookmarks{Title: "Bundle Test", Site: "mgeko", Location: series}
+	dst := filepath.Join(t.TempDir(), config.BundleFileName(manga, false))
+	if err := config.ExportSeriesBundle(manga, dst, false); err != nil {
+		t.Fatalf("ExportSeriesBundle: %v", err)
+	}
+	if filepath.Base(dst) != "Bundle Test.zip" {
+		t.Errorf("bundle name = %s", filepath.Base(dst))
+	}
+
+	names, data := readZipEntries(t, dst)
+	want := []string{"cover.jpg", "ch1.cbz", "ch2.cbz", "ch10.cbz", config.BundleManifestName}
+	if len(names) != len(want) {
+		t.Fatalf("bundle entries = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, names[i], want[i])
+		}
+	}
+	if string(data["cover.jpg"]) != "cover" {
+		t.Errorf("cover = %q, want the series cover file", data["cover.jpg"])
+	}
+
+	original, _ := os.ReadFile(filepath.Join(series, "ch2.cbz"))
+	if string(data["ch2.cbz"]) != string(original) {
+		t.Error("chapter cbz was not stored unchanged")
+	}
+
+	var manifest config.BundleManifest
+	if err := json.Unmarshal(data[config.BundleManifestName], &manifest); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if manifest.Title != "Bundle Test" || manifest.Combined || manifest.Cover != "cover.jpg" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if len(manifest.Chapters) != 3 || manifest.Chapters[2].File != "ch10.cbz" || manifest.Chapters[1].Pages != 2 {
+		t.Errorf("manifest chapters = %+v", manifest.Chapters)
+	}
+}
+
+func TestExportSeriesBundleCombined(t *testing.T) {
+	series := t.TempDir()
+	writeBundleSeries(t, series)
+
+	manga := &config.Bookmarks{Title: "Bundle Test", Location: series}
+	dst := filepath.Join(t.TempDir(), config.BundleFileName(manga, true))
+	if err := config.ExportSeriesBundle(manga, dst, true); err != nil {
+		t.Fatalf("ExportSeriesBundle: %v", err)
+	}
+
+	names, data := readZipEntries(t, dst)
+
+	// No cover file, so the first page of the first chapter is the cover
+	want := []struct{ name, data string }{
+		{"00000.png", "ch1-p1"},
+		{"00001.png", "ch1-p1"},
+		{"00002.jpg", "ch2-p9"},
+		{"00003.jpg", "ch2-p10"},
+		{"00004.jpg", "ch10-p1"},
+		{"00005.jpg", "ch10-p2"},
+		{config.BundleManifestName, ""},
+	}
+	if len(names) != len(want) {
+		t.Fatalf("bundle entries = %v, want %d entries", names, len(want))
+	}
+	for i, w := range want {
+		if names[i] != w.name {
+			t.Errorf("entry %d = %s, want %s", i, names[i], w.name)
+			continue
+		}
+		if w.data != "" && string(data[w.name]) != w.data {
+			t.Errorf("%s = %q, want %q (pages out of order)", w.name, data[w.name], w.data)
+		}
+	}
+
+	var manifest config.BundleManifest
+	if err := json.Unmarshal(data[config.BundleManifestName], &manifest); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if !manifest.Combined || manifest.Cover != "00000.png" || len(manifest.Chapters) != 3 {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+	if c := manifest.Chapters[2]; c.Chapter != "ch10" || c.FirstPage != "00004.jpg" || c.Pages != 2 {
+		t.Errorf("last chapter = %+v", c)
+	}
+}
@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"kansho/config"
+)
+
+func readDirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read %s: %v", dir, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Test_ExportSeriesToDevice_Rename(t *testing.T) {
+	src := t.TempDir()
+	writeChapters(t, src, "ch001.cbz", "ch002.cbz", "ch002.5.cbz")
+	manga := &config.Bookmarks{Title: "Solo: Leveling?", Location: src}
+
+	dest := filepath.Join(t.TempDir(), "device")
+	if err := config.ExportSeriesToDevice(manga, dest, true); err != nil {
+		t.Fatalf("ExportSeriesToDevice: %v", err)
+	}
+
+	want := []string{"Solo Leveling 001.cbz", "Solo Leveling 002.5.cbz", "Solo Leveling 002.cbz"}
+	if got := readDirNames(t, dest); !reflect.DeepEqual(got, want) {
+		t.Fatalf("exported = %v, want %v", got, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, "Solo Leveling 002.5.cbz"))
+	if err != nil || string(data) != "cbz" {
+		t.Fatalf("exported content = %q, %v", data, err)
+	}
+
+	// Source chapters are untouched
+	if got := readDirNames(t, src); !reflect.DeepEqual(got, []string{"ch001.cbz", "ch002.5.cbz", "ch002.cbz"}) {
+		t.Fatalf("source dir changed: %v", got)
+	}
+}
+
+func Test_ExportSeriesToDevice_KeepNames(t *testing.T) {
+	src := t.TempDir()
+	writeChapters(t, src, "ch010.cbz", "ch011.cbz")
+	manga := &config.Bookmarks{Title: "Series", Location: src}
+
+	dest := t.TempDir()
+	if err := config.ExportSeriesToDevice(manga, dest, false); err != nil {
+		t.Fatalf("ExportSeriesToDevice: %v", err)
+	}
+	if got := readDirNames(t, dest); !reflect.DeepEqual(got, []string{"ch010.cbz", "ch011.cbz"}) {
+		t.Fatalf("exported = %v", got)
+	}
+
+	// Exporting into the series directory itself reports each file instead of
+	// truncating it
+	err := config.ExportSeriesToDevice(manga, src, false)
+	if err == nil {
+		t.Fatal("expected per-file errors exporting onto the source")
+	}
+	data, readErr := os.ReadFile(filepath.Join(src, "ch010.cbz"))
+	if readErr != nil || string(data) != "cbz" {
+		t.Fatalf("source chapter damaged: %q, %v", data, readErr)
+	}
+}
@@ -0,0 +1,228 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+// sessionSite fetches its chapter list from the test server through the API client,
+// so both the chapter list and the image requests go over the wire
+type sessionSite struct {
+	resumeSite
+}
+
+func (s *sessionSite) GetSiteName() string { return "sessiontest" }
+
+func (s *sessionSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			var chapters []map[string]string
+			if err := client.FetchJSON(context.Background(), s.imageBase+"/chapters", &chapters); err != nil {
+				return nil, err
+			}
+			return chapters, nil
+		},
+	}
+}
+
+func Test_ParseRequestExtras_ApplyOverridesCookies(t *testing.T) {
+	extras := parser.ParseRequestExtras("https://www.example.com/series/1", "session=abc; bad cookie; remember=1", map[string]string{"X-Token": "t1", " ": "dropped"})
+	if len(extras.Cookies) != 2 || len(extras.Headers) != 1 {
+		t.Fatalf("ParseRequestExtras = %d cookies, %d headers, want 2 and 1", len(extras.Cookies), len(extras.Headers))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/chapter/1", nil)
+	req.Header.Set("Cookie", "cf_clearance=cf; session=old")
+	extras.Apply(req)
+	if got, want := req.Header.Get("Cookie"), "cf_clearance=cf; session=abc; remember=1"; got != want {
+		t.Errorf("Cookie = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Token"); got != "t1" {
+		t.Errorf("X-Token = %q, want t1", got)
+	}
+}
+
+func Test_RequestExtras_OnlyForTheBookmarkHost(t *testing.T) {
+	extras := parser.ParseRequestExtras("https://www.example.com/series/1", "session=abc", map[string]string{"Authorization": "Bearer secret"})
+	for target, want := range map[string]bool{
+		"https://example.com/chapter/1":        true,
+		"https://www.example.com/chapter/1":    true,
+		"https://img.example.com:8443/p/1.png": true,
+		"https://cdn.other.net/p/1.png":        false,
+		"https://example.com.evil.net/p/1.png": false,
+		"https://notexample.com/chapter/1":     false,
+	} {
+		parsed, _ := url.Parse(target)
+		if got := extras.AppliesTo(parsed); got != want {
+			t.Errorf("AppliesTo(%s) = %v, want %v", target, got, want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "https://cdn.other.net/p/1.png", nil)
+	extras.Apply(req)
+	if req.Header.Get("Cookie") != "" || req.Header.Get("Authorization") != "" {
+		t.Errorf("request to another host got Cookie %q and Authorization %q", req.Header.Get("Cookie"), req.Header.Get("Authorization"))
+	}
+}
+
+// cdnSessionSite lists its chapters on the series host and serves its images from
+// another host
+type cdnSessionSite struct {
+	sessionSite
+	seriesBase string
+}
+
+func (s *cdnSessionSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			var chapters []map[string]string
+			if err := client.FetchJSON(context.Background(), s.seriesBase+"/chapters", &chapters); err != nil {
+				return nil, err
+			}
+			return chapters, nil
+		},
+	}
+}
+
+func Test_ExecuteSiteDownload_KeepsSessionExtrasFromCDN(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	page := encodePNG(t, 4, 4)
+
+	type seen struct{ host, path, cookie, token string }
+	var (
+		mu       sync.Mutex
+		requests []seen
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, seen{r.Host, r.URL.Path, r.Header.Get("Cookie"), r.Header.Get("X-Session-Token")})
+		mu.Unlock()
+		if r.URL.Path == "/chapters" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"url": "` + "http://" + r.Host + `/chapter/1", "number": "1"}]`))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	// The same server answers as the series host and, through localhost, as the CDN
+	cdn := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	site := &cdnSessionSite{sessionSite: sessionSite{resumeSite{imageBase: cdn}}, seriesBase: server.URL}
+	config.RegisterSite("sessioncdntest", func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		return downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site}).Download(ctx)
+	})
+
+	manga := &config.Bookmarks{
+		Title:          "Session CDN Test",
+		Url:            server.URL + "/series",
+		Location:       t.TempDir(),
+		Site:           "sessioncdntest",
+		SessionCookies: "session=abc123",
+		SessionHeaders: map[string]string{"X-Session-Token": "secret"},
+	}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(filepath.Dir(tempDir))) })
+
+	if err := config.ExecuteSiteDownload(context.Background(), manga, nil); err != nil {
+		t.Fatalf("ExecuteSiteDownload: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var chapterList, images int
+	for _, req := range requests {
+		if req.path == "/chapters" {
+			chapterList++
+			if req.cookie != "session=abc123" || req.token != "secret" {
+				t.Errorf("chapter list sent Cookie %q and X-Session-Token %q, want the session", req.cookie, req.token)
+			}
+			continue
+		}
+		images++
+		if !strings.HasPrefix(req.host, "localhost:") {
+			t.Errorf("image %s requested from %s, want the CDN host", req.path, req.host)
+		}
+		if req.cookie != "" || req.token != "" {
+			t.Errorf("image %s on the CDN got Cookie %q and X-Session-Token %q", req.path, req.cookie, req.token)
+		}
+	}
+	if chapterList != 1 || images != 3 {
+		t.Errorf("got %d chapter list and %d image requests, want 1 and 3", chapterList, images)
+	}
+}
+
+func Test_ExecuteSiteDownload_SendsSessionExtras(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	page := encodePNG(t, 4, 4)
+
+	type seen struct{ path, cookie, token string }
+	var (
+		mu       sync.Mutex
+		requests []seen
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, seen{r.URL.Path, r.Header.Get("Cookie"), r.Header.Get("X-Session-Token")})
+		mu.Unlock()
+		if r.URL.Path == "/chapters" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"url": "` + "http://" + r.Host + `/chapter/1", "number": "1"}]`))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	site := &sessionSite{resumeSite{imageBase: server.URL}}
+	config.RegisterSite(site.GetSiteName(), func(ctx context.Context, manga *config.Bookmarks, progress config.ProgressFunc) error {
+		return downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site}).Download(ctx)
+	})
+
+	manga := &config.Bookmarks{
+		Title:          "Session Test",
+		Url:            server.URL + "/series",
+		Location:       t.TempDir(),
+		Site:           site.GetSiteName(),
+		SessionCookies: "session=abc123; age_ok=1",
+		SessionHeaders: map[string]string{"X-Session-Token": "secret"},
+	}
+	tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(filepath.Dir(tempDir))) })
+
+	if err := config.ExecuteSiteDownload(context.Background(), manga, nil); err != nil {
+		t.Fatalf("ExecuteSiteDownload: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var chapterList, images int
+	for _, req := range requests {
+		if req.cookie != "session=abc123; age_ok=1" || req.token != "secret" {
+			t.Errorf("%s sent Cookie %q and X-Session-Token %q", req.path, req.cookie, req.token)
+		}
+		if req.path == "/chapters" {
+			chapterList++
+		} else {
+			images++
+		}
+	}
+	if chapterList != 1 || images != 3 {
+		t.Errorf("got %d chapter list and %d image requests, want 1 and 3", chapterList, images)
+	}
+}
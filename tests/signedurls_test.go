@@ -0,0 +1,127 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+)
+
+// signedURLSite hands out page URLs signed with a new token every time the chapter's
+// images are extracted
+type signedURLSite struct {
+	resumeSite
+
+	mu          sync.Mutex
+	extractions int
+}
+
+func (s *signedURLSite) GetSiteName() string { return "signedurltest" }
+
+func (s *signedURLSite) GetImageExtractionMethod() *downloader.ImageExtractionMethod {
+	return &downloader.ImageExtractionMethod{
+		Type: "api",
+		APIFunc: func(chapterURL string, chapterData map[string]string, client *downloader.APIClient) ([]string, error) {
+			s.mu.Lock()
+			s.extractions++
+			token := s.extractions
+			s.mu.Unlock()
+
+			urls := make([]string, 4)
+			for i := range urls {
+				urls[i] = fmt.Sprintf("%s/img/%d?token=%d&expires=60", s.imageBase, i+1, token)
+			}
+			return urls, nil
+		},
+	}
+}
+
+func TestIsSignedImageURL(t *testing.T) {
+	for imageURL, want := range map[string]bool{
+		"https://cdn.example.com/1.jpg?token=abc&expires=1700000000": true,
+		"https://cdn.example.com/1.jpg?X-Amz-Signature=abc":          true,
+		"https://cdn.example.com/1.jpg?Policy=x&Key-Pair-Id=y":       true,
+		"https://cdn.example.com/1.jpg":                              false,
+		"https://cdn.example.com/1.jpg?w=800":                        false,
+		"https://cdn.example.com/token/expires.jpg":                  false,
+	} {
+		if got := downloader.IsSignedImageURL(imageURL); got != want {
+			t.Errorf("IsSignedImageURL(%s) = %v, want %v", imageURL, got, want)
+		}
+	}
+}
+
+func Test_Manager_RefreshesExpiredSignedURLs(t *testing.T) {
+	// The first token expires after two pages, as if the earlier pages were slow
+	var mu sync.Mutex
+	servedWithFirst := 0
+	rejected := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(filepath.Base(r.URL.Path))
+		mu.Lock()
+		expired := false
+		if r.URL.Query().Get("token") == "1" {
+			if servedWithFirst >= 2 {
+				expired = true
+				rejected++
+			} else {
+				servedWithFirst++
+			}
+		}
+		mu.Unlock()
+		if expired {
+			http.Error(w, "signature expired", http.StatusForbidden)
+			return
+		}
+		data, err := encodeMockPage(page, 1)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	site := &signedURLSite{resumeSite: resumeSite{imageBase: server.URL}}
+	manga := &config.Bookmarks{Title: "Signed URL Manga", Url: server.URL + "/series", Location: t.TempDir(), Site: site.GetSiteName()}
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz"))) })
+
+	if err := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site}).Download(context.Background()); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	names, data := readZipEntries(t, filepath.Join(manga.Location, "ch001.cbz"))
+	if len(names) != 4 {
+		t.Fatalf("CBZ has %v, want all 4 pages", names)
+	}
+	// Pages are 10, 20, 30 and 40 pixels wide, the order survived the refresh
+	for i, name := range names {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data[name]))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if cfg.Width != (i+1)*10 {
+			t.Errorf("entry %s is %dpx wide, want page %d", name, cfg.Width, i+1)
+		}
+	}
+
+	site.mu.Lock()
+	defer site.mu.Unlock()
+	if site.extractions != 2 {
+		t.Errorf("images extracted %d times, want once more after the token expired", site.extractions)
+	}
+	if rejected == 0 {
+		t.Error("no page was requested with the expired token")
+	}
+}
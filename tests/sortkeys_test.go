@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"slices"
+	"testing"
+
+	"kansho/parser"
+)
+
+func keysOf(keys ...string) map[string]string {
+	m := make(map[string]string, len(keys))
+	for _, key := range keys {
+		m[key] = "https://cdn.example.com/" + key
+	}
+	return m
+}
+
+func TestSortKeysNumeric(t *testing.T) {
+	cases := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{"unpadded", []string{"10", "2", "1", "0", "20"}, []string{"0", "1", "2", "10", "20"}},
+		{"padded", []string{"010", "002", "001", "100"}, []string{"001", "002", "010", "100"}},
+		{"extensions", []string{"10.jpg", "9.jpg", "1.webp", "100.png"}, []string{"1.webp", "9.jpg", "10.jpg", "100.png"}},
+		{"mixed padding", []string{"011.jpg", "9.jpg", "10", "002.webp"}, []string{"002.webp", "9.jpg", "10", "011.jpg"}},
+		{"same number", []string{"1.jpg", "01.jpg", "1"}, []string{"01.jpg", "1", "1.jpg"}},
+		{"non-numeric last", []string{"cover.jpg", "2.jpg", "banner", "1.jpg"}, []string{"1.jpg", "2.jpg", "banner", "cover.jpg"}},
+		{"paths", []string{"tmp/12.jpg", "tmp/3.jpg"}, []string{"tmp/3.jpg", "tmp/12.jpg"}},
+	}
+
+	for _, tc := range cases {
+		got, err := parser.SortKeysNumeric(keysOf(tc.keys...))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSortKeysNumericDeterministic(t *testing.T) {
+	keys := keysOf("b", "a", "3", "c.jpg", "03", "1")
+	first, _ := parser.SortKeysNumeric(keys)
+	for range 20 {
+		if got, _ := parser.SortKeysNumeric(keys); !slices.Equal(got, first) {
+			t.Fatalf("order changed between runs: %v vs %v", got, first)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+// specialsSite lists numbered chapters mixed with specials, newest first
+type specialsSite struct{ skipSite }
+
+func (s *specialsSite) GetChapterExtractionMethod() *downloader.ChapterExtractionMethod {
+	return &downloader.ChapterExtractionMethod{
+		Type: "api",
+		APIFunc: func(baseURL string, client *downloader.APIClient) ([]map[string]string, error) {
+			return []map[string]string{
+				{"url": "/special-2", "text": "Special"},
+				{"url": "/chapter-12", "text": "Chapter 12"},
+				{"url": "/vol-3-extra", "text": "Volume 3 Extra"},
+				{"url": "/chapter-11-5", "text": "Chapter 11.5 - Side Story"},
+				{"url": "/chapter-11", "text": "Chapter 11"},
+				{"url": "/vol-3-extra", "text": "Volume 3 Extra"},
+				{"url": "/omake", "text": "Omake"},
+			}, nil
+		},
+	}
+}
+
+var specialsSiteChapterRe = regexp.MustCompile(`Chapter (\d+)(?:\.(\d+))?`)
+
+func (s *specialsSite) NormalizeChapterFilename(data map[string]string) string {
+	m := specialsSiteChapterRe.FindStringSubmatch(data["text"])
+	if m == nil {
+		return ""
+	}
+	return parser.ChapterFilename(m[1], m[2])
+}
+
+func TestIsSpecialChapter(t *testing.T) {
+	for label, want := range map[string]bool{
+		"Volume 3 Extra":            true,
+		"Special":                   true,
+		"Omake 2":                   true,
+		"Side Story: The Beach":     true,
+		"Chapter 12":                false,
+		"Chapter 45.5 - Side Story": false,
+		"Ch. 45.5 - Special":        false,
+		"Extraordinary Chapter 3":   false,
+		"The Specialist":            false,
+	} {
+		if got := parser.IsSpecialChapter(label); got != want {
+			t.Errorf("IsSpecialChapter(%q) = %v, want %v", label, got, want)
+		}
+	}
+}
+
+func TestSpecialChapters_NamedAndSortedLast(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	chapters, err := downloader.FetchChapterList(context.Background(), "http://127.0.0.1/series", &specialsSite{})
+	if err != nil {
+		t.Fatalf("FetchChapterList: %v", err)
+	}
+	want := map[string]string{
+		"ch011.cbz":   "/chapter-11",
+		"ch011.5.cbz": "/chapter-11-5",
+		"ch012.cbz":   "/chapter-12",
+		// Oldest first, the repeated extra is named once
+		"sp001.cbz": "/omake",
+		"sp002.cbz": "/vol-3-extra",
+		"sp003.cbz": "/special-2",
+	}
+	if len(chapters.URLs) != len(want) {
+		t.Errorf("chapters = %v, want %v", chapters.URLs, want)
+	}
+	for name, url := range want {
+		if chapters.URLs[name] != url {
+			t.Errorf("%s = %q, want %q", name, chapters.URLs[name], url)
+		}
+	}
+
+	names := parser.SortChapterKeys(chapters.URLs)
+	names = append(names, "s02ch001.cbz")
+	parser.SortChaptersNumeric(names)
+	wantOrder := []string{"ch011.cbz", "ch011.5.cbz", "ch012.cbz", "s02ch001.cbz", "sp001.cbz", "sp002.cbz", "sp003.cbz"}
+	if !slices.Equal(names, wantOrder) {
+		t.Errorf("sorted = %v, want %v", names, wantOrder)
+	}
+
+	configDir := filepath.Join(home, ".config", "kansho")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"skip_special_chapters": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chapters, err = downloader.FetchChapterList(context.Background(), "http://127.0.0.1/series", &specialsSite{})
+	if err != nil {
+		t.Fatalf("FetchChapterList: %v", err)
+	}
+	if len(chapters.URLs) != 3 || chapters.URLs["sp001.cbz"] != "" {
+		t.Errorf("with skip_special_chapters chapters = %v, want only the numbered ones", chapters.URLs)
+	}
+}
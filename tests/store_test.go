@@ -0,0 +1,237 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"kansho/config"
+)
+
+// openStores returns a constructor for every store backend, each store lives in a
+// fresh temp dir
+func openStores(t *testing.T) map[string]func(t *testing.T) config.Store {
+	return map[string]func(t *testing.T) config.Store{
+		config.StorageJSON: func(t *testing.T) config.Store {
+			return config.NewJSONStore(t.TempDir())
+		},
+		config.StorageSQLite: func(t *testing.T) config.Store {
+			return openSQLite(t, filepath.Join(t.TempDir(), config.SQLiteStoreName))
+		},
+	}
+}
+
+func openSQLite(t *testing.T, path string) *config.SQLiteStore {
+	t.Helper()
+	store, err := config.OpenSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("OpenSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func storeTitles(t *testing.T, store config.Store) []string {
+	t.Helper()
+	bookmarks, err := store.ListBookmarks()
+	if err != nil {
+		t.Fatalf("ListBookmarks: %v", err)
+	}
+	titles := []string{}
+	for _, manga := range bookmarks {
+		titles = append(titles, manga.Title)
+	}
+	return titles
+}
+
+func Test_Store_Bookmarks(t *testing.T) {
+	for name, open := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+
+			if titles := storeTitles(t, store); len(titles) != 0 {
+				t.Fatalf("new store lists %v", titles)
+			}
+
+			first := config.Bookmarks{Title: "First", Url: "https://example.test/first", Site: "mgeko", MirrorLocations: []string{"/mnt/a"}}
+			second := config.Bookmarks{Title: "Second", Url: "https://example.test/second", Site: "mgeko"}
+			for _, manga := range []config.Bookmarks{first, second} {
+				if err := store.SaveBookmark(manga); err != nil {
+					t.Fatalf("SaveBookmark: %v", err)
+				}
+			}
+
+			// Saving an existing URL updates it in place
+			first.Title = "First Renamed"
+			first.KeepLatest = 5
+			if err := store.SaveBookmark(first); err != nil {
+				t.Fatalf("SaveBookmark: %v", err)
+			}
+			bookmarks, err := store.ListBookmarks()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(bookmarks, []config.Bookmarks{first, second}) {
+				t.Fatalf("ListBookmarks = %+v", bookmarks)
+			}
+
+			if err := store.SaveBookmark(config.Bookmarks{Title: "No URL"}); err == nil {
+				t.Error("saved a bookmark without a url")
+			}
+
+			if err := store.DeleteBookmark(first.Url); err != nil {
+				t.Fatalf("DeleteBookmark: %v", err)
+			}
+			if err := store.DeleteBookmark("https://example.test/missing"); err != nil {
+				t.Errorf("DeleteBookmark of a missing url: %v", err)
+			}
+			if got := storeTitles(t, store); !reflect.DeepEqual(got, []string{"Second"}) {
+				t.Fatalf("after delete = %v", got)
+			}
+
+			// Replace keeps the given order
+			third := config.Bookmarks{Title: "Third", Url: "https://example.test/third"}
+			if err := store.ReplaceBookmarks([]config.Bookmarks{third, first, second}); err != nil {
+				t.Fatalf("ReplaceBookmarks: %v", err)
+			}
+			if got := storeTitles(t, store); !reflect.DeepEqual(got, []string{"Third", "First Renamed", "Second"}) {
+				t.Fatalf("after replace = %v", got)
+			}
+		})
+	}
+}
+
+func Test_Store_RunHistory(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	runs := []config.RunRecord{
+		{URL: "https://example.test/a", Title: "A", Site: "mgeko", Status: "completed", Started: base, Finished: base.Add(time.Minute)},
+		{URL: "https://example.test/b", Title: "B", Site: "mgeko", Status: "failed", Message: "Error: boom", Started: base.Add(time.Hour), Finished: base.Add(time.Hour + time.Minute)},
+		{URL: "https://example.test/a", Title: "A", Site: "mgeko", Status: "completed", Started: base.Add(2 * time.Hour), Finished: base.Add(2*time.Hour + time.Minute)},
+	}
+
+	for name, open := range openStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			for _, run := range runs {
+				if err := store.RecordRun(run); err != nil {
+					t.Fatalf("RecordRun: %v", err)
+				}
+			}
+
+			history, err := store.RunHistory("https://example.test/a", 0)
+			if err != nil {
+				t.Fatalf("RunHistory: %v", err)
+			}
+			if len(history) != 2 || !history[0].Started.Equal(runs[2].Started) || !history[1].Started.Equal(runs[0].Started) {
+				t.Fatalf("history of a = %+v, want its two runs newest first", history)
+			}
+
+			all, err := store.RunHistory("", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 2 || all[1].Title != "B" || all[1].Message != "Error: boom" || !all[1].Finished.Equal(runs[1].Finished) {
+				t.Fatalf("latest two runs = %+v", all)
+			}
+		})
+	}
+}
+
+func Test_Store_MigrateJSONToSQLite(t *testing.T) {
+	dir := t.TempDir()
+	jsonStore := config.NewJSONStore(dir)
+	bookmarks := []config.Bookmarks{
+		{Title: "B", Url: "https://example.test/b"},
+		{Title: "A", Url: "https://example.test/a", DelayNewChapterHours: 12},
+	}
+	if err := jsonStore.ReplaceBookmarks(bookmarks); err != nil {
+		t.Fatal(err)
+	}
+	run := config.RunRecord{URL: "https://example.test/a", Title: "A", Status: "completed", Started: time.Now(), Finished: time.Now()}
+	if err := jsonStore.RecordRun(run); err != nil {
+		t.Fatal(err)
+	}
+
+	db := openSQLite(t, filepath.Join(dir, config.SQLiteStoreName))
+	migrated, err := config.MigrateJSONToSQLite(jsonStore, db)
+	if err != nil {
+		t.Fatalf("MigrateJSONToSQLite: %v", err)
+	}
+	if migrated != len(bookmarks) {
+		t.Errorf("migrated %d bookmarks, want %d", migrated, len(bookmarks))
+	}
+	got, err := db.ListBookmarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, bookmarks) {
+		t.Fatalf("migrated bookmarks = %+v", got)
+	}
+	if history, _ := db.RunHistory("", 0); len(history) != 1 {
+		t.Errorf("migrated history = %+v", history)
+	}
+
+	// Only once, later JSON changes do not overwrite the database
+	jsonStore.ReplaceBookmarks(nil)
+	if migrated, err := config.MigrateJSONToSQLite(jsonStore, db); err != nil || migrated != 0 {
+		t.Fatalf("second migration = %d, %v", migrated, err)
+	}
+	if titles := storeTitles(t, db); len(titles) != len(bookmarks) {
+		t.Errorf("database changed by second migration: %v", titles)
+	}
+}
+
+func Test_JSONStore_ReadsBookmarksFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	want := []config.Bookmarks{{Title: "Saved", Url: "https://example.test/saved", Site: "mgeko"}}
+	if err := config.SaveBookmarks(config.Manga{Manga: want}); err != nil {
+		t.Fatalf("SaveBookmarks: %v", err)
+	}
+
+	got, err := config.NewJSONStore(filepath.Join(home, ".config", "kansho")).ListBookmarks()
+	if err != nil {
+		t.Fatalf("ListBookmarks: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("JSON store read %+v, want what SaveBookmarks wrote", got)
+	}
+}
+
+func Test_Store_SQLiteSetting(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kansho")
+
+	legacy := []config.Bookmarks{{Title: "Legacy", Url: "https://example.test/legacy"}}
+	if err := config.SaveBookmarks(config.Manga{Manga: legacy}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "settings.json"), []byte(`{"storage": "sqlite"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first load migrates bookmarks.json into the database
+	if got := config.LoadBookmarks().Manga; !reflect.DeepEqual(got, legacy) {
+		t.Fatalf("LoadBookmarks = %+v, want the migrated bookmarks", got)
+	}
+
+	added := append(legacy, config.Bookmarks{Title: "Added", Url: "https://example.test/added"})
+	if err := config.SaveBookmarks(config.Manga{Manga: added}); err != nil {
+		t.Fatalf("SaveBookmarks: %v", err)
+	}
+	if got := config.LoadBookmarks().Manga; !reflect.DeepEqual(got, added) {
+		t.Fatalf("LoadBookmarks = %+v after save", got)
+	}
+
+	// bookmarks.json stays as it was before the switch
+	if got := storeTitles(t, config.NewJSONStore(configDir)); !reflect.DeepEqual(got, []string{"Legacy"}) {
+		t.Errorf("bookmarks.json = %v, want it left untouched", got)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, config.SQLiteStoreName)); err != nil {
+		t.Errorf("database not created: %v", err)
+	}
+}
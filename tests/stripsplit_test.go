@@ -0,0 +1,183 @@
+package integration
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kansho/parser"
+)
+
+// writeStrip writes a width x height PNG where each row is coloured by rowColor.
+// Non-white rows get a black line art stripe on the left so they read as panel
+// content rather than gutter.
+func writeStrip(t *testing.T, path string, width, height int, rowColor func(y int) color.Color) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		c := rowColor(y)
+		for x := 0; x < width; x++ {
+			if x < 10 && c != color.White {
+				img.Set(x, y, color.Black)
+				continue
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create %s: %v", path, err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("encode %s: %v", path, err)
+	}
+}
+
+func decodePage(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	return img
+}
+
+func Test_SplitTallPages_CutsAtGutters(t *testing.T) {
+	dir := t.TempDir()
+
+	red := color.RGBA{200, 0, 0, 255}
+	green := color.RGBA{0, 200, 0, 255}
+	blue := color.RGBA{0, 0, 200, 255}
+
+	// Three panels separated by white gutters at rows 800-819 and 1700-1719
+	writePNG(t, filepath.Join(dir, "001.png"), 100, 150)
+	writeStrip(t, filepath.Join(dir, "002.png"), 100, 2500, func(y int) color.Color {
+		switch {
+		case y < 800:
+			return red
+		case y < 820:
+			return color.White
+		case y < 1700:
+			return green
+		case y < 1720:
+			return color.White
+		default:
+			return blue
+		}
+	})
+	writePNG(t, filepath.Join(dir, "003.png"), 100, 150)
+
+	pages, err := parser.SplitTallPages(dir, 1000)
+	if err != nil {
+		t.Fatalf("SplitTallPages: %v", err)
+	}
+	if pages != 5 {
+		t.Fatalf("expected 5 pages, got %d", pages)
+	}
+
+	want := []struct {
+		height int
+		mid    color.Color
+	}{
+		{150, nil},
+		{819, red},
+		{900, green},
+		{781, blue},
+		{150, nil},
+	}
+	for i, w := range want {
+		name := filepath.Join(dir, []string{"001.png", "002.png", "003.png", "004.png", "005.png"}[i])
+		img := decodePage(t, name)
+		if got := img.Bounds().Dy(); got != w.height {
+			t.Errorf("%s: expected height %d, got %d", filepath.Base(name), w.height, got)
+		}
+		if w.mid == nil {
+			continue
+		}
+		r, g, b, _ := img.At(50, img.Bounds().Dy()/2).RGBA()
+		wr, wg, wb, _ := w.mid.RGBA()
+		if r != wr || g != wg || b != wb {
+			t.Errorf("%s: slice out of order, middle pixel %v %v %v", filepath.Base(name), r>>8, g>>8, b>>8)
+		}
+	}
+}
+
+func Test_SplitTallPages_FixedHeightFallback(t *testing.T) {
+	dir := t.TempDir()
+
+	// Every row has panel content, there is no gutter to cut at
+	writeStrip(t, filepath.Join(dir, "001.png"), 100, 2500, func(y int) color.Color {
+		return color.Gray{uint8(100 + y%100)}
+	})
+
+	pages, err := parser.SplitTallPages(dir, 1000)
+	if err != nil {
+		t.Fatalf("SplitTallPages: %v", err)
+	}
+	if pages != 3 {
+		t.Fatalf("expected 3 pages, got %d", pages)
+	}
+	for i, height := range []int{1000, 1000, 500} {
+		name := filepath.Join(dir, []string{"001.png", "002.png", "003.png"}[i])
+		if got := decodePage(t, name).Bounds().Dy(); got != height {
+			t.Errorf("%s: expected height %d, got %d", filepath.Base(name), height, got)
+		}
+	}
+}
+
+func Test_SplitTallPages_Disabled(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "page.png"), 100, 5000)
+
+	if _, err := parser.SplitTallPages(dir, 0); err != nil {
+		t.Fatalf("SplitTallPages: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "page.png")); err != nil {
+		t.Fatalf("page should be untouched when disabled: %v", err)
+	}
+}
+
+func Test_SplitTallPages_KeepsPNGSlicesWithOptimizeJPEG(t *testing.T) {
+	dir := t.TempDir()
+	parser.SetOptimizeJPEG(true)
+	defer parser.SetOptimizeJPEG(false)
+
+	writeStrip(t, filepath.Join(dir, "001.png"), 100, 500, func(y int) color.Color {
+		if y%100 < 5 {
+			return color.White
+		}
+		return color.RGBA{0, 0, 200, 255}
+	})
+	pages, err := parser.SplitTallPages(dir, 200)
+	if err != nil {
+		t.Fatalf("SplitTallPages: %v", err)
+	}
+	if pages < 2 {
+		t.Fatalf("split into %d pages, want several", pages)
+	}
+
+	// The optimized JPEG encoder is for JPEG slices, a .png slice stays a PNG
+	for i := 1; i <= pages; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%03d.png", i))
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("slice %d: %v", i, err)
+		}
+		_, format, err := image.DecodeConfig(f)
+		f.Close()
+		if err != nil || format != "png" {
+			t.Errorf("%s decodes as %q (%v), want png", filepath.Base(path), format, err)
+		}
+	}
+}
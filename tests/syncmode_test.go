@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"kansho/config"
+	"kansho/downloader"
+	"kansho/parser"
+)
+
+func Test_Manager_SyncModes(t *testing.T) {
+	var imageHits atomic.Int32
+	served := encodePNG(t, 30, 40)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		imageHits.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(served)
+	}))
+	defer server.Close()
+
+	site := &resumeSite{imageBase: server.URL}
+
+	for _, tc := range []struct {
+		mode      string
+		wantHits  int32
+		wantPages int
+	}{
+		{"", 0, 2},
+		{config.SyncModeFull, 3, 3},
+	} {
+		manga := &config.Bookmarks{
+			Title:    "Sync Mode Test " + tc.mode,
+			Url:      server.URL + "/series",
+			Location: t.TempDir(),
+			Site:     site.GetSiteName(),
+			SyncMode: tc.mode,
+		}
+		tempDir := downloader.ChapterTempDir(site.GetSiteName(), manga, "ch001.cbz")
+		t.Cleanup(func() { os.RemoveAll(filepath.Dir(tempDir)) })
+
+		// The local copy of ch001 predates the source re-uploading it with an extra page
+		pagesDir := t.TempDir()
+		for _, name := range []string{"001.png", "002.png"} {
+			writePNG(t, filepath.Join(pagesDir, name), 10, 20)
+		}
+		cbzPath := filepath.Join(manga.Location, "ch001.cbz")
+		if err := parser.CreateCbzFromDir(pagesDir, cbzPath); err != nil {
+			t.Fatal(err)
+		}
+
+		imageHits.Store(0)
+		manager := downloader.NewManager(&downloader.DownloadConfig{Manga: manga, Site: site})
+		if err := manager.Download(context.Background()); err != nil {
+			t.Fatalf("mode %q: Download: %v", tc.mode, err)
+		}
+
+		if got := imageHits.Load(); got != tc.wantHits {
+			t.Errorf("mode %q: expected %d image downloads, got %d", tc.mode, tc.wantHits, got)
+		}
+		pages, err := parser.CbzPageCount(cbzPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pages != tc.wantPages {
+			t.Errorf("mode %q: expected %d pages in ch001.cbz, got %d", tc.mode, tc.wantPages, pages)
+		}
+	}
+}
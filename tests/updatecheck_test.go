@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kansho/config"
+)
+
+// writeChaptersWithSpacing creates count chapter files whose modification times are
+// spaced interval apart, the newest one being latestAge old.
+func writeChaptersWithSpacing(t *testing.T, count int, interval, latestAge time.Duration) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	latest := time.Now().Add(-latestAge)
+
+	for i := 1; i <= count; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("ch%03d.cbz", i))
+		if err := os.WriteFile(path, []byte("cbz"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+		modTime := latest.Add(-time.Duration(count-i) * interval)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to set mtime on %s: %v", path, err)
+		}
+	}
+	return dir
+}
+
+func Test_LikelyHasUpdate_Cadence(t *testing.T) {
+	week := 7 * 24 * time.Hour
+
+	cases := []struct {
+		name      string
+		count     int
+		interval  time.Duration
+		latestAge time.Duration
+		want      bool
+	}{
+		{"weekly series past due", 6, week, 8 * 24 * time.Hour, true},
+		{"weekly series recently updated", 6, week, 24 * time.Hour, false},
+		{"weekly series dormant for years", 6, week, 2 * 365 * 24 * time.Hour, false},
+		{"bulk downloaded series has no cadence", 6, time.Second, 24 * time.Hour, true},
+		{"single chapter has no cadence", 1, week, 24 * time.Hour, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			manga := &config.Bookmarks{
+				Title:    tc.name,
+				Location: writeChaptersWithSpacing(t, tc.count, tc.interval, tc.latestAge),
+			}
+			if got := config.LikelyHasUpdate(manga); got != tc.want {
+				t.Fatalf("LikelyHasUpdate() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
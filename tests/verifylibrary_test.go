@@ -0,0 +1,100 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"kansho/parser"
+)
+
+func TestVerifyLibrary_FlagsDamagedChapters(t *testing.T) {
+	dir := t.TempDir()
+	page := encodePNG(t, 10, 10)
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, image.NewRGBA(image.Rect(0, 0, 10, 10)), nil); err != nil {
+		t.Fatal(err)
+	}
+	pages := func(n int) []cbzEntry {
+		out := make([]cbzEntry, n)
+		for i := range out {
+			out[i] = cbzEntry{fmt.Sprintf("%03d.img", i+1), page}
+		}
+		return out
+	}
+
+	for n := 1; n <= 4; n++ {
+		writeStoredCbz(t, filepath.Join(dir, fmt.Sprintf("ch00%d.cbz", n)), pages(8))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ch005.cbz"), []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	damaged := append(pages(5),
+		cbzEntry{"006.img", []byte{}},
+		cbzEntry{"007.img", page[:len(page)/2]},
+		cbzEntry{"008.img", jpg.Bytes()[:jpg.Len()-200]},
+	)
+	writeStoredCbz(t, filepath.Join(dir, "ch006.cbz"), damaged)
+	writeStoredCbz(t, filepath.Join(dir, "ch007.cbz"), pages(2))
+	writeStoredCbz(t, filepath.Join(dir, "ch008.cbz"), pages(8))
+	var manifest []string
+	for i := 0; i < 9; i++ {
+		manifest = append(manifest, fmt.Sprintf("https://example.com/%d.png", i))
+	}
+	if err := parser.WriteImageManifest(filepath.Join(dir, "ch008.cbz"), manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := parser.VerifyLibrary(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("VerifyLibrary: %v", err)
+	}
+	if report.Chapters != 8 {
+		t.Errorf("checked %d chapters, want 8", report.Chapters)
+	}
+	// Only ch008 has a manifest, the corrupted ch005 has no page count to compare
+	if report.Unlisted != 6 {
+		t.Errorf("%d chapters without a manifest, want 6", report.Unlisted)
+	}
+
+	got := map[string][]string{}
+	for _, issue := range report.Issues {
+		got[issue.Chapter] = append(got[issue.Chapter], issue.Problem)
+	}
+	if problems := got["ch005.cbz"]; len(problems) != 1 {
+		t.Errorf("ch005.cbz problems = %q, want the corrupted archive", problems)
+	}
+	delete(got, "ch005.cbz")
+	want := map[string][]string{
+		"ch006.cbz": {"page 006.img is empty (zero bytes)", "page 007.img is a truncated PNG", "page 008.img is a truncated JPEG"},
+		"ch007.cbz": {"has only 2 pages where the other chapters have 8"},
+		"ch008.cbz": {"has 8 of the 9 pages the site listed"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issues = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyLibrary_ExpandsHomeLocation(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	library := filepath.Join(home, "manga", "Series")
+	if err := os.MkdirAll(library, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeStoredCbz(t, filepath.Join(library, "ch001.cbz"), []cbzEntry{{"001.img", encodePNG(t, 10, 10)}})
+
+	report, err := parser.VerifyLibrary(context.Background(), "~/manga/Series")
+	if err != nil {
+		t.Fatalf("VerifyLibrary: %v", err)
+	}
+	if report.Chapters != 1 || len(report.Issues) != 0 {
+		t.Errorf("report = %+v, want one sound chapter", report)
+	}
+}
@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"kansho/parser"
+)
+
+// 1x1 WebP images, one per coding: VP8L, VP8 and VP8 with an ALPH chunk in a VP8X file
+const (
+	losslessWebP   = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+	lossyWebP      = "UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA"
+	lossyAlphaWebP = "UklGRkoAAABXRUJQVlA4WAoAAAAQAAAAAAAAAAAAQUxQSAwAAAARBxAR/Q9ERP8DAABWUDggGAAAABQBAJ0BKgEAAQAAAP4AAA3AAP7mtQAAAA=="
+)
+
+func decodeWebP(t *testing.T, b64 string) []byte {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// extendedLosslessWebP wraps the lossless image in a VP8X file, the layout encoders
+// use once there is metadata or alpha
+func extendedLosslessWebP(t *testing.T) []byte {
+	image := decodeWebP(t, losslessWebP)[12:] // the VP8L chunk
+
+	vp8x := make([]byte, 18)
+	copy(vp8x, "VP8X")
+	binary.LittleEndian.PutUint32(vp8x[4:], 10)
+	vp8x[8] = 0x10 // alpha flag
+
+	body := append(append([]byte("WEBP"), vp8x...), image...)
+	riff := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	return append(riff, body...)
+}
+
+func TestIsLosslessWebP(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"lossless", decodeWebP(t, losslessWebP), true},
+		{"extended lossless", extendedLosslessWebP(t), true},
+		{"lossy", decodeWebP(t, lossyWebP), false},
+		{"lossy with alpha", decodeWebP(t, lossyAlphaWebP), false},
+		{"png", encodePNG(t, 1, 1), false},
+		{"truncated", decodeWebP(t, losslessWebP)[:14], false},
+	}
+	for _, tt := range tests {
+		if got := parser.IsLosslessWebP(tt.data); got != tt.want {
+			t.Errorf("IsLosslessWebP(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSaveImage_KeepsLosslessWebPLossless(t *testing.T) {
+	t.Cleanup(func() { parser.SetKeepLosslessWebP(false) })
+
+	tests := []struct {
+		name string
+		data []byte
+		keep bool
+		want string
+	}{
+		{"lossless, toggle off", decodeWebP(t, losslessWebP), false, "001.jpg"},
+		{"lossless, toggle on", decodeWebP(t, losslessWebP), true, "001.png"},
+		{"lossy, toggle on", decodeWebP(t, lossyWebP), true, "001.jpg"},
+		{"lossy with alpha, toggle on", decodeWebP(t, lossyAlphaWebP), true, "001.jpg"},
+	}
+	for _, tt := range tests {
+		parser.SetKeepLosslessWebP(tt.keep)
+		dir := t.TempDir()
+		if err := parser.SaveImage(tt.data, dir, "1", false); err != nil {
+			t.Errorf("%s: SaveImage: %v", tt.name, err)
+			continue
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 || entries[0].Name() != tt.want {
+			t.Errorf("%s: saved %v, want %s", tt.name, entries, tt.want)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package ui
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -34,9 +33,9 @@ func ShowImportBookmarksDialog(kanshoApp fyne.App, window fyne.Window) {
 			return
 		}
 
-		// Validate JSON
-		var importedData config.Manga
-		if err := json.Unmarshal(fileContent, &importedData); err != nil {
+		// Validate JSON, malformed entries are skipped so the rest can still be imported
+		importedData, unreadable, err := config.DecodeBookmarks(fileContent)
+		if err != nil && len(importedData.Manga) == 0 {
 			dialog.ShowError(fmt.Errorf("invalid JSON file: %v", err), window)
 			return
 		}
@@ -57,10 +56,12 @@ func ShowImportBookmarksDialog(kanshoApp fyne.App, window fyne.Window) {
 		summaryMsg := fmt.Sprintf(
 			"Import completed!\n\n"+
 				"Total in imported file: %d\n"+
+				"Unreadable entries skipped: %d\n"+
 				"Exact duplicates skipped: %d\n"+
 				"Partial duplicates (renamed): %d\n"+
 				"New bookmarks added: %d",
-			len(importedData.Manga),
+			len(importedData.Manga)+unreadable,
+			unreadable,
 			result.ExactDuplicates,
 			result.PartialDuplicates,
 			result.NewBookmarks,
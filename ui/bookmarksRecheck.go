@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"kansho/config"
+)
+
+// ShowRecheckAllDialog queues a download for every bookmarked series that is
+// likely to have a new chapter. When force is true every series is queued
+// regardless of its release cadence.
+func ShowRecheckAllDialog(window fyne.Window, force bool) {
+	bookmarks := config.LoadBookmarks()
+	if len(bookmarks.Manga) == 0 {
+		dialog.ShowInformation("Recheck All", "No bookmarks to recheck.", window)
+		return
+	}
+
+	go func() {
+		queued, skipped := config.GetDownloadQueue().QueueLikelyUpdates(bookmarks.Manga, force)
+		log.Printf("[UI] Recheck all finished: %d queued, %d skipped", queued, skipped)
+
+		fyne.Do(func() {
+			dialog.ShowInformation(
+				"Recheck All",
+				fmt.Sprintf("Queued %d series for download.\nSkipped %d series (not due, dormant, completed or already queued).", queued, skipped),
+				window,
+			)
+		})
+	}()
+}
@@ -0,0 +1,340 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"kansho/config"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// seriesRow is one bookmark in the Series tab of the bookmarks window, with its own
+// update and cancel buttons and the progress of its queue task
+type seriesRow struct {
+	manga        config.Bookmarks
+	taskID       string
+	statusLabel  *widget.Label
+	progressBar  *widget.ProgressBar
+	updateButton *widget.Button
+	cancelButton *widget.Button
+
+	// availability is compared with the filter, box is the row hidden by it
+	availability      config.ChapterAvailability
+	availabilityLabel *widget.Label
+	box               *fyne.Container
+}
+
+// seriesFilters are the Series tab filter options, by the availability they show
+var seriesFilters = []string{"All", "Behind", "Up to date", "Unknown"}
+
+// allTagsFilter is the tag filter option showing series with any tags or none
+const allTagsFilter = "All tags"
+
+// newSeriesUpdatePanel lists every bookmark with buttons to update that one series
+// straight away or cancel its download, and how far behind the site it is. Rows
+// follow the queue until the returned func is called.
+func newSeriesUpdatePanel(window fyne.Window) (fyne.CanvasObject, func()) {
+	queue := config.GetDownloadQueue()
+	bookmarks := config.LoadBookmarks().Manga
+	if len(bookmarks) == 0 {
+		return widget.NewLabel("No bookmarks yet"), func() {}
+	}
+
+	rows := make(map[string]*seriesRow, len(bookmarks))
+	list := container.NewVBox()
+	filter := seriesFilters[0]
+	tagFilter := allTagsFilter
+	applyFilter := func() {
+		for _, row := range rows {
+			availabilityShown := filter == seriesFilters[0] || filter == seriesFilterFor(row.availability)
+			tagShown := tagFilter == allTagsFilter || row.manga.HasTag(tagFilter)
+			if availabilityShown && tagShown {
+				row.box.Show()
+			} else {
+				row.box.Hide()
+			}
+		}
+	}
+
+	for _, manga := range bookmarks {
+		row := &seriesRow{manga: manga}
+
+		titleLabel := widget.NewLabel(manga.Title)
+		titleLabel.TextStyle.Bold = true
+		titleLabel.Truncation = fyne.TextTruncateEllipsis
+
+		// Only counts already known are shown on open, the site is checked on request
+		row.availabilityLabel = widget.NewLabel("")
+		row.showAvailability(config.SeriesAvailability(manga))
+
+		row.statusLabel = widget.NewLabel("")
+		row.statusLabel.Truncation = fyne.TextTruncateEllipsis
+
+		row.progressBar = widget.NewProgressBar()
+		row.progressBar.Min = 0
+		row.progressBar.Max = 1
+
+		row.updateButton = widget.NewButton("Update", func() {
+			row.onUpdate(queue, window)
+		})
+		row.cancelButton = widget.NewButton("Cancel", func() {
+			row.onCancel(queue, window)
+		})
+
+		snapshot, ok := queue.TaskSnapshotForManga(manga.Title)
+		row.show(snapshot, ok)
+
+		rows[manga.Title] = row
+		details := container.NewVBox(titleLabel)
+		if len(manga.Tags) > 0 {
+			tagsLabel := widget.NewLabel("Tags: " + strings.Join(manga.Tags, ", "))
+			tagsLabel.Truncation = fyne.TextTruncateEllipsis
+			details.Add(tagsLabel)
+		}
+		details.Add(row.statusLabel)
+		details.Add(row.progressBar)
+
+		row.box = container.NewVBox(
+			container.NewBorder(
+				nil, nil, nil,
+				container.NewHBox(row.availabilityLabel, row.updateButton, row.cancelButton),
+				details,
+			),
+			NewSeparator(),
+		)
+		list.Add(row.box)
+	}
+
+	refresh := func(title string) {
+		row := rows[title]
+		if row == nil {
+			return
+		}
+		snapshot, ok := queue.TaskSnapshotForManga(title)
+		row.show(snapshot, ok)
+
+		// A finished run has recorded the site's chapter count and changed the library
+		if ok && snapshot.Status != "queued" && snapshot.Status != "downloading" {
+			row.showAvailability(config.SeriesAvailability(row.manga))
+			applyFilter()
+		}
+	}
+
+	unsubscribe := queue.Subscribe(config.QueueListener{
+		OnTaskAdded: func(task *config.DownloadTask) {
+			title := task.Manga.Title
+			fyne.Do(func() { refresh(title) })
+		},
+		OnTaskUpdated: func(task *config.DownloadTask) {
+			title := task.Manga.Title
+			fyne.Do(func() { refresh(title) })
+		},
+		OnTaskRemoved: func(taskID string) {
+			fyne.Do(func() {
+				for title, row := range rows {
+					if row.taskID == taskID {
+						refresh(title)
+					}
+				}
+			})
+		},
+	})
+
+	filterSelect := widget.NewSelect(seriesFilters, func(selected string) {
+		filter = selected
+		applyFilter()
+	})
+	filterSelect.SetSelected(filter)
+
+	// Download Tagged queues every series with the selected tag, like Recheck All
+	// does for the whole library
+	downloadTaggedButton := widget.NewButton("Download Tagged", func() {
+		tag := tagFilter
+		go func() {
+			queued, skipped := queue.QueueTagged(bookmarks, tag)
+			log.Printf("[UI] Download tagged %q: %d queued, %d skipped", tag, queued, skipped)
+			fyne.Do(func() {
+				dialog.ShowInformation("Download Tagged",
+					fmt.Sprintf("Queued %d series tagged \"%s\" for download.\nSkipped %d series (completed or already queued).", queued, tag, skipped),
+					window)
+			})
+		}()
+	})
+	downloadTaggedButton.Disable()
+
+	tagSelect := widget.NewSelect(append([]string{allTagsFilter}, config.AllTags(bookmarks)...), func(selected string) {
+		tagFilter = selected
+		if tagFilter == allTagsFilter {
+			downloadTaggedButton.Disable()
+		} else {
+			downloadTaggedButton.Enable()
+		}
+		applyFilter()
+	})
+	tagSelect.SetSelected(tagFilter)
+
+	// The checks run one series at a time and stop when the window closes
+	checkCtx, stopChecks := context.WithCancel(context.Background())
+	checkStatus := widget.NewLabel("")
+	var checkButton *widget.Button
+	checkButton = widget.NewButton("Check for New Chapters", func() {
+		checkButton.Disable()
+		go func() {
+			for i, manga := range bookmarks {
+				if checkCtx.Err() != nil {
+					return
+				}
+				fyne.Do(func() {
+					checkStatus.SetText(fmt.Sprintf("Checking %d/%d: %s", i+1, len(bookmarks), manga.Title))
+				})
+				availability, err := config.CheckRemoteChapters(checkCtx, manga)
+				if err != nil {
+					log.Printf("[UI] Checking '%s' for new chapters failed: %v", manga.Title, err)
+				}
+				fyne.Do(func() {
+					if row := rows[manga.Title]; row != nil {
+						row.showAvailability(availability)
+						applyFilter()
+					}
+				})
+			}
+			fyne.Do(func() {
+				checkStatus.SetText("")
+				checkButton.Enable()
+			})
+		}()
+	})
+
+	// Verify Library reads every chapter on disk, it shares the status line and
+	// stops with the checks when the window closes
+	var verifyButton *widget.Button
+	verifyButton = widget.NewButton("Verify Library", func() {
+		verifyButton.Disable()
+		go func() {
+			report := verifyLibrary(checkCtx, bookmarks, func(i int, manga config.Bookmarks) {
+				fyne.Do(func() {
+					checkStatus.SetText(fmt.Sprintf("Verifying %d/%d: %s", i+1, len(bookmarks), manga.Title))
+				})
+			})
+			if checkCtx.Err() != nil {
+				return
+			}
+			fyne.Do(func() {
+				checkStatus.SetText("")
+				verifyButton.Enable()
+				showVerifyReport(report, window)
+			})
+		}()
+	})
+
+	toolbar := container.NewBorder(nil, nil,
+		container.NewHBox(widget.NewLabel("Show"), filterSelect, tagSelect, downloadTaggedButton),
+		container.NewHBox(verifyButton, checkButton),
+		checkStatus,
+	)
+
+	return container.NewBorder(toolbar, nil, nil, nil, container.NewVScroll(list)), func() {
+		stopChecks()
+		unsubscribe()
+	}
+}
+
+// seriesFilterFor is the filter option showing a series with availability a
+func seriesFilterFor(a config.ChapterAvailability) string {
+	switch a.State {
+	case config.AvailabilityBehind:
+		return "Behind"
+	case config.AvailabilityUpToDate:
+		return "Up to date"
+	default:
+		return "Unknown"
+	}
+}
+
+// showAvailability shows how the series compares with its site
+func (r *seriesRow) showAvailability(a config.ChapterAvailability) {
+	r.availability = a
+	r.availabilityLabel.SetText(a.Label())
+	if a.State == config.AvailabilityBehind {
+		r.availabilityLabel.Importance = widget.WarningImportance
+	} else {
+		r.availabilityLabel.Importance = widget.MediumImportance
+	}
+	r.availabilityLabel.Refresh()
+}
+
+// show brings the row in line with its task, ok is false when the series has no
+// task in the queue
+func (r *seriesRow) show(task config.DownloadTask, ok bool) {
+	if !ok {
+		r.taskID = ""
+		r.statusLabel.SetText("Not in the download queue")
+		r.progressBar.SetValue(0)
+		r.progressBar.Hide()
+		r.updateButton.Enable()
+		r.cancelButton.Disable()
+		return
+	}
+
+	r.taskID = task.ID
+	message := task.StatusMessage
+	if task.Status == "failed" && task.Error != nil {
+		message = FriendlyError(task.Error)
+	}
+	r.statusLabel.SetText(fmt.Sprintf("%s %s", taskStatusIcon(task.Status), message))
+	r.progressBar.SetValue(task.Progress)
+	r.progressBar.Show()
+
+	switch task.Status {
+	case "queued", "downloading":
+		r.updateButton.Disable()
+		r.cancelButton.Enable()
+	case "waiting_cf", "waiting_confirm":
+		// Answered from the download queue's dialogs
+		r.updateButton.Disable()
+		r.cancelButton.Disable()
+	default:
+		// A cancelled download still unwinding keeps its CancelFunc until it returns
+		r.updateButton.Enable()
+		if task.CancelFunc != nil {
+			r.updateButton.Disable()
+		}
+		r.cancelButton.Disable()
+	}
+}
+
+// onUpdate queues the series ahead of the rest of the queue. A finished earlier run
+// of the same series is cleared out of the way first.
+func (r *seriesRow) onUpdate(queue *config.DownloadQueue, window fyne.Window) {
+	if r.taskID != "" {
+		if err := queue.RemoveFinishedTask(r.taskID); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+	}
+
+	manga := r.manga
+	task, err := queue.AddPriorityTask(&manga)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	log.Printf("[UI] Updating '%s' from the bookmarks window (ID: %s)", manga.Title, task.ID)
+}
+
+func (r *seriesRow) onCancel(queue *config.DownloadQueue, window fyne.Window) {
+	if r.taskID == "" {
+		return
+	}
+	if err := queue.CancelTask(r.taskID); err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	log.Printf("[UI] Cancelled '%s' from the bookmarks window", r.manga.Title)
+}
@@ -159,7 +159,15 @@ func ShowBookmarksWindow(kanshoApp fyne.App) {
 	scroll := container.NewScroll(bookmarksLabel)
 
 	content := container.NewBorder(searchBox, nil, nil, nil, scroll)
-	bookmarksWindow.SetContent(content)
+
+	seriesPanel, unsubscribe := newSeriesUpdatePanel(bookmarksWindow)
+	bookmarksWindow.SetOnClosed(unsubscribe)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Series", seriesPanel),
+		container.NewTabItem("bookmarks.json", content),
+	)
+	bookmarksWindow.SetContent(tabs)
 	bookmarksWindow.Show()
 
 	go func() {
@@ -170,8 +170,12 @@ func ShowConfigWindow(kanshoApp fyne.App) {
 		clearSearch()
 	})
 
+	enabledSitesButton := widget.NewButton("Enabled Sites...", func() {
+		showEnabledSitesDialog(configWindow)
+	})
+
 	searchBox := container.NewBorder(nil, nil, nil,
-		container.NewHBox(searchButton, clearButton),
+		container.NewHBox(searchButton, clearButton, enabledSitesButton),
 		searchEntry)
 
 	scroll := container.NewScroll(configLabel)
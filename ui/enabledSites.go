@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"kansho/config"
+	"kansho/sites"
+)
+
+// showEnabledSitesDialog lets the user pick the sites offered in the Add Manga site
+// dropdown. Ticking every site saves an empty list, so sites added later show up too.
+func showEnabledSitesDialog(window fyne.Window) {
+	sitesConfig := sites.LoadSitesConfig()
+
+	displayNames := make([]string, len(sitesConfig.Sites))
+	nameByDisplay := make(map[string]string, len(sitesConfig.Sites))
+	for i, site := range sitesConfig.Sites {
+		displayNames[i] = site.DisplayName
+		nameByDisplay[site.DisplayName] = site.Name
+	}
+
+	checks := widget.NewCheckGroup(displayNames, nil)
+	checks.SetSelected(SiteDropdownOptions(sitesConfig, config.LoadSettings().EnabledSites, ""))
+
+	allButton := widget.NewButton("All", func() { checks.SetSelected(displayNames) })
+	noneButton := widget.NewButton("None", func() { checks.SetSelected(nil) })
+
+	list := container.NewVScroll(checks)
+	list.SetMinSize(fyne.NewSize(320, 360))
+	content := container.NewBorder(
+		widget.NewLabel("Sites offered when adding manga.\nBookmarks of other sites keep working."),
+		container.NewHBox(allButton, noneButton),
+		nil,
+		nil,
+		list,
+	)
+
+	dialog.ShowCustomConfirm("Enabled Sites", "Save", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if len(checks.Selected) == 0 {
+			dialog.ShowError(fmt.Errorf("enable at least one site"), window)
+			return
+		}
+
+		var enabled []string
+		if len(checks.Selected) < len(displayNames) {
+			for _, display := range checks.Selected {
+				enabled = append(enabled, nameByDisplay[display])
+			}
+		}
+		if err := config.UpdateSettings(func(settings *config.Settings) {
+			settings.EnabledSites = enabled
+		}); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		log.Printf("[UI] Enabled sites set to %v (empty = all)", enabled)
+	}, window)
+}
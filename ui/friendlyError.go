@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+
+	"kansho/cf"
+	"kansho/config"
+	"kansho/downloader"
+)
+
+// FriendlyError turns a download error into a message the user can act on. The
+// full technical error is already in the log, so only the cause and the next step
+// are shown here. Errors with no known cause fall back to their own text.
+func FriendlyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var cfErr *cf.CfChallengeError
+	var incompleteErr *config.IncompleteDownloadError
+	var libraryErr *config.LibraryUnavailableError
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	var urlErr *url.Error
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "The download was cancelled."
+
+	case errors.As(err, &cfErr):
+		return "Cloudflare is blocking the site. Complete the challenge in the browser window that opened, then retry."
+
+	case errors.Is(err, downloader.ErrDiskFull), errors.Is(err, syscall.ENOSPC):
+		return "The disk is full. Free up space in the library folder and retry."
+
+	case errors.As(err, &libraryErr):
+		return fmt.Sprintf("The library folder %s is not accessible. Check the drive is connected and the folder is writable, then retry.", libraryErr.Location)
+
+	case errors.As(err, &incompleteErr):
+		return incompleteErr.Summary.Message() + ". Retry to fetch the missing chapters."
+
+	case errors.Is(err, downloader.ErrNoChaptersFound):
+		return "No chapters were found. Check that the bookmark URL points to the series page, not a chapter."
+
+	case errors.Is(err, downloader.ErrSiteMaintenance):
+		return "The site is down for maintenance. Retry once it is back up."
+
+	case errors.Is(err, downloader.ErrSiteChanged):
+		return "The site's page layout has changed and it can no longer be read. Try again later or update Kansho."
+
+	case errors.Is(err, downloader.ErrNetwork), errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &opErr), errors.As(err, &dnsErr), errors.As(err, &urlErr):
+		return "Could not reach the site. Check your internet connection and retry."
+	}
+
+	return fmt.Sprintf("Download failed: %v", err)
+}
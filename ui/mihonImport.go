@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+
+	"kansho/config"
+)
+
+// maxUnsupportedListed caps the unsupported series named in the import summary
+const maxUnsupportedListed = 15
+
+// ShowImportMihonBackupDialog imports the library of a Tachiyomi/Mihon backup as
+// bookmarks. The user picks the backup, then the library directory the series
+// download into.
+func ShowImportMihonBackupDialog(window fyne.Window) {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("error opening file dialog: %v", err), window)
+			return
+		}
+		if reader == nil {
+			// User cancelled
+			return
+		}
+		backupPath := reader.URI().Path()
+		reader.Close()
+
+		chooseMihonLibraryRoot(backupPath, window)
+	}, window)
+
+	openDialog.SetFilter(storage.NewExtensionFileFilter([]string{".tachibk", ".gz", ".proto", ".json"}))
+	setHomeLocation(openDialog.SetLocation)
+
+	openDialog.Resize(fyne.NewSize(900, 700))
+	openDialog.Show()
+}
+
+// chooseMihonLibraryRoot asks for the library directory and runs the import
+func chooseMihonLibraryRoot(backupPath string, window fyne.Window) {
+	folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if uri == nil {
+			// User cancelled
+			return
+		}
+
+		imported, report, err := config.ImportMihonBackupWithReport(backupPath, uri.Path())
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+
+		currentBookmarks := config.LoadBookmarks()
+		result := processImportedBookmarks(&currentBookmarks, &config.Manga{Manga: imported})
+
+		if err := config.SaveBookmarks(currentBookmarks); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save bookmarks: %v", err), window)
+			return
+		}
+
+		dialog.ShowInformation("Import Summary", mihonImportSummary(report, result), window)
+	}, window)
+
+	setHomeLocation(folderDialog.SetLocation)
+
+	folderDialog.Resize(fyne.NewSize(900, 700))
+	folderDialog.Show()
+}
+
+// mihonImportSummary formats the import result, naming the unsupported series so
+// they can be added by hand
+func mihonImportSummary(report config.MihonImportReport, result ImportResult) string {
+	summary := fmt.Sprintf(
+		"Import completed!\n\n"+
+			"Series in backup: %d\n"+
+			"Unsupported sources skipped: %d\n"+
+			"Exact duplicates skipped: %d\n"+
+			"Partial duplicates (renamed): %d\n"+
+			"New bookmarks added: %d",
+		report.Total,
+		len(report.Unsupported),
+		result.ExactDuplicates,
+		result.PartialDuplicates,
+		result.NewBookmarks,
+	)
+
+	if len(report.Unsupported) == 0 {
+		return summary
+	}
+
+	listed := report.Unsupported
+	if len(listed) > maxUnsupportedListed {
+		listed = listed[:maxUnsupportedListed]
+	}
+	summary += "\n\nNot imported:\n" + strings.Join(listed, "\n")
+	if more := len(report.Unsupported) - len(listed); more > 0 {
+		summary += fmt.Sprintf("\n...and %d more (see logs)", more)
+	}
+	return summary
+}
+
+// setHomeLocation starts a file dialog in the user's home directory
+func setHomeLocation(setLocation func(fyne.ListableURI)) {
+	homePath, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	homeDir, err := storage.ListerForURI(storage.NewFileURI(homePath))
+	if err == nil {
+		setLocation(homeDir)
+	}
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"kansho/parser"
+)
+
+// ShowPackageFoldersDialog asks for a folder whose subfolders hold chapter images
+// (eg: the output of another downloader) and packages each of them into a CBZ
+// next to it
+func ShowPackageFoldersDialog(window fyne.Window) {
+	folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if uri == nil {
+			// User cancelled
+			return
+		}
+		confirmPackageFolders(uri.Path(), window)
+	}, window)
+
+	homePath, err := os.UserHomeDir()
+	if err == nil {
+		homeDir, err := storage.ListerForURI(storage.NewFileURI(homePath))
+		if err == nil {
+			folderDialog.SetLocation(homeDir)
+		}
+	}
+
+	folderDialog.Resize(fyne.NewSize(900, 700))
+	folderDialog.Show()
+}
+
+// confirmPackageFolders lets the user set the series name and page format, then
+// packages the folders off the UI goroutine
+func confirmPackageFolders(root string, window fyne.Window) {
+	seriesEntry := widget.NewEntry()
+	seriesEntry.SetText(filepath.Base(root))
+	nativeCheck := widget.NewCheck("Keep the original image format (no JPEG conversion)", nil)
+	overwriteCheck := widget.NewCheck("Replace CBZs that already exist", nil)
+
+	content := widget.NewForm(
+		widget.NewFormItem("Folder", widget.NewLabel(root)),
+		widget.NewFormItem("Series", seriesEntry),
+		widget.NewFormItem("", nativeCheck),
+		widget.NewFormItem("", overwriteCheck),
+	)
+
+	dialog.ShowCustomConfirm("Package Folders", "Package", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		opts := parser.PackageOptions{
+			Series:     seriesEntry.Text,
+			KeepNative: nativeCheck.Checked,
+			Overwrite:  overwriteCheck.Checked,
+		}
+		progress := dialog.NewCustomWithoutButtons("Package Folders",
+			widget.NewLabel(fmt.Sprintf("Packaging the folders in %s...", root)), window)
+		progress.Show()
+
+		go func() {
+			err := parser.PackageImageFolders(root, opts)
+			fyne.Do(func() {
+				progress.Hide()
+				if err != nil {
+					log.Printf("[Package] %s: %v", root, err)
+					dialog.ShowError(fmt.Errorf("packaging %s finished with errors:\n%v", root, err), window)
+					return
+				}
+				dialog.ShowInformation("Package Folders", fmt.Sprintf("The folders in %s were packaged into CBZs.", root), window)
+			})
+		}()
+	}, window)
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"kansho/config"
+	"kansho/parser"
+)
+
+// ShowFixPageOrderDialog asks for confirmation, then rewrites the chapters of manga
+// whose pages are out of order. The originals are kept as backups.
+func ShowFixPageOrderDialog(manga config.Bookmarks, window fyne.Window) {
+	message := fmt.Sprintf(
+		"Check every chapter of \"%s\" and rewrite the ones whose pages would be read out of order?\n\n"+
+			"Affected chapters get zero-padded page names. The original of each rewritten chapter "+
+			"is kept next to it as <chapter>.cbz%s.",
+		manga.Title, parser.PageOrderBackupSuffix)
+
+	dialog.ShowConfirm("Fix Page Order", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		progress := dialog.NewCustomWithoutButtons("Fix Page Order",
+			widget.NewLabel(fmt.Sprintf("Checking %s...", manga.Title)), window)
+		progress.Show()
+
+		go func() {
+			fixed, err := config.FixSeriesPageOrder(&manga)
+			fyne.Do(func() {
+				progress.Hide()
+				if err != nil {
+					log.Printf("[PageOrder] %s: %v", manga.Title, err)
+					dialog.ShowError(fmt.Errorf("page order check of %s finished with errors:\n%v", manga.Title, err), window)
+					return
+				}
+				if len(fixed) == 0 {
+					dialog.ShowInformation("Fix Page Order", fmt.Sprintf("All chapters of %s are already in order.", manga.Title), window)
+					return
+				}
+				dialog.ShowInformation("Fix Page Order",
+					fmt.Sprintf("Fixed %d chapters of %s:\n%s", len(fixed), manga.Title, strings.Join(fixed, "\n")), window)
+			})
+		}()
+	}, window)
+}
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"kansho/config"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// RunLogPanel shows the run log of one queued download and scrolls along as new
+// messages arrive. The global log window still has everything, this only follows
+// the series being watched.
+type RunLogPanel struct {
+	Container   fyne.CanvasObject
+	titleLabel  *widget.Label
+	list        *widget.List
+	lines       []string
+	runLog      *config.RunLog
+	unsubscribe func()
+}
+
+func NewRunLogPanel() *RunLogPanel {
+	panel := &RunLogPanel{}
+
+	panel.titleLabel = widget.NewLabel("Run log")
+	panel.titleLabel.TextStyle.Bold = true
+
+	panel.list = widget.NewList(
+		func() int {
+			return len(panel.lines)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("00:00:00  Run log message")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			if id < len(panel.lines) {
+				item.(*widget.Label).SetText(panel.lines[id])
+			}
+		},
+	)
+
+	panel.Container = container.NewBorder(panel.titleLabel, nil, nil, nil, panel.list)
+	return panel
+}
+
+// Follow switches the panel to runLog, replaying the lines it already holds. Following
+// the log already shown does nothing, a nil runLog clears the panel.
+func (p *RunLogPanel) Follow(runLog *config.RunLog, title string) {
+	if runLog == p.runLog {
+		return
+	}
+	p.Stop()
+	p.runLog = runLog
+
+	p.lines = p.lines[:0]
+	if runLog == nil {
+		p.titleLabel.SetText("Run log")
+		p.list.Refresh()
+		return
+	}
+
+	p.titleLabel.SetText("Run log - " + title)
+	for _, line := range runLog.Lines() {
+		p.lines = append(p.lines, line.String())
+	}
+	p.unsubscribe = runLog.Subscribe(func(line config.RunLogLine) {
+		fyne.Do(func() {
+			// Lines from a log we have since stopped following may still be queued
+			if p.runLog != runLog {
+				return
+			}
+			p.lines = append(p.lines, line.String())
+			if len(p.lines) > config.RunLogCapacity {
+				p.lines = p.lines[len(p.lines)-config.RunLogCapacity:]
+			}
+			p.list.Refresh()
+			p.list.ScrollToBottom()
+		})
+	})
+
+	p.list.Refresh()
+	p.list.ScrollToBottom()
+}
+
+// Stop unsubscribes from the log being followed, the lines stay on screen
+func (p *RunLogPanel) Stop() {
+	if p.unsubscribe != nil {
+		p.unsubscribe()
+		p.unsubscribe = nil
+	}
+}
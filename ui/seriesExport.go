@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"kansho/config"
+	"kansho/parser"
+)
+
+// ShowExportSeriesDialog asks for a destination folder and copies the chapters of
+// manga into it, for sideloading onto an e-reader
+func ShowExportSeriesDialog(manga config.Bookmarks, window fyne.Window) {
+	folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if uri == nil {
+			// User cancelled
+			return
+		}
+		confirmSeriesExport(manga, uri.Path(), window)
+	}, window)
+
+	homePath, err := os.UserHomeDir()
+	if err == nil {
+		homeDir, err := storage.ListerForURI(storage.NewFileURI(homePath))
+		if err == nil {
+			folderDialog.SetLocation(homeDir)
+		}
+	}
+
+	folderDialog.Resize(fyne.NewSize(900, 700))
+	folderDialog.Show()
+}
+
+// confirmSeriesExport lets the user choose whether the copies are renamed, then runs
+// the export off the UI goroutine
+func confirmSeriesExport(manga config.Bookmarks, destDir string, window fyne.Window) {
+	renameCheck := widget.NewCheck(fmt.Sprintf("Rename to \"%s <chapter>.cbz\"", manga.Title), nil)
+	renameCheck.SetChecked(true)
+
+	content := widget.NewForm(
+		widget.NewFormItem("Destination", widget.NewLabel(destDir)),
+		widget.NewFormItem("", renameCheck),
+	)
+
+	dialog.ShowCustomConfirm("Copy to Device", "Copy", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		rename := renameCheck.Checked
+		go func() {
+			err := config.ExportSeriesToDevice(&manga, destDir, rename)
+			fyne.Do(func() {
+				if err != nil {
+					log.Printf("[Export] %s: %v", manga.Title, err)
+					dialog.ShowError(fmt.Errorf("export of %s finished with errors:\n%v", manga.Title, err), window)
+					return
+				}
+				dialog.ShowInformation("Copy to Device", fmt.Sprintf("%s copied to %s", manga.Title, destDir), window)
+			})
+		}()
+	}, window)
+}
+
+// ShowArchiveSeriesDialog asks for a destination folder and writes recompressed
+// copies of the chapters of manga into it for long-term storage
+func ShowArchiveSeriesDialog(manga config.Bookmarks, window fyne.Window) {
+	folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if uri == nil {
+			// User cancelled
+			return
+		}
+
+		destDir := uri.Path()
+		progress := dialog.NewCustomWithoutButtons("Archive Series",
+			widget.NewLabel(fmt.Sprintf("Recompressing %s...", manga.Title)), window)
+		progress.Show()
+
+		go func() {
+			err := config.ArchiveSeries(&manga, destDir, parser.DefaultRepackLevel)
+			fyne.Do(func() {
+				progress.Hide()
+				if err != nil {
+					log.Printf("[Archive] %s: %v", manga.Title, err)
+					dialog.ShowError(fmt.Errorf("archive of %s finished with errors:\n%v", manga.Title, err), window)
+					return
+				}
+				dialog.ShowInformation("Archive Series", fmt.Sprintf("%s archived to %s", manga.Title, destDir), window)
+			})
+		}()
+	}, window)
+
+	homePath, err := os.UserHomeDir()
+	if err == nil {
+		homeDir, err := storage.ListerForURI(storage.NewFileURI(homePath))
+		if err == nil {
+			folderDialog.SetLocation(homeDir)
+		}
+	}
+
+	folderDialog.Resize(fyne.NewSize(900, 700))
+	folderDialog.Show()
+}
+
+// ShowBundleSeriesDialog asks for a destination folder and exports manga with its
+// cover into a single archive, either as the chapter cbz files or merged into one cbz
+func ShowBundleSeriesDialog(manga config.Bookmarks, window fyne.Window) {
+	folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if uri == nil {
+			// User cancelled
+			return
+		}
+		confirmSeriesBundle(manga, uri.Path(), window)
+	}, window)
+
+	homePath, err := os.UserHomeDir()
+	if err == nil {
+		homeDir, err := storage.ListerForURI(storage.NewFileURI(homePath))
+		if err == nil {
+			folderDialog.SetLocation(homeDir)
+		}
+	}
+
+	folderDialog.Resize(fyne.NewSize(900, 700))
+	folderDialog.Show()
+}
+
+// confirmSeriesBundle lets the user choose the bundle form, then writes it off the
+// UI goroutine
+func confirmSeriesBundle(manga config.Bookmarks, destDir string, window fyne.Window) {
+	combinedCheck := widget.NewCheck("Merge all chapters into a single cbz", nil)
+
+	content := widget.NewForm(
+		widget.NewFormItem("Destination", widget.NewLabel(destDir)),
+		widget.NewFormItem("", combinedCheck),
+	)
+
+	dialog.ShowCustomConfirm("Bundle Series", "Bundle", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		combined := combinedCheck.Checked
+		dst := filepath.Join(destDir, config.BundleFileName(&manga, combined))
+		progress := dialog.NewCustomWithoutButtons("Bundle Series",
+			widget.NewLabel(fmt.Sprintf("Bundling %s...", manga.Title)), window)
+		progress.Show()
+
+		go func() {
+			err := config.ExportSeriesBundle(&manga, dst, combined)
+			fyne.Do(func() {
+				progress.Hide()
+				if err != nil {
+					log.Printf("[Export] %s: %v", manga.Title, err)
+					dialog.ShowError(err, window)
+					return
+				}
+				dialog.ShowInformation("Bundle Series", fmt.Sprintf("%s bundled to %s", manga.Title, dst), window)
+			})
+		}()
+	}, window)
+}
@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"kansho/sites"
+)
+
+// ShowReloadSiteConfigDialog re-reads the site configuration (including the user
+// sites.json overrides) and reports the result. Views registered for reloads, like
+// the Add/Edit manga site dropdown, refresh themselves. Downloads already running
+// keep the config they started with.
+func ShowReloadSiteConfigDialog(window fyne.Window) {
+	sitesConfig := sites.ReloadSitesConfig()
+	log.Printf("[UI] Site config reloaded: %d sites", len(sitesConfig.Sites))
+
+	dialog.ShowInformation(
+		"Reload Site Config",
+		fmt.Sprintf("Loaded %d sites.\nNew downloads will use the updated selectors, running downloads are unaffected.", len(sitesConfig.Sites)),
+		window,
+	)
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"kansho/cf"
+	"kansho/downloader"
+	"kansho/sites"
+)
+
+// ShowSiteLoginDialog lets the user log in to a site that needs an account. The login
+// page opens in a browser window, closing it saves the site's cookies as the login
+// session downloads from the site are sent with.
+func ShowSiteLoginDialog(window fyne.Window) {
+	sitesConfig := sites.LoadSitesConfig()
+	loginURLs := make(map[string]string)
+	var siteNames []string
+	for _, site := range sitesConfig.Sites {
+		if site.LoginURL != "" {
+			siteNames = append(siteNames, site.DisplayName)
+			loginURLs[site.DisplayName] = site.LoginURL
+		}
+	}
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://example.com/login")
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	showSession := func() {
+		domain := loginDomain(urlEntry.Text)
+		if domain == "" {
+			statusLabel.SetText("")
+			return
+		}
+		session, err := cf.LoadLoginSession(domain)
+		if err != nil {
+			statusLabel.SetText(fmt.Sprintf("Not logged in to %s", domain))
+			return
+		}
+		statusLabel.SetText(fmt.Sprintf("Logged in to %s (captured %s)", session.Domain, session.CapturedAt))
+	}
+	urlEntry.OnChanged = func(string) { showSession() }
+
+	siteSelect := widget.NewSelect(siteNames, func(name string) {
+		urlEntry.SetText(loginURLs[name])
+	})
+	siteSelect.PlaceHolder = "Pick a site or enter its login page"
+	if len(siteNames) == 0 {
+		siteSelect.Hide()
+	}
+
+	var loginButton, logoutButton *widget.Button
+	loginButton = widget.NewButton("Log In", func() {
+		loginURL := urlEntry.Text
+		if loginDomain(loginURL) == "" {
+			dialog.ShowError(fmt.Errorf("enter the site's login page URL"), window)
+			return
+		}
+		loginButton.Disable()
+		logoutButton.Disable()
+		statusLabel.SetText("Log in in the browser window, then close it to save the session")
+
+		go func() {
+			session, err := downloader.CaptureLoginSession(context.Background(), loginURL)
+			fyne.Do(func() {
+				loginButton.Enable()
+				logoutButton.Enable()
+				if err != nil {
+					log.Printf("[UI] Site login failed for %s: %v", loginURL, err)
+					statusLabel.SetText(fmt.Sprintf("❌ Login not saved: %v", err))
+					return
+				}
+				log.Printf("[UI] Saved login session for %s", session.Domain)
+				statusLabel.SetText(fmt.Sprintf("✅ Saved login session for %s (%d cookies)", session.Domain, len(session.AllCookies)))
+			})
+		}()
+	})
+	loginButton.Importance = widget.HighImportance
+
+	logoutButton = widget.NewButton("Log Out", func() {
+		domain := loginDomain(urlEntry.Text)
+		if domain == "" {
+			return
+		}
+		if err := cf.DeleteLoginSession(domain); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		log.Printf("[UI] Removed login session for %s", domain)
+		showSession()
+	})
+
+	content := container.NewVBox(
+		widget.NewLabel("For sites that only show chapters to logged in users."),
+		siteSelect,
+		urlEntry,
+		statusLabel,
+		container.NewGridWithColumns(2, logoutButton, loginButton),
+	)
+
+	loginDialog := dialog.NewCustom("Site Login", "Close", content, window)
+	loginDialog.Resize(fyne.NewSize(520, 260))
+	loginDialog.Show()
+}
+
+// loginDomain is the host of a login page URL, empty when it is not a web URL
+func loginDomain(loginURL string) string {
+	parsed, err := url.Parse(loginURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	return parsed.Hostname()
+}
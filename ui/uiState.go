@@ -1,8 +1,12 @@
 package ui
 
 import (
+	"fmt"
+	"log"
+
 	"kansho/config"
 	"kansho/models"
+	"kansho/parser"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
@@ -19,8 +23,9 @@ type KanshoAppState struct {
 	// Window is the main application window, needed for showing dialogs
 	Window fyne.Window
 
-	// MangaData contains all loaded manga bookmarks
-	MangaData config.Manga
+	// MangaData contains all loaded manga bookmarks. Downloads read it from other
+	// goroutines, so it is only accessed through the store's methods.
+	MangaData *config.BookmarkStore
 
 	// SitesConfig contains configuration for all supported manga sites
 	SitesConfig models.SitesConfig
@@ -54,9 +59,27 @@ type KanshoAppState struct {
 // Returns:
 //   - *KanshoAppState: A new state instance with initialized data
 func NewKanshoAppState(window fyne.Window) *KanshoAppState {
+	// Chapter lists are read before any download applies the settings
+	if err := parser.SetChapterFilenameTemplate(config.LoadSettings().ChapterFilenameTemplate); err != nil {
+		log.Printf("[UI] ⚠️ %v", err)
+	}
+
+	mangaData, skipped := config.LoadBookmarksWithSkipped()
+	if skipped > 0 {
+		// Shown once the event loop is running, the window is not visible yet
+		go fyne.Do(func() {
+			dialog.ShowInformation(
+				"Bookmarks Warning",
+				fmt.Sprintf("%d bookmark entries could not be loaded and were skipped.\n"+
+					"A backup of the original bookmarks file was saved next to it.", skipped),
+				window,
+			)
+		})
+	}
+
 	return &KanshoAppState{
 		Window:          window,
-		MangaData:       config.LoadBookmarks(),
+		MangaData:       config.NewBookmarkStore(mangaData),
 		SitesConfig:     models.SitesConfig{}, // Will be loaded by config package
 		SelectedMangaID: -1,                   // No selection initially
 		OnMangaSelected: make([]func(int), 0),
@@ -69,7 +92,7 @@ func NewKanshoAppState(window fyne.Window) *KanshoAppState {
 // This is called when a user clicks on a manga in the list.
 //
 // Parameters:
-//   - id: The index of the selected manga in the MangaData.Manga slice
+//   - id: The index of the selected manga in MangaData
 func (s *KanshoAppState) SelectManga(id int) {
 	s.SelectedMangaID = id
 
@@ -88,10 +111,10 @@ func (s *KanshoAppState) SelectManga(id int) {
 // TODO: Implement actual persistence (save to file/database)
 func (s *KanshoAppState) AddManga(manga config.Bookmarks) {
 	// Add the manga to our in-memory data
-	s.MangaData.Manga = append(s.MangaData.Manga, manga)
+	s.MangaData.Add(manga)
 
 	// Save to disk immediately
-	err := config.SaveBookmarks(s.MangaData)
+	err := s.MangaData.Save()
 	if err != nil {
 		// Handle error - maybe show a dialog to the user
 		dialog.ShowError(err, s.Window)
@@ -109,16 +132,13 @@ func (s *KanshoAppState) AddManga(manga config.Bookmarks) {
 // Parameters:
 //   - id: The index of the manga to delete
 func (s *KanshoAppState) DeleteManga(id int) {
-	// Validate the ID is within bounds
-	if id < 0 || id >= len(s.MangaData.Manga) {
+	// Remove the manga, ignoring IDs that are out of bounds
+	if !s.MangaData.Delete(id) {
 		return
 	}
 
-	// Remove the manga from the slice
-	s.MangaData.Manga = append(s.MangaData.Manga[:id], s.MangaData.Manga[id+1:]...)
-
 	// Save to disk immediately
-	err := config.SaveBookmarks(s.MangaData)
+	err := s.MangaData.Save()
 	if err != nil {
 		// Handle error - show a dialog to the user
 		dialog.ShowError(err, s.Window)
@@ -139,14 +159,16 @@ func (s *KanshoAppState) DeleteManga(id int) {
 }
 
 // GetSelectedManga returns the currently selected manga, or nil if none is selected.
+// The result is a copy, edits to it are not saved and later edits do not change it.
 //
 // Returns:
-//   - *config.Bookmarks: Pointer to the selected manga, or nil if no selection
+//   - *config.Bookmarks: Pointer to a copy of the selected manga, or nil if no selection
 func (s *KanshoAppState) GetSelectedManga() *config.Bookmarks {
-	if s.SelectedMangaID < 0 || s.SelectedMangaID >= len(s.MangaData.Manga) {
+	manga, ok := s.MangaData.Get(s.SelectedMangaID)
+	if !ok {
 		return nil
 	}
-	return &s.MangaData.Manga[s.SelectedMangaID]
+	return &manga
 }
 
 // RegisterMangaSelectedCallback registers a callback to be called when manga selection changes.
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"kansho/config"
+	"kansho/parser"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// verifyLibrary checks the chapters of every bookmark with a download location,
+// calling progress before each series, and returns the text of the report. Stops
+// early with ctx, the report then covers the series checked so far.
+func verifyLibrary(ctx context.Context, bookmarks []config.Bookmarks, progress func(i int, manga config.Bookmarks)) string {
+	var b strings.Builder
+	series, chapters, problems, unlisted := 0, 0, 0, 0
+	for i, manga := range bookmarks {
+		if ctx.Err() != nil {
+			break
+		}
+		if manga.Location == "" {
+			continue
+		}
+		progress(i, manga)
+
+		report, err := parser.VerifyLibrary(ctx, manga.Location)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[Verify] %s: %v", manga.Title, err)
+			fmt.Fprintf(&b, "%s\n  cannot be checked: %v\n\n", manga.Title, err)
+			continue
+		}
+		series++
+		chapters += report.Chapters
+		unlisted += report.Unlisted
+		if len(report.Issues) == 0 {
+			continue
+		}
+
+		problems += len(report.Issues)
+		fmt.Fprintf(&b, "%s (%d chapters)\n", manga.Title, report.Chapters)
+		for _, issue := range report.Issues {
+			fmt.Fprintf(&b, "  %s: %s\n", issue.Chapter, issue.Problem)
+		}
+		b.WriteString("\n")
+	}
+
+	summary := fmt.Sprintf("Checked %d chapters of %d series, found %d problems.", chapters, series, problems)
+	if ctx.Err() != nil {
+		summary = "Stopped early. " + summary
+	}
+	log.Printf("[Verify] %s", summary)
+	if problems > 0 {
+		summary += "\nRe-download the affected chapters from the chapter list to replace them."
+	}
+	if unlisted > 0 {
+		// Without the manifest only a chapter far shorter than the rest stands out
+		summary += fmt.Sprintf("\n%d chapters have no image manifest, their page counts were compared with the other chapters only, not with the site. "+
+			"Turn on write_image_manifest to record what the site lists for new downloads.", unlisted)
+	}
+	return summary + "\n\n" + b.String()
+}
+
+// showVerifyReport shows the text verifyLibrary returned in a scrollable dialog
+func showVerifyReport(report string, window fyne.Window) {
+	label := widget.NewLabel(strings.TrimSpace(report))
+	label.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(label)
+	scroll.SetMinSize(fyne.NewSize(600, 400))
+	dialog.ShowCustom("Verify Library", "Close", scroll, window)
+}
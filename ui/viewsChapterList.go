@@ -17,12 +17,16 @@ import (
 type ChapterListView struct {
 	Card                fyne.CanvasObject
 	selectedMangaLabel  *widget.Label
+	notesLabel          *widget.Label // notes of the selected series, hidden when it has none
 	chapterList         *widget.List
 	contentContainer    *fyne.Container
 	queueDownloadButton *widget.Button
+	retryFailedButton   *widget.Button
+	redownloadButton    *widget.Button
 	viewToggleButton    *widget.Button
 	state               *KanshoAppState
 	chapters            []string
+	selectedChapter     string // chapter picked in the list, "" for none
 
 	// View management
 	downloadQueueView *DownloadQueueView
@@ -39,12 +43,28 @@ func NewChapterListView(state *KanshoAppState) *ChapterListView {
 
 	view.selectedMangaLabel = widget.NewLabel("Select a manga to view chapters")
 
+	view.notesLabel = widget.NewLabel("")
+	view.notesLabel.Wrapping = fyne.TextWrapWord
+	view.notesLabel.Hide()
+
 	// Queue Download button - adds current manga to download queue
 	view.queueDownloadButton = widget.NewButton("Queue Download", func() {
 		view.onQueueDownloadClicked()
 	})
 	view.queueDownloadButton.Disable()
 
+	// Retry Failed button - queues only the chapters earlier runs could not download
+	view.retryFailedButton = widget.NewButton("Retry Failed Chapters", func() {
+		view.onRetryFailedClicked()
+	})
+	view.retryFailedButton.Disable()
+
+	// Re-download button - fetches the selected chapter, or every chapter, again
+	view.redownloadButton = widget.NewButton("Re-download", func() {
+		view.onRedownloadClicked()
+	})
+	view.redownloadButton.Disable()
+
 	// View Toggle button - switches between chapter list and download queue
 	view.viewToggleButton = widget.NewButton("Download Queue", func() {
 		view.toggleView()
@@ -72,6 +92,8 @@ func NewChapterListView(state *KanshoAppState) *ChapterListView {
 	// Create button container
 	buttonContainer := container.NewHBox(
 		view.queueDownloadButton,
+		view.retryFailedButton,
+		view.redownloadButton,
 		view.viewToggleButton,
 	)
 
@@ -80,6 +102,7 @@ func NewChapterListView(state *KanshoAppState) *ChapterListView {
 		container.NewVBox(
 			NewBoldLabel("Chapter List"),
 			NewSeparator(),
+			view.notesLabel,
 		),
 		container.NewVBox(
 			NewSeparator(),
@@ -138,6 +161,8 @@ func (v *ChapterListView) toggleView() {
 func (v *ChapterListView) buildChapterListCard() *fyne.Container {
 	buttonContainer := container.NewHBox(
 		v.queueDownloadButton,
+		v.retryFailedButton,
+		v.redownloadButton,
 		v.viewToggleButton,
 	)
 
@@ -145,6 +170,7 @@ func (v *ChapterListView) buildChapterListCard() *fyne.Container {
 		container.NewVBox(
 			NewBoldLabel("Chapter List"),
 			NewSeparator(),
+			v.notesLabel,
 		),
 		container.NewVBox(
 			NewSeparator(),
@@ -179,6 +205,74 @@ func (v *ChapterListView) onQueueDownloadClicked() {
 	)
 }
 
+func (v *ChapterListView) onRetryFailedClicked() {
+	manga := v.state.GetSelectedManga()
+	if manga == nil {
+		dialog.ShowError(fmt.Errorf("no manga selected"), v.state.Window)
+		return
+	}
+
+	failed, err := parser.FailedChapters(manga.Location)
+	if err != nil {
+		dialog.ShowError(err, v.state.Window)
+		return
+	}
+	if len(failed) == 0 {
+		dialog.ShowInformation(
+			"Retry Failed Chapters",
+			fmt.Sprintf("'%s' has no failed chapters to retry", manga.Title),
+			v.state.Window,
+		)
+		return
+	}
+
+	task, err := config.GetDownloadQueue().AddRetryFailedTask(manga)
+	if err != nil {
+		dialog.ShowError(err, v.state.Window)
+		return
+	}
+
+	log.Printf("[UI] Added retry of %d failed chapters of '%s' to download queue (ID: %s)", len(failed), manga.Title, task.ID)
+
+	dialog.ShowInformation(
+		"Added to Queue",
+		fmt.Sprintf("%d failed chapters of '%s' have been added to the download queue", len(failed), manga.Title),
+		v.state.Window,
+	)
+}
+
+// onRedownloadClicked queues the selected chapter, or every local chapter when none
+// is selected, to be downloaded again over the CBZ files in the library
+func (v *ChapterListView) onRedownloadClicked() {
+	manga := v.state.GetSelectedManga()
+	if manga == nil {
+		dialog.ShowError(fmt.Errorf("no manga selected"), v.state.Window)
+		return
+	}
+
+	var chapters []string
+	target := fmt.Sprintf("every chapter of '%s'", manga.Title)
+	if v.selectedChapter != "" {
+		chapters = []string{v.selectedChapter}
+		target = fmt.Sprintf("%s of '%s'", v.selectedChapter, manga.Title)
+	}
+
+	message := fmt.Sprintf("Download %s again?\n\nThe CBZ files in the library are overwritten.", target)
+	dialog.ShowConfirm("Re-download Chapters", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		task, err := config.GetDownloadQueue().AddRedownloadTask(manga, chapters...)
+		if err != nil {
+			dialog.ShowError(err, v.state.Window)
+			return
+		}
+
+		log.Printf("[UI] Added re-download of %s to download queue (ID: %s)", target, task.ID)
+	}, v.state.Window)
+}
+
 func (v *ChapterListView) onMangaSelected(id int) {
 	manga := v.state.GetSelectedManga()
 	if manga == nil {
@@ -187,6 +281,9 @@ func (v *ChapterListView) onMangaSelected(id int) {
 	}
 
 	v.queueDownloadButton.Enable()
+	v.retryFailedButton.Enable()
+	v.redownloadButton.Enable()
+	v.showNotes(manga.Notes)
 
 	if manga.Location == "" {
 		v.defaultChapterList()
@@ -213,6 +310,7 @@ func (v *ChapterListView) onMangaSelected(id int) {
 
 func (v *ChapterListView) updateChapterList(chapters []string) {
 	v.chapters = chapters
+	v.selectedChapter = ""
 
 	if len(chapters) == 0 {
 		v.showNoChapters()
@@ -235,14 +333,36 @@ func (v *ChapterListView) updateChapterList(chapters []string) {
 			}
 		},
 	)
+	v.chapterList.OnSelected = func(id widget.ListItemID) {
+		if id < len(v.chapters) {
+			v.selectedChapter = v.chapters[id]
+		}
+	}
+	v.chapterList.OnUnselected = func(widget.ListItemID) {
+		v.selectedChapter = ""
+	}
 
 	v.contentContainer.Objects = []fyne.CanvasObject{v.chapterList}
 	v.contentContainer.Refresh()
 }
 
+// showNotes shows the notes of the selected series above its chapters
+func (v *ChapterListView) showNotes(notes string) {
+	if notes == "" {
+		v.notesLabel.Hide()
+		return
+	}
+	v.notesLabel.SetText("Notes: " + notes)
+	v.notesLabel.Show()
+}
+
 func (v *ChapterListView) showNoSelection() {
 	v.chapters = []string{}
+	v.selectedChapter = ""
 	v.queueDownloadButton.Disable()
+	v.retryFailedButton.Disable()
+	v.redownloadButton.Disable()
+	v.showNotes("")
 	v.contentContainer.Objects = []fyne.CanvasObject{
 		widget.NewLabel("Select a manga to view chapters"),
 	}
@@ -251,6 +371,7 @@ func (v *ChapterListView) showNoSelection() {
 
 func (v *ChapterListView) defaultChapterList() {
 	v.chapters = []string{}
+	v.selectedChapter = ""
 	v.contentContainer.Objects = []fyne.CanvasObject{
 		widget.NewLabel("No chapters found"),
 	}
@@ -259,6 +380,7 @@ func (v *ChapterListView) defaultChapterList() {
 
 func (v *ChapterListView) showNoChapters() {
 	v.chapters = []string{}
+	v.selectedChapter = ""
 	v.contentContainer.Objects = []fyne.CanvasObject{
 		widget.NewLabel("No chapters found for this manga"),
 	}
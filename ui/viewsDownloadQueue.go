@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"log"
 
@@ -18,22 +19,28 @@ type DownloadQueueView struct {
 	taskList          *widget.List
 	contentContainer  *fyne.Container
 	cancelButton      *widget.Button
+	skipButton        *widget.Button
 	retryButton       *widget.Button
 	cancelAllButton   *widget.Button
 	clearButton       *widget.Button
 	chapterListButton *widget.Button
+	aggregateLabel    *widget.Label
+	aggregateBar      *widget.ProgressBar
+	aggregateBox      *fyne.Container
+	runLogPanel       *RunLogPanel
 	state             *KanshoAppState
-	tasks             []*config.DownloadTask
+	tasks             []config.DownloadTask // snapshots, rebuilt from the queue on every refresh
 	selectedTaskID    string
 	onViewToggle      func()
-	cfDialogShown     map[string]bool
+	dialogShown       map[string]bool // tasks whose CF or renumber dialog is already up
+	unsubscribe       func()
 }
 
 func NewDownloadQueueView(state *KanshoAppState) *DownloadQueueView {
 	view := &DownloadQueueView{
-		state:         state,
-		tasks:         []*config.DownloadTask{},
-		cfDialogShown: make(map[string]bool),
+		state:       state,
+		tasks:       []config.DownloadTask{},
+		dialogShown: make(map[string]bool),
 	}
 
 	view.cancelButton = widget.NewButton("Cancel Download", func() {
@@ -41,6 +48,11 @@ func NewDownloadQueueView(state *KanshoAppState) *DownloadQueueView {
 	})
 	view.cancelButton.Disable()
 
+	view.skipButton = widget.NewButton("Skip Chapter", func() {
+		view.onSkipChapter()
+	})
+	view.skipButton.Disable()
+
 	view.retryButton = widget.NewButton("Retry", func() {
 		view.onRetryDownload()
 	})
@@ -60,6 +72,15 @@ func NewDownloadQueueView(state *KanshoAppState) *DownloadQueueView {
 		}
 	})
 
+	// Combined progress of every series in the current batch, only shown while
+	// more than one series is queued
+	view.aggregateLabel = widget.NewLabel("")
+	view.aggregateBar = widget.NewProgressBar()
+	view.aggregateBar.Min = 0
+	view.aggregateBar.Max = 1
+	view.aggregateBox = container.NewVBox(view.aggregateLabel, view.aggregateBar)
+	view.aggregateBox.Hide()
+
 	view.taskList = widget.NewList(
 		func() int {
 			return len(view.tasks)
@@ -93,9 +114,13 @@ func NewDownloadQueueView(state *KanshoAppState) *DownloadQueueView {
 			statusLabel := vbox.Objects[1].(*widget.Label)
 			progressBar := vbox.Objects[2].(*widget.ProgressBar)
 
-			statusIcon := view.getStatusIcon(task.Status)
+			statusIcon := taskStatusIcon(task.Status)
 			titleLabel.SetText(fmt.Sprintf("%s %s", statusIcon, task.Manga.Title))
-			statusLabel.SetText(task.StatusMessage)
+			if task.Status == "failed" && task.Error != nil {
+				statusLabel.SetText(FriendlyError(task.Error))
+			} else {
+				statusLabel.SetText(task.StatusMessage)
+			}
 			progressBar.SetValue(task.Progress)
 		},
 	)
@@ -104,24 +129,31 @@ func NewDownloadQueueView(state *KanshoAppState) *DownloadQueueView {
 		if id < len(view.tasks) {
 			view.selectedTaskID = view.tasks[id].ID
 			task := view.tasks[id]
+			view.refreshRunLog(config.GetDownloadQueue())
 
 			switch task.Status {
 			case "queued", "downloading":
 				view.cancelButton.Enable()
 				view.retryButton.Disable()
-			case "waiting_cf", "failed":
+			case "waiting_cf", "waiting_confirm", "failed":
 				view.cancelButton.Disable()
 				view.retryButton.Enable()
 			default:
 				view.cancelButton.Disable()
 				view.retryButton.Disable()
 			}
+			if task.Status == "downloading" {
+				view.skipButton.Enable()
+			} else {
+				view.skipButton.Disable()
+			}
 		}
 	}
 
 	view.taskList.OnUnselected = func(id widget.ListItemID) {
 		view.selectedTaskID = ""
 		view.cancelButton.Disable()
+		view.skipButton.Disable()
 		view.retryButton.Disable()
 	}
 
@@ -129,8 +161,15 @@ func NewDownloadQueueView(state *KanshoAppState) *DownloadQueueView {
 		widget.NewLabel("No downloads in queue"),
 	)
 
+	// Messages of the selected task's run, or of the running one when nothing is
+	// selected
+	view.runLogPanel = NewRunLogPanel()
+	split := container.NewVSplit(view.contentContainer, view.runLogPanel.Container)
+	split.Offset = 0.65
+
 	buttonContainer := container.NewHBox(
 		view.cancelButton,
+		view.skipButton,
 		view.retryButton,
 		view.cancelAllButton,
 		view.clearButton,
@@ -141,6 +180,7 @@ func NewDownloadQueueView(state *KanshoAppState) *DownloadQueueView {
 		container.NewVBox(
 			NewBoldLabel("Download Queue"),
 			NewSeparator(),
+			view.aggregateBox,
 		),
 		container.NewVBox(
 			NewSeparator(),
@@ -148,39 +188,54 @@ func NewDownloadQueueView(state *KanshoAppState) *DownloadQueueView {
 		),
 		nil,
 		nil,
-		view.contentContainer,
+		split,
 	)
 
 	view.Card = NewCard(cardContent)
 
+	// Subscribe rather than take the single SetCallbacks slot, the queue keeps
+	// running when this view goes away and a new view reattaches the same way:
+	// refreshTaskList rebuilds from the queue's current snapshots, then listens.
 	queue := config.GetDownloadQueue()
-	queue.SetCallbacks(
-		func(task *config.DownloadTask) {
+	view.unsubscribe = queue.Subscribe(config.QueueListener{
+		OnTaskAdded: func(task *config.DownloadTask) {
 			fyne.Do(func() {
 				view.refreshTaskList()
 			})
 		},
-		func(task *config.DownloadTask) {
+		OnTaskUpdated: func(task *config.DownloadTask) {
+			id := task.ID
 			fyne.Do(func() {
-				if task.Status == "waiting_cf" && !view.cfDialogShown[task.ID] {
-					view.showCFDialog(task)
-					view.cfDialogShown[task.ID] = true
+				if snapshot, ok := queue.GetTaskSnapshot(id); ok && !view.dialogShown[id] {
+					switch snapshot.Status {
+					case "waiting_cf":
+						// Skipped unattended tasks wait in the list to be retried,
+						// no challenge was opened
+						var skipped *config.CFSkippedError
+						if !errors.As(snapshot.Error, &skipped) {
+							view.showCFDialog(snapshot)
+						}
+						view.dialogShown[id] = true
+					case "waiting_confirm":
+						view.showRenumberDialog(snapshot)
+						view.dialogShown[id] = true
+					}
 				}
 				view.refreshTaskList()
 			})
 		},
-		func(taskID string) {
+		OnTaskRemoved: func(taskID string) {
 			fyne.Do(func() {
-				delete(view.cfDialogShown, taskID)
+				delete(view.dialogShown, taskID)
 				view.refreshTaskList()
 			})
 		},
-		func() {
+		OnQueueEmpty: func() {
 			fyne.Do(func() {
 				view.refreshTaskList()
 			})
 		},
-	)
+	})
 
 	view.refreshTaskList()
 	return view
@@ -190,7 +245,18 @@ func (v *DownloadQueueView) SetViewToggleCallback(callback func()) {
 	v.onViewToggle = callback
 }
 
-func (v *DownloadQueueView) getStatusIcon(status string) string {
+// Close stops the view listening to the queue, downloads carry on regardless and
+// a new view picks their progress back up when it is created
+func (v *DownloadQueueView) Close() {
+	if v.unsubscribe != nil {
+		v.unsubscribe()
+		v.unsubscribe = nil
+	}
+	v.runLogPanel.Stop()
+}
+
+// taskStatusIcon is the icon shown in front of a task for its status
+func taskStatusIcon(status string) string {
 	switch status {
 	case "queued":
 		return "⏳"
@@ -198,8 +264,12 @@ func (v *DownloadQueueView) getStatusIcon(status string) string {
 		return "⬇️"
 	case "waiting_cf":
 		return "🔒"
+	case "waiting_confirm":
+		return "❔"
 	case "completed":
 		return "✅"
+	case "completed_with_errors":
+		return "⚠️"
 	case "cancelled":
 		return "🚫"
 	case "failed":
@@ -209,7 +279,7 @@ func (v *DownloadQueueView) getStatusIcon(status string) string {
 	}
 }
 
-func (v *DownloadQueueView) showCFDialog(task *config.DownloadTask) {
+func (v *DownloadQueueView) showCFDialog(task config.DownloadTask) {
 	log.Printf("[UI] showCFDialog called for task: %s", task.ID)
 	log.Printf("[UI] task.Error type: %T", task.Error)
 	log.Printf("[UI] task.Error value: %v", task.Error)
@@ -223,7 +293,7 @@ func (v *DownloadQueueView) showCFDialog(task *config.DownloadTask) {
 	log.Printf("[UI] Showing CF dialog for URL: %s", cfErr.URL)
 	ShowcfDialog(v.state.Window, cfErr.URL, func() {
 		queue := config.GetDownloadQueue()
-		delete(v.cfDialogShown, task.ID)
+		delete(v.dialogShown, task.ID)
 		if err := queue.RetryTask(task.ID); err != nil {
 			dialog.ShowError(fmt.Errorf("failed to retry: %w", err), v.state.Window)
 		}
@@ -231,6 +301,25 @@ func (v *DownloadQueueView) showCFDialog(task *config.DownloadTask) {
 	log.Printf("[UI] CF dialog should be visible now")
 }
 
+// showRenumberDialog asks whether to download a series whose chapter list no longer
+// lines up with the chapters on disk, declining leaves the task waiting
+func (v *DownloadQueueView) showRenumberDialog(task config.DownloadTask) {
+	message := fmt.Sprintf("The chapter list of %s no longer matches the downloaded chapters.\n"+
+		"The site may have renumbered the series (eg: added a chapter 0),\n"+
+		"downloading now could fetch chapters again under the wrong numbers.\n\n%v\n\nDownload anyway?",
+		task.Manga.Title, task.Error)
+
+	dialog.ShowConfirm("Chapter Numbering Changed", message, func(confirmed bool) {
+		delete(v.dialogShown, task.ID)
+		if !confirmed {
+			return
+		}
+		if err := config.GetDownloadQueue().ConfirmTask(task.ID); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to confirm: %w", err), v.state.Window)
+		}
+	}, v.state.Window)
+}
+
 func (v *DownloadQueueView) onCancelDownload() {
 	if v.selectedTaskID == "" {
 		return
@@ -246,6 +335,7 @@ func (v *DownloadQueueView) onCancelDownload() {
 	log.Printf("[UI] Cancelled task: %s", v.selectedTaskID)
 	v.selectedTaskID = ""
 	v.cancelButton.Disable()
+	v.skipButton.Disable()
 	v.retryButton.Disable()
 	v.refreshTaskList()
 }
@@ -255,9 +345,16 @@ func (v *DownloadQueueView) onRetryDownload() {
 		return
 	}
 
-	delete(v.cfDialogShown, v.selectedTaskID)
+	delete(v.dialogShown, v.selectedTaskID)
 
 	queue := config.GetDownloadQueue()
+	// Retrying would only stop at the renumber check again, ask instead
+	if snapshot, ok := queue.GetTaskSnapshot(v.selectedTaskID); ok && snapshot.Status == "waiting_confirm" {
+		v.dialogShown[snapshot.ID] = true
+		v.showRenumberDialog(snapshot)
+		return
+	}
+
 	err := queue.RetryTask(v.selectedTaskID)
 	if err != nil {
 		dialog.ShowError(err, v.state.Window)
@@ -267,10 +364,27 @@ func (v *DownloadQueueView) onRetryDownload() {
 	log.Printf("[UI] Retrying task: %s", v.selectedTaskID)
 	v.selectedTaskID = ""
 	v.cancelButton.Disable()
+	v.skipButton.Disable()
 	v.retryButton.Disable()
 	v.refreshTaskList()
 }
 
+// onSkipChapter abandons the chapter the selected task is downloading, the rest of
+// the series keeps downloading
+func (v *DownloadQueueView) onSkipChapter() {
+	if v.selectedTaskID == "" {
+		return
+	}
+
+	queue := config.GetDownloadQueue()
+	if err := queue.SkipChapter(v.selectedTaskID); err != nil {
+		dialog.ShowError(err, v.state.Window)
+		return
+	}
+
+	log.Printf("[UI] Skipping current chapter of task: %s", v.selectedTaskID)
+}
+
 func (v *DownloadQueueView) onCancelAll() {
 	dialog.ShowConfirm(
 		"Cancel All Downloads",
@@ -298,9 +412,9 @@ func (v *DownloadQueueView) onClearCompleted() {
 		currentIDs[task.ID] = true
 	}
 
-	for id := range v.cfDialogShown {
+	for id := range v.dialogShown {
 		if !currentIDs[id] {
-			delete(v.cfDialogShown, id)
+			delete(v.dialogShown, id)
 		}
 	}
 
@@ -309,7 +423,7 @@ func (v *DownloadQueueView) onClearCompleted() {
 
 func (v *DownloadQueueView) refreshTaskList() {
 	queue := config.GetDownloadQueue()
-	v.tasks = queue.GetTasks()
+	v.tasks = queue.GetTaskSnapshots()
 
 	if len(v.tasks) == 0 {
 		v.contentContainer.Objects = []fyne.CanvasObject{
@@ -324,4 +438,41 @@ func (v *DownloadQueueView) refreshTaskList() {
 	if len(v.tasks) > 0 {
 		v.taskList.Refresh()
 	}
+
+	v.refreshAggregate(queue.AggregateProgress())
+	v.refreshRunLog(queue)
+}
+
+// refreshRunLog points the run log panel at the selected task, falling back to the
+// first task that is downloading. With neither the panel keeps the last run shown
+// so its final messages can still be read.
+func (v *DownloadQueueView) refreshRunLog(queue *config.DownloadQueue) {
+	var followed *config.DownloadTask
+	for i := range v.tasks {
+		if v.tasks[i].ID == v.selectedTaskID {
+			followed = &v.tasks[i]
+			break
+		}
+		if followed == nil && v.tasks[i].Status == "downloading" {
+			followed = &v.tasks[i]
+		}
+	}
+
+	if followed == nil {
+		return
+	}
+	v.runLogPanel.Follow(queue.GetRunLog(followed.ID), followed.Manga.Title)
+}
+
+// refreshAggregate updates the combined progress bar, hiding it for single series
+// downloads where it would only repeat the task's own bar
+func (v *DownloadQueueView) refreshAggregate(agg config.AggregateProgress) {
+	if agg.Series < 2 {
+		v.aggregateBox.Hide()
+		return
+	}
+
+	v.aggregateLabel.SetText(agg.String())
+	v.aggregateBar.SetValue(agg.Fraction())
+	v.aggregateBox.Show()
 }
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
@@ -14,6 +16,7 @@ import (
 
 	"kansho/config"
 	"kansho/models"
+	"kansho/parser"
 	"kansho/sites"
 	"kansho/validation"
 )
@@ -34,9 +37,23 @@ type EditMangaView struct {
 	// UI components that need to be accessed after creation
 	SiteSelect           *widget.Select   // Dropdown for site selection
 	Title                *widget.Entry    // Text input for manga name
+	FetchTitleButton     *widget.Button   // Button to fill the name from the series page
 	UrlEntry             *widget.Entry    // Text input for manga URL
 	DirectoryLabel       *widget.Label    // Label showing selected directory
 	DirectoryButton      *widget.Button   // Button to open directory picker
+	KeepLatestEntry      *widget.Entry    // Optional number of latest chapters to keep on disk
+	DelayEntry           *widget.Entry    // Optional hours to hold back newly published chapters
+	CheckIntervalEntry   *widget.Entry    // Optional hours between automatic update checks
+	SyncModeSelect       *widget.Select   // Append only new chapters or fully resync
+	ImageQualitySelect   *widget.Select   // Page quality on sites serving more than one
+	LanguagesEntry       *widget.Entry    // Optional preferred translations, comma separated
+	GroupsEntry          *widget.Entry    // Optional preferred scanlation groups, comma separated
+	CompletedCheck       *widget.Check    // Finished series, left out of Recheck All
+	MirrorEntry          *widget.Entry    // Optional extra folders new chapters are copied to
+	CookiesEntry         *widget.Entry    // Optional session cookies for this series (sensitive)
+	HeadersEntry         *widget.Entry    // Optional extra request headers for this series
+	NotesEntry           *widget.Entry    // Optional free text notes about the series
+	TagsEntry            *widget.Entry    // Optional comma separated tags of the series
 	AddButton            *widget.Button   // Button to add new manga
 	SaveButton           *widget.Button   // Button to save changes to existing manga
 	CancelButton         *widget.Button   // Button to cancel editing
@@ -69,32 +86,93 @@ func NewEditMangaView(State *KanshoAppState) *EditMangaView {
 		editingMangaID: -1,
 	}
 
-	// Load the sites configuration
-	view.SitesConfig = sites.LoadSitesConfig()
-
-	// Create a slice of site display names for the dropdown
-	siteNames := make([]string, len(view.SitesConfig.Sites))
-	for i, site := range view.SitesConfig.Sites {
-		siteNames[i] = site.DisplayName
-	}
-
-	// Create the site selection dropdown
-	view.SiteSelect = widget.NewSelect(siteNames, func(selected string) {
+	// Create the site selection dropdown, options are filled from the sites configuration
+	view.SiteSelect = widget.NewSelect(nil, func(selected string) {
 		view.onSiteSelected(selected)
 	})
 	view.SiteSelect.PlaceHolder = "Site name"
 
+	// Load the sites configuration, and follow any later "Reload Site Config"
+	view.setSitesConfig(sites.LoadSitesConfig())
+	sites.OnSitesConfigReload(view.setSitesConfig)
+
+	// Follow changes to the enabled sites made in the site configuration window
+	config.OnSettingsChanged(func(config.Settings) {
+		fyne.Do(func() {
+			view.refreshSiteOptions(view.SiteSelect.Selected)
+		})
+	})
+
 	// Create the title/name input field
 	view.Title = widget.NewEntry()
 	view.Title.SetPlaceHolder("Full Manga Name")
 
+	// Create the button that reads the title from the URL
+	view.FetchTitleButton = widget.NewButton("Fetch Title", func() {
+		view.onFetchTitleClicked()
+	})
+
 	// Create the URL input field
 	view.UrlEntry = widget.NewEntry()
 	view.UrlEntry.SetPlaceHolder("Paste manga URL")
 
-	// Create the directory selection label and button
+	// Create the optional retention input field
+	view.KeepLatestEntry = widget.NewEntry()
+	view.KeepLatestEntry.SetPlaceHolder("0 = keep all chapters")
+
+	// Create the optional new chapter delay input field
+	view.DelayEntry = widget.NewEntry()
+	view.DelayEntry.SetPlaceHolder("0 = download new chapters straight away")
+
+	// Create the optional automatic check interval input field
+	view.CheckIntervalEntry = widget.NewEntry()
+	view.CheckIntervalEntry.SetPlaceHolder("empty = auto check setting, -1 = never")
+
+	// Create the sync mode dropdown, append is the default
+	view.SyncModeSelect = widget.NewSelect([]string{syncModeAppendLabel, syncModeFullLabel}, nil)
+	view.SyncModeSelect.SetSelected(syncModeAppendLabel)
+
+	// Create the image quality dropdown, the site's setting is the default
+	view.ImageQualitySelect = widget.NewSelect([]string{imageQualityDefaultLabel, imageQualityOriginalLabel, imageQualityDataSaverLabel}, nil)
+	view.ImageQualitySelect.SetSelected(imageQualityDefaultLabel)
+
+	// Create the optional language and scanlation group preferences (MangaDex)
+	view.LanguagesEntry = widget.NewEntry()
+	view.LanguagesEntry.SetPlaceHolder("Optional, most preferred first, eg: en, es-la (default en)")
+	view.GroupsEntry = widget.NewEntry()
+	view.GroupsEntry.SetPlaceHolder("Optional, most preferred first, names or IDs")
+
+	// Create the completed checkbox, finished series are not rechecked in bulk
+	view.CompletedCheck = widget.NewCheck("Series completed (skip in Recheck All)", nil)
+
+	// Create the optional mirror folders input, one folder per line
+	view.MirrorEntry = widget.NewMultiLineEntry()
+	view.MirrorEntry.SetPlaceHolder("Optional, one folder per line (eg: a NAS share)")
+	view.MirrorEntry.SetMinRowsVisible(2)
+
+	// Create the optional session cookie and header inputs for login gated series,
+	// the cookies are credentials so they are masked
+	view.CookiesEntry = widget.NewPasswordEntry()
+	view.CookiesEntry.SetPlaceHolder("Optional, eg: session=abc; remember=1")
+	view.HeadersEntry = widget.NewMultiLineEntry()
+	view.HeadersEntry.SetPlaceHolder("Optional, one \"Name: value\" per line")
+	view.HeadersEntry.SetMinRowsVisible(2)
+
+	// Create the optional notes input
+	view.NotesEntry = widget.NewMultiLineEntry()
+	view.NotesEntry.SetPlaceHolder("Optional, eg: reads right to left")
+	view.NotesEntry.Wrapping = fyne.TextWrapWord
+	view.NotesEntry.SetMinRowsVisible(2)
+
+	// Create the optional tags input
+	view.TagsEntry = widget.NewEntry()
+	view.TagsEntry.SetPlaceHolder("Optional, comma separated, eg: ongoing, isekai")
+
+	// Create the directory selection label and button, new series go to the
+	// default library root until another directory is chosen
 	view.DirectoryLabel = widget.NewLabel("No directory selected")
 	view.DirectoryLabel.Wrapping = fyne.TextTruncate
+	view.resetDirectory()
 	view.DirectoryButton = widget.NewButton("Choose Directory...", func() {
 		view.onDirectoryButtonClicked()
 	})
@@ -121,7 +199,7 @@ func NewEditMangaView(State *KanshoAppState) *EditMangaView {
 		nil,
 		nil,
 		widget.NewLabel("Name:"),
-		nil,
+		view.FetchTitleButton,
 		view.Title,
 	)
 
@@ -146,6 +224,101 @@ func NewEditMangaView(State *KanshoAppState) *EditMangaView {
 		container.NewBorder(nil, nil, view.DirectoryButton, nil, view.DirectoryLabel),
 	)
 
+	// Create the retention row
+	keepLatestRow := container.NewBorder(
+		nil,
+		nil,
+		widget.NewLabel("Keep latest:"),
+		nil,
+		view.KeepLatestEntry,
+	)
+
+	// Create the new chapter delay row
+	delayRow := container.NewBorder(
+		nil,
+		nil,
+		widget.NewLabel("Delay new chapters (hours):"),
+		nil,
+		view.DelayEntry,
+	)
+
+	// Create the automatic check interval row
+	checkIntervalRow := container.NewBorder(
+		nil,
+		nil,
+		widget.NewLabel("Check for updates every (hours):"),
+		nil,
+		view.CheckIntervalEntry,
+	)
+
+	// Create the sync mode row
+	syncModeRow := container.NewBorder(
+		nil,
+		nil,
+		widget.NewLabel("Sync mode:"),
+		nil,
+		view.SyncModeSelect,
+	)
+
+	// Create the image quality row
+	imageQualityRow := container.NewBorder(
+		nil,
+		nil,
+		widget.NewLabel("Image quality:"),
+		nil,
+		view.ImageQualitySelect,
+	)
+
+	// Create the language and scanlation group rows
+	languagesRow := container.NewBorder(
+		nil,
+		nil,
+		widget.NewLabel("Languages:"),
+		nil,
+		view.LanguagesEntry,
+	)
+	groupsRow := container.NewBorder(
+		nil,
+		nil,
+		widget.NewLabel("Preferred groups:"),
+		nil,
+		view.GroupsEntry,
+	)
+
+	// Create the mirror folders row
+	mirrorRow := container.NewVBox(
+		widget.NewLabel("Mirror to:"),
+		view.MirrorEntry,
+	)
+
+	// Create the session cookie and header rows
+	cookiesRow := container.NewBorder(
+		nil,
+		nil,
+		widget.NewLabel("Session cookies (sensitive):"),
+		nil,
+		view.CookiesEntry,
+	)
+	headersRow := container.NewVBox(
+		widget.NewLabel("Request headers:"),
+		view.HeadersEntry,
+	)
+
+	// Create the notes row
+	notesRow := container.NewVBox(
+		widget.NewLabel("Notes:"),
+		view.NotesEntry,
+	)
+
+	// Create the tags row
+	tagsRow := container.NewBorder(
+		nil,
+		nil,
+		widget.NewLabel("Tags:"),
+		nil,
+		view.TagsEntry,
+	)
+
 	// Create container for the buttons, centered
 	buttonRow := container.NewCenter(
 		container.NewHBox(
@@ -163,6 +336,19 @@ func NewEditMangaView(State *KanshoAppState) *EditMangaView {
 		siteRow,
 		urlRow,
 		directoryRow,
+		keepLatestRow,
+		delayRow,
+		checkIntervalRow,
+		syncModeRow,
+		imageQualityRow,
+		languagesRow,
+		groupsRow,
+		view.CompletedCheck,
+		mirrorRow,
+		cookiesRow,
+		headersRow,
+		notesRow,
+		tagsRow,
 		NewSeparator(),
 		buttonRow,
 	)
@@ -175,12 +361,11 @@ func NewEditMangaView(State *KanshoAppState) *EditMangaView {
 
 // LoadMangaForEditing loads an existing manga's data into the form for editing
 func (v *EditMangaView) LoadMangaForEditing(mangaID int) {
-	if mangaID < 0 || mangaID >= len(v.State.MangaData.Manga) {
+	manga, ok := v.State.MangaData.Get(mangaID)
+	if !ok {
 		return
 	}
 
-	manga := v.State.MangaData.Manga[mangaID]
-
 	// Set edit mode
 	v.isEditMode = true
 	v.editingMangaID = mangaID
@@ -188,9 +373,39 @@ func (v *EditMangaView) LoadMangaForEditing(mangaID int) {
 
 	// Load the manga data into the form
 	v.Title.SetText(manga.Title)
+	v.refreshSiteOptions(manga.Site)
 	v.SiteSelect.SetSelected(manga.Site)
 	v.UrlEntry.SetText(manga.Url)
 	v.DirectoryLabel.SetText(manga.Location)
+	if manga.KeepLatest > 0 {
+		v.KeepLatestEntry.SetText(strconv.Itoa(manga.KeepLatest))
+	} else {
+		v.KeepLatestEntry.SetText("")
+	}
+	if manga.DelayNewChapterHours > 0 {
+		v.DelayEntry.SetText(strconv.Itoa(manga.DelayNewChapterHours))
+	} else {
+		v.DelayEntry.SetText("")
+	}
+	if manga.CheckIntervalHours != 0 {
+		v.CheckIntervalEntry.SetText(strconv.Itoa(manga.CheckIntervalHours))
+	} else {
+		v.CheckIntervalEntry.SetText("")
+	}
+	if manga.SyncMode == config.SyncModeFull {
+		v.SyncModeSelect.SetSelected(syncModeFullLabel)
+	} else {
+		v.SyncModeSelect.SetSelected(syncModeAppendLabel)
+	}
+	v.ImageQualitySelect.SetSelected(imageQualityLabel(manga.ImageQuality))
+	v.LanguagesEntry.SetText(strings.Join(manga.Languages, ", "))
+	v.GroupsEntry.SetText(strings.Join(manga.ScanlationGroups, ", "))
+	v.CompletedCheck.SetChecked(manga.Completed)
+	v.MirrorEntry.SetText(strings.Join(manga.MirrorLocations, "\n"))
+	v.CookiesEntry.SetText(manga.SessionCookies)
+	v.HeadersEntry.SetText(formatSessionHeaders(manga.SessionHeaders))
+	v.NotesEntry.SetText(manga.Notes)
+	v.TagsEntry.SetText(strings.Join(manga.Tags, ", "))
 
 	// Parse the location to set the directory URI
 	// Location format is typically: /path/to/directory/MangaName
@@ -211,13 +426,76 @@ func (v *EditMangaView) LoadMangaForEditing(mangaID int) {
 	log.Printf("[EditManga] Loaded manga for editing: %s (ID: %d)", manga.Title, mangaID)
 }
 
+// setSitesConfig replaces the sites configuration and refreshes the site dropdown.
+// The current selection is kept if the site still exists in the new config.
+func (v *EditMangaView) setSitesConfig(sitesConfig models.SitesConfig) {
+	v.SitesConfig = sitesConfig
+	v.refreshSiteOptions(v.SiteSelect.Selected)
+}
+
+// SiteDropdownOptions returns the display names offered in the site dropdown: the
+// enabled sites in config order, or every site when enabled is empty. keep, the
+// site of the bookmark being edited, is offered even when it is not enabled so
+// bookmarks of disabled sites stay editable.
+func SiteDropdownOptions(sitesConfig models.SitesConfig, enabled []string, keep string) []string {
+	enabledNames := make(map[string]bool, len(enabled))
+	for _, name := range enabled {
+		enabledNames[name] = true
+	}
+
+	options := []string{}
+	for _, site := range sitesConfig.Sites {
+		kept := keep != "" && (keep == site.Name || keep == site.DisplayName)
+		if len(enabled) == 0 || enabledNames[site.Name] || kept {
+			options = append(options, site.DisplayName)
+		}
+	}
+	return options
+}
+
+// refreshSiteOptions fills the site dropdown with the enabled sites plus keep, see
+// SiteDropdownOptions
+func (v *EditMangaView) refreshSiteOptions(keep string) {
+	siteNames := SiteDropdownOptions(v.SitesConfig, config.LoadSettings().EnabledSites, keep)
+
+	selected := v.SiteSelect.Selected
+	v.SiteSelect.SetOptions(siteNames)
+	if selected != "" {
+		found := false
+		for _, name := range siteNames {
+			if name == selected {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.SiteSelect.ClearSelected()
+		}
+	}
+
+	log.Printf("[EditManga] Site list updated: %d sites", len(siteNames))
+}
+
 // ClearForm resets the form to add mode
 func (v *EditMangaView) clearForm() {
 	v.Title.SetText("")
 	v.UrlEntry.SetText("")
-	v.DirectoryLabel.SetText("No directory selected")
-	v.SelectedDirectoryURI = nil
+	v.KeepLatestEntry.SetText("")
+	v.DelayEntry.SetText("")
+	v.CheckIntervalEntry.SetText("")
+	v.SyncModeSelect.SetSelected(syncModeAppendLabel)
+	v.ImageQualitySelect.SetSelected(imageQualityDefaultLabel)
+	v.LanguagesEntry.SetText("")
+	v.GroupsEntry.SetText("")
+	v.CompletedCheck.SetChecked(false)
+	v.MirrorEntry.SetText("")
+	v.CookiesEntry.SetText("")
+	v.HeadersEntry.SetText("")
+	v.NotesEntry.SetText("")
+	v.TagsEntry.SetText("")
+	v.resetDirectory()
 	v.SiteSelect.ClearSelected()
+	v.refreshSiteOptions("")
 
 	// Reset to add mode
 	v.isEditMode = false
@@ -246,16 +524,22 @@ func (v *EditMangaView) onDirectoryButtonClicked() {
 		v.DirectoryLabel.SetText(uri.Path())
 	}, v.State.Window)
 
-	homePath, err := os.UserHomeDir()
-	if err != nil {
-		log.Printf("Failed to get home directory: %v", err)
-	} else {
-		homeURI := storage.NewFileURI(homePath)
-		homeDir, err := storage.ListerForURI(homeURI)
+	// Start in the default library root when it exists, otherwise the home directory
+	startPath := defaultLibraryRoot()
+	if info, err := os.Stat(startPath); startPath == "" || err != nil || !info.IsDir() {
+		homePath, err := os.UserHomeDir()
+		if err != nil {
+			log.Printf("Failed to get home directory: %v", err)
+		}
+		startPath = homePath
+	}
+	if startPath != "" {
+		startURI := storage.NewFileURI(startPath)
+		startDir, err := storage.ListerForURI(startURI)
 		if err != nil {
-			log.Printf("Failed to get ListableURI for %s: %v", homePath, err)
+			log.Printf("Failed to get ListableURI for %s: %v", startPath, err)
 		} else {
-			folderDialog.SetLocation(homeDir)
+			folderDialog.SetLocation(startDir)
 		}
 	}
 
@@ -263,6 +547,32 @@ func (v *EditMangaView) onDirectoryButtonClicked() {
 	folderDialog.Show()
 }
 
+// resetDirectory clears the chosen directory. With a default library root configured
+// the label shows the root, since new series are added there.
+func (v *EditMangaView) resetDirectory() {
+	v.SelectedDirectoryURI = nil
+	if root := defaultLibraryRoot(); root != "" {
+		v.DirectoryLabel.SetText(root)
+		return
+	}
+	v.DirectoryLabel.SetText("No directory selected")
+}
+
+// defaultLibraryRoot returns the default_library_root setting with ~ expanded, "" when
+// it is not set. Read on every use so edits to settings.json apply straight away.
+func defaultLibraryRoot() string {
+	root := strings.TrimSpace(config.LoadSettings().DefaultLibraryRoot)
+	if root == "" {
+		return ""
+	}
+	expanded, err := parser.ExpandPath(root)
+	if err != nil {
+		log.Printf("[EditManga] Ignoring default library root %q: %v", root, err)
+		return ""
+	}
+	return expanded
+}
+
 // onSiteSelected is called when the user selects a site from the dropdown.
 func (v *EditMangaView) onSiteSelected(selected string) {
 	var selectedSite models.Site
@@ -276,6 +586,48 @@ func (v *EditMangaView) onSiteSelected(selected string) {
 	log.Printf("Selected site: %s\n", selectedSite.Name)
 }
 
+// onFetchTitleClicked fills the Name field with the title read from the series page
+// at the entered URL. The request runs in the background so the form stays usable.
+func (v *EditMangaView) onFetchTitleClicked() {
+	mangaURL := strings.TrimSpace(v.UrlEntry.Text)
+	if mangaURL == "" {
+		dialog.ShowInformation("Fetch Title", "Enter the manga URL first.", v.State.Window)
+		return
+	}
+
+	v.FetchTitleButton.Disable()
+	go func() {
+		title, err := sites.FetchMangaTitle(mangaURL)
+
+		fyne.Do(func() {
+			v.FetchTitleButton.Enable()
+			if err != nil {
+				log.Printf("[EditManga] Failed to fetch title for %s: %v", mangaURL, err)
+				dialog.ShowError(fmt.Errorf("could not read the title from this URL: %v", err), v.State.Window)
+				return
+			}
+			log.Printf("[EditManga] Fetched title for %s: %s", mangaURL, title)
+			v.Title.SetText(title)
+		})
+	}()
+}
+
+// normalizeURL cleans the entered URL up with sites.NormalizeMangaURL and shows the
+// result in the URL field, so the user sees what is saved
+func (v *EditMangaView) normalizeURL(site, rawURL string) (string, error) {
+	normalized, err := sites.NormalizeMangaURL(site, rawURL)
+	if err != nil {
+		return "", err
+	}
+	if normalized != rawURL {
+		log.Printf("[EditManga] Normalized URL %s -> %s", rawURL, normalized)
+		if v.UrlEntry != nil {
+			v.UrlEntry.SetText(normalized)
+		}
+	}
+	return normalized, nil
+}
+
 // onAddButtonClicked is called when the user clicks the Add Manga button.
 func (v *EditMangaView) onAddButtonClicked() {
 	selectedSite := v.SiteSelect.Selected
@@ -291,13 +643,66 @@ func (v *EditMangaView) onAddButtonClicked() {
 	}
 
 	location := ""
+	cleanedDirectory := ""
+	inLibraryRoot := false
 	if v.SelectedDirectoryURI != nil {
-		cleanedDirectory := strings.ReplaceAll(v.SelectedDirectoryURI.String(), "file://", "")
+		cleanedDirectory = strings.ReplaceAll(v.SelectedDirectoryURI.String(), "file://", "")
+		location = fmt.Sprintf("%s/%s", cleanedDirectory, title)
+	} else if root := defaultLibraryRoot(); root != "" && title != "" {
+		cleanedDirectory, inLibraryRoot = root, true
 		location = fmt.Sprintf("%s/%s", cleanedDirectory, title)
 	}
 
 	// Validate the input
 	err := validation.ValidateAddManga(selectedSite, title, "", url, location, &v.SitesConfig)
+	if err == nil && url != "" {
+		url, err = v.normalizeURL(selectedSite, url)
+	}
+	if err != nil {
+		if v.State != nil && v.State.Window != nil {
+			dialog.ShowError(err, v.State.Window)
+		}
+		return
+	}
+
+	// The title becomes the folder name, keep it inside the chosen directory. In the
+	// default library root it is also sanitized, the user never saw the path.
+	if cleanedDirectory != "" {
+		if inLibraryRoot {
+			location, err = sites.LibraryLocation(cleanedDirectory, title)
+		} else {
+			location, err = validation.SafeJoin(cleanedDirectory, title)
+		}
+		if err != nil {
+			if v.State != nil && v.State.Window != nil {
+				dialog.ShowError(fmt.Errorf("invalid manga name for a folder: %v", err), v.State.Window)
+			}
+			return
+		}
+	}
+
+	keepLatest, err := v.keepLatestValue()
+	if err != nil {
+		if v.State != nil && v.State.Window != nil {
+			dialog.ShowError(err, v.State.Window)
+		}
+		return
+	}
+	delayHours, err := v.delayHoursValue()
+	if err != nil {
+		if v.State != nil && v.State.Window != nil {
+			dialog.ShowError(err, v.State.Window)
+		}
+		return
+	}
+	checkHours, err := v.checkIntervalValue()
+	if err != nil {
+		if v.State != nil && v.State.Window != nil {
+			dialog.ShowError(err, v.State.Window)
+		}
+		return
+	}
+	headers, err := v.sessionHeadersValue()
 	if err != nil {
 		if v.State != nil && v.State.Window != nil {
 			dialog.ShowError(err, v.State.Window)
@@ -319,11 +724,25 @@ func (v *EditMangaView) onAddButtonClicked() {
 
 	// Create the new manga entry
 	newManga := config.Bookmarks{
-		Title:     title,
-		Shortname: "", // Removed shortname
-		Url:       url,
-		Site:      selectedSite,
-		Location:  location,
+		Title:      title,
+		Shortname:  "", // Removed shortname
+		Url:        url,
+		Site:       selectedSite,
+		Location:   location,
+		KeepLatest: keepLatest,
+		SyncMode:   v.syncModeValue(),
+		Completed:  v.CompletedCheck.Checked,
+
+		MirrorLocations:      v.mirrorLocationsValue(),
+		DelayNewChapterHours: delayHours,
+		CheckIntervalHours:   checkHours,
+		ImageQuality:         v.imageQualityValue(),
+		Languages:            config.NormalizeTags(commaListValue(v.LanguagesEntry.Text)),
+		ScanlationGroups:     commaListValue(v.GroupsEntry.Text),
+		SessionCookies:       strings.TrimSpace(v.CookiesEntry.Text),
+		SessionHeaders:       headers,
+		Notes:                strings.TrimSpace(v.NotesEntry.Text),
+		Tags:                 config.ParseTags(v.TagsEntry.Text),
 	}
 
 	// Add to app state
@@ -343,7 +762,7 @@ func (v *EditMangaView) onAddButtonClicked() {
 
 // onSaveButtonClicked is called when the user clicks the Save Manga button.
 func (v *EditMangaView) onSaveButtonClicked() {
-	if !v.isEditMode || v.editingMangaID < 0 || v.editingMangaID >= len(v.State.MangaData.Manga) {
+	if _, ok := v.State.MangaData.Get(v.editingMangaID); !v.isEditMode || !ok {
 		dialog.ShowError(fmt.Errorf("no manga loaded for editing"), v.State.Window)
 		return
 	}
@@ -362,6 +781,33 @@ func (v *EditMangaView) onSaveButtonClicked() {
 
 	// Validate the input
 	err := validation.ValidateAddManga(selectedSite, title, "", url, newLocation, &v.SitesConfig)
+	if err == nil {
+		err = validation.ValidateLocation(newLocation)
+	}
+	if err == nil && url != "" {
+		url, err = v.normalizeURL(selectedSite, url)
+	}
+	if err != nil {
+		dialog.ShowError(err, v.State.Window)
+		return
+	}
+
+	keepLatest, err := v.keepLatestValue()
+	if err != nil {
+		dialog.ShowError(err, v.State.Window)
+		return
+	}
+	delayHours, err := v.delayHoursValue()
+	if err != nil {
+		dialog.ShowError(err, v.State.Window)
+		return
+	}
+	checkHours, err := v.checkIntervalValue()
+	if err != nil {
+		dialog.ShowError(err, v.State.Window)
+		return
+	}
+	headers, err := v.sessionHeadersValue()
 	if err != nil {
 		dialog.ShowError(err, v.State.Window)
 		return
@@ -407,14 +853,38 @@ func (v *EditMangaView) onSaveButtonClicked() {
 	}
 
 	// Update the manga entry
-	v.State.MangaData.Manga[v.editingMangaID].Title = title
-	v.State.MangaData.Manga[v.editingMangaID].Site = selectedSite
-	v.State.MangaData.Manga[v.editingMangaID].Url = url
-	v.State.MangaData.Manga[v.editingMangaID].Location = newLocation
-	v.State.MangaData.Manga[v.editingMangaID].Shortname = "" // Remove shortname
+	syncMode := v.syncModeValue()
+	imageQuality := v.imageQualityValue()
+	languages := config.NormalizeTags(commaListValue(v.LanguagesEntry.Text))
+	groups := commaListValue(v.GroupsEntry.Text)
+	completed := v.CompletedCheck.Checked
+	mirrors := v.mirrorLocationsValue()
+	cookies := strings.TrimSpace(v.CookiesEntry.Text)
+	notes := strings.TrimSpace(v.NotesEntry.Text)
+	tags := config.ParseTags(v.TagsEntry.Text)
+	v.State.MangaData.Update(v.editingMangaID, func(manga *config.Bookmarks) {
+		manga.Title = title
+		manga.Site = selectedSite
+		manga.Url = url
+		manga.Location = newLocation
+		manga.Shortname = "" // Remove shortname
+		manga.KeepLatest = keepLatest
+		manga.DelayNewChapterHours = delayHours
+		manga.CheckIntervalHours = checkHours
+		manga.SyncMode = syncMode
+		manga.ImageQuality = imageQuality
+		manga.Languages = languages
+		manga.ScanlationGroups = groups
+		manga.Completed = completed
+		manga.MirrorLocations = mirrors
+		manga.SessionCookies = cookies
+		manga.SessionHeaders = headers
+		manga.Notes = notes
+		manga.Tags = tags
+	})
 
 	// Save to disk
-	err = config.SaveBookmarks(v.State.MangaData)
+	err = v.State.MangaData.Save()
 	if err != nil {
 		dialog.ShowError(
 			fmt.Errorf("failed to save bookmarks: %v", err),
@@ -439,3 +909,151 @@ func (v *EditMangaView) onSaveButtonClicked() {
 	// Clear the form
 	v.clearForm()
 }
+
+// keepLatestValue parses the optional "Keep latest" field, empty means keep all chapters
+func (v *EditMangaView) keepLatestValue() (int, error) {
+	text := strings.TrimSpace(v.KeepLatestEntry.Text)
+	if text == "" {
+		return 0, nil
+	}
+
+	keep, err := strconv.Atoi(text)
+	if err != nil || keep < 0 {
+		return 0, fmt.Errorf("keep latest must be a whole number of chapters (0 keeps all)")
+	}
+	return keep, nil
+}
+
+// mirrorLocationsValue returns the folders listed in the "Mirror to" field, one per
+// line with blank lines dropped
+func (v *EditMangaView) mirrorLocationsValue() []string {
+	var mirrors []string
+	for _, line := range strings.Split(v.MirrorEntry.Text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			mirrors = append(mirrors, line)
+		}
+	}
+	return mirrors
+}
+
+// commaListValue splits a comma separated field into its trimmed, non-empty items
+func commaListValue(text string) []string {
+	var items []string
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// delayHoursValue parses the optional "Delay new chapters" field, empty means no delay
+func (v *EditMangaView) delayHoursValue() (int, error) {
+	text := strings.TrimSpace(v.DelayEntry.Text)
+	if text == "" {
+		return 0, nil
+	}
+
+	hours, err := strconv.Atoi(text)
+	if err != nil || hours < 0 {
+		return 0, fmt.Errorf("delay must be a whole number of hours (0 downloads new chapters straight away)")
+	}
+	return hours, nil
+}
+
+// checkIntervalValue parses the optional "Check for updates every" field, empty
+// means the series follows the auto_check_interval_hours setting
+func (v *EditMangaView) checkIntervalValue() (int, error) {
+	text := strings.TrimSpace(v.CheckIntervalEntry.Text)
+	if text == "" {
+		return 0, nil
+	}
+
+	hours, err := strconv.Atoi(text)
+	if err != nil || hours < -1 {
+		return 0, fmt.Errorf("check interval must be a whole number of hours (-1 never checks the series automatically)")
+	}
+	return hours, nil
+}
+
+// sessionHeadersValue parses the "Request headers" field, one "Name: value" per line
+// with blank lines dropped
+func (v *EditMangaView) sessionHeadersValue() (map[string]string, error) {
+	var headers map[string]string
+	for _, line := range strings.Split(v.HeadersEntry.Text, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("request headers must be one \"Name: value\" per line, got %q", line)
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// formatSessionHeaders lists headers as "Name: value" lines in name order, the
+// format sessionHeadersValue reads back
+func formatSessionHeaders(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, name+": "+headers[name])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Sync mode dropdown labels
+const (
+	syncModeAppendLabel = "Append new chapters"
+	syncModeFullLabel   = "Full resync (re-download changed chapters)"
+)
+
+// Image quality dropdown labels
+const (
+	imageQualityDefaultLabel   = "Site default"
+	imageQualityOriginalLabel  = "Original"
+	imageQualityDataSaverLabel = "Data saver (MangaDex)"
+)
+
+// imageQualityLabel returns the dropdown label for a bookmark image quality
+func imageQualityLabel(quality string) string {
+	switch quality {
+	case config.ImageQualityOriginal:
+		return imageQualityOriginalLabel
+	case config.ImageQualityDataSaver:
+		return imageQualityDataSaverLabel
+	}
+	return imageQualityDefaultLabel
+}
+
+// imageQualityValue returns the bookmark image quality for the dropdown selection,
+// the site default is stored as empty
+func (v *EditMangaView) imageQualityValue() string {
+	switch v.ImageQualitySelect.Selected {
+	case imageQualityOriginalLabel:
+		return config.ImageQualityOriginal
+	case imageQualityDataSaverLabel:
+		return config.ImageQualityDataSaver
+	}
+	return ""
+}
+
+// syncModeValue returns the bookmark sync mode for the dropdown selection, append
+// is stored as empty so existing bookmarks serialize unchanged
+func (v *EditMangaView) syncModeValue() string {
+	if v.SyncModeSelect.Selected == syncModeFullLabel {
+		return config.SyncModeFull
+	}
+	return ""
+}
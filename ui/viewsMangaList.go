@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os/exec"
 	"runtime"
-	"sort"
 	"strings"
 	"time"
 
@@ -17,8 +16,13 @@ import (
 	"fyne.io/fyne/v2/widget"
 
 	"image/color"
+
+	"kansho/config"
 )
 
+// completedBadge is appended to the titles of series marked completed
+const completedBadge = "  [Completed]"
+
 // hoverLabel is a custom label that shows a tooltip on hover
 type hoverLabel struct {
 	widget.Label
@@ -158,11 +162,15 @@ func (h *hoverLabel) hideTooltip() {
 type MangaListView struct {
 	Card fyne.CanvasObject
 
-	List         *widget.List
-	deleteButton *widget.Button
-	editButton   *widget.Button
-	dirButton    *widget.Button
-	siteButton   *widget.Button
+	List          *widget.List
+	deleteButton  *widget.Button
+	editButton    *widget.Button
+	dirButton     *widget.Button
+	siteButton    *widget.Button
+	exportButton  *widget.Button
+	archiveButton *widget.Button
+	bundleButton  *widget.Button
+	pagesButton   *widget.Button
 
 	searchEntry       *widget.Entry
 	searchButton      *widget.Button
@@ -205,6 +213,26 @@ func NewMangaListView(state *KanshoAppState) *MangaListView {
 	})
 	view.siteButton.Disable()
 
+	view.exportButton = widget.NewButton("Copy to Device", func() {
+		view.onExportButtonClicked()
+	})
+	view.exportButton.Disable()
+
+	view.archiveButton = widget.NewButton("Archive", func() {
+		view.onArchiveButtonClicked()
+	})
+	view.archiveButton.Disable()
+
+	view.bundleButton = widget.NewButton("Bundle", func() {
+		view.onBundleButtonClicked()
+	})
+	view.bundleButton.Disable()
+
+	view.pagesButton = widget.NewButton("Fix Pages", func() {
+		view.onPagesButtonClicked()
+	})
+	view.pagesButton.Disable()
+
 	view.searchEntry = widget.NewEntry()
 	view.searchEntry.SetPlaceHolder("Search manga titles...")
 	view.searchEntry.OnSubmitted = func(string) {
@@ -219,22 +247,28 @@ func NewMangaListView(state *KanshoAppState) *MangaListView {
 		view.clearSearch()
 	})
 
-	sort.Slice(view.state.MangaData.Manga, func(i, j int) bool {
-		return view.state.MangaData.Manga[i].Title < view.state.MangaData.Manga[j].Title
-	})
+	view.state.MangaData.SortByTitle()
 
 	view.List = widget.NewList(
 		func() int {
-			return len(view.state.MangaData.Manga)
+			return view.state.MangaData.Len()
 		},
 		func() fyne.CanvasObject {
 			return newHoverLabel("template", "", view.state.Window)
 		},
 		func(id widget.ListItemID, item fyne.CanvasObject) {
 			hoverLabel := item.(*hoverLabel)
-			manga := view.state.MangaData.Manga[id]
-			hoverLabel.SetText(manga.Title)
-			hoverLabel.tooltipText = fmt.Sprintf("%s", manga.Site)
+			manga, _ := view.state.MangaData.Get(int(id))
+			if manga.Completed {
+				hoverLabel.SetText(manga.Title + completedBadge)
+				hoverLabel.tooltipText = fmt.Sprintf("%s (completed, skipped by Recheck All)", manga.Site)
+			} else {
+				hoverLabel.SetText(manga.Title)
+				hoverLabel.tooltipText = fmt.Sprintf("%s", manga.Site)
+			}
+			if notes := notesSummary(manga.Notes); notes != "" {
+				hoverLabel.tooltipText += " - " + notes
+			}
 		},
 	)
 
@@ -244,6 +278,10 @@ func NewMangaListView(state *KanshoAppState) *MangaListView {
 		view.editButton.Enable()
 		view.dirButton.Enable()
 		view.siteButton.Enable()
+		view.exportButton.Enable()
+		view.archiveButton.Enable()
+		view.bundleButton.Enable()
+		view.pagesButton.Enable()
 		view.state.SelectManga(int(id))
 	}
 
@@ -268,6 +306,10 @@ func NewMangaListView(state *KanshoAppState) *MangaListView {
 					view.editButton,
 					view.dirButton,
 					view.siteButton,
+					view.exportButton,
+					view.archiveButton,
+					view.bundleButton,
+					view.pagesButton,
 				),
 			),
 		),
@@ -294,9 +336,7 @@ func (v *MangaListView) SetEditMangaView(editView *EditMangaView) {
 }
 
 func (v *MangaListView) refresh() {
-	sort.Slice(v.state.MangaData.Manga, func(i, j int) bool {
-		return v.state.MangaData.Manga[i].Title < v.state.MangaData.Manga[j].Title
-	})
+	v.state.MangaData.SortByTitle()
 
 	v.selectedIndex = -1
 	v.List.UnselectAll()
@@ -304,6 +344,10 @@ func (v *MangaListView) refresh() {
 	v.editButton.Disable()
 	v.dirButton.Disable()
 	v.siteButton.Disable()
+	v.exportButton.Disable()
+	v.archiveButton.Disable()
+	v.bundleButton.Disable()
+	v.pagesButton.Disable()
 
 	v.searchResults = []int{}
 	v.currentSearchIdx = -1
@@ -311,13 +355,20 @@ func (v *MangaListView) refresh() {
 	v.List.Refresh()
 }
 
+// selectedManga returns a copy of the manga selected in the list, false when nothing
+// is selected
+func (v *MangaListView) selectedManga() (config.Bookmarks, bool) {
+	return v.state.MangaData.Get(v.selectedIndex)
+}
+
 func (v *MangaListView) onDeleteButtonClicked() {
-	if v.selectedIndex < 0 || v.selectedIndex >= len(v.state.MangaData.Manga) {
+	manga, ok := v.selectedManga()
+	if !ok {
 		dialog.ShowInformation("Delete Manga", "Please select a manga to delete.", v.state.Window)
 		return
 	}
 
-	mangaTitle := v.state.MangaData.Manga[v.selectedIndex].Title
+	mangaTitle := manga.Title
 
 	dialog.ShowConfirm(
 		"Delete Manga",
@@ -332,7 +383,7 @@ func (v *MangaListView) onDeleteButtonClicked() {
 }
 
 func (v *MangaListView) onEditButtonClicked() {
-	if v.selectedIndex < 0 || v.selectedIndex >= len(v.state.MangaData.Manga) {
+	if _, ok := v.selectedManga(); !ok {
 		dialog.ShowInformation("Edit Manga", "Please select a manga to edit.", v.state.Window)
 		return
 	}
@@ -346,12 +397,13 @@ func (v *MangaListView) onEditButtonClicked() {
 }
 
 func (v *MangaListView) onDirButtonClicked() {
-	if v.selectedIndex < 0 || v.selectedIndex >= len(v.state.MangaData.Manga) {
+	manga, ok := v.selectedManga()
+	if !ok {
 		dialog.ShowInformation("Open Manga Directory", "Please select a manga to open its directory.", v.state.Window)
 		return
 	}
 
-	mangaLocation := v.state.MangaData.Manga[v.selectedIndex].Location
+	mangaLocation := manga.Location
 
 	var err error
 	switch runtime.GOOS {
@@ -371,6 +423,11 @@ func (v *MangaListView) onDirButtonClicked() {
 	}
 }
 
+// notesSummary flattens a series' notes onto one line for the list tooltip
+func notesSummary(notes string) string {
+	return strings.Join(strings.Fields(notes), " ")
+}
+
 func (v *MangaListView) performSearch() {
 	searchTerm := strings.TrimSpace(v.searchEntry.Text)
 	if searchTerm == "" {
@@ -382,7 +439,7 @@ func (v *MangaListView) performSearch() {
 
 	if searchTerm != v.lastSearchTerm {
 		v.searchResults = []int{}
-		for i, manga := range v.state.MangaData.Manga {
+		for i, manga := range v.state.MangaData.All() {
 			if strings.Contains(strings.ToLower(manga.Title), searchTermLower) {
 				v.searchResults = append(v.searchResults, i)
 			}
@@ -411,12 +468,13 @@ func (v *MangaListView) performSearch() {
 }
 
 func (v *MangaListView) onSiteButtonClicked() {
-	if v.selectedIndex < 0 || v.selectedIndex >= len(v.state.MangaData.Manga) {
+	manga, ok := v.selectedManga()
+	if !ok {
 		dialog.ShowInformation("Open Site", "Select a manga from the list to open the site.", v.state.Window)
 		return
 	}
 
-	mangaURL := v.state.MangaData.Manga[v.selectedIndex].Url
+	mangaURL := manga.Url
 	var err error
 	switch runtime.GOOS {
 	case "linux":
@@ -435,6 +493,46 @@ func (v *MangaListView) onSiteButtonClicked() {
 	}
 }
 
+func (v *MangaListView) onExportButtonClicked() {
+	manga, ok := v.selectedManga()
+	if !ok {
+		dialog.ShowInformation("Copy to Device", "Select a manga from the list to copy its chapters.", v.state.Window)
+		return
+	}
+
+	ShowExportSeriesDialog(manga, v.state.Window)
+}
+
+func (v *MangaListView) onArchiveButtonClicked() {
+	manga, ok := v.selectedManga()
+	if !ok {
+		dialog.ShowInformation("Archive Series", "Select a manga from the list to archive its chapters.", v.state.Window)
+		return
+	}
+
+	ShowArchiveSeriesDialog(manga, v.state.Window)
+}
+
+func (v *MangaListView) onBundleButtonClicked() {
+	manga, ok := v.selectedManga()
+	if !ok {
+		dialog.ShowInformation("Bundle Series", "Select a manga from the list to bundle its chapters.", v.state.Window)
+		return
+	}
+
+	ShowBundleSeriesDialog(manga, v.state.Window)
+}
+
+func (v *MangaListView) onPagesButtonClicked() {
+	manga, ok := v.selectedManga()
+	if !ok {
+		dialog.ShowInformation("Fix Page Order", "Select a manga from the list to check its chapters.", v.state.Window)
+		return
+	}
+
+	ShowFixPageOrderDialog(manga, v.state.Window)
+}
+
 func (v *MangaListView) clearSearch() {
 	v.searchEntry.SetText("")
 	v.searchResults = []int{}
@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SafeJoin joins name onto root and returns the resulting path, rejecting names
+// that would land outside root (eg: a title of "../../etc" or "/etc/cron.d").
+// name must be a single path component, such as a manga title or a CBZ filename.
+func SafeJoin(root, name string) (string, error) {
+	if root == "" {
+		return "", errors.New("directory is required")
+	}
+
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" || trimmed == "." || trimmed == ".." {
+		return "", fmt.Errorf("invalid name %q", name)
+	}
+	if filepath.IsAbs(trimmed) || strings.ContainsAny(trimmed, `/\`) || filepath.VolumeName(trimmed) != "" {
+		return "", fmt.Errorf("name %q must not contain path separators", name)
+	}
+
+	target := filepath.Join(root, name)
+	if err := EnsureWithin(root, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// EnsureWithin returns an error unless target resolves to root or a path below it
+func EnsureWithin(root, target string) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("invalid directory %q: %w", root, err)
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return fmt.Errorf("invalid path %q: %w", target, err)
+	}
+
+	rel, err := filepath.Rel(absRoot, absTarget)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path %q is outside of %q", target, root)
+	}
+	return nil
+}
+
+// ValidateLocation checks a manga directory entered or chosen by the user: it must be
+// absolute (or start with ~) and must not contain ".." components
+func ValidateLocation(location string) error {
+	if location == "" {
+		return nil
+	}
+	if !filepath.IsAbs(location) && !strings.HasPrefix(location, "~") {
+		return fmt.Errorf("location %q must be an absolute path", location)
+	}
+	for _, part := range strings.FieldsFunc(location, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == ".." {
+			return fmt.Errorf("location %q must not contain '..'", location)
+		}
+	}
+	return nil
+}